@@ -0,0 +1,31 @@
+// Command aggregator merges the Parquet backups of many agents into one
+// central DuckDB file for fleet queries, dashboards and the MCP server. It
+// is the same as "syschecker aggregate" and takes the same flags.
+//
+// Agents ship their data with "syschecker backup -o <dir>", each into its
+// own subdirectory of -in, e.g. by rsync or an object store sync:
+//
+//	aggregator -db fleet.db -in /srv/backups -publish /srv/fleet-ro.db -label env=prod
+//
+// or serve it with -fleet-addr for the aggregator to pull over mutual TLS
+// from -peers and the agents found by -discover tailscale or wireguard.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"syschecker/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cli.Aggregate(ctx, cli.Globals{}, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
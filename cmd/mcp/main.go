@@ -0,0 +1,27 @@
+// Command mcp runs the SysChecker MCP server over stdio. It is the same as
+// "syschecker mcp serve" and takes the same flags.
+//
+// Configuration also comes from the environment: GEMINI_API_KEY (required),
+// GEMINI_MODEL, NEO4J_URI, NEO4J_USER, NEO4J_PASSWORD, NEO4J_DATABASE,
+// NEO4J_BEARER_TOKEN, NEO4J_KERBEROS_TICKET, NEO4J_CA_CERT, DUCKDB_PATH,
+// SYSCHECKER_HOST_ROOT and SYSCHECKER_TOPOLOGY.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"syschecker/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cli.ServeMCP(ctx, cli.Globals{}, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,147 @@
+// Command soak runs collection, persistence and graph ingest for a long period while
+// recording syschecker's own RSS, goroutines and database growth, and fails if they
+// trend upward.
+//
+// Usage:
+//
+//	go run ./cmd/soak -duration 6h -interval 5s
+//
+// Graph ingest uses Neo4j when NEO4J_URI is set (NEO4J_USER, NEO4J_PASSWORD).
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"syschecker/internal/collector"
+	"syschecker/internal/database"
+	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/selfstats"
+)
+
+func main() {
+	duration := flag.Duration("duration", 2*time.Hour, "how long to run")
+	interval := flag.Duration("interval", 5*time.Second, "collection interval")
+	sampleEvery := flag.Duration("sample", time.Minute, "self-metrics sampling interval")
+	dbPath := flag.String("db", "", "DuckDB file (default: temporary file, removed afterwards)")
+	maxGrowth := flag.Float64("max-growth", 0.10, "maximum fitted RSS/goroutine growth over the run (fraction)")
+	maxAccel := flag.Float64("max-db-accel", 1.5, "maximum ratio of late to early DB growth rate")
+	flag.Parse()
+
+	if *dbPath == "" {
+		dir, err := os.MkdirTemp("", "syschecker-soak-")
+		if err != nil {
+			log.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		*dbPath = filepath.Join(dir, "soak.db")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbClient, err := relational.NewDuckDBClient(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open DuckDB: %v", err)
+	}
+	defer dbClient.Close()
+	repo := relational.NewRepo(dbClient.DB())
+	if err := repo.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	var graphClient graph.GraphClient
+	if uri := os.Getenv("NEO4J_URI"); uri != "" {
+		user := os.Getenv("NEO4J_USER")
+		if user == "" {
+			user = "neo4j"
+		}
+		c, err := graph.NewNeo4jClient(uri, user, os.Getenv("NEO4J_PASSWORD"), "", graph.Neo4jOptionsFromEnv()...)
+		if err != nil {
+			log.Fatalf("Failed to connect to Neo4j: %v", err)
+		}
+		graphClient = c
+	} else {
+		log.Printf("NEO4J_URI not set; running without graph ingest")
+	}
+
+	cfg := flagger.DefaultConfig()
+	worker, err := database.NewDataWorker(collector.NewSystemCollector(), flagger.NewFlaggerService(cfg), repo, graphClient,
+		"soak-agent", "", "",
+		database.WithInterval(*interval),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create data worker: %v", err)
+	}
+
+	sampler, err := selfstats.NewSampler(*dbPath, selfstats.WithPersistReporter(worker))
+	if err != nil {
+		log.Fatalf("Failed to create sampler: %v", err)
+	}
+
+	if err := worker.Start(ctx); err != nil {
+		log.Fatalf("Failed to start worker: %v", err)
+	}
+
+	rss := &series{name: "rss"}
+	goroutines := &series{name: "goroutines"}
+	dbSize := &series{name: "db"}
+
+	start := time.Now()
+	deadline := time.NewTimer(*duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(*sampleEvery)
+	defer ticker.Stop()
+
+	fmt.Printf("Soak test: duration=%s interval=%s db=%s\n", *duration, *interval, *dbPath)
+	fmt.Println("elapsed\trss_mb\tgoroutines\theap_mb\tdb_mb")
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Interrupted; evaluating samples collected so far")
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			s, err := sampler.Sample(ctx)
+			if err != nil {
+				log.Printf("Sample failed: %v", err)
+				continue
+			}
+			h := time.Since(start).Hours()
+			rss.add(h, float64(s.RSSBytes))
+			goroutines.add(h, float64(s.Goroutines))
+			dbSize.add(h, float64(s.DBBytes))
+			fmt.Printf("%s\t%.1f\t%d\t%.1f\t%.1f\n", time.Since(start).Truncate(time.Second),
+				float64(s.RSSBytes)/(1<<20), s.Goroutines, float64(s.HeapAlloc)/(1<<20), float64(s.DBBytes)/(1<<20))
+		}
+	}
+
+	worker.Stop()
+
+	if len(rss.vals) < 10 {
+		fmt.Printf("Only %d samples collected; need at least 10 for a verdict\n", len(rss.vals))
+		os.Exit(2)
+	}
+
+	failures := verdict(rss, goroutines, dbSize, *maxGrowth, *maxAccel)
+	if len(failures) > 0 {
+		fmt.Println("SOAK FAILED:")
+		for _, f := range failures {
+			fmt.Println("  - " + f)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("SOAK PASSED: no upward trend in RSS, goroutines, or DB growth rate")
+}
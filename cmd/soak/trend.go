@@ -0,0 +1,88 @@
+package main
+
+import "fmt"
+
+// slope returns the least-squares slope of ys against xs.
+func slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / den
+}
+
+// series is one recorded metric over elapsed hours.
+type series struct {
+	name  string
+	hours []float64
+	vals  []float64
+}
+
+func (s *series) add(h, v float64) {
+	s.hours = append(s.hours, h)
+	s.vals = append(s.vals, v)
+}
+
+// growth returns the fitted relative increase across the run, ignoring the first
+// warmup fraction of samples while caches and pools fill up.
+func (s *series) growth(warmup float64) float64 {
+	start := int(float64(len(s.vals)) * warmup)
+	xs, ys := s.hours[start:], s.vals[start:]
+	if len(xs) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, v := range ys {
+		mean += v
+	}
+	mean /= float64(len(ys))
+	if mean == 0 {
+		return 0
+	}
+	return slope(xs, ys) * (xs[len(xs)-1] - xs[0]) / mean
+}
+
+// acceleration compares the growth rate of the second half with the first half.
+// Append-only storage grows linearly; a ratio well above 1 means growth per
+// snapshot is increasing.
+func (s *series) acceleration() float64 {
+	mid := len(s.vals) / 2
+	if mid < 2 {
+		return 1
+	}
+	first := slope(s.hours[:mid], s.vals[:mid])
+	second := slope(s.hours[mid:], s.vals[mid:])
+	if first <= 0 {
+		if second > 0 {
+			return second
+		}
+		return 1
+	}
+	return second / first
+}
+
+// verdict checks recorded series against leak limits and returns failure reasons.
+func verdict(rss, goroutines, db *series, maxGrowth, maxAccel float64) []string {
+	var failures []string
+	if g := rss.growth(0.2); g > maxGrowth {
+		failures = append(failures, fmt.Sprintf("RSS trended up %.0f%% over the run", g*100))
+	}
+	if g := goroutines.growth(0.2); g > maxGrowth {
+		failures = append(failures, fmt.Sprintf("goroutines trended up %.0f%% over the run", g*100))
+	}
+	if a := db.acceleration(); a > maxAccel {
+		failures = append(failures, fmt.Sprintf("DB growth rate accelerated %.1fx", a))
+	}
+	return failures
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func build(name string, f func(h float64) float64, n int) *series {
+	s := &series{name: name}
+	for i := 0; i < n; i++ {
+		h := float64(i) / 10
+		s.add(h, f(h))
+	}
+	return s
+}
+
+func TestVerdictFlat(t *testing.T) {
+	rss := build("rss", func(h float64) float64 { return 50e6 + float64(int(h*10)%3)*1e6 }, 60)
+	gor := build("goroutines", func(float64) float64 { return 40 }, 60)
+	db := build("db", func(h float64) float64 { return 1e6 + h*5e5 }, 60)
+	if f := verdict(rss, gor, db, 0.1, 1.5); len(f) != 0 {
+		t.Errorf("expected pass, got %v", f)
+	}
+}
+
+func TestVerdictDetectsLeaks(t *testing.T) {
+	rss := build("rss", func(h float64) float64 { return 50e6 + h*20e6 }, 60)
+	gor := build("goroutines", func(h float64) float64 { return 40 + h*30 }, 60)
+	db := build("db", func(h float64) float64 { return 1e6 + h*h*5e5 }, 60)
+	if f := verdict(rss, gor, db, 0.1, 1.5); len(f) != 3 {
+		t.Errorf("expected 3 failures, got %v", f)
+	}
+}
@@ -0,0 +1,845 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"syschecker/internal/buildinfo"
+	"syschecker/internal/cli"
+	"syschecker/internal/collector"
+	"syschecker/internal/config"
+	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/doctor"
+	"syschecker/internal/flagger"
+	"syschecker/internal/i18n"
+	"syschecker/internal/output"
+	"syschecker/internal/replay"
+	"syschecker/internal/report"
+	"syschecker/internal/schema"
+	"syschecker/internal/service"
+	"syschecker/internal/slo"
+	"syschecker/internal/timefmt"
+	"syschecker/internal/update"
+)
+
+// usage lists the subcommands and the flags of the default TUI command.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, `Usage: syschecker [global flags] [command] [flags]
+
+Commands:
+  tui        collect and show the dashboard (the default)
+  mcp serve  run the MCP server over stdio
+  chat       ask questions through this executable's MCP server
+  client     interactive client for any MCP server command
+  check      call the MCP server's main tools once
+  export     write stored snapshots to a CSV, JSON or Parquet file
+  backup     write the whole database to a directory of Parquet files
+  aggregate  merge many agents' backups into one fleet database
+  service    install, uninstall or show the headless agent system service
+  update     replace this binary with the latest verified release
+  version    print the build's version, commit and date
+  doctor     check the environment and print fixes
+  report     collect once and print the dashboard
+  watch      re-render the console report every interval
+  replay     re-flag stored snapshots at speed to see how an incident unfolded
+  heatmap    print or export the severity heatmap
+  bookmark   bookmark the latest snapshot, or list bookmarks
+  schema     print a published JSON Schema
+
+Global flags (-config, -db, -log-level) go before the command; "syschecker
+<command> -h" lists a command's own flags. The flags of tui:`)
+	flag.PrintDefaults()
+}
+
+// runCommand dispatches a CLI subcommand. It returns false if name is not a known command.
+func runCommand(g cli.Globals, name string, args []string) (bool, error) {
+	switch name {
+	case "heatmap":
+		return true, runHeatmap(g, args)
+	case "schema":
+		return true, runSchema(args)
+	case "watch":
+		return true, runWatch(args)
+	case "replay":
+		return true, runReplay(g, args)
+	case "report":
+		return true, runReport(g, args)
+	case "doctor":
+		return true, runDoctor(g, args)
+	case "bookmark":
+		return true, runBookmark(g, args)
+	case "export":
+		return true, runExport(g, args)
+	case "backup":
+		return true, runBackup(g, args)
+	case "aggregate":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return true, cli.Aggregate(ctx, g, args)
+	case "service":
+		return true, runService(g, args)
+	case "update":
+		return true, runUpdate(args)
+	case "version":
+		return true, runVersion(args)
+	case "mcp":
+		if len(args) == 0 || args[0] != "serve" {
+			return true, errors.New("usage: syschecker mcp serve [flags]")
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return true, cli.ServeMCP(ctx, g, args[1:])
+	case "chat":
+		return true, cli.Chat(context.Background(), g, args)
+	case "client":
+		return true, cli.Client(context.Background(), args)
+	case "check":
+		return true, cli.Check(context.Background(), g, args)
+	default:
+		return false, nil
+	}
+}
+
+// openRepo opens the DuckDB store at dbPath, migrating it if needed.
+func openRepo(ctx context.Context, dbPath string) (*relational.Repo, func(), error) {
+	dbClient, err := relational.NewDuckDBClient(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	repo := relational.NewRepo(dbClient.DB())
+	if err := repo.Migrate(ctx); err != nil {
+		dbClient.Close()
+		return nil, nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return repo, func() { dbClient.Close() }, nil
+}
+
+// openRepoReadOnly opens the DuckDB store at dbPath without writing to it.
+// It fails while a running agent holds the database lock.
+func openRepoReadOnly(dbPath string) (*relational.Repo, func(), error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil, err
+	}
+	dbClient, err := relational.NewDuckDBClient(dbPath + "?access_mode=READ_ONLY")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s read-only (stop the agent using it first): %w", dbPath, err)
+	}
+	return relational.NewRepo(dbClient.DB()), func() { dbClient.Close() }, nil
+}
+
+// runHeatmap prints or exports the severity heatmap.
+func runHeatmap(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	since := fs.Duration("since", 28*24*time.Hour, "how far back to aggregate")
+	host := fs.String("host", "", "hostname to filter by")
+	out := fs.String("o", "", "write to file (.svg or .html); prints a text grid when empty")
+	save := fs.Bool("save", false, "also store the written file as a report artifact")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo, closeRepo, err := openRepo(ctx, g.DB)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	hm, err := repo.SeverityHeatmap(ctx, *host, time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Print(report.RenderHeatmapText(hm))
+		return nil
+	}
+
+	var write func(io.Writer, *relational.SeverityHeatmap) error
+	var mime string
+	switch {
+	case strings.HasSuffix(*out, ".svg"):
+		write, mime = report.WriteHeatmapSVG, "image/svg+xml"
+	case strings.HasSuffix(*out, ".html"), strings.HasSuffix(*out, ".htm"):
+		write, mime = report.WriteHeatmapHTML, "text/html"
+	default:
+		return fmt.Errorf("unsupported output format %q (want .svg or .html)", *out)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, hm); err != nil {
+		return fmt.Errorf("failed to write heatmap: %w", err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Heatmap written to %s\n", *out)
+
+	if *save {
+		id, err := repo.InsertArtifact(ctx, relational.Artifact{
+			Kind: relational.ArtifactReport,
+			MIME: mime,
+			Name: filepath.Base(*out),
+			Data: buf.Bytes(),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Stored as artifact %d\n", id)
+	}
+	return nil
+}
+
+// runSchema prints a published JSON Schema, or lists the available names.
+func runSchema(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: syschecker schema <name>")
+		fmt.Println("Available schemas:")
+		for _, name := range schema.Names() {
+			fmt.Println("  " + name)
+		}
+		return nil
+	}
+
+	b, err := schema.JSON(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// runDoctor checks the environment and prints fixes for what is missing.
+// It fails when any check does, so it can gate deployment scripts.
+func runDoctor(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	dbPath := fs.String("db", g.DB, "DuckDB file the agent writes")
+	timeout := fs.Duration("timeout", 10*time.Second, "time limit per check")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	reports := doctor.Run(context.Background(), *timeout,
+		doctor.DBWritable(*dbPath),
+		doctor.Clock(nil, func(ctx context.Context) (time.Time, error) { return newestSnapshot(ctx, *dbPath) }),
+		doctor.Smartctl(),
+		doctor.DockerSocket(),
+		doctor.Neo4j(os.Getenv("NEO4J_URI"), os.Getenv("NEO4J_USER"), os.Getenv("NEO4J_PASSWORD"), graph.Neo4jOptionsFromEnv()...),
+		doctor.GeminiKey(os.Getenv("GEMINI_API_KEY")),
+	)
+	failed, err := doctor.Write(os.Stdout, reports)
+	if err != nil {
+		return err
+	}
+	if failed {
+		return errors.New("doctor: some checks failed")
+	}
+	return nil
+}
+
+// newestSnapshot returns when the newest snapshot in dbPath was collected.
+// It fails while a running agent holds the database lock.
+func newestSnapshot(ctx context.Context, dbPath string) (time.Time, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return time.Time{}, err
+	}
+	dbClient, err := relational.NewDuckDBClient(dbPath + "?access_mode=READ_ONLY")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer dbClient.Close()
+	var at sql.NullTime
+	err = dbClient.DB().QueryRowContext(ctx, `SELECT max(collected_at) FROM snapshots`).Scan(&at)
+	return at.Time, err
+}
+
+// runBookmark bookmarks the latest stored snapshot, or lists bookmarks.
+// While an agent holds the database, POST /api/v1/bookmarks on its debug
+// address instead.
+func runBookmark(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("bookmark", flag.ExitOnError)
+	note := fs.String("note", "", "why the snapshot is worth keeping")
+	host := fs.String("host", "", "hostname whose latest snapshot to bookmark (default: the most recent host)")
+	list := fs.Bool("list", false, "list bookmarks instead of adding one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo, closeRepo, err := openRepo(ctx, g.DB)
+	if err != nil {
+		return fmt.Errorf("%w (while the agent runs, POST /api/v1/bookmarks on its -debug-addr)", err)
+	}
+	defer closeRepo()
+
+	if *list {
+		marks, err := repo.ListAnnotations(ctx, *host, relational.AnnotationBookmark, time.Time{}, 0)
+		if err != nil {
+			return err
+		}
+		for _, m := range marks {
+			fmt.Printf("%d\t%s\t%s\t%s\n", m.SnapshotID, m.Hostname, m.CollectedAt.UTC().Format(time.RFC3339), m.Note)
+		}
+		return nil
+	}
+	mark, err := repo.BookmarkLatest(ctx, *host, *note)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Bookmarked snapshot %d of %s taken %s\n", mark.SnapshotID, mark.Hostname, mark.CollectedAt.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// runExport writes stored snapshots to a file for spreadsheets and other
+// tools. It reads the database without writing, so stop the agent first.
+func runExport(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("o", "", "file to write (required)")
+	format := fs.String("format", "", strings.Join(relational.ExportFormats, ", ")+" (default: from the -o extension)")
+	host := fs.String("host", "", "hostname to filter by")
+	since := fs.Duration("since", 0, "only snapshots this recent (0 exports all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("export needs -o")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*out), ".")
+	}
+
+	repo, closeRepo, err := openRepoReadOnly(g.DB)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	f := relational.ExportFilter{Hostname: *host}
+	if *since > 0 {
+		f.Since = time.Now().Add(-*since)
+	}
+	n, err := repo.ExportSnapshots(context.Background(), *out, *format, f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d snapshots to %s\n", n, *out)
+	return nil
+}
+
+// runBackup exports the whole database as Parquet files into a new
+// directory. It reads the database without writing, so stop the agent
+// first.
+func runBackup(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := fs.String("o", "", "directory to create (default: syschecker-backup-<UTC time>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = "syschecker-backup-" + time.Now().UTC().Format("20060102T150405Z")
+	}
+	if _, err := os.Stat(*out); err == nil {
+		return fmt.Errorf("%s already exists", *out)
+	}
+
+	repo, closeRepo, err := openRepoReadOnly(g.DB)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	if err := repo.Backup(context.Background(), *out); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %s to %s\n", g.DB, *out)
+	fmt.Printf("Restore into a new database with: duckdb restored.db \"IMPORT DATABASE '%s'\"\n", *out)
+	return nil
+}
+
+// runService installs the headless agent as a system service running with
+// the global flags given here, or removes it or shows its state.
+func runService(g cli.Globals, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: syschecker [global flags] service install|status|uninstall [flags] [-- agent flags]")
+	}
+	fs := flag.NewFlagSet("service "+args[0], flag.ExitOnError)
+	name := fs.String("name", service.DefaultName, "service name")
+	var env []string
+	if args[0] == "install" {
+		fs.Func("env", "copy this environment variable into the service, e.g. NEO4J_PASSWORD (repeatable)", func(s string) error {
+			env = append(env, s)
+			return nil
+		})
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "install":
+		c, err := serviceConfig(g, *name, env, fs.Args())
+		if err != nil {
+			return err
+		}
+		if err := service.Install(c); err != nil {
+			return err
+		}
+		fmt.Printf("Installed and started %s: %s %s\n", *name, c.Executable, strings.Join(c.Args, " "))
+		return nil
+	case "uninstall":
+		if err := service.Uninstall(*name); err != nil {
+			return err
+		}
+		fmt.Printf("Uninstalled %s\n", *name)
+		return nil
+	case "status":
+		st, err := service.QueryStatus(*name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", *name, st)
+		return nil
+	default:
+		return fmt.Errorf("unknown service command %q (want install, status or uninstall)", args[0])
+	}
+}
+
+// serviceConfig runs this executable headless with g, made absolute so the
+// service does not depend on the directory it was installed from, and the
+// extra agent flags.
+func serviceConfig(g cli.Globals, name string, env, extra []string) (service.Config, error) {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return service.Config{}, fmt.Errorf("locate syschecker executable: %w", err)
+	}
+	db, err := filepath.Abs(g.DB)
+	if err != nil {
+		return service.Config{}, err
+	}
+	c := service.Config{Name: name, Executable: exe, WorkDir: filepath.Dir(db), Env: map[string]string{}}
+	c.Args = append(c.Args, "-db", db)
+	if g.Config != "" {
+		config, err := filepath.Abs(g.Config)
+		if err != nil {
+			return service.Config{}, err
+		}
+		c.Args = append(c.Args, "-config", config)
+	}
+	c.Args = append(c.Args, "-log-level", g.LogLevel, "-headless")
+	c.Args = append(c.Args, extra...)
+	for _, k := range env {
+		v, ok := os.LookupEnv(k)
+		if !ok {
+			return service.Config{}, fmt.Errorf("-env %s: not set in this shell", k)
+		}
+		c.Env[k] = v
+	}
+	return c, nil
+}
+
+// runUpdate replaces this executable with the latest GitHub release once
+// its checksum, and signature when a key is built in, verify.
+func runUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	check := fs.Bool("check", false, "only report whether an update is available")
+	force := fs.Bool("force", false, "install the latest release even if it is not newer, e.g. over a development build")
+	repo := fs.String("repo", update.DefaultRepo, "GitHub owner/name to fetch releases from")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := update.DefaultConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Repo = *repo
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	rel, err := update.Latest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	version := buildinfo.Get().Version
+	newer := update.Newer(version, rel.Tag)
+	if *check || (!newer && !*force) {
+		if newer {
+			fmt.Printf("Update available: %s -> %s\n", version, rel.Tag)
+		} else {
+			fmt.Printf("syschecker %s is up to date (latest release %s)\n", version, rel.Tag)
+		}
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return fmt.Errorf("locate syschecker executable: %w", err)
+	}
+	if cfg.PublicKey == nil {
+		fmt.Fprintln(os.Stderr, "Warning: this build has no release signing key; verifying the checksum only")
+	}
+	if err := update.Apply(ctx, cfg, rel, exe); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s from %s to %s; restart running agents to use it\n", exe, version, rel.Tag)
+	return nil
+}
+
+// runVersion prints the build information, as JSON with -json.
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print JSON, as served at /version on -debug-addr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	info := buildinfo.Get()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	fmt.Printf("syschecker %s %s %s\n", info, info.GoVersion, info.Platform)
+	return nil
+}
+
+// runWatch re-renders the console report in place every interval until
+// interrupted, highlighting values that changed since the previous render.
+// It collects directly and stores nothing, so it is light enough for SSH.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("n", 2*time.Second, "refresh interval")
+	slowEvery := fs.Duration("slow", 30*time.Second, "how often to refresh slow metrics (latency, TCP, host info)")
+	count := fs.Int("count", 0, "stop after this many renders (0 runs until interrupted)")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "mark changes with * and ! instead of color")
+	tz := fs.String("tz", "", "time zone to display times in: UTC (default), Local or an IANA name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("refresh interval must be positive")
+	}
+	loc, err := timefmt.ParseZone(*tz)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	provider := collector.NewSystemCollectorWithConfig(collector.DefaultCollectorConfig())
+	cfg := flagger.DefaultConfig().WithLocation(loc)
+	tty := isTerminal(os.Stdout)
+	color := tty && !*noColor
+
+	var (
+		slow     *collector.RawStats
+		slowAt   time.Time
+		prev     *relational.RawStatsFixed
+		rendered int
+	)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if slow == nil || time.Since(slowAt) >= *slowEvery {
+			s, err := provider.GetSlowMetrics(ctx)
+			if err != nil && ctx.Err() == nil {
+				return fmt.Errorf("failed to collect slow metrics: %w", err)
+			}
+			slow, slowAt = s, time.Now()
+		}
+		fast, err := provider.GetFastMetrics(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to collect metrics: %w", err)
+		}
+
+		cur := relational.MergeStats(fast, slow, "", "", "")
+		var buf bytes.Buffer
+		if tty {
+			buf.WriteString("\x1b[H\x1b[2J") // redraw in place
+		} else if prev != nil {
+			buf.WriteString("\n")
+		}
+		if err := report.RenderConsole(&buf, &cur, prev, cfg, color); err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		prev = &cur
+
+		if rendered++; *count > 0 && rendered >= *count {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runReplay feeds stored snapshots of one host back through the flagger,
+// redrawing the console report per snapshot like watch, or printing a
+// timeline of flag changes.
+func runReplay(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	host := fs.String("host", "", "hostname to replay (default: the most recently seen host)")
+	from := fs.String("from", "1h", "start of the replay: an RFC 3339 time or a duration ago such as 2h")
+	to := fs.String("to", "", "end of the replay, like -from (default: the latest snapshot)")
+	speed := fs.Float64("speed", 60, "replay this many times faster than the snapshots were collected; 0 does not wait")
+	timeline := fs.Bool("timeline", false, "print one line per flag change instead of redrawing the report")
+	toGraph := fs.Bool("graph", false, "also ingest the replayed snapshots into Neo4j ($NEO4J_URI, $NEO4J_USER, $NEO4J_PASSWORD)")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "mark changes with * and ! instead of color")
+	tz := fs.String("tz", "", "time zone to display times in: UTC (default), Local or an IANA name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	loc, err := timefmt.ParseZone(*tz)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	start, err := parseReplayTime(*from, now)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	var end time.Time
+	if *to != "" {
+		if end, err = parseReplayTime(*to, now); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+	if *speed < 0 {
+		return errors.New("-speed must not be negative")
+	}
+
+	// Re-flag with the current thresholds, so a replay also shows how a
+	// tuned config would have judged the incident.
+	cfg := flagger.DefaultConfig().WithLocale(i18n.FromEnv()).WithLocation(loc)
+	if g.Config != "" {
+		file, err := config.Load(g.Config)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg = file.FlaggerConfig(cfg)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	repo, closeRepo, err := openRepoReadOnly(g.DB)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	opts := []replay.Option{replay.WithSpeed(*speed)}
+	if *toGraph {
+		uri := os.Getenv("NEO4J_URI")
+		if uri == "" {
+			return errors.New("-graph needs NEO4J_URI")
+		}
+		user := os.Getenv("NEO4J_USER")
+		if user == "" {
+			user = "neo4j"
+		}
+		c, err := graph.NewNeo4jClient(uri, user, os.Getenv("NEO4J_PASSWORD"), "", graph.Neo4jOptionsFromEnv()...)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Neo4j: %w", err)
+		}
+		defer c.Close(context.Background())
+		opts = append(opts, replay.WithGraph(c))
+	}
+
+	tty := isTerminal(os.Stdout)
+	color := tty && !*noColor && !*timeline
+	sum, err := replay.New(repo, flagger.NewFlaggerService(cfg), opts...).Run(ctx, *host, start, end, func(f replay.Frame) error {
+		cur := &f.Payload.Raw
+		if *timeline {
+			if len(f.Raised) == 0 && len(f.Cleared) == 0 {
+				return nil
+			}
+			var changes []string
+			for _, name := range f.Raised {
+				changes = append(changes, "+"+name)
+			}
+			for _, name := range f.Cleared {
+				changes = append(changes, "-"+name)
+			}
+			_, err := fmt.Printf("%s  %-8s %s\n", timefmt.Format(cur.CollectedAt, loc),
+				fmt.Sprintf("sev %d", f.Payload.Flags.SeverityLevel), strings.Join(changes, " "))
+			return err
+		}
+		var buf bytes.Buffer
+		if tty {
+			buf.WriteString("\x1b[H\x1b[2J") // redraw in place
+		} else if f.Prev != nil {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "replay %d/%d at %gx\n", f.Index+1, f.Total, *speed)
+		var prev *relational.RawStatsFixed
+		if f.Prev != nil {
+			prev = &f.Prev.Raw
+		}
+		if err := report.RenderConsole(&buf, cur, prev, cfg, color); err != nil {
+			return err
+		}
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Replayed %d snapshots from %s to %s\n", sum.Frames,
+		timefmt.Format(sum.From, loc), timefmt.Format(sum.To, loc))
+	if sum.GraphErrors > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Neo4j rejected %d of them\n", sum.GraphErrors)
+	}
+	return nil
+}
+
+// parseReplayTime parses an RFC 3339 time, or a duration before now.
+func parseReplayTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// runReport collects once and prints the dashboard as text, Markdown or HTML.
+func runReport(g cli.Globals, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text, markdown or html")
+	history := fs.Int("history", 0, "draw sparklines and percentiles from this many stored snapshots of this host (needs the local database)")
+	withSLOs := fs.Bool("slo", false, "add the compliance of this host with the SLOs of -config (needs the local database)")
+	out := fs.String("o", "", "write to this file instead of stdout")
+	tz := fs.String("tz", "", "time zone to display times in: UTC (default), Local or an IANA name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	loc, err := timefmt.ParseZone(*tz)
+	if err != nil {
+		return err
+	}
+
+	var write func(io.Writer, *output.DashboardView) error
+	switch *format {
+	case "text":
+	case "markdown", "md":
+		write = output.WriteMarkdown
+	case "html":
+		write = output.WriteHTML
+	default:
+		return fmt.Errorf("unsupported format %q (want text, markdown or html)", *format)
+	}
+
+	cfg := flagger.DefaultConfig().WithLocale(i18n.FromEnv()).WithLocation(loc)
+	var objectives []slo.Objective
+	if g.Config != "" {
+		file, err := config.Load(g.Config)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg = file.FlaggerConfig(cfg)
+		objectives = file.SLOs
+	}
+	if *withSLOs && len(objectives) == 0 {
+		return errors.New(`-slo needs a -config file with an "slos" section`)
+	}
+
+	ctx := context.Background()
+	provider := collector.NewSystemCollectorWithConfig(collector.DefaultCollectorConfig())
+	p, err := output.RunPipeline(ctx, provider, flagger.NewFlaggerService(cfg), noRates{}, "", "", "")
+	if err != nil {
+		return err
+	}
+
+	var past []relational.SnapshotSummary
+	var percentiles []relational.MetricPercentile
+	var slos []slo.Status
+	if (write != nil && *history > 0) || *withSLOs {
+		repo, closeRepo, err := openRepo(ctx, g.DB)
+		if err != nil {
+			return err
+		}
+		if write != nil && *history > 0 {
+			past, err = repo.QuerySnapshots(ctx, p.Raw.Hostname, *history)
+			if err == nil && len(past) > 0 {
+				// Summarise the same window the sparklines cover.
+				percentiles, err = repo.MetricPercentiles(ctx, p.Raw.Hostname, nil, past[len(past)-1].CollectedAt)
+			}
+		}
+		if err == nil && *withSLOs {
+			slos, err = slo.Evaluate(ctx, repo, objectives, p.Raw.Hostname, time.Now())
+		}
+		closeRepo()
+		if err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if write == nil {
+		err = report.RenderConsole(&buf, &p.Raw, nil, cfg, false)
+		if err == nil {
+			err = report.RenderSLOs(&buf, slos, false)
+		}
+	} else {
+		view := output.NewDashboardView(p, past)
+		view.AddSLOs(slos)
+		view.AddPercentiles(percentiles)
+		view.Location = loc
+		err = write(&buf, view)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	if *out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Report written to %s\n", *out)
+	return nil
+}
+
+// noRates leaves derived rates empty; one-off reports have no previous snapshot.
+type noRates struct{}
+
+func (noRates) GetDerivedRates(context.Context, relational.RawStatsFixed) (*relational.DerivedRates, error) {
+	return &relational.DerivedRates{}, nil
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
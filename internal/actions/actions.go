@@ -0,0 +1,253 @@
+// Package actions implements state-changing remediation steps: typed
+// actions with preconditions, confirmation requirements and rollback
+// notes, run by an Executor that enforces an allow-list and writes an
+// audit log. The MCP tools and interactive front ends share it.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Kinds of action.
+const (
+	KindRestartContainer = "restart_container"
+	KindTruncateLog      = "truncate_log"
+	KindKillProcess      = "kill_process"
+	KindVacuumJournal    = "vacuum_journal"
+	KindClearTemp        = "clear_temp"
+	KindRemoveCoreDumps  = "remove_core_dumps"
+	KindStartService     = "start_service"
+)
+
+// Kinds lists every kind of action.
+var Kinds = []string{
+	KindRestartContainer, KindTruncateLog, KindKillProcess, KindVacuumJournal,
+	KindClearTemp, KindRemoveCoreDumps, KindStartService,
+}
+
+// Spec describes an action for review before it runs.
+type Spec struct {
+	ID          string   `json:"id" jsonschema:"kind:target, stable for the same action"`
+	Kind        string   `json:"kind"`
+	Target      string   `json:"target,omitempty" jsonschema:"container, file, process, directory or service acted on"`
+	Description string   `json:"description"`
+	Command     []string `json:"command,omitempty" jsonschema:"the equivalent shell command"`
+	Confirm     bool     `json:"requires_confirmation,omitempty" jsonschema:"the action only runs when explicitly confirmed"`
+	Rollback    string   `json:"rollback" jsonschema:"how to undo the action, or why it cannot be undone"`
+}
+
+// Action is one remediation step.
+type Action interface {
+	Spec() Spec
+	// Check returns why the action cannot run now, or nil.
+	Check(ctx context.Context) error
+	// Run performs the action and returns its output.
+	Run(ctx context.Context) (string, error)
+}
+
+func spec(kind, target, description, rollback string, confirm bool, command ...string) Spec {
+	id := kind
+	if target != "" {
+		id += ":" + target
+	}
+	return Spec{ID: id, Kind: kind, Target: target, Description: description, Command: command, Confirm: confirm, Rollback: rollback}
+}
+
+// RestartContainer restarts a Docker container by name or ID.
+type RestartContainer struct{ Name string }
+
+func (a RestartContainer) Spec() Spec {
+	return spec(KindRestartContainer, a.Name, "Restart container "+a.Name,
+		"None needed; the container keeps its configuration and volumes, but in-memory state is lost.", true,
+		"docker", "restart", a.Name)
+}
+
+func (a RestartContainer) Check(ctx context.Context) error {
+	if _, err := run(ctx, "docker", "inspect", "--format", "{{.State.Status}}", a.Name); err != nil {
+		return fmt.Errorf("container %s not found: %w", a.Name, err)
+	}
+	return nil
+}
+
+func (a RestartContainer) Run(ctx context.Context) (string, error) {
+	return runSpec(ctx, a.Spec())
+}
+
+// TruncateLog empties a log file in place, so writers holding it open keep
+// working.
+type TruncateLog struct{ Path string }
+
+func (a TruncateLog) Spec() Spec {
+	return spec(KindTruncateLog, a.Path, "Truncate "+a.Path+" to zero bytes",
+		"Not reversible: the truncated lines are gone. Copy the file first if they are needed.", true,
+		"truncate", "-s", "0", a.Path)
+}
+
+func (a TruncateLog) Check(context.Context) error {
+	info, err := os.Lstat(a.Path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", a.Path)
+	}
+	return nil
+}
+
+func (a TruncateLog) Run(context.Context) (string, error) {
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Truncate(a.Path, 0); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("freed %d bytes", info.Size()), nil
+}
+
+// KillProcess terminates a process with SIGTERM. Name guards against the
+// PID having been reused by another program since it was observed.
+type KillProcess struct {
+	PID  int32
+	Name string
+}
+
+func (a KillProcess) Spec() Spec {
+	pid := strconv.Itoa(int(a.PID))
+	return spec(KindKillProcess, pid, fmt.Sprintf("Terminate %s (pid %s)", a.Name, pid),
+		"Restart the program or its service; its unsaved state is lost.", true,
+		"kill", "-TERM", pid)
+}
+
+func (a KillProcess) Check(ctx context.Context) error {
+	if a.PID <= 1 || a.PID == int32(os.Getpid()) {
+		return fmt.Errorf("refusing to terminate pid %d", a.PID)
+	}
+	p, err := process.NewProcessWithContext(ctx, a.PID)
+	if err != nil {
+		return fmt.Errorf("pid %d: %w", a.PID, err)
+	}
+	name, err := p.NameWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("pid %d: %w", a.PID, err)
+	}
+	if a.Name != "" && name != a.Name {
+		return fmt.Errorf("pid %d is now %s, not %s", a.PID, name, a.Name)
+	}
+	return nil
+}
+
+func (a KillProcess) Run(ctx context.Context) (string, error) {
+	p, err := process.NewProcessWithContext(ctx, a.PID)
+	if err != nil {
+		return "", err
+	}
+	return "", p.TerminateWithContext(ctx)
+}
+
+// VacuumJournal shrinks the systemd journal to MaxSize, e.g. "500M".
+type VacuumJournal struct{ MaxSize string }
+
+func (a VacuumJournal) Spec() Spec {
+	return spec(KindVacuumJournal, "", "Shrink the systemd journal to "+a.MaxSize,
+		"Not reversible: the oldest journal entries are deleted.", false,
+		"journalctl", "--vacuum-size="+a.MaxSize)
+}
+
+func (a VacuumJournal) Check(context.Context) error {
+	_, err := exec.LookPath("journalctl")
+	return err
+}
+
+func (a VacuumJournal) Run(ctx context.Context) (string, error) {
+	return runSpec(ctx, a.Spec())
+}
+
+// ClearTemp deletes files in Dir not modified for MaxAgeDays days.
+type ClearTemp struct {
+	Dir        string
+	MaxAgeDays int
+}
+
+func (a ClearTemp) Spec() Spec {
+	return spec(KindClearTemp, a.Dir, fmt.Sprintf("Delete files in %s untouched for %d days", a.Dir, a.MaxAgeDays),
+		"Not reversible: the deleted files are gone.", false,
+		"find", a.Dir, "-xdev", "-type", "f", "-mtime", fmt.Sprintf("+%d", a.MaxAgeDays), "-delete")
+}
+
+func (a ClearTemp) Check(context.Context) error { return checkDir(a.Dir) }
+
+func (a ClearTemp) Run(ctx context.Context) (string, error) {
+	return runSpec(ctx, a.Spec())
+}
+
+// RemoveCoreDumps deletes every file in a core dump directory.
+type RemoveCoreDumps struct{ Dir string }
+
+func (a RemoveCoreDumps) Spec() Spec {
+	return spec(KindRemoveCoreDumps, a.Dir, "Delete the core dumps in "+a.Dir,
+		"Not reversible: the dumps can no longer be debugged.", true,
+		"find", a.Dir, "-xdev", "-type", "f", "-delete")
+}
+
+func (a RemoveCoreDumps) Check(context.Context) error { return checkDir(a.Dir) }
+
+func (a RemoveCoreDumps) Run(ctx context.Context) (string, error) {
+	return runSpec(ctx, a.Spec())
+}
+
+// StartService starts a systemd service.
+type StartService struct{ Name string }
+
+func (a StartService) Spec() Spec {
+	return spec(KindStartService, a.Name, "Start the "+a.Name+" service",
+		"Stop it again with systemctl stop "+a.Name+".", false,
+		"systemctl", "start", a.Name)
+}
+
+func (a StartService) Check(context.Context) error {
+	_, err := exec.LookPath("systemctl")
+	return err
+}
+
+func (a StartService) Run(ctx context.Context) (string, error) {
+	return runSpec(ctx, a.Spec())
+}
+
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+// run executes a command and returns its combined output; tests replace it.
+var run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// runSpec runs the spec's command, including its output in the error.
+func runSpec(ctx context.Context, s Spec) (string, error) {
+	out, err := run(ctx, s.Command[0], s.Command[1:]...)
+	text := string(bytes.TrimSpace(out))
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && text != "" {
+		return text, fmt.Errorf("%s: %w: %s", s.Command[0], err, text)
+	}
+	if err != nil {
+		return text, fmt.Errorf("%s: %w", s.Command[0], err)
+	}
+	return text, nil
+}
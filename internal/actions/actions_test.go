@@ -0,0 +1,127 @@
+package actions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeRun records commands instead of running them.
+func fakeRun(t *testing.T) *[][]string {
+	t.Helper()
+	var ran [][]string
+	orig := run
+	run = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		ran = append(ran, append([]string{name}, args...))
+		return []byte("ok\n"), nil
+	}
+	t.Cleanup(func() { run = orig })
+	return &ran
+}
+
+func readAudit(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestExecutorGuardsAndAudits(t *testing.T) {
+	ran := fakeRun(t)
+	auditPath := filepath.Join(t.TempDir(), "actions.jsonl")
+	audit, err := OpenFileAuditor(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	if _, err := NewExecutor([]string{"reboot"}, audit); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	e, err := NewExecutor([]string{KindRestartContainer}, audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	restart := RestartContainer{Name: "api"}
+
+	if _, err := e.Execute(ctx, TruncateLog{Path: "/var/log/syslog"}, Options{Confirmed: true}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("got %v, want ErrNotAllowed", err)
+	}
+	if res, err := e.Execute(ctx, restart, Options{DryRun: true}); err != nil || !res.DryRun {
+		t.Errorf("dry run: %+v, %v", res, err)
+	}
+	if _, err := e.Execute(ctx, restart, Options{}); !errors.Is(err, ErrConfirmationRequired) {
+		t.Errorf("got %v, want ErrConfirmationRequired", err)
+	}
+	res, err := e.Execute(ctx, restart, Options{Confirmed: true, Actor: "test"})
+	if err != nil || res.Output != "ok" {
+		t.Errorf("run: %+v, %v", res, err)
+	}
+
+	// Every Execute checks the container; only the confirmed one restarts it.
+	if restarts := slices.IndexFunc(*ran, func(c []string) bool { return slices.Equal(c, restart.Spec().Command) }); restarts != len(*ran)-1 {
+		t.Errorf("commands run: %v", *ran)
+	}
+
+	var outcomes []string
+	for _, entry := range readAudit(t, auditPath) {
+		outcomes = append(outcomes, entry.Outcome)
+	}
+	want := []string{OutcomeRefused, OutcomeDryRun, OutcomeRefused, OutcomeDone}
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("audited %v, want %v", outcomes, want)
+	}
+}
+
+func TestTruncateLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewExecutor([]string{KindTruncateLog}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := e.Execute(context.Background(), TruncateLog{Path: path}, Options{Confirmed: true})
+	if err != nil || res.Output != "freed 10 bytes" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("size %d after truncation", info.Size())
+	}
+
+	if err := (TruncateLog{Path: filepath.Dir(path)}).Check(context.Background()); err == nil {
+		t.Error("directory accepted as a log file")
+	}
+}
+
+func TestKillProcessChecks(t *testing.T) {
+	ctx := context.Background()
+	if err := (KillProcess{PID: int32(os.Getpid())}).Check(ctx); err == nil {
+		t.Error("allowed to terminate itself")
+	}
+	if err := (KillProcess{PID: 1}).Check(ctx); err == nil {
+		t.Error("allowed to terminate pid 1")
+	}
+	if err := (KillProcess{PID: int32(os.Getppid()), Name: "surely-not-this-name"}).Check(ctx); err == nil {
+		t.Error("accepted a pid whose name changed")
+	}
+}
@@ -0,0 +1,77 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit outcomes.
+const (
+	OutcomeDone    = "done"
+	OutcomeDryRun  = "dry-run"
+	OutcomeFailed  = "failed"
+	OutcomeRefused = "refused" // not allowed or not confirmed
+)
+
+// maxAuditOutput bounds the command output kept per entry.
+const maxAuditOutput = 4 << 10
+
+// Entry is one audited Execute call.
+type Entry struct {
+	At      time.Time `json:"at"`
+	Actor   string    `json:"actor,omitempty"`
+	ID      string    `json:"id"`
+	Kind    string    `json:"kind"`
+	Target  string    `json:"target,omitempty"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	Output  string    `json:"output,omitempty"`
+}
+
+// Auditor records Execute calls.
+type Auditor interface {
+	Record(Entry) error
+}
+
+// FileAuditor appends entries to a file as JSON lines.
+type FileAuditor struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFileAuditor opens path for appending, creating it if needed.
+func OpenFileAuditor(path string) (*FileAuditor, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open action audit log: %w", err)
+	}
+	return &FileAuditor{file: f}, nil
+}
+
+// Record appends e and syncs it to disk.
+func (a *FileAuditor) Record(e Entry) error {
+	if len(e.Output) > maxAuditOutput {
+		e.Output = e.Output[:maxAuditOutput] + "..."
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// Close closes the file.
+func (a *FileAuditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
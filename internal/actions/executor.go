@@ -0,0 +1,116 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotAllowed is returned for kinds outside the executor's allow-list.
+	ErrNotAllowed = errors.New("action kind is not allowed")
+	// ErrConfirmationRequired is returned for actions whose Spec asks for
+	// confirmation when Options.Confirmed is not set.
+	ErrConfirmationRequired = errors.New("action requires confirmation")
+)
+
+// Options qualify one Execute call.
+type Options struct {
+	DryRun    bool   // check preconditions only
+	Confirmed bool   // the user confirmed an action that requires it
+	Actor     string // who asked, for the audit log, e.g. "mcp" or "tui"
+}
+
+// Result is the outcome of Execute.
+type Result struct {
+	Spec   Spec   `json:"action"`
+	DryRun bool   `json:"dry_run"`
+	Output string `json:"output,omitempty"`
+}
+
+// Executor runs actions of allowed kinds and audits every attempt.
+type Executor struct {
+	allow   map[string]bool
+	audit   Auditor
+	timeout time.Duration
+}
+
+// NewExecutor returns an executor for the given kinds that records to
+// audit; a nil audit records nothing.
+func NewExecutor(allow []string, audit Auditor) (*Executor, error) {
+	e := &Executor{allow: make(map[string]bool, len(allow)), audit: audit, timeout: 2 * time.Minute}
+	for _, kind := range allow {
+		kind = strings.TrimSpace(kind)
+		if !slices.Contains(Kinds, kind) {
+			return nil, fmt.Errorf("unknown action kind %q (kinds: %s)", kind, strings.Join(Kinds, ", "))
+		}
+		e.allow[kind] = true
+	}
+	return e, nil
+}
+
+// Allowed reports whether actions of kind may run. A nil executor allows
+// nothing.
+func (e *Executor) Allowed(kind string) bool {
+	return e != nil && e.allow[kind]
+}
+
+// Execute checks a's preconditions and, unless opts.DryRun, runs it.
+func (e *Executor) Execute(ctx context.Context, a Action, opts Options) (res Result, err error) {
+	res = Result{Spec: a.Spec(), DryRun: opts.DryRun}
+	defer func() { e.record(opts, res, err) }()
+
+	if !e.Allowed(res.Spec.Kind) {
+		return res, fmt.Errorf("%s: %w", res.Spec.Kind, ErrNotAllowed)
+	}
+	if err := a.Check(ctx); err != nil {
+		return res, fmt.Errorf("%s: precondition failed: %w", res.Spec.ID, err)
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+	if res.Spec.Confirm && !opts.Confirmed {
+		return res, fmt.Errorf("%s: %w", res.Spec.ID, ErrConfirmationRequired)
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	res.Output, err = a.Run(ctx)
+	return res, err
+}
+
+// record writes an audit entry; failures are logged, the action has
+// already been decided.
+func (e *Executor) record(opts Options, res Result, err error) {
+	if e == nil || e.audit == nil {
+		return
+	}
+	entry := Entry{
+		At:     time.Now().UTC(),
+		Actor:  opts.Actor,
+		ID:     res.Spec.ID,
+		Kind:   res.Spec.Kind,
+		Target: res.Spec.Target,
+		DryRun: res.DryRun,
+		Output: res.Output,
+	}
+	switch {
+	case errors.Is(err, ErrNotAllowed), errors.Is(err, ErrConfirmationRequired):
+		entry.Outcome = OutcomeRefused
+	case err != nil:
+		entry.Outcome = OutcomeFailed
+	case res.DryRun:
+		entry.Outcome = OutcomeDryRun
+	default:
+		entry.Outcome = OutcomeDone
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if aerr := e.audit.Record(entry); aerr != nil {
+		log.Printf("actions: audit %s: %v", entry.ID, aerr)
+	}
+}
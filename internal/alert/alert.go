@@ -0,0 +1,328 @@
+// Package alert opens incidents in paging services, and posts to Slack,
+// webhooks and email, when flags fire, and resolves them when the flags
+// clear.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+// Alert is one incident, keyed per host and flag.
+type Alert struct {
+	Key      string // stable dedup key, e.g. "syschecker/web-1/cpu_overloaded"
+	AgentID  string
+	Flag     string
+	Severity int // the snapshot's severity level, 0-3
+	Labels   map[string]string
+	Summary  string
+	Body     string // Markdown dashboard of the snapshot that raised the alert
+	At       time.Time
+	Details  map[string]any
+}
+
+// Sender delivers alerts to one paging service.
+type Sender interface {
+	Name() string
+	Trigger(ctx context.Context, a Alert) error
+	Resolve(ctx context.Context, a Alert) error
+}
+
+// DigestSender is a Sender that can also post digests, which are not
+// incidents. Paging services cannot, so digests skip them.
+type DigestSender interface {
+	Sender
+	PostDigest(ctx context.Context, a Alert) error
+}
+
+// DigestFlag is the flag digests are routed as, so routing rules can match
+// them with "flags": ["digest"].
+const DigestFlag = "digest"
+
+// Notifier turns persisted snapshots into trigger and resolve calls on its
+// senders. It implements database.PayloadNotifier; delivery happens on a
+// background goroutine.
+type Notifier struct {
+	route   func(Alert) []Sender
+	policy  func(flag string) flagPolicy // nil notifies every flag at once
+	timeout time.Duration
+
+	mu       sync.Mutex
+	active   map[string]Alert       // open incidents by key
+	pending  map[string]pendingFlag // set flags waiting out their debounce or cooldown, by key
+	lastSent map[string]time.Time   // when each key last triggered
+
+	queue  chan func(context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNotifier starts a notifier that sends every alert to all senders.
+// Close stops it.
+func NewNotifier(senders ...Sender) *Notifier {
+	return newNotifier(func(Alert) []Sender { return senders })
+}
+
+// NewRoutedNotifier starts a notifier that sends each alert where r routes
+// it, holding flags back as r's policies say.
+func NewRoutedNotifier(r *Router) *Notifier {
+	n := newNotifier(r.Senders)
+	n.policy = r.policy
+	return n
+}
+
+// pendingFlag is a set flag that has not triggered yet.
+type pendingFlag struct {
+	agentID string
+	flag    string
+	since   time.Time
+}
+
+func newNotifier(route func(Alert) []Sender) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		route:    route,
+		timeout:  10 * time.Second,
+		active:   make(map[string]Alert),
+		pending:  make(map[string]pendingFlag),
+		lastSent: make(map[string]time.Time),
+		queue:    make(chan func(context.Context), 64),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go n.run(ctx)
+	return n
+}
+
+// Notify triggers an incident for each newly active flag (or re-triggers
+// it when severity rose) and resolves incidents whose flag cleared. A
+// flag's policy can hold its trigger back until the snapshot's severity
+// reaches a threshold, the flag has stayed set for its debounce, and its
+// cooldown has passed since it last triggered on the host.
+func (n *Notifier) Notify(ctx context.Context, p *output.PipelinePayload) error {
+	triggers, resolves := n.diff(p)
+	for _, a := range triggers {
+		n.enqueue(a, Sender.Trigger)
+	}
+	for _, a := range resolves {
+		n.enqueue(a, Sender.Resolve)
+	}
+	return nil
+}
+
+// NotifyEscalation re-triggers the open incident of a flag that escalated
+// or is due a reminder, so the paging service hears about it again.
+// Flags without an open incident are left to the next Notify.
+func (n *Notifier) NotifyEscalation(ctx context.Context, e relational.EscalationNotice) error {
+	n.mu.Lock()
+	a, ok := n.active[alertKey(e.AgentID, e.Flag)]
+	n.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	activeFor := e.ActiveFor.Truncate(time.Minute)
+	if e.Repeat {
+		a.Summary = fmt.Sprintf("%s: %s still active for %s (reminder #%d)", e.AgentID, e.Flag, activeFor, e.NotifyCount)
+	} else {
+		a.Summary = fmt.Sprintf("%s: %s escalated to level %d after %s", e.AgentID, e.Flag, e.Level, activeFor)
+	}
+	a.Details = maps.Clone(a.Details)
+	if a.Details == nil {
+		a.Details = map[string]any{}
+	}
+	a.Details["escalation_level"] = e.Level
+	a.Details["active_for"] = activeFor.String()
+	n.enqueue(a, Sender.Trigger)
+	return nil
+}
+
+// NotifyDigest sends a digest to the senders it routes to that implement
+// DigestSender.
+func (n *Notifier) NotifyDigest(ctx context.Context, title, body string, at time.Time) error {
+	a := Alert{
+		Key:     "syschecker/" + DigestFlag,
+		Flag:    DigestFlag,
+		Summary: title,
+		Body:    body,
+		At:      at,
+	}
+	n.enqueue(a, func(s Sender, ctx context.Context, a Alert) error {
+		if d, ok := s.(DigestSender); ok {
+			return d.PostDigest(ctx, a)
+		}
+		return nil
+	})
+	return nil
+}
+
+func alertKey(agentID, flag string) string {
+	return fmt.Sprintf("syschecker/%s/%s", agentID, flag)
+}
+
+// diff updates the open incidents of p's host and returns what changed.
+func (n *Notifier) diff(p *output.PipelinePayload) (triggers, resolves []Alert) {
+	agent := p.Raw.AgentID
+	flags := p.Flags.ActiveFlags()
+	var body strings.Builder
+	if len(flags) > 0 {
+		_ = output.WriteMarkdown(&body, output.NewDashboardView(p, nil))
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.pending == nil {
+		n.pending = make(map[string]pendingFlag)
+		n.lastSent = make(map[string]time.Time)
+	}
+	for _, flag := range flags {
+		a := Alert{
+			Key:      alertKey(agent, flag),
+			AgentID:  agent,
+			Flag:     flag,
+			Severity: p.Flags.SeverityLevel,
+			Labels:   p.Raw.Labels,
+			Summary:  fmt.Sprintf("%s: %s", agent, flag),
+			Body:     body.String(),
+			At:       p.Raw.CollectedAt,
+			Details: map[string]any{
+				"explanation":   p.Flags.Explanation,
+				"primary_cause": p.Flags.PrimaryCause,
+				"cause_entity":  p.Flags.CauseEntityKey,
+				"risk_score":    p.Flags.RiskScore,
+				"trace_id":      p.Raw.TraceID,
+			},
+		}
+		if p.Flags.Explanation != "" {
+			a.Summary += " - " + p.Flags.Explanation
+		}
+		if prev, ok := n.active[a.Key]; ok {
+			if a.Severity > prev.Severity {
+				triggers = append(triggers, a)
+				n.active[a.Key] = a
+				n.lastSent[a.Key] = a.At
+			}
+			continue
+		}
+		if n.due(a) {
+			triggers = append(triggers, a)
+			n.active[a.Key] = a
+			n.lastSent[a.Key] = a.At
+		}
+	}
+	for key, a := range n.active {
+		// Resolves keep the trigger's fields so they route the same way.
+		if a.AgentID == agent && !slices.Contains(flags, a.Flag) {
+			resolves = append(resolves, a)
+			delete(n.active, key)
+		}
+	}
+	for key, pf := range n.pending {
+		if pf.agentID == agent && !slices.Contains(flags, pf.flag) {
+			delete(n.pending, key)
+		}
+	}
+	return triggers, resolves
+}
+
+// due reports whether the set flag of a, without an open incident, may
+// trigger now under its policy, tracking how long it has waited. Times are
+// those of the snapshots.
+func (n *Notifier) due(a Alert) bool {
+	var pol flagPolicy
+	if n.policy != nil {
+		pol = n.policy(a.Flag)
+	}
+	if a.Severity < pol.minSeverity {
+		delete(n.pending, a.Key)
+		return false
+	}
+	pf, ok := n.pending[a.Key]
+	if !ok {
+		pf = pendingFlag{agentID: a.AgentID, flag: a.Flag, since: a.At}
+		n.pending[a.Key] = pf
+	}
+	if a.At.Sub(pf.since) < pol.debounce {
+		return false
+	}
+	if last, ok := n.lastSent[a.Key]; ok && a.At.Sub(last) < pol.cooldown {
+		return false
+	}
+	delete(n.pending, a.Key)
+	return true
+}
+
+func (n *Notifier) enqueue(a Alert, call func(Sender, context.Context, Alert) error) {
+	senders := n.route(a)
+	if len(senders) == 0 {
+		return
+	}
+	job := func(ctx context.Context) {
+		for _, s := range senders {
+			sendCtx, cancel := context.WithTimeout(ctx, n.timeout)
+			if err := call(s, sendCtx, a); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "%s alert %s failed: %v\n", s.Name(), a.Key, err)
+			}
+			cancel()
+		}
+	}
+	select {
+	case n.queue <- job:
+	default:
+		fmt.Fprintf(os.Stderr, "Alert queue full, dropped %s\n", a.Key)
+	}
+}
+
+// Close stops delivery, abandoning queued alerts.
+func (n *Notifier) Close() {
+	n.cancel()
+	<-n.done
+}
+
+func (n *Notifier) run(ctx context.Context) {
+	defer close(n.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-n.queue:
+			job(ctx)
+		}
+	}
+}
+
+// severityName maps a severity level to PagerDuty's severities.
+func severityName(level int) string {
+	switch {
+	case level >= 3:
+		return "critical"
+	case level == 2:
+		return "error"
+	case level == 1:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// priority maps a severity level to Opsgenie's P1-P5.
+func priority(level int) string {
+	switch {
+	case level >= 3:
+		return "P1"
+	case level == 2:
+		return "P2"
+	case level == 1:
+		return "P3"
+	default:
+		return "P4"
+	}
+}
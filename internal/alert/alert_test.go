@@ -0,0 +1,198 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+	"syschecker/internal/webhook"
+)
+
+func snapshot(severity int, flags relational.SnapshotFlags) *output.PipelinePayload {
+	flags.SeverityLevel = severity
+	return &output.PipelinePayload{
+		Raw:   relational.RawStatsFixed{AgentID: "web-1", CollectedAt: time.Unix(100, 0)},
+		Flags: flags,
+	}
+}
+
+func TestNotifierDiff(t *testing.T) {
+	n := &Notifier{active: make(map[string]Alert)}
+
+	tr, res := n.diff(snapshot(2, relational.SnapshotFlags{FlagCPUOverloaded: true}))
+	if len(tr) != 1 || tr[0].Key != "syschecker/web-1/cpu_overloaded" || len(res) != 0 {
+		t.Fatalf("first: triggers=%v resolves=%v", tr, res)
+	}
+	if !strings.Contains(tr[0].Body, "## CPU (warning)") {
+		t.Errorf("body lacks the dashboard:\n%s", tr[0].Body)
+	}
+	// Unchanged flags are not re-sent; a higher severity is.
+	if tr, _ := n.diff(snapshot(2, relational.SnapshotFlags{FlagCPUOverloaded: true})); len(tr) != 0 {
+		t.Errorf("repeat triggered %v", tr)
+	}
+	if tr, _ := n.diff(snapshot(3, relational.SnapshotFlags{FlagCPUOverloaded: true})); len(tr) != 1 {
+		t.Errorf("escalation triggered %v, want 1", tr)
+	}
+	tr, res = n.diff(snapshot(0, relational.SnapshotFlags{}))
+	if len(tr) != 0 || len(res) != 1 || res[0].Flag != "cpu_overloaded" {
+		t.Errorf("clear: triggers=%v resolves=%v", tr, res)
+	}
+}
+
+func TestNotifierPolicy(t *testing.T) {
+	n := &Notifier{active: make(map[string]Alert)}
+	n.policy = func(string) flagPolicy {
+		return flagPolicy{minSeverity: 2, debounce: 2 * time.Minute, cooldown: 30 * time.Minute}
+	}
+	at := func(severity int, minute int, set bool) *output.PipelinePayload {
+		p := snapshot(severity, relational.SnapshotFlags{FlagCPUOverloaded: set})
+		p.Raw.CollectedAt = time.Unix(0, 0).Add(time.Duration(minute) * time.Minute)
+		return p
+	}
+
+	steps := []struct {
+		name             string
+		p                *output.PipelinePayload
+		triggers, clears int
+	}{
+		{"below the severity threshold", at(1, 0, true), 0, 0},
+		{"crossed it, debouncing", at(2, 1, true), 0, 0},
+		{"still debouncing", at(2, 2, true), 0, 0},
+		{"debounced", at(2, 3, true), 1, 0},
+		{"cleared", at(0, 4, false), 0, 1},
+		{"set again", at(2, 5, true), 0, 0},
+		{"debounced but cooling down", at(2, 8, true), 0, 0},
+		{"cooled down", at(2, 33, true), 1, 0},
+	}
+	for _, s := range steps {
+		tr, res := n.diff(s.p)
+		if len(tr) != s.triggers || len(res) != s.clears {
+			t.Errorf("%s: triggers=%v resolves=%v, want %d and %d", s.name, tr, res, s.triggers, s.clears)
+		}
+	}
+}
+
+func TestNotifyEscalationRetriggersOpenIncident(t *testing.T) {
+	n := &Notifier{active: make(map[string]Alert), queue: make(chan func(context.Context), 1)}
+	n.route = func(Alert) []Sender { return []Sender{nil} }
+	notice := relational.EscalationNotice{AgentID: "web-1", Flag: "cpu_overloaded", Level: 1, ActiveFor: 16 * time.Minute}
+
+	n.NotifyEscalation(context.Background(), notice)
+	if len(n.queue) != 0 {
+		t.Fatal("a flag without an open incident should wait for Notify")
+	}
+	n.diff(snapshot(3, relational.SnapshotFlags{FlagCPUOverloaded: true}))
+	n.NotifyEscalation(context.Background(), notice)
+	if len(n.queue) != 1 {
+		t.Fatal("escalation of an open incident was not re-triggered")
+	}
+}
+
+func TestSendersWireFormat(t *testing.T) {
+	var got []map[string]any
+	var paths, auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+		paths = append(paths, r.URL.RequestURI())
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	a := Alert{Key: "syschecker/web-1/cpu_overloaded", AgentID: "web-1", Flag: "cpu_overloaded", Severity: 3, Summary: "web-1: cpu_overloaded", Body: "# web-1", At: time.Unix(100, 0)}
+	pd := &PagerDuty{RoutingKey: "rk", URL: srv.URL, Client: srv.Client()}
+	og := &Opsgenie{APIKey: "gk", URL: srv.URL, Client: srv.Client()}
+	ctx := context.Background()
+	for _, err := range []error{pd.Trigger(ctx, a), pd.Resolve(ctx, a), og.Trigger(ctx, a), og.Resolve(ctx, a)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got[0]["event_action"] != "trigger" || got[0]["dedup_key"] != a.Key || got[0]["payload"].(map[string]any)["severity"] != "critical" {
+		t.Errorf("pagerduty trigger = %v", got[0])
+	}
+	if got[1]["event_action"] != "resolve" {
+		t.Errorf("pagerduty resolve = %v", got[1])
+	}
+	if got[0]["payload"].(map[string]any)["custom_details"].(map[string]any)["dashboard"] != a.Body {
+		t.Errorf("pagerduty details lack the body: %v", got[0])
+	}
+	if got[2]["priority"] != "P1" || got[2]["alias"] != a.Key || got[2]["description"] != a.Body || auth[2] != "GenieKey gk" {
+		t.Errorf("opsgenie trigger = %v (auth %q)", got[2], auth[2])
+	}
+	if paths[3] != "/v2/alerts/syschecker%2Fweb-1%2Fcpu_overloaded/close?identifierType=alias" {
+		t.Errorf("opsgenie close path = %s", paths[3])
+	}
+}
+
+func TestWebhookAndEmailSenders(t *testing.T) {
+	var body map[string]any
+	var event, signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		event, signature = r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderSignature)
+	}))
+	defer srv.Close()
+
+	a := Alert{Key: "syschecker/web-1/cpu_overloaded", AgentID: "web-1", Flag: "cpu_overloaded", Severity: 3, Summary: "web-1: cpu_overloaded\nBcc: x@example.com", Body: "# web-1", At: time.Unix(100, 0)}
+	hook := &Webhook{URL: srv.URL, Secret: "s3cret", Client: srv.Client()}
+	if err := hook.Trigger(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if event != "alert.trigger" || !strings.HasPrefix(signature, "sha256=") || body["flag"] != "cpu_overloaded" || body["severity"] != float64(3) {
+		t.Errorf("webhook got event %q, signature %q, body %v", event, signature, body)
+	}
+
+	var sent []byte
+	mail := NewEmail("smtp.invalid:25", "syschecker@example.com", []string{"ops@example.com"}, "", "")
+	mail.send = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		sent = msg
+		return nil
+	}
+	if err := mail.Trigger(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	msg := string(sent)
+	header, _, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(header, "Subject: [critical] web-1: cpu_overloaded Bcc: x@example.com") || strings.Contains(header, "\r\nBcc:") {
+		t.Errorf("subject not folded onto one line:\n%s", msg)
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\n# web-1\r\n") {
+		t.Errorf("body lacks the dashboard:\n%s", msg)
+	}
+}
+
+func TestNotifyDigestSkipsPagers(t *testing.T) {
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+	}))
+	defer srv.Close()
+
+	pd := &PagerDuty{RoutingKey: "rk", URL: srv.URL, Client: srv.Client()}
+	slack := &Slack{WebhookURL: srv.URL, Client: srv.Client()}
+	n := &Notifier{timeout: time.Second, queue: make(chan func(context.Context), 1)}
+	var routed Alert
+	n.route = func(a Alert) []Sender { routed = a; return []Sender{pd, slack} }
+
+	n.NotifyDigest(context.Background(), "syschecker digest", "All quiet.", time.Unix(100, 0))
+	(<-n.queue)(context.Background())
+	if routed.Flag != DigestFlag {
+		t.Errorf("routed as %q", routed.Flag)
+	}
+	if len(got) != 1 || got[0]["text"] != ":bar_chart: *syschecker digest*\nAll quiet." {
+		t.Errorf("posted %v, want only the Slack digest", got)
+	}
+}
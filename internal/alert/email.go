@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EnvSMTPPassword is consulted when an email target has no password.
+const EnvSMTPPassword = "SYSCHECKER_SMTP_PASSWORD"
+
+// Email sends alerts as plain-text mail through an SMTP relay, upgrading
+// to TLS when the relay offers STARTTLS.
+type Email struct {
+	Addr     string // relay host:port
+	From     string
+	To       []string
+	Username string // authenticates with PLAIN when set
+	Password string
+
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmail(addr, from string, to []string, username, password string) *Email {
+	return &Email{Addr: addr, From: from, To: to, Username: username, Password: password, send: smtp.SendMail}
+}
+
+func (e *Email) Name() string {
+	return "Email"
+}
+
+func (e *Email) Trigger(ctx context.Context, a Alert) error {
+	body := a.Summary
+	if a.Body != "" {
+		body += "\n\n" + a.Body
+	}
+	return e.mail(ctx, fmt.Sprintf("[%s] %s", severityName(a.Severity), a.Summary), body)
+}
+
+func (e *Email) Resolve(ctx context.Context, a Alert) error {
+	return e.mail(ctx, fmt.Sprintf("Resolved: %s: %s", a.AgentID, a.Flag), fmt.Sprintf("%s cleared on %s.", a.Flag, a.AgentID))
+}
+
+// PostDigest mails a digest.
+func (e *Email) PostDigest(ctx context.Context, a Alert) error {
+	return e.mail(ctx, a.Summary, a.Body)
+}
+
+// mail sends one message. net/smtp takes no context, so a cancelled ctx
+// only abandons the wait, not the delivery.
+func (e *Email) mail(ctx context.Context, subject, body string) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	msg := emailMessage(e.From, e.To, subject, body, time.Now())
+	done := make(chan error, 1)
+	go func() { done <- e.send(e.Addr, auth, e.From, e.To, msg) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// emailMessage renders a message with its headers. Line breaks in the
+// subject are folded into spaces so it cannot add headers.
+func emailMessage(from string, to []string, subject, body string, at time.Time) []byte {
+	subject = strings.Join(strings.Fields(subject), " ")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
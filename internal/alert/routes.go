@@ -0,0 +1,352 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"syschecker/internal/webhook"
+)
+
+// RoutingConfig is the JSON routing file: named targets and the ordered
+// rules that pick them. Alerts no rule matches go to Default.
+//
+//	{
+//	  "targets": {
+//	    "storage": {"type": "slack", "url": "https://hooks.slack.com/...", "channel": "#storage"},
+//	    "app":     {"type": "pagerduty", "key": "..."}
+//	  },
+//	  "routes": [
+//	    {"match": {"categories": ["disk"]}, "targets": ["storage"]},
+//	    {"match": {"categories": ["container"], "min_severity": 3, "hours": "08:00-20:00"}, "targets": ["app"]}
+//	  ],
+//	  "default": ["app"],
+//	  "policies": [
+//	    {"flags": ["cpu_*"], "debounce": "2m", "cooldown": "30m"},
+//	    {"flags": ["disk_*"], "min_severity": 2}
+//	  ]
+//	}
+type RoutingConfig struct {
+	Targets  map[string]TargetConfig `json:"targets"`
+	Routes   []Route                 `json:"routes"`
+	Default  []string                `json:"default"`
+	Policies []Policy                `json:"policies"`
+}
+
+// TargetConfig configures one sender. Type is pagerduty, opsgenie, slack,
+// webhook or email.
+type TargetConfig struct {
+	Type     string   `json:"type"`
+	Key      string   `json:"key"`      // PagerDuty routing key or Opsgenie API key
+	URL      string   `json:"url"`      // Slack or generic webhook, or an API endpoint override
+	Channel  string   `json:"channel"`  // Slack channel override
+	Secret   string   `json:"secret"`   // signs webhook bodies; default $SYSCHECKER_WEBHOOK_SECRET
+	SMTP     string   `json:"smtp"`     // email relay host:port
+	From     string   `json:"from"`     // email sender
+	To       []string `json:"to"`       // email recipients
+	Username string   `json:"username"` // email relay login
+	Password string   `json:"password"` // default $SYSCHECKER_SMTP_PASSWORD
+}
+
+// Policy tunes when the flags it matches notify. The first policy listing
+// a flag applies; flags none lists notify as soon as they are set.
+type Policy struct {
+	Flags       []string `json:"flags"`        // flag names or globs; empty matches every flag
+	MinSeverity int      `json:"min_severity"` // wait until the snapshot's severity reaches this
+	Debounce    string   `json:"debounce"`     // how long the flag must stay set first, e.g. "2m"
+	Cooldown    string   `json:"cooldown"`     // least time between two notifications of it on a host, e.g. "30m"
+}
+
+// flagPolicy is a parsed Policy.
+type flagPolicy struct {
+	flags       []string
+	minSeverity int
+	debounce    time.Duration
+	cooldown    time.Duration
+}
+
+func (p Policy) parse() (flagPolicy, error) {
+	fp := flagPolicy{flags: p.Flags, minSeverity: p.MinSeverity}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{{"debounce", p.Debounce, &fp.debounce}, {"cooldown", p.Cooldown, &fp.cooldown}} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v < 0 {
+			return flagPolicy{}, fmt.Errorf("%s %q: want a duration such as 5m", d.name, d.value)
+		}
+		*d.dst = v
+	}
+	return fp, nil
+}
+
+// Route sends matching alerts to Targets. Matching stops at the first
+// route unless Continue is set.
+type Route struct {
+	Match    Rule     `json:"match"`
+	Targets  []string `json:"targets"`
+	Continue bool     `json:"continue"`
+}
+
+// Rule matches alerts; empty fields match anything.
+type Rule struct {
+	Flags       []string          `json:"flags"`      // flag names or globs such as "disk_*"
+	Categories  []string          `json:"categories"` // see Category
+	MinSeverity int               `json:"min_severity"`
+	Hosts       []string          `json:"hosts"`  // agent ID globs
+	Labels      map[string]string `json:"labels"` // host labels that must all be present
+	Days        []string          `json:"days"`   // mon, tue, ... in local time
+	Hours       string            `json:"hours"`  // "HH:MM-HH:MM" local time; may wrap midnight
+}
+
+// categories group flags by the subsystem they concern.
+var categories = []struct{ prefix, category string }{
+	{"disk_", "disk"},
+	{"inode_", "disk"},
+	{"memory_", "memory"},
+	{"swap_", "memory"},
+	{"cpu_", "cpu"},
+	{"runaway_process_", "process"},
+	{"network_", "network"},
+	{"link_", "network"},
+	{"docker_", "container"},
+	{"container_", "container"},
+	{"thermal_", "hardware"},
+	{"under_voltage", "hardware"},
+	{"user_", "user"},
+	{"host_", "host"},
+	{"system_", "host"},
+	{"check_", "check"},
+	{"storage_", "host"},
+}
+
+// Category returns the subsystem a flag concerns: disk, memory, cpu,
+// process, network, container, hardware, user, host or check.
+func Category(flag string) string {
+	for _, c := range categories {
+		if strings.HasPrefix(flag, c.prefix) {
+			return c.category
+		}
+	}
+	return "other"
+}
+
+// Matches reports whether a satisfies every condition of r.
+func (r Rule) Matches(a Alert) bool {
+	if len(r.Flags) > 0 && !matchAny(r.Flags, a.Flag) {
+		return false
+	}
+	if len(r.Categories) > 0 && !slices.Contains(r.Categories, Category(a.Flag)) {
+		return false
+	}
+	if a.Severity < r.MinSeverity {
+		return false
+	}
+	if len(r.Hosts) > 0 && !matchAny(r.Hosts, a.AgentID) {
+		return false
+	}
+	for k, v := range r.Labels {
+		if a.Labels[k] != v {
+			return false
+		}
+	}
+	at := a.At.Local()
+	if len(r.Days) > 0 && !slices.Contains(r.Days, strings.ToLower(at.Weekday().String()[:3])) {
+		return false
+	}
+	if r.Hours != "" {
+		from, to, _ := parseHours(r.Hours)
+		now := at.Hour()*60 + at.Minute()
+		if from <= to && (now < from || now >= to) || from > to && now < from && now >= to {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHours parses "HH:MM-HH:MM" into minutes since midnight.
+func parseHours(s string) (from, to int, err error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("hours %q: want HH:MM-HH:MM", s)
+	}
+	a, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return 0, 0, fmt.Errorf("hours %q: %w", s, err)
+	}
+	b, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return 0, 0, fmt.Errorf("hours %q: %w", s, err)
+	}
+	return a.Hour()*60 + a.Minute(), b.Hour()*60 + b.Minute(), nil
+}
+
+// Router picks the senders for each alert and the policy of each flag.
+type Router struct {
+	targets  map[string]Sender
+	routes   []Route
+	def      []string
+	policies []flagPolicy
+}
+
+// NewRouter builds the targets of cfg and checks its routes.
+func NewRouter(cfg RoutingConfig) (*Router, error) {
+	r := &Router{targets: make(map[string]Sender, len(cfg.Targets)), routes: cfg.Routes, def: cfg.Default}
+	for name, t := range cfg.Targets {
+		s, err := t.sender()
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %w", name, err)
+		}
+		r.targets[name] = s
+	}
+	check := func(names []string) error {
+		for _, n := range names {
+			if _, ok := r.targets[n]; !ok {
+				return fmt.Errorf("unknown target %q", n)
+			}
+		}
+		return nil
+	}
+	for i, rt := range cfg.Routes {
+		if err := check(rt.Targets); err != nil {
+			return nil, fmt.Errorf("route %d: %w", i+1, err)
+		}
+		if rt.Match.Hours != "" {
+			if _, _, err := parseHours(rt.Match.Hours); err != nil {
+				return nil, fmt.Errorf("route %d: %w", i+1, err)
+			}
+		}
+	}
+	if err := check(cfg.Default); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	for i, p := range cfg.Policies {
+		fp, err := p.parse()
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i+1, err)
+		}
+		r.policies = append(r.policies, fp)
+	}
+	return r, nil
+}
+
+// LoadRouter reads a RoutingConfig from a JSON file.
+func LoadRouter(file string) (*Router, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read alert routes: %w", err)
+	}
+	var cfg RoutingConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse alert routes %s: %w", file, err)
+	}
+	return NewRouter(cfg)
+}
+
+// Senders returns the distinct senders a should go to.
+func (r *Router) Senders(a Alert) []Sender {
+	var names []string
+	matched := false
+	for _, rt := range r.routes {
+		if !rt.Match.Matches(a) {
+			continue
+		}
+		matched = true
+		names = append(names, rt.Targets...)
+		if !rt.Continue {
+			break
+		}
+	}
+	if !matched {
+		names = r.def
+	}
+	var out []Sender
+	seen := make(map[string]bool)
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, r.targets[n])
+		}
+	}
+	return out
+}
+
+// policy returns the policy of flag.
+func (r *Router) policy(flag string) flagPolicy {
+	for _, p := range r.policies {
+		if len(p.flags) == 0 || matchAny(p.flags, flag) {
+			return p
+		}
+	}
+	return flagPolicy{}
+}
+
+func (t TargetConfig) sender() (Sender, error) {
+	switch t.Type {
+	case "pagerduty":
+		if t.Key == "" {
+			return nil, errors.New("pagerduty needs a key")
+		}
+		s := NewPagerDuty(t.Key)
+		if t.URL != "" {
+			s.URL = t.URL
+		}
+		return s, nil
+	case "opsgenie":
+		if t.Key == "" {
+			return nil, errors.New("opsgenie needs a key")
+		}
+		s := NewOpsgenie(t.Key)
+		if t.URL != "" {
+			s.URL = t.URL
+		}
+		return s, nil
+	case "slack":
+		if t.URL == "" {
+			return nil, errors.New("slack needs a webhook url")
+		}
+		return NewSlack(t.URL, t.Channel), nil
+	case "webhook":
+		if t.URL == "" {
+			return nil, errors.New("webhook needs a url")
+		}
+		secret := t.Secret
+		if secret == "" {
+			secret = os.Getenv(webhook.EnvSecret)
+		}
+		return NewWebhook(t.URL, secret), nil
+	case "email":
+		if _, _, err := net.SplitHostPort(t.SMTP); err != nil {
+			return nil, fmt.Errorf("email needs an smtp relay host:port: %w", err)
+		}
+		if t.From == "" || len(t.To) == 0 {
+			return nil, errors.New("email needs from and to addresses")
+		}
+		password := t.Password
+		if password == "" {
+			password = os.Getenv(EnvSMTPPassword)
+		}
+		return NewEmail(t.SMTP, t.From, t.To, t.Username, password), nil
+	default:
+		return nil, fmt.Errorf("unknown target type %q", t.Type)
+	}
+}
@@ -0,0 +1,109 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRuleMatches(t *testing.T) {
+	// Wednesday 23:30 local time.
+	night := time.Date(2026, 3, 4, 23, 30, 0, 0, time.Local)
+	a := Alert{AgentID: "db-1", Flag: "disk_space_critical", Severity: 2, At: night, Labels: map[string]string{"team": "storage"}}
+
+	tests := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"empty", Rule{}, true},
+		{"flag glob", Rule{Flags: []string{"disk_*"}}, true},
+		{"category", Rule{Categories: []string{"container"}}, false},
+		{"severity", Rule{MinSeverity: 3}, false},
+		{"host glob", Rule{Hosts: []string{"db-*"}}, true},
+		{"label", Rule{Labels: map[string]string{"team": "payments"}}, false},
+		{"day", Rule{Days: []string{"mon", "tue"}}, false},
+		{"office hours", Rule{Hours: "09:00-18:00"}, false},
+		{"overnight", Rule{Hours: "22:00-06:00"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Matches(a); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRouter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "routes.json")
+	os.WriteFile(file, []byte(`{
+		"targets": {
+			"storage": {"type": "slack", "url": "http://slack.invalid/hook", "channel": "#storage"},
+			"app": {"type": "pagerduty", "key": "rk"},
+			"ops": {"type": "opsgenie", "key": "gk"}
+		},
+		"routes": [
+			{"match": {"categories": ["disk"]}, "targets": ["storage"], "continue": true},
+			{"match": {"min_severity": 3}, "targets": ["ops"]},
+			{"match": {"categories": ["container"]}, "targets": ["app"]}
+		],
+		"default": ["ops"]
+	}`), 0o644)
+	r, err := LoadRouter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(a Alert) (out []string) {
+		for _, s := range r.Senders(a) {
+			out = append(out, s.Name())
+		}
+		return out
+	}
+	if got := names(Alert{Flag: "disk_space_critical", Severity: 3}); len(got) != 2 || got[0] != "Slack" || got[1] != "Opsgenie" {
+		t.Errorf("critical disk alert went to %v", got)
+	}
+	if got := names(Alert{Flag: "container_cpu_hog", Severity: 2}); len(got) != 1 || got[0] != "PagerDuty" {
+		t.Errorf("container alert went to %v", got)
+	}
+	if got := names(Alert{Flag: "cpu_overloaded", Severity: 2}); len(got) != 1 || got[0] != "Opsgenie" {
+		t.Errorf("unmatched alert went to %v, want the default", got)
+	}
+
+	if _, err := NewRouter(RoutingConfig{Default: []string{"missing"}}); err == nil {
+		t.Error("expected an error for an unknown target")
+	}
+}
+
+func TestRouterPolicies(t *testing.T) {
+	r, err := NewRouter(RoutingConfig{
+		Targets: map[string]TargetConfig{
+			"hook": {Type: "webhook", URL: "http://hook.invalid/alerts"},
+			"mail": {Type: "email", SMTP: "smtp.invalid:587", From: "syschecker@example.com", To: []string{"ops@example.com"}},
+		},
+		Default: []string{"hook", "mail"},
+		Policies: []Policy{
+			{Flags: []string{"cpu_*"}, Debounce: "2m", Cooldown: "30m"},
+			{MinSeverity: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := r.policy("cpu_overloaded"); p.debounce != 2*time.Minute || p.cooldown != 30*time.Minute {
+		t.Errorf("cpu_overloaded policy = %+v", p)
+	}
+	if p := r.policy("disk_space_critical"); p.minSeverity != 2 || p.debounce != 0 {
+		t.Errorf("disk_space_critical policy = %+v, want the catch-all", p)
+	}
+
+	for _, cfg := range []RoutingConfig{
+		{Policies: []Policy{{Debounce: "soon"}}},
+		{Targets: map[string]TargetConfig{"mail": {Type: "email", SMTP: "smtp.invalid"}}},
+		{Targets: map[string]TargetConfig{"hook": {Type: "webhook"}}},
+	} {
+		if _, err := NewRouter(cfg); err == nil {
+			t.Errorf("NewRouter(%+v) accepted", cfg)
+		}
+	}
+}
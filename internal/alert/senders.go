@@ -0,0 +1,251 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"syschecker/internal/webhook"
+)
+
+// Environment variables consulted when no key flag is given.
+const (
+	EnvPagerDutyKey = "SYSCHECKER_PAGERDUTY_KEY"
+	EnvOpsgenieKey  = "SYSCHECKER_OPSGENIE_KEY"
+)
+
+// Default API endpoints; EU Opsgenie accounts use https://api.eu.opsgenie.com.
+const (
+	PagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieURL  = "https://api.opsgenie.com"
+)
+
+// PagerDuty sends alerts through the Events API v2.
+type PagerDuty struct {
+	RoutingKey string
+	URL        string
+	Client     *http.Client
+}
+
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{RoutingKey: routingKey, URL: PagerDutyURL, Client: http.DefaultClient}
+}
+
+func (p *PagerDuty) Name() string {
+	return "PagerDuty"
+}
+
+func (p *PagerDuty) Trigger(ctx context.Context, a Alert) error {
+	return p.enqueue(ctx, map[string]any{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.Key,
+		"payload": map[string]any{
+			"summary":        truncate(a.Summary, 1024),
+			"source":         a.AgentID,
+			"severity":       severityName(a.Severity),
+			"timestamp":      a.At.UTC().Format(time.RFC3339),
+			"component":      a.Flag,
+			"custom_details": pagerDutyDetails(a),
+		},
+	})
+}
+
+// pagerDutyDetails adds the alert body to its details without modifying them.
+func pagerDutyDetails(a Alert) map[string]any {
+	if a.Body == "" {
+		return a.Details
+	}
+	details := make(map[string]any, len(a.Details)+1)
+	for k, v := range a.Details {
+		details[k] = v
+	}
+	details["dashboard"] = a.Body
+	return details
+}
+
+func (p *PagerDuty) Resolve(ctx context.Context, a Alert) error {
+	return p.enqueue(ctx, map[string]any{
+		"routing_key":  p.RoutingKey,
+		"event_action": "resolve",
+		"dedup_key":    a.Key,
+	})
+}
+
+func (p *PagerDuty) enqueue(ctx context.Context, event map[string]any) error {
+	return postJSON(ctx, p.Client, p.URL, nil, event)
+}
+
+// Opsgenie sends alerts through the Alert API, using the dedup key as alias.
+type Opsgenie struct {
+	APIKey string
+	URL    string
+	Client *http.Client
+}
+
+func NewOpsgenie(apiKey string) *Opsgenie {
+	return &Opsgenie{APIKey: apiKey, URL: OpsgenieURL, Client: http.DefaultClient}
+}
+
+func (o *Opsgenie) Name() string {
+	return "Opsgenie"
+}
+
+func (o *Opsgenie) Trigger(ctx context.Context, a Alert) error {
+	details := make(map[string]string, len(a.Details))
+	for k, v := range a.Details {
+		details[k] = fmt.Sprint(v)
+	}
+	description := a.Summary
+	if a.Body != "" {
+		description = a.Body
+	}
+	return postJSON(ctx, o.Client, o.URL+"/v2/alerts", o.header(), map[string]any{
+		"message":     truncate(a.Summary, 130),
+		"alias":       a.Key,
+		"description": truncate(description, 15000),
+		"priority":    priority(a.Severity),
+		"source":      a.AgentID,
+		"entity":      a.AgentID,
+		"tags":        []string{"syschecker", a.Flag},
+		"details":     details,
+	})
+}
+
+func (o *Opsgenie) Resolve(ctx context.Context, a Alert) error {
+	endpoint := o.URL + "/v2/alerts/" + url.PathEscape(a.Key) + "/close?identifierType=alias"
+	return postJSON(ctx, o.Client, endpoint, o.header(), map[string]any{"source": a.AgentID})
+}
+
+func (o *Opsgenie) header() http.Header {
+	return http.Header{"Authorization": {"GenieKey " + o.APIKey}}
+}
+
+// Slack posts alerts to an incoming webhook. Slack has no incidents, so a
+// resolve is a follow-up message.
+type Slack struct {
+	WebhookURL string
+	Channel    string // overrides the webhook's default channel when set
+	Client     *http.Client
+}
+
+func NewSlack(webhookURL, channel string) *Slack {
+	return &Slack{WebhookURL: webhookURL, Channel: channel, Client: http.DefaultClient}
+}
+
+func (s *Slack) Name() string {
+	return "Slack"
+}
+
+func (s *Slack) Trigger(ctx context.Context, a Alert) error {
+	return s.post(ctx, fmt.Sprintf(":rotating_light: [%s] %s", severityName(a.Severity), a.Summary))
+}
+
+func (s *Slack) Resolve(ctx context.Context, a Alert) error {
+	return s.post(ctx, fmt.Sprintf(":white_check_mark: Resolved: %s: %s", a.AgentID, a.Flag))
+}
+
+// PostDigest posts a digest as a message of its own.
+func (s *Slack) PostDigest(ctx context.Context, a Alert) error {
+	return s.post(ctx, fmt.Sprintf(":bar_chart: *%s*\n%s", a.Summary, truncate(a.Body, slackMaxText)))
+}
+
+// slackMaxText keeps digest messages well inside Slack's message limit.
+const slackMaxText = 3500
+
+func (s *Slack) post(ctx context.Context, text string) error {
+	msg := map[string]any{"text": text}
+	if s.Channel != "" {
+		msg["channel"] = s.Channel
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, msg)
+}
+
+// Webhook posts alerts as JSON to any endpoint, signed like the snapshot
+// webhooks when Secret is set, with the event in HeaderEvent.
+type Webhook struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret, Client: http.DefaultClient}
+}
+
+func (w *Webhook) Name() string {
+	return "Webhook"
+}
+
+func (w *Webhook) Trigger(ctx context.Context, a Alert) error {
+	return w.post(ctx, "alert.trigger", a)
+}
+
+func (w *Webhook) Resolve(ctx context.Context, a Alert) error {
+	return w.post(ctx, "alert.resolve", a)
+}
+
+// PostDigest posts a digest as an event of its own.
+func (w *Webhook) PostDigest(ctx context.Context, a Alert) error {
+	return w.post(ctx, "digest", a)
+}
+
+func (w *Webhook) post(ctx context.Context, event string, a Alert) error {
+	body, err := json.Marshal(map[string]any{
+		"event":    event,
+		"key":      a.Key,
+		"agent_id": a.AgentID,
+		"flag":     a.Flag,
+		"severity": a.Severity,
+		"labels":   a.Labels,
+		"summary":  a.Summary,
+		"body":     a.Body,
+		"at":       a.At.UTC(),
+		"details":  a.Details,
+	})
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+	header := http.Header{webhook.HeaderEvent: {event}}
+	if w.Secret != "" {
+		header.Set(webhook.HeaderSignature, webhook.Sign(w.Secret, body))
+	}
+	return postJSON(ctx, w.Client, w.URL, header, json.RawMessage(body))
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, header http.Header, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build alert request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,121 @@
+// Package buildinfo identifies the build of the running binary, so a
+// mixed-version fleet can be told apart in the TUI, over MCP, on the debug
+// address and in the hosts table.
+//
+// Release builds set the variables with
+//
+//	-ldflags "-X syschecker/internal/buildinfo.Version=v1.2.3
+//	          -X syschecker/internal/buildinfo.Commit=$(git rev-parse HEAD)
+//	          -X syschecker/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Other builds fall back to what the Go toolchain records: the module
+// version for "go install pkg@version", and the VCS revision and commit
+// time for builds inside a git checkout.
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set at link time; see the package comment.
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// DevVersion is the version of builds that carry none.
+const DevVersion = "dev"
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a dirty checkout
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"` // GOOS/GOARCH
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build information, read once.
+func Get() Info {
+	once.Do(func() { info = read(debug.ReadBuildInfo) })
+	return info
+}
+
+// read merges the link-time variables with the toolchain's build info.
+func read(readBuildInfo func() (*debug.BuildInfo, bool)) Info {
+	i := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	if bi, ok := readBuildInfo(); ok {
+		if i.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			i.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if i.Commit == "" {
+					i.Commit = s.Value
+				}
+			case "vcs.time":
+				if i.Date == "" {
+					i.Date = s.Value
+				}
+			case "vcs.modified":
+				i.Modified = s.Value == "true"
+			}
+		}
+	}
+	if i.Version == "" {
+		i.Version = DevVersion
+	}
+	return i
+}
+
+// ShortCommit is the first 12 digits of Commit.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// String renders i on one line, e.g. "v1.2.3 (0123456789ab, 2026-01-02T15:04:05Z)".
+func (i Info) String() string {
+	var extra []string
+	if c := i.ShortCommit(); c != "" {
+		if i.Modified {
+			c += "+dirty"
+		}
+		extra = append(extra, c)
+	}
+	if i.Date != "" {
+		extra = append(extra, i.Date)
+	}
+	if len(extra) == 0 {
+		return i.Version
+	}
+	return i.Version + " (" + strings.Join(extra, ", ") + ")"
+}
+
+// Handler serves Get as JSON on GET.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Get())
+	})
+}
@@ -0,0 +1,58 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+)
+
+func TestReadPrefersLinkTimeVariables(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.0.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2026-01-02T15:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	fromToolchain := read(func() (*debug.BuildInfo, bool) { return bi, true })
+	if got := fromToolchain.String(); got != "v1.0.0 (0123456789ab+dirty, 2026-01-02T15:04:05Z)" {
+		t.Errorf("toolchain info %q", got)
+	}
+
+	Version, Commit = "v1.2.3", "fedcba"
+	t.Cleanup(func() { Version, Commit = "", "" })
+	linked := read(func() (*debug.BuildInfo, bool) { return bi, true })
+	if linked.Version != "v1.2.3" || linked.Commit != "fedcba" || linked.Date != "2026-01-02T15:04:05Z" {
+		t.Errorf("linked info %+v", linked)
+	}
+}
+
+func TestReadDevBuild(t *testing.T) {
+	dev := read(func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, true
+	})
+	if dev.String() != DevVersion {
+		t.Errorf("got %q, want %q", dev.String(), DevVersion)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var got Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != Get() {
+		t.Errorf("served %+v, want %+v", got, Get())
+	}
+
+	rec = httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST got %d", rec.Code)
+	}
+}
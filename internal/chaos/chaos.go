@@ -0,0 +1,159 @@
+// Package chaos injects faults — sensor timeouts, database write errors,
+// Neo4j outages and metric spikes — so the alerting, buffering and
+// flagger paths can be exercised deterministically in demos and tests.
+//
+// Faults only fire in binaries built with the chaos tag:
+//
+//	go build -tags chaos .
+//
+// Elsewhere Enabled is false and the hooks compile to nothing.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+// Faults that can be injected.
+const (
+	SensorTimeout = "sensor_timeout" // collections fail as if a sensor timed out
+	DBWriteError  = "db_write_error" // DuckDB snapshot inserts fail
+	Neo4jOutage   = "neo4j_outage"   // graph ingests fail as if Neo4j were unreachable
+	MetricSpike   = "metric_spike"   // fast metrics report CPU and memory near 100%
+)
+
+// Faults lists the faults Inject accepts.
+var Faults = []string{SensorTimeout, DBWriteError, Neo4jOutage, MetricSpike}
+
+// ErrInjected is wrapped by every error a fault produces. The errors also
+// wrap context.DeadlineExceeded so retry and buffering treat them as the
+// transient failures they simulate.
+var ErrInjected = errors.New("injected fault")
+
+// Injection is an active fault.
+type Injection struct {
+	Fault     string    `json:"fault"`
+	Until     time.Time `json:"until,omitempty"`     // zero: until cleared
+	Remaining int       `json:"remaining,omitempty"` // hits left; 0: unlimited
+	Hits      int       `json:"hits"`                // times the fault fired
+}
+
+// Injector holds the active faults.
+type Injector struct {
+	clock clock.Clock
+
+	mu     sync.Mutex
+	active map[string]*Injection
+}
+
+// NewInjector returns an injector without faults; a nil c uses the real
+// clock.
+func NewInjector(c clock.Clock) *Injector {
+	return &Injector{clock: clock.OrReal(c), active: make(map[string]*Injection)}
+}
+
+// Default is the injector the package-level hooks consult.
+var Default = NewInjector(nil)
+
+// Inject activates fault for d, or until cleared when d is 0, firing at
+// most count times, or unlimited when count is 0. Injecting an active
+// fault again replaces it.
+func (in *Injector) Inject(fault string, d time.Duration, count int) error {
+	if !slices.Contains(Faults, fault) {
+		return fmt.Errorf("unknown fault %q (want one of %s)", fault, strings.Join(Faults, ", "))
+	}
+	if d < 0 || count < 0 {
+		return fmt.Errorf("%s: duration and count must not be negative", fault)
+	}
+	inj := &Injection{Fault: fault, Remaining: count}
+	if d > 0 {
+		inj.Until = in.clock.Now().Add(d)
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.active[fault] = inj
+	return nil
+}
+
+// Clear deactivates fault, or every fault when it is empty.
+func (in *Injector) Clear(fault string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if fault == "" {
+		clear(in.active)
+		return
+	}
+	delete(in.active, fault)
+}
+
+// Active returns the faults still in effect, by name.
+func (in *Injector) Active() []Injection {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	now := in.clock.Now()
+	out := []Injection{}
+	for name, inj := range in.active {
+		if in.expired(inj, now) {
+			delete(in.active, name)
+			continue
+		}
+		out = append(out, *inj)
+	}
+	slices.SortFunc(out, func(a, b Injection) int { return strings.Compare(a.Fault, b.Fault) })
+	return out
+}
+
+// Fire reports whether fault is active, counting the hit.
+func (in *Injector) Fire(fault string) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	inj, ok := in.active[fault]
+	if !ok {
+		return false
+	}
+	if in.expired(inj, in.clock.Now()) {
+		delete(in.active, fault)
+		return false
+	}
+	inj.Hits++
+	if inj.Remaining > 0 {
+		if inj.Remaining--; inj.Remaining == 0 {
+			delete(in.active, fault)
+		}
+	}
+	return true
+}
+
+// Err returns the error fault produces when it fires, else nil.
+func (in *Injector) Err(fault string) error {
+	if !in.Fire(fault) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w: %w", fault, ErrInjected, context.DeadlineExceeded)
+}
+
+func (in *Injector) expired(inj *Injection, now time.Time) bool {
+	return !inj.Until.IsZero() && !now.Before(inj.Until)
+}
+
+// Fire reports whether fault fires on Default. It is always false without
+// the chaos build tag.
+func Fire(fault string) bool {
+	return Enabled && Default.Fire(fault)
+}
+
+// Err returns the error of fault firing on Default, or nil. It is always
+// nil without the chaos build tag.
+func Err(fault string) error {
+	if !Enabled {
+		return nil
+	}
+	return Default.Err(fault)
+}
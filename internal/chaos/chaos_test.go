@@ -0,0 +1,54 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestInjector(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	in := NewInjector(c)
+
+	if err := in.Inject("meteor", 0, 0); err == nil {
+		t.Error("unknown fault accepted")
+	}
+	if err := in.Inject(DBWriteError, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.Inject(Neo4jOutage, time.Minute, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// A counted fault fires exactly count times.
+	for i := range 3 {
+		err := in.Err(DBWriteError)
+		if fire := i < 2; (err != nil) != fire {
+			t.Fatalf("hit %d: got %v", i, err)
+		}
+		if err != nil && (!errors.Is(err, ErrInjected) || !errors.Is(err, context.DeadlineExceeded)) {
+			t.Errorf("error %v does not look transient", err)
+		}
+	}
+
+	// A timed one fires until it expires.
+	if !in.Fire(Neo4jOutage) || in.Fire(SensorTimeout) {
+		t.Error("wrong faults fired")
+	}
+	if got := in.Active(); len(got) != 1 || got[0].Fault != Neo4jOutage || got[0].Hits != 1 {
+		t.Errorf("active = %+v", got)
+	}
+	c.Advance(time.Minute)
+	if in.Fire(Neo4jOutage) || len(in.Active()) != 0 {
+		t.Error("fault outlived its duration")
+	}
+
+	in.Inject(MetricSpike, 0, 0)
+	in.Clear("")
+	if in.Fire(MetricSpike) {
+		t.Error("cleared fault fired")
+	}
+}
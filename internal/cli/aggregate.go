@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/fleet"
+)
+
+// backupSettle is how long a backup directory must go unmodified before
+// Aggregate merges it, so a backup still being copied in is not read half
+// written.
+const backupSettle = 30 * time.Second
+
+// Aggregate merges the backups agents write with "syschecker backup" into
+// the database g.DB, making it a fleet-wide store for the dashboards,
+// fleet queries and MCP tools. Every interval it pulls the backups of the
+// agents in -peers or found by -discover into -in, then merges each
+// subdirectory of -in that changed since it was last merged, until ctx
+// is done; with -interval 0 it runs once and returns.
+func Aggregate(ctx context.Context, g Globals, args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ExitOnError)
+	g.Register(fs)
+	in := fs.String("in", "", "directory holding one backup directory per agent, as written by \"syschecker backup\"; pulled backups are kept here too (default: a temporary directory when pulling)")
+	interval := fs.Duration("interval", 5*time.Minute, "how often to look for new or updated backups; 0 merges once and exits")
+	publish := fs.String("publish", "", "after each merge, write a copy of the database here for read-only viewers, which cannot open it while it is being aggregated")
+	remove := fs.Bool("remove", false, "delete each backup once it is merged")
+	peers := fs.String("peers", "", "comma-separated host or host:port of agents serving -fleet-addr to pull backups from into -in")
+	discover := fs.String("discover", "", "also pull from the agents found this way: "+strings.Join(fleet.Sources, ", ")+", or both comma-separated")
+	fleetPort := fs.String("fleet-port", fleet.DefaultPort, "fleet API port of discovered agents and -peers without one")
+	tailscaleTag := fs.String("tailscale-tag", "", "with -discover tailscale, only pull from tailnet peers with this ACL tag, e.g. tag:syschecker")
+	wgInterface := fs.String("wg-interface", "", "with -discover wireguard, the interface whose peers are agents (default: all)")
+	tokens := fs.String("fleet-tokens", "", "JSON file mapping each agent's name to the token it requires with -fleet-token-file; agents missing from it are not pulled. Re-read every interval, so tokens rotate without a restart")
+	pullTimeout := fs.Duration("pull-timeout", 10*time.Minute, "how long one agent's backup may take to download")
+	var fleetTLS fleet.TLSFiles
+	fleetTLS.Register(fs)
+	labels := map[string]string{}
+	fs.Func("label", "host label key=value added to every aggregated host (repeatable)", func(s string) error {
+		k, v, err := relational.ParseLabel(s)
+		if err != nil {
+			return err
+		}
+		labels[k] = v
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLogLevel(g.LogLevel); err != nil {
+		return err
+	}
+	if relational.IsRemote(g.DB) {
+		return fmt.Errorf("aggregate writes to -db, which cannot be the URL %s", g.DB)
+	}
+	a := &aggregator{in: *in, labels: labels, publish: *publish, remove: *remove, merged: map[string]time.Time{}}
+	if *peers != "" || *discover != "" {
+		for _, src := range splitList(*discover) {
+			if !slices.Contains(fleet.Sources, src) {
+				return fmt.Errorf("unknown -discover source %q (want %s)", src, strings.Join(fleet.Sources, ", "))
+			}
+		}
+		a.discover = fleet.DiscoverOptions{
+			Sources:      splitList(*discover),
+			Static:       splitList(*peers),
+			Port:         *fleetPort,
+			TailscaleTag: *tailscaleTag,
+			WGInterface:  *wgInterface,
+		}
+		tlsConfig, err := fleetTLS.ClientConfig()
+		if err != nil {
+			return err
+		}
+		a.client = fleet.NewClient(tlsConfig, *pullTimeout)
+		a.tokens = *tokens
+		if a.in == "" {
+			dir, err := os.MkdirTemp("", "syschecker-pulled-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(dir)
+			a.in = dir
+		}
+	}
+	if a.in == "" {
+		return errors.New("aggregate needs -in, -peers or -discover")
+	}
+
+	dbClient, err := relational.NewDuckDBClient(g.DB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer dbClient.Close()
+	a.repo = relational.NewRepo(dbClient.DB())
+	if err := a.repo.Migrate(ctx); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if err := a.run(ctx); err != nil || *interval <= 0 {
+		return err
+	}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := a.run(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// aggregator remembers which backups it merged, by their last
+// modification, so unchanged ones are not read again each interval.
+type aggregator struct {
+	repo    *relational.Repo
+	in      string
+	labels  map[string]string
+	publish string
+	remove  bool
+	merged  map[string]time.Time
+
+	client   *http.Client // nil unless pulling from agents
+	discover fleet.DiscoverOptions
+	tokens   string // file of the agents' tokens, if they require one
+}
+
+// run pulls the agents' backups, if any, and merges the backups that
+// changed since the last run. A backup that fails to pull or merge is
+// logged and skipped until the next run or change; only a failure to
+// read -in or to publish stops the aggregator.
+func (a *aggregator) run(ctx context.Context) error {
+	pulled := a.pull(ctx)
+	entries, err := os.ReadDir(a.in)
+	if err != nil {
+		return fmt.Errorf("read backups: %w", err)
+	}
+	changed := false
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasSuffix(e.Name(), fleet.PartialSuffix) || ctx.Err() != nil {
+			continue
+		}
+		dir := filepath.Join(a.in, e.Name())
+		modified, err := lastModified(dir)
+		if err != nil {
+			slog.Warn("Skipping backup", "dir", dir, "error", err)
+			continue
+		}
+		// Pulled backups appear complete; others may still be copied in.
+		if (!pulled[dir] && time.Since(modified) < backupSettle) || a.merged[dir].Equal(modified) {
+			continue
+		}
+		a.merged[dir] = modified
+
+		res, err := a.repo.MergeBackup(ctx, dir, a.labels)
+		if err != nil {
+			slog.Warn("Failed to merge backup", "dir", dir, "error", err)
+			continue
+		}
+		slog.Info("Merged backup", "dir", dir, "hosts", res.Hosts, "new_hosts", res.NewHosts,
+			"snapshots", res.Snapshots, "duplicates", res.Duplicates)
+		changed = changed || res.Snapshots > 0 || res.NewHosts > 0
+		if a.remove {
+			if err := os.RemoveAll(dir); err != nil {
+				slog.Warn("Failed to remove merged backup", "dir", dir, "error", err)
+			}
+			delete(a.merged, dir)
+		}
+	}
+	if changed && a.publish != "" {
+		if err := a.repo.Publish(ctx, a.publish); err != nil {
+			return err
+		}
+		slog.Info("Published aggregated database", "path", a.publish)
+	}
+	return nil
+}
+
+// pull downloads the backup of every agent discovered into -in and
+// returns the directories written.
+func (a *aggregator) pull(ctx context.Context) map[string]bool {
+	pulled := map[string]bool{}
+	if a.client == nil {
+		return pulled
+	}
+	var tokens map[string]string
+	if a.tokens != "" {
+		var err error
+		if tokens, err = fleet.LoadTokens(a.tokens); err != nil {
+			slog.Warn("Not pulling from agents", "error", err)
+			return pulled
+		}
+	}
+	peers, err := fleet.Discover(ctx, a.discover)
+	if err != nil {
+		slog.Warn("Agent discovery failed", "error", err)
+	}
+	for _, p := range peers {
+		token := tokens[p.Name]
+		if a.tokens != "" && token == "" {
+			slog.Warn("Not pulling from agent without a token", "agent", p.Name, "tokens", a.tokens)
+			continue
+		}
+		dir, err := fleet.Pull(ctx, a.client, p, token, a.in)
+		if err != nil {
+			slog.Warn("Failed to pull backup", "agent", p.Name, "addr", p.Addr, "error", err)
+			continue
+		}
+		pulled[dir] = true
+	}
+	return pulled
+}
+
+// splitList splits a comma-separated flag, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// lastModified returns the latest modification time of dir and the files
+// directly in it.
+func lastModified(dir string) (time.Time, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	latest := info.ModTime()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
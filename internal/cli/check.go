@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"syschecker/internal/buildinfo"
+)
+
+// Check starts the MCP server, calls its main tools once and reports what
+// worked. It fails when the server cannot be reached at all.
+func Check(ctx context.Context, g Globals, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	g.Register(fs)
+	server := fs.String("server", "", `MCP server command to check, split on spaces (default: this executable's "mcp serve")`)
+	var envFiles []string
+	fs.Func("env", "KEY=value file loaded before starting the server (repeatable; default env/.env and ui/Testing/env/.env)", func(s string) error {
+		envFiles = append(envFiles, s)
+		return nil
+	})
+	timeout := fs.Duration("timeout", 30*time.Second, "time limit for the whole check")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLogLevel(g.LogLevel); err != nil {
+		return err
+	}
+	if len(envFiles) == 0 {
+		envFiles = []string{"env/.env", "ui/Testing/env/.env"}
+	}
+	for _, f := range envFiles {
+		loadEnvFile(f)
+	}
+
+	if os.Getenv("GEMINI_API_KEY") == "" {
+		return errors.New("GEMINI_API_KEY not set in the environment or -env files")
+	}
+
+	fmt.Println("🧪 Testing MCP Server and Tool Calling")
+	fmt.Println("=======================================")
+	fmt.Println()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if *server != "" {
+		fields := strings.Fields(*server)
+		cmd = exec.Command(fields[0], fields[1:]...)
+	} else {
+		var err error
+		if cmd, err = SelfCommand(g, "mcp", "serve"); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✅ Test 1: MCP server command: %s\n", strings.Join(cmd.Args, " "))
+	cmd.Stderr = os.Stderr
+	transport := &mcp.CommandTransport{Command: cmd}
+
+	// Create client
+	client := mcp.NewClient(&mcp.Implementation{
+		Name:    "test-client",
+		Version: buildinfo.Get().Version,
+	}, nil)
+
+	// Connect to server
+	session, err := client.Connect(ctx, transport, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MCP server: %w", err)
+	}
+	defer session.Close()
+	fmt.Println("✅ Test 2: Connected to MCP server")
+
+	// List available tools
+	fmt.Println("\n✓ Test 3: Listing available tools")
+	listResult, err := session.ListTools(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+	fmt.Printf("  Found %d tools:\n", len(listResult.Tools))
+	for _, tool := range listResult.Tools {
+		fmt.Printf("  - %s: %s\n", tool.Name, tool.Description)
+	}
+
+	// Test 1: get_realtime_metrics
+	fmt.Println("\n✓ Test 4: Testing get_realtime_metrics tool")
+	metricsResult, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "get_realtime_metrics",
+		Arguments: map[string]interface{}{
+			"metric_type": "fast",
+		},
+	})
+	if err != nil {
+		fmt.Printf("  ❌ Metrics tool failed: %v\n", err)
+	} else {
+		fmt.Println("  ✅ Metrics tool called successfully")
+		if len(metricsResult.Content) > 0 {
+			fmt.Println("  ✅ Received metrics data:")
+			for i, content := range metricsResult.Content {
+				if i >= 3 {
+					fmt.Printf("  ... and %d more content items\n", len(metricsResult.Content)-i)
+					break
+				}
+				switch v := content.(type) {
+				case *mcp.TextContent:
+					preview := v.Text
+					if len(preview) > 200 {
+						preview = preview[:200] + "..."
+					}
+					fmt.Printf("    %s\n", preview)
+				default:
+					fmt.Printf("    [%T]\n", content)
+				}
+			}
+		}
+	}
+
+	// Test 2: ask_syschecker (with timeout)
+	fmt.Println("\n✓ Test 5: Testing ask_syschecker tool")
+	askCtx, askCancel := context.WithTimeout(ctx, 15*time.Second)
+	defer askCancel()
+
+	askResult, err := session.CallTool(askCtx, &mcp.CallToolParams{
+		Name: "ask_syschecker",
+		Arguments: map[string]interface{}{
+			"question": "What is my system's hostname?",
+		},
+	})
+	if err != nil {
+		if askCtx.Err() == context.DeadlineExceeded {
+			fmt.Println("  ⚠️  Ask tool timed out (may need Neo4j to be running)")
+		} else {
+			fmt.Printf("  ❌ Ask tool failed: %v\n", err)
+		}
+	} else {
+		fmt.Println("  ✅ Ask tool called successfully")
+		if len(askResult.Content) > 0 {
+			fmt.Println("  ✅ Received answer:")
+			for _, content := range askResult.Content {
+				switch v := content.(type) {
+				case *mcp.TextContent:
+					fmt.Printf("    %s\n", v.Text)
+				default:
+					fmt.Printf("    [%T]\n", content)
+				}
+			}
+		}
+	}
+
+	// Test 3: get_historical_snapshots
+	fmt.Println("\n✓ Test 6: Testing get_historical_snapshots tool")
+	snapshotsResult, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "get_historical_snapshots",
+		Arguments: map[string]interface{}{
+			"limit": 5,
+		},
+	})
+	if err != nil {
+		fmt.Printf("  ⚠️  Snapshots tool failed (may be empty database): %v\n", err)
+	} else {
+		fmt.Println("  ✅ Snapshots tool called successfully")
+		if len(snapshotsResult.Content) > 0 {
+			fmt.Printf("  ✅ Received %d content items\n", len(snapshotsResult.Content))
+		}
+	}
+
+	fmt.Println("\n=======================================")
+	fmt.Println("✅ All MCP tool calling tests complete!")
+	fmt.Println("\n💡 To test interactively, run: syschecker chat")
+	return nil
+}
+
+// loadEnvFile sets the KEY=value lines of path in the environment. A
+// missing file is ignored.
+func loadEnvFile(path string) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			value = strings.Trim(value, `"'`)
+			os.Setenv(key, value)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package cli implements the syschecker subcommands shared by the root
+// command and the standalone binaries under cmd/.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Globals are the flags every subcommand honors. They are given before the
+// subcommand name: syschecker -db /data/s.db mcp serve.
+type Globals struct {
+	Config   string // JSON file of threshold, interval and ignore-rule overrides
+	DB       string // DuckDB file
+	LogLevel string // see LogLevels
+}
+
+// DefaultDB is the DuckDB file used when neither -db nor $DUCKDB_PATH is
+// given.
+const DefaultDB = "syschecker.db"
+
+// EnvDB names the environment variable -db defaults to.
+const EnvDB = "DUCKDB_PATH"
+
+// LogLevels lists the accepted -log-level values.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Register adds the global flags to fs. Fields already set are the
+// defaults, so a subcommand registering them again lets them follow its
+// name too: syschecker mcp serve -db /data/s.db.
+func (g *Globals) Register(fs *flag.FlagSet) {
+	if g.DB == "" {
+		g.DB = getenv(EnvDB, DefaultDB)
+	}
+	if g.LogLevel == "" {
+		g.LogLevel = "info"
+	}
+	fs.StringVar(&g.Config, "config", g.Config, "JSON file of threshold, interval and ignore-rule overrides; edits are applied without a restart")
+	fs.StringVar(&g.DB, "db", g.DB, "DuckDB file to store snapshots in (or $"+EnvDB+"); read-only viewers also take an s3:// or https:// URL of one")
+	fs.StringVar(&g.LogLevel, "log-level", g.LogLevel, "minimum level of log lines: "+strings.Join(LogLevels, ", "))
+}
+
+// Args renders g as flags, for starting another syschecker process with
+// the same globals.
+func (g Globals) Args() []string {
+	var args []string
+	if g.Config != "" {
+		args = append(args, "-config", g.Config)
+	}
+	if g.DB != "" {
+		args = append(args, "-db", g.DB)
+	}
+	if g.LogLevel != "" {
+		args = append(args, "-log-level", g.LogLevel)
+	}
+	return args
+}
+
+// SetLogLevel drops structured log lines below level. Lines written with
+// the log package are failures and always shown.
+func SetLogLevel(level string) error {
+	var l slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		l = slog.LevelDebug
+	case "info":
+		l = slog.LevelInfo
+	case "warn":
+		l = slog.LevelWarn
+	case "error":
+		l = slog.LevelError
+	default:
+		return fmt.Errorf("unknown log level %q (want one of %s)", level, strings.Join(LogLevels, ", "))
+	}
+	slog.SetLogLoggerLevel(l)
+	return nil
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// SelfCommand returns a command running this executable with g and args,
+// e.g. the MCP server for the client to talk to.
+func SelfCommand(g Globals, args ...string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate syschecker executable: %w", err)
+	}
+	return exec.Command(exe, append(g.Args(), args...)...), nil
+}
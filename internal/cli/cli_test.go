@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestGlobalsFollowSubcommand(t *testing.T) {
+	t.Setenv(EnvDB, "")
+	var g Globals
+	root := flag.NewFlagSet("syschecker", flag.ContinueOnError)
+	g.Register(root)
+	if err := root.Parse([]string{"-db", "root.db", "-log-level", "warn", "mcp"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A subcommand registering the globals again starts from the root's.
+	sub := g
+	fs := flag.NewFlagSet("mcp serve", flag.ContinueOnError)
+	sub.Register(fs)
+	if err := fs.Parse([]string{"-config", "c.json"}); err != nil {
+		t.Fatal(err)
+	}
+	want := Globals{Config: "c.json", DB: "root.db", LogLevel: "warn"}
+	if sub != want {
+		t.Errorf("got %+v, want %+v", sub, want)
+	}
+	if args := sub.Args(); !slices.Equal(args, []string{"-config", "c.json", "-db", "root.db", "-log-level", "warn"}) {
+		t.Errorf("Args() = %q", args)
+	}
+}
+
+func TestGlobalsDefaultDB(t *testing.T) {
+	t.Setenv(EnvDB, "/data/env.db")
+	var g Globals
+	g.Register(flag.NewFlagSet("syschecker", flag.ContinueOnError))
+	if g.DB != "/data/env.db" || g.LogLevel != "info" {
+		t.Errorf("got %+v", g)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	for _, l := range LogLevels {
+		if err := SetLogLevel(l); err != nil {
+			t.Errorf("SetLogLevel(%q): %v", l, err)
+		}
+	}
+	if err := SetLogLevel("verbose"); err == nil {
+		t.Error("unknown level accepted")
+	}
+	_ = SetLogLevel("info")
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"syschecker/internal/buildinfo"
+)
+
+// Client connects to the MCP server started by args and runs the
+// interactive client until /exit or end of input.
+func Client(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: client <server-command> [<args>], e.g. client ./syschecker mcp serve")
+	}
+	return repl(ctx, exec.Command(args[0], args[1:]...))
+}
+
+// Chat runs the interactive client against this executable's own MCP
+// server, started with g.
+func Chat(ctx context.Context, g Globals, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("chat takes no arguments, got %q", args)
+	}
+	cmd, err := SelfCommand(g, "mcp", "serve")
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = os.Stderr
+	return repl(ctx, cmd)
+}
+
+// repl starts server and answers commands and questions read from stdin.
+func repl(ctx context.Context, server *exec.Cmd) error {
+	transport := &mcp.CommandTransport{Command: server}
+
+	// Create MCP client
+	client := mcp.NewClient(&mcp.Implementation{
+		Name:    "syschecker-client",
+		Version: buildinfo.Get().Version,
+	}, nil)
+
+	// Connect to the server
+	session, err := client.Connect(ctx, transport, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer session.Close()
+
+	fmt.Println("Connected to SysChecker MCP Server!")
+	fmt.Println("Available commands:")
+	fmt.Println("  /tools        - List available tools")
+	fmt.Println("  /metrics      - Get fast realtime metrics")
+	fmt.Println("  /metrics-slow - Get detailed realtime metrics")
+	fmt.Println("  /metrics-all  - Get fast and cached detailed metrics together")
+	fmt.Println("  /history [hostname] [limit] - Get historical snapshots")
+	fmt.Println("  /graph <cypher> - Execute Cypher query")
+	fmt.Println("  /suggest      - Suggest questions for the current state")
+	fmt.Println("  /exit         - Exit the client")
+	fmt.Println("  <question>    - Ask a question using GraphRAG")
+	fmt.Println()
+	suggestQuestions(ctx, session)
+
+	// Interactive REPL
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			break
+		}
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			continue
+		}
+
+		switch {
+		case input == "/exit":
+			fmt.Println("Goodbye!")
+			return nil
+
+		case input == "/tools":
+			listTools(ctx, session)
+
+		case input == "/metrics":
+			callTool(ctx, session, "get_realtime_metrics", map[string]interface{}{
+				"metric_type": "fast",
+			})
+
+		case input == "/metrics-slow":
+			callTool(ctx, session, "get_realtime_metrics", map[string]interface{}{
+				"metric_type": "slow",
+			})
+
+		case input == "/metrics-all":
+			callTool(ctx, session, "get_realtime_metrics", map[string]interface{}{
+				"metric_type": "merged",
+			})
+
+		case strings.HasPrefix(input, "/history"):
+			parts := strings.Fields(input)
+			args := map[string]interface{}{}
+			if len(parts) > 1 {
+				args["hostname"] = parts[1]
+			}
+			if len(parts) > 2 {
+				args["limit"] = parts[2]
+			}
+			callTool(ctx, session, "get_historical_snapshots", args)
+
+		case input == "/suggest":
+			suggestQuestions(ctx, session)
+
+		case strings.HasPrefix(input, "/graph "):
+			cypher := strings.TrimPrefix(input, "/graph ")
+			callTool(ctx, session, "query_graph", map[string]interface{}{
+				"cypher": cypher,
+			})
+
+		default:
+			// Treat as a question for ask_syschecker
+			callTool(ctx, session, "ask_syschecker", map[string]interface{}{
+				"question": input,
+			})
+		}
+	}
+	return scanner.Err()
+}
+
+func listTools(ctx context.Context, session *mcp.ClientSession) {
+	fmt.Println("Available Tools:")
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			log.Printf("Error listing tools: %v", err)
+			return
+		}
+		fmt.Printf("  - %s: %s\n", tool.Name, tool.Description)
+	}
+	fmt.Println()
+}
+
+// suggestQuestions prints the questions suggested by the server for the
+// current flags and incidents. Failures are silent: suggestions are a
+// convenience and the server may predate the tool.
+func suggestQuestions(ctx context.Context, session *mcp.ClientSession) {
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "suggest_questions"})
+	if err != nil || result.IsError || len(result.Content) == 0 {
+		return
+	}
+	text, ok := result.Content[0].(*mcp.TextContent)
+	if !ok {
+		return
+	}
+	var suggested struct {
+		Questions []string `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(text.Text), &suggested); err != nil || len(suggested.Questions) == 0 {
+		return
+	}
+	fmt.Println("Try asking:")
+	for _, q := range suggested.Questions {
+		fmt.Printf("  %s\n", q)
+	}
+	fmt.Println()
+}
+
+func callTool(ctx context.Context, session *mcp.ClientSession, toolName string, args map[string]interface{}) {
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      toolName,
+		Arguments: args,
+	})
+	if err != nil {
+		log.Printf("Error calling tool: %v", err)
+		return
+	}
+
+	printResult(result)
+}
+
+func printResult(result *mcp.CallToolResult) {
+	if result.IsError {
+		fmt.Printf("❌ Error: ")
+	} else {
+		fmt.Printf("✅ Result: ")
+	}
+
+	// Try to pretty-print the content
+	for _, content := range result.Content {
+		switch v := content.(type) {
+		case *mcp.TextContent:
+			fmt.Println(v.Text)
+		default:
+			// Try JSON marshaling for other types
+			jsonData, err := json.MarshalIndent(content, "", "  ")
+			if err != nil {
+				fmt.Printf("%+v\n", content)
+			} else {
+				fmt.Println(string(jsonData))
+			}
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"syschecker/internal/actions"
+	"syschecker/internal/alert"
+	"syschecker/internal/buildinfo"
+	"syschecker/internal/collector"
+	"syschecker/internal/config"
+	"syschecker/internal/database"
+	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/debugserver"
+	"syschecker/internal/flagger"
+	"syschecker/internal/health"
+	"syschecker/internal/i18n"
+	"syschecker/internal/mcpserver"
+	"syschecker/internal/schedule"
+	"syschecker/internal/slo"
+	"syschecker/internal/units"
+)
+
+// defaultAgentID is the hostname, the ID the agent collects under.
+func defaultAgentID() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "mcp-server"
+}
+
+// ServeMCP runs the MCP server over stdio until ctx is done.
+//
+// Beyond the flags, configuration comes from the environment:
+// GEMINI_API_KEY (required), GEMINI_MODEL, NEO4J_URI, NEO4J_USER,
+// NEO4J_PASSWORD, NEO4J_DATABASE, NEO4J_BEARER_TOKEN,
+// NEO4J_KERBEROS_TICKET, NEO4J_CA_CERT, SYSCHECKER_HOST_ROOT and
+// SYSCHECKER_TOPOLOGY. A -db URL is served read-only, with the
+// credentials of relational.RemoteSourceFromEnv.
+func ServeMCP(ctx context.Context, g Globals, args []string) error {
+	fs := flag.NewFlagSet("mcp serve", flag.ExitOnError)
+	g.Register(fs)
+	debugAddr := fs.String("debug-addr", "", "serve pprof and runtime stats on this address (or $"+debugserver.EnvAddr+")")
+	hostRoot := fs.String("host-root", os.Getenv(collector.EnvHostRoot), "collect from a host filesystem mounted at this path (or $"+collector.EnvHostRoot+")")
+	topologyFile := fs.String("topology", os.Getenv(graph.EnvTopology), "JSON file declaring service dependencies between hosts (or $"+graph.EnvTopology+")")
+	lang := fs.String("lang", i18n.FromEnv(), "language of flag explanations and answers: "+strings.Join(i18n.Supported(), ", ")+" (or $"+i18n.EnvLang+")")
+	graphBufferPath := fs.String("graph-buffer", "", `JSONL file buffering snapshots while Neo4j is unreachable, replayed once it is back (default: -db path + ".graph-buffer"; "off" disables)`)
+	graphBufferMax := fs.String("graph-buffer-max-size", "64MiB", "cap on -graph-buffer; once full, further snapshots are dropped until Neo4j is back")
+	graphIngestBatch := fs.Int("graph-ingest-batch", 16, "write up to this many queued snapshots, of any hosts, to Neo4j in one transaction; 1 writes each on its own")
+	agentID := fs.String("agent-id", defaultAgentID(), "host ID snapshots are stored under; matching the agent collecting this host into the same database lets only one of the two ingest")
+	admin := fs.Bool("admin", false, "register the set_collection_interval, toggle_sensor and set_threshold tools, and edit_thresholds with -config, so clients can tune the running system")
+	allowActions := fs.String("allow-actions", "", "comma-separated remediation kinds the execute_action tool may run ("+strings.Join(actions.Kinds, ", ")+"); empty leaves the tool off")
+	actionAudit := fs.String("action-audit", "", `JSONL file recording every execute_action attempt (default: -db path + ".actions.jsonl")`)
+	digestPeriod := fs.Duration("digest", 0, "write a health digest of the stored rollups and incidents this often, e.g. 168h for weekly, kept as a digest artifact; 0 disables")
+	shutdownTimeout := fs.Duration("shutdown-timeout", mcpserver.DefaultShutdownTimeout, "on SIGTERM or Ctrl+C, how long tool calls in flight may finish, and then how long queued graph ingests may flush")
+	digestRoutes := fs.String("digest-routes", "", `alert routing JSON file whose Slack, webhook and email targets also receive each -digest; route them with "flags": ["digest"]`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLogLevel(g.LogLevel); err != nil {
+		return err
+	}
+
+	// stdout carries the MCP protocol; keep logs on stderr.
+	log.SetOutput(os.Stderr)
+
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return errors.New("GEMINI_API_KEY is required")
+	}
+
+	defaultProfile := schedule.Profile{
+		Name: schedule.Default, Description: "normal collection", Fast: time.Second, Slow: 30 * time.Second,
+	}
+	remote := relational.IsRemote(g.DB)
+	if remote && *digestPeriod > 0 {
+		return errors.New("-digest stores digests in -db and cannot be used with a remote database")
+	}
+	if remote && *graphBufferPath == "" {
+		*graphBufferPath = "off" // nothing is ingested
+	}
+	if remote && *allowActions != "" && *actionAudit == "" {
+		return errors.New("-allow-actions with a remote -db needs an explicit -action-audit file")
+	}
+
+	scheduler := schedule.NewScheduler(schedule.WithProfiles(defaultProfile))
+	flaggerCfg := flagger.DefaultConfig().WithLocale(*lang)
+	flaggerSvc := flagger.NewFlaggerService(flaggerCfg)
+	slos := slo.NewTracker()
+	if g.Config != "" {
+		file, err := config.Load(g.Config)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		target := config.Target{Flagger: flaggerSvc, BaseConfig: flaggerCfg, Scheduler: scheduler, BaseProfile: defaultProfile, SLOs: slos}
+		target.Apply(file)
+		go config.Watch(ctx, g.Config, 2*time.Second, target)
+	}
+	var graphBuffer *database.GraphBuffer
+	if *graphBufferPath != "off" {
+		path := *graphBufferPath
+		if path == "" {
+			path = g.DB + ".graph-buffer"
+		}
+		maxBytes, err := units.ParseBytes(*graphBufferMax)
+		if err != nil || maxBytes <= 0 {
+			return fmt.Errorf("invalid -graph-buffer-max-size %q", *graphBufferMax)
+		}
+		b, err := database.OpenGraphBuffer(path, maxBytes)
+		if err != nil {
+			return fmt.Errorf("invalid -graph-buffer: %w", err)
+		}
+		if n := b.Backlog(); n > 0 {
+			slog.Info("Buffered snapshots will be replayed into Neo4j", "count", n, "path", path)
+		}
+		graphBuffer = b
+	}
+
+	var dbClient *relational.DuckDBClient
+	var err error
+	if remote {
+		dbClient, err = relational.OpenRemote(relational.RemoteSourceFromEnv(g.DB))
+	} else {
+		dbClient, err = relational.NewDuckDBClient(g.DB)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open DuckDB: %w", err)
+	}
+	defer dbClient.Close()
+
+	repo := relational.NewRepo(dbClient.DB())
+	// A remote database is read-only and left at whatever version wrote it.
+	if !remote {
+		if err := repo.Migrate(ctx); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	collectorCfg := collector.DefaultCollectorConfig()
+	if *hostRoot != "" {
+		if err := collector.UseHostRoot(*hostRoot); err != nil {
+			return fmt.Errorf("failed to use host root: %w", err)
+		}
+		collectorCfg = collectorCfg.WithHostRoot(*hostRoot)
+	}
+
+	var topology *graph.Topology
+	if *topologyFile != "" {
+		if topology, err = graph.LoadTopology(*topologyFile); err != nil {
+			return fmt.Errorf("failed to load topology: %w", err)
+		}
+	}
+
+	// Expensive and state-changing tools need a role: set $SYSCHECKER_MCP_ROLE
+	// for a trusted local client, or hand out tokens via $SYSCHECKER_MCP_TOKENS.
+	access, err := mcpserver.AccessFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid access config: %w", err)
+	}
+	slog.Info("Callers without a token get the default role", "role", access.DefaultRole)
+
+	cfg := mcpserver.Config{
+		ServerName:    "syschecker",
+		ServerVersion: buildinfo.Get().Version,
+		GeminiAPIKey:  apiKey,
+		GeminiModel:   os.Getenv("GEMINI_MODEL"),
+		Neo4jURI:      getenv("NEO4J_URI", "bolt://localhost:7687"),
+		Neo4jUser:     getenv("NEO4J_USER", "neo4j"),
+		Neo4jPassword: getenv("NEO4J_PASSWORD", "password"),
+		Neo4jDatabase: getenv("NEO4J_DATABASE", "neo4j"),
+		Neo4jOptions:  graph.Neo4jOptionsFromEnv(),
+		Topology:      topology,
+		Language:      *lang,
+		Scheduler:     scheduler,
+		Flagger:       flaggerSvc,
+		Admin:         *admin,
+		Access:        access,
+		AgentID:       *agentID,
+		GraphBuffer:   graphBuffer,
+		SLOs:          slos,
+		DigestPeriod:  *digestPeriod,
+		ConfigPath:    g.Config,
+
+		ShutdownTimeout:  *shutdownTimeout,
+		GraphIngestBatch: *graphIngestBatch,
+		ReadOnly:         remote,
+	}
+	if *digestRoutes != "" {
+		router, err := alert.LoadRouter(*digestRoutes)
+		if err != nil {
+			return fmt.Errorf("invalid -digest-routes: %w", err)
+		}
+		notifier := alert.NewRoutedNotifier(router)
+		defer notifier.Close()
+		cfg.DigestDelivery = notifier
+	}
+	if *allowActions != "" {
+		path := *actionAudit
+		if path == "" {
+			path = g.DB + ".actions.jsonl"
+		}
+		audit, err := actions.OpenFileAuditor(path)
+		if err != nil {
+			return err
+		}
+		defer audit.Close()
+		if cfg.Actions, err = actions.NewExecutor(strings.Split(*allowActions, ","), audit); err != nil {
+			return fmt.Errorf("invalid -allow-actions: %w", err)
+		}
+	}
+
+	slog.Info("Starting SysChecker MCP Server", "version", buildinfo.Get(), "db", g.DB)
+	server, err := mcpserver.NewServer(cfg, repo, collector.NewSystemCollectorWithConfig(collectorCfg))
+	if err != nil {
+		return fmt.Errorf("failed to create MCP server: %w", err)
+	}
+	// Runs before the deferred closes above, so the repo, notifier and
+	// audit log outlive the jobs and flushes using them.
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		if err := server.Close(closeCtx); err != nil {
+			slog.Warn("MCP server did not shut down cleanly", "error", err)
+		}
+		slog.Info("MCP server stopped")
+	}()
+
+	if _, err := debugserver.Start(debugserver.Addr(*debugAddr),
+		debugserver.Route{Pattern: "/api/profile", Handler: schedule.Handler(scheduler)},
+		debugserver.Route{Pattern: "/api/v1/graph-ingest", Handler: database.GraphIngestHandler(server.GraphIngest())},
+		debugserver.Route{Pattern: "/api/v1/dependencies", Handler: health.Handler(server.Dependencies())}); err != nil {
+		return fmt.Errorf("failed to start debug server: %w", err)
+	}
+
+	if err := server.Start(ctx); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("MCP server error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeAdvance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	f.Advance(90 * time.Minute)
+	if got := f.Now(); !got.Equal(start.Add(90 * time.Minute)) {
+		t.Errorf("Now() = %v", got)
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	tk := f.NewTicker(time.Minute)
+
+	f.Advance(30 * time.Second)
+	select {
+	case <-tk.C():
+		t.Fatal("ticker fired early")
+	default:
+	}
+
+	f.Advance(30 * time.Second)
+	select {
+	case at := <-tk.C():
+		if !at.Equal(time.Unix(60, 0)) {
+			t.Errorf("tick at %v, want 60s", at)
+		}
+	default:
+		t.Fatal("ticker did not fire")
+	}
+
+	// Undrained ticks are dropped rather than queued.
+	f.Advance(10 * time.Minute)
+	<-tk.C()
+	select {
+	case <-tk.C():
+		t.Fatal("expected missed ticks to be dropped")
+	default:
+	}
+
+	tk.Stop()
+	f.Advance(time.Hour)
+	select {
+	case <-tk.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
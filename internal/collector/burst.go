@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	gnet "github.com/shirou/gopsutil/v4/net"
+
+	"syschecker/internal/collector/services"
+)
+
+// BurstDetail is the extended detail captured while a burst is active:
+// every process rather than the top N, and every inet socket.
+type BurstDetail struct {
+	Processes   []ProcessStat
+	Connections []ConnStat
+}
+
+// ConnStat is one socket and its owning process.
+type ConnStat struct {
+	Proto      string // tcp, tcp6, udp, udp6
+	LocalAddr  string
+	RemoteAddr string // empty for listening and unconnected sockets
+	Status     string
+	PID        int32
+}
+
+// GetBurstDetail collects the full process list and per-connection table.
+// It is too expensive for every snapshot and is only called during bursts.
+func (s *SystemCollector) GetBurstDetail(ctx context.Context) (*BurstDetail, error) {
+	res, err := s.fullProcessSensor.Collect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	procs := res.(services.ProcessResult).Processes
+
+	detail := &BurstDetail{Processes: make([]ProcessStat, 0, len(procs))}
+	for _, p := range procs {
+		detail.Processes = append(detail.Processes, ProcessStat{
+			PID:    p.PID,
+			Name:   p.Name,
+			User:   p.User,
+			CPU:    p.CPU,
+			Memory: p.Memory,
+		})
+	}
+
+	conns, err := gnet.ConnectionsWithContext(ctx, "inet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	detail.Connections = make([]ConnStat, 0, len(conns))
+	for _, c := range conns {
+		detail.Connections = append(detail.Connections, ConnStat{
+			Proto:      connProto(c.Family, c.Type),
+			LocalAddr:  joinAddr(c.Laddr),
+			RemoteAddr: joinAddr(c.Raddr),
+			Status:     c.Status,
+			PID:        c.Pid,
+		})
+	}
+	return detail, nil
+}
+
+// connProto names a socket from its address family and type.
+func connProto(family, sockType uint32) string {
+	proto := "tcp"
+	if sockType == 2 { // SOCK_DGRAM
+		proto = "udp"
+	}
+	if family == 10 || family == 30 { // AF_INET6 on Linux, macOS
+		proto += "6"
+	}
+	return proto
+}
+
+func joinAddr(a gnet.Addr) string {
+	if a.IP == "" && a.Port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(a.IP, strconv.Itoa(int(a.Port)))
+}
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"syschecker/internal/collector/services"
+)
+
+// Defaults for checks loaded from a file.
+const (
+	defaultCheckTimeout  = 10 * time.Second
+	defaultCheckSeverity = 2
+)
+
+// checkDef is the JSON form of a check.
+type checkDef struct {
+	Name         string   `json:"name"`
+	Command      []string `json:"command"`
+	ExpectedExit int      `json:"expected_exit"`
+	Timeout      string   `json:"timeout"`  // Go duration; default 10s
+	Severity     int      `json:"severity"` // 1 warning, 2 high, 3 critical; default 2
+}
+
+// LoadChecks reads user-defined checks from a JSON array such as
+//
+//	[{"name": "nginx", "command": ["curl", "-sf", "http://localhost/"], "timeout": "5s"}]
+//
+// Commands run directly, not through a shell; use ["sh", "-c", "..."] for pipelines.
+func LoadChecks(file string) ([]services.CheckSpec, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read checks: %w", err)
+	}
+	var defs []checkDef
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("parse checks %s: %w", file, err)
+	}
+	checks := make([]services.CheckSpec, len(defs))
+	for i, d := range defs {
+		c := services.CheckSpec{
+			Name:         d.Name,
+			Command:      d.Command,
+			ExpectedExit: d.ExpectedExit,
+			Timeout:      defaultCheckTimeout,
+			Severity:     defaultCheckSeverity,
+		}
+		if d.Timeout != "" {
+			if c.Timeout, err = time.ParseDuration(d.Timeout); err != nil {
+				return nil, fmt.Errorf("check %q: invalid timeout: %w", d.Name, err)
+			}
+		}
+		if d.Severity != 0 {
+			if d.Severity < 1 || d.Severity > 3 {
+				return nil, fmt.Errorf("check %q: severity must be 1, 2 or 3", d.Name)
+			}
+			c.Severity = d.Severity
+		}
+		checks[i] = c
+	}
+	return checks, nil
+}
@@ -1,6 +1,11 @@
 package collector
 
-import "time"
+import (
+	"path/filepath"
+	"time"
+
+	"syschecker/internal/collector/services"
+)
 
 // CollectorConfig contains configurable parameters for the system collector.
 // Use DefaultCollectorConfig() to get sensible defaults, then override as needed.
@@ -13,6 +18,11 @@ type CollectorConfig struct {
 	NetworkCheckEndpoint string        // Endpoint for network latency check (default: "8.8.8.8:53")
 	NetworkCheckTimeout  time.Duration // Timeout for network check (default: 3s)
 
+	// Background prober (probes NetworkCheckEndpoint continuously)
+	EnableProber  bool          // Whether to run the prober (default: false)
+	ProbeInterval time.Duration // Time between probes (default: 1s)
+	ProbeWindow   time.Duration // Window for latency, jitter and loss (default: 1m)
+
 	// Polling intervals (for workers/TUI)
 	FastPollInterval time.Duration // How often to poll fast metrics (default: 1s)
 	SlowPollInterval time.Duration // How often to poll slow metrics (default: 30s)
@@ -22,6 +32,25 @@ type CollectorConfig struct {
 	MaxConsoleLogs     int // Maximum console log entries to retain (default: 100)
 	CPUHistoryCapacity int // Capacity for CPU history buffer (default: 31)
 
+	// Process detail
+	ProcessDetailCount int      // Top processes whose cmdline and cgroup are captured (default: 0, disabled)
+	RedactPatterns     []string // Regexes scrubbed from captured command lines (default: services.DefaultRedactPatterns)
+
+	// Log growth watcher
+	LogWatchDirs   []string // Directories watched for fast-growing files (default: /var/log)
+	LogGrowerCount int      // Number of top growing files reported per snapshot (default: 5)
+
+	// Temp and core dump accounting
+	TempDirs       []string      // Temp directories measured on the slow path (default: /tmp, /var/tmp)
+	TempStaleAge   time.Duration // Files untouched for longer count as stale (default: 7 days)
+	TempMaxEntries int           // Entries visited per location before giving up (default: 100000)
+
+	// User-defined checks run on the slow path
+	Checks []services.CheckSpec // (default: none)
+
+	// Host-mount mode
+	HostRoot string // Host filesystem mount when running in a container (default: "", collect locally)
+
 	// Feature flags
 	EnableDockerMetrics  bool // Whether to collect Docker metrics (default: true)
 	EnableDiskHealth     bool // Whether to collect disk health via smartctl (default: true)
@@ -39,6 +68,8 @@ func DefaultCollectorConfig() CollectorConfig {
 		// Network
 		NetworkCheckEndpoint: "8.8.8.8:53",
 		NetworkCheckTimeout:  3 * time.Second,
+		ProbeInterval:        time.Second,
+		ProbeWindow:          time.Minute,
 
 		// Polling
 		FastPollInterval: 1 * time.Second,
@@ -49,6 +80,18 @@ func DefaultCollectorConfig() CollectorConfig {
 		MaxConsoleLogs:     100,
 		CPUHistoryCapacity: 31,
 
+		// Process detail
+		RedactPatterns: append([]string(nil), services.DefaultRedactPatterns...),
+
+		// Log growth
+		LogWatchDirs:   []string{"/var/log"},
+		LogGrowerCount: 5,
+
+		// Temp data
+		TempDirs:       []string{"/tmp", "/var/tmp"},
+		TempStaleAge:   7 * 24 * time.Hour,
+		TempMaxEntries: 100_000,
+
 		// Features (all enabled by default)
 		EnableDockerMetrics:  true,
 		EnableDiskHealth:     true,
@@ -87,6 +130,12 @@ func (c CollectorConfig) WithSlowPollInterval(d time.Duration) CollectorConfig {
 	return c
 }
 
+// WithProber returns a copy of the config with the background prober enabled/disabled.
+func (c CollectorConfig) WithProber(enabled bool) CollectorConfig {
+	c.EnableProber = enabled
+	return c
+}
+
 // WithDockerMetrics returns a copy of the config with Docker metrics enabled/disabled.
 func (c CollectorConfig) WithDockerMetrics(enabled bool) CollectorConfig {
 	c.EnableDockerMetrics = enabled
@@ -99,6 +148,45 @@ func (c CollectorConfig) WithDiskHealth(enabled bool) CollectorConfig {
 	return c
 }
 
+// WithLogWatchDirs returns a copy of the config watching the given directories for log growth.
+func (c CollectorConfig) WithLogWatchDirs(dirs ...string) CollectorConfig {
+	c.LogWatchDirs = append([]string(nil), dirs...)
+	return c
+}
+
+// WithProcessDetail returns a copy of the config capturing the command line
+// and cgroup of the top n processes. Extra patterns are redacted in addition
+// to the configured ones.
+func (c CollectorConfig) WithProcessDetail(n int, patterns ...string) CollectorConfig {
+	c.ProcessDetailCount = n
+	c.RedactPatterns = append(append([]string(nil), c.RedactPatterns...), patterns...)
+	return c
+}
+
+// WithChecks returns a copy of the config running the given checks on the slow path.
+func (c CollectorConfig) WithChecks(checks ...services.CheckSpec) CollectorConfig {
+	c.Checks = append([]services.CheckSpec(nil), checks...)
+	return c
+}
+
+// WithHostRoot returns a copy of the config that collects from a host
+// filesystem mounted at root. Watched log and temp directories are resolved
+// under it; call UseHostRoot to redirect gopsutil as well.
+func (c CollectorConfig) WithHostRoot(root string) CollectorConfig {
+	c.HostRoot = root
+	c.LogWatchDirs = underRoot(root, c.LogWatchDirs)
+	c.TempDirs = underRoot(root, c.TempDirs)
+	return c
+}
+
+func underRoot(root string, dirs []string) []string {
+	out := make([]string, len(dirs))
+	for i, d := range dirs {
+		out[i] = filepath.Join(root, d)
+	}
+	return out
+}
+
 // Validate checks if the configuration is valid and returns an error if not.
 func (c CollectorConfig) Validate() error {
 	if c.FastMetricsTimeout <= 0 {
@@ -113,6 +201,30 @@ func (c CollectorConfig) Validate() error {
 	if c.TopProcessCount <= 0 {
 		return &ConfigError{Field: "TopProcessCount", Message: "must be positive"}
 	}
+	if c.LogGrowerCount < 0 {
+		return &ConfigError{Field: "LogGrowerCount", Message: "must not be negative"}
+	}
+	seen := make(map[string]bool, len(c.Checks))
+	for _, chk := range c.Checks {
+		switch {
+		case chk.Name == "" || seen[chk.Name]:
+			return &ConfigError{Field: "Checks", Message: "names must be unique and not empty"}
+		case len(chk.Command) == 0:
+			return &ConfigError{Field: "Checks", Message: "check " + chk.Name + " has no command"}
+		case chk.Timeout <= 0 || chk.Timeout > c.SlowMetricsTimeout:
+			return &ConfigError{Field: "Checks", Message: "check " + chk.Name + " timeout must be positive and within SlowMetricsTimeout"}
+		}
+		seen[chk.Name] = true
+	}
+	if c.EnableProber && (c.ProbeInterval <= 0 || c.ProbeWindow < c.ProbeInterval) {
+		return &ConfigError{Field: "ProbeWindow", Message: "must be at least one positive ProbeInterval"}
+	}
+	if c.ProcessDetailCount < 0 {
+		return &ConfigError{Field: "ProcessDetailCount", Message: "must not be negative"}
+	}
+	if _, err := services.NewRedactor(c.RedactPatterns); err != nil {
+		return &ConfigError{Field: "RedactPatterns", Message: err.Error()}
+	}
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -40,6 +42,14 @@ func TestDefaultCollectorConfig(t *testing.T) {
 		t.Errorf("Expected CPUHistoryCapacity 31, got %d", cfg.CPUHistoryCapacity)
 	}
 
+	// Check log growth defaults
+	if len(cfg.LogWatchDirs) != 1 || cfg.LogWatchDirs[0] != "/var/log" {
+		t.Errorf("Expected LogWatchDirs [/var/log], got %v", cfg.LogWatchDirs)
+	}
+	if cfg.LogGrowerCount != 5 {
+		t.Errorf("Expected LogGrowerCount 5, got %d", cfg.LogGrowerCount)
+	}
+
 	// Check feature flags
 	if !cfg.EnableDockerMetrics {
 		t.Error("Expected EnableDockerMetrics to be true by default")
@@ -154,6 +164,15 @@ func TestCollectorConfig_WithMethods(t *testing.T) {
 	if newCfg.EnableDiskHealth {
 		t.Error("WithDiskHealth(false) failed")
 	}
+
+	// Test WithLogWatchDirs
+	newCfg = cfg.WithLogWatchDirs("/srv/logs", "/opt/app/log")
+	if len(newCfg.LogWatchDirs) != 2 || newCfg.LogWatchDirs[0] != "/srv/logs" {
+		t.Errorf("WithLogWatchDirs failed, got %v", newCfg.LogWatchDirs)
+	}
+	if len(cfg.LogWatchDirs) != 1 {
+		t.Error("WithLogWatchDirs mutated original config")
+	}
 }
 
 func TestConfigError(t *testing.T) {
@@ -194,3 +213,68 @@ func TestCollectorConfig_Chaining(t *testing.T) {
 		t.Errorf("Chained config should be valid, got error: %v", err)
 	}
 }
+
+func TestCollectorConfig_WithHostRoot(t *testing.T) {
+	cfg := DefaultCollectorConfig().WithHostRoot("/host")
+
+	if cfg.HostRoot != "/host" {
+		t.Errorf("Expected HostRoot /host, got %q", cfg.HostRoot)
+	}
+	if len(cfg.LogWatchDirs) != 1 || cfg.LogWatchDirs[0] != "/host/var/log" {
+		t.Errorf("Expected LogWatchDirs under host root, got %v", cfg.LogWatchDirs)
+	}
+	if len(cfg.TempDirs) != 2 || cfg.TempDirs[0] != "/host/tmp" || cfg.TempDirs[1] != "/host/var/tmp" {
+		t.Errorf("Expected TempDirs under host root, got %v", cfg.TempDirs)
+	}
+}
+
+func TestCollectorConfig_WithProcessDetail(t *testing.T) {
+	base := DefaultCollectorConfig()
+	cfg := base.WithProcessDetail(5, `(LICENSE=)\w+`)
+
+	if cfg.ProcessDetailCount != 5 {
+		t.Errorf("Expected ProcessDetailCount 5, got %d", cfg.ProcessDetailCount)
+	}
+	if len(cfg.RedactPatterns) != len(base.RedactPatterns)+1 {
+		t.Errorf("Expected default patterns plus one, got %d", len(cfg.RedactPatterns))
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	if err := base.WithProcessDetail(1, "(").Validate(); err == nil {
+		t.Error("Expected error for invalid redact pattern")
+	}
+	if err := base.WithProcessDetail(-1).Validate(); err == nil {
+		t.Error("Expected error for negative ProcessDetailCount")
+	}
+}
+
+func TestLoadChecks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checks.json")
+	data := `[
+		{"name": "nginx", "command": ["curl", "-sf", "http://localhost/"], "timeout": "5s"},
+		{"name": "backup", "command": ["test", "-f", "/backup/ok"], "expected_exit": 0, "severity": 3}
+	]`
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checks, err := LoadChecks(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 2 || checks[0].Timeout != 5*time.Second || checks[1].Timeout != defaultCheckTimeout {
+		t.Fatalf("checks = %+v", checks)
+	}
+	if checks[0].Severity != defaultCheckSeverity || checks[1].Severity != 3 {
+		t.Errorf("severities = %d, %d", checks[0].Severity, checks[1].Severity)
+	}
+	if err := DefaultCollectorConfig().WithChecks(checks...).Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	dup := DefaultCollectorConfig().WithChecks(checks[0], checks[0])
+	if err := dup.Validate(); err == nil {
+		t.Error("Expected error for duplicate check names")
+	}
+}
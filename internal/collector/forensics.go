@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"context"
+
+	"syschecker/internal/collector/services"
+)
+
+// ForensicCapture is an extended one-off snapshot for incident analysis.
+type ForensicCapture = services.ForensicsResult
+
+// ListeningPort is a socket accepting connections or datagrams.
+type ListeningPort = services.ListeningPort
+
+// forensicLogLines is how much of the kernel log a capture keeps.
+const forensicLogLines = 200
+
+// CaptureForensics collects the full process tree with command lines,
+// listening ports, the kernel log tail, and `docker inspect` of the given
+// containers.
+func CaptureForensics(ctx context.Context, containers []string) (*ForensicCapture, error) {
+	res, err := services.NewForensicsSensor(containers, forensicLogLines).Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	capture := res.(services.ForensicsResult)
+	return &capture, nil
+}
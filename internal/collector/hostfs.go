@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvHostRoot names the environment variable that switches the agent into
+// host-mount mode, e.g. SYSCHECKER_HOST_ROOT=/host with / mounted there.
+const EnvHostRoot = "SYSCHECKER_HOST_ROOT"
+
+// hostEnv maps gopsutil's HOST_* variables to their location under the host root.
+var hostEnv = []struct{ key, dir string }{
+	{"HOST_PROC", "proc"},
+	{"HOST_SYS", "sys"},
+	{"HOST_ETC", "etc"},
+	{"HOST_VAR", "var"},
+	{"HOST_RUN", "run"},
+	{"HOST_DEV", "dev"},
+}
+
+// UseHostRoot points gopsutil and the sensors at a host filesystem mounted
+// at root, so a containerized agent reports on the host rather than on its
+// own container. Variables that are already set are left alone, allowing
+// individual mounts to live elsewhere. It must be called before collection
+// starts.
+func UseHostRoot(root string) error {
+	if root == "" || filepath.Clean(root) == "/" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(root, "proc")); err != nil {
+		return fmt.Errorf("host root %s: %w", root, err)
+	}
+	for _, e := range append(hostEnv, struct{ key, dir string }{"HOST_ROOT", ""}) {
+		if os.Getenv(e.key) != "" {
+			continue
+		}
+		if err := os.Setenv(e.key, filepath.Join(root, e.dir)); err != nil {
+			return fmt.Errorf("set %s: %w", e.key, err)
+		}
+	}
+	return nil
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"net"
 	"os/exec"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"syschecker/internal/chaos"
 	"syschecker/internal/collector/services"
 
 	"github.com/shirou/gopsutil/v4/disk"
@@ -31,25 +33,26 @@ type RawStats struct {
 	CPUModel   string    // CPU model name
 	CPUCores   int       // Number of logical CPU cores
 
-	// RAM Metrics
-	RAMUsage     float64
-	RAMAvailable uint64
-	RAMUsed      uint64
-	RAMFree      uint64
-	RAMCached    uint64
-	RAMBuffered  uint64
-	TotalRAM_GB  uint64
+	// RAM Metrics, in bytes; format them with the units package
+	RAMUsage          float64
+	RAMAvailableBytes uint64
+	RAMUsedBytes      uint64
+	RAMFreeBytes      uint64
+	RAMCachedBytes    uint64
+	RAMBufferedBytes  uint64
+	RAMTotalBytes     uint64
 
 	// Swap Metrics
-	SwapUsage float64
-	SwapTotal uint64
-	SwapUsed  uint64
+	SwapUsage      float64
+	SwapTotalBytes uint64
+	SwapUsedBytes  uint64
+	SwapInBytes    uint64 // cumulative bytes swapped in since boot
 
 	// Disk Metrics
-	DiskUsage    float64
-	TotalDisk_GB uint64
-	InodeUsage   float64
-	TotalInodes  uint64
+	DiskUsage      float64
+	DiskTotalBytes uint64
+	InodeUsage     float64
+	TotalInodes    uint64
 
 	// Disk Details
 	Partitions []PartitionUsage
@@ -59,6 +62,11 @@ type RawStats struct {
 	// Network Metrics
 	NetLatency_ms float64
 	IsConnected   bool
+	NetJitter_ms  float64 // from the background prober, when enabled
+	NetLoss_pct   float64
+	NetProbes     int     // probes behind the jitter and loss figures; 0 without the prober
+	VPNInterface  string  // active tunnel, if any
+	NetDirect_ms  float64 // latency via the physical interface, bypassing the VPN; 0 when unknown
 	NetInterfaces []NetInterfaceStats
 	ActiveTCP     int
 
@@ -74,11 +82,52 @@ type RawStats struct {
 	Uptime        uint64
 	Procs         uint64
 
+	// Cgroup limits when syschecker runs in a container. When set, CPU and
+	// RAM usage above are relative to these limits rather than the host.
+	Containerized       bool
+	CgroupMemLimitBytes uint64  // 0 when unlimited
+	CgroupCPULimit      float64 // cores; 0 when unlimited
+
+	// Environment profile: bare-metal, vm, wsl or cloud
+	Environment   string
+	Hypervisor    string
+	CloudProvider string
+	LatencyNAT    bool // latency includes a host NAT hop (WSL2)
+
 	// Physical Metrics
 	Temperatures []TemperatureStat
 
+	// SoC power and cluster utilization (macOS powermetrics)
+	CPUEClusterPct  float64 // efficiency cores active residency
+	CPUPClusterPct  float64 // performance cores active residency
+	PowerCPUWatts   float64
+	PowerGPUWatts   float64
+	PowerANEWatts   float64
+	PowerTotalWatts float64
+	ThermalPressure string // Nominal, Moderate, Heavy, Trapping or Sleeping
+
+	// Raspberry Pi firmware state (get_throttled bits, see services.Throttle*)
+	ThrottledBits uint32
+	CoreVolts     float64
+
+	// Discrete and integrated GPUs (nvidia-smi, amdgpu sysfs, IOAccelerator)
+	GPUs []GPUStat
+
 	// Process Metrics
 	TopProcesses []ProcessStat
+	UserUsage    []UserUsage // per-UID totals across all processes
+
+	// Kernel events since the previous collection
+	OOMKills []OOMKill
+
+	// Fastest growing files under the watched log directories
+	LogGrowers []FileGrowth
+
+	// Temp directories and core dump locations
+	TempUsage []TempUsage
+
+	// User-defined checks
+	Checks []CheckResult
 }
 
 type DockerContainerInfo struct {
@@ -98,11 +147,76 @@ type TemperatureStat struct {
 	Temperature float64
 }
 
+// GPUStat is one GPU's load. Readings its vendor does not expose are 0.
+type GPUStat struct {
+	Index         int
+	Vendor        string // nvidia, amd or apple
+	Name          string
+	UtilPct       float64
+	MemUsedBytes  uint64
+	MemTotalBytes uint64 // 0 for unified memory
+	TemperatureC  float64
+	PowerWatts    float64
+}
+
 type ProcessStat struct {
 	PID    int32
 	Name   string
+	User   string
 	CPU    float64
 	Memory float32
+
+	Cmdline     string // redacted; only set for the top processes when detail capture is on
+	Cgroup      string
+	ContainerID string // owning container, matching DockerContainerInfo.ID
+}
+
+// FileGrowth is a file that grew since the previous collection.
+type FileGrowth struct {
+	Path      string
+	SizeBytes uint64
+	GrowthBps float64
+}
+
+// TempUsage summarises a temp or core-dump location.
+type TempUsage struct {
+	Path       string
+	Kind       string // "temp" or "core"
+	Files      int
+	TotalBytes uint64
+	StaleBytes uint64
+}
+
+// CheckResult is the outcome of a user-defined check.
+type CheckResult struct {
+	Name       string
+	OK         bool
+	ExitCode   int // -1 when the command could not run or timed out
+	DurationMS float64
+	Value      *float64 // first output line, when numeric
+	Output     string
+	Severity   int
+}
+
+// OOMKill is a process terminated by the kernel OOM killer.
+type OOMKill struct {
+	At           time.Time
+	PID          int32
+	Process      string
+	Cgroup       string
+	UID          int32
+	AnonRSSBytes uint64
+	Constraint   string
+}
+
+// UserUsage aggregates the processes owned by one UID.
+type UserUsage struct {
+	UID       int32
+	User      string
+	Processes int
+	CPU       float64 // sum of per-process CPU percent (100 = one core)
+	Memory    float64 // percent of total RAM
+	RSSBytes  uint64
 }
 
 type NetInterfaceStats struct {
@@ -115,6 +229,13 @@ type NetInterfaceStats struct {
 	ErrOut      uint64
 	DropIn      uint64
 	DropOut     uint64
+
+	// Link state; speeds are 0 when unknown
+	OperState      string
+	SpeedMbps      int
+	MaxSpeedMbps   int
+	Duplex         string
+	CarrierChanges uint64
 }
 
 type PartitionUsage struct {
@@ -122,7 +243,7 @@ type PartitionUsage struct {
 	Device      string
 	Fstype      string
 	UsedPercent float64
-	TotalGB     uint64
+	TotalBytes  uint64
 	InodeUsage  float64
 	TotalInodes uint64
 }
@@ -141,6 +262,7 @@ type DiskHealthInfo struct {
 	Device  string
 	Status  string
 	Message string
+	Raw     string // smartctl output, kept for failing disks
 }
 
 // ============================================================================
@@ -166,9 +288,65 @@ type SystemCollector struct {
 	hostSensor     services.Sensor
 	physicalSensor services.Sensor
 	processSensor  services.Sensor
+	journalSensor  services.Sensor
+	logSensor      services.Sensor
+	tempSensor     services.Sensor
+	cgroupSensor   services.Sensor
+	powerSensor    services.Sensor
+	rpiSensor      services.Sensor
+	gpuSensor      services.Sensor
+	checkSensor    services.Sensor
+
+	fullProcessSensor services.Sensor // every process, for burst captures
+	proberSensor      services.Sensor // nil unless the background prober is enabled
+
+	envOnce sync.Once
+	env     services.Environment
+
+	// hostMode is set when collecting a mounted host filesystem; the
+	// agent's own cgroup then says nothing about the host.
+	hostMode bool
+
+	toggleMu sync.RWMutex
+	disabled map[string]bool // optional sensors switched off, see SetSensorEnabled
+
+	released sync.Pool // *RawStats handed back by Release, refilled by GetFastMetrics
+}
+
+// Releaser is implemented by providers that can reuse the memory of stats
+// their callers are done with, such as *SystemCollector.
+type Releaser interface {
+	Release(stats *RawStats)
+}
+
+// Release hands back stats returned by GetFastMetrics once nothing refers
+// to them or their slices any more, so a later cycle fills them instead of
+// allocating per-interface, per-container and per-process lists afresh.
+// On hosts with hundreds of containers or interfaces that is most of what
+// a fast cycle allocates. Stats from anywhere else must not be released.
+func (s *SystemCollector) Release(stats *RawStats) {
+	if stats != nil {
+		s.released.Put(stats)
+	}
+}
+
+// reuse returns buf emptied with room for n elements. It is never nil, so
+// empty lists still encode as [] rather than null.
+func reuse[T any](buf []T, n int) []T {
+	if buf == nil || cap(buf) < n {
+		return make([]T, 0, n)
+	}
+	return buf[:0]
 }
 
 func NewSystemCollector() *SystemCollector {
+	return NewSystemCollectorWithConfig(DefaultCollectorConfig())
+}
+
+// NewSystemCollectorWithConfig creates a collector whose log growth and temp
+// data sensors follow cfg. With cfg.HostRoot set, cgroup limits are not
+// applied, since usage is reported for the host.
+func NewSystemCollectorWithConfig(cfg CollectorConfig) *SystemCollector {
 	return &SystemCollector{
 		cpuSensor:      services.NewCPUSensor(),
 		memSensor:      services.NewMemSensor(),
@@ -177,10 +355,40 @@ func NewSystemCollector() *SystemCollector {
 		dockerSensor:   services.NewDockerSensor(),
 		hostSensor:     services.NewHostSensor(),
 		physicalSensor: services.NewPhysicalSensor(),
-		processSensor:  services.NewProcessSensor(),
+		processSensor:  services.NewProcessSensor().WithDetail(cfg.ProcessDetailCount, redactor(cfg.RedactPatterns)),
+		journalSensor:  services.NewJournalSensor(),
+		logSensor:      services.NewLogGrowthSensor(cfg.LogWatchDirs, cfg.LogGrowerCount),
+		tempSensor:     services.NewTempSensor(cfg.TempDirs, cfg.TempStaleAge, cfg.TempMaxEntries),
+		cgroupSensor:   services.NewCgroupSensor(),
+		powerSensor:    services.NewPowerSensor(),
+		rpiSensor:      services.NewRPiSensor(),
+		gpuSensor:      services.NewGPUSensor(),
+		checkSensor:    services.NewCheckSensor(cfg.Checks),
+		hostMode:       cfg.HostRoot != "",
+		disabled:       disabledSensors(cfg),
+
+		fullProcessSensor: services.NewProcessSensorWithLimit(0),
+		proberSensor:      newProber(cfg),
 	}
 }
 
+func newProber(cfg CollectorConfig) services.Sensor {
+	if !cfg.EnableProber {
+		return nil
+	}
+	return services.NewProberSensor(cfg.NetworkCheckEndpoint, cfg.ProbeInterval, cfg.ProbeWindow)
+}
+
+// redactor compiles patterns, falling back to the defaults when they are
+// invalid (CollectorConfig.Validate reports that case).
+func redactor(patterns []string) *services.Redactor {
+	r, err := services.NewRedactor(patterns)
+	if err != nil {
+		return services.DefaultRedactor()
+	}
+	return r
+}
+
 // Internal result types for concurrency
 type cpuResult struct {
 	stats services.CPUResult
@@ -224,9 +432,51 @@ type processResult struct {
 	err   error
 }
 
+type journalResult struct {
+	stats services.JournalResult
+	err   error
+}
+
+type logGrowthResult struct {
+	stats services.LogGrowthResult
+	err   error
+}
+
+type tempResult struct {
+	stats services.TempResult
+	err   error
+}
+
+type cgroupResult struct {
+	stats services.CgroupResult
+	err   error
+}
+
+type powerResult struct {
+	stats services.PowerResult
+	err   error
+}
+
+type rpiResult struct {
+	stats services.RPiResult
+	err   error
+}
+
+type gpuResult struct {
+	stats services.GPUResult
+	err   error
+}
+
+type checkResult struct {
+	stats services.CheckResults
+	err   error
+}
+
 type netResult struct {
 	latency float64
 	online  bool
+	vpn     string
+	direct  float64
 }
 
 type netIOResult struct {
@@ -246,6 +496,9 @@ type healthResult struct {
 
 // GetFastMetrics collects high-frequency metrics (CPU, RAM, Disk Usage/IO, Net IO, Docker, Processes).
 func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error) {
+	if err := chaos.Err(chaos.SensorTimeout); err != nil {
+		return nil, fmt.Errorf("failed to get CPU metrics: %w", err)
+	}
 	cpuCh := make(chan cpuResult, 1)
 	loadCh := make(chan loadResult, 1)
 	memCh := make(chan memResult, 1)
@@ -253,9 +506,11 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 	netIOCh := make(chan netIOResult, 1)
 	dockerCh := make(chan dockerMetricsResult, 1)
 	processCh := make(chan processResult, 1)
+	cgroupCh := make(chan cgroupResult, 1)
+	gpuCh := make(chan gpuResult, 1)
 
 	var wg sync.WaitGroup
-	wg.Add(7)
+	wg.Add(9)
 
 	go func() {
 		defer wg.Done()
@@ -300,7 +555,7 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 
 	go func() {
 		defer wg.Done()
-		res, err := s.dockerSensor.Collect(ctx)
+		res, err := s.collect(ctx, SensorDocker, s.dockerSensor)
 		if err != nil {
 			dockerCh <- dockerMetricsResult{err: err}
 			return
@@ -310,7 +565,7 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 
 	go func() {
 		defer wg.Done()
-		res, err := s.processSensor.Collect(ctx)
+		res, err := s.collect(ctx, SensorProcesses, s.processSensor)
 		if err != nil {
 			processCh <- processResult{err: err}
 			return
@@ -318,6 +573,26 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 		processCh <- processResult{stats: res.(services.ProcessResult), err: nil}
 	}()
 
+	go func() {
+		defer wg.Done()
+		res, err := s.cgroupSensor.Collect(ctx)
+		if err != nil {
+			cgroupCh <- cgroupResult{err: err}
+			return
+		}
+		cgroupCh <- cgroupResult{stats: res.(services.CgroupResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorGPU, s.gpuSensor)
+		if err != nil {
+			gpuCh <- gpuResult{err: err}
+			return
+		}
+		gpuCh <- gpuResult{stats: res.(services.GPUResult), err: nil}
+	}()
+
 	wg.Wait()
 
 	// Gather results
@@ -328,6 +603,8 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 	netIORes := <-netIOCh
 	dockerRes := <-dockerCh
 	processRes := <-processCh
+	cgroupRes := <-cgroupCh
+	gpuRes := <-gpuCh
 
 	if cpuRes.err != nil {
 		return nil, fmt.Errorf("failed to get CPU metrics: %w", cpuRes.err)
@@ -342,9 +619,14 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 		return nil, fmt.Errorf("failed to get disk metrics: %w", diskRes.err)
 	}
 
+	stats, _ := s.released.Get().(*RawStats)
+	if stats == nil {
+		stats = &RawStats{}
+	}
+
 	// Process Disk Results
 	var rootUsage services.UsageStat
-	usageMap := make(map[string]services.UsageStat)
+	usageMap := make(map[string]services.UsageStat, len(diskRes.stats.Usage))
 	for _, u := range diskRes.stats.Usage {
 		usageMap[u.Path] = u
 		if u.Path == "/" {
@@ -352,7 +634,7 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 		}
 	}
 
-	partitions := []PartitionUsage{} // Initialize as empty slice
+	partitions := reuse(stats.Partitions, len(diskRes.stats.Partitions))
 	for _, p := range diskRes.stats.Partitions {
 		if u, ok := usageMap[p.Mountpoint]; ok {
 			partitions = append(partitions, PartitionUsage{
@@ -360,14 +642,14 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 				Device:      p.Device,
 				Fstype:      p.Fstype,
 				UsedPercent: u.UsedPercent,
-				TotalGB:     u.Total / (1024 * 1024 * 1024),
+				TotalBytes:  u.Total,
 				InodeUsage:  u.InodesUsedPercent,
 				TotalInodes: u.InodesTotal,
 			})
 		}
 	}
 
-	ioCounters := []DiskIOCounters{} // Initialize as empty slice
+	ioCounters := reuse(stats.IOCounters, len(diskRes.stats.IOCounters))
 	for _, c := range diskRes.stats.IOCounters {
 		ioCounters = append(ioCounters, DiskIOCounters{
 			Device:      c.Name,
@@ -380,7 +662,7 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 		})
 	}
 
-	netStats := []NetInterfaceStats{} // Initialize as empty slice
+	netStats := reuse(stats.NetInterfaces, len(netIORes.stats.Interfaces))
 	for _, ns := range netIORes.stats.Interfaces {
 		netStats = append(netStats, NetInterfaceStats{
 			Name:        ns.Name,
@@ -392,10 +674,16 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 			ErrOut:      ns.ErrOut,
 			DropIn:      ns.DropIn,
 			DropOut:     ns.DropOut,
+
+			OperState:      ns.Link.OperState,
+			SpeedMbps:      ns.Link.SpeedMbps,
+			MaxSpeedMbps:   ns.Link.MaxSpeedMbps,
+			Duplex:         ns.Link.Duplex,
+			CarrierChanges: ns.Link.CarrierChanges,
 		})
 	}
 
-	dockerContainers := []DockerContainerInfo{} // Initialize as empty slice
+	dockerContainers := reuse(stats.DockerContainers, len(dockerRes.stats.Containers))
 	if dockerRes.err == nil && dockerRes.stats.Available {
 		for _, c := range dockerRes.stats.Containers {
 			dockerContainers = append(dockerContainers, DockerContainerInfo{
@@ -412,70 +700,181 @@ func (s *SystemCollector) GetFastMetrics(ctx context.Context) (*RawStats, error)
 		}
 	}
 
-	topProcesses := []ProcessStat{} // Initialize as empty slice
+	topProcesses := reuse(stats.TopProcesses, len(processRes.stats.Processes))
 	if processRes.err == nil {
 		for _, p := range processRes.stats.Processes {
 			topProcesses = append(topProcesses, ProcessStat{
 				PID:    p.PID,
 				Name:   p.Name,
+				User:   p.User,
 				CPU:    p.CPU,
 				Memory: p.Memory,
+
+				Cmdline: p.Cmdline,
+				Cgroup:  p.Cgroup,
 			})
 		}
 	}
 
-	return &RawStats{
-		CPUUsage:         cpuRes.stats.TotalUsage,
-		CPUPerCore:       cpuRes.stats.PerCore,
-		LoadAvg1:         loadRes.avg1,
-		LoadAvg5:         loadRes.avg5,
-		LoadAvg15:        loadRes.avg15,
-		CPUModel:         cpuRes.stats.Model,
-		CPUCores:         cpuRes.stats.Cores,
-		RAMUsage:         memRes.stats.UsedPercent,
-		RAMAvailable:     memRes.stats.Available / (1024 * 1024 * 1024),
-		RAMUsed:          memRes.stats.Used / (1024 * 1024 * 1024),
-		RAMFree:          memRes.stats.Free / (1024 * 1024 * 1024),
-		RAMCached:        memRes.stats.Cached / (1024 * 1024 * 1024),
-		RAMBuffered:      memRes.stats.Buffers / (1024 * 1024 * 1024),
-		TotalRAM_GB:      memRes.stats.Total / (1024 * 1024 * 1024),
-		SwapUsage:        memRes.stats.SwapUsage,
-		SwapTotal:        memRes.stats.SwapTotal / (1024 * 1024 * 1024),
-		SwapUsed:         memRes.stats.SwapUsed / (1024 * 1024 * 1024),
-		DiskUsage:        rootUsage.UsedPercent,
-		TotalDisk_GB:     rootUsage.Total / (1024 * 1024 * 1024),
-		InodeUsage:       rootUsage.InodesUsedPercent,
-		TotalInodes:      rootUsage.InodesTotal,
-		Partitions:       partitions,
-		IOCounters:       ioCounters,
-		NetInterfaces:    netStats,
-		DockerAvailable:  dockerRes.stats.Available,
-		DockerContainers: dockerContainers,
-		TopProcesses:     topProcesses,
-		DiskHealth:       []DiskHealthInfo{},  // Not collected in fast metrics
-		Temperatures:     []TemperatureStat{}, // Not collected in fast metrics
-		NetLatency_ms:    0,                   // Not collected in fast metrics
-		IsConnected:      true,                // Assume connected in fast metrics
-		ActiveTCP:        0,                   // Not collected in fast metrics
-		Hostname:         "",                  // Not collected in fast metrics
-		OS:               "",                  // Not collected in fast metrics
-		Platform:         "",                  // Not collected in fast metrics
-		KernelVersion:    "",                  // Not collected in fast metrics
-		Uptime:           0,                   // Not collected in fast metrics
-		Procs:            0,                   // Not collected in fast metrics
-	}, nil
+	mapProcessContainers(topProcesses, dockerContainers)
+
+	var userUsage []UserUsage
+	if processRes.err == nil && len(processRes.stats.Users) > 0 {
+		userUsage = reuse(stats.UserUsage, len(processRes.stats.Users))
+		for _, u := range processRes.stats.Users {
+			userUsage = append(userUsage, UserUsage{
+				UID:       u.UID,
+				User:      u.User,
+				Processes: u.Processes,
+				CPU:       u.CPU,
+				Memory:    u.Memory,
+				RSSBytes:  u.RSSBytes,
+			})
+		}
+	}
+
+	var gpus []GPUStat
+	if gpuRes.err == nil && len(gpuRes.stats.GPUs) > 0 {
+		gpus = reuse(stats.GPUs, len(gpuRes.stats.GPUs))
+		for _, g := range gpuRes.stats.GPUs {
+			gpus = append(gpus, GPUStat(g))
+		}
+	}
+
+	*stats = RawStats{
+		CPUUsage:          cpuRes.stats.TotalUsage,
+		CPUPerCore:        cpuRes.stats.PerCore,
+		LoadAvg1:          loadRes.avg1,
+		LoadAvg5:          loadRes.avg5,
+		LoadAvg15:         loadRes.avg15,
+		CPUModel:          cpuRes.stats.Model,
+		CPUCores:          cpuRes.stats.Cores,
+		RAMUsage:          memRes.stats.UsedPercent,
+		RAMAvailableBytes: memRes.stats.Available,
+		RAMUsedBytes:      memRes.stats.Used,
+		RAMFreeBytes:      memRes.stats.Free,
+		RAMCachedBytes:    memRes.stats.Cached,
+		RAMBufferedBytes:  memRes.stats.Buffers,
+		RAMTotalBytes:     memRes.stats.Total,
+		SwapUsage:         memRes.stats.SwapUsage,
+		SwapTotalBytes:    memRes.stats.SwapTotal,
+		SwapUsedBytes:     memRes.stats.SwapUsed,
+		SwapInBytes:       memRes.stats.SwapIn,
+		DiskUsage:         rootUsage.UsedPercent,
+		DiskTotalBytes:    rootUsage.Total,
+		InodeUsage:        rootUsage.InodesUsedPercent,
+		TotalInodes:       rootUsage.InodesTotal,
+		Partitions:        partitions,
+		IOCounters:        ioCounters,
+		NetInterfaces:     netStats,
+		DockerAvailable:   dockerRes.stats.Available,
+		DockerContainers:  dockerContainers,
+		TopProcesses:      topProcesses,
+		UserUsage:         userUsage,
+		GPUs:              gpus,
+		DiskHealth:        []DiskHealthInfo{},  // Not collected in fast metrics
+		Temperatures:      []TemperatureStat{}, // Not collected in fast metrics
+		NetLatency_ms:     0,                   // Not collected in fast metrics
+		IsConnected:       true,                // Assume connected in fast metrics
+		ActiveTCP:         0,                   // Not collected in fast metrics
+		Hostname:          "",                  // Not collected in fast metrics
+		OS:                "",                  // Not collected in fast metrics
+		Platform:          "",                  // Not collected in fast metrics
+		KernelVersion:     "",                  // Not collected in fast metrics
+		Uptime:            0,                   // Not collected in fast metrics
+		Procs:             0,                   // Not collected in fast metrics
+	}
+	if cgroupRes.err == nil && !s.hostMode {
+		applyCgroupLimits(stats, cgroupRes.stats, memRes.stats.Total)
+	}
+	s.applyProbe(ctx, stats, true)
+	if chaos.Fire(chaos.MetricSpike) {
+		spikeMetrics(stats)
+	}
+	return stats, nil
+}
+
+// spikeMetrics pushes CPU, memory and load close to saturation, for the
+// chaos metric_spike fault.
+func spikeMetrics(stats *RawStats) {
+	stats.CPUUsage = 99
+	for i := range stats.CPUPerCore {
+		stats.CPUPerCore[i] = 99
+	}
+	stats.LoadAvg1 = float64(max(stats.CPUCores, 1)) * 4
+	stats.RAMUsage = 97
+	stats.RAMUsedBytes = stats.RAMTotalBytes * 97 / 100
+	stats.RAMAvailableBytes = stats.RAMTotalBytes - stats.RAMUsedBytes
+}
+
+// applyProbe adds the background prober's jitter and loss. With
+// withLatency set, its mean latency also replaces the placeholder values of
+// the fast path.
+func (s *SystemCollector) applyProbe(ctx context.Context, stats *RawStats, withLatency bool) {
+	if s.proberSensor == nil {
+		return
+	}
+	res, err := s.collect(ctx, SensorProber, s.proberSensor)
+	if err != nil {
+		return
+	}
+	probe := res.(services.ProbeResult)
+	if probe.Sent == 0 {
+		return
+	}
+	stats.NetJitter_ms = probe.JitterMS
+	stats.NetLoss_pct = probe.LossPct
+	stats.NetProbes = probe.Sent
+	if withLatency {
+		stats.NetLatency_ms = probe.LatencyMS
+		stats.IsConnected = probe.Lost < probe.Sent
+	}
+}
+
+// applyCgroupLimits rebases CPU and RAM usage onto the cgroup's limits. A
+// memory limit at or above physical RAM constrains nothing and is ignored.
+func applyCgroupLimits(stats *RawStats, cg services.CgroupResult, hostRAM uint64) {
+	stats.Containerized = cg.Containerized
+
+	if limit := cg.MemLimitBytes; limit > 0 && limit < hostRAM {
+		used := min(cg.MemUsageBytes, limit)
+		stats.CgroupMemLimitBytes = limit
+		stats.RAMUsage = float64(used) / float64(limit) * 100
+		stats.RAMUsedBytes = used
+		stats.RAMAvailableBytes = limit - used
+		stats.RAMFreeBytes = stats.RAMAvailableBytes
+		stats.RAMTotalBytes = limit
+	}
+
+	if cores := cg.CPULimitCores; cores > 0 && cores < float64(stats.CPUCores) {
+		stats.CgroupCPULimit = cores
+		if cg.CPUValid {
+			stats.CPUUsage = min(cg.CPUUsagePct, 100)
+		}
+	}
 }
 
 // GetSlowMetrics collects low-frequency metrics (Disk Health, Network Latency, Net Connections, Host, Physical).
 func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error) {
+	if err := chaos.Err(chaos.SensorTimeout); err != nil {
+		return nil, fmt.Errorf("failed to get slow metrics: %w", err)
+	}
 	netCh := make(chan netResult, 1)
 	netConnCh := make(chan netConnResult, 1)
 	healthCh := make(chan healthResult, 1)
 	hostCh := make(chan hostResult, 1)
 	physCh := make(chan physicalResult, 1)
+	journalCh := make(chan journalResult, 1)
+	logCh := make(chan logGrowthResult, 1)
+	tempCh := make(chan tempResult, 1)
+	powerCh := make(chan powerResult, 1)
+	rpiCh := make(chan rpiResult, 1)
+	checkCh := make(chan checkResult, 1)
+
+	env := s.environment(ctx)
 
 	var wg sync.WaitGroup
-	wg.Add(5)
+	wg.Add(11)
 
 	go s.fetchNetwork(ctx, &wg, netCh)
 	go s.fetchNetConns(&wg, netConnCh)
@@ -493,7 +892,12 @@ func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error)
 
 	go func() {
 		defer wg.Done()
-		res, err := s.physicalSensor.Collect(ctx)
+		if env.Virtualized() {
+			// Guests see no real thermal sensors, or fake constant ones.
+			physCh <- physicalResult{}
+			return
+		}
+		res, err := s.collect(ctx, SensorTemperatures, s.physicalSensor)
 		if err != nil {
 			physCh <- physicalResult{err: err}
 			return
@@ -501,6 +905,66 @@ func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error)
 		physCh <- physicalResult{stats: res.(services.PhysicalResult), err: nil}
 	}()
 
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorJournal, s.journalSensor)
+		if err != nil {
+			journalCh <- journalResult{err: err}
+			return
+		}
+		journalCh <- journalResult{stats: res.(services.JournalResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorLogGrowth, s.logSensor)
+		if err != nil {
+			logCh <- logGrowthResult{err: err}
+			return
+		}
+		logCh <- logGrowthResult{stats: res.(services.LogGrowthResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorTempFiles, s.tempSensor)
+		if err != nil {
+			tempCh <- tempResult{err: err}
+			return
+		}
+		tempCh <- tempResult{stats: res.(services.TempResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorPower, s.powerSensor)
+		if err != nil {
+			powerCh <- powerResult{err: err}
+			return
+		}
+		powerCh <- powerResult{stats: res.(services.PowerResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.rpiSensor.Collect(ctx)
+		if err != nil {
+			rpiCh <- rpiResult{err: err}
+			return
+		}
+		rpiCh <- rpiResult{stats: res.(services.RPiResult), err: nil}
+	}()
+
+	go func() {
+		defer wg.Done()
+		res, err := s.collect(ctx, SensorChecks, s.checkSensor)
+		if err != nil {
+			checkCh <- checkResult{err: err}
+			return
+		}
+		checkCh <- checkResult{stats: res.(services.CheckResults), err: nil}
+	}()
+
 	wg.Wait()
 
 	netRes := <-netCh
@@ -508,6 +972,12 @@ func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error)
 	healthRes := <-healthCh
 	hostRes := <-hostCh
 	physRes := <-physCh
+	journalRes := <-journalCh
+	logRes := <-logCh
+	tempRes := <-tempCh
+	powerRes := <-powerCh
+	rpiRes := <-rpiCh
+	checkRes := <-checkCh
 
 	temps := []TemperatureStat{} // Initialize as empty slice
 	if physRes.err == nil {
@@ -519,9 +989,55 @@ func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error)
 		}
 	}
 
-	return &RawStats{
+	var power services.PowerResult
+	if powerRes.err == nil && powerRes.stats.Available {
+		power = powerRes.stats
+		for _, t := range power.Temperatures {
+			temps = append(temps, TemperatureStat{SensorKey: t.SensorKey, Temperature: t.Temperature})
+		}
+	}
+
+	var oomKills []OOMKill
+	if journalRes.err == nil {
+		for _, k := range journalRes.stats.OOMKills {
+			oomKills = append(oomKills, OOMKill{
+				At:           k.At,
+				PID:          k.PID,
+				Process:      k.Process,
+				Cgroup:       k.Cgroup,
+				UID:          k.UID,
+				AnonRSSBytes: k.AnonRSSBytes,
+				Constraint:   k.Constraint,
+			})
+		}
+	}
+
+	var logGrowers []FileGrowth
+	if logRes.err == nil {
+		for _, f := range logRes.stats.Files {
+			logGrowers = append(logGrowers, FileGrowth{Path: f.Path, SizeBytes: f.SizeBytes, GrowthBps: f.GrowthBps})
+		}
+	}
+
+	var tempUsage []TempUsage
+	if tempRes.err == nil {
+		for _, l := range tempRes.stats.Locations {
+			tempUsage = append(tempUsage, TempUsage{Path: l.Path, Kind: l.Kind, Files: l.Files, TotalBytes: l.TotalBytes, StaleBytes: l.StaleBytes})
+		}
+	}
+
+	var checks []CheckResult
+	if checkRes.err == nil {
+		for _, c := range checkRes.stats.Checks {
+			checks = append(checks, CheckResult(c))
+		}
+	}
+
+	stats := &RawStats{
 		NetLatency_ms: netRes.latency,
 		IsConnected:   netRes.online,
+		VPNInterface:  netRes.vpn,
+		NetDirect_ms:  netRes.direct,
 		ActiveTCP:     netConnRes.activeTCP,
 		DiskHealth:    healthRes.health,
 		Hostname:      hostRes.stats.Hostname,
@@ -530,8 +1046,43 @@ func (s *SystemCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error)
 		KernelVersion: hostRes.stats.KernelVersion,
 		Uptime:        hostRes.stats.Uptime,
 		Procs:         hostRes.stats.Procs,
+		Environment:   env.Kind,
+		Hypervisor:    env.Hypervisor,
+		CloudProvider: env.Provider,
+		LatencyNAT:    env.NATLatency(),
 		Temperatures:  temps,
-	}, nil
+
+		CPUEClusterPct:  power.EClusterPct,
+		CPUPClusterPct:  power.PClusterPct,
+		PowerCPUWatts:   power.CPUWatts,
+		PowerGPUWatts:   power.GPUWatts,
+		PowerANEWatts:   power.ANEWatts,
+		PowerTotalWatts: power.TotalWatts,
+		ThermalPressure: power.ThermalPressure,
+
+		ThrottledBits: rpiRes.stats.ThrottledBits,
+		CoreVolts:     rpiRes.stats.CoreVolts,
+
+		OOMKills:   oomKills,
+		LogGrowers: logGrowers,
+		TempUsage:  tempUsage,
+		Checks:     checks,
+	}
+	s.applyProbe(ctx, stats, false)
+	return stats, nil
+}
+
+// environment detects the host's environment profile on first use. The
+// host does not change underneath a running collector.
+func (s *SystemCollector) environment(ctx context.Context) services.Environment {
+	s.envOnce.Do(func() {
+		res, err := s.hostSensor.Collect(ctx)
+		if err != nil {
+			return
+		}
+		s.env = services.DetectEnvironment(res.(services.HostResult))
+	})
+	return s.env
 }
 
 // Helper methods for concurrent fetching
@@ -547,15 +1098,23 @@ func (s *SystemCollector) fetchNetwork(ctx context.Context, wg *sync.WaitGroup,
 	defer wg.Done()
 	defer close(ch)
 
-	start := time.Now()
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", "8.8.8.8:53")
+	const endpoint = "8.8.8.8:53"
+	rtt, err := services.DialLatency(ctx, endpoint, "")
 	if err != nil {
 		ch <- netResult{latency: 0, online: false}
 		return
 	}
-	conn.Close()
-	ch <- netResult{latency: float64(time.Since(start).Milliseconds()), online: true}
+	res := netResult{latency: float64(rtt.Milliseconds()), online: true}
+
+	// Behind a VPN, also probe around the tunnel so a slow tunnel can be
+	// told apart from a slow uplink.
+	if routes := services.DetectRoutes(); routes.VPN() && routes.Physical != "" {
+		res.vpn = routes.Tunnel
+		if rtt, err := services.DialLatency(ctx, endpoint, routes.Physical); err == nil {
+			res.direct = float64(rtt.Milliseconds())
+		}
+	}
+	ch <- res
 }
 
 func (s *SystemCollector) fetchNetConns(wg *sync.WaitGroup, ch chan netConnResult) {
@@ -573,6 +1132,11 @@ func (s *SystemCollector) fetchHealth(wg *sync.WaitGroup, ch chan healthResult)
 	defer wg.Done()
 	defer close(ch)
 
+	// Virtual disks have no SMART data.
+	if s.env.Virtualized() || !s.sensorEnabled(SensorDiskHealth) {
+		ch <- healthResult{}
+		return
+	}
 	if _, err := exec.LookPath("smartctl"); err != nil {
 		ch <- healthResult{}
 		return
@@ -608,7 +1172,38 @@ func (s *SystemCollector) fetchHealth(wg *sync.WaitGroup, ch chan healthResult)
 			status = "failed"
 			msg = "SMART health failed"
 		}
-		health = append(health, DiskHealthInfo{Device: p.Device, Status: status, Message: msg})
+		info := DiskHealthInfo{Device: p.Device, Status: status, Message: msg}
+		if status == "failed" {
+			info.Raw = string(output)
+		}
+		health = append(health, info)
 	}
 	ch <- healthResult{health: health}
 }
+
+// mapProcessContainers sets ContainerID on processes whose cgroup path names
+// a known container. Cgroups not captured with detail are read here, and only
+// when a container is running.
+func mapProcessContainers(procs []ProcessStat, containers []DockerContainerInfo) {
+	running := func(c DockerContainerInfo) bool { return c.Running && c.ID != "" }
+	if !slices.ContainsFunc(containers, running) {
+		return
+	}
+	for i := range procs {
+		p := &procs[i]
+		if p.Cgroup == "" {
+			p.Cgroup = services.ProcessCgroup(p.PID)
+		}
+		id := services.ContainerIDFromCgroup(p.Cgroup)
+		if id == "" {
+			continue
+		}
+		// The CLI fallback reports short IDs.
+		for _, c := range containers {
+			if running(c) && strings.HasPrefix(id, c.ID) {
+				p.ContainerID = c.ID
+				break
+			}
+		}
+	}
+}
@@ -2,7 +2,10 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"testing"
+
+	"syschecker/internal/collector/services"
 )
 
 // MockCollector satisfies the StatsProvider interface
@@ -21,9 +24,9 @@ func (m MockCollector) GetSlowMetrics(ctx context.Context) (*RawStats, error) {
 
 func TestMockCollector(t *testing.T) {
 	expectedStats := &RawStats{
-		CPUUsage:    10.5,
-		RAMUsage:    50.0,
-		TotalRAM_GB: 16,
+		CPUUsage:      10.5,
+		RAMUsage:      50.0,
+		RAMTotalBytes: 16 << 30,
 	}
 
 	mock := MockCollector{
@@ -75,7 +78,7 @@ func TestSystemCollectorDiskDetails(t *testing.T) {
 		if p.UsedPercent < 0 || p.UsedPercent > 100 {
 			t.Errorf("partition %s used percent out of range: %f", p.Mountpoint, p.UsedPercent)
 		}
-		if p.TotalGB == 0 {
+		if p.TotalBytes == 0 {
 			// Some virtual mounts may report zero; log instead of fail.
 			t.Logf("partition %s total size is zero (skipping size assertion)", p.Mountpoint)
 		}
@@ -107,3 +110,135 @@ func TestSystemCollectorDiskDetails(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyCgroupLimits(t *testing.T) {
+	const gib = 1 << 30
+	stats := &RawStats{CPUUsage: 12, CPUCores: 16, RAMUsage: 30, RAMTotalBytes: 64 * gib}
+	applyCgroupLimits(stats, services.CgroupResult{
+		Containerized: true,
+		MemLimitBytes: 4 * gib,
+		MemUsageBytes: 3 * gib,
+		CPULimitCores: 2,
+		CPUUsagePct:   95,
+		CPUValid:      true,
+	}, 64*gib)
+
+	if !stats.Containerized || stats.CgroupMemLimitBytes != 4*gib || stats.CgroupCPULimit != 2 {
+		t.Errorf("limits not recorded: %+v", stats)
+	}
+	if stats.RAMUsage != 75 || stats.RAMTotalBytes != 4*gib || stats.RAMAvailableBytes != gib {
+		t.Errorf("RAM = %.1f%% of %d bytes (%d available), want 75%% of 4 GiB", stats.RAMUsage, stats.RAMTotalBytes, stats.RAMAvailableBytes)
+	}
+	if stats.CPUUsage != 95 {
+		t.Errorf("CPU = %.1f%%, want 95%%", stats.CPUUsage)
+	}
+
+	// Limits at or above the host's capacity constrain nothing.
+	stats = &RawStats{CPUUsage: 12, CPUCores: 4, RAMUsage: 30, RAMTotalBytes: 8 * gib}
+	applyCgroupLimits(stats, services.CgroupResult{MemLimitBytes: 16 * gib, CPULimitCores: 8, CPUValid: true}, 8*gib)
+	if stats.CgroupMemLimitBytes != 0 || stats.CgroupCPULimit != 0 || stats.RAMUsage != 30 || stats.CPUUsage != 12 {
+		t.Errorf("host values rewritten: %+v", stats)
+	}
+}
+
+func TestSensorToggles(t *testing.T) {
+	c := NewSystemCollectorWithConfig(DefaultCollectorConfig().WithDockerMetrics(false))
+	if states := c.SensorStates(); states[SensorDocker] || !states[SensorProcesses] || states[SensorProber] {
+		t.Fatalf("initial states = %v", states)
+	}
+	if err := c.SetSensorEnabled(SensorProcesses, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetSensorEnabled("fpga", false); err == nil {
+		t.Error("unknown sensor accepted")
+	}
+	if err := c.SetSensorEnabled(SensorProber, true); err == nil {
+		t.Error("enabling a prober that was never started should fail")
+	}
+
+	stats, err := c.GetFastMetrics(context.Background())
+	if err != nil {
+		t.Skipf("Skipping system test: %v (might be environment specific)", err)
+	}
+	if len(stats.TopProcesses) != 0 || len(stats.DockerContainers) != 0 || stats.DockerAvailable {
+		t.Errorf("disabled sensors still collected: %d processes, %d containers", len(stats.TopProcesses), len(stats.DockerContainers))
+	}
+}
+
+// fixedSensor returns the same result from every Collect.
+type fixedSensor struct{ res any }
+
+func (fixedSensor) Name() string                           { return "fixed" }
+func (fixedSensor) Connect(context.Context) error          { return nil }
+func (fixedSensor) Disconnect(context.Context) error       { return nil }
+func (f fixedSensor) Collect(context.Context) (any, error) { return f.res, nil }
+
+// fakeCollector reports a host with the given numbers of containers and
+// network interfaces.
+func fakeCollector(containers, nics int) *SystemCollector {
+	docker := services.DockerResult{Available: true}
+	for i := range containers {
+		docker.Containers = append(docker.Containers, services.DockerContainerStat{ID: fmt.Sprintf("c%d", i), Running: true, MemUsage: 1 << 20})
+	}
+	var net services.NetResult
+	for i := range nics {
+		net.Interfaces = append(net.Interfaces, services.NetInterfaceStats{Name: fmt.Sprintf("veth%d", i), BytesRecv: uint64(i)})
+	}
+	return &SystemCollector{
+		cpuSensor:     fixedSensor{services.CPUResult{TotalUsage: 10, Cores: 4}},
+		memSensor:     fixedSensor{services.MemResult{Total: 8 << 30}},
+		diskSensor:    fixedSensor{services.DiskResult{}},
+		netSensor:     fixedSensor{net},
+		dockerSensor:  fixedSensor{docker},
+		processSensor: fixedSensor{services.ProcessResult{}},
+		cgroupSensor:  fixedSensor{services.CgroupResult{}},
+		gpuSensor:     fixedSensor{services.GPUResult{}},
+		hostMode:      true,
+	}
+}
+
+func TestReleasedStatsAreRefilled(t *testing.T) {
+	ctx := context.Background()
+	c := fakeCollector(3, 2)
+	first, err := c.GetFastMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Release(first)
+
+	// Fewer containers and no interfaces: nothing of the first cycle may
+	// show through.
+	c.dockerSensor = fixedSensor{services.DockerResult{Available: true, Containers: []services.DockerContainerStat{{ID: "only"}}}}
+	c.netSensor = fixedSensor{services.NetResult{}}
+	stats, err := c.GetFastMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.DockerContainers) != 1 || stats.DockerContainers[0].ID != "only" || stats.DockerContainers[0].MemUsage != 0 {
+		t.Errorf("containers = %+v", stats.DockerContainers)
+	}
+	if stats.NetInterfaces == nil || len(stats.NetInterfaces) != 0 || stats.CPUUsage != 10 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+// BenchmarkGetFastMetrics measures a fast cycle on a host with 500
+// containers and interfaces, with and without handing stats back.
+func BenchmarkGetFastMetrics(b *testing.B) {
+	ctx := context.Background()
+	for _, release := range []bool{false, true} {
+		b.Run(fmt.Sprintf("release=%t", release), func(b *testing.B) {
+			c := fakeCollector(500, 500)
+			b.ReportAllocs()
+			for b.Loop() {
+				stats, err := c.GetFastMetrics(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if release {
+					c.Release(stats)
+				}
+			}
+		})
+	}
+}
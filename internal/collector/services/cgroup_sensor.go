@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cgroupUnlimited is the threshold above which a cgroup v1 memory limit is
+// the kernel's "no limit" sentinel rather than a real limit.
+const cgroupUnlimited = 1 << 62
+
+// CgroupResult describes the cgroup syschecker itself runs in.
+type CgroupResult struct {
+	Containerized bool
+	Version       int     // 1 or 2; 0 when no cgroup filesystem was found
+	MemLimitBytes uint64  // 0 when unlimited
+	MemUsageBytes uint64  // excludes inactive page cache, like docker stats
+	CPULimitCores float64 // quota/period; 0 when unlimited
+	CPUUsagePct   float64 // percent of CPULimitCores since the previous collection
+	CPUValid      bool    // false on the first collection
+}
+
+// CgroupSensor reads the limits of the cgroup this process belongs to so
+// usage inside a container can be reported against the container's share
+// rather than the whole host.
+type CgroupSensor struct {
+	root       string   // cgroup mount point
+	selfCgroup string   // /proc/self/cgroup
+	markers    []string // files whose presence means we run in a container
+
+	mu        sync.Mutex
+	prevUsage uint64 // CPU time in microseconds
+	prevAt    time.Time
+}
+
+func NewCgroupSensor() *CgroupSensor {
+	return &CgroupSensor{
+		root:       "/sys/fs/cgroup",
+		selfCgroup: "/proc/self/cgroup",
+		markers:    []string{"/.dockerenv", "/run/.containerenv"},
+	}
+}
+
+func (s *CgroupSensor) Name() string {
+	return "Cgroup"
+}
+
+func (s *CgroupSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *CgroupSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *CgroupSensor) Collect(ctx context.Context) (any, error) {
+	res := CgroupResult{Containerized: s.containerized()}
+
+	paths := parseSelfCgroup(s.selfCgroup)
+	var cpuUsage uint64
+	var haveCPU bool
+	if p, ok := paths[""]; ok && fileExists(filepath.Join(s.root, "cgroup.controllers")) {
+		res.Version = 2
+		dir := s.resolve("", p, "memory.max")
+		res.MemLimitBytes = readLimit(filepath.Join(dir, "memory.max"))
+		res.MemUsageBytes = workingSet(filepath.Join(dir, "memory.current"), filepath.Join(dir, "memory.stat"), "inactive_file")
+		res.CPULimitCores = readCPUMax(filepath.Join(dir, "cpu.max"))
+		if v, ok := readStatKey(filepath.Join(dir, "cpu.stat"), "usage_usec"); ok {
+			cpuUsage, haveCPU = v, true
+		}
+	} else if len(paths) > 0 {
+		res.Version = 1
+		memDir := s.resolve("memory", paths["memory"], "memory.limit_in_bytes")
+		res.MemLimitBytes = readLimit(filepath.Join(memDir, "memory.limit_in_bytes"))
+		res.MemUsageBytes = workingSet(filepath.Join(memDir, "memory.usage_in_bytes"), filepath.Join(memDir, "memory.stat"), "total_inactive_file")
+
+		cpuDir := s.resolve("cpu", paths["cpu"], "cpu.cfs_quota_us")
+		quota, qok := readInt(filepath.Join(cpuDir, "cpu.cfs_quota_us"))
+		period, pok := readInt(filepath.Join(cpuDir, "cpu.cfs_period_us"))
+		if qok && pok && quota > 0 && period > 0 {
+			res.CPULimitCores = float64(quota) / float64(period)
+		}
+		acctDir := s.resolve("cpuacct", paths["cpuacct"], "cpuacct.usage")
+		if ns, ok := readInt(filepath.Join(acctDir, "cpuacct.usage")); ok && ns >= 0 {
+			cpuUsage, haveCPU = uint64(ns)/1000, true
+		}
+	}
+
+	if haveCPU && res.CPULimitCores > 0 {
+		now := time.Now()
+		s.mu.Lock()
+		if !s.prevAt.IsZero() && cpuUsage >= s.prevUsage {
+			if elapsed := now.Sub(s.prevAt).Microseconds(); elapsed > 0 {
+				res.CPUUsagePct = float64(cpuUsage-s.prevUsage) / (float64(elapsed) * res.CPULimitCores) * 100
+				res.CPUValid = true
+			}
+		}
+		s.prevUsage, s.prevAt = cpuUsage, now
+		s.mu.Unlock()
+	}
+	return res, nil
+}
+
+// containerized reports whether a container runtime marker file exists.
+func (s *CgroupSensor) containerized() bool {
+	for _, m := range s.markers {
+		if fileExists(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the directory holding file for a controller. With a cgroup
+// namespace the process sees its own cgroup at the mount root, so the path
+// from /proc/self/cgroup is only used when it exists under the mount.
+func (s *CgroupSensor) resolve(controller, path, file string) string {
+	base := s.root
+	if controller != "" {
+		base = filepath.Join(s.root, controller)
+		// cpu and cpuacct are usually co-mounted as "cpu,cpuacct".
+		if !fileExists(base) {
+			if matches, _ := filepath.Glob(filepath.Join(s.root, "*"+controller+"*")); len(matches) > 0 {
+				base = matches[0]
+			}
+		}
+	}
+	if dir := filepath.Join(base, path); path != "" && fileExists(filepath.Join(dir, file)) {
+		return dir
+	}
+	return base
+}
+
+// parseSelfCgroup maps controller names to cgroup paths. The cgroup v2
+// unified hierarchy is keyed by the empty string.
+func parseSelfCgroup(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	paths := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.SplitN(sc.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			paths[c] = parts[2]
+		}
+	}
+	return paths
+}
+
+// readLimit reads a memory limit, returning 0 for "max" or the v1 sentinel.
+func readLimit(path string) uint64 {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil || v >= cgroupUnlimited {
+		return 0
+	}
+	return v
+}
+
+// readCPUMax parses cgroup v2 cpu.max ("$QUOTA $PERIOD" or "max $PERIOD").
+func readCPUMax(path string) float64 {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || quota <= 0 || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// workingSet subtracts inactive page cache from the raw usage counter.
+func workingSet(usagePath, statPath, inactiveKey string) uint64 {
+	usage, ok := readInt(usagePath)
+	if !ok || usage < 0 {
+		return 0
+	}
+	if inactive, ok := readStatKey(statPath, inactiveKey); ok && inactive < uint64(usage) {
+		return uint64(usage) - inactive
+	}
+	return uint64(usage)
+}
+
+func readInt(path string) (int64, bool) {
+	b, ok := readTrimmed(path)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(b, 10, 64)
+	return v, err == nil
+}
+
+// readStatKey reads one "key value" line from a cgroup stat file.
+func readStatKey(path, key string) (uint64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), " ")
+		if ok && k == key {
+			n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
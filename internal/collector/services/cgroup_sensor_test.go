@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCgroupSensorV2(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "cgroup")
+	writeFile(t, filepath.Join(root, "cgroup.controllers"), "cpu memory\n")
+	writeFile(t, filepath.Join(root, "memory.max"), "536870912\n")
+	writeFile(t, filepath.Join(root, "memory.current"), "300000000\n")
+	writeFile(t, filepath.Join(root, "memory.stat"), "anon 200000000\ninactive_file 100000000\n")
+	writeFile(t, filepath.Join(root, "cpu.max"), "150000 100000\n")
+	writeFile(t, filepath.Join(root, "cpu.stat"), "usage_usec 1000000\nuser_usec 800000\n")
+	writeFile(t, filepath.Join(dir, "self"), "0::/\n")
+	writeFile(t, filepath.Join(dir, "dockerenv"), "")
+
+	s := &CgroupSensor{root: root, selfCgroup: filepath.Join(dir, "self"), markers: []string{filepath.Join(dir, "dockerenv")}}
+	res, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(CgroupResult)
+	if !got.Containerized || got.Version != 2 {
+		t.Errorf("containerized=%v version=%d", got.Containerized, got.Version)
+	}
+	if got.MemLimitBytes != 512<<20 || got.MemUsageBytes != 200000000 {
+		t.Errorf("mem limit=%d usage=%d", got.MemLimitBytes, got.MemUsageBytes)
+	}
+	if got.CPULimitCores != 1.5 || got.CPUValid {
+		t.Errorf("cpu limit=%v valid=%v", got.CPULimitCores, got.CPUValid)
+	}
+
+	// 1.2s of CPU time over one second is 1.2 cores busy out of 1.5 allowed.
+	s.prevAt = time.Now().Add(-time.Second)
+	writeFile(t, filepath.Join(root, "cpu.stat"), "usage_usec 2200000\n")
+	res, _ = s.Collect(context.Background())
+	got = res.(CgroupResult)
+	if !got.CPUValid || got.CPUUsagePct < 75 || got.CPUUsagePct > 81 {
+		t.Errorf("cpu usage = %.1f%% (valid=%v), want ~80%%", got.CPUUsagePct, got.CPUValid)
+	}
+}
+
+func TestCgroupSensorV1Unlimited(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "cgroup")
+	writeFile(t, filepath.Join(root, "memory", "docker", "abc", "memory.limit_in_bytes"), "9223372036854771712\n")
+	writeFile(t, filepath.Join(root, "memory", "docker", "abc", "memory.usage_in_bytes"), "4096\n")
+	writeFile(t, filepath.Join(root, "cpu,cpuacct", "docker", "abc", "cpu.cfs_quota_us"), "-1\n")
+	writeFile(t, filepath.Join(root, "cpu,cpuacct", "docker", "abc", "cpu.cfs_period_us"), "100000\n")
+	writeFile(t, filepath.Join(dir, "self"), "12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n")
+
+	s := &CgroupSensor{root: root, selfCgroup: filepath.Join(dir, "self")}
+	res, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(CgroupResult)
+	if got.Version != 1 || got.Containerized {
+		t.Errorf("version=%d containerized=%v", got.Version, got.Containerized)
+	}
+	if got.MemLimitBytes != 0 || got.CPULimitCores != 0 {
+		t.Errorf("expected no limits, got mem=%d cpu=%v", got.MemLimitBytes, got.CPULimitCores)
+	}
+	if got.MemUsageBytes != 4096 {
+		t.Errorf("mem usage = %d", got.MemUsageBytes)
+	}
+}
+
+func TestContainerIDFromCgroup(t *testing.T) {
+	id := "4f1c2ab9e0d3c5b7a6f8e9d0c1b2a3f4e5d6c7b8a9f0e1d2c3b4a5f6e7d8c9b0"
+	tests := map[string]string{
+		"/docker/" + id:                                               id,
+		"/system.slice/docker-" + id + ".scope":                       id,
+		"/kubepods/burstable/pod1234/cri-containerd-" + id + ".scope": id,
+		"/machine.slice/libpod-" + id + ".scope":                      id,
+		"/user.slice/user-1000.slice/session-2.scope":                 "",
+		"": "",
+	}
+	for path, want := range tests {
+		if got := ContainerIDFromCgroup(path); got != want {
+			t.Errorf("ContainerIDFromCgroup(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
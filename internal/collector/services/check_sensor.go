@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkOutputLimit caps the stored output of a check.
+const checkOutputLimit = 1024
+
+// CheckSpec is a user-defined probe: a command whose exit code decides
+// whether the check passes.
+type CheckSpec struct {
+	Name         string
+	Command      []string // argv; not run through a shell
+	ExpectedExit int
+	Timeout      time.Duration
+	Severity     int // severity raised when the check fails (1-3)
+}
+
+// CheckResult is the outcome of one run of a check. If the first line of
+// its output is a number it is kept as Value, so checks can report metrics.
+type CheckResult struct {
+	Name       string
+	OK         bool
+	ExitCode   int // -1 when the command could not run or timed out
+	DurationMS float64
+	Value      *float64
+	Output     string // combined stdout and stderr, truncated
+	Severity   int
+}
+
+type CheckResults struct {
+	Checks []CheckResult
+}
+
+// CheckSensor runs the configured checks concurrently.
+type CheckSensor struct {
+	checks []CheckSpec
+}
+
+func NewCheckSensor(checks []CheckSpec) *CheckSensor {
+	return &CheckSensor{checks: append([]CheckSpec(nil), checks...)}
+}
+
+func (s *CheckSensor) Name() string {
+	return "Checks"
+}
+
+func (s *CheckSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *CheckSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *CheckSensor) Collect(ctx context.Context) (any, error) {
+	res := CheckResults{Checks: make([]CheckResult, len(s.checks))}
+	var wg sync.WaitGroup
+	for i, c := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res.Checks[i] = RunCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+	return res, nil
+}
+
+// RunCheck runs c once, killing it when its timeout expires.
+func RunCheck(ctx context.Context, c CheckSpec) CheckResult {
+	res := CheckResult{Name: c.Name, ExitCode: -1, Severity: c.Severity}
+	if len(c.Command) == 0 {
+		res.Output = "no command configured"
+		return res
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	cmd.WaitDelay = time.Second // don't hang on children holding the pipes
+
+	start := time.Now()
+	err := cmd.Run()
+	res.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	res.Output = truncateOutput(out.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		res.Output = strings.TrimSpace(res.Output + "\ntimed out after " + c.Timeout.String())
+		return res
+	case err == nil:
+		res.ExitCode = 0
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		res.Output = strings.TrimSpace(res.Output + "\n" + err.Error())
+		return res
+	}
+	res.OK = res.ExitCode == c.ExpectedExit
+
+	first, _, _ := strings.Cut(strings.TrimSpace(res.Output), "\n")
+	if v, err := strconv.ParseFloat(strings.TrimSpace(first), 64); err == nil {
+		res.Value = &v
+	}
+	return res
+}
+
+func truncateOutput(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > checkOutputLimit {
+		s = s[:checkOutputLimit] + "..."
+	}
+	return s
+}
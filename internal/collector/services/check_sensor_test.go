@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCheck(t *testing.T) {
+	ctx := context.Background()
+
+	ok := RunCheck(ctx, CheckSpec{Name: "queue", Command: []string{"sh", "-c", "echo 42; echo depth"}, Timeout: 5 * time.Second})
+	if !ok.OK || ok.ExitCode != 0 || ok.Value == nil || *ok.Value != 42 {
+		t.Errorf("queue check = %+v", ok)
+	}
+
+	// Exit 2 is the expected result, so it passes.
+	warn := RunCheck(ctx, CheckSpec{Name: "warn", Command: []string{"sh", "-c", "exit 2"}, ExpectedExit: 2, Timeout: 5 * time.Second})
+	if !warn.OK || warn.ExitCode != 2 {
+		t.Errorf("warn check = %+v", warn)
+	}
+
+	slow := RunCheck(ctx, CheckSpec{Name: "slow", Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond})
+	if slow.OK || slow.ExitCode != -1 || !strings.Contains(slow.Output, "timed out") {
+		t.Errorf("slow check = %+v", slow)
+	}
+
+	missing := RunCheck(ctx, CheckSpec{Name: "missing", Command: []string{"/nonexistent/check"}, Timeout: time.Second})
+	if missing.OK || missing.ExitCode != -1 || missing.Output == "" {
+		t.Errorf("missing check = %+v", missing)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirUsage is the apparent size of a directory tree.
+type DirUsage struct {
+	Path      string
+	SizeBytes uint64
+}
+
+// DirScanLimits bounds a largest-directories scan.
+type DirScanLimits struct {
+	MaxDepth   int // directories deeper than this roll up into their ancestor
+	MaxEntries int // stop after visiting this many entries (0 = unlimited)
+	Top        int // number of directories returned
+}
+
+// errScanLimit stops a walk once MaxEntries is reached.
+var errScanLimit = errors.New("scan entry limit reached")
+
+// ScanLargestDirs walks roots du-style without crossing filesystems and returns the
+// largest directories (apparent size) up to MaxDepth below each root. partial is true
+// when the walk stopped early because of the entry limit or ctx.
+func ScanLargestDirs(ctx context.Context, roots []string, lim DirScanLimits) (dirs []DirUsage, partial bool) {
+	sizes := make(map[string]uint64)
+	visited := 0
+
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		rootInfo, err := os.Lstat(root)
+		if err != nil || !rootInfo.IsDir() {
+			continue
+		}
+		rootDev, hasDev := deviceID(rootInfo)
+
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // unreadable entries are skipped
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			visited++
+			if lim.MaxEntries > 0 && visited > lim.MaxEntries {
+				return errScanLimit
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if dev, ok := deviceID(info); hasDev && ok && dev != rootDev {
+					return fs.SkipDir // another filesystem (like du -x)
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			size := uint64(info.Size())
+			for _, dir := range ancestors(root, filepath.Dir(p), lim.MaxDepth) {
+				sizes[dir] += size
+			}
+			return nil
+		})
+		if err != nil {
+			partial = true
+			break
+		}
+	}
+
+	for p, size := range sizes {
+		dirs = append(dirs, DirUsage{Path: p, SizeBytes: size})
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].SizeBytes != dirs[j].SizeBytes {
+			return dirs[i].SizeBytes > dirs[j].SizeBytes
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+	if lim.Top > 0 && len(dirs) > lim.Top {
+		dirs = dirs[:lim.Top]
+	}
+	return dirs, partial
+}
+
+// ancestors returns the directories from depth 1 below root down to dir,
+// truncated at maxDepth. The root itself is not included.
+func ancestors(root, dir string, maxDepth int) []string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if maxDepth > 0 && len(parts) > maxDepth {
+		parts = parts[:maxDepth]
+	}
+	out := make([]string, 0, len(parts))
+	cur := root
+	for _, part := range parts {
+		cur = filepath.Join(cur, part)
+		out = append(out, cur)
+	}
+	return out
+}
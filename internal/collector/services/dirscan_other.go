@@ -0,0 +1,10 @@
+//go:build !unix
+
+package services
+
+import "io/fs"
+
+// deviceID is unavailable here; scans may cross filesystems.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
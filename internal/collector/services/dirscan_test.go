@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSized(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanLargestDirs(t *testing.T) {
+	root := t.TempDir()
+	writeSized(t, filepath.Join(root, "var", "lib", "docker", "overlay", "layer", "blob"), 5000)
+	writeSized(t, filepath.Join(root, "var", "log", "syslog"), 1000)
+	writeSized(t, filepath.Join(root, "home", "a", "file"), 2000)
+	writeSized(t, filepath.Join(root, "top.txt"), 9999) // files directly under the root roll up nowhere
+
+	dirs, partial := ScanLargestDirs(context.Background(), []string{root}, DirScanLimits{MaxDepth: 3, Top: 3})
+	if partial {
+		t.Error("unexpected partial scan")
+	}
+	want := []DirUsage{
+		{Path: filepath.Join(root, "var"), SizeBytes: 6000},
+		{Path: filepath.Join(root, "var", "lib"), SizeBytes: 5000},
+		{Path: filepath.Join(root, "var", "lib", "docker"), SizeBytes: 5000},
+	}
+	if len(dirs) != len(want) {
+		t.Fatalf("got %+v, want %+v", dirs, want)
+	}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Errorf("dirs[%d] = %+v, want %+v", i, dirs[i], want[i])
+		}
+	}
+}
+
+func TestScanLargestDirsEntryLimit(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		writeSized(t, filepath.Join(root, name, "f"), 10)
+	}
+	_, partial := ScanLargestDirs(context.Background(), []string{root}, DirScanLimits{MaxDepth: 1, MaxEntries: 3})
+	if !partial {
+		t.Error("expected partial scan when the entry limit is hit")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package services
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// deviceID returns the device a file lives on, used to stay on one filesystem.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
@@ -77,19 +77,29 @@ func (s *DiskSensor) Collect(ctx context.Context) (any, error) {
 	var partStats []PartitionStat
 	var usageStats []UsageStat
 
+	seen := make(map[string]int, len(partitions))
 	for _, p := range partitions {
-		partStats = append(partStats, PartitionStat{
+		ps := PartitionStat{
 			Device:     p.Device,
 			Mountpoint: p.Mountpoint,
 			Fstype:     p.Fstype,
 			Opts:       p.Opts,
-		})
+		}
+		// A mount stacked on the same path hides the earlier one; usage
+		// already reflects the top mount.
+		if i, ok := seen[p.Mountpoint]; ok {
+			partStats[i] = ps
+			continue
+		}
+		seen[p.Mountpoint] = len(partStats)
+		partStats = append(partStats, ps)
 
-		// Collect usage for each partition
-		u, err := disk.UsageWithContext(ctx, p.Mountpoint)
+		// Collect usage for each partition. Mountpoints are the host's, so
+		// in host-mount mode they are resolved under HOST_ROOT.
+		u, err := disk.UsageWithContext(ctx, HostRoot(p.Mountpoint))
 		if err == nil {
 			usageStats = append(usageStats, UsageStat{
-				Path:              u.Path,
+				Path:              p.Mountpoint,
 				Fstype:            u.Fstype,
 				Total:             u.Total,
 				Free:              u.Free,
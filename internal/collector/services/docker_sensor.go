@@ -60,7 +60,7 @@ func (s *DockerSensor) Collect(ctx context.Context) (any, error) {
 		return s.collectViaCLI(ctx)
 	}
 
-	var results []DockerContainerStat
+	results := make([]DockerContainerStat, 0, len(containers))
 
 	for _, c := range containers {
 		stat := DockerContainerStat{
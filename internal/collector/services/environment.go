@@ -0,0 +1,127 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment kinds reported by DetectEnvironment.
+const (
+	EnvBareMetal = "bare-metal"
+	EnvVM        = "vm"
+	EnvWSL       = "wsl"
+	EnvCloud     = "cloud"
+)
+
+// azureAssetTag is the DMI chassis asset tag Azure stamps on every VM.
+const azureAssetTag = "7783-7084-3265-9085-8269-3286-77"
+
+// Environment describes what the host runs on. Collection and flagging use
+// it to skip sensors and checks that are meaningless on virtual hardware.
+type Environment struct {
+	Kind       string // one of the Env* constants
+	Hypervisor string // e.g. kvm, hyperv, vmware, xen; empty on bare metal
+	Provider   string // cloud provider (aws, gcp, azure, ...) when Kind is EnvCloud
+}
+
+// Virtualized reports whether disks and thermal sensors are virtual, making
+// SMART health and temperature readings unavailable or fictitious.
+func (e Environment) Virtualized() bool {
+	return e.Kind != "" && e.Kind != EnvBareMetal
+}
+
+// NATLatency reports whether outbound latency includes a NAT hop owned by
+// the host OS, as with WSL2's default networking.
+func (e Environment) NATLatency() bool {
+	return e.Kind == EnvWSL
+}
+
+// DetectEnvironment classifies the host from its kernel string, the
+// virtualization role gopsutil reports, and the DMI identifiers in sysfs.
+func DetectEnvironment(h HostResult) Environment {
+	return detectEnvironment(h, HostSys("class/dmi/id"))
+}
+
+func detectEnvironment(h HostResult, dmiDir string) Environment {
+	kernel := strings.ToLower(h.KernelVersion)
+	if strings.Contains(kernel, "microsoft") || strings.Contains(kernel, "wsl") {
+		return Environment{Kind: EnvWSL, Hypervisor: "hyperv"}
+	}
+
+	dmi := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dmiDir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	vendor, product := dmi("sys_vendor"), dmi("product_name")
+	bios, asset := dmi("bios_vendor"), dmi("chassis_asset_tag")
+
+	hypervisor := ""
+	if h.VirtualizationRole == "guest" && !isContainerRuntime(h.Virtualization) {
+		hypervisor = h.Virtualization
+	}
+
+	provider := ""
+	switch {
+	case strings.Contains(vendor, "Amazon EC2") || strings.HasPrefix(bios, "Amazon"):
+		provider = "aws"
+	case strings.Contains(product, "Google Compute Engine") || vendor == "Google":
+		provider = "gcp"
+	case asset == azureAssetTag:
+		provider = "azure"
+	case strings.HasPrefix(asset, "OracleCloud"):
+		provider = "oci"
+	case vendor == "DigitalOcean":
+		provider = "digitalocean"
+	case vendor == "Hetzner":
+		provider = "hetzner"
+	case strings.Contains(vendor, "Alibaba"):
+		provider = "alibaba"
+	case strings.Contains(product, "OpenStack"):
+		provider = "openstack"
+	}
+	if provider != "" {
+		return Environment{Kind: EnvCloud, Hypervisor: hypervisor, Provider: provider}
+	}
+
+	if hypervisor == "" {
+		hypervisor = hypervisorFromDMI(vendor, product)
+	}
+	if hypervisor != "" {
+		return Environment{Kind: EnvVM, Hypervisor: hypervisor}
+	}
+	return Environment{Kind: EnvBareMetal}
+}
+
+// hypervisorFromDMI recognises common hypervisors by their DMI strings, for
+// when gopsutil cannot determine the virtualization role (e.g. unprivileged).
+func hypervisorFromDMI(vendor, product string) string {
+	switch {
+	case strings.Contains(product, "VirtualBox"):
+		return "vbox"
+	case strings.Contains(vendor, "VMware"):
+		return "vmware"
+	case strings.Contains(vendor, "QEMU") || strings.Contains(product, "KVM"):
+		return "kvm"
+	case vendor == "Xen" || strings.Contains(product, "HVM domU"):
+		return "xen"
+	case vendor == "Microsoft Corporation" && product == "Virtual Machine":
+		return "hyperv"
+	case strings.Contains(vendor, "Parallels"):
+		return "parallels"
+	}
+	return ""
+}
+
+// isContainerRuntime reports whether a gopsutil virtualization system is an
+// OS-level container rather than a hypervisor.
+func isContainerRuntime(system string) bool {
+	switch system {
+	case "docker", "lxc", "podman", "openvz", "rkt", "systemd-nspawn", "wsl":
+		return true
+	}
+	return false
+}
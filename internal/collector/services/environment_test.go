@@ -0,0 +1,59 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	cases := []struct {
+		name string
+		host HostResult
+		dmi  map[string]string
+		want Environment
+	}{
+		{
+			name: "wsl2",
+			host: HostResult{KernelVersion: "5.15.153.1-microsoft-standard-WSL2"},
+			want: Environment{Kind: EnvWSL, Hypervisor: "hyperv"},
+		},
+		{
+			name: "aws",
+			host: HostResult{Virtualization: "kvm", VirtualizationRole: "guest"},
+			dmi:  map[string]string{"sys_vendor": "Amazon EC2", "product_name": "m6i.large"},
+			want: Environment{Kind: EnvCloud, Hypervisor: "kvm", Provider: "aws"},
+		},
+		{
+			name: "azure",
+			host: HostResult{},
+			dmi:  map[string]string{"sys_vendor": "Microsoft Corporation", "product_name": "Virtual Machine", "chassis_asset_tag": azureAssetTag},
+			want: Environment{Kind: EnvCloud, Provider: "azure"},
+		},
+		{
+			name: "local hyper-v",
+			host: HostResult{},
+			dmi:  map[string]string{"sys_vendor": "Microsoft Corporation", "product_name": "Virtual Machine"},
+			want: Environment{Kind: EnvVM, Hypervisor: "hyperv"},
+		},
+		{
+			name: "docker on bare metal",
+			host: HostResult{Virtualization: "docker", VirtualizationRole: "guest"},
+			dmi:  map[string]string{"sys_vendor": "Dell Inc.", "product_name": "PowerEdge R640"},
+			want: Environment{Kind: EnvBareMetal},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, v := range tc.dmi {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(v+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := detectEnvironment(tc.host, dir); got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
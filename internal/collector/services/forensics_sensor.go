@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	gnet "github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ForensicProcess is one entry of the process tree, in depth-first order.
+type ForensicProcess struct {
+	PID     int32   `json:"pid"`
+	PPID    int32   `json:"ppid"`
+	Depth   int     `json:"depth"`
+	Name    string  `json:"name"`
+	User    string  `json:"user,omitempty"`
+	Cmdline string  `json:"cmdline,omitempty"`
+	CPU     float64 `json:"cpu_percent"`
+	RSS     uint64  `json:"rss_bytes"`
+	Status  string  `json:"status,omitempty"`
+}
+
+// ListeningPort is a socket accepting connections or datagrams.
+type ListeningPort struct {
+	Proto   string `json:"proto"`
+	Addr    string `json:"addr"`
+	Port    uint32 `json:"port"`
+	PID     int32  `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+}
+
+// ContainerInspect is the raw `docker inspect` document of one container.
+type ContainerInspect struct {
+	Name    string          `json:"name"`
+	Inspect json.RawMessage `json:"inspect"`
+}
+
+// ForensicsResult is an extended one-off capture for incident analysis.
+type ForensicsResult struct {
+	CapturedAt time.Time          `json:"captured_at"`
+	Processes  []ForensicProcess  `json:"processes"`
+	Ports      []ListeningPort    `json:"ports"`
+	Kernel     []string           `json:"kernel_log"` // dmesg tail
+	Containers []ContainerInspect `json:"containers,omitempty"`
+	Errors     []string           `json:"errors,omitempty"` // parts that could not be collected
+}
+
+// ForensicsSensor captures the full process tree with command lines,
+// listening ports, the kernel log tail, and `docker inspect` of the given
+// containers. It is expensive and meant for on-demand use only.
+type ForensicsSensor struct {
+	containers []string
+	logLines   int
+	redactor   *Redactor
+}
+
+// NewForensicsSensor captures the given containers; command lines are
+// scrubbed with the default redaction patterns.
+func NewForensicsSensor(containers []string, logLines int) *ForensicsSensor {
+	return &ForensicsSensor{containers: containers, logLines: logLines, redactor: DefaultRedactor()}
+}
+
+func (s *ForensicsSensor) Name() string {
+	return "Forensics"
+}
+
+func (s *ForensicsSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *ForensicsSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+// Collect never fails outright; sections that cannot be read are noted in Errors.
+func (s *ForensicsSensor) Collect(ctx context.Context) (any, error) {
+	res := ForensicsResult{CapturedAt: time.Now()}
+	names := make(map[int32]string)
+
+	procs, err := processTree(ctx, s.redactor)
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	for _, p := range procs {
+		names[p.PID] = p.Name
+	}
+	res.Processes = procs
+
+	if res.Ports, err = listeningPorts(ctx, names); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	if res.Kernel, err = kernelLogTail(ctx, s.logLines); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	if len(s.containers) > 0 {
+		if res.Containers, err = dockerInspect(ctx, s.containers); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		}
+	}
+	return res, nil
+}
+
+// processTree lists every process ordered depth-first from the roots.
+func processTree(ctx context.Context, r *Redactor) ([]ForensicProcess, error) {
+	ps, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list processes: %w", err)
+	}
+	all := make([]ForensicProcess, 0, len(ps))
+	for _, p := range ps {
+		fp := ForensicProcess{PID: p.Pid}
+		fp.PPID, _ = p.PpidWithContext(ctx)
+		fp.Name, _ = p.NameWithContext(ctx)
+		fp.User, _ = p.UsernameWithContext(ctx)
+		if cmd, err := p.CmdlineWithContext(ctx); err == nil {
+			fp.Cmdline = r.Redact(cmd)
+		}
+		fp.CPU, _ = p.CPUPercentWithContext(ctx)
+		if mi, err := p.MemoryInfoWithContext(ctx); err == nil && mi != nil {
+			fp.RSS = mi.RSS
+		}
+		if st, err := p.StatusWithContext(ctx); err == nil {
+			fp.Status = strings.Join(st, ",")
+		}
+		all = append(all, fp)
+	}
+	return orderTree(all), nil
+}
+
+// orderTree sorts processes depth-first by PID and sets each one's depth.
+// Processes whose parent is unknown are treated as roots.
+func orderTree(all []ForensicProcess) []ForensicProcess {
+	byPID := make(map[int32]bool, len(all))
+	for _, p := range all {
+		byPID[p.PID] = true
+	}
+	children := make(map[int32][]ForensicProcess)
+	var roots []ForensicProcess
+	for _, p := range all {
+		if p.PPID == p.PID || !byPID[p.PPID] {
+			roots = append(roots, p)
+		} else {
+			children[p.PPID] = append(children[p.PPID], p)
+		}
+	}
+
+	out := make([]ForensicProcess, 0, len(all))
+	var walk func(ps []ForensicProcess, depth int)
+	walk = func(ps []ForensicProcess, depth int) {
+		sort.Slice(ps, func(i, j int) bool { return ps[i].PID < ps[j].PID })
+		for _, p := range ps {
+			p.Depth = depth
+			out = append(out, p)
+			walk(children[p.PID], depth+1)
+		}
+	}
+	walk(roots, 0)
+	return out
+}
+
+// listeningPorts returns TCP listeners and bound UDP sockets.
+func listeningPorts(ctx context.Context, names map[int32]string) ([]ListeningPort, error) {
+	conns, err := gnet.ConnectionsWithContext(ctx, "inet")
+	if err != nil {
+		return nil, fmt.Errorf("list sockets: %w", err)
+	}
+	var ports []ListeningPort
+	for _, c := range conns {
+		udp := c.Type == 2 // SOCK_DGRAM
+		if !(c.Status == "LISTEN" || udp && c.Raddr.IP == "") {
+			continue
+		}
+		proto := "tcp"
+		if udp {
+			proto = "udp"
+		}
+		ports = append(ports, ListeningPort{
+			Proto:   proto,
+			Addr:    c.Laddr.IP,
+			Port:    c.Laddr.Port,
+			PID:     c.Pid,
+			Process: names[c.Pid],
+		})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Proto < ports[j].Proto
+	})
+	return ports, nil
+}
+
+// kernelLogTail returns the last n kernel log lines from dmesg, falling back
+// to the journal when dmesg is restricted.
+func kernelLogTail(ctx context.Context, n int) ([]string, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(cmdCtx, "dmesg", "-T").Output()
+	if err != nil {
+		out, err = exec.CommandContext(cmdCtx, "journalctl", "-k", "--no-pager", "-q", "-o", "short-iso", "-n", fmt.Sprint(n)).Output()
+		if err != nil {
+			return nil, fmt.Errorf("read kernel log: %w", err)
+		}
+	}
+	return tailLines(out, n), nil
+}
+
+func tailLines(out []byte, n int) []string {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// dockerInspect returns the inspect document of each named container.
+func dockerInspect(ctx context.Context, containers []string) ([]ContainerInspect, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(cmdCtx, "docker", append([]string{"inspect"}, containers...)...)
+	cmd.Stderr = &stderr
+	// docker inspect exits non-zero if any container is missing but still
+	// prints the ones it found.
+	out, runErr := cmd.Output()
+	var docs []json.RawMessage
+	if err := json.Unmarshal(out, &docs); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("docker inspect: %w: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("decode docker inspect: %w", err)
+	}
+	res := make([]ContainerInspect, 0, len(docs))
+	for _, d := range docs {
+		var meta struct{ Name string }
+		_ = json.Unmarshal(d, &meta)
+		res = append(res, ContainerInspect{Name: strings.TrimPrefix(meta.Name, "/"), Inspect: d})
+	}
+	if runErr != nil {
+		return res, fmt.Errorf("docker inspect: %s", strings.TrimSpace(stderr.String()))
+	}
+	return res, nil
+}
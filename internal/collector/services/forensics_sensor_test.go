@@ -0,0 +1,37 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderTree(t *testing.T) {
+	got := orderTree([]ForensicProcess{
+		{PID: 30, PPID: 1},
+		{PID: 1, PPID: 0},
+		{PID: 12, PPID: 10},
+		{PID: 10, PPID: 1},
+		{PID: 99, PPID: 98}, // parent already exited
+	})
+	var order []int32
+	var depths []int
+	for _, p := range got {
+		order = append(order, p.PID)
+		depths = append(depths, p.Depth)
+	}
+	if want := []int32{1, 10, 12, 30, 99}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if want := []int{0, 1, 2, 1, 0}; !reflect.DeepEqual(depths, want) {
+		t.Errorf("depths = %v, want %v", depths, want)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	if got := tailLines([]byte("a\nb\nc\n"), 2); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("tailLines = %v", got)
+	}
+	if got := tailLines(nil, 5); got != nil {
+		t.Errorf("tailLines(empty) = %v", got)
+	}
+}
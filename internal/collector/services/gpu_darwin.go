@@ -0,0 +1,19 @@
+//go:build darwin
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// sampleIOAccelerator lists the GPUs' IOAccelerator entries, whose
+// PerformanceStatistics hold utilization and memory in use.
+func sampleIOAccelerator(ctx context.Context) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "ioreg", "-r", "-d", "1", "-c", "IOAccelerator").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ioreg: %w", err)
+	}
+	return out, nil
+}
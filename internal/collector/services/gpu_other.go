@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package services
+
+import "context"
+
+// sampleIOAccelerator is only available on macOS.
+func sampleIOAccelerator(ctx context.Context) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GPUStat is one GPU's load. Readings a vendor does not expose are 0.
+type GPUStat struct {
+	Index         int
+	Vendor        string // nvidia, amd or apple
+	Name          string
+	UtilPct       float64
+	MemUsedBytes  uint64 // VRAM; on Apple Silicon, the unified memory in use by the GPU
+	MemTotalBytes uint64 // 0 for unified memory
+	TemperatureC  float64
+	PowerWatts    float64
+}
+
+// MemPct returns VRAM usage as a percent of its total, or 0 when the total
+// is unknown.
+func (g GPUStat) MemPct() float64 {
+	if g.MemTotalBytes == 0 {
+		return 0
+	}
+	return float64(g.MemUsedBytes) / float64(g.MemTotalBytes) * 100
+}
+
+// GPUResult holds every GPU found.
+type GPUResult struct {
+	GPUs []GPUStat `json:"gpus"`
+}
+
+// GPUSensor reads NVIDIA GPUs through nvidia-smi, which reports NVML's
+// counters without linking against the driver, AMD GPUs through the amdgpu
+// sysfs attributes and Apple GPUs through the IOAccelerator statistics in
+// ioreg. Hosts without a GPU report none.
+type GPUSensor struct {
+	drmDir string // /sys/class/drm
+}
+
+func NewGPUSensor() *GPUSensor {
+	return &GPUSensor{drmDir: HostSys("class", "drm")}
+}
+
+func (s *GPUSensor) Name() string {
+	return "GPU"
+}
+
+func (s *GPUSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *GPUSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *GPUSensor) Collect(ctx context.Context) (any, error) {
+	var res GPUResult
+	if _, err := exec.LookPath("nvidia-smi"); err == nil {
+		out, err := exec.CommandContext(ctx, "nvidia-smi",
+			"--query-gpu=index,name,utilization.gpu,memory.used,memory.total,temperature.gpu,power.draw",
+			"--format=csv,noheader,nounits").Output()
+		if err != nil {
+			return nil, fmt.Errorf("nvidia-smi: %w", err)
+		}
+		res.GPUs = append(res.GPUs, parseNvidiaSMI(out)...)
+	}
+	res.GPUs = append(res.GPUs, s.amdGPUs(len(res.GPUs))...)
+
+	out, err := sampleIOAccelerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.GPUs = append(res.GPUs, parseIOAccelerator(out, len(res.GPUs))...)
+	return res, nil
+}
+
+// parseNvidiaSMI parses nvidia-smi's CSV output, one GPU per line with
+// memory in MiB. Readings the card does not support are "[N/A]".
+func parseNvidiaSMI(out []byte) []GPUStat {
+	var gpus []GPUStat
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Split(sc.Text(), ",")
+		if len(fields) < 7 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		num := func(s string) float64 {
+			v, _ := strconv.ParseFloat(s, 64)
+			return v
+		}
+		gpus = append(gpus, GPUStat{
+			Index:         index,
+			Vendor:        "nvidia",
+			Name:          fields[1],
+			UtilPct:       num(fields[2]),
+			MemUsedBytes:  uint64(num(fields[3])) << 20,
+			MemTotalBytes: uint64(num(fields[4])) << 20,
+			TemperatureC:  num(fields[5]),
+			PowerWatts:    num(fields[6]),
+		})
+	}
+	return gpus
+}
+
+// amdVendorID is the PCI vendor ID of AMD GPUs.
+const amdVendorID = "0x1002"
+
+// amdGPUs reads the amdgpu driver's sysfs attributes, numbering the cards
+// after the first GPU index given.
+func (s *GPUSensor) amdGPUs(first int) []GPUStat {
+	cards, _ := filepath.Glob(filepath.Join(s.drmDir, "card[0-9]*"))
+	var gpus []GPUStat
+	for _, card := range cards {
+		if strings.Contains(filepath.Base(card), "-") {
+			continue // a connector, such as card0-DP-1
+		}
+		dev := filepath.Join(card, "device")
+		if vendor, ok := readTrimmed(filepath.Join(dev, "vendor")); !ok || vendor != amdVendorID {
+			continue
+		}
+		busy, ok := readInt(filepath.Join(dev, "gpu_busy_percent"))
+		if !ok {
+			continue // not driven by amdgpu
+		}
+		g := GPUStat{Index: first + len(gpus), Vendor: "amd", Name: filepath.Base(card), UtilPct: float64(busy)}
+		if name, ok := readTrimmed(filepath.Join(dev, "product_name")); ok && name != "" {
+			g.Name = name
+		}
+		if n, ok := readInt(filepath.Join(dev, "mem_info_vram_used")); ok {
+			g.MemUsedBytes = uint64(n)
+		}
+		if n, ok := readInt(filepath.Join(dev, "mem_info_vram_total")); ok {
+			g.MemTotalBytes = uint64(n)
+		}
+		hwmons, _ := filepath.Glob(filepath.Join(dev, "hwmon", "hwmon*"))
+		for _, hw := range hwmons {
+			if n, ok := readInt(filepath.Join(hw, "temp1_input")); ok {
+				g.TemperatureC = float64(n) / 1000
+			}
+			// Newer kernels report power1_input, older ones power1_average.
+			if n, ok := readInt(filepath.Join(hw, "power1_input")); ok {
+				g.PowerWatts = float64(n) / 1e6
+			} else if n, ok := readInt(filepath.Join(hw, "power1_average")); ok {
+				g.PowerWatts = float64(n) / 1e6
+			}
+		}
+		gpus = append(gpus, g)
+	}
+	return gpus
+}
+
+var (
+	ioregModelRe = regexp.MustCompile(`"model" = "([^"]+)"`)
+	ioregStatRe  = regexp.MustCompile(`"(Device Utilization %|In use system memory)"=(\d+)`)
+)
+
+// parseIOAccelerator parses `ioreg -r -d 1 -c IOAccelerator` output, one
+// entry per GPU, numbering them after the first GPU index given.
+func parseIOAccelerator(out []byte, first int) []GPUStat {
+	var gpus []GPUStat
+	var g *GPUStat
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "+-o ") {
+			gpus = append(gpus, GPUStat{Index: first + len(gpus), Vendor: "apple"})
+			g = &gpus[len(gpus)-1]
+			continue
+		}
+		if g == nil {
+			continue
+		}
+		if m := ioregModelRe.FindStringSubmatch(line); m != nil {
+			g.Name = m[1]
+		}
+		for _, m := range ioregStatRe.FindAllStringSubmatch(line, -1) {
+			v, _ := strconv.ParseUint(m[2], 10, 64)
+			if m[1] == "Device Utilization %" {
+				g.UtilPct = float64(v)
+			} else {
+				g.MemUsedBytes = v
+			}
+		}
+	}
+	return gpus
+}
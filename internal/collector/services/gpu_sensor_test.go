@@ -0,0 +1,71 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNvidiaSMI(t *testing.T) {
+	out := []byte("0, NVIDIA A100-SXM4-40GB, 87, 30512, 40960, 71, 312.45\n1, Tesla T4, 0, 5, 15360, 38, [N/A]\n")
+	gpus := parseNvidiaSMI(out)
+	if len(gpus) != 2 {
+		t.Fatalf("got %d GPUs, want 2", len(gpus))
+	}
+	a := gpus[0]
+	if a.Name != "NVIDIA A100-SXM4-40GB" || a.UtilPct != 87 || a.MemUsedBytes != 30512<<20 || a.TemperatureC != 71 || a.PowerWatts != 312.45 {
+		t.Errorf("gpu 0 = %+v", a)
+	}
+	if pct := a.MemPct(); pct < 74 || pct > 75 {
+		t.Errorf("MemPct = %.1f, want 74.5", pct)
+	}
+	if gpus[1].Index != 1 || gpus[1].PowerWatts != 0 {
+		t.Errorf("gpu 1 = %+v, want no power reading", gpus[1])
+	}
+}
+
+func TestParseIOAccelerator(t *testing.T) {
+	out := []byte(`+-o AGXAcceleratorG13X  <class AGXAcceleratorG13X, id 0x1000003e9, registered, matched, active, busy 0 (0 ms), retain 29>
+    {
+      "model" = "Apple M1 Pro"
+      "PerformanceStatistics" = {"In use system memory (driver)"=0,"Alloc system memory"=1649508352,"Tiler Utilization %"=3,"Renderer Utilization %"=11,"Device Utilization %"=12,"In use system memory"=419954688}
+    }
+`)
+	gpus := parseIOAccelerator(out, 0)
+	if len(gpus) != 1 {
+		t.Fatalf("got %d GPUs, want 1", len(gpus))
+	}
+	if g := gpus[0]; g.Name != "Apple M1 Pro" || g.UtilPct != 12 || g.MemUsedBytes != 419954688 || g.MemTotalBytes != 0 {
+		t.Errorf("gpu = %+v", g)
+	}
+}
+
+func TestGPUSensorAMDSysfs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o444); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("card1/device/vendor", "0x1002\n")
+	write("card1/device/gpu_busy_percent", "64\n")
+	write("card1/device/mem_info_vram_used", "2147483648\n")
+	write("card1/device/mem_info_vram_total", "8589934592\n")
+	write("card1/device/hwmon/hwmon3/temp1_input", "58000\n")
+	write("card1/device/hwmon/hwmon3/power1_average", "95000000\n")
+	write("card1-DP-1/status", "connected\n")
+	write("card0/device/vendor", "0x8086\n") // integrated Intel graphics
+
+	gpus := (&GPUSensor{drmDir: dir}).amdGPUs(1)
+	if len(gpus) != 1 {
+		t.Fatalf("got %+v, want the AMD card only", gpus)
+	}
+	g := gpus[0]
+	if g.Index != 1 || g.Name != "card1" || g.UtilPct != 64 || g.MemPct() != 25 || g.TemperatureC != 58 || g.PowerWatts != 95 {
+		t.Errorf("gpu = %+v", g)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// HostProc, HostSys and HostRoot resolve host paths the same way gopsutil
+// does: under $HOST_PROC, $HOST_SYS and $HOST_ROOT when set, so sensors
+// running in a container read the host's view through its mounts.
+func HostProc(elem ...string) string { return hostPath("HOST_PROC", "/proc", elem) }
+
+func HostSys(elem ...string) string { return hostPath("HOST_SYS", "/sys", elem) }
+
+func HostRoot(elem ...string) string { return hostPath("HOST_ROOT", "/", elem) }
+
+func hostPath(env, def string, elem []string) string {
+	base := os.Getenv(env)
+	if base == "" {
+		base = def
+	}
+	return filepath.Join(append([]string{base}, elem...)...)
+}
+
+// hostRootSet reports whether a non-default host root is configured.
+func hostRootSet() bool {
+	r := os.Getenv("HOST_ROOT")
+	return r != "" && filepath.Clean(r) != "/"
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestHostPaths(t *testing.T) {
+	t.Setenv("HOST_PROC", "")
+	t.Setenv("HOST_ROOT", "")
+	if got := HostProc("sys/kernel/core_pattern"); got != "/proc/sys/kernel/core_pattern" {
+		t.Errorf("HostProc default = %q", got)
+	}
+	if hostRootSet() {
+		t.Error("hostRootSet with HOST_ROOT unset")
+	}
+
+	t.Setenv("HOST_SYS", "/host/sys")
+	t.Setenv("HOST_ROOT", "/host")
+	if got := HostSys("class", "hwmon"); got != "/host/sys/class/hwmon" {
+		t.Errorf("HostSys = %q", got)
+	}
+	if got := HostRoot("/var/lib"); got != "/host/var/lib" {
+		t.Errorf("HostRoot = %q", got)
+	}
+	if !hostRootSet() {
+		t.Error("hostRootSet with HOST_ROOT=/host")
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OOMKillEvent is a process terminated by the kernel OOM killer.
+type OOMKillEvent struct {
+	At           time.Time
+	PID          int32
+	Process      string
+	Cgroup       string // task_memcg, e.g. /system.slice/app.service
+	UID          int32
+	AnonRSSBytes uint64
+	Constraint   string // CONSTRAINT_NONE (global) or CONSTRAINT_MEMCG (cgroup limit)
+}
+
+type JournalResult struct {
+	Available bool
+	OOMKills  []OOMKillEvent
+}
+
+// JournalSensor reads new kernel journal entries since the previous call and
+// extracts OOM-kill events. It needs journalctl and read access to the journal.
+type JournalSensor struct {
+	mu     sync.Mutex
+	cursor string
+	since  time.Time
+}
+
+func NewJournalSensor() *JournalSensor {
+	return &JournalSensor{since: time.Now()}
+}
+
+func (s *JournalSensor) Name() string {
+	return "Journal"
+}
+
+func (s *JournalSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *JournalSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *JournalSensor) Collect(ctx context.Context) (any, error) {
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return JournalResult{Available: false}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	args := []string{"-k", "-o", "json", "--no-pager", "-q"}
+	if hostRootSet() {
+		args = append(args, "--root="+HostRoot())
+	}
+	if s.cursor != "" {
+		args = append(args, "--after-cursor="+s.cursor)
+	} else {
+		args = append(args, fmt.Sprintf("--since=@%d", s.since.Unix()))
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(readCtx, "journalctl", args...).Output()
+	if err != nil {
+		// No journal access (permissions, containers without journald) is not fatal.
+		return JournalResult{Available: false}, nil
+	}
+
+	entries, cursor := parseJournalJSON(output)
+	if cursor != "" {
+		s.cursor = cursor
+	}
+	return JournalResult{Available: true, OOMKills: ParseOOMKills(entries)}, nil
+}
+
+// JournalEntry is a single kernel log message.
+type JournalEntry struct {
+	At      time.Time
+	Message string
+}
+
+// parseJournalJSON decodes `journalctl -o json` output and returns the entries
+// with the cursor of the last one.
+func parseJournalJSON(output []byte) ([]JournalEntry, string) {
+	var entries []JournalEntry
+	var cursor string
+
+	sc := bufio.NewScanner(bytes.NewReader(output))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var rec struct {
+			Message  json.RawMessage `json:"MESSAGE"`
+			Realtime string          `json:"__REALTIME_TIMESTAMP"`
+			Cursor   string          `json:"__CURSOR"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Cursor != "" {
+			cursor = rec.Cursor
+		}
+		var msg string
+		if err := json.Unmarshal(rec.Message, &msg); err != nil {
+			continue // binary messages are encoded as byte arrays
+		}
+		at := time.Now()
+		if us, err := strconv.ParseInt(rec.Realtime, 10, 64); err == nil {
+			at = time.UnixMicro(us)
+		}
+		entries = append(entries, JournalEntry{At: at, Message: msg})
+	}
+	return entries, cursor
+}
+
+var (
+	killedProcessRe = regexp.MustCompile(`Killed process (\d+) \((.*?)\)`)
+	anonRSSRe       = regexp.MustCompile(`anon-rss:(\d+)kB`)
+	killedUIDRe     = regexp.MustCompile(`UID:(\d+)`)
+)
+
+// ParseOOMKills extracts OOM-kill events from kernel messages. The kernel logs an
+// "oom-kill:" summary line (cgroup, task, pid) followed by "Killed process" with
+// memory details; both are merged per PID.
+func ParseOOMKills(entries []JournalEntry) []OOMKillEvent {
+	var events []OOMKillEvent
+	index := make(map[int32]int) // pid -> position in events
+
+	get := func(pid int32, at time.Time) *OOMKillEvent {
+		if i, ok := index[pid]; ok {
+			return &events[i]
+		}
+		index[pid] = len(events)
+		events = append(events, OOMKillEvent{At: at, PID: pid})
+		return &events[len(events)-1]
+	}
+
+	for _, e := range entries {
+		if i := strings.Index(e.Message, "oom-kill:"); i >= 0 {
+			fields := make(map[string]string)
+			for _, kv := range strings.Split(e.Message[i+len("oom-kill:"):], ",") {
+				if k, v, ok := strings.Cut(strings.TrimSpace(kv), "="); ok {
+					fields[k] = v
+				}
+			}
+			pid, err := strconv.ParseInt(fields["pid"], 10, 32)
+			if err != nil {
+				continue
+			}
+			ev := get(int32(pid), e.At)
+			if ev.Process == "" {
+				ev.Process = fields["task"]
+			}
+			ev.Cgroup = fields["task_memcg"]
+			ev.Constraint = fields["constraint"]
+			if uid, err := strconv.ParseInt(fields["uid"], 10, 32); err == nil {
+				ev.UID = int32(uid)
+			}
+			continue
+		}
+
+		m := killedProcessRe.FindStringSubmatch(e.Message)
+		if m == nil {
+			continue
+		}
+		pid, err := strconv.ParseInt(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		ev := get(int32(pid), e.At)
+		ev.Process = m[2]
+		if rss := anonRSSRe.FindStringSubmatch(e.Message); rss != nil {
+			kb, _ := strconv.ParseUint(rss[1], 10, 64)
+			ev.AnonRSSBytes = kb * 1024
+		}
+		if uid := killedUIDRe.FindStringSubmatch(e.Message); uid != nil {
+			if v, err := strconv.ParseInt(uid[1], 10, 32); err == nil {
+				ev.UID = int32(v)
+			}
+		}
+	}
+	return events
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOOMKills(t *testing.T) {
+	at := time.Date(2025, 3, 4, 3, 12, 0, 0, time.UTC)
+	entries := []JournalEntry{
+		{At: at, Message: "myapp invoked oom-killer: gfp_mask=0x140cca(GFP_HIGHUSER_MOVABLE|__GFP_COMP), order=0, oom_score_adj=0"},
+		{At: at, Message: "oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,oom_memcg=/system.slice/myapp.service,task_memcg=/system.slice/myapp.service,task=myapp,pid=4242,uid=1000"},
+		{At: at, Message: "Memory cgroup out of memory: Killed process 4242 (myapp) total-vm:2097152kB, anon-rss:1048576kB, file-rss:0kB, shmem-rss:0kB, UID:1000 pgtables:2200kB oom_score_adj:0"},
+		{At: at.Add(time.Hour), Message: "Out of memory: Killed process 17 (java worker) total-vm:10kB, anon-rss:512kB, file-rss:0kB, shmem-rss:0kB, UID:0 pgtables:1kB oom_score_adj:0"},
+		{At: at, Message: "usb 1-1: new high-speed USB device number 3"},
+	}
+
+	got := ParseOOMKills(entries)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(got), got)
+	}
+
+	first := got[0]
+	if first.PID != 4242 || first.Process != "myapp" || first.Cgroup != "/system.slice/myapp.service" ||
+		first.UID != 1000 || first.AnonRSSBytes != 1<<30 || first.Constraint != "CONSTRAINT_MEMCG" || !first.At.Equal(at) {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+
+	second := got[1]
+	if second.PID != 17 || second.Process != "java worker" || second.Cgroup != "" || second.AnonRSSBytes != 512*1024 {
+		t.Errorf("unexpected second event: %+v", second)
+	}
+}
+
+func TestParseJournalJSON(t *testing.T) {
+	out := []byte(`{"MESSAGE":"Out of memory: Killed process 9 (a) anon-rss:4kB","__REALTIME_TIMESTAMP":"1700000000000000","__CURSOR":"s=1"}
+{"MESSAGE":[1,2,3],"__REALTIME_TIMESTAMP":"1700000001000000","__CURSOR":"s=2"}
+not json
+`)
+	entries, cursor := parseJournalJSON(out)
+	if cursor != "s=2" {
+		t.Errorf("cursor = %q, want s=2", cursor)
+	}
+	if len(entries) != 1 || !entries[0].At.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+}
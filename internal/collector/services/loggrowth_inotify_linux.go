@@ -0,0 +1,105 @@
+//go:build linux
+
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_MOVED_TO
+
+// inotifyWatcher watches directories (recursively) with a non-blocking inotify fd.
+type inotifyWatcher struct {
+	fd   int
+	dirs map[int32]string // watch descriptor -> directory
+	buf  []byte
+}
+
+func newChangeWatcher(dirs []string) (changeWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	w := &inotifyWatcher{fd: fd, dirs: make(map[int32]string), buf: make([]byte, 64*1024)}
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				w.add(p)
+			}
+			return nil
+		})
+	}
+	if len(w.dirs) == 0 {
+		syscall.Close(fd)
+		return nil, os.ErrNotExist
+	}
+	return w, nil
+}
+
+func (w *inotifyWatcher) add(dir string) {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err == nil {
+		w.dirs[int32(wd)] = dir
+	}
+}
+
+func (w *inotifyWatcher) Drain() ([]string, bool) {
+	changed := make(map[string]bool)
+	overflow := false
+
+	for {
+		n, err := syscall.Read(w.fd, w.buf)
+		if err != nil || n <= 0 {
+			break // EAGAIN: queue drained
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&w.buf[off]))
+			nameStart := off + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(ev.Len)
+			if nameEnd > n {
+				break
+			}
+			name := strings.TrimRight(string(w.buf[nameStart:nameEnd]), "\x00")
+			off = nameEnd
+
+			switch {
+			case ev.Mask&syscall.IN_Q_OVERFLOW != 0:
+				overflow = true
+			case ev.Mask&syscall.IN_IGNORED != 0:
+				delete(w.dirs, ev.Wd)
+			case name == "":
+			case ev.Mask&syscall.IN_ISDIR != 0:
+				// New subdirectory: watch it and pick up files written before the watch existed.
+				dir, ok := w.dirs[ev.Wd]
+				if !ok {
+					continue
+				}
+				sub := filepath.Join(dir, name)
+				w.add(sub)
+				if entries, err := os.ReadDir(sub); err == nil {
+					for _, e := range entries {
+						changed[filepath.Join(sub, e.Name())] = true
+					}
+				}
+			default:
+				if dir, ok := w.dirs[ev.Wd]; ok {
+					changed[filepath.Join(dir, name)] = true
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(changed))
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	return paths, overflow
+}
+
+func (w *inotifyWatcher) Close() error {
+	return syscall.Close(w.fd)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package services
+
+import "errors"
+
+// newChangeWatcher is only implemented with inotify; other platforms rescan.
+func newChangeWatcher(dirs []string) (changeWatcher, error) {
+	return nil, errors.New("change notifications not supported on this platform")
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileGrowth is a file that grew since the previous collection.
+type FileGrowth struct {
+	Path      string
+	SizeBytes uint64
+	GrowthBps float64
+}
+
+type LogGrowthResult struct {
+	Files []FileGrowth // fastest growing first
+}
+
+// changeWatcher reports files that may have changed since the last drain.
+type changeWatcher interface {
+	// Drain returns changed paths; overflow means events were lost and a rescan is needed.
+	Drain() (paths []string, overflow bool)
+	Close() error
+}
+
+// LogGrowthSensor tracks the fastest-growing files under a set of directories.
+// On Linux it uses inotify so only modified files are re-stat'ed; elsewhere it
+// rescans the directories on every collection.
+type LogGrowthSensor struct {
+	dirs []string
+	top  int
+
+	mu      sync.Mutex
+	started bool
+	watcher changeWatcher
+	sizes   map[string]uint64
+	lastAt  time.Time
+}
+
+func NewLogGrowthSensor(dirs []string, top int) *LogGrowthSensor {
+	return &LogGrowthSensor{
+		dirs:  append([]string(nil), dirs...),
+		top:   top,
+		sizes: make(map[string]uint64),
+	}
+}
+
+func (s *LogGrowthSensor) Name() string {
+	return "LogGrowth"
+}
+
+func (s *LogGrowthSensor) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start()
+	return nil
+}
+
+func (s *LogGrowthSensor) Disconnect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher != nil {
+		err := s.watcher.Close()
+		s.watcher = nil
+		return err
+	}
+	return nil
+}
+
+// start sets up the watcher once; failure (no inotify, watch limit reached) falls back to rescans.
+func (s *LogGrowthSensor) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	if w, err := newChangeWatcher(s.dirs); err == nil {
+		s.watcher = w
+	}
+}
+
+func (s *LogGrowthSensor) Collect(ctx context.Context) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start()
+
+	now := time.Now()
+	baseline := s.lastAt.IsZero()
+
+	var paths []string
+	full := baseline || s.watcher == nil
+	if !full {
+		var overflow bool
+		paths, overflow = s.watcher.Drain()
+		full = overflow
+	}
+	if full {
+		paths = s.scan()
+	}
+
+	seen := make(map[string]bool, len(paths))
+	grown := make(map[string]uint64)
+	for _, p := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			delete(s.sizes, p)
+			continue
+		}
+		seen[p] = true
+		size := uint64(info.Size())
+		prev := s.sizes[p]
+		s.sizes[p] = size
+		// Files created since the last collection count from zero; truncation or rotation counts as no growth.
+		if !baseline && size > prev {
+			grown[p] = size - prev
+		}
+	}
+	if full {
+		for p := range s.sizes {
+			if !seen[p] {
+				delete(s.sizes, p)
+			}
+		}
+	}
+
+	elapsed := now.Sub(s.lastAt).Seconds()
+	s.lastAt = now
+	if baseline || elapsed <= 0 {
+		return LogGrowthResult{}, nil
+	}
+
+	files := make([]FileGrowth, 0, len(grown))
+	for p, delta := range grown {
+		files = append(files, FileGrowth{Path: p, SizeBytes: s.sizes[p], GrowthBps: float64(delta) / elapsed})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].GrowthBps != files[j].GrowthBps {
+			return files[i].GrowthBps > files[j].GrowthBps
+		}
+		return files[i].Path < files[j].Path
+	})
+	if s.top > 0 && len(files) > s.top {
+		files = files[:s.top]
+	}
+	return LogGrowthResult{Files: files}, nil
+}
+
+// scan lists regular files under the watched directories.
+func (s *LogGrowthSensor) scan() []string {
+	var paths []string
+	for _, dir := range s.dirs {
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // unreadable entries are skipped, not fatal
+			}
+			if d.Type().IsRegular() {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+	}
+	return paths
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendBytes(t *testing.T, path string, n int) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Repeat("x", n)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func collectGrowth(t *testing.T, s *LogGrowthSensor) []FileGrowth {
+	t.Helper()
+	res, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.(LogGrowthResult).Files
+}
+
+func TestLogGrowthSensorRanksGrowers(t *testing.T) {
+	dir := t.TempDir()
+	quiet := filepath.Join(dir, "quiet.log")
+	busy := filepath.Join(dir, "app", "busy.log")
+	if err := os.Mkdir(filepath.Dir(busy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	appendBytes(t, quiet, 10)
+	appendBytes(t, busy, 10)
+
+	s := NewLogGrowthSensor([]string{dir}, 2)
+	defer s.Disconnect(context.Background())
+
+	if files := collectGrowth(t, s); len(files) != 0 {
+		t.Fatalf("baseline collection reported growth: %+v", files)
+	}
+
+	appendBytes(t, quiet, 100)
+	appendBytes(t, busy, 5000)
+	appendBytes(t, filepath.Join(dir, "new.log"), 1000)
+
+	files := collectGrowth(t, s)
+	if len(files) != 2 {
+		t.Fatalf("expected top 2 growers, got %+v", files)
+	}
+	if files[0].Path != busy || files[0].SizeBytes != 5010 {
+		t.Errorf("fastest grower = %+v, want %s", files[0], busy)
+	}
+	if files[1].Path != filepath.Join(dir, "new.log") {
+		t.Errorf("second grower = %+v, want new.log", files[1])
+	}
+
+	if files := collectGrowth(t, s); len(files) != 0 {
+		t.Errorf("idle collection reported growth: %+v", files)
+	}
+}
+
+func TestLogGrowthSensorIgnoresTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rotated.log")
+	appendBytes(t, path, 1000)
+
+	s := NewLogGrowthSensor([]string{dir}, 5)
+	defer s.Disconnect(context.Background())
+	collectGrowth(t, s)
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if files := collectGrowth(t, s); len(files) != 0 {
+		t.Errorf("truncation reported as growth: %+v", files)
+	}
+}
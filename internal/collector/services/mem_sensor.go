@@ -18,6 +18,7 @@ type MemResult struct {
 	SwapUsage      float64
 	SwapTotal      uint64
 	SwapUsed       uint64
+	SwapIn         uint64 // bytes swapped in since boot
 	Active         uint64
 	Inactive       uint64
 	Wired          uint64
@@ -77,10 +78,12 @@ func (s *MemSensor) Collect(ctx context.Context) (any, error) {
 	swapUsage := 0.0
 	swapTotal := v.SwapTotal
 	swapUsed := v.SwapTotal - v.SwapFree
+	var swapIn uint64
 	if swapErr == nil && swapStat != nil {
 		swapUsage = swapStat.UsedPercent
 		swapTotal = swapStat.Total
 		swapUsed = swapStat.Used
+		swapIn = swapStat.Sin
 	}
 
 	return MemResult{
@@ -94,6 +97,7 @@ func (s *MemSensor) Collect(ctx context.Context) (any, error) {
 		SwapUsage:      swapUsage,
 		SwapTotal:      swapTotal,
 		SwapUsed:       swapUsed,
+		SwapIn:         swapIn,
 		Active:         v.Active,
 		Inactive:       v.Inactive,
 		Wired:          v.Wired,
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/shirou/gopsutil/v4/net"
 )
@@ -17,16 +18,20 @@ type NetInterfaceStats struct {
 	ErrOut      uint64
 	DropIn      uint64
 	DropOut     uint64
+	Link        LinkInfo
 }
 
 type NetResult struct {
 	Interfaces []NetInterfaceStats
 }
 
-type NetSensor struct{}
+type NetSensor struct {
+	mu       sync.Mutex
+	maxSpeed map[string]int // interface -> supported speed; probed once per interface
+}
 
 func NewNetSensor() *NetSensor {
-	return &NetSensor{}
+	return &NetSensor{maxSpeed: make(map[string]int)}
 }
 
 func (s *NetSensor) Name() string {
@@ -47,8 +52,12 @@ func (s *NetSensor) Collect(ctx context.Context) (any, error) {
 		return nil, fmt.Errorf("failed to get net io counters: %w", err)
 	}
 
-	var stats []NetInterfaceStats
+	stats := make([]NetInterfaceStats, 0, len(counters))
 	for _, c := range counters {
+		link := readLink(c.Name)
+		if link.SpeedMbps > 0 {
+			link.MaxSpeedMbps = s.supportedSpeed(ctx, c.Name)
+		}
 		stats = append(stats, NetInterfaceStats{
 			Name:        c.Name,
 			BytesSent:   c.BytesSent,
@@ -59,8 +68,21 @@ func (s *NetSensor) Collect(ctx context.Context) (any, error) {
 			ErrOut:      c.Errout,
 			DropIn:      c.Dropin,
 			DropOut:     c.Dropout,
+			Link:        link,
 		})
 	}
 
 	return NetResult{Interfaces: stats}, nil
 }
+
+// supportedSpeed caches ethtool's answer; supported modes do not change.
+func (s *NetSensor) supportedSpeed(ctx context.Context, name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := s.maxSpeed[name]; ok {
+		return n
+	}
+	n := ethtoolMaxSpeed(ctx, name)
+	s.maxSpeed[name] = n
+	return n
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LinkInfo is the physical link state of an interface.
+type LinkInfo struct {
+	OperState      string // up, down, dormant, unknown...
+	SpeedMbps      int    // negotiated speed; 0 when unknown or down
+	MaxSpeedMbps   int    // fastest supported link mode; 0 when unknown
+	Duplex         string // full, half or ""
+	CarrierChanges uint64 // link up/down transitions since boot
+}
+
+// readLink reads the link state from sysfs, falling back to the interface
+// flags where sysfs is unavailable.
+func readLink(name string) LinkInfo {
+	dir := HostSys("class", "net", name)
+	var li LinkInfo
+	if b, ok := readTrimmed(dir + "/operstate"); ok {
+		li.OperState = b
+	} else if ifc, err := net.InterfaceByName(name); err == nil {
+		li.OperState = "down"
+		if ifc.Flags&net.FlagUp != 0 {
+			li.OperState = "up"
+		}
+	}
+	// speed and duplex fail with EINVAL while the link is down.
+	if n, ok := readInt(dir + "/speed"); ok && n > 0 {
+		li.SpeedMbps = int(n)
+	}
+	if d, ok := readTrimmed(dir + "/duplex"); ok && (d == "full" || d == "half") {
+		li.Duplex = d
+	}
+	if n, ok := readInt(dir + "/carrier_changes"); ok && n > 0 {
+		li.CarrierChanges = uint64(n)
+	}
+	return li
+}
+
+// ethtoolMaxSpeed returns the fastest link mode ethtool reports as
+// supported, or 0 when ethtool is missing or the driver does not say.
+func ethtoolMaxSpeed(ctx context.Context, name string) int {
+	cmdCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(cmdCtx, "ethtool", name).Output()
+	if err != nil {
+		return 0
+	}
+	return parseSupportedSpeed(out)
+}
+
+var linkModePattern = regexp.MustCompile(`(\d+)base`)
+
+// parseSupportedSpeed scans the "Supported link modes" block of ethtool
+// output, which continues on indented lines without a colon.
+func parseSupportedSpeed(out []byte) int {
+	best := 0
+	inBlock := false
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if _, rest, ok := strings.Cut(line, "Supported link modes:"); ok {
+			inBlock = true
+			line = rest
+		} else if inBlock && strings.Contains(line, ":") {
+			break
+		}
+		if !inBlock {
+			continue
+		}
+		for _, m := range linkModePattern.FindAllStringSubmatch(line, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > best {
+				best = n
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSupportedSpeed(t *testing.T) {
+	out := []byte(`Settings for eno1:
+	Supported ports: [ TP ]
+	Supported link modes:   10baseT/Half 10baseT/Full
+	                        100baseT/Half 100baseT/Full
+	                        1000baseT/Full
+	Supported pause frame use: No
+	Supports auto-negotiation: Yes
+	Advertised link modes:  10000baseT/Full
+	Speed: 100Mb/s
+	Duplex: Full
+`)
+	if got := parseSupportedSpeed(out); got != 1000 {
+		t.Errorf("parseSupportedSpeed = %d, want 1000", got)
+	}
+	if got := parseSupportedSpeed([]byte("Settings for wg0:\n\tLink detected: yes\n")); got != 0 {
+		t.Errorf("parseSupportedSpeed without link modes = %d, want 0", got)
+	}
+}
+
+// fakeSysNet writes n interfaces' link attributes under a HOST_SYS root.
+func fakeSysNet(tb testing.TB, n int) []string {
+	root := tb.TempDir()
+	tb.Setenv("HOST_SYS", root)
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("veth%d", i)
+		dir := filepath.Join(root, "class", "net", names[i])
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		for file, v := range map[string]string{"operstate": "up\n", "speed": "10000\n", "duplex": "full\n", "carrier_changes": "3\n"} {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(v), 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	return names
+}
+
+func TestReadLink(t *testing.T) {
+	names := fakeSysNet(t, 1)
+	want := LinkInfo{OperState: "up", SpeedMbps: 10000, Duplex: "full", CarrierChanges: 3}
+	if got := readLink(names[0]); got != want {
+		t.Errorf("readLink = %+v, want %+v", got, want)
+	}
+
+	// Longer than the pooled buffer: read in full.
+	long := strings.Repeat("x", 3*smallFileBuf)
+	path := filepath.Join(t.TempDir(), "long")
+	os.WriteFile(path, []byte(" "+long+"\n"), 0o644)
+	if got, ok := readTrimmed(path); !ok || got != long {
+		t.Errorf("readTrimmed of a long file = %d bytes, %t", len(got), ok)
+	}
+	if _, ok := readTrimmed(path + ".missing"); ok {
+		t.Error("readTrimmed of a missing file succeeded")
+	}
+}
+
+// BenchmarkReadLink reads the link state of 100 interfaces, as a network
+// collection does on a host running 100 containers.
+func BenchmarkReadLink(b *testing.B) {
+	names := fakeSysNet(b, 100)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, name := range names {
+			readLink(name)
+		}
+	}
+}
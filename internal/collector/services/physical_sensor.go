@@ -36,9 +36,6 @@ func (s *PhysicalSensor) Disconnect(ctx context.Context) error {
 
 func (s *PhysicalSensor) Collect(ctx context.Context) (any, error) {
 	data, err := sensors.TemperaturesWithContext(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get temperatures: %w", err)
-	}
 
 	var temps []TempStat
 	for _, t := range data {
@@ -47,6 +44,12 @@ func (s *PhysicalSensor) Collect(ctx context.Context) (any, error) {
 			Temperature: t.Temperature,
 		})
 	}
+	if len(temps) == 0 {
+		temps = platformTemperatures(ctx)
+	}
+	if len(temps) == 0 && err != nil {
+		return nil, fmt.Errorf("failed to get temperatures: %w", err)
+	}
 
 	return PhysicalResult{Temperatures: temps}, nil
 }
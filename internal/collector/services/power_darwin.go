@@ -0,0 +1,23 @@
+//go:build darwin
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// samplePowermetrics takes one 1s powermetrics sample. It returns nil output
+// when powermetrics is missing or we are not root.
+func samplePowermetrics(ctx context.Context) ([]byte, error) {
+	if _, err := exec.LookPath("powermetrics"); err != nil || os.Geteuid() != 0 {
+		return nil, nil
+	}
+	out, err := exec.CommandContext(ctx, "powermetrics", "--samplers", "cpu_power,gpu_power,thermal,smc", "-n", "1", "-i", "1000").Output()
+	if err != nil {
+		return nil, fmt.Errorf("powermetrics: %w", err)
+	}
+	return out, nil
+}
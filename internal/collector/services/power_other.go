@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package services
+
+import "context"
+
+// samplePowermetrics is only available on macOS.
+func samplePowermetrics(ctx context.Context) ([]byte, error) {
+	return nil, nil
+}
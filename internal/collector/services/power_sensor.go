@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PowerResult holds SoC power and thermal readings from macOS powermetrics.
+type PowerResult struct {
+	Available       bool
+	EClusterPct     float64 // mean active residency of the efficiency clusters
+	PClusterPct     float64 // mean active residency of the performance clusters
+	CPUWatts        float64
+	GPUWatts        float64
+	ANEWatts        float64
+	TotalWatts      float64
+	ThermalPressure string // Nominal, Moderate, Heavy, Trapping or Sleeping
+	Temperatures    []TempStat
+}
+
+// PowerSensor samples Apple Silicon power, cluster utilization and thermal
+// pressure via powermetrics, which gopsutil does not cover. powermetrics
+// needs root; elsewhere the sensor reports Available=false.
+type PowerSensor struct{}
+
+func NewPowerSensor() *PowerSensor {
+	return &PowerSensor{}
+}
+
+func (s *PowerSensor) Name() string {
+	return "Power"
+}
+
+func (s *PowerSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *PowerSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *PowerSensor) Collect(ctx context.Context) (any, error) {
+	out, err := samplePowermetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return PowerResult{Available: false}, nil
+	}
+	return parsePowermetrics(out), nil
+}
+
+var (
+	clusterResidencyRe = regexp.MustCompile(`^([EP])\d*-Cluster HW active residency:\s+([\d.]+)%`)
+	powerRe            = regexp.MustCompile(`^(CPU|GPU|ANE|Combined) Power(?: \([^)]*\))?:\s+([\d.]+)\s*mW`)
+	dieTempRe          = regexp.MustCompile(`^(CPU|GPU) die temperature:\s+([\d.]+) C`)
+)
+
+// parsePowermetrics extracts readings from powermetrics text output.
+func parsePowermetrics(out []byte) PowerResult {
+	res := PowerResult{Available: true}
+	var eSum, pSum float64
+	var eN, pN int
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if m := clusterResidencyRe.FindStringSubmatch(line); m != nil {
+			v, _ := strconv.ParseFloat(m[2], 64)
+			if m[1] == "E" {
+				eSum, eN = eSum+v, eN+1
+			} else {
+				pSum, pN = pSum+v, pN+1
+			}
+			continue
+		}
+		if m := powerRe.FindStringSubmatch(line); m != nil {
+			mw, _ := strconv.ParseFloat(m[2], 64)
+			switch m[1] {
+			case "CPU":
+				res.CPUWatts = mw / 1000
+			case "GPU":
+				res.GPUWatts = mw / 1000
+			case "ANE":
+				res.ANEWatts = mw / 1000
+			case "Combined":
+				res.TotalWatts = mw / 1000
+			}
+			continue
+		}
+		// Intel Macs report die temperatures via the smc sampler; Apple
+		// Silicon only exposes the pressure level.
+		if m := dieTempRe.FindStringSubmatch(line); m != nil {
+			c, _ := strconv.ParseFloat(m[2], 64)
+			res.Temperatures = append(res.Temperatures, TempStat{SensorKey: strings.ToLower(m[1]) + "_die", Temperature: c})
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "Current pressure level:"); ok {
+			res.ThermalPressure = strings.TrimSpace(v)
+		}
+	}
+
+	if eN > 0 {
+		res.EClusterPct = eSum / float64(eN)
+	}
+	if pN > 0 {
+		res.PClusterPct = pSum / float64(pN)
+	}
+	if res.TotalWatts == 0 {
+		res.TotalWatts = res.CPUWatts + res.GPUWatts + res.ANEWatts
+	}
+	return res
+}
@@ -0,0 +1,46 @@
+package services
+
+import "testing"
+
+const powermetricsM1Pro = `*** Sampled system activity (Wed Oct 15 10:12:01 2026 +0100) (1004.12ms elapsed) ***
+
+**** Processor usage ****
+
+E-Cluster HW active frequency: 1210 MHz
+E-Cluster HW active residency:  62.50% (600 MHz:  10% 972 MHz:  20% 1332 MHz:  70%)
+CPU 0 frequency: 1215 MHz
+P0-Cluster HW active frequency: 2100 MHz
+P0-Cluster HW active residency:  30.00% (600 MHz:   0% 3228 MHz:  50%)
+P1-Cluster HW active frequency: 600 MHz
+P1-Cluster HW active residency:  10.00% (600 MHz: 100%)
+
+ANE Power: 0 mW
+CPU Power: 2450 mW
+GPU Power: 312 mW
+Combined Power (CPU + GPU + ANE): 2762 mW
+
+**** Thermal pressure ****
+
+Current pressure level: Moderate
+`
+
+func TestParsePowermetrics(t *testing.T) {
+	got := parsePowermetrics([]byte(powermetricsM1Pro))
+	if got.EClusterPct != 62.5 || got.PClusterPct != 20 {
+		t.Errorf("clusters E=%.1f P=%.1f, want 62.5 and 20", got.EClusterPct, got.PClusterPct)
+	}
+	if got.CPUWatts != 2.45 || got.GPUWatts != 0.312 || got.TotalWatts != 2.762 {
+		t.Errorf("power cpu=%v gpu=%v total=%v", got.CPUWatts, got.GPUWatts, got.TotalWatts)
+	}
+	if got.ThermalPressure != "Moderate" {
+		t.Errorf("pressure = %q", got.ThermalPressure)
+	}
+	if len(got.Temperatures) != 0 {
+		t.Errorf("unexpected temperatures %v", got.Temperatures)
+	}
+
+	intel := parsePowermetrics([]byte("CPU die temperature: 71.25 C\nPackage Power: 9000 mW\n"))
+	if len(intel.Temperatures) != 1 || intel.Temperatures[0].SensorKey != "cpu_die" || intel.Temperatures[0].Temperature != 71.25 {
+		t.Errorf("intel temperatures = %v", intel.Temperatures)
+	}
+}
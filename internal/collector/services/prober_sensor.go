@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// ProbeResult summarizes the probes sent within the window.
+type ProbeResult struct {
+	Sent      int
+	Lost      int
+	LatencyMS float64 // mean round trip of successful probes
+	JitterMS  float64 // mean difference between consecutive round trips
+	LossPct   float64
+}
+
+type probeSample struct {
+	at  time.Time
+	rtt time.Duration // < 0 when the probe failed
+}
+
+// ProberSensor sends one lightweight probe (a TCP connect, which needs no
+// privileges) per interval in the background and reports latency, jitter
+// and loss over a sliding window. The loop starts on Connect or the first
+// Collect and stops on Disconnect.
+type ProberSensor struct {
+	endpoint string
+	interval time.Duration
+	window   time.Duration
+	dial     func(ctx context.Context, endpoint string) error
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	samples []probeSample
+}
+
+func NewProberSensor(endpoint string, interval, window time.Duration) *ProberSensor {
+	return &ProberSensor{
+		endpoint: endpoint,
+		interval: interval,
+		window:   window,
+		dial:     dialTCP,
+	}
+}
+
+func (s *ProberSensor) Name() string {
+	return "Prober"
+}
+
+func (s *ProberSensor) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start()
+	return nil
+}
+
+func (s *ProberSensor) Disconnect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	return nil
+}
+
+func (s *ProberSensor) Collect(ctx context.Context) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start()
+	s.trim(time.Now())
+	return summarizeProbes(s.samples), nil
+}
+
+// start launches the probe loop once; the caller holds mu.
+func (s *ProberSensor) start() {
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.run(ctx)
+}
+
+func (s *ProberSensor) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.probe(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ProberSensor) probe(ctx context.Context) {
+	// A probe slower than the interval counts as lost.
+	probeCtx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+	start := time.Now()
+	rtt := time.Duration(-1)
+	if err := s.dial(probeCtx, s.endpoint); err == nil {
+		rtt = time.Since(start)
+	} else if ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, probeSample{at: start, rtt: rtt})
+	s.trim(start)
+}
+
+// trim drops samples older than the window; the caller holds mu.
+func (s *ProberSensor) trim(now time.Time) {
+	cutoff := now.Add(-s.window)
+	i := 0
+	for i < len(s.samples) && s.samples[i].at.Before(cutoff) {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// summarizeProbes computes loss, mean latency and jitter. Jitter is the mean
+// absolute difference between consecutive successful round trips.
+func summarizeProbes(samples []probeSample) ProbeResult {
+	res := ProbeResult{Sent: len(samples)}
+	var sum, diffs float64
+	var ok, pairs int
+	prev := time.Duration(-1)
+	for _, p := range samples {
+		if p.rtt < 0 {
+			res.Lost++
+			continue
+		}
+		ms := float64(p.rtt) / float64(time.Millisecond)
+		sum += ms
+		ok++
+		if prev >= 0 {
+			d := ms - float64(prev)/float64(time.Millisecond)
+			if d < 0 {
+				d = -d
+			}
+			diffs += d
+			pairs++
+		}
+		prev = p.rtt
+	}
+	if ok > 0 {
+		res.LatencyMS = sum / float64(ok)
+	}
+	if pairs > 0 {
+		res.JitterMS = diffs / float64(pairs)
+	}
+	if res.Sent > 0 {
+		res.LossPct = float64(res.Lost) / float64(res.Sent) * 100
+	}
+	return res
+}
+
+func dialTCP(ctx context.Context, endpoint string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSummarizeProbes(t *testing.T) {
+	at := time.Now()
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	samples := []probeSample{
+		{at: at, rtt: ms(10)},
+		{at: at, rtt: ms(30)},
+		{at: at, rtt: -1},
+		{at: at, rtt: ms(20)},
+	}
+	res := summarizeProbes(samples)
+	if res.Sent != 4 || res.Lost != 1 || res.LossPct != 25 {
+		t.Errorf("sent/lost/loss = %d/%d/%.1f, want 4/1/25", res.Sent, res.Lost, res.LossPct)
+	}
+	if res.LatencyMS != 20 {
+		t.Errorf("latency = %.2f, want 20", res.LatencyMS)
+	}
+	// |30-10| and |20-30|; the lost probe is skipped.
+	if math.Abs(res.JitterMS-15) > 1e-9 {
+		t.Errorf("jitter = %.2f, want 15", res.JitterMS)
+	}
+
+	if res := summarizeProbes(nil); res != (ProbeResult{}) {
+		t.Errorf("empty window = %+v", res)
+	}
+}
+
+func TestProberSensorLoop(t *testing.T) {
+	s := NewProberSensor("example:53", 5*time.Millisecond, time.Minute)
+	var n atomic.Int32
+	s.dial = func(ctx context.Context, endpoint string) error {
+		if n.Add(1)%2 == 0 {
+			return errors.New("unreachable")
+		}
+		return nil
+	}
+	ctx := context.Background()
+	if err := s.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Disconnect(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		out, err := s.Collect(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := out.(ProbeResult)
+		if res.Sent >= 4 {
+			if res.Lost == 0 || res.Lost == res.Sent {
+				t.Errorf("expected partial loss, got %+v", res)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d probes sent", res.Sent)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
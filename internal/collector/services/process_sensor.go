@@ -3,25 +3,68 @@ package services
 import (
 	"context"
 	"fmt"
+	"os/user"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
 
+	"github.com/shirou/gopsutil/v4/mem"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
 type ProcessInfo struct {
 	PID    int32   `json:"pid"`
 	Name   string  `json:"name,omitempty"`
+	User   string  `json:"user,omitempty"`
 	CPU    float64 `json:"cpu_percent,omitempty"`
 	Memory float32 `json:"memory_percent,omitempty"`
+
+	// Set for the top processes when detail capture is enabled.
+	Cmdline string `json:"cmdline,omitempty"` // redacted
+	Cgroup  string `json:"cgroup,omitempty"`
+}
+
+// UserUsage aggregates resource usage of all processes owned by one UID.
+type UserUsage struct {
+	UID       int32   `json:"uid"`
+	User      string  `json:"user"`
+	Processes int     `json:"processes"`
+	CPU       float64 `json:"cpu_percent"` // sum of per-process CPU percent (100 = one core)
+	Memory    float64 `json:"memory_percent"`
+	RSSBytes  uint64  `json:"rss_bytes"`
 }
 
 type ProcessResult struct {
 	Processes []ProcessInfo `json:"processes"`
+	Users     []UserUsage   `json:"users"` // highest memory share first
 }
 
-type ProcessSensor struct{}
+type ProcessSensor struct {
+	limit    int // detailed entries kept; <= 0 keeps every process
+	detail   int // top processes by CPU whose cmdline and cgroup are captured
+	redactor *Redactor
+
+	mu    sync.Mutex
+	names map[int32]string // uid -> user name
+}
 
 func NewProcessSensor() *ProcessSensor {
-	return &ProcessSensor{}
+	return NewProcessSensorWithLimit(50) // safety limit; user totals cover every process
+}
+
+// NewProcessSensorWithLimit keeps up to limit detailed entries, or every
+// process when limit <= 0, as burst captures do.
+func NewProcessSensorWithLimit(limit int) *ProcessSensor {
+	return &ProcessSensor{limit: limit, names: make(map[int32]string)}
+}
+
+// WithDetail captures the command line and cgroup path of the top n
+// processes by CPU. Command lines are passed through r, which may be nil.
+func (s *ProcessSensor) WithDetail(n int, r *Redactor) *ProcessSensor {
+	s.detail = n
+	s.redactor = r
+	return s
 }
 
 func (s *ProcessSensor) Name() string {
@@ -42,30 +85,145 @@ func (s *ProcessSensor) Collect(ctx context.Context) (any, error) {
 		return nil, fmt.Errorf("failed to list pids: %w", err)
 	}
 
-	processes := make([]ProcessInfo, 0, len(pids))
-	limit := 50 // safety limit to avoid long runs
-	count := 0
+	var totalMem uint64
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		totalMem = vm.Total
+	}
+
+	processes := make([]ProcessInfo, 0, min(len(pids), 50))
+	users := make(map[int32]*UserUsage)
 
 	for _, pid := range pids {
-		if count >= limit {
+		if ctx.Err() != nil {
 			break
 		}
 		p, err := process.NewProcessWithContext(ctx, pid)
 		if err != nil {
 			continue
 		}
-		name, _ := p.NameWithContext(ctx)
 		cpuPct, _ := p.CPUPercentWithContext(ctx)
-		memPct, _ := p.MemoryPercentWithContext(ctx)
-
-		processes = append(processes, ProcessInfo{
-			PID:    pid,
-			Name:   name,
-			CPU:    cpuPct,
-			Memory: memPct,
-		})
-		count++
+		var rss uint64
+		if mi, err := p.MemoryInfoWithContext(ctx); err == nil && mi != nil {
+			rss = mi.RSS
+		}
+		var memPct float64
+		if totalMem > 0 {
+			memPct = float64(rss) / float64(totalMem) * 100
+		}
+
+		var u *UserUsage
+		if uids, err := p.UidsWithContext(ctx); err == nil && len(uids) > 0 {
+			uid := int32(uids[0])
+			var ok bool
+			if u, ok = users[uid]; !ok {
+				u = &UserUsage{UID: uid, User: s.userName(uid)}
+				users[uid] = u
+			}
+			u.Processes++
+			u.CPU += cpuPct
+			u.Memory += memPct
+			u.RSSBytes += rss
+		}
+
+		if s.limit <= 0 || len(processes) < s.limit {
+			name, _ := p.NameWithContext(ctx)
+			info := ProcessInfo{
+				PID:    pid,
+				Name:   name,
+				CPU:    cpuPct,
+				Memory: float32(memPct),
+			}
+			if u != nil {
+				info.User = u.User
+			}
+			processes = append(processes, info)
+		}
+	}
+
+	s.addDetail(ctx, processes)
+
+	res := ProcessResult{Processes: processes, Users: make([]UserUsage, 0, len(users))}
+	for _, u := range users {
+		res.Users = append(res.Users, *u)
 	}
+	sort.Slice(res.Users, func(i, j int) bool {
+		if res.Users[i].Memory != res.Users[j].Memory {
+			return res.Users[i].Memory > res.Users[j].Memory
+		}
+		return res.Users[i].UID < res.Users[j].UID
+	})
+	return res, nil
+}
+
+// addDetail fills Cmdline and Cgroup for the top processes by CPU, then
+// memory, leaving their order unchanged.
+func (s *ProcessSensor) addDetail(ctx context.Context, processes []ProcessInfo) {
+	if s.detail <= 0 {
+		return
+	}
+	idx := make([]int, len(processes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		pa, pb := processes[idx[a]], processes[idx[b]]
+		if pa.CPU != pb.CPU {
+			return pa.CPU > pb.CPU
+		}
+		return pa.Memory > pb.Memory
+	})
+	for _, i := range idx[:min(s.detail, len(idx))] {
+		info := &processes[i]
+		if p, err := process.NewProcessWithContext(ctx, info.PID); err == nil {
+			if cmd, err := p.CmdlineWithContext(ctx); err == nil {
+				info.Cmdline = s.redactor.Redact(cmd)
+			}
+		}
+		info.Cgroup = ProcessCgroup(info.PID)
+	}
+}
+
+// ProcessCgroup returns the cgroup v2 path of pid, or the first v1 path on
+// hosts without the unified hierarchy.
+func ProcessCgroup(pid int32) string {
+	paths := parseSelfCgroup(HostProc(strconv.Itoa(int(pid)), "cgroup"))
+	if p, ok := paths[""]; ok {
+		return p
+	}
+	for _, c := range []string{"memory", "cpu", "name=systemd"} {
+		if p, ok := paths[c]; ok {
+			return p
+		}
+	}
+	return ""
+}
+
+// containerIDPattern matches the 64-hex container ID used by Docker,
+// containerd and Podman in cgroup paths such as /docker/<id>,
+// /system.slice/docker-<id>.scope and /kubepods/.../cri-containerd-<id>.scope.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
 
-	return ProcessResult{Processes: processes}, nil
+// ContainerIDFromCgroup returns the container ID in a cgroup path, or ""
+// for processes outside a container.
+func ContainerIDFromCgroup(path string) string {
+	ids := containerIDPattern.FindAllString(path, -1)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[len(ids)-1]
+}
+
+// userName resolves a UID, falling back to the numeric ID for unknown users.
+func (s *ProcessSensor) userName(uid int32) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name, ok := s.names[uid]; ok {
+		return name
+	}
+	name := strconv.Itoa(int(uid))
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	s.names[uid] = name
+	return name
 }
@@ -0,0 +1,40 @@
+package services
+
+import "testing"
+
+func TestRedactor_Defaults(t *testing.T) {
+	r := DefaultRedactor()
+	tests := []struct {
+		in, want string
+	}{
+		{"java -jar app.jar --port 8080", "java -jar app.jar --port 8080"},
+		{"mysql -u root --password=hunter2 db", "mysql -u root --password=<redacted> db"},
+		{"app --api-key abc123 --verbose", "app --api-key <redacted> --verbose"},
+		{"java -Ddb.password=s3cret -jar svc.jar", "java -Ddb.password=<redacted> -jar svc.jar"},
+		{"worker postgres://svc:pa55@db:5432/x", "worker postgres://svc:<redacted>@db:5432/x"},
+		{`curl -H Authorization: Bearer tok123 x`, `curl -H Authorization: Bearer <redacted> x`},
+		{"agent --token-file /etc/token", "agent --token-file /etc/token"},
+	}
+	for _, tt := range tests {
+		if got := r.Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactor_Custom(t *testing.T) {
+	if _, err := NewRedactor([]string{"("}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	r, err := NewRedactor([]string{`(LICENSE=)\w+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Redact("svc LICENSE=XYZ"); got != "svc LICENSE=<redacted>" {
+		t.Errorf("got %q", got)
+	}
+	var nilR *Redactor
+	if got := nilR.Redact("--password=x"); got != "--password=x" {
+		t.Errorf("nil redactor changed input: %q", got)
+	}
+}
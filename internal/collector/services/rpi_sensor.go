@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Bits of the Raspberry Pi firmware's get_throttled value. The low bits are
+// the current state, the same bits shifted by 16 record whether it has
+// happened since boot.
+const (
+	ThrottleUnderVoltage  = 1 << 0
+	ThrottleFreqCapped    = 1 << 1
+	ThrottleThrottled     = 1 << 2
+	ThrottleSoftTempLimit = 1 << 3
+	ThrottleOccurredShift = 16
+)
+
+// RPiResult holds Raspberry Pi firmware power and throttling state.
+type RPiResult struct {
+	Available     bool
+	ThrottledBits uint32  // raw get_throttled value
+	CoreVolts     float64 // 0 when vcgencmd is unavailable
+}
+
+// RPiSensor reads under-voltage and throttling state on Raspberry Pi class
+// boards, from the firmware sysfs node when present and vcgencmd otherwise.
+type RPiSensor struct {
+	sysfsThrottled string // firmware get_throttled node
+	hwmonDir       string // /sys/class/hwmon, for the rpi_volt alarm
+}
+
+func NewRPiSensor() *RPiSensor {
+	return &RPiSensor{
+		sysfsThrottled: HostSys("devices/platform/soc/soc:firmware/get_throttled"),
+		hwmonDir:       HostSys("class/hwmon"),
+	}
+}
+
+func (s *RPiSensor) Name() string {
+	return "RPi"
+}
+
+func (s *RPiSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *RPiSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *RPiSensor) Collect(ctx context.Context) (any, error) {
+	var res RPiResult
+	if b, err := os.ReadFile(s.sysfsThrottled); err == nil {
+		if v, ok := parseThrottled(string(b)); ok {
+			res.Available, res.ThrottledBits = true, v
+		}
+	}
+
+	if _, err := exec.LookPath("vcgencmd"); err == nil {
+		if !res.Available {
+			if out, err := exec.CommandContext(ctx, "vcgencmd", "get_throttled").Output(); err == nil {
+				if v, ok := parseThrottled(string(out)); ok {
+					res.Available, res.ThrottledBits = true, v
+				}
+			}
+		}
+		if out, err := exec.CommandContext(ctx, "vcgencmd", "measure_volts", "core").Output(); err == nil {
+			res.CoreVolts = parseVolts(string(out))
+		}
+	}
+
+	// Without firmware access the rpi_volt hwmon driver still reports
+	// under-voltage, though not throttling.
+	if !res.Available && s.underVoltageAlarm() {
+		res.Available = true
+		res.ThrottledBits = ThrottleUnderVoltage | ThrottleUnderVoltage<<ThrottleOccurredShift
+	}
+	return res, nil
+}
+
+// underVoltageAlarm reports whether the rpi_volt hwmon device has its alarm set.
+func (s *RPiSensor) underVoltageAlarm() bool {
+	dirs, _ := filepath.Glob(filepath.Join(s.hwmonDir, "hwmon*"))
+	for _, dir := range dirs {
+		name, err := os.ReadFile(filepath.Join(dir, "name"))
+		if err != nil || strings.TrimSpace(string(name)) != "rpi_volt" {
+			continue
+		}
+		alarm, err := os.ReadFile(filepath.Join(dir, "in0_lcrit_alarm"))
+		return err == nil && strings.TrimSpace(string(alarm)) == "1"
+	}
+	return false
+}
+
+// parseThrottled accepts "throttled=0x50005" from vcgencmd or a bare hex
+// value from sysfs.
+func parseThrottled(s string) (uint32, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "throttled=")
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 32)
+	return uint32(v), err == nil
+}
+
+// parseVolts parses "volt=0.8563V".
+func parseVolts(s string) float64 {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "volt="), "V")
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
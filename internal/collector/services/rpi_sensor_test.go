@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseThrottled(t *testing.T) {
+	cases := map[string]uint32{
+		"throttled=0x50005\n": 0x50005,
+		"throttled=0x0":       0,
+		"50000\n":             0x50000,
+	}
+	for in, want := range cases {
+		if got, ok := parseThrottled(in); !ok || got != want {
+			t.Errorf("parseThrottled(%q) = %#x, %v; want %#x", in, got, ok, want)
+		}
+	}
+	if _, ok := parseThrottled("error"); ok {
+		t.Error("expected garbage to be rejected")
+	}
+	if v := parseVolts("volt=0.8563V\n"); v != 0.8563 {
+		t.Errorf("parseVolts = %v", v)
+	}
+}
+
+func TestRPiSensorSysfs(t *testing.T) {
+	dir := t.TempDir()
+	node := filepath.Join(dir, "get_throttled")
+	if err := os.WriteFile(node, []byte("50005\n"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &RPiSensor{sysfsThrottled: node, hwmonDir: filepath.Join(dir, "hwmon")}
+	res, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(RPiResult)
+	if !got.Available || got.ThrottledBits&ThrottleUnderVoltage == 0 || got.ThrottledBits&ThrottleThrottled == 0 {
+		t.Errorf("got %+v, want under-voltage and throttled", got)
+	}
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"testing"
+	"time"
 )
 
 type sensorTestCase struct {
@@ -21,6 +23,12 @@ var sensorCases = []sensorTestCase{
 	{name: "Process", factory: func() Sensor { return NewProcessSensor() }},
 	{name: "Physical", factory: func() Sensor { return NewPhysicalSensor() }, optional: true},
 	{name: "Docker", factory: func() Sensor { return NewDockerSensor() }, optional: true},
+	{name: "Journal", factory: func() Sensor { return NewJournalSensor() }, optional: true},
+	{name: "TempFiles", factory: func() Sensor { return NewTempSensor([]string{os.TempDir()}, 7*24*time.Hour, 10000) }},
+	{name: "Cgroup", factory: func() Sensor { return NewCgroupSensor() }},
+	{name: "Power", factory: func() Sensor { return NewPowerSensor() }},
+	{name: "RPi", factory: func() Sensor { return NewRPiSensor() }},
+	{name: "GPU", factory: func() Sensor { return NewGPUSensor() }, optional: true},
 }
 
 func TestSensorsSuite(t *testing.T) {
@@ -0,0 +1,38 @@
+//go:build freebsd || openbsd
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// platformTemperatures reads thermal sensors from sysctl, which gopsutil does
+// not cover on the BSDs: dev.cpu.N.temperature and ACPI thermal zones on
+// FreeBSD, hw.sensors on OpenBSD.
+func platformTemperatures(ctx context.Context) []TempStat {
+	var args []string
+	if runtime.GOOS == "openbsd" {
+		args = []string{"hw.sensors"}
+	} else {
+		args = []string{"dev.cpu", "hw.acpi.thermal"}
+	}
+	// sysctl exits non-zero when one of the OIDs is missing but still prints the rest.
+	out, _ := exec.CommandContext(ctx, "sysctl", args...).Output()
+	return parseSysctlTemperatures(out)
+}
+
+// platformCorePattern returns FreeBSD's kern.corefile. OpenBSD always dumps
+// into the crashing process's working directory.
+func platformCorePattern() (string, bool) {
+	if runtime.GOOS != "freebsd" {
+		return "", false
+	}
+	out, err := exec.Command("sysctl", "-n", "kern.corefile").Output()
+	if err != nil {
+		return "", false
+	}
+	return string(bytes.TrimSpace(out)), true
+}
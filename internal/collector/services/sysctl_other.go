@@ -0,0 +1,15 @@
+//go:build !freebsd && !openbsd
+
+package services
+
+import "context"
+
+// platformTemperatures is only needed where gopsutil has no sensor support.
+func platformTemperatures(ctx context.Context) []TempStat {
+	return nil
+}
+
+// platformCorePattern is only needed where there is no /proc core_pattern.
+func platformCorePattern() (string, bool) {
+	return "", false
+}
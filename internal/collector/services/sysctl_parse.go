@@ -0,0 +1,45 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// parseSysctlTemperatures extracts temperatures from sysctl output in either
+// the FreeBSD form ("dev.cpu.0.temperature: 45.0C") or the OpenBSD form
+// ("hw.sensors.cpu0.temp0=45.00 degC").
+func parseSysctlTemperatures(out []byte) []TempStat {
+	var temps []TempStat
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			key, value, ok = strings.Cut(line, ": ")
+		}
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var raw string
+		switch {
+		case strings.Contains(value, " degC"):
+			// OpenBSD may append a description: "27.80 degC (zone temperature)".
+			raw, _, _ = strings.Cut(value, " degC")
+		case strings.HasSuffix(key, "temperature") && strings.HasSuffix(value, "C"):
+			raw = strings.TrimSuffix(value, "C")
+		default:
+			continue
+		}
+		c, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		key = strings.TrimPrefix(strings.TrimPrefix(key, "hw.sensors."), "dev.")
+		temps = append(temps, TempStat{SensorKey: key, Temperature: c})
+	}
+	return temps
+}
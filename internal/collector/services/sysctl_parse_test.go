@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestParseSysctlTemperatures(t *testing.T) {
+	freebsd := `dev.cpu.0.temperature: 45.0C
+dev.cpu.0.freq: 2400
+dev.cpu.1.temperature: 47.5C
+hw.acpi.thermal.tz0.temperature: 27.9C
+hw.acpi.thermal.tz0._CRT: 100.0C
+`
+	openbsd := `hw.sensors.cpu0.temp0=52.00 degC
+hw.sensors.acpitz0.temp0=27.80 degC (zone temperature)
+hw.sensors.acpibat0.volt0=11.10 VDC (voltage)
+`
+	cases := map[string][]TempStat{
+		freebsd: {{"cpu.0.temperature", 45}, {"cpu.1.temperature", 47.5}, {"hw.acpi.thermal.tz0.temperature", 27.9}},
+		openbsd: {{"cpu0.temp0", 52}, {"acpitz0.temp0", 27.8}},
+	}
+	for out, want := range cases {
+		got := parseSysctlTemperatures([]byte(out))
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("got[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// smallFileBuf fits any single-value sysfs or cgroup attribute.
+const smallFileBuf = 128
+
+// smallFileBufs recycles read buffers for readTrimmed. os.ReadFile sizes
+// its buffer from the file's size, which sysfs reports as a whole page, so
+// every attribute read would allocate over 4 KiB: megabytes a cycle on a
+// host with hundreds of interfaces or containers.
+var smallFileBufs = sync.Pool{New: func() any {
+	b := make([]byte, smallFileBuf)
+	return &b
+}}
+
+// readTrimmed returns the content of a small kernel file, such as a sysfs
+// attribute, without surrounding whitespace. It reads into a pooled buffer
+// and falls back to reading the rest of longer files in full.
+func readTrimmed(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	bp := smallFileBufs.Get().(*[]byte)
+	defer smallFileBufs.Put(bp)
+	buf := *bp
+	n, err := io.ReadFull(f, buf)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return string(bytes.TrimSpace(buf[:n])), true
+	case err != nil:
+		return "", false
+	}
+	rest, err := io.ReadAll(f)
+	if err != nil {
+		return "", false
+	}
+	return string(bytes.TrimSpace(append(buf[:n:n], rest...))), true
+}
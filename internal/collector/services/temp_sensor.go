@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TempUsage summarises a temp or core-dump location.
+type TempUsage struct {
+	Path       string
+	Kind       string // "temp" or "core"
+	Files      int
+	TotalBytes uint64
+	StaleBytes uint64 // bytes in files not modified within the stale age
+	Partial    bool   // entry limit reached before the walk finished
+}
+
+type TempResult struct {
+	Locations []TempUsage
+}
+
+// TempSensor measures temp directories and core dump locations. Core dump
+// locations are derived from /proc/sys/kernel/core_pattern, or kern.corefile
+// on FreeBSD.
+type TempSensor struct {
+	tempDirs    []string
+	staleAge    time.Duration
+	maxEntries  int
+	corePattern string // path of the core_pattern file
+}
+
+func NewTempSensor(tempDirs []string, staleAge time.Duration, maxEntries int) *TempSensor {
+	return &TempSensor{
+		tempDirs:    append([]string(nil), tempDirs...),
+		staleAge:    staleAge,
+		maxEntries:  maxEntries,
+		corePattern: HostProc("sys/kernel/core_pattern"),
+	}
+}
+
+func (s *TempSensor) Name() string {
+	return "TempFiles"
+}
+
+func (s *TempSensor) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *TempSensor) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (s *TempSensor) Collect(ctx context.Context) (any, error) {
+	now := time.Now()
+	var res TempResult
+	for _, dir := range s.tempDirs {
+		if u, ok := s.measure(ctx, dir, "temp", now); ok {
+			res.Locations = append(res.Locations, u)
+		}
+	}
+	coreDirs := coreDumpDirs(s.corePattern)
+	if pattern, ok := platformCorePattern(); ok {
+		coreDirs = coreDumpDirsFor(pattern)
+	}
+	for _, dir := range coreDirs {
+		if u, ok := s.measure(ctx, dir, "core", now); ok {
+			res.Locations = append(res.Locations, u)
+		}
+	}
+	return res, nil
+}
+
+func (s *TempSensor) measure(ctx context.Context, dir, kind string, now time.Time) (TempUsage, bool) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return TempUsage{}, false
+	}
+	u := TempUsage{Path: dir, Kind: kind}
+	visited := 0
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			u.Partial = true
+			return ctx.Err()
+		}
+		visited++
+		if s.maxEntries > 0 && visited > s.maxEntries {
+			u.Partial = true
+			return errScanLimit
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		size := uint64(info.Size())
+		u.Files++
+		u.TotalBytes += size
+		if s.staleAge > 0 && now.Sub(info.ModTime()) > s.staleAge {
+			u.StaleBytes += size
+		}
+		return nil
+	})
+	return u, true
+}
+
+// coreDumpDirs reads the kernel core_pattern file and maps it to dump directories.
+func coreDumpDirs(patternFile string) []string {
+	b, err := os.ReadFile(patternFile)
+	if err != nil {
+		return nil
+	}
+	return coreDumpDirsFor(strings.TrimSpace(string(b)))
+}
+
+// coreDumpDirsFor maps a core file pattern to directories where dumps accumulate.
+func coreDumpDirsFor(pattern string) []string {
+	switch {
+	case strings.HasPrefix(pattern, "|"):
+		// Piped to a helper; the well-known ones keep dumps in fixed locations.
+		switch {
+		case strings.Contains(pattern, "systemd-coredump"):
+			return []string{"/var/lib/systemd/coredump"}
+		case strings.Contains(pattern, "apport"):
+			return []string{"/var/crash"}
+		case strings.Contains(pattern, "abrt"):
+			return []string{"/var/spool/abrt", "/var/tmp/abrt"}
+		}
+		return nil
+	case filepath.IsAbs(pattern):
+		return []string{filepath.Dir(pattern)}
+	}
+	// Relative patterns write into each crashing process's cwd; nothing to watch.
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCoreDumpDirs(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string][]string{
+		"|/usr/lib/systemd/systemd-coredump %P %u %g %s %t %c %h": {"/var/lib/systemd/coredump"},
+		"|/usr/share/apport/apport -p%p -s%s -c%c":                {"/var/crash"},
+		"/var/cores/core.%e.%p":                                   {"/var/cores"},
+		"core":                                                    nil,
+	}
+	for pattern, want := range cases {
+		file := filepath.Join(dir, "core_pattern")
+		if err := os.WriteFile(file, []byte(pattern+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := coreDumpDirs(file)
+		if len(got) != len(want) || (len(want) > 0 && got[0] != want[0]) {
+			t.Errorf("coreDumpDirs(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestTempSensorStaleBytes(t *testing.T) {
+	tmp := t.TempDir()
+	cores := t.TempDir()
+	writeSized(t, filepath.Join(tmp, "fresh.bin"), 100)
+	writeSized(t, filepath.Join(tmp, "build", "old.o"), 400)
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tmp, "build", "old.o"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	writeSized(t, filepath.Join(cores, "core.app.123"), 1000)
+
+	pattern := filepath.Join(t.TempDir(), "core_pattern")
+	if err := os.WriteFile(pattern, []byte(filepath.Join(cores, "core.%e.%p")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewTempSensor([]string{tmp, filepath.Join(tmp, "missing")}, 7*24*time.Hour, 0)
+	s.corePattern = pattern
+	res, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	locs := res.(TempResult).Locations
+	if len(locs) != 2 {
+		t.Fatalf("expected temp and core locations, got %+v", locs)
+	}
+	if l := locs[0]; l.Kind != "temp" || l.Files != 2 || l.TotalBytes != 500 || l.StaleBytes != 400 {
+		t.Errorf("unexpected temp usage: %+v", l)
+	}
+	if l := locs[1]; l.Kind != "core" || l.Path != cores || l.TotalBytes != 1000 || l.StaleBytes != 0 {
+		t.Errorf("unexpected core usage: %+v", l)
+	}
+}
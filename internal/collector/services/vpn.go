@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tunnelPrefixes name the interfaces VPN clients create.
+var tunnelPrefixes = []string{"tun", "tap", "wg", "utun", "ppp", "ipsec", "tailscale", "zt", "nordlynx", "proton", "gpd"}
+
+// Routes describes which interface carries traffic to the internet.
+type Routes struct {
+	Default  string // interface of the preferred default route
+	Tunnel   string // active VPN interface, if any
+	Physical string // best non-tunnel uplink; equals Default without a VPN
+}
+
+// VPN reports whether a tunnel is up.
+func (r Routes) VPN() bool { return r.Tunnel != "" }
+
+// isTunnel reports whether an interface name belongs to a VPN client.
+func isTunnel(name string) bool {
+	for _, p := range tunnelPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectRoutes finds the default route and any active tunnel. Linux reads
+// the main routing table; elsewhere the default is unknown and the first
+// up interface of each kind is used.
+func DetectRoutes() Routes {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return Routes{}
+	}
+	return detectRoutes(ifaces, readDefaultRoutes(HostProc("net", "route")))
+}
+
+func detectRoutes(ifaces []net.Interface, defaults []string) Routes {
+	up := make(map[string]bool)
+	var r Routes
+	var firstPhysical string
+	for _, ifc := range ifaces {
+		if ifc.Flags&net.FlagUp == 0 || ifc.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		up[ifc.Name] = true
+		if isTunnel(ifc.Name) {
+			if r.Tunnel == "" {
+				r.Tunnel = ifc.Name
+			}
+		} else if firstPhysical == "" && len(ifc.HardwareAddr) > 0 {
+			firstPhysical = ifc.Name
+		}
+	}
+	// Defaults are ordered by metric; a tunnel that is the default wins.
+	for _, name := range defaults {
+		if !up[name] {
+			continue
+		}
+		if r.Default == "" {
+			r.Default = name
+		}
+		if isTunnel(name) {
+			r.Tunnel = name
+		} else if r.Physical == "" {
+			r.Physical = name
+		}
+	}
+	if r.Physical == "" {
+		r.Physical = firstPhysical
+	}
+	if r.Default == "" {
+		r.Default = r.Physical
+	}
+	return r
+}
+
+// readDefaultRoutes returns the interfaces of IPv4 default routes in
+// /proc/net/route, lowest metric first.
+func readDefaultRoutes(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	type route struct {
+		iface  string
+		metric int
+	}
+	var routes []route
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		metric, _ := strconv.Atoi(fields[6])
+		routes = append(routes, route{fields[0], metric})
+	}
+	for i := 1; i < len(routes); i++ {
+		for j := i; j > 0 && routes[j].metric < routes[j-1].metric; j-- {
+			routes[j], routes[j-1] = routes[j-1], routes[j]
+		}
+	}
+	names := make([]string, len(routes))
+	for i, r := range routes {
+		names[i] = r.iface
+	}
+	return names
+}
+
+// DialLatency measures a TCP connect to endpoint. With iface set the socket
+// is bound to that interface, bypassing a VPN's default route; this is not
+// supported on every platform and fails when the interface has no route to
+// the endpoint.
+func DialLatency(ctx context.Context, endpoint, iface string) (time.Duration, error) {
+	d := net.Dialer{}
+	if iface != "" {
+		d.Control = bindToDevice(iface)
+	}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return time.Since(start), nil
+}
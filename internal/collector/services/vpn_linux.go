@@ -0,0 +1,20 @@
+//go:build linux
+
+package services
+
+import "syscall"
+
+// bindToDevice pins a socket to an interface with SO_BINDTODEVICE, which
+// unprivileged processes may use since Linux 5.7.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var opErr error
+		err := c.Control(func(fd uintptr) {
+			opErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+		})
+		if err != nil {
+			return err
+		}
+		return opErr
+	}
+}
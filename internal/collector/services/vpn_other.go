@@ -0,0 +1,15 @@
+//go:build !linux
+
+package services
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice is only implemented on Linux; elsewhere the direct probe is skipped.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		return errors.New("binding to an interface is not supported on this platform")
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadDefaultRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route")
+	route := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t00000000\t0100A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n" +
+		"eth0\t0000A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n" +
+		"wg0\t00000000\t00000000\t0001\t0\t0\t50\t00000000\t0\t0\t0\n"
+	if err := os.WriteFile(path, []byte(route), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readDefaultRoutes(path); !reflect.DeepEqual(got, []string{"wg0", "eth0"}) {
+		t.Errorf("readDefaultRoutes = %v", got)
+	}
+}
+
+func TestDetectRoutes(t *testing.T) {
+	mac := net.HardwareAddr{0, 1, 2, 3, 4, 5}
+	ifaces := []net.Interface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Name: "eth0", Flags: net.FlagUp, HardwareAddr: mac},
+		{Name: "wg0", Flags: net.FlagUp},
+	}
+
+	r := detectRoutes(ifaces, []string{"wg0", "eth0"})
+	if want := (Routes{Default: "wg0", Tunnel: "wg0", Physical: "eth0"}); r != want {
+		t.Errorf("routes = %+v, want %+v", r, want)
+	}
+
+	// Without a routing table the first physical interface is assumed.
+	r = detectRoutes(ifaces[:2], nil)
+	if r.VPN() || r.Default != "eth0" {
+		t.Errorf("routes = %+v, want eth0 without a VPN", r)
+	}
+}
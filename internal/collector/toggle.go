@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"syschecker/internal/collector/services"
+)
+
+// Optional sensors that can be switched off at runtime, e.g. when one is
+// slow or noisy during an incident. Core CPU, memory, disk and network
+// sensors always run.
+const (
+	SensorDocker       = "docker"
+	SensorProcesses    = "processes"
+	SensorDiskHealth   = "disk_health"
+	SensorTemperatures = "temperatures"
+	SensorJournal      = "journal"
+	SensorLogGrowth    = "log_growth"
+	SensorTempFiles    = "temp_files"
+	SensorPower        = "power"
+	SensorGPU          = "gpu"
+	SensorChecks       = "checks"
+	SensorProber       = "prober"
+)
+
+// ToggleableSensors lists the sensors SetSensorEnabled accepts.
+var ToggleableSensors = []string{
+	SensorDocker, SensorProcesses, SensorDiskHealth, SensorTemperatures, SensorJournal,
+	SensorLogGrowth, SensorTempFiles, SensorPower, SensorGPU, SensorChecks, SensorProber,
+}
+
+// SensorToggler is implemented by collectors whose optional sensors can be
+// switched on and off while running.
+type SensorToggler interface {
+	SetSensorEnabled(name string, enabled bool) error
+	SensorStates() map[string]bool
+}
+
+var errSensorDisabled = errors.New("sensor disabled")
+
+// SetSensorEnabled switches an optional sensor on or off for subsequent
+// collections. A disabled docker sensor reports Docker as unavailable.
+func (s *SystemCollector) SetSensorEnabled(name string, enabled bool) error {
+	if !slices.Contains(ToggleableSensors, name) {
+		return fmt.Errorf("unknown sensor %q (want one of %s)", name, strings.Join(ToggleableSensors, ", "))
+	}
+	if name == SensorProber && enabled && s.proberSensor == nil {
+		return fmt.Errorf("the prober was not started; restart with -probe to enable it")
+	}
+	s.toggleMu.Lock()
+	defer s.toggleMu.Unlock()
+	if s.disabled == nil {
+		s.disabled = make(map[string]bool)
+	}
+	s.disabled[name] = !enabled
+	return nil
+}
+
+// SensorStates reports whether each optional sensor is enabled.
+func (s *SystemCollector) SensorStates() map[string]bool {
+	s.toggleMu.RLock()
+	defer s.toggleMu.RUnlock()
+	states := make(map[string]bool, len(ToggleableSensors))
+	for _, name := range ToggleableSensors {
+		states[name] = !s.disabled[name]
+	}
+	states[SensorProber] = states[SensorProber] && s.proberSensor != nil
+	return states
+}
+
+func (s *SystemCollector) sensorEnabled(name string) bool {
+	s.toggleMu.RLock()
+	defer s.toggleMu.RUnlock()
+	return !s.disabled[name]
+}
+
+// collect runs sensor unless it is switched off under name.
+func (s *SystemCollector) collect(ctx context.Context, name string, sensor services.Sensor) (any, error) {
+	if !s.sensorEnabled(name) {
+		return nil, errSensorDisabled
+	}
+	return sensor.Collect(ctx)
+}
+
+// disabledSensors returns the sensors cfg's feature flags turn off.
+func disabledSensors(cfg CollectorConfig) map[string]bool {
+	return map[string]bool{
+		SensorDocker:       !cfg.EnableDockerMetrics,
+		SensorDiskHealth:   !cfg.EnableDiskHealth,
+		SensorTemperatures: !cfg.EnableTemperatures,
+		SensorProcesses:    !cfg.EnableProcessMetrics,
+	}
+}
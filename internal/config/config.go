@@ -0,0 +1,177 @@
+// Package config loads the optional JSON config file and watches it, so
+// thresholds, collection intervals, ignore rules and SLOs can be changed
+// without restarting the agent.
+//
+// Example:
+//
+//	{
+//	  "thresholds": {"cpu": {"warning": 80, "critical": 95}},
+//	  "intervals": {"fast": "2s", "slow": "30s"},
+//	  "ignore": [{"flag": "docker_unavailable"}, {"flag": "swap_thrashing", "host": "build-*"}],
+//	  "slos": [{"name": "latency", "metric": "net_latency_ms", "op": "<", "threshold": 50, "target": 99}]
+//	}
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/schedule"
+	"syschecker/internal/slo"
+)
+
+// File is the parsed config file. Omitted sections keep their defaults.
+type File struct {
+	Thresholds map[string]flagger.Thresholds `json:"thresholds,omitempty"`
+	Intervals  *Intervals                    `json:"intervals,omitempty"`
+	Ignore     []flagger.IgnoreRule          `json:"ignore,omitempty"`
+	SLOs       []slo.Objective               `json:"slos,omitempty"`
+}
+
+// Intervals replace those of the default collection profile. Values are Go
+// durations such as "20s"; an empty value keeps the built-in one.
+type Intervals struct {
+	Fast string `json:"fast,omitempty"`
+	Slow string `json:"slow,omitempty"`
+}
+
+// thresholdFields maps config keys to the flagger thresholds they set.
+var thresholdFields = map[string]func(*flagger.Config) *flagger.Thresholds{
+	"cpu":        func(c *flagger.Config) *flagger.Thresholds { return &c.CPU },
+	"ram":        func(c *flagger.Config) *flagger.Thresholds { return &c.RAM },
+	"disk":       func(c *flagger.Config) *flagger.Thresholds { return &c.Disk },
+	"inode":      func(c *flagger.Config) *flagger.Thresholds { return &c.Inode },
+	"net":        func(c *flagger.Config) *flagger.Thresholds { return &c.Net },
+	"loss":       func(c *flagger.Config) *flagger.Thresholds { return &c.Loss },
+	"active_tcp": func(c *flagger.Config) *flagger.Thresholds { return &c.ActiveTCP },
+	"user_share": func(c *flagger.Config) *flagger.Thresholds { return &c.UserShare },
+	"container":  func(c *flagger.Config) *flagger.Thresholds { return &c.Container },
+	"gpu":        func(c *flagger.Config) *flagger.Thresholds { return &c.GPU },
+	"gpu_mem":    func(c *flagger.Config) *flagger.Thresholds { return &c.GPUMem },
+}
+
+// ThresholdNames lists the threshold keys accepted in a config file and by
+// SetThreshold.
+func ThresholdNames() []string {
+	names := make([]string, 0, len(thresholdFields))
+	for k := range thresholdFields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Thresholds returns cfg's thresholds keyed by ThresholdNames.
+func Thresholds(cfg flagger.Config) map[string]flagger.Thresholds {
+	out := make(map[string]flagger.Thresholds, len(thresholdFields))
+	for name, field := range thresholdFields {
+		out[name] = *field(&cfg)
+	}
+	return out
+}
+
+// SetThreshold returns cfg with the named thresholds replaced.
+func SetThreshold(cfg flagger.Config, name string, th flagger.Thresholds) (flagger.Config, error) {
+	field, ok := thresholdFields[name]
+	if !ok {
+		return cfg, fmt.Errorf("unknown threshold %q (want one of %s)", name, strings.Join(ThresholdNames(), ", "))
+	}
+	if th.Warning > th.Critical {
+		return cfg, fmt.Errorf("threshold %q: warning %.1f is above critical %.1f", name, th.Warning, th.Critical)
+	}
+	*field(&cfg) = th
+	return cfg, nil
+}
+
+// Load reads and validates a config file.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	var f File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *File) validate() error {
+	for key, th := range f.Thresholds {
+		if _, err := SetThreshold(flagger.Config{}, key, th); err != nil {
+			return err
+		}
+	}
+	if _, err := f.profile(schedule.Profile{}); err != nil {
+		return err
+	}
+	for _, r := range f.Ignore {
+		if !slices.Contains(relational.FlagNames, r.Flag) {
+			return fmt.Errorf("ignore: unknown flag %q", r.Flag)
+		}
+		if _, err := path.Match(r.Host, ""); err != nil {
+			return fmt.Errorf("ignore %s: bad host pattern %q: %w", r.Flag, r.Host, err)
+		}
+	}
+	names := map[string]bool{}
+	for _, o := range f.SLOs {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		if names[o.Name] {
+			return fmt.Errorf("slo %s: defined twice", o.Name)
+		}
+		names[o.Name] = true
+	}
+	return nil
+}
+
+// FlaggerConfig returns base with the file's thresholds and ignore rules.
+func (f *File) FlaggerConfig(base flagger.Config) flagger.Config {
+	for key, th := range f.Thresholds {
+		*thresholdFields[key](&base) = th
+	}
+	base.Ignore = slices.Clone(f.Ignore)
+	return base
+}
+
+// Profile returns base, normally the built-in default profile, with the
+// file's intervals applied.
+func (f *File) Profile(base schedule.Profile) schedule.Profile {
+	p, _ := f.profile(base)
+	return p
+}
+
+func (f *File) profile(base schedule.Profile) (schedule.Profile, error) {
+	if f.Intervals == nil {
+		return base, nil
+	}
+	for _, iv := range []struct {
+		name, value string
+		dst         *time.Duration
+	}{{"fast", f.Intervals.Fast, &base.Fast}, {"slow", f.Intervals.Slow, &base.Slow}} {
+		if iv.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(iv.value)
+		if err != nil || d <= 0 {
+			return base, fmt.Errorf("intervals: invalid %s interval %q", iv.name, iv.value)
+		}
+		*iv.dst = d
+	}
+	return base, nil
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/flagger"
+	"syschecker/internal/schedule"
+)
+
+func writeConfig(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syschecker.json")
+	writeConfig(t, path, `{
+		"thresholds": {"cpu": {"warning": 70, "critical": 90}},
+		"intervals": {"slow": "1m"},
+		"ignore": [{"flag": "docker_unavailable", "host": "build-*"}],
+		"slos": [{"name": "latency", "metric": "net_latency_ms", "op": "<", "threshold": 50, "target": 99, "window": "168h"}]
+	}`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := f.FlaggerConfig(flagger.DefaultConfig())
+	if cfg.CPU != (flagger.Thresholds{Warning: 70, Critical: 90}) || cfg.RAM != flagger.DefaultConfig().RAM {
+		t.Errorf("CPU = %+v, RAM = %+v", cfg.CPU, cfg.RAM)
+	}
+	if len(cfg.Ignore) != 1 || !cfg.Ignore[0].Matches("build-3") || cfg.Ignore[0].Matches("web-1") {
+		t.Errorf("Ignore = %+v", cfg.Ignore)
+	}
+
+	base := schedule.Builtin()[0]
+	if p := f.Profile(base); p.Slow != time.Minute || p.Fast != base.Fast {
+		t.Errorf("Profile = %+v", p)
+	}
+	if len(f.SLOs) != 1 || f.SLOs[0].WindowDuration() != 7*24*time.Hour {
+		t.Errorf("SLOs = %+v", f.SLOs)
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syschecker.json")
+	for body, want := range map[string]string{
+		`{"thresholds": {"fpga": {"warning": 1, "critical": 2}}}`:  "unknown threshold",
+		`{"thresholds": {"cpu": {"warning": 95, "critical": 80}}}`: "above critical",
+		`{"intervals": {"fast": "soon"}}`:                          "invalid fast interval",
+		`{"ignore": [{"flag": "not_a_flag"}]}`:                     "unknown flag",
+		`{"ignore": [{"flag": "cpu_overloaded", "host": "["}]}`:    "bad host pattern",
+		`{"threshold": {}}`: "unknown field",
+		`{"slos": [{"name": "disk", "metric": "disk_usage_pct", "op": "<", "threshold": 90, "target": 0}]}`:                                                                                "target",
+		`{"slos": [{"name": "a", "metric": "cpu_usage_pct", "op": "<", "threshold": 1, "target": 99}, {"name": "a", "metric": "cpu_usage_pct", "op": "<", "threshold": 1, "target": 99}]}`: "defined twice",
+	} {
+		writeConfig(t, path, body)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestWatchAppliesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syschecker.json")
+	writeConfig(t, path, `{}`)
+
+	fs := flagger.NewFlaggerService(flagger.DefaultConfig())
+	s := schedule.NewScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, path, 10*time.Millisecond, Target{
+		Flagger: fs, BaseConfig: flagger.DefaultConfig(), Scheduler: s, BaseProfile: schedule.Builtin()[0],
+	})
+
+	// A broken edit keeps the previous config; the fixed one is applied.
+	time.Sleep(30 * time.Millisecond)
+	writeConfig(t, path, `{"thresholds": {"cpu": {"warning": 10}}}`)
+	time.Sleep(30 * time.Millisecond)
+	if fs.Config().CPU != flagger.DefaultConfig().CPU {
+		t.Fatalf("invalid config was applied: %+v", fs.Config().CPU)
+	}
+	writeConfig(t, path, `{"thresholds": {"cpu": {"warning": 10, "critical": 20}}, "intervals": {"fast": "5s"}}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fs.Config().CPU.Critical != 20 || s.Current().Profile.Fast != 5*time.Second {
+		if time.Now().After(deadline) {
+			t.Fatalf("CPU = %+v, profile = %+v", fs.Config().CPU, s.Current().Profile)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestThresholdEdits(t *testing.T) {
+	edits, err := ParseThresholdEdits([]byte(`{"edits": [{"metric": "disk", "warning": 85}, {"metric": "cpu", "critical": 97}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for body, want := range map[string]string{
+		`{"edits": []}`: "no threshold edits",
+		`{"edits": [{"metric": "fpga", "warning": 1}]}`:  "unknown threshold",
+		`{"edits": [{"metric": "disk"}]}`:                "no level",
+		`{"edits": [{"metric": "disk", "warning": -1}]}`: "negative",
+		`{"edits": [{"metric": "disk", "warn": 80}]}`:    "unknown field",
+	} {
+		if _, err := ParseThresholdEdits([]byte(body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", body, err, want)
+		}
+	}
+
+	// cpu is set by the file, disk falls back to the levels in effect.
+	f := &File{Thresholds: map[string]flagger.Thresholds{"cpu": {Warning: 70, Critical: 90}}}
+	edited, err := f.WithThresholdEdits(flagger.DefaultConfig(), edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := edited.Thresholds["cpu"]; got != (flagger.Thresholds{Warning: 70, Critical: 97}) {
+		t.Errorf("cpu = %+v", got)
+	}
+	if got := edited.Thresholds["disk"]; got != (flagger.Thresholds{Warning: 85, Critical: flagger.DefaultConfig().Disk.Critical}) {
+		t.Errorf("disk = %+v", got)
+	}
+	if f.Thresholds["cpu"].Critical != 90 {
+		t.Error("edits changed the original file")
+	}
+	low := 50.0
+	if _, err := f.WithThresholdEdits(flagger.DefaultConfig(), []ThresholdEdit{{Metric: "cpu", Critical: &low}}); err == nil {
+		t.Error("critical below warning accepted")
+	}
+
+	path := filepath.Join(t.TempDir(), "syschecker.json")
+	if err := Save(path, edited); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Thresholds["disk"] != edited.Thresholds["disk"] {
+		t.Errorf("saved disk = %+v", loaded.Thresholds["disk"])
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := "{\n  a\n  b\n  c\n  d\n  e\n  f\n}\n"
+	b := "{\n  a\n  b\n  c\n  d\n  E\n  f\n}\n"
+	want := "...\n   c\n   d\n-  e\n+  E\n   f\n }\n"
+	if got := Diff(a, b); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := Diff(a, a); got != "" {
+		t.Errorf("unchanged: %q", got)
+	}
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"syschecker/internal/flagger"
+)
+
+// ThresholdEdit changes the levels of one threshold. A nil level keeps
+// its current value, so "warn me when disk hits 85%" leaves the critical
+// level alone.
+type ThresholdEdit struct {
+	Metric   string   `json:"metric" jsonschema:"threshold to change, one of the keys of the config file's thresholds section"`
+	Warning  *float64 `json:"warning,omitempty" jsonschema:"new warning level; omit to keep the current one"`
+	Critical *float64 `json:"critical,omitempty" jsonschema:"new critical level; omit to keep the current one"`
+}
+
+// Check validates e on its own; whether the levels fit the threshold's
+// other level is checked when it is applied.
+func (e ThresholdEdit) Check() error {
+	if _, ok := thresholdFields[e.Metric]; !ok {
+		return fmt.Errorf("unknown threshold %q (want one of %s)", e.Metric, strings.Join(ThresholdNames(), ", "))
+	}
+	if e.Warning == nil && e.Critical == nil {
+		return fmt.Errorf("threshold %q: no level to change", e.Metric)
+	}
+	for _, v := range []*float64{e.Warning, e.Critical} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("threshold %q: negative level %g", e.Metric, *v)
+		}
+	}
+	return nil
+}
+
+// ParseThresholdEdits decodes and checks a list of edits given as
+// {"edits": [...]}, the form the LLM is asked for. Unknown fields are
+// rejected rather than guessed at.
+func ParseThresholdEdits(data []byte) ([]ThresholdEdit, error) {
+	var doc struct {
+		Edits []ThresholdEdit `json:"edits"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse threshold edits: %w", err)
+	}
+	if len(doc.Edits) == 0 {
+		return nil, errors.New("no threshold edits")
+	}
+	for _, e := range doc.Edits {
+		if err := e.Check(); err != nil {
+			return nil, err
+		}
+	}
+	return doc.Edits, nil
+}
+
+// WithThresholdEdits returns a copy of f with edits applied. Levels an
+// edit leaves out come from f, or from current, the thresholds in effect,
+// when f does not set that threshold.
+func (f *File) WithThresholdEdits(current flagger.Config, edits []ThresholdEdit) (*File, error) {
+	out := *f
+	out.Thresholds = maps.Clone(f.Thresholds)
+	if out.Thresholds == nil {
+		out.Thresholds = map[string]flagger.Thresholds{}
+	}
+	for _, e := range edits {
+		if err := e.Check(); err != nil {
+			return nil, err
+		}
+		th, ok := out.Thresholds[e.Metric]
+		if !ok {
+			th = *thresholdFields[e.Metric](&current)
+		}
+		if e.Warning != nil {
+			th.Warning = *e.Warning
+		}
+		if e.Critical != nil {
+			th.Critical = *e.Critical
+		}
+		if _, err := SetThreshold(current, e.Metric, th); err != nil {
+			return nil, err
+		}
+		out.Thresholds[e.Metric] = th
+	}
+	return &out, nil
+}
+
+// Marshal returns f as Save writes it: indented JSON with sorted keys.
+func (f *File) Marshal() ([]byte, error) {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Save validates f and writes it to path. The file is replaced by a
+// rename, so Watch never loads half of it, and keeps its permissions.
+func Save(path string, f *File) error {
+	if err := f.validate(); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	data, err := f.Marshal()
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// diffContext is the number of unchanged lines Diff keeps around a change.
+const diffContext = 2
+
+// Diff returns a line diff from a to b: removed lines start with "-",
+// added ones with "+" and unchanged context with a space. Runs of
+// unchanged lines beyond diffContext are cut to "...". It is meant for
+// config files, which are small: the cost is quadratic in lines.
+func Diff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	for i, j := 0, 0; i < len(x) || j < len(y); {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, " "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+x[i])
+			i++
+		default:
+			lines = append(lines, "+"+y[j])
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	elided := false
+	for k, line := range lines {
+		if line[0] == ' ' && !nearChange(lines, k) {
+			if !elided {
+				sb.WriteString("...\n")
+				elided = true
+			}
+			continue
+		}
+		elided = false
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// nearChange reports whether a changed line is within diffContext of
+// lines[k].
+func nearChange(lines []string, k int) bool {
+	for i := max(k-diffContext, 0); i <= min(k+diffContext, len(lines)-1); i++ {
+		if lines[i][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"syschecker/internal/flagger"
+	"syschecker/internal/schedule"
+	"syschecker/internal/slo"
+)
+
+// Target is what a config file is applied to. Nil fields are skipped.
+type Target struct {
+	Flagger     *flagger.FlaggerService
+	Learner     *flagger.BaselineLearner // suggests thresholds relative to the same config
+	BaseConfig  flagger.Config           // the file's thresholds and ignore rules are layered on this
+	Scheduler   *schedule.Scheduler
+	BaseProfile schedule.Profile // the default profile the file's intervals replace
+	SLOs        *slo.Tracker
+}
+
+// Apply makes f effective. Loops running the default profile are re-timed
+// immediately; a timed profile such as incident keeps running until it
+// reverts to the new default.
+func (t Target) Apply(f *File) {
+	if t.Flagger != nil {
+		t.Flagger.SetConfig(f.FlaggerConfig(t.BaseConfig))
+	}
+	if t.Learner != nil {
+		t.Learner.SetConfig(f.FlaggerConfig(t.BaseConfig))
+	}
+	if t.Scheduler != nil {
+		p := f.Profile(t.BaseProfile)
+		p.Name = schedule.Default
+		t.Scheduler.Register(p)
+		if t.Scheduler.Current().Profile.Name == schedule.Default {
+			t.Scheduler.Activate(schedule.Default)
+		}
+	}
+	if t.SLOs != nil {
+		t.SLOs.SetObjectives(f.SLOs)
+	}
+}
+
+// Watch polls path every interval and applies each valid new version to t
+// until ctx is done. Invalid edits are logged and the previous config stays
+// in effect. Polling needs no platform file-event support and copes with
+// editors that replace the file instead of writing it in place.
+func Watch(ctx context.Context, path string, interval time.Duration, t Target) {
+	last := stamp(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cur := stamp(path)
+		if cur == last {
+			continue
+		}
+		last = cur
+		f, err := Load(path)
+		if err != nil {
+			log.Printf("Config change ignored, keeping the previous config: %v", err)
+			continue
+		}
+		t.Apply(f)
+		log.Printf("Config reloaded from %s: %d threshold override(s), %d ignore rule(s), %d SLO(s), default profile fast=%s slow=%s",
+			path, len(f.Thresholds), len(f.Ignore), len(f.SLOs), f.Profile(t.BaseProfile).Fast, f.Profile(t.BaseProfile).Slow)
+	}
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func stamp(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: fi.ModTime(), size: fi.Size()}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// BackupWriter writes the whole database to a new directory.
+type BackupWriter interface {
+	Backup(ctx context.Context, dir string) error
+}
+
+// BackupHandler serves a backup of the database on GET as a tar archive
+// of its Parquet files, for an aggregator pulling from the agent.
+func BackupHandler(b BackupWriter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		tmp, err := os.MkdirTemp("", "syschecker-backup-")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(tmp)
+		dir := filepath.Join(tmp, "backup")
+		if err := b.Backup(r.Context(), dir); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Errors past this point can only cut the archive short, which the
+		// reader detects.
+		w.Header().Set("Content-Type", "application/x-tar")
+		tw := tar.NewWriter(w)
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			if err := addTarFile(tw, filepath.Join(dir, e.Name())); err != nil {
+				return
+			}
+		}
+		_ = tw.Close()
+	})
+}
+
+func addTarFile(tw *tar.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fileBackup struct{}
+
+func (fileBackup) Backup(_ context.Context, dir string) error {
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range []string{"load.sql", "hosts.parquet"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestBackupHandler(t *testing.T) {
+	h := BackupHandler(fileBackup{})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/backup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d %s", rec.Code, rec.Body)
+	}
+	got := map[string]string{}
+	tr := tar.NewReader(rec.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = string(data)
+	}
+	if len(got) != 2 || got["hosts.parquet"] != "hosts.parquet" {
+		t.Errorf("archive = %v, want load.sql and hosts.parquet", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/backup", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// BookmarkStore creates and lists snapshot bookmarks.
+type BookmarkStore interface {
+	BookmarkLatest(ctx context.Context, hostname, note string) (*relational.Annotation, error)
+	ListAnnotations(ctx context.Context, hostname, kind string, since time.Time, limit int) ([]relational.Annotation, error)
+}
+
+// BookmarksHandler serves snapshot bookmarks. GET lists them, newest first,
+// optionally for ?host=NAME and up to ?limit=N; POST bookmarks the latest
+// snapshot of ?host=NAME (default: the most recent host) with an optional
+// JSON body {"note": "..."}.
+func BookmarksHandler(s BookmarkStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.URL.Query().Get("host")
+		var result any
+		var err error
+		status := http.StatusOK
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			result, err = s.ListAnnotations(r.Context(), host, relational.AnnotationBookmark, time.Time{}, limit)
+		case http.MethodPost:
+			var body struct {
+				Note string `json:"note"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&body); err != nil && err != io.EOF {
+				http.Error(w, "body must be a JSON object with a note: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if result, err = s.BookmarkLatest(r.Context(), host, body.Note); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			status = http.StatusCreated
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+type memBookmarks struct {
+	marks []relational.Annotation
+}
+
+func (m *memBookmarks) BookmarkLatest(_ context.Context, hostname, note string) (*relational.Annotation, error) {
+	a := relational.Annotation{SnapshotID: 42, Hostname: hostname, Kind: relational.AnnotationBookmark, Note: note}
+	m.marks = append(m.marks, a)
+	return &a, nil
+}
+
+func (m *memBookmarks) ListAnnotations(context.Context, string, string, time.Time, int) ([]relational.Annotation, error) {
+	return m.marks, nil
+}
+
+func TestBookmarksHandler(t *testing.T) {
+	store := &memBookmarks{}
+	h := BookmarksHandler(store)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/bookmarks?host=web-1", strings.NewReader(`{"note":"deploy spike"}`)))
+	if rec.Code != http.StatusCreated || len(store.marks) != 1 || store.marks[0].Note != "deploy spike" {
+		t.Fatalf("POST = %d %s, marks %+v", rec.Code, rec.Body, store.marks)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/bookmarks", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST without a body = %d, want 201", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/bookmarks", nil))
+	if !strings.Contains(rec.Body.String(), `"deploy spike"`) {
+		t.Errorf("GET = %s", rec.Body)
+	}
+}
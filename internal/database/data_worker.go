@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
+	"syschecker/internal/clock"
 	"syschecker/internal/database/graph"
 	"syschecker/internal/database/relational"
 	"syschecker/internal/output"
+	"syschecker/internal/schedule"
 )
 
 const defaultPollInterval = 20 * time.Second
@@ -20,7 +23,18 @@ type DataWorker struct {
 	flagger     relational.StatsFlagger
 	repo        relational.StatsRepository
 	graphClient graph.GraphClient
+	escalator   relational.FlagEscalator
+	baseline    relational.BaselineObserver
+	seasonal    relational.DeviationDetector
+	forecaster  relational.MemoryForecaster
+	disk        relational.DiskInvestigator
+	storage     relational.StorageChecker
+	burst       relational.BurstController
+	publisher   relational.SnapshotPublisher
+	notifiers   []PayloadNotifier
+	clock       clock.Clock
 	interval    time.Duration
+	scheduler   *schedule.Scheduler
 	agentID     string
 	machineID   string
 	bootID      string
@@ -29,6 +43,171 @@ type DataWorker struct {
 	cancel  context.CancelFunc
 	running bool
 	wg      sync.WaitGroup
+
+	persistMu     sync.Mutex
+	lastPersist   time.Duration
+	lastPersistAt time.Time
+
+	changeOnly *ChangeEpsilons
+	dedupMu    sync.Mutex
+	lastStored *output.PipelinePayload
+	lastID     int64
+	repeats    int
+
+	journal     *Journal
+	graphBuffer *GraphBuffer
+	graphOpts   []GraphIngestPoolOption
+	graphPool   *GraphIngestPool
+	lease       *LeaseKeeper
+}
+
+// PayloadNotifier is handed each payload once it is persisted, e.g. to
+// forward it to an outbound integration.
+type PayloadNotifier interface {
+	Notify(ctx context.Context, p *output.PipelinePayload) error
+}
+
+// EscalationNotifier is a PayloadNotifier that also wants escalation and
+// reminder notices for flags that stay active, e.g. to re-page.
+type EscalationNotifier interface {
+	NotifyEscalation(ctx context.Context, n relational.EscalationNotice) error
+}
+
+// DataWorkerOption configures optional DataWorker behavior.
+type DataWorkerOption func(*DataWorker)
+
+// WithEscalator enables severity escalation for flags that stay active.
+func WithEscalator(e relational.FlagEscalator) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.escalator = e
+	}
+}
+
+// WithBaselineLearner enables per-host threshold learning.
+func WithBaselineLearner(b relational.BaselineObserver) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.baseline = b
+	}
+}
+
+// WithSeasonalDetector enables hour-of-week deviation detection.
+func WithSeasonalDetector(d relational.DeviationDetector) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.seasonal = d
+	}
+}
+
+// WithMemoryForecaster enables predictive out-of-memory flagging.
+func WithMemoryForecaster(f relational.MemoryForecaster) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.forecaster = f
+	}
+}
+
+// WithDiskInvestigator enables largest-directory scans when disk space is critical.
+func WithDiskInvestigator(d relational.DiskInvestigator) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.disk = d
+	}
+}
+
+// WithStorageGuard flags snapshots while the database nears its size budget.
+func WithStorageGuard(s relational.StorageChecker) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.storage = s
+	}
+}
+
+// WithBurstCapture records full process and connection detail while a
+// critical flag's burst window is open.
+func WithBurstCapture(b relational.BurstController) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.burst = b
+	}
+}
+
+// WithPublisher announces each persisted snapshot, e.g. to stream clients.
+func WithPublisher(p relational.SnapshotPublisher) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.publisher = p
+	}
+}
+
+// WithNotifier adds a notifier, such as a webhook, called after each persist.
+func WithNotifier(n PayloadNotifier) DataWorkerOption {
+	return func(w *DataWorker) {
+		if n != nil {
+			w.notifiers = append(w.notifiers, n)
+		}
+	}
+}
+
+// WithClock drives the worker's ticker and snapshot timestamps from c.
+func WithClock(c clock.Clock) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.clock = clock.OrReal(c)
+	}
+}
+
+// WithInterval overrides the default poll interval.
+func WithInterval(d time.Duration) DataWorkerOption {
+	return func(w *DataWorker) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithScheduler takes the poll interval from the scheduler's active profile
+// and re-times the loop whenever the profile changes.
+func WithScheduler(s *schedule.Scheduler) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.scheduler = s
+	}
+}
+
+// WithChangeOnly stores a collection only when it differs from the last
+// stored snapshot by more than eps; otherwise that snapshot's repeat count
+// is bumped. It has no effect unless the repository implements
+// relational.SnapshotRepeater.
+func WithChangeOnly(eps ChangeEpsilons) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.changeOnly = &eps
+	}
+}
+
+// WithJournal buffers payloads in j while DuckDB rejects inserts, e.g.
+// because the file is locked or the disk is full, and replays them in
+// order once an insert succeeds again.
+func WithJournal(j *Journal) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.journal = j
+	}
+}
+
+// WithGraphBuffer buffers graph ingests in b while Neo4j is unreachable
+// and replays them once it is back, instead of dropping them.
+func WithGraphBuffer(b *GraphBuffer) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.graphBuffer = b
+	}
+}
+
+// WithGraphIngest configures the pool pushing payloads into the graph,
+// e.g. its concurrency and queue length.
+func WithGraphIngest(opts ...GraphIngestPoolOption) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.graphOpts = append(w.graphOpts, opts...)
+	}
+}
+
+// WithIngestLease makes each cycle take or renew k's per-host lease first
+// and skip collection while another pipeline holds it. The lease lasts
+// three intervals, so a stopped holder's lease passes on soon after.
+func WithIngestLease(k *LeaseKeeper) DataWorkerOption {
+	return func(w *DataWorker) {
+		w.lease = k
+	}
 }
 
 // NewDataWorker creates a new worker instance.
@@ -38,20 +217,31 @@ func NewDataWorker(
 	r relational.StatsRepository,
 	g graph.GraphClient,
 	agentID, machineID, bootID string,
+	opts ...DataWorkerOption,
 ) (*DataWorker, error) {
 	if c == nil || f == nil || r == nil {
 		return nil, errors.New("collector, flagger, and repo are required")
 	}
-	return &DataWorker{
+	w := &DataWorker{
 		collector:   c,
 		flagger:     f,
 		repo:        r,
 		graphClient: g,
+		clock:       clock.Real,
 		interval:    defaultPollInterval,
 		agentID:     agentID,
 		machineID:   machineID,
 		bootID:      bootID,
-	}, nil
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(w)
+		}
+	}
+	if g != nil {
+		w.graphPool = NewGraphIngestPool(g, append([]GraphIngestPoolOption{WithIngestBuffer(w.graphBuffer)}, w.graphOpts...)...)
+	}
+	return w, nil
 }
 
 // Start begins the periodic data collection loop.
@@ -84,8 +274,17 @@ func (w *DataWorker) Stop() {
 	}
 	w.wg.Wait()
 
+	if w.lease != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := w.lease.Release(ctx); err != nil {
+			fmt.Printf("Release ingest lease: %v\n", err)
+		}
+		cancel()
+	}
+
 	// Reset graph data on stop (ephemeral session)
 	if w.graphClient != nil {
+		w.graphPool.Close()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		w.graphClient.Reset(ctx)
@@ -93,6 +292,19 @@ func (w *DataWorker) Stop() {
 	}
 }
 
+// GraphIngest returns the pool pushing payloads into the graph, or nil
+// without a graph client.
+func (w *DataWorker) GraphIngest() *GraphIngestPool {
+	return w.graphPool
+}
+
+// LastPersist returns how long the most recent DuckDB insert took and when it finished.
+func (w *DataWorker) LastPersist() (time.Duration, time.Time) {
+	w.persistMu.Lock()
+	defer w.persistMu.Unlock()
+	return w.lastPersist, w.lastPersistAt
+}
+
 // PullOnce executes a single collection cycle immediately.
 func (w *DataWorker) PullOnce(ctx context.Context) error {
 	return w.execute(ctx)
@@ -100,23 +312,56 @@ func (w *DataWorker) PullOnce(ctx context.Context) error {
 
 func (w *DataWorker) loop(ctx context.Context) {
 	defer w.wg.Done()
-	ticker := time.NewTicker(w.interval)
-	defer ticker.Stop()
+	interval := w.currentInterval()
+	ticker := w.clock.NewTicker(interval)
+	defer func() { ticker.Stop() }()
 
 	for {
+		var changed <-chan struct{}
+		if w.scheduler != nil {
+			changed = w.scheduler.Changed()
+		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
+		case <-changed:
+		case <-ticker.C():
 			if err := w.execute(ctx); err != nil {
 				// In a real app, use a logger
 				fmt.Printf("Worker execution failed: %v\n", err)
 			}
 		}
+
+		if next := w.currentInterval(); next != interval {
+			ticker.Stop()
+			interval = next
+			ticker = w.clock.NewTicker(interval)
+		}
+	}
+}
+
+// currentInterval is the active profile's slow interval, or the fixed
+// interval when no scheduler is configured.
+func (w *DataWorker) currentInterval() time.Duration {
+	if w.scheduler != nil {
+		if d := w.scheduler.Current().Profile.Slow; d > 0 {
+			return d
+		}
 	}
+	return w.interval
 }
 
 func (w *DataWorker) execute(ctx context.Context) error {
+	// Leave the host to another pipeline ingesting it into this database
+	if w.lease != nil {
+		if held, err := w.lease.Hold(ctx, 3*w.currentInterval()); err != nil || !held {
+			return err
+		}
+	}
+
+	// One trace ID ties this cycle's rows, graph nodes, alerts and logs together
+	trace := output.NewTraceID()
+
 	// Run the pipeline via the Output layer (the "lever")
 	payload, err := output.RunPipeline(
 		ctx,
@@ -126,34 +371,224 @@ func (w *DataWorker) execute(ctx context.Context) error {
 		w.agentID,
 		w.machineID,
 		w.bootID,
+		output.WithClock(w.clock),
+		output.WithLabeler(w.labeler()),
+		output.WithTraceID(trace),
 	)
 	if err != nil {
-		return fmt.Errorf("pipeline execution failed: %w", err)
+		return fmt.Errorf("trace %s: pipeline execution failed: %w", trace, err)
+	}
+
+	// Compare against the usual level for this hour of the week
+	if w.seasonal != nil {
+		deviations, err := w.seasonal.Detect(ctx, &payload.Raw, &payload.Flags)
+		if err != nil {
+			tracef(trace, "Seasonal detection failed: %v\n", err)
+		}
+		payload.Seasonal = deviations
+	}
+
+	// Project memory exhaustion from the recent trend
+	if w.forecaster != nil {
+		payload.Forecast = w.forecaster.Forecast(&payload.Raw, &payload.Flags)
+	}
+
+	// Find what is filling the disk
+	if w.disk != nil {
+		payload.Raw.LargestDirs = w.disk.Investigate(ctx, &payload.Raw, &payload.Flags)
+	}
+
+	// Warn before the storage budget forces early retention
+	if w.storage != nil {
+		w.storage.Check(ctx, &payload.Flags)
+	}
+
+	// Escalate flags that have been active for too long
+	if w.escalator != nil {
+		notices, err := w.escalator.Apply(ctx, payload.Raw.AgentID, payload.Raw.CollectedAt, &payload.Flags)
+		if err != nil {
+			tracef(trace, "Flag escalation failed: %v\n", err)
+		}
+		for _, n := range notices {
+			if n.Repeat {
+				tracef(trace, "Flag %s still active for %s (reminder #%d)\n", n.Flag, n.ActiveFor.Truncate(time.Second), n.NotifyCount)
+			} else {
+				tracef(trace, "Flag %s escalated to level %d after %s\n", n.Flag, n.Level, n.ActiveFor.Truncate(time.Second))
+			}
+			for _, pn := range w.notifiers {
+				if en, ok := pn.(EscalationNotifier); ok {
+					if err := en.NotifyEscalation(ctx, n); err != nil {
+						tracef(trace, "Escalation notify failed: %v\n", err)
+					}
+				}
+			}
+		}
+	}
+
+	// Nothing meaningful changed on an idle host: count a repeat instead
+	if w.skipRepeat(ctx, payload) {
+		return nil
 	}
 
 	// Persist the final payload to DuckDB
-	_, err = w.repo.InsertRawStats(ctx, payload.Raw, payload.Derived, payload.Flags)
+	persistStart := w.clock.Now()
+	res, err := w.persist(ctx, payload)
 	if err != nil {
-		return fmt.Errorf("persist stats: %w", err)
+		return fmt.Errorf("trace %s: persist stats: %w", trace, err)
 	}
+	w.persistMu.Lock()
+	w.lastPersistAt = w.clock.Now()
+	w.lastPersist = w.lastPersistAt.Sub(persistStart)
+	w.persistMu.Unlock()
+	w.rememberStored(payload, res.SnapshotID)
 
-	// Push to Graph DB asynchronously
-	if w.graphClient != nil {
-		w.wg.Add(1)
-		go func() {
-			defer w.wg.Done()
-			// Use a separate context or the worker context?
-			// If worker context is canceled, we might want to abort graph push.
-			// But usually we want to finish the push.
-			// Let's use a detached context with timeout to ensure it finishes or times out.
-			pushCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			if err := w.graphClient.IngestSnapshot(pushCtx, payload); err != nil {
-				fmt.Printf("Graph ingest failed: %v\n", err)
+	// Notify stream clients
+	if w.publisher != nil {
+		w.publisher.Publish(res, &payload.Raw, &payload.Derived, &payload.Flags)
+	}
+	for _, n := range w.notifiers {
+		if err := n.Notify(ctx, payload); err != nil {
+			tracef(trace, "Notify failed: %v\n", err)
+		}
+	}
+
+	// Capture extended detail while a burst is open
+	if w.burst != nil {
+		w.captureBurst(ctx, payload, res.SnapshotID)
+	}
+
+	// Learn per-host thresholds once enough history exists
+	if w.baseline != nil {
+		learned, err := w.baseline.Observe(ctx, payload.Raw.AgentID, payload.Raw.CollectedAt)
+		if err != nil {
+			tracef(trace, "Baseline learning failed: %v\n", err)
+		}
+		for _, t := range learned {
+			state := "suggested"
+			if t.Applied {
+				state = "applied"
 			}
-		}()
+			tracef(trace, "Learned %s threshold for %s: warning=%.1f critical=%.1f (%s)\n",
+				t.Metric, t.AgentID, t.Warning, t.Critical, state)
+		}
 	}
 
+	// Push to Graph DB asynchronously; a full queue means Neo4j is not
+	// keeping up, and the pool counts what it drops.
+	if w.graphPool != nil {
+		if err := w.graphPool.Submit(payload); err != nil {
+			tracef(trace, "Graph ingest skipped: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// tracef logs a line of the collection cycle with the given trace ID to
+// stderr, as the MCP server's stdout carries its protocol.
+func tracef(trace, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "[trace %s] "+format, append([]any{trace}, args...)...)
+}
+
+// persist inserts payload. With a journal, earlier payloads that failed to
+// insert are replayed first, and payload is journaled instead when the
+// database is still unwritable so history keeps its order. A payload
+// failing for good is not journaled; it would only fail again on replay.
+func (w *DataWorker) persist(ctx context.Context, payload *output.PipelinePayload) (relational.InsertResult, error) {
+	if w.journal == nil {
+		return w.repo.InsertRawStats(ctx, payload.Raw, payload.Derived, payload.Flags)
+	}
+	n, err := w.journal.Replay(ctx, w.insertPayload)
+	if n > 0 {
+		fmt.Printf("Replayed %d journaled snapshots\n", n)
+	}
+	var res relational.InsertResult
+	if err == nil {
+		res, err = w.repo.InsertRawStats(ctx, payload.Raw, payload.Derived, payload.Flags)
+		if err == nil || !IsTransient(err) {
+			return res, err
+		}
+	}
+	if jerr := w.journal.Append(payload); jerr != nil {
+		return res, fmt.Errorf("%w (journal: %v)", err, jerr)
+	}
+	return res, fmt.Errorf("%w (journaled for replay)", err)
+}
+
+func (w *DataWorker) insertPayload(ctx context.Context, p *output.PipelinePayload) error {
+	_, err := w.repo.InsertRawStats(ctx, p.Raw, p.Derived, p.Flags)
+	return err
+}
+
+// skipRepeat reports whether change-only persistence drops payload as a
+// repeat of the last stored snapshot, recording the repeat if so.
+func (w *DataWorker) skipRepeat(ctx context.Context, payload *output.PipelinePayload) bool {
+	rep, ok := w.repo.(relational.SnapshotRepeater)
+	if w.changeOnly == nil || !ok {
+		return false
+	}
+	w.dedupMu.Lock()
+	defer w.dedupMu.Unlock()
+	if w.lastStored == nil || !w.changeOnly.unchanged(w.lastStored, payload) {
+		return false
+	}
+	if limit := w.changeOnly.MaxRepeats; limit > 0 && w.repeats >= limit {
+		return false
+	}
+	if err := rep.RecordRepeat(ctx, w.lastID, payload.Raw.CollectedAt); err != nil {
+		fmt.Printf("Recording repeat failed: %v\n", err)
+		return false
+	}
+	w.repeats++
+	return true
+}
+
+// rememberStored makes payload the snapshot later collections are compared to.
+func (w *DataWorker) rememberStored(payload *output.PipelinePayload, snapshotID int64) {
+	if w.changeOnly == nil {
+		return
+	}
+	w.dedupMu.Lock()
+	w.lastStored, w.lastID, w.repeats = payload, snapshotID, 0
+	w.dedupMu.Unlock()
+}
+
+// labeler returns the repo as a HostLabeler when it stores labels.
+func (w *DataWorker) labeler() relational.HostLabeler {
+	if l, ok := w.repo.(relational.HostLabeler); ok {
+		return l
+	}
 	return nil
 }
+
+// captureBurst lets the burst controller open or close a burst and, while
+// one is open, records the full process list and connection table with the
+// snapshot.
+func (w *DataWorker) captureBurst(ctx context.Context, payload *output.PipelinePayload, snapshotID int64) {
+	incidentID, err := w.burst.Observe(ctx, payload.Raw.AgentID, payload.Raw.CollectedAt, &payload.Flags)
+	if err != nil {
+		fmt.Printf("Burst tracking failed: %v\n", err)
+	}
+	if incidentID == "" {
+		return
+	}
+	dc, ok := w.collector.(relational.DetailCollector)
+	if !ok {
+		return
+	}
+	detail, err := dc.GetBurstDetail(ctx)
+	if err != nil {
+		fmt.Printf("Burst detail collection failed: %v\n", err)
+		return
+	}
+	err = w.burst.Record(ctx, relational.BurstSample{
+		IncidentID:  incidentID,
+		SnapshotID:  snapshotID,
+		CollectedAt: payload.Raw.CollectedAt,
+		Processes:   detail.Processes,
+		Connections: detail.Connections,
+	})
+	if err != nil {
+		fmt.Printf("Burst sample persist failed: %v\n", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"syschecker/internal/collector"
 	"syschecker/internal/database"
+	"syschecker/internal/database/graph"
 	"syschecker/internal/database/relational"
 	"syschecker/internal/flagger"
 	"syschecker/internal/output"
@@ -183,3 +184,7 @@ func (m *MockGraphClient) IngestSnapshot(ctx context.Context, payload *output.Pi
 func (m *MockGraphClient) ExecuteCypher(ctx context.Context, query string) ([]map[string]any, error) {
 	return nil, nil
 }
+
+func (m *MockGraphClient) RunCypher(ctx context.Context, query string, opts graph.CypherOptions) ([]map[string]any, error) {
+	return nil, nil
+}
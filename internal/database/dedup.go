@@ -0,0 +1,110 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"syschecker/internal/output"
+)
+
+// ChangeEpsilons are the largest differences from the last stored snapshot
+// that change-only persistence treats as noise. A collection within every
+// epsilon, with the same flags and severity, is not stored; the stored
+// snapshot's repeat count is bumped instead.
+type ChangeEpsilons struct {
+	CPUPct    float64 // percentage points
+	RAMPct    float64 // also used for swap
+	DiskPct   float64 // root usage and inode usage
+	LoadAvg   float64 // 1-minute load average
+	LatencyMS float64
+	DiskBps   float64 // read and write throughput
+	NetBps    float64 // transmit and receive throughput
+	ActiveTCP int
+
+	// MaxRepeats stores a snapshot anyway after this many consecutive
+	// repeats, so history keeps a heartbeat. Zero means no limit.
+	MaxRepeats int
+}
+
+// DefaultChangeEpsilons suit an idle host: small jitter is ignored and a
+// snapshot is still stored at least every 30 collections.
+func DefaultChangeEpsilons() ChangeEpsilons {
+	return ChangeEpsilons{
+		CPUPct:     2,
+		RAMPct:     1,
+		DiskPct:    0.5,
+		LoadAvg:    0.2,
+		LatencyMS:  10,
+		DiskBps:    256 << 10,
+		NetBps:     64 << 10,
+		ActiveTCP:  5,
+		MaxRepeats: 30,
+	}
+}
+
+// ParseChangeEpsilons reads "default" or comma-separated key=value
+// overrides of DefaultChangeEpsilons, e.g. "cpu=5,max_repeats=60". Keys
+// are cpu, ram, disk, load, latency_ms, disk_bps, net_bps, active_tcp and
+// max_repeats.
+func ParseChangeEpsilons(spec string) (ChangeEpsilons, error) {
+	e := DefaultChangeEpsilons()
+	if spec == "" || spec == "default" {
+		return e, nil
+	}
+	floats := map[string]*float64{
+		"cpu": &e.CPUPct, "ram": &e.RAMPct, "disk": &e.DiskPct, "load": &e.LoadAvg,
+		"latency_ms": &e.LatencyMS, "disk_bps": &e.DiskBps, "net_bps": &e.NetBps,
+	}
+	ints := map[string]*int{"active_tcp": &e.ActiveTCP, "max_repeats": &e.MaxRepeats}
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return e, fmt.Errorf("want key=value, got %q", pair)
+		}
+		if dst, ok := floats[key]; ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || v < 0 {
+				return e, fmt.Errorf("invalid %s %q", key, value)
+			}
+			*dst = v
+		} else if dst, ok := ints[key]; ok {
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 0 {
+				return e, fmt.Errorf("invalid %s %q", key, value)
+			}
+			*dst = v
+		} else {
+			return e, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return e, nil
+}
+
+// unchanged reports whether cur is within e of prev.
+func (e ChangeEpsilons) unchanged(prev, cur *output.PipelinePayload) bool {
+	p, c := &prev.Raw, &cur.Raw
+	pf, cf := prev.Flags, cur.Flags
+	if pf.Mask() != cf.Mask() || pf.SeverityLevel != cf.SeverityLevel {
+		return false
+	}
+	if p.IsConnected != c.IsConnected || p.DockerAvailable != c.DockerAvailable ||
+		len(p.DockerContainers) != len(c.DockerContainers) || p.BootID != c.BootID {
+		return false
+	}
+	within := func(a, b, eps float64) bool { return math.Abs(a-b) <= eps }
+	pd, cd := &prev.Derived, &cur.Derived
+	return within(p.CPUUsagePct, c.CPUUsagePct, e.CPUPct) &&
+		within(p.SwapUsagePct, c.SwapUsagePct, e.RAMPct) &&
+		within(p.RAMUsagePct, c.RAMUsagePct, e.RAMPct) &&
+		within(p.DiskUsagePct, c.DiskUsagePct, e.DiskPct) &&
+		within(p.InodeUsagePct, c.InodeUsagePct, e.DiskPct) &&
+		within(p.LoadAvg1, c.LoadAvg1, e.LoadAvg) &&
+		within(p.NetLatencyMS, c.NetLatencyMS, e.LatencyMS) &&
+		within(pd.DiskReadBps, cd.DiskReadBps, e.DiskBps) &&
+		within(pd.DiskWriteBps, cd.DiskWriteBps, e.DiskBps) &&
+		within(pd.NetTxBps, cd.NetTxBps, e.NetBps) &&
+		within(pd.NetRxBps, cd.NetRxBps, e.NetBps) &&
+		within(float64(p.ActiveTCP), float64(c.ActiveTCP), float64(e.ActiveTCP))
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+func idlePayload(cpu float64) *output.PipelinePayload {
+	return &output.PipelinePayload{
+		Raw:     relational.RawStatsFixed{CPUUsagePct: cpu, RAMUsagePct: 30, DiskUsagePct: 50, IsConnected: true},
+		Derived: relational.DerivedRates{NetRxBps: 1000},
+	}
+}
+
+func TestChangeEpsilonsUnchanged(t *testing.T) {
+	eps := DefaultChangeEpsilons()
+	prev := idlePayload(3)
+
+	if !eps.unchanged(prev, idlePayload(4)) {
+		t.Error("1 point of CPU jitter should count as unchanged")
+	}
+	if eps.unchanged(prev, idlePayload(40)) {
+		t.Error("a CPU jump should count as a change")
+	}
+	flagged := idlePayload(3)
+	flagged.Flags.FlagMemoryPressure = true
+	if eps.unchanged(prev, flagged) {
+		t.Error("a new flag should count as a change")
+	}
+}
+
+func TestParseChangeEpsilons(t *testing.T) {
+	e, err := ParseChangeEpsilons("cpu=5, max_repeats=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.CPUPct != 5 || e.MaxRepeats != 60 || e.RAMPct != DefaultChangeEpsilons().RAMPct {
+		t.Errorf("epsilons = %+v", e)
+	}
+	for _, bad := range []string{"cpu", "gpu=1", "cpu=-1", "max_repeats=x"} {
+		if _, err := ParseChangeEpsilons(bad); err == nil {
+			t.Errorf("ParseChangeEpsilons(%q) should fail", bad)
+		}
+	}
+}
+
+type repeatRepo struct {
+	relational.StatsRepository
+	repeats []int64
+}
+
+func (r *repeatRepo) RecordRepeat(_ context.Context, snapshotID int64, _ time.Time) error {
+	r.repeats = append(r.repeats, snapshotID)
+	return nil
+}
+
+func TestSkipRepeatHonoursMaxRepeats(t *testing.T) {
+	repo := &repeatRepo{}
+	eps := DefaultChangeEpsilons()
+	eps.MaxRepeats = 2
+	w := &DataWorker{repo: repo}
+	WithChangeOnly(eps)(w)
+	ctx := context.Background()
+
+	if w.skipRepeat(ctx, idlePayload(3)) {
+		t.Fatal("the first collection must be stored")
+	}
+	w.rememberStored(idlePayload(3), 7)
+	for i := range 2 {
+		if !w.skipRepeat(ctx, idlePayload(3.5)) {
+			t.Fatalf("repeat %d was stored", i+1)
+		}
+	}
+	if w.skipRepeat(ctx, idlePayload(3.5)) {
+		t.Error("a snapshot should be stored after MaxRepeats repeats")
+	}
+	if len(repo.repeats) != 2 || repo.repeats[0] != 7 {
+		t.Errorf("repeats recorded against %v, want snapshot 7 twice", repo.repeats)
+	}
+}
@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
+)
+
+// Environment variables for Neo4j deployments beyond basic auth on a
+// local server, such as Aura or a cluster behind SSO. Read by
+// Neo4jOptionsFromEnv.
+const (
+	EnvNeo4jBearerToken    = "NEO4J_BEARER_TOKEN"    // SSO token, replaces user and password
+	EnvNeo4jKerberosTicket = "NEO4J_KERBEROS_TICKET" // base64 Kerberos ticket, replaces user and password
+	EnvNeo4jCACert         = "NEO4J_CA_CERT"         // PEM bundle trusted for bolt+s and neo4j+s
+)
+
+// neo4jSchemes are the URI schemes the driver accepts. "+s" verifies the
+// server certificate, "+ssc" accepts a self-signed one.
+var neo4jSchemes = []string{"bolt", "bolt+s", "bolt+ssc", "neo4j", "neo4j+s", "neo4j+ssc"}
+
+// Neo4jOption configures NewNeo4jClient.
+type Neo4jOption func(*neo4jOptions)
+
+type neo4jOptions struct {
+	bearerToken    string
+	kerberosTicket string
+	caCertFile     string
+}
+
+// WithBearerToken authenticates with an SSO bearer token instead of a
+// user and password.
+func WithBearerToken(token string) Neo4jOption {
+	return func(o *neo4jOptions) { o.bearerToken = token }
+}
+
+// WithKerberosTicket authenticates with a base64 Kerberos ticket instead
+// of a user and password.
+func WithKerberosTicket(ticket string) Neo4jOption {
+	return func(o *neo4jOptions) { o.kerberosTicket = ticket }
+}
+
+// WithCACertFile trusts the PEM certificates in path, instead of the
+// system's, when verifying a bolt+s or neo4j+s server.
+func WithCACertFile(path string) Neo4jOption {
+	return func(o *neo4jOptions) { o.caCertFile = path }
+}
+
+// Neo4jOptionsFromEnv returns the options set by EnvNeo4jBearerToken,
+// EnvNeo4jKerberosTicket and EnvNeo4jCACert.
+func Neo4jOptionsFromEnv() []Neo4jOption {
+	var opts []Neo4jOption
+	if v := os.Getenv(EnvNeo4jBearerToken); v != "" {
+		opts = append(opts, WithBearerToken(v))
+	}
+	if v := os.Getenv(EnvNeo4jKerberosTicket); v != "" {
+		opts = append(opts, WithKerberosTicket(v))
+	}
+	if v := os.Getenv(EnvNeo4jCACert); v != "" {
+		opts = append(opts, WithCACertFile(v))
+	}
+	return opts
+}
+
+// authToken picks the authentication for o, basic unless a token or
+// ticket is set.
+func (o neo4jOptions) authToken(username, password string) (neo4j.AuthToken, error) {
+	switch {
+	case o.bearerToken != "" && o.kerberosTicket != "":
+		return neo4j.AuthToken{}, errors.New("set a bearer token or a Kerberos ticket, not both")
+	case o.bearerToken != "":
+		return neo4j.BearerAuth(o.bearerToken), nil
+	case o.kerberosTicket != "":
+		return neo4j.KerberosAuth(o.kerberosTicket), nil
+	}
+	return neo4j.BasicAuth(username, password, ""), nil
+}
+
+// configurers checks uri's scheme and returns the driver settings for o.
+func (o neo4jOptions) configurers(uri string) ([]func(*config.Config), error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid neo4j URI: %w", err)
+	}
+	if !slices.Contains(neo4jSchemes, u.Scheme) {
+		return nil, fmt.Errorf("unsupported neo4j URI scheme %q (want one of %s)", u.Scheme, strings.Join(neo4jSchemes, ", "))
+	}
+	if o.caCertFile == "" {
+		return nil, nil
+	}
+	// The driver only uses its TLS config for these schemes, and "+ssc"
+	// skips verification, so a CA bundle elsewhere would silently do
+	// nothing.
+	if !strings.HasSuffix(u.Scheme, "+s") {
+		return nil, fmt.Errorf("a CA certificate needs a bolt+s or neo4j+s URI, not %s", u.Scheme)
+	}
+	pem, err := os.ReadFile(o.caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("read neo4j CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates in %s", o.caCertFile)
+	}
+	return []func(*config.Config){func(c *config.Config) {
+		c.TlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}}, nil
+}
@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
+)
+
+func writeCACert(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "test CA"},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour), IsCA: true, BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNeo4jOptions(t *testing.T) {
+	scheme := func(opts ...Neo4jOption) string {
+		var o neo4jOptions
+		for _, opt := range opts {
+			opt(&o)
+		}
+		token, err := o.authToken("neo4j", "secret")
+		if err != nil {
+			return "error"
+		}
+		return token.Tokens["scheme"].(string)
+	}
+	if got := scheme(); got != "basic" {
+		t.Errorf("default auth = %s", got)
+	}
+	if got := scheme(WithBearerToken("t")); got != "bearer" {
+		t.Errorf("bearer auth = %s", got)
+	}
+	if got := scheme(WithKerberosTicket("k")); got != "kerberos" {
+		t.Errorf("kerberos auth = %s", got)
+	}
+	if got := scheme(WithBearerToken("t"), WithKerberosTicket("k")); got != "error" {
+		t.Errorf("conflicting auth = %s", got)
+	}
+
+	ca := writeCACert(t)
+	configurers, err := neo4jOptions{caCertFile: ca}.configurers("neo4j+s://abc.databases.neo4j.io")
+	if err != nil || len(configurers) != 1 {
+		t.Fatalf("configurers = %v, %v", configurers, err)
+	}
+	var c config.Config
+	configurers[0](&c)
+	if c.TlsConfig == nil || c.TlsConfig.RootCAs == nil {
+		t.Errorf("TLS config = %+v", c.TlsConfig)
+	}
+
+	for uri, want := range map[string]string{
+		"http://localhost:7474":  "unsupported",
+		"bolt://localhost:7687":  "needs a bolt+s or neo4j+s URI",
+		"neo4j+ssc://db.example": "needs a bolt+s or neo4j+s URI",
+	} {
+		if _, err := (neo4jOptions{caCertFile: ca}).configurers(uri); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", uri, err, want)
+		}
+	}
+	if _, err := (neo4jOptions{caCertFile: os.Args[0]}).configurers("bolt+s://db.example"); err == nil {
+		t.Error("accepted a file without certificates")
+	}
+	if c, err := (neo4jOptions{}).configurers("neo4j+s://db.example"); err != nil || c != nil {
+		t.Errorf("plain TLS: %v, %v", c, err)
+	}
+}
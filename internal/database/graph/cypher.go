@@ -4,34 +4,66 @@ import (
 	"context"
 	"fmt"
 
+	"syschecker/internal/chaos"
+
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// ExecuteCypher executes a raw Cypher query and returns the results.
+// CypherOptions controls how RunCypher executes a query.
+type CypherOptions struct {
+	// Write runs the query in a write transaction. Neo4j rejects writes in
+	// read transactions, so only authorized callers should set it.
+	Write bool
+
+	// MaxRows stops reading the result after this many records and
+	// discards the rest on the server, so a broad query cannot pull the
+	// whole graph into memory. Zero reads every record.
+	MaxRows int
+
+	// Params are the query's $parameters. Pass values this way rather
+	// than formatting them into the query.
+	Params map[string]any
+}
+
+// ExecuteCypher executes a raw read-only Cypher query and returns the results.
 func (c *Neo4jClient) ExecuteCypher(ctx context.Context, query string) ([]map[string]any, error) {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
-	defer session.Close(ctx)
+	return c.RunCypher(ctx, query, CypherOptions{})
+}
 
-	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		res, err := tx.Run(ctx, query, nil)
-		if err != nil {
-			return nil, err
-		}
+// RunCypher executes a raw Cypher query as opts describes and returns the results.
+func (c *Neo4jClient) RunCypher(ctx context.Context, query string, opts CypherOptions) ([]map[string]any, error) {
+	if err := chaos.Err(chaos.Neo4jOutage); err != nil {
+		return nil, err
+	}
+	session := c.session(ctx)
+	defer session.Close(ctx)
 
-		records, err := res.Collect(ctx)
+	run := session.ExecuteRead
+	if opts.Write {
+		run = session.ExecuteWrite
+	}
+	result, err := run(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, query, opts.Params)
 		if err != nil {
 			return nil, err
 		}
 
-		// Convert records to maps
+		// Convert records to maps as they stream in
 		var results []map[string]any
-		for _, record := range records {
+		for (opts.MaxRows <= 0 || len(results) < opts.MaxRows) && res.Next(ctx) {
+			record := res.Record()
 			rowMap := make(map[string]any)
 			for i, key := range record.Keys {
 				rowMap[key] = convertNeo4jValue(record.Values[i])
 			}
 			results = append(results, rowMap)
 		}
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := res.Consume(ctx); err != nil {
+			return nil, err
+		}
 
 		return results, nil
 	})
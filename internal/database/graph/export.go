@@ -0,0 +1,424 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Export formats.
+const (
+	FormatDOT     = "dot"
+	FormatGraphML = "graphml"
+	FormatHTML    = "html"
+)
+
+// ExportFormats lists the formats Subgraph.Export accepts.
+var ExportFormats = []string{FormatDOT, FormatGraphML, FormatHTML}
+
+// ExportMIME maps export formats to their MIME types.
+var ExportMIME = map[string]string{
+	FormatDOT:     "text/vnd.graphviz",
+	FormatGraphML: "application/graphml+xml",
+	FormatHTML:    "text/html",
+}
+
+// MaxSubgraphHops bounds how far SnapshotSubgraph follows relationships.
+const MaxSubgraphHops = 3
+
+// maxSubgraphPaths caps the paths read for one subgraph, so a snapshot
+// with thousands of processes cannot pull in half the graph.
+const maxSubgraphPaths = 500
+
+// ErrNoSnapshot is returned when no snapshot matches.
+var ErrNoSnapshot = errors.New("no matching snapshot in the graph")
+
+// SubgraphNode is a node of an exported subgraph.
+type SubgraphNode struct {
+	ID         string         `json:"id"`
+	Labels     []string       `json:"labels"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Caption names n for display: its first label and identifying property,
+// e.g. "Host api-1".
+func (n SubgraphNode) Caption() string {
+	label := "Node"
+	if len(n.Labels) > 0 {
+		label = n.Labels[0]
+	}
+	for _, key := range captionKeys {
+		if v, ok := n.Properties[key]; ok && v != nil && v != "" {
+			return fmt.Sprintf("%s %v", label, v)
+		}
+	}
+	return label
+}
+
+// captionKeys are the properties that identify a node, in order of
+// preference.
+var captionKeys = []string{
+	"hostname", "name", "primary_cause", "metric", "path", "container_id", "device",
+	"interface", "collected_at", "snapshot_id",
+}
+
+// SubgraphEdge is a relationship of an exported subgraph.
+type SubgraphEdge struct {
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Type       string         `json:"type"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Subgraph is the neighbourhood of a snapshot: the host it belongs to and
+// everything reachable from it, such as flags, causes and the entities
+// they blame.
+type Subgraph struct {
+	SnapshotID string         `json:"snapshot_id"`
+	Nodes      []SubgraphNode `json:"nodes"`
+	Edges      []SubgraphEdge `json:"edges"`
+}
+
+// LatestSnapshotID returns the graph ID of hostname's newest snapshot, or
+// of its newest flagged one when flagged is set.
+func LatestSnapshotID(ctx context.Context, c GraphClient, hostname string, flagged bool) (string, error) {
+	query := `
+		MATCH (:Host {hostname: $hostname})-[:HAS_SNAPSHOT]->(s:Snapshot)
+		WHERE NOT $flagged OR s.severity_level > 0
+		RETURN s.snapshot_id AS snapshot_id
+		ORDER BY s.collected_at DESC
+		LIMIT 1`
+	rows, err := c.RunCypher(ctx, query, CypherOptions{Params: map[string]any{"hostname": hostname, "flagged": flagged}})
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", ErrNoSnapshot
+	}
+	id, _ := rows[0]["snapshot_id"].(string)
+	return id, nil
+}
+
+// SnapshotSubgraph reads the subgraph around the snapshot with graph ID
+// snapshotID: its host and the nodes reachable from it within hops
+// outgoing relationships (1 to MaxSubgraphHops).
+func SnapshotSubgraph(ctx context.Context, c GraphClient, snapshotID string, hops int) (*Subgraph, error) {
+	hops = min(max(hops, 1), MaxSubgraphHops)
+	// Host and paths come from separate subqueries so a snapshot without
+	// outgoing relationships still returns a row.
+	query := fmt.Sprintf(`
+		MATCH (s:Snapshot {snapshot_id: $snapshot_id})
+		OPTIONAL MATCH (h:Host)-[hs:HAS_SNAPSHOT]->(s)
+		WITH s, collect(h) + [s] AS anchors, collect(hs) AS anchorRels
+		OPTIONAL MATCH p = (s)-[*1..%d]->()
+		WITH anchors, anchorRels, p LIMIT %d
+		RETURN anchors, anchorRels, nodes(p) AS nodes, relationships(p) AS rels`, hops, maxSubgraphPaths)
+	rows, err := c.RunCypher(ctx, query, CypherOptions{Params: map[string]any{"snapshot_id": snapshotID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoSnapshot
+	}
+	g := subgraphFromRows(rows)
+	g.SnapshotID = snapshotID
+	return g, nil
+}
+
+// subgraphFromRows collects the distinct nodes and relationships in rows,
+// as returned by RunCypher, in order of first appearance.
+func subgraphFromRows(rows []map[string]any) *Subgraph {
+	g := &Subgraph{Nodes: []SubgraphNode{}, Edges: []SubgraphEdge{}}
+	seenNodes := map[string]bool{}
+	seenEdges := map[string]bool{}
+	var visit func(v any)
+	visit = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				visit(item)
+			}
+		case map[string]any:
+			if labels, ok := v["labels"]; ok {
+				id, _ := v["id"].(string)
+				if id == "" || seenNodes[id] {
+					return
+				}
+				seenNodes[id] = true
+				props, _ := v["properties"].(map[string]any)
+				g.Nodes = append(g.Nodes, SubgraphNode{ID: id, Labels: toStrings(labels), Properties: props})
+				return
+			}
+			if typ, ok := v["type"].(string); ok {
+				from, _ := v["startNode"].(string)
+				to, _ := v["endNode"].(string)
+				key := from + "\x00" + typ + "\x00" + to
+				if from == "" || to == "" || seenEdges[key] {
+					return
+				}
+				seenEdges[key] = true
+				props, _ := v["properties"].(map[string]any)
+				g.Edges = append(g.Edges, SubgraphEdge{From: from, To: to, Type: typ, Properties: props})
+			}
+		}
+	}
+	for _, row := range rows {
+		keys := make([]string, 0, len(row))
+		for k := range row {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			visit(row[k])
+		}
+	}
+	// Relationships to nodes outside the subgraph cannot be drawn.
+	g.Edges = slices.DeleteFunc(g.Edges, func(e SubgraphEdge) bool { return !seenNodes[e.From] || !seenNodes[e.To] })
+	return g
+}
+
+// toStrings converts labels, which arrive as []string or []any.
+func toStrings(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Export renders g in format, one of ExportFormats.
+func (g *Subgraph) Export(format string) ([]byte, error) {
+	switch format {
+	case FormatDOT:
+		return []byte(g.DOT()), nil
+	case FormatGraphML:
+		return g.GraphML()
+	case FormatHTML:
+		return g.HTML()
+	}
+	return nil, fmt.Errorf("unknown export format %q (want one of %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+// DOT renders g in Graphviz DOT, with nodes numbered in order and labelled
+// by their captions.
+func (g *Subgraph) DOT() string {
+	index := g.index()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", strconv.Quote("snapshot "+g.SnapshotID))
+	sb.WriteString("  rankdir=LR;\n  node [shape=box, style=rounded];\n")
+	for i, n := range g.Nodes {
+		fmt.Fprintf(&sb, "  n%d [label=%s];\n", i, strconv.Quote(n.Caption()))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  n%d -> n%d [label=%s];\n", index[e.From], index[e.To], strconv.Quote(e.Type))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// GraphML renders g as GraphML, keeping node labels, captions and
+// relationship types as data attributes.
+func (g *Subgraph) GraphML() ([]byte, error) {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type key struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   []data `xml:"data"`
+	}
+	type graphElem struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	doc := struct {
+		XMLName xml.Name  `xml:"graphml"`
+		XMLNS   string    `xml:"xmlns,attr"`
+		Keys    []key     `xml:"key"`
+		Graph   graphElem `xml:"graph"`
+	}{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{ID: "labels", For: "node", Name: "labels", Type: "string"},
+			{ID: "caption", For: "node", Name: "caption", Type: "string"},
+			{ID: "properties", For: "node", Name: "properties", Type: "string"},
+			{ID: "type", For: "edge", Name: "type", Type: "string"},
+		},
+		Graph: graphElem{ID: g.SnapshotID, EdgeDefault: "directed"},
+	}
+	index := g.index()
+	for i, n := range g.Nodes {
+		props, err := json.Marshal(n.Properties)
+		if err != nil {
+			return nil, err
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{ID: "n" + strconv.Itoa(i), Data: []data{
+			{Key: "labels", Value: strings.Join(n.Labels, ":")},
+			{Key: "caption", Value: n.Caption()},
+			{Key: "properties", Value: string(props)},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{
+			Source: "n" + strconv.Itoa(index[e.From]),
+			Target: "n" + strconv.Itoa(index[e.To]),
+			Data:   []data{{Key: "type", Value: e.Type}},
+		})
+	}
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// HTML renders g as a self-contained page that lays the graph out with a
+// small force simulation and shows a node's properties on click. It loads
+// nothing from the network, so it opens offline.
+func (g *Subgraph) HTML() ([]byte, error) {
+	type viewNode struct {
+		Caption    string         `json:"caption"`
+		Label      string         `json:"label"`
+		Properties map[string]any `json:"properties,omitempty"`
+	}
+	type viewEdge struct {
+		From int    `json:"from"`
+		To   int    `json:"to"`
+		Type string `json:"type"`
+	}
+	var view struct {
+		Nodes []viewNode `json:"nodes"`
+		Edges []viewEdge `json:"edges"`
+	}
+	index := g.index()
+	for _, n := range g.Nodes {
+		label := ""
+		if len(n.Labels) > 0 {
+			label = n.Labels[0]
+		}
+		view.Nodes = append(view.Nodes, viewNode{Caption: n.Caption(), Label: label, Properties: n.Properties})
+	}
+	for _, e := range g.Edges {
+		view.Edges = append(view.Edges, viewEdge{From: index[e.From], To: index[e.To], Type: e.Type})
+	}
+	var buf bytes.Buffer
+	err := viewerTemplate.Execute(&buf, struct {
+		SnapshotID string
+		Graph      any
+	}{g.SnapshotID, view})
+	return buf.Bytes(), err
+}
+
+// index maps node IDs to their position in g.Nodes.
+func (g *Subgraph) index() map[string]int {
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.ID] = i
+	}
+	return index
+}
+
+var viewerTemplate = template.Must(template.New("viewer").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Snapshot {{.SnapshotID}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; }
+svg { flex: 1; background: #fafafa; }
+aside { width: 320px; padding: 12px; border-left: 1px solid #ddd; overflow: auto; font-size: 13px; }
+text { font-size: 11px; pointer-events: none; }
+.edge { stroke: #999; }
+.edge-label { fill: #666; font-size: 9px; }
+circle { stroke: #333; cursor: pointer; }
+pre { white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<svg id="graph"><defs><marker id="arrow" viewBox="0 0 10 10" refX="18" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0L10,5L0,10z" fill="#999"/></marker></defs></svg>
+<aside><h3>Snapshot {{.SnapshotID}}</h3><p>Click a node to see its properties.</p><div id="details"></div></aside>
+<script>
+const graph = {{.Graph}};
+const svg = document.getElementById("graph");
+const W = svg.clientWidth, H = svg.clientHeight, NS = "http://www.w3.org/2000/svg";
+const colors = {};
+const palette = ["#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"];
+graph.nodes.forEach((n, i) => {
+  if (!(n.label in colors)) colors[n.label] = palette[Object.keys(colors).length % palette.length];
+  n.x = W / 2 + Math.cos(i) * 200 * Math.random();
+  n.y = H / 2 + Math.sin(i) * 200 * Math.random();
+});
+// A few hundred steps of repulsion between nodes and springs along edges.
+for (let step = 0; step < 300; step++) {
+  for (const a of graph.nodes) { a.dx = 0; a.dy = 0; }
+  for (const a of graph.nodes) for (const b of graph.nodes) {
+    if (a === b) continue;
+    const dx = a.x - b.x, dy = a.y - b.y, d2 = Math.max(dx * dx + dy * dy, 1);
+    a.dx += dx / d2 * 800; a.dy += dy / d2 * 800;
+  }
+  for (const e of graph.edges) {
+    const a = graph.nodes[e.from], b = graph.nodes[e.to];
+    const dx = b.x - a.x, dy = b.y - a.y;
+    a.dx += dx * 0.01; a.dy += dy * 0.01; b.dx -= dx * 0.01; b.dy -= dy * 0.01;
+  }
+  for (const a of graph.nodes) {
+    a.x = Math.min(W - 20, Math.max(20, a.x + Math.max(-10, Math.min(10, a.dx))));
+    a.y = Math.min(H - 20, Math.max(20, a.y + Math.max(-10, Math.min(10, a.dy))));
+  }
+}
+const el = (tag, attrs, text) => {
+  const e = document.createElementNS(NS, tag);
+  for (const k in attrs) e.setAttribute(k, attrs[k]);
+  if (text) e.textContent = text;
+  svg.appendChild(e);
+  return e;
+};
+for (const e of graph.edges) {
+  const a = graph.nodes[e.from], b = graph.nodes[e.to];
+  el("line", {x1: a.x, y1: a.y, x2: b.x, y2: b.y, class: "edge", "marker-end": "url(#arrow)"});
+  el("text", {x: (a.x + b.x) / 2, y: (a.y + b.y) / 2, class: "edge-label"}, e.type);
+}
+for (const n of graph.nodes) {
+  const c = el("circle", {cx: n.x, cy: n.y, r: 9, fill: colors[n.label]});
+  c.addEventListener("click", () => {
+    const details = document.getElementById("details");
+    details.textContent = "";
+    const h = document.createElement("h4"); h.textContent = n.caption;
+    const pre = document.createElement("pre"); pre.textContent = JSON.stringify(n.properties || {}, null, 2);
+    details.append(h, pre);
+  });
+  el("text", {x: n.x + 12, y: n.y + 4}, n.caption);
+}
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func node(id, label string, props map[string]any) map[string]any {
+	return map[string]any{"id": id, "labels": []any{label}, "properties": props}
+}
+
+func rel(from, typ, to string) map[string]any {
+	return map[string]any{"type": typ, "startNode": from, "endNode": to, "properties": map[string]any{}}
+}
+
+func TestSubgraphFromRows(t *testing.T) {
+	host := node("h", "Host", map[string]any{"hostname": "api-1"})
+	snap := node("s", "Snapshot", map[string]any{"snapshot_id": "api-1-1", "collected_at": "2026-01-02T03:04:05Z"})
+	cause := node("c", "Cause", map[string]any{"primary_cause": "container_cpu_hog"})
+	container := node("k", "Container", map[string]any{"container_id": "abc"})
+	rows := []map[string]any{
+		{"anchors": []any{host, snap}, "anchorRels": []any{rel("h", "HAS_SNAPSHOT", "s")},
+			"nodes": []any{snap, cause}, "rels": []any{rel("s", "HAS_CAUSE", "c")}},
+		{"anchors": []any{host, snap}, "anchorRels": []any{rel("h", "HAS_SNAPSHOT", "s")},
+			"nodes": []any{snap, cause, container}, "rels": []any{rel("s", "HAS_CAUSE", "c"), rel("c", "CAUSED_BY", "k"), rel("k", "RUNS_ON", "gone")}},
+	}
+	g := subgraphFromRows(rows)
+	g.SnapshotID = "api-1-1"
+
+	if len(g.Nodes) != 4 || len(g.Edges) != 3 {
+		t.Fatalf("nodes %+v, edges %+v", g.Nodes, g.Edges)
+	}
+	if got := g.Nodes[0].Caption(); got != "Host api-1" {
+		t.Errorf("caption = %q", got)
+	}
+
+	dot := g.DOT()
+	for _, want := range []string{`digraph "snapshot api-1-1"`, `[label="Cause container_cpu_hog"]`, `n0 -> n1 [label="HAS_SNAPSHOT"]`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT lacks %q:\n%s", want, dot)
+		}
+	}
+
+	gml, err := g.GraphML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Nodes []struct{} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(gml, &doc); err != nil || len(doc.Nodes) != 4 || len(doc.Edges) != 3 {
+		t.Errorf("GraphML: %v, %d nodes, %d edges", err, len(doc.Nodes), len(doc.Edges))
+	}
+
+	page, err := g.Export(FormatHTML)
+	if err != nil || !strings.Contains(string(page), `"caption":"Container abc"`) {
+		t.Errorf("HTML: %v\n%s", err, page)
+	}
+	if _, err := g.Export("png"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
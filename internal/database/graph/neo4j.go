@@ -2,9 +2,14 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
+	"syschecker/internal/chaos"
 	"syschecker/internal/database/relational"
 	"syschecker/internal/output"
 
@@ -17,41 +22,117 @@ type GraphClient interface {
 	Reset(ctx context.Context) error
 	IngestSnapshot(ctx context.Context, payload *output.PipelinePayload) error
 	ExecuteCypher(ctx context.Context, query string) ([]map[string]any, error)
+	RunCypher(ctx context.Context, query string, opts CypherOptions) ([]map[string]any, error)
+}
+
+// BatchIngester is a GraphClient that can ingest several payloads in one
+// write, saving a transaction per payload when many hosts report at once.
+type BatchIngester interface {
+	IngestSnapshots(ctx context.Context, payloads []*output.PipelinePayload) error
+}
+
+// IsTransient reports whether a graph write failed because Neo4j was
+// unreachable or asked for a retry, rather than because of the write itself.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var conn *neo4j.ConnectivityError
+	var limit *neo4j.TransactionExecutionLimit // retries of a retryable error ran out
+	return errors.As(err, &conn) || errors.As(err, &limit) || neo4j.IsRetryable(err) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
 }
 
 // Neo4jClient implements GraphClient for Neo4j.
 type Neo4jClient struct {
-	driver neo4j.DriverWithContext
-	dbName string
+	mu      sync.RWMutex // guards driver, replaced by Reconnect
+	driver  neo4j.DriverWithContext
+	dbName  string
+	connect func(ctx context.Context) (neo4j.DriverWithContext, error)
 }
 
-// NewNeo4jClient creates a new Neo4j client.
-func NewNeo4jClient(uri, username, password, dbName string) (*Neo4jClient, error) {
-	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""))
+// NewNeo4jClient creates a new Neo4j client. It authenticates with
+// username and password unless an option supplies a token, and accepts
+// the driver's URI schemes, including neo4j+s for Aura.
+func NewNeo4jClient(uri, username, password, dbName string, opts ...Neo4jOption) (*Neo4jClient, error) {
+	var o neo4jOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	token, err := o.authToken(username, password)
+	if err != nil {
+		return nil, err
+	}
+	configurers, err := o.configurers(uri)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create neo4j driver: %w", err)
+		return nil, err
+	}
+
+	connect := func(ctx context.Context) (neo4j.DriverWithContext, error) {
+		driver, err := neo4j.NewDriverWithContext(uri, token, configurers...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create neo4j driver: %w", err)
+		}
+		if err := driver.VerifyConnectivity(ctx); err != nil {
+			driver.Close(ctx)
+			return nil, fmt.Errorf("failed to connect to neo4j: %w", err)
+		}
+		return driver, nil
 	}
 
-	// Verify connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := driver.VerifyConnectivity(ctx); err != nil {
-		return nil, fmt.Errorf("failed to connect to neo4j: %w", err)
+	driver, err := connect(ctx)
+	if err != nil {
+		return nil, err
 	}
-
 	return &Neo4jClient{
-		driver: driver,
-		dbName: dbName,
+		driver:  driver,
+		dbName:  dbName,
+		connect: connect,
 	}, nil
 }
 
 func (c *Neo4jClient) Close(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.driver.Close(ctx)
 }
 
+// Ping checks that Neo4j accepts connections.
+func (c *Neo4jClient) Ping(ctx context.Context) error {
+	c.mu.RLock()
+	driver := c.driver
+	c.mu.RUnlock()
+	return driver.VerifyConnectivity(ctx)
+}
+
+// Reconnect replaces the driver with a freshly connected one, dropping
+// pooled connections that went stale, e.g. after a cluster failover. The
+// old driver is kept if the new one cannot connect. Sessions still open
+// on the old driver fail once it is closed.
+func (c *Neo4jClient) Reconnect(ctx context.Context) error {
+	driver, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	old := c.driver
+	c.driver = driver
+	c.mu.Unlock()
+	return old.Close(ctx)
+}
+
+// session opens a session on the current driver.
+func (c *Neo4jClient) session(ctx context.Context) neo4j.SessionWithContext {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+}
+
 // Reset deletes all data in the graph.
 func (c *Neo4jClient) Reset(ctx context.Context) error {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+	session := c.session(ctx)
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -62,304 +143,470 @@ func (c *Neo4jClient) Reset(ctx context.Context) error {
 
 // IngestSnapshot pushes the pipeline payload into the graph.
 func (c *Neo4jClient) IngestSnapshot(ctx context.Context, payload *output.PipelinePayload) error {
-	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+	return c.IngestSnapshots(ctx, []*output.PipelinePayload{payload})
+}
+
+// IngestSnapshots pushes payloads into the graph in one transaction, in
+// order. Each step is a single UNWIND over the rows of every payload, so a
+// batch from many hosts costs the round trips of one snapshot. Either all
+// payloads are written or none.
+func (c *Neo4jClient) IngestSnapshots(ctx context.Context, payloads []*output.PipelinePayload) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+	if err := chaos.Err(chaos.Neo4jOutage); err != nil {
+		return err
+	}
+	batch := newIngestBatch(payloads)
+	session := c.session(ctx)
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// 1. Merge Host
-		if err := mergeHost(ctx, tx, payload.Raw); err != nil {
-			return nil, err
-		}
-
-		// 2. Create Snapshot
-		snapID, err := createSnapshot(ctx, tx, payload)
-		if err != nil {
-			return nil, err
-		}
-
-		// 3. Link Host -> Snapshot
-		if err := linkHostSnapshot(ctx, tx, payload.Raw.AgentID, snapID); err != nil {
-			return nil, err
-		}
-
-		// 4. Create Flags & Causes
-		if err := createFlagsAndCauses(ctx, tx, snapID, payload.Flags); err != nil {
-			return nil, err
-		}
-
-		// 5. Create Dimensions (Disks, Interfaces, etc.) & Links
-		if err := createDimensions(ctx, tx, snapID, payload.Raw); err != nil {
-			return nil, err
-		}
-
-		return nil, nil
+		return nil, batch.write(ctx, tx)
 	})
-
 	return err
 }
 
-func mergeHost(ctx context.Context, tx neo4j.ManagedTransaction, raw relational.RawStatsFixed) error {
-	query := `
-		MERGE (h:Host {agent_id: $agent_id})
-		SET h.host_id = $host_id,
-			h.machine_id = $machine_id,
-			h.boot_id = $boot_id,
-			h.hostname = $hostname,
-			h.os = $os,
-			h.platform = $platform,
-			h.kernel_version = $kernel_version
-	`
-	params := map[string]any{
-		"agent_id":       raw.AgentID,
-		"host_id":        raw.AgentID, // Using AgentID as HostID for simplicity if int64 not avail
-		"machine_id":     raw.MachineID,
-		"boot_id":        raw.BootID,
-		"hostname":       raw.Hostname,
-		"os":             raw.OS,
-		"platform":       raw.Platform,
-		"kernel_version": raw.KernelVersion,
-	}
-	_, err := tx.Run(ctx, query, params)
-	return err
+// ingestStep is one UNWIND write over $rows. Rows name their snapshot by
+// its index "i" in the batch, resolved through $snap_ids, and cause links
+// their cause by index "c", resolved through $cause_ids.
+type ingestStep struct {
+	query string
+	rows  []map[string]any
+}
+
+// ingestBatch holds the parameter rows of a batch of payloads.
+type ingestBatch struct {
+	hosts      []map[string]any // one per agent, from its latest payload
+	agentIDs   []string
+	snapshots  []map[string]any
+	causes     []map[string]any
+	causeLinks []ingestStep // by entity type, after causes
+	steps      []ingestStep // after snapshots
 }
 
-func createSnapshot(ctx context.Context, tx neo4j.ManagedTransaction, p *output.PipelinePayload) (string, error) {
-	query := `
+// Queries for the batch steps.
+const (
+	mergeHostsQuery = `
+		UNWIND $rows AS row
+		MERGE (h:Host {agent_id: row.agent_id})
+		SET h.host_id = row.host_id,
+			h.machine_id = row.machine_id,
+			h.boot_id = row.boot_id,
+			h.hostname = row.hostname,
+			h.os = row.os,
+			h.platform = row.platform,
+			h.kernel_version = row.kernel_version,
+			h.containerized = row.containerized,
+			h.cgroup_mem_limit_bytes = row.cgroup_mem_limit_bytes,
+			h.cgroup_cpu_limit = row.cgroup_cpu_limit,
+			h.environment = row.environment,
+			h.hypervisor = row.hypervisor,
+			h.cloud_provider = row.cloud_provider,
+			h.labels = row.labels
+		FOREACH (_ IN CASE WHEN row.containerized THEN [1] ELSE [] END | SET h:Containerized)
+		FOREACH (_ IN CASE WHEN row.containerized THEN [] ELSE [1] END | REMOVE h:Containerized)
+	`
+	createSnapshotsQuery = `
+		UNWIND $rows AS row
+		MATCH (h:Host {agent_id: row.agent_id})
 		CREATE (s:Snapshot {
-			snapshot_id: $snapshot_id,
-			collected_at: $collected_at,
-			kind: $kind,
-			
-			cpu_usage_pct: $cpu_usage,
-			ram_usage_pct: $ram_usage,
-			disk_usage_pct: $disk_usage,
-			
-			severity_level: $severity,
-			risk_score: $risk_score,
-			primary_cause: $primary_cause,
-			explanation: $explanation
+			snapshot_id: row.snapshot_id,
+			collected_at: row.collected_at,
+			kind: row.kind,
+
+			cpu_usage_pct: row.cpu_usage,
+			ram_usage_pct: row.ram_usage,
+			disk_usage_pct: row.disk_usage,
+
+			severity_level: row.severity,
+			risk_score: row.risk_score,
+			primary_cause: row.primary_cause,
+			explanation: row.explanation,
+			trace_id: row.trace_id
 		})
-		RETURN elementId(s)
+		CREATE (h)-[:HAS_SNAPSHOT]->(s)
+		RETURN row.i, elementId(s)
 	`
-	// Generate a unique ID for snapshot if not present, or use timestamp
-	snapID := fmt.Sprintf("%s-%d", p.Raw.AgentID, p.Raw.CollectedAt.UnixNano())
-
-	params := map[string]any{
-		"snapshot_id":   snapID,
-		"collected_at":  p.Raw.CollectedAt.Format(time.RFC3339),
-		"kind":          string(p.Raw.Kind),
-		"cpu_usage":     p.Raw.CPUUsagePct,
-		"ram_usage":     p.Raw.RAMUsagePct,
-		"disk_usage":    p.Raw.DiskUsagePct,
-		"severity":      p.Flags.SeverityLevel,
-		"risk_score":    p.Flags.RiskScore,
-		"primary_cause": p.Flags.PrimaryCause,
-		"explanation":   p.Flags.Explanation,
-	}
-
-	res, err := tx.Run(ctx, query, params)
-	if err != nil {
-		return "", err
-	}
+	createFlagsQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (f:Flag {name: row.name})
+		CREATE (s)-[:TRIGGERED]->(f)
+	`
+	createCausesQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		CREATE (c:Cause {
+			primary_cause: row.primary,
+			entity_type: row.etype,
+			entity_key: row.ekey,
+			explanation: row.expl,
+			trace_id: s.trace_id
+		})
+		CREATE (s)-[:HAS_CAUSE]->(c)
+		RETURN row.c, elementId(c)
+	`
+	// linkCausesQuery is completed with the pattern of the blamed entity.
+	linkCausesQuery = `
+		UNWIND $rows AS row
+		MATCH (c:Cause) WHERE elementId(c) = $cause_ids[row.c]
+		MERGE (t:%s)
+		CREATE (c)-[:CAUSED_BY]->(t)
+	`
+	createDiskIOQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (d:DiskDevice {device: row.device, host_id: row.agent_id})
+		CREATE (s)-[:OBSERVED_DISK_IO {
+			read_bytes: row.rb, write_bytes: row.wb,
+			read_count: row.rc, write_count: row.wc
+		}]->(d)
+	`
+	createInterfacesQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (n:NetInterface {name: row.name, host_id: row.agent_id})
+		CREATE (s)-[:OBSERVED_INTERFACE {
+			bytes_sent: row.bs, bytes_recv: row.br,
+			packets_sent: row.ps, packets_recv: row.pr,
+			err_in: row.ei, err_out: row.eo
+		}]->(n)
+	`
+	createContainersQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (cnt:Container {container_id: row.cid})
+		SET cnt.name = row.name, cnt.image = row.image, cnt.host_id = row.agent_id
+		CREATE (s)-[:OBSERVED_CONTAINER {
+			cpu_usage_pct: row.cpu,
+			mem_usage_bytes: row.mem,
+			status: row.status
+		}]->(cnt)
+	`
+	createProcessesQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (cnt:Container {container_id: row.cid})
+		MERGE (p:Process {name: row.process})
+		MERGE (p)-[r:RUNS_IN]->(cnt)
+		SET r.pid = row.pid
+		CREATE (s)-[:OBSERVED_PROCESS {
+			pid: row.pid,
+			cpu_pct: row.cpu,
+			mem_pct: row.mem,
+			container_id: row.cid
+		}]->(p)
+	`
+	createDeviationsQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		CREATE (d:Deviation {
+			metric: row.metric,
+			slot: row.slot,
+			actual: row.actual,
+			expected_mean: row.mean,
+			expected_low: row.low,
+			expected_high: row.high,
+			ratio: row.ratio
+		})
+		CREATE (s)-[:HAS_DEVIATION]->(d)
+	`
+	createOOMKillsQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (p:Process {name: row.process})
+		CREATE (e:OOMKill {
+			occurred_at: row.at,
+			pid: row.pid,
+			cgroup: row.cgroup,
+			uid: row.uid,
+			anon_rss_bytes: row.rss,
+			constraint: row.constraint,
+			host_id: row.agent_id
+		})
+		CREATE (s)-[:HAS_EVENT]->(e)
+		CREATE (e)-[:KILLED]->(p)
+	`
+	createLargestDirsQuery = `
+		UNWIND $rows AS row
+		MATCH (s:Snapshot) WHERE elementId(s) = $snap_ids[row.i]
+		MERGE (dir:Directory {path: row.path, host_id: row.agent_id})
+		CREATE (s)-[:LARGEST_DIR {rank: row.rank, size_bytes: row.size}]->(dir)
+	`
+)
 
-	rec, err := res.Single(ctx)
-	if err != nil {
-		return "", err
-	}
-	return rec.Values[0].(string), nil
+// causeEntities maps a cause's entity type to the node it blames.
+var causeEntities = map[string]string{
+	"container": "Container {container_id: row.key}",
+	"disk":      "DiskDevice {device: row.key}",
+	"netif":     "NetInterface {name: row.key}",
+	"mount":     "Mount {mountpoint: row.key}",
+	"file":      "File {path: row.key}",
+	"directory": "Directory {path: row.key}",
+	"user":      "User {name: row.key}",
+	"process":   "Process {name: row.key}",
+	"check":     "Check {name: row.key}",
 }
 
-func linkHostSnapshot(ctx context.Context, tx neo4j.ManagedTransaction, agentID, snapElementID string) error {
-	query := `
-		MATCH (h:Host {agent_id: $agent_id})
-		MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-		CREATE (h)-[:HAS_SNAPSHOT]->(s)
-	`
-	_, err := tx.Run(ctx, query, map[string]any{
-		"agent_id": agentID,
-		"snap_id":  snapElementID,
-	})
-	return err
+// graphFlags are the flags written as TRIGGERED relationships.
+var graphFlags = []struct {
+	name string
+	set  func(relational.SnapshotFlags) bool
+}{
+	{"cpu_overloaded", func(f relational.SnapshotFlags) bool { return f.FlagCPUOverloaded }},
+	{"memory_pressure", func(f relational.SnapshotFlags) bool { return f.FlagMemoryPressure }},
+	{"disk_space_critical", func(f relational.SnapshotFlags) bool { return f.FlagDiskSpaceCritical }},
+	{"network_latency", func(f relational.SnapshotFlags) bool { return f.FlagNetworkLatencyDegraded }},
+	{"disk_io_saturation", func(f relational.SnapshotFlags) bool { return f.FlagDiskIOSaturation }},
+	{"docker_unavailable", func(f relational.SnapshotFlags) bool { return f.FlagDockerUnavailable }},
 }
 
-func createFlagsAndCauses(ctx context.Context, tx neo4j.ManagedTransaction, snapElementID string, flags relational.SnapshotFlags) error {
-	// 1. Triggered Flags
-	flagMap := map[string]bool{
-		"cpu_overloaded":      flags.FlagCPUOverloaded,
-		"memory_pressure":     flags.FlagMemoryPressure,
-		"disk_space_critical": flags.FlagDiskSpaceCritical,
-		"network_latency":     flags.FlagNetworkLatencyDegraded,
-		"disk_io_saturation":  flags.FlagDiskIOSaturation,
-		"docker_unavailable":  flags.FlagDockerUnavailable,
-	}
+// newIngestBatch gathers the rows of every step from payloads.
+func newIngestBatch(payloads []*output.PipelinePayload) *ingestBatch {
+	b := &ingestBatch{}
+	hostIndex := map[string]int{}
+	var flags, diskIO, interfaces, containers, processes, deviations, ooms, dirs []map[string]any
+	links := map[string][]map[string]any{}
+
+	for i, p := range payloads {
+		raw := p.Raw
+		host := map[string]any{
+			"agent_id":               raw.AgentID,
+			"host_id":                raw.AgentID, // Using AgentID as HostID for simplicity if int64 not avail
+			"machine_id":             raw.MachineID,
+			"boot_id":                raw.BootID,
+			"hostname":               raw.Hostname,
+			"os":                     raw.OS,
+			"platform":               raw.Platform,
+			"kernel_version":         raw.KernelVersion,
+			"containerized":          raw.Containerized,
+			"cgroup_mem_limit_bytes": int64(raw.CgroupMemLimitBytes),
+			"cgroup_cpu_limit":       raw.CgroupCPULimit,
+			"environment":            raw.Environment,
+			"hypervisor":             raw.Hypervisor,
+			"cloud_provider":         raw.CloudProvider,
+			"labels":                 relational.FormatLabels(raw.Labels),
+		}
+		if j, ok := hostIndex[raw.AgentID]; ok {
+			b.hosts[j] = host
+		} else {
+			hostIndex[raw.AgentID] = len(b.hosts)
+			b.hosts = append(b.hosts, host)
+			b.agentIDs = append(b.agentIDs, raw.AgentID)
+		}
 
-	for name, triggered := range flagMap {
-		if triggered {
-			query := `
-				MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-				MERGE (f:Flag {name: $name})
-				CREATE (s)-[:TRIGGERED]->(f)
-			`
-			if _, err := tx.Run(ctx, query, map[string]any{"snap_id": snapElementID, "name": name}); err != nil {
-				return err
+		b.snapshots = append(b.snapshots, map[string]any{
+			"i":        i,
+			"agent_id": raw.AgentID,
+			// Generate a unique ID for snapshot if not present, or use timestamp
+			"snapshot_id":   fmt.Sprintf("%s-%d", raw.AgentID, raw.CollectedAt.UnixNano()),
+			"collected_at":  raw.CollectedAt.UTC().Format(time.RFC3339),
+			"kind":          string(raw.Kind),
+			"cpu_usage":     raw.CPUUsagePct,
+			"ram_usage":     raw.RAMUsagePct,
+			"disk_usage":    raw.DiskUsagePct,
+			"severity":      p.Flags.SeverityLevel,
+			"risk_score":    p.Flags.RiskScore,
+			"primary_cause": p.Flags.PrimaryCause,
+			"explanation":   p.Flags.Explanation,
+			"trace_id":      raw.TraceID,
+		})
+
+		for _, f := range graphFlags {
+			if f.set(p.Flags) {
+				flags = append(flags, map[string]any{"i": i, "name": f.name})
 			}
 		}
-	}
 
-	// 2. Cause
-	if flags.PrimaryCause != "" {
-		query := `
-			MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-			CREATE (c:Cause {
-				primary_cause: $primary,
-				entity_type: $etype,
-				entity_key: $ekey,
-				explanation: $expl
+		if p.Flags.PrimaryCause != "" {
+			c := len(b.causes)
+			b.causes = append(b.causes, map[string]any{
+				"i":       i,
+				"c":       c,
+				"primary": p.Flags.PrimaryCause,
+				"etype":   p.Flags.CauseEntityType,
+				"ekey":    p.Flags.CauseEntityKey,
+				"expl":    p.Flags.Explanation,
 			})
-			CREATE (s)-[:HAS_CAUSE]->(c)
-			RETURN elementId(c)
-		`
-		params := map[string]any{
-			"snap_id": snapElementID,
-			"primary": flags.PrimaryCause,
-			"etype":   flags.CauseEntityType,
-			"ekey":    flags.CauseEntityKey,
-			"expl":    flags.Explanation,
-		}
-		res, err := tx.Run(ctx, query, params)
-		if err != nil {
-			return err
+			if _, ok := causeEntities[p.Flags.CauseEntityType]; ok {
+				links[p.Flags.CauseEntityType] = append(links[p.Flags.CauseEntityType], map[string]any{"c": c, "key": p.Flags.CauseEntityKey})
+			}
 		}
 
-		// Link Cause to Entity if possible
-		// This requires creating the entity nodes first or merging them.
-		// For simplicity, we'll skip the dynamic linking here or do it in createDimensions if we pass the cause ID.
-		// But the prompt asks for: (Cause)-[:CAUSED_BY]->(Entity)
-
-		// We can do a generic merge based on type
-		causeElementIDRec, err := res.Single(ctx)
-		if err == nil {
-			causeElementID := causeElementIDRec.Values[0].(string)
-			linkCauseToEntity(ctx, tx, causeElementID, flags.CauseEntityType, flags.CauseEntityKey)
+		for _, io := range raw.IOCounters {
+			diskIO = append(diskIO, map[string]any{
+				"i":        i,
+				"device":   io.Device,
+				"agent_id": raw.AgentID,
+				"rb":       io.ReadBytes,
+				"wb":       io.WriteBytes,
+				"rc":       io.ReadCount,
+				"wc":       io.WriteCount,
+			})
+		}
+		for _, net := range raw.NetInterfaces {
+			interfaces = append(interfaces, map[string]any{
+				"i":        i,
+				"name":     net.Name,
+				"agent_id": raw.AgentID,
+				"bs":       net.BytesSent,
+				"br":       net.BytesRecv,
+				"ps":       net.PacketsSent,
+				"pr":       net.PacketsRecv,
+				"ei":       net.ErrIn,
+				"eo":       net.ErrOut,
+			})
+		}
+		for _, c := range raw.DockerContainers {
+			containers = append(containers, map[string]any{
+				"i":        i,
+				"cid":      c.ID,
+				"name":     c.Name,
+				"image":    c.Image,
+				"agent_id": raw.AgentID,
+				"cpu":      c.CPUUsagePct,
+				"mem":      c.MemUsageBytes,
+				"status":   c.Status,
+			})
+		}
+		// Processes running inside containers
+		for _, proc := range raw.TopProcesses {
+			if proc.ContainerID == "" {
+				continue
+			}
+			processes = append(processes, map[string]any{
+				"i":       i,
+				"cid":     proc.ContainerID,
+				"process": proc.Name,
+				"pid":     proc.PID,
+				"cpu":     proc.CPUPct,
+				"mem":     float64(proc.MemPct),
+			})
+		}
+		// Seasonal deviations (expected vs actual)
+		for _, d := range p.Seasonal {
+			deviations = append(deviations, map[string]any{
+				"i":      i,
+				"metric": d.Metric,
+				"slot":   d.Slot,
+				"actual": d.Actual,
+				"mean":   d.ExpectedMean,
+				"low":    d.ExpectedLow,
+				"high":   d.ExpectedHigh,
+				"ratio":  d.Ratio,
+			})
+		}
+		// OOM kill events and their victim processes
+		for _, k := range raw.OOMKills {
+			ooms = append(ooms, map[string]any{
+				"i":          i,
+				"process":    k.Process,
+				"at":         k.At,
+				"pid":        k.PID,
+				"cgroup":     k.Cgroup,
+				"uid":        k.UID,
+				"rss":        int64(k.AnonRSSBytes),
+				"constraint": k.Constraint,
+				"agent_id":   raw.AgentID,
+			})
+		}
+		// Largest directories from a disk-critical scan
+		for rank, d := range raw.LargestDirs {
+			dirs = append(dirs, map[string]any{
+				"i":        i,
+				"path":     d.Path,
+				"agent_id": raw.AgentID,
+				"rank":     rank + 1,
+				"size":     int64(d.SizeBytes),
+			})
 		}
 	}
-	return nil
-}
 
-func linkCauseToEntity(ctx context.Context, tx neo4j.ManagedTransaction, causeID, eType, eKey string) {
-	// Helper to link cause to specific entity types
-	var query string
-	switch eType {
-	case "container":
-		query = `
-			MATCH (c:Cause) WHERE elementId(c) = $cause_id
-			MERGE (t:Container {container_id: $key})
-			CREATE (c)-[:CAUSED_BY]->(t)
-		`
-	case "disk":
-		query = `
-			MATCH (c:Cause) WHERE elementId(c) = $cause_id
-			MERGE (t:DiskDevice {device: $key})
-			CREATE (c)-[:CAUSED_BY]->(t)
-		`
-	case "netif":
-		query = `
-			MATCH (c:Cause) WHERE elementId(c) = $cause_id
-			MERGE (t:NetInterface {name: $key})
-			CREATE (c)-[:CAUSED_BY]->(t)
-		`
+	for _, etype := range slices.Sorted(maps.Keys(links)) {
+		b.causeLinks = append(b.causeLinks, ingestStep{fmt.Sprintf(linkCausesQuery, causeEntities[etype]), links[etype]})
 	}
-
-	if query != "" {
-		tx.Run(ctx, query, map[string]any{"cause_id": causeID, "key": eKey})
+	b.steps = []ingestStep{
+		{createFlagsQuery, flags},
+		{createDiskIOQuery, diskIO},
+		{createInterfacesQuery, interfaces},
+		{createContainersQuery, containers},
+		{createProcessesQuery, processes},
+		{createDeviationsQuery, deviations},
+		{createOOMKillsQuery, ooms},
+		{createLargestDirsQuery, dirs},
 	}
+	return b
 }
 
-func createDimensions(ctx context.Context, tx neo4j.ManagedTransaction, snapElementID string, raw relational.RawStatsFixed) error {
-	// 1. Disk Devices
-	for _, io := range raw.IOCounters {
-		query := `
-			MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-			MERGE (d:DiskDevice {device: $device, host_id: $agent_id})
-			CREATE (s)-[:OBSERVED_DISK_IO {
-				read_bytes: $rb, write_bytes: $wb,
-				read_count: $rc, write_count: $wc
-			}]->(d)
-		`
-		params := map[string]any{
-			"snap_id":  snapElementID,
-			"device":   io.Device,
-			"agent_id": raw.AgentID,
-			"rb":       io.ReadBytes,
-			"wb":       io.WriteBytes,
-			"rc":       io.ReadCount,
-			"wc":       io.WriteCount,
-		}
-		if _, err := tx.Run(ctx, query, params); err != nil {
+// write runs the batch's steps in tx.
+func (b *ingestBatch) write(ctx context.Context, tx neo4j.ManagedTransaction) error {
+	if _, err := tx.Run(ctx, mergeHostsQuery, map[string]any{"rows": b.hosts}); err != nil {
+		return err
+	}
+	if err := linkHostServices(ctx, tx, b.agentIDs); err != nil {
+		return err
+	}
+	snapIDs, err := runIndexed(ctx, tx, createSnapshotsQuery, map[string]any{"rows": b.snapshots}, len(b.snapshots))
+	if err != nil {
+		return err
+	}
+	params := map[string]any{"snap_ids": snapIDs}
+	if len(b.causes) > 0 {
+		params["rows"] = b.causes
+		causeIDs, err := runIndexed(ctx, tx, createCausesQuery, params, len(b.causes))
+		if err != nil {
 			return err
 		}
+		for _, step := range b.causeLinks {
+			if _, err := tx.Run(ctx, step.query, map[string]any{"rows": step.rows, "cause_ids": causeIDs}); err != nil {
+				return err
+			}
+		}
 	}
-
-	// 2. Network Interfaces
-	for _, net := range raw.NetInterfaces {
-		query := `
-			MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-			MERGE (n:NetInterface {name: $name, host_id: $agent_id})
-			CREATE (s)-[:OBSERVED_INTERFACE {
-				bytes_sent: $bs, bytes_recv: $br,
-				packets_sent: $ps, packets_recv: $pr,
-				err_in: $ei, err_out: $eo
-			}]->(n)
-		`
-		params := map[string]any{
-			"snap_id":  snapElementID,
-			"name":     net.Name,
-			"agent_id": raw.AgentID,
-			"bs":       net.BytesSent,
-			"br":       net.BytesRecv,
-			"ps":       net.PacketsSent,
-			"pr":       net.PacketsRecv,
-			"ei":       net.ErrIn,
-			"eo":       net.ErrOut,
+	for _, step := range b.steps {
+		if len(step.rows) == 0 {
+			continue
 		}
-		if _, err := tx.Run(ctx, query, params); err != nil {
+		params["rows"] = step.rows
+		if _, err := tx.Run(ctx, step.query, params); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	// 3. Containers
-	for _, c := range raw.DockerContainers {
-		query := `
-			MATCH (s:Snapshot) WHERE elementId(s) = $snap_id
-			MERGE (cnt:Container {container_id: $cid})
-			SET cnt.name = $name, cnt.image = $image, cnt.host_id = $agent_id
-			CREATE (s)-[:OBSERVED_CONTAINER {
-				cpu_usage_pct: $cpu,
-				mem_usage_bytes: $mem,
-				status: $status
-			}]->(cnt)
-		`
-		params := map[string]any{
-			"snap_id":  snapElementID,
-			"cid":      c.ID,
-			"name":     c.Name,
-			"image":    c.Image,
-			"agent_id": raw.AgentID,
-			"cpu":      c.CPUUsagePct,
-			"mem":      c.MemUsageBytes,
-			"status":   c.Status,
+// runIndexed runs a query returning (index, elementId) pairs and lists the
+// element IDs by index.
+func runIndexed(ctx context.Context, tx neo4j.ManagedTransaction, query string, params map[string]any, n int) ([]string, error) {
+	res, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := res.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, n)
+	for _, rec := range recs {
+		i, _ := rec.Values[0].(int64)
+		id, _ := rec.Values[1].(string)
+		if i < 0 || int(i) >= n {
+			return nil, fmt.Errorf("ingest returned index %d of %d", i, n)
 		}
-		if _, err := tx.Run(ctx, query, params); err != nil {
-			return err
+		ids[i] = id
+	}
+	for i, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("ingest wrote no node for row %d", i)
 		}
 	}
-
-	return nil
+	return ids, nil
 }
 
 // ExecuteQuery runs a custom Cypher query and processes results with a callback.
 func ExecuteQuery(ctx context.Context, client *Neo4jClient, query string, processRecord func(record map[string]any)) error {
-	session := client.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: client.dbName})
+	session := client.session(ctx)
 	defer session.Close(ctx)
 
 	result, err := session.Run(ctx, query, nil)
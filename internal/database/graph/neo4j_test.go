@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+func TestNewIngestBatch(t *testing.T) {
+	payload := func(agent, hostname string, cause string) *output.PipelinePayload {
+		return &output.PipelinePayload{
+			Raw: relational.RawStatsFixed{
+				AgentID:          agent,
+				Hostname:         hostname,
+				DockerContainers: []relational.DockerContainerInfoFixed{{ID: agent + "-c"}},
+			},
+			Flags: relational.SnapshotFlags{FlagCPUOverloaded: true, PrimaryCause: cause, CauseEntityType: "container", CauseEntityKey: agent + "-c"},
+		}
+	}
+	b := newIngestBatch([]*output.PipelinePayload{
+		payload("a", "old-name", "cpu"),
+		payload("b", "b", ""),
+		payload("a", "new-name", "cpu"),
+	})
+
+	if len(b.hosts) != 2 || b.hosts[0]["hostname"] != "new-name" || strings.Join(b.agentIDs, ",") != "a,b" {
+		t.Errorf("hosts = %v, agents %v; want a once, with its latest hostname, then b", b.hosts, b.agentIDs)
+	}
+	if len(b.snapshots) != 3 || b.snapshots[2]["i"] != 2 || b.snapshots[2]["agent_id"] != "a" {
+		t.Errorf("snapshots = %v", b.snapshots)
+	}
+	if len(b.causes) != 2 || b.causes[1]["i"] != 2 || b.causes[1]["c"] != 1 {
+		t.Errorf("causes = %v, want payloads 0 and 2", b.causes)
+	}
+	if len(b.causeLinks) != 1 || !strings.Contains(b.causeLinks[0].query, "Container {container_id: row.key}") || len(b.causeLinks[0].rows) != 2 {
+		t.Errorf("cause links = %+v", b.causeLinks)
+	}
+	rows := map[string]int{}
+	for _, step := range b.steps {
+		rows[step.query] = len(step.rows)
+	}
+	if rows[createFlagsQuery] != 3 || rows[createContainersQuery] != 3 || rows[createDiskIOQuery] != 0 {
+		t.Errorf("step rows = flags %d, containers %d, disk IO %d; want 3, 3, 0",
+			rows[createFlagsQuery], rows[createContainersQuery], rows[createDiskIOQuery])
+	}
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// EnvTopology names the topology file when no flag is given.
+const EnvTopology = "SYSCHECKER_TOPOLOGY"
+
+// ServiceRef names a service on a host, by hostname or agent ID.
+type ServiceRef struct {
+	Host    string `json:"host"`
+	Service string `json:"service"`
+}
+
+func (s ServiceRef) String() string { return s.Host + "/" + s.Service }
+
+// Dependency declares that From needs To, e.g. an API on api-1 that uses
+// the database on db-1.
+type Dependency struct {
+	From ServiceRef `json:"from"`
+	To   ServiceRef `json:"to"`
+	Kind string     `json:"kind,omitempty"` // free-form, e.g. "database" or "cache"
+}
+
+// Topology is the declared service dependency graph.
+type Topology struct {
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Validate reports incomplete or self-referencing dependencies.
+func (t *Topology) Validate() error {
+	for i, d := range t.Dependencies {
+		for _, ref := range []ServiceRef{d.From, d.To} {
+			if ref.Host == "" || ref.Service == "" {
+				return fmt.Errorf("dependency %d: host and service are required", i+1)
+			}
+		}
+		if d.From == d.To {
+			return fmt.Errorf("dependency %d: %s depends on itself", i+1, d.From)
+		}
+	}
+	return nil
+}
+
+// Services returns every service named by a dependency, once each.
+func (t *Topology) Services() []ServiceRef {
+	seen := map[ServiceRef]bool{}
+	var out []ServiceRef
+	for _, d := range t.Dependencies {
+		for _, ref := range []ServiceRef{d.From, d.To} {
+			if !seen[ref] {
+				seen[ref] = true
+				out = append(out, ref)
+			}
+		}
+	}
+	return out
+}
+
+// LoadTopology reads a Topology from a JSON file.
+func LoadTopology(file string) (*Topology, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read topology: %w", err)
+	}
+	var t Topology
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse topology %s: %w", file, err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("topology %s: %w", file, err)
+	}
+	return &t, nil
+}
+
+// SyncTopology replaces the Service nodes and DEPENDS_ON relationships with
+// those declared in t and links services to the hosts already in the graph
+// that run them. Hosts ingested later are linked by IngestSnapshot.
+func (c *Neo4jClient) SyncTopology(ctx context.Context, t *Topology) error {
+	services := t.Services()
+	keys := make([]any, len(services))
+	nodes := make([]any, len(services))
+	for i, s := range services {
+		keys[i] = []any{s.Host, s.Service}
+		nodes[i] = map[string]any{"host": s.Host, "name": s.Service}
+	}
+	deps := make([]any, len(t.Dependencies))
+	for i, d := range t.Dependencies {
+		deps[i] = map[string]any{
+			"from_host": d.From.Host, "from": d.From.Service,
+			"to_host": d.To.Host, "to": d.To.Service,
+			"kind": d.Kind,
+		}
+	}
+
+	session := c.session(ctx)
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		steps := []struct {
+			query  string
+			params map[string]any
+		}{
+			{`MATCH (s:Service) WHERE NOT [s.host, s.name] IN $keys DETACH DELETE s`,
+				map[string]any{"keys": keys}},
+			{`MATCH (:Service)-[d:DEPENDS_ON]->(:Service) DELETE d`, nil},
+			{`UNWIND $services AS svc
+			  MERGE (s:Service {host: svc.host, name: svc.name})
+			  WITH s
+			  MATCH (h:Host) WHERE h.hostname = s.host OR h.agent_id = s.host
+			  MERGE (h)-[:RUNS]->(s)`,
+				map[string]any{"services": nodes}},
+			{`UNWIND $deps AS dep
+			  MATCH (a:Service {host: dep.from_host, name: dep.from})
+			  MATCH (b:Service {host: dep.to_host, name: dep.to})
+			  MERGE (a)-[d:DEPENDS_ON]->(b)
+			  SET d.kind = dep.kind`,
+				map[string]any{"deps": deps}},
+		}
+		for _, step := range steps {
+			if _, err := tx.Run(ctx, step.query, step.params); err != nil {
+				return nil, fmt.Errorf("sync topology: %w", err)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// linkHostServices attaches hosts to the declared services they run.
+func linkHostServices(ctx context.Context, tx neo4j.ManagedTransaction, agentIDs []string) error {
+	query := `
+		UNWIND $agent_ids AS agent_id
+		MATCH (h:Host {agent_id: agent_id})
+		MATCH (s:Service) WHERE s.host = h.hostname OR s.host = h.agent_id
+		MERGE (h)-[:RUNS]->(s)
+	`
+	_, err := tx.Run(ctx, query, map[string]any{"agent_ids": agentIDs})
+	return err
+}
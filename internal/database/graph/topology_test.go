@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTopology(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "topology.json")
+	data := `{"dependencies": [
+		{"from": {"host": "api-1", "service": "api"}, "to": {"host": "db-1", "service": "postgres"}, "kind": "database"},
+		{"from": {"host": "api-1", "service": "api"}, "to": {"host": "cache-1", "service": "redis"}}
+	]}`
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	topo, err := LoadTopology(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := topo.Services(); len(got) != 3 || got[0].String() != "api-1/api" {
+		t.Errorf("services = %v, want api-1/api, db-1/postgres, cache-1/redis", got)
+	}
+
+	for _, bad := range []Topology{
+		{Dependencies: []Dependency{{From: ServiceRef{Host: "a"}, To: ServiceRef{Host: "b", Service: "db"}}}},
+		{Dependencies: []Dependency{{From: ServiceRef{"a", "x"}, To: ServiceRef{"a", "x"}}}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"syschecker/internal/database/graph"
+	"syschecker/internal/output"
+)
+
+// DefaultGraphBufferMaxBytes caps the graph buffer.
+const DefaultGraphBufferMaxBytes = 64 << 20
+
+// GraphBuffer keeps graph ingests that failed while Neo4j was unreachable
+// in an on-disk journal and replays them, oldest first, before the next
+// ingest, so the graph catches up after an outage instead of missing it.
+// Ingests may run concurrently, e.g. from a GraphIngestPool: the journal
+// serializes replays, and a host's next ingest replays its earlier ones
+// before it runs.
+type GraphBuffer struct {
+	journal *Journal
+}
+
+// OpenGraphBuffer uses the JSONL file at path, keeping ingests buffered by
+// a previous run. maxBytes <= 0 means DefaultGraphBufferMaxBytes.
+func OpenGraphBuffer(path string, maxBytes int64) (*GraphBuffer, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultGraphBufferMaxBytes
+	}
+	j, err := OpenJournal(path, maxBytes, WithTransient(graph.IsTransient))
+	if err != nil {
+		return nil, err
+	}
+	return &GraphBuffer{journal: j}, nil
+}
+
+// Path returns the buffer file.
+func (b *GraphBuffer) Path() string {
+	return b.journal.Path()
+}
+
+// Backlog returns the number of ingests waiting for Neo4j.
+func (b *GraphBuffer) Backlog() int {
+	return b.journal.Pending()
+}
+
+// Ingest replays buffered ingests into g, then ingests payload. When Neo4j
+// is still unreachable payload is buffered behind them and the error says
+// so; an ingest failing for another reason is returned without buffering.
+func (b *GraphBuffer) Ingest(ctx context.Context, g graph.GraphClient, payload *output.PipelinePayload) error {
+	n, err := b.journal.Replay(ctx, g.IngestSnapshot)
+	if n > 0 {
+		fmt.Fprintf(os.Stderr, "Replayed %d buffered graph ingests, %d left\n", n, b.journal.Pending())
+	}
+	if err == nil {
+		err = g.IngestSnapshot(ctx, payload)
+		if err == nil || !graph.IsTransient(err) {
+			return err
+		}
+	}
+	if jerr := b.journal.Append(payload); jerr != nil {
+		return fmt.Errorf("%w (buffer: %v)", err, jerr)
+	}
+	return fmt.Errorf("%w (buffered for replay, %d pending)", err, b.journal.Pending())
+}
+
+// IngestBatch is Ingest for several payloads written by bi in one
+// transaction. When Neo4j is still unreachable all of them are buffered.
+func (b *GraphBuffer) IngestBatch(ctx context.Context, g graph.GraphClient, bi graph.BatchIngester, payloads []*output.PipelinePayload) error {
+	n, err := b.journal.Replay(ctx, g.IngestSnapshot)
+	if n > 0 {
+		fmt.Fprintf(os.Stderr, "Replayed %d buffered graph ingests, %d left\n", n, b.journal.Pending())
+	}
+	if err == nil {
+		err = bi.IngestSnapshots(ctx, payloads)
+		if err == nil || !graph.IsTransient(err) {
+			return err
+		}
+	}
+	for _, payload := range payloads {
+		if jerr := b.journal.Append(payload); jerr != nil {
+			return fmt.Errorf("%w (buffer: %v)", err, jerr)
+		}
+	}
+	return fmt.Errorf("%w (buffered for replay, %d pending)", err, b.journal.Pending())
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"syschecker/internal/database/graph"
+	"syschecker/internal/output"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type flakyGraph struct {
+	graph.GraphClient
+	down     bool
+	ingested []float64
+}
+
+func (g *flakyGraph) IngestSnapshot(_ context.Context, p *output.PipelinePayload) error {
+	if g.down {
+		return &neo4j.ConnectivityError{Inner: errors.New("connection refused")}
+	}
+	g.ingested = append(g.ingested, p.Raw.CPUUsagePct)
+	return nil
+}
+
+func TestGraphBufferReplaysAfterOutage(t *testing.T) {
+	b, err := OpenGraphBuffer(filepath.Join(t.TempDir(), "graph-buffer"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &flakyGraph{down: true}
+	ctx := context.Background()
+	for cpu := range 2 {
+		if err := b.Ingest(ctx, g, idlePayload(float64(cpu))); err == nil {
+			t.Fatal("Ingest should report the outage")
+		}
+	}
+	if b.Backlog() != 2 {
+		t.Fatalf("backlog = %d, want 2", b.Backlog())
+	}
+
+	g.down = false
+	if err := b.Ingest(ctx, g, idlePayload(2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.ingested) != 3 || g.ingested[0] != 0 || g.ingested[1] != 1 || g.ingested[2] != 2 {
+		t.Errorf("ingested %v, want 0, 1 then 2", g.ingested)
+	}
+	if b.Backlog() != 0 {
+		t.Errorf("backlog = %d after replay, want 0", b.Backlog())
+	}
+}
+
+func TestGraphIngestFailuresAreNotBuffered(t *testing.T) {
+	b, err := OpenGraphBuffer(filepath.Join(t.TempDir(), "graph-buffer"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := &rejectingGraph{err: &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "bad"}}
+	if err := b.Ingest(context.Background(), bad, idlePayload(1)); err == nil {
+		t.Fatal("expected the ingest error")
+	}
+	if b.Backlog() != 0 {
+		t.Errorf("backlog = %d; an ingest Neo4j rejects would only fail again", b.Backlog())
+	}
+}
+
+type rejectingGraph struct {
+	graph.GraphClient
+	err error
+}
+
+func (g *rejectingGraph) IngestSnapshot(context.Context, *output.PipelinePayload) error {
+	return g.err
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syschecker/internal/database/graph"
+	"syschecker/internal/output"
+)
+
+const (
+	defaultIngestWorkers = 4
+	defaultIngestQueue   = 16
+	defaultIngestTimeout = 30 * time.Second
+	defaultIngestBatch   = 1
+)
+
+var (
+	// ErrIngestQueueFull is returned by Submit when the host's queue is
+	// full, i.e. Neo4j is not keeping up. The payload is dropped.
+	ErrIngestQueueFull = errors.New("graph ingest queue full")
+	// ErrIngestPoolClosed is returned by Submit after Close.
+	ErrIngestPoolClosed = errors.New("graph ingest pool closed")
+)
+
+// GraphIngestStats describes the pool's load, for spotting a graph that
+// falls behind.
+type GraphIngestStats struct {
+	Workers  int    `json:"workers"`
+	Queued   int64  `json:"queued"`    // submitted, waiting for a worker
+	InFlight int64  `json:"in_flight"` // being ingested now
+	Ingested uint64 `json:"ingested"`
+	Failed   uint64 `json:"failed"`
+	Dropped  uint64 `json:"dropped"`           // rejected because the queue was full
+	Backlog  int    `json:"backlog,omitempty"` // waiting in the graph buffer
+}
+
+// GraphIngestPoolOption configures a GraphIngestPool.
+type GraphIngestPoolOption func(*GraphIngestPool)
+
+// WithIngestWorkers sets how many payloads are ingested concurrently.
+func WithIngestWorkers(n int) GraphIngestPoolOption {
+	return func(p *GraphIngestPool) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// WithIngestQueue sets how many payloads each worker holds before Submit
+// starts rejecting them.
+func WithIngestQueue(n int) GraphIngestPoolOption {
+	return func(p *GraphIngestPool) {
+		if n > 0 {
+			p.queueLen = n
+		}
+	}
+}
+
+// WithIngestBuffer routes ingests through b so those failing while Neo4j
+// is unreachable are replayed later.
+func WithIngestBuffer(b *GraphBuffer) GraphIngestPoolOption {
+	return func(p *GraphIngestPool) {
+		p.buffer = b
+	}
+}
+
+// WithIngestBatch lets a worker ingest up to n queued payloads, of any of
+// its hosts, in one transaction when the graph client is a
+// graph.BatchIngester. Payloads are only combined when already queued, so
+// batching adds no latency. If a batch fails for a reason other than an
+// unreachable Neo4j, its payloads are retried one by one so a single bad
+// payload does not take the others with it.
+func WithIngestBatch(n int) GraphIngestPoolOption {
+	return func(p *GraphIngestPool) {
+		if n > 0 {
+			p.batch = n
+		}
+	}
+}
+
+// WithIngestTimeout bounds a single ingest.
+func WithIngestTimeout(d time.Duration) GraphIngestPoolOption {
+	return func(p *GraphIngestPool) {
+		if d > 0 {
+			p.timeout = d
+		}
+	}
+}
+
+// GraphIngestPool pushes payloads into the graph with bounded
+// concurrency. Payloads of one host always go to the same worker, so they
+// are ingested in the order they were submitted; hosts are spread over
+// the workers.
+type GraphIngestPool struct {
+	g        graph.GraphClient
+	buffer   *GraphBuffer
+	workers  int
+	queueLen int
+	timeout  time.Duration
+	batch    int
+
+	mu     sync.RWMutex // guards closed against sends on closed queues
+	closed bool
+	queues []chan *output.PipelinePayload
+	wg     sync.WaitGroup
+
+	queued, inFlight          atomic.Int64
+	ingested, failed, dropped atomic.Uint64
+}
+
+// NewGraphIngestPool starts the workers ingesting into g.
+func NewGraphIngestPool(g graph.GraphClient, opts ...GraphIngestPoolOption) *GraphIngestPool {
+	p := &GraphIngestPool{
+		g:        g,
+		workers:  defaultIngestWorkers,
+		queueLen: defaultIngestQueue,
+		timeout:  defaultIngestTimeout,
+		batch:    defaultIngestBatch,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.queues = make([]chan *output.PipelinePayload, p.workers)
+	for i := range p.queues {
+		p.queues[i] = make(chan *output.PipelinePayload, p.queueLen)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+// Submit queues payload for ingestion without waiting for it.
+func (p *GraphIngestPool) Submit(payload *output.PipelinePayload) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrIngestPoolClosed
+	}
+	p.queued.Add(1)
+	select {
+	case p.queues[p.shard(payload)] <- payload:
+		return nil
+	default:
+		p.queued.Add(-1)
+		p.dropped.Add(1)
+		return ErrIngestQueueFull
+	}
+}
+
+// Close stops accepting payloads and waits for the queued ones.
+func (p *GraphIngestPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// Stats returns the current load.
+func (p *GraphIngestPool) Stats() GraphIngestStats {
+	s := GraphIngestStats{
+		Workers:  p.workers,
+		Queued:   p.queued.Load(),
+		InFlight: p.inFlight.Load(),
+		Ingested: p.ingested.Load(),
+		Failed:   p.failed.Load(),
+		Dropped:  p.dropped.Load(),
+	}
+	if p.buffer != nil {
+		s.Backlog = p.buffer.Backlog()
+	}
+	return s
+}
+
+// shard picks the worker for payload's host.
+func (p *GraphIngestPool) shard(payload *output.PipelinePayload) int {
+	key := payload.Raw.AgentID
+	if key == "" {
+		key = payload.Raw.Hostname
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+func (p *GraphIngestPool) work(q <-chan *output.PipelinePayload) {
+	defer p.wg.Done()
+	bi, _ := p.g.(graph.BatchIngester)
+	batch := make([]*output.PipelinePayload, 0, p.batch)
+	for payload := range q {
+		batch = append(batch[:0], payload)
+		if bi != nil {
+			batch = drain(q, batch, p.batch)
+		}
+		n := int64(len(batch))
+		p.queued.Add(-n)
+		p.inFlight.Add(n)
+		if len(batch) > 1 {
+			err := p.ingestBatch(bi, batch)
+			switch {
+			case err == nil:
+				p.inFlight.Add(-n)
+				p.ingested.Add(uint64(n))
+				continue
+			case graph.IsTransient(err):
+				// One by one would fail the same way; the buffer, if any,
+				// holds the batch for replay.
+				p.inFlight.Add(-n)
+				p.failed.Add(uint64(n))
+				fmt.Fprintf(os.Stderr, "Graph ingest of %d payloads failed: %v\n", n, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Graph ingest of %d payloads failed, retrying one by one: %v\n", n, err)
+		}
+		for _, payload := range batch {
+			err := p.ingest(payload)
+			p.inFlight.Add(-1)
+			if err != nil {
+				p.failed.Add(1)
+				tracef(payload.Raw.TraceID, "Graph ingest failed: %v\n", err)
+				continue
+			}
+			p.ingested.Add(1)
+		}
+	}
+}
+
+// drain adds payloads already waiting in q to batch, up to limit in all.
+func drain(q <-chan *output.PipelinePayload, batch []*output.PipelinePayload, limit int) []*output.PipelinePayload {
+	for len(batch) < limit {
+		select {
+		case payload, ok := <-q:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, payload)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// ingest writes one payload.
+func (p *GraphIngestPool) ingest(payload *output.PipelinePayload) error {
+	// Detached from the submitter so a payload already queued is not
+	// abandoned when the collection cycle that produced it ends.
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	if p.buffer != nil {
+		return p.buffer.Ingest(ctx, p.g, payload)
+	}
+	return p.g.IngestSnapshot(ctx, payload)
+}
+
+// ingestBatch writes batch in one transaction.
+func (p *GraphIngestPool) ingestBatch(bi graph.BatchIngester, batch []*output.PipelinePayload) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	if p.buffer != nil {
+		return p.buffer.IngestBatch(ctx, p.g, bi, batch)
+	}
+	return bi.IngestSnapshots(ctx, batch)
+}
+
+// GraphIngestHandler serves the pool's stats on GET.
+func GraphIngestHandler(p *GraphIngestPool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(p.Stats())
+	})
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"syschecker/internal/database/graph"
+	"syschecker/internal/output"
+)
+
+type recordingGraph struct {
+	graph.GraphClient
+	gate chan struct{} // when set, each ingest waits for a value
+
+	mu  sync.Mutex
+	got map[string][]float64
+}
+
+func (g *recordingGraph) IngestSnapshot(_ context.Context, p *output.PipelinePayload) error {
+	if g.gate != nil {
+		<-g.gate
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.got[p.Raw.AgentID] = append(g.got[p.Raw.AgentID], p.Raw.CPUUsagePct)
+	return nil
+}
+
+func hostPayload(agent string, cpu float64) *output.PipelinePayload {
+	p := idlePayload(cpu)
+	p.Raw.AgentID = agent
+	return p
+}
+
+func TestGraphIngestPoolKeepsHostOrder(t *testing.T) {
+	g := &recordingGraph{got: map[string][]float64{}}
+	pool := NewGraphIngestPool(g, WithIngestWorkers(3), WithIngestQueue(50))
+	hosts := []string{"a", "b", "c", "d", "e"}
+	for i := range 10 {
+		for _, h := range hosts {
+			if err := pool.Submit(hostPayload(h, float64(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	pool.Close()
+
+	for _, h := range hosts {
+		got := g.got[h]
+		if len(got) != 10 {
+			t.Fatalf("host %s: ingested %d, want 10", h, len(got))
+		}
+		for i, cpu := range got {
+			if cpu != float64(i) {
+				t.Fatalf("host %s ingested out of order: %v", h, got)
+			}
+		}
+	}
+	if s := pool.Stats(); s.Ingested != 50 || s.Queued != 0 || s.InFlight != 0 || s.Dropped != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+	if err := pool.Submit(hostPayload("a", 1)); !errors.Is(err, ErrIngestPoolClosed) {
+		t.Errorf("Submit after Close = %v", err)
+	}
+}
+
+func TestGraphIngestPoolDropsWhenFull(t *testing.T) {
+	g := &recordingGraph{gate: make(chan struct{}), got: map[string][]float64{}}
+	pool := NewGraphIngestPool(g, WithIngestWorkers(1), WithIngestQueue(1))
+	// One payload is taken by the blocked worker, one fills the queue; the
+	// worker may not have picked up the first yet, so submit until rejected.
+	var full bool
+	for i := range 3 {
+		if err := pool.Submit(hostPayload("a", float64(i))); errors.Is(err, ErrIngestQueueFull) {
+			full = true
+		}
+	}
+	if !full {
+		t.Fatal("a full queue should reject payloads")
+	}
+	if s := pool.Stats(); s.Dropped == 0 {
+		t.Errorf("stats = %+v, want the drop counted", s)
+	}
+	close(g.gate)
+	pool.Close()
+}
+
+// batchGraph records the sizes of the batches written, failing those that
+// hold the payload with CPU reject.
+type batchGraph struct {
+	recordingGraph
+	reject  float64
+	batches []int
+}
+
+func (g *batchGraph) IngestSnapshots(ctx context.Context, ps []*output.PipelinePayload) error {
+	<-g.gate
+	g.mu.Lock()
+	g.batches = append(g.batches, len(ps))
+	g.mu.Unlock()
+	for _, p := range ps {
+		if p.Raw.CPUUsagePct == g.reject {
+			return errors.New("constraint violation")
+		}
+	}
+	for _, p := range ps {
+		_ = g.recordingGraph.IngestSnapshot(ctx, p)
+	}
+	return nil
+}
+
+func (g *batchGraph) IngestSnapshot(ctx context.Context, p *output.PipelinePayload) error {
+	if p.Raw.CPUUsagePct == g.reject {
+		<-g.gate
+		return errors.New("constraint violation")
+	}
+	return g.recordingGraph.IngestSnapshot(ctx, p)
+}
+
+func TestGraphIngestPoolBatches(t *testing.T) {
+	g := &batchGraph{recordingGraph: recordingGraph{gate: make(chan struct{}), got: map[string][]float64{}}, reject: 9}
+	pool := NewGraphIngestPool(g, WithIngestWorkers(1), WithIngestQueue(50), WithIngestBatch(4))
+	// The worker blocks on the first payload or two while the rest queue up.
+	for i := range 10 {
+		if err := pool.Submit(hostPayload([]string{"a", "b"}[i%2], float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(g.gate)
+	pool.Close()
+
+	// The batch holding 9 fails and its other payloads are retried alone.
+	if len(g.got["a"]) != 5 || len(g.got["b"]) != 4 {
+		t.Errorf("ingested %v, want all but 9", g.got)
+	}
+	if len(g.batches) < 2 {
+		t.Errorf("batches = %v, want the queued payloads combined", g.batches)
+	}
+	for _, n := range g.batches {
+		if n < 2 || n > 4 {
+			t.Errorf("batches = %v, want 2 to 4 payloads each", g.batches)
+		}
+	}
+	if s := pool.Stats(); s.Ingested != 9 || s.Failed != 1 || s.InFlight != 0 || s.Queued != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
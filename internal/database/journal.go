@@ -0,0 +1,285 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/output"
+)
+
+// DefaultJournalMaxBytes caps the journal so a long outage cannot fill the
+// disk the database lives on.
+const DefaultJournalMaxBytes = 64 << 20
+
+// ErrJournalFull is returned by Append once the journal reaches its cap.
+var ErrJournalFull = errors.New("journal full")
+
+// transientInsertErrors are fragments of DuckDB and OS errors that clear up
+// on their own: another process holding the file, a full or failing disk.
+var transientInsertErrors = []string{
+	"lock", "no space left", "disk full", "i/o error", "io error",
+	"read-only", "database is closed", "connection",
+}
+
+// IsTransient reports whether an insert failed for a reason retrying can
+// fix. Anything else, e.g. a constraint or conversion error, fails the
+// same way every time.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, frag := range transientInsertErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Journal buffers payloads that could not be persisted, one JSON object per
+// line, so they can be inserted once the database accepts writes again.
+// Each append is synced before it returns; a line torn by a crash is
+// skipped on replay. Payloads that fail permanently are moved to a
+// dead-letter file next to the journal so they cannot block the rest.
+type Journal struct {
+	path     string
+	maxBytes int64
+
+	transient func(error) bool
+
+	mu      sync.Mutex
+	pending int
+}
+
+// JournalOption configures a Journal.
+type JournalOption func(*Journal)
+
+// WithTransient replaces IsTransient as the test of whether a failed
+// replay should be retried later rather than dead-lettered, e.g. for a
+// journal replayed into a store other than DuckDB.
+func WithTransient(fn func(error) bool) JournalOption {
+	return func(j *Journal) {
+		j.transient = fn
+	}
+}
+
+// OpenJournal uses the file at path, creating its directory if needed.
+// Entries left by a previous run are kept for replay. maxBytes <= 0 means
+// DefaultJournalMaxBytes.
+func OpenJournal(path string, maxBytes int64, opts ...JournalOption) (*Journal, error) {
+	if path == "" {
+		return nil, errors.New("journal path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultJournalMaxBytes
+	}
+	j := &Journal{path: path, maxBytes: maxBytes, transient: IsTransient}
+	for _, opt := range opts {
+		opt(j)
+	}
+	lines, err := j.readLines()
+	if err != nil {
+		return nil, err
+	}
+	j.pending = len(lines)
+	return j, nil
+}
+
+// Path returns the journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// DeadLetterPath returns the file permanently failing payloads are moved to.
+func (j *Journal) DeadLetterPath() string {
+	return j.path + ".dead"
+}
+
+// Append adds p to the end of the journal.
+func (j *Journal) Append(p *output.PipelinePayload) error {
+	line, err := output.EncodePayload(p)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat journal: %w", err)
+	}
+	if info.Size()+int64(len(line)) > j.maxBytes {
+		return ErrJournalFull
+	}
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+	j.pending++
+	return nil
+}
+
+// Pending returns the number of journaled payloads awaiting replay. The
+// count is kept in memory, so it is cheap to call every cycle.
+func (j *Journal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.pending
+}
+
+// Replay hands each journaled payload to insert, oldest first, and returns
+// how many were inserted. A payload failing with a transient error stops
+// the replay and is kept, with the ones after it, for the next one; a
+// payload failing permanently is moved to the dead-letter file and the
+// replay goes on. The journal is only read when payloads are pending.
+func (j *Journal) Replay(ctx context.Context, insert func(context.Context, *output.PipelinePayload) error) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.pending == 0 {
+		return 0, nil
+	}
+	lines, err := j.readLines()
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, done := 0, 0
+	var insertErr error
+	for _, line := range lines {
+		if err := ctx.Err(); err != nil {
+			insertErr = err
+			break
+		}
+		if !json.Valid(line) {
+			// A partial line from a crash mid-append: nothing to recover.
+			done++
+			continue
+		}
+		// Decoded through the schema converters, as the journal may have
+		// been written by an older agent before an upgrade.
+		p, err := output.DecodePayload(line)
+		if err != nil {
+			if derr := j.appendDead(line); derr != nil {
+				insertErr = derr
+				break
+			}
+			fmt.Fprintf(os.Stderr, "Undecodable journaled snapshot moved to %s: %v\n", j.DeadLetterPath(), err)
+			done++
+			continue
+		}
+		if err := insert(ctx, p); err != nil {
+			if j.transient(err) {
+				insertErr = err
+				break
+			}
+			if derr := j.appendDead(line); derr != nil {
+				insertErr = derr
+				break
+			}
+			tracef(p.Raw.TraceID, "Journaled snapshot from %s failed permanently, moved to %s: %v\n",
+				p.Raw.CollectedAt.UTC().Format(time.RFC3339), j.DeadLetterPath(), err)
+		} else {
+			inserted++
+		}
+		done++
+	}
+	if err := j.rewrite(lines[done:]); err != nil {
+		return inserted, err
+	}
+	j.pending = len(lines) - done
+	return inserted, insertErr
+}
+
+// appendDead adds line to the dead-letter file.
+func (j *Journal) appendDead(line []byte) error {
+	f, err := os.OpenFile(j.DeadLetterPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open dead-letter file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("write dead-letter file: %w", err)
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("write dead-letter file: %w", err)
+	}
+	return f.Sync()
+}
+
+// readLines returns the non-empty lines of the journal. A missing file is
+// an empty journal.
+func (j *Journal) readLines() ([][]byte, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	var lines [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, int(j.maxBytes))
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+	return lines, sc.Err()
+}
+
+// rewrite replaces the journal with lines, atomically, or removes it when
+// nothing is left.
+func (j *Journal) rewrite(lines [][]byte) error {
+	if len(lines) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove journal: %w", err)
+		}
+		return nil
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("rewrite journal: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("rewrite journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("rewrite journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("rewrite journal: %w", err)
+	}
+	return os.Rename(tmp, j.path)
+}
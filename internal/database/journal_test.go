@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+func TestJournalReplayKeepsOrderAndRemainder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.journal")
+	j, err := OpenJournal(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cpu := range 3 {
+		if err := j.Append(idlePayload(float64(cpu))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A torn line left by a crash mid-append is skipped.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"Raw":{"CPUUs`)
+	f.Close()
+
+	var got []float64
+	locked := true
+	down := errors.New("database is locked")
+	insert := func(_ context.Context, p *output.PipelinePayload) error {
+		if locked && p.Raw.CPUUsagePct == 2 {
+			return down
+		}
+		got = append(got, p.Raw.CPUUsagePct)
+		return nil
+	}
+	n, err := j.Replay(context.Background(), insert)
+	if !errors.Is(err, down) || n != 2 {
+		t.Fatalf("Replay = %d, %v; want 2 inserted before the outage", n, err)
+	}
+	if pending := j.Pending(); pending != 2 {
+		t.Fatalf("pending = %d, want the failed payload and the torn line", pending)
+	}
+
+	locked = false
+	n, err = j.Replay(context.Background(), insert)
+	if err != nil || n != 1 {
+		t.Fatalf("Replay = %d, %v; want the failed payload replayed and the torn line dropped", n, err)
+	}
+	if len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("inserted %v, want 0, 1 then 2", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("an empty journal should be removed")
+	}
+}
+
+func TestJournalDeadLettersPermanentFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.journal")
+	j, err := OpenJournal(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cpu := range 3 {
+		if err := j.Append(idlePayload(float64(cpu))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An entry from an agent of an unsupported schema cannot be decoded.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"SchemaVersion":"99.0.0","Raw":{"CPUUsagePct":3}}` + "\n")
+	f.Close()
+	// Reopening counts the entries left behind without replaying them.
+	if j, err = OpenJournal(path, 0); err != nil || j.Pending() != 4 {
+		t.Fatalf("reopened journal has %d pending, err %v", j.Pending(), err)
+	}
+
+	var got []float64
+	insert := func(_ context.Context, p *output.PipelinePayload) error {
+		if p.Raw.CPUUsagePct == 1 {
+			return errors.New("Conversion Error: value out of range")
+		}
+		got = append(got, p.Raw.CPUUsagePct)
+		return nil
+	}
+	n, err := j.Replay(context.Background(), insert)
+	if err != nil || n != 2 {
+		t.Fatalf("Replay = %d, %v; want the bad payload skipped and the rest inserted", n, err)
+	}
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("inserted %v, want 0 then 2", got)
+	}
+	if j.Pending() != 0 {
+		t.Errorf("pending = %d, want 0", j.Pending())
+	}
+	dead, err := os.ReadFile(j.DeadLetterPath())
+	if err != nil || !strings.Contains(string(dead), `"CPUUsagePct":1`) || !strings.Contains(string(dead), `"99.0.0"`) {
+		t.Errorf("dead-letter file = %q, %v", dead, err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("IO Error: Could not set lock on file"), true},
+		{errors.New("write: no space left on device"), true},
+		{context.DeadlineExceeded, true},
+		{errors.New("Constraint Error: duplicate key"), false},
+		{nil, false},
+	} {
+		if got := IsTransient(tc.err); got != tc.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestJournalFull(t *testing.T) {
+	j, err := OpenJournal(filepath.Join(t.TempDir(), "j"), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Append(idlePayload(1)); !errors.Is(err, ErrJournalFull) {
+		t.Errorf("Append = %v, want ErrJournalFull", err)
+	}
+}
+
+type flakyRepo struct {
+	relational.StatsRepository
+	down     bool
+	inserted []time.Time
+}
+
+func (r *flakyRepo) InsertRawStats(_ context.Context, s relational.RawStatsFixed, _ relational.DerivedRates, _ relational.SnapshotFlags) (relational.InsertResult, error) {
+	if r.down {
+		return relational.InsertResult{}, errors.New("disk full")
+	}
+	r.inserted = append(r.inserted, s.CollectedAt)
+	return relational.InsertResult{SnapshotID: int64(len(r.inserted))}, nil
+}
+
+func TestPersistJournalsDuringOutage(t *testing.T) {
+	j, err := OpenJournal(filepath.Join(t.TempDir(), "j"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &flakyRepo{down: true}
+	w := &DataWorker{repo: repo}
+	WithJournal(j)(w)
+	ctx := context.Background()
+	at := func(min int) *output.PipelinePayload {
+		p := idlePayload(1)
+		p.Raw.CollectedAt = time.Date(2026, 1, 1, 0, min, 0, 0, time.UTC)
+		return p
+	}
+
+	for min := range 2 {
+		if _, err := w.persist(ctx, at(min)); err == nil {
+			t.Fatal("persist should report the outage")
+		}
+	}
+	repo.down = false
+	res, err := w.persist(ctx, at(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SnapshotID != 3 || len(repo.inserted) != 3 {
+		t.Fatalf("inserted %d snapshots, want the 2 journaled plus the new one", len(repo.inserted))
+	}
+	for i, ts := range repo.inserted {
+		if ts.Minute() != i {
+			t.Errorf("snapshot %d collected at minute %d; history is out of order", i, ts.Minute())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// LabelStore reads and writes host labels.
+type LabelStore interface {
+	HostLabels(ctx context.Context, agentID string) (map[string]string, error)
+	AllHostLabels(ctx context.Context) (map[string]map[string]string, error)
+	SetHostLabels(ctx context.Context, agentID string, labels map[string]string, replace bool) (map[string]string, error)
+}
+
+// LabelsHandler serves host labels. GET lists every host's labels, or one
+// host's with ?agent=ID; PUT replaces and PATCH merges the labels of ?agent=ID
+// from a JSON object, where an empty value removes a key.
+func LabelsHandler(s LabelStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := r.URL.Query().Get("agent")
+		var result any
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			if agent == "" {
+				result, err = s.AllHostLabels(r.Context())
+			} else {
+				result, err = s.HostLabels(r.Context(), agent)
+			}
+		case http.MethodPut, http.MethodPatch:
+			if agent == "" {
+				http.Error(w, "agent is required", http.StatusBadRequest)
+				return
+			}
+			var labels map[string]string
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&labels); err != nil {
+				http.Error(w, "body must be a JSON object of string labels: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if result, err = s.SetHostLabels(r.Context(), agent, labels, r.Method == http.MethodPut); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, PATCH")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
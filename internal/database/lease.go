@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// IngestLeaser grants one ingestion pipeline per host at a time, e.g. a
+// relational.Repo.
+type IngestLeaser interface {
+	AcquireIngestLease(ctx context.Context, agentID, holder string, ttl time.Duration) (relational.IngestLease, error)
+	ReleaseIngestLease(ctx context.Context, agentID, holder string) error
+}
+
+// LeaseKeeper holds the ingest lease on one host for this process, so an
+// agent and an MCP server collecting the same host into one database do
+// not both store snapshots. It is not safe for concurrent use.
+type LeaseKeeper struct {
+	leaser  IngestLeaser
+	agentID string
+	holder  string
+
+	held, known bool
+}
+
+// NewLeaseKeeper keeps the lease on agentID for holder (see
+// relational.LeaseHolder).
+func NewLeaseKeeper(l IngestLeaser, agentID, holder string) *LeaseKeeper {
+	return &LeaseKeeper{leaser: l, agentID: agentID, holder: holder}
+}
+
+// Hold takes or renews the lease for ttl and reports whether this process
+// may ingest. Changes of hands are logged once.
+func (k *LeaseKeeper) Hold(ctx context.Context, ttl time.Duration) (bool, error) {
+	lease, err := k.leaser.AcquireIngestLease(ctx, k.agentID, k.holder, ttl)
+	if err != nil {
+		return false, fmt.Errorf("acquire ingest lease: %w", err)
+	}
+	held := lease.Holder == k.holder
+	if !k.known || held != k.held {
+		if held {
+			fmt.Fprintf(os.Stderr, "Ingesting %s as %s\n", k.agentID, k.holder)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s is ingested by %s (lease until %s); skipping collection\n",
+				k.agentID, lease.Holder, lease.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+	k.held, k.known = held, true
+	return held, nil
+}
+
+// Release gives the lease up if this process holds it.
+func (k *LeaseKeeper) Release(ctx context.Context) error {
+	if !k.held {
+		return nil
+	}
+	k.held = false
+	return k.leaser.ReleaseIngestLease(ctx, k.agentID, k.holder)
+}
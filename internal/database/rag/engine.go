@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
 
 	"github.com/google/generative-ai-go/genai"
 )
@@ -47,16 +52,59 @@ var AvailableModels = map[string]ModelConfig{
 	},
 }
 
+// compareWindow is how far back host comparisons and percentiles added to
+// the context look.
+const compareWindow = 24 * time.Hour
+
+// HostComparer diffs two hosts' metrics and flags over a window.
+type HostComparer interface {
+	CompareHosts(ctx context.Context, hostA, hostB string, window time.Duration) (*relational.HostComparison, error)
+}
+
+// PercentileSource summarises the distribution of a host's metrics.
+type PercentileSource interface {
+	MetricPercentiles(ctx context.Context, hostname string, metrics []string, since time.Time) ([]relational.MetricPercentile, error)
+}
+
 // GraphRAGEngine handles retrieval augmented generation using graph structures.
 type GraphRAGEngine struct {
 	neo4jClient  graph.GraphClient
 	geminiClient *genai.Client
 	modelName    string
 	config       ModelConfig
+	comparer     HostComparer
+	percentiles  PercentileSource
+	language     string // answer language; empty for English
+}
+
+// Option configures a GraphRAGEngine.
+type Option func(*GraphRAGEngine)
+
+// WithHostComparer adds a structured comparison to the context of questions
+// that name exactly two known hosts, such as "why is node3 slower than node4".
+func WithHostComparer(c HostComparer) Option {
+	return func(e *GraphRAGEngine) { e.comparer = c }
+}
+
+// WithPercentiles adds the p50/p95/p99 of CPU, latency and IO metrics to
+// the context of questions that name exactly one known host, so answers can
+// speak to tail behaviour rather than averages alone.
+func WithPercentiles(p PercentileSource) Option {
+	return func(e *GraphRAGEngine) { e.percentiles = p }
+}
+
+// WithLanguage makes answers use the language of locale lang (see
+// i18n.Supported). Cypher generation is unaffected.
+func WithLanguage(lang string) Option {
+	return func(e *GraphRAGEngine) {
+		if i18n.Normalize(lang) != i18n.English {
+			e.language = i18n.LanguageName(lang)
+		}
+	}
 }
 
 // NewGraphRAGEngine constructs a new engine backed by the provided graph wrapper.
-func NewGraphRAGEngine(neo4j graph.GraphClient, gemini *genai.Client, modelKey string) *GraphRAGEngine {
+func NewGraphRAGEngine(neo4j graph.GraphClient, gemini *genai.Client, modelKey string, opts ...Option) *GraphRAGEngine {
 	if modelKey == "" {
 		modelKey = "pro" // Default to pro for best quality
 	}
@@ -67,12 +115,16 @@ func NewGraphRAGEngine(neo4j graph.GraphClient, gemini *genai.Client, modelKey s
 		config = AvailableModels["pro"]
 	}
 
-	return &GraphRAGEngine{
+	e := &GraphRAGEngine{
 		neo4jClient:  neo4j,
 		geminiClient: gemini,
 		modelName:    config.Name,
 		config:       config,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // getModel returns a configured GenerativeModel instance.
@@ -102,11 +154,18 @@ func (e *GraphRAGEngine) Query(ctx context.Context, question string) (string, er
 			OPTIONAL MATCH (s)-[:TRIGGERED]->(f:Flag)
 			OPTIONAL MATCH (s)-[:HAS_CAUSE]->(c:Cause)
 			OPTIONAL MATCH (s)-[:OBSERVED_CONTAINER]->(cont:Container)
+			OPTIONAL MATCH (s)-[:HAS_DEVIATION]->(d:Deviation)
+			OPTIONAL MATCH (s)-[ld:LARGEST_DIR]->(dir:Directory)
+			OPTIONAL MATCH (h)-[:RUNS]->(:Service)-[:DEPENDS_ON*1..3]->(:Service)<-[:RUNS]-(dep:Host)
 			WITH h, s, 
 				 collect(DISTINCT f.name) as flags,
 				 collect(DISTINCT {cause: c.primary_cause, explanation: c.explanation}) as causes,
-				 collect(DISTINCT {name: cont.name, running: cont.running}) as containers
+				 collect(DISTINCT {name: cont.name, running: cont.running}) as containers,
+				 collect(DISTINCT {metric: d.metric, slot: d.slot, actual: d.actual, expected_low: d.expected_low, expected_high: d.expected_high, ratio: d.ratio}) as deviations,
+				 collect(DISTINCT {path: dir.path, size_bytes: ld.size_bytes, rank: ld.rank}) as largest_dirs,
+				 collect(DISTINCT dep.hostname) as depends_on_hosts
 			RETURN h.hostname as host,
+				   h.labels as labels,
 				   s.cpu_usage_pct as cpu_pct,
 				   s.ram_usage_pct as ram_pct,
 				   s.disk_usage_pct as disk_pct,
@@ -114,7 +173,10 @@ func (e *GraphRAGEngine) Query(ctx context.Context, question string) (string, er
 				   s.collected_at as timestamp,
 				   flags,
 				   causes,
-				   containers
+				   containers,
+				   deviations,
+				   largest_dirs,
+				   depends_on_hosts
 			ORDER BY s.collected_at DESC
 			LIMIT 5
 		`
@@ -124,8 +186,13 @@ func (e *GraphRAGEngine) Query(ctx context.Context, question string) (string, er
 		}
 	}
 
-	// Step 3: Synthesize answer using Gemini with the graph context
-	answer, err := e.synthesizeAnswer(ctx, question, graphData)
+	// Step 3: Synthesize answer using Gemini with the graph context and,
+	// when one host is named, its percentiles or, when two are, their
+	// structured diff
+	hosts := e.mentionedKnownHosts(ctx, question)
+	comparison := e.compareHosts(ctx, hosts)
+	percentiles := e.hostPercentiles(ctx, hosts)
+	answer, err := e.synthesizeAnswer(ctx, question, graphData, comparison, percentiles)
 	if err != nil {
 		return "", fmt.Errorf("failed to synthesize answer: %w", err)
 	}
@@ -140,19 +207,29 @@ func (e *GraphRAGEngine) generateCypher(ctx context.Context, question string) (s
 	prompt := fmt.Sprintf(`You are a Neo4j Cypher query expert. Convert the following question into a Cypher query for a system monitoring graph database.
 
 Graph Schema:
-- Nodes: Host, Snapshot, Flag, Cause, DiskDevice, NetInterface, Container
+- Nodes: Host, Snapshot, Flag, Cause, DiskDevice, NetInterface, Container, Deviation, Process, File, OOMKill, Directory, User, Service, Check
 - Relationships: 
   - (Host)-[:HAS_SNAPSHOT]->(Snapshot)
   - (Snapshot)-[:TRIGGERED]->(Flag)
   - (Snapshot)-[:HAS_CAUSE]->(Cause)
-  - (Cause)-[:CAUSED_BY]->(DiskDevice|NetInterface|Container)
+  - (Cause)-[:CAUSED_BY]->(DiskDevice|NetInterface|Container|Process|File|Directory|User|Check)
   - (Snapshot)-[:OBSERVED_DISK_IO]->(DiskDevice)
   - (Snapshot)-[:OBSERVED_INTERFACE]->(NetInterface)
   - (Snapshot)-[:OBSERVED_CONTAINER]->(Container)
+  - (Snapshot)-[:HAS_DEVIATION]->(Deviation)
+  - (Snapshot)-[:HAS_EVENT]->(OOMKill)-[:KILLED]->(Process)
+  - (Snapshot)-[:LARGEST_DIR {rank, size_bytes}]->(Directory)
+  - (Host)-[:RUNS]->(Service)-[:DEPENDS_ON {kind}]->(Service)<-[:RUNS]-(Host)
 
+Host properties: agent_id, hostname, os, platform, kernel_version, environment (bare-metal|vm|wsl|cloud), hypervisor, cloud_provider, containerized, cgroup_mem_limit_bytes, cgroup_cpu_limit, labels (list of "key=value" strings, e.g. WHERE 'env=prod' IN h.labels) (containerized hosts also carry the :Containerized label; their CPU/RAM percentages are relative to the cgroup limits)
 Snapshot properties: snapshot_id, collected_at, cpu_usage_pct, ram_usage_pct, disk_usage_pct, severity_level, risk_score, primary_cause, explanation
 Flag properties: name (e.g., "cpu_overloaded", "memory_pressure", "disk_space_critical")
 Cause properties: primary_cause, entity_type, entity_key, explanation
+Deviation properties: metric, slot (e.g. "Tuesday 14:00"), actual, expected_mean, expected_low, expected_high, ratio (actual vs usual level for that hour of week)
+OOMKill properties: occurred_at, pid, cgroup, uid, anon_rss_bytes, constraint
+Process properties: name; File properties: path; Directory properties: path, host_id; User properties: name
+Check properties: name (a user-defined probe that failed; flag "check_failed")
+Service properties: host, name. Services and their DEPENDS_ON links are declared by the operator. To explain a symptom on one host, follow DEPENDS_ON (possibly several hops) to the hosts running its dependencies and check their latest snapshots, e.g. MATCH (:Host {hostname: 'api-1'})-[:RUNS]->(:Service)-[:DEPENDS_ON*1..3]->(:Service)<-[:RUNS]-(dep:Host)-[:HAS_SNAPSHOT]->(s:Snapshot)-[:TRIGGERED]->(f:Flag)
 
 Question: %s
 
@@ -175,7 +252,7 @@ Return ONLY the Cypher query, no explanation. Limit results to 10.`, question)
 }
 
 // synthesizeAnswer uses Gemini to generate a natural language answer from graph data.
-func (e *GraphRAGEngine) synthesizeAnswer(ctx context.Context, question string, graphData []map[string]any) (string, error) {
+func (e *GraphRAGEngine) synthesizeAnswer(ctx context.Context, question string, graphData []map[string]any, comparison *relational.HostComparison, percentiles []relational.MetricPercentile) (string, error) {
 	model := e.getModel()
 
 	// Convert graph data to JSON for context
@@ -183,6 +260,27 @@ func (e *GraphRAGEngine) synthesizeAnswer(ctx context.Context, question string,
 	if err != nil {
 		return "", err
 	}
+	var comparisonText string
+	if comparison != nil {
+		b, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		comparisonText = fmt.Sprintf(`
+Host Comparison (from DuckDB; metrics sorted by relative difference, host_a first):
+%s
+`, b)
+	}
+	if len(percentiles) > 0 {
+		b, err := json.MarshalIndent(percentiles, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		comparisonText += fmt.Sprintf(`
+Metric Percentiles over the last %s (from DuckDB; judge tails by p95/p99, not the average):
+%s
+`, compareWindow, b)
+	}
 
 	prompt := fmt.Sprintf(`You are a system monitoring expert. Answer the following question based on the graph database results.
 
@@ -190,14 +288,17 @@ Question: %s
 
 Graph Data (from Neo4j):
 %s
-
+%s
 Provide a clear, concise answer explaining:
 1. What the data shows
 2. Root causes if applicable
 3. Severity and impact
 4. Recommended actions if relevant
 
-If the graph data is empty or insufficient, say so clearly.`, question, string(graphJSON))
+If the graph data is empty or insufficient, say so clearly.`, question, string(graphJSON), comparisonText)
+	if e.language != "" {
+		prompt += fmt.Sprintf("\n\nWrite the answer in %s. Keep metric names, flag names, hostnames and paths unchanged.", e.language)
+	}
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
@@ -212,6 +313,157 @@ If the graph data is empty or insufficient, say so clearly.`, question, string(g
 	return answer, nil
 }
 
+// WriteDigest writes a short "your system at a glance" digest from facts,
+// the JSON of a digest.Facts: per-host hourly rollups and incidents.
+func (e *GraphRAGEngine) WriteDigest(ctx context.Context, facts string) (string, error) {
+	model := e.getModel()
+
+	prompt := fmt.Sprintf(`You are a system monitoring expert writing a periodic health digest for busy operators: "your system at a glance".
+
+Facts (from DuckDB hourly rollups; metrics hold the average and peak of each host's snapshots, incidents are runs of consecutive hours with a flag raised, severity is 0 (ok) to 3 (critical)):
+%s
+
+Write Markdown of at most 250 words:
+1. One sentence on overall health across the period
+2. A short bullet per host that needs attention: what happened, how often and when it was last seen
+3. Trends or peaks worth watching, with numbers
+4. At most three concrete recommendations
+
+Do not restate every number and do not invent facts. If all hosts were healthy, say so briefly.`, facts)
+	if e.language != "" {
+		prompt += fmt.Sprintf("\n\nWrite the digest in %s. Keep metric names, flag names and hostnames unchanged.", e.language)
+	}
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])), nil
+}
+
+// ParseThresholdEdits turns an instruction such as "warn me when disk
+// hits 85%" into JSON of the form {"edits": [{"metric", "warning",
+// "critical"}]}, constrained by a response schema. names are the
+// thresholds that can be changed and current the JSON of their levels in
+// effect. The result still needs validating, e.g. by
+// config.ParseThresholdEdits.
+func (e *GraphRAGEngine) ParseThresholdEdits(ctx context.Context, instruction string, names []string, current string) (string, error) {
+	model := e.getModel()
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"edits": {
+				Type: genai.TypeArray,
+				Items: &genai.Schema{
+					Type: genai.TypeObject,
+					Properties: map[string]*genai.Schema{
+						"metric":   {Type: genai.TypeString, Enum: names},
+						"warning":  {Type: genai.TypeNumber, Description: "new warning level; omit to keep the current one"},
+						"critical": {Type: genai.TypeNumber, Description: "new critical level; omit to keep the current one"},
+					},
+					Required: []string{"metric"},
+				},
+			},
+		},
+		Required: []string{"edits"},
+	}
+
+	prompt := fmt.Sprintf(`You translate requests to change the alerting thresholds of a system monitor into threshold edits.
+
+Thresholds and their current levels (cpu, ram, disk, inode and user_share are percentages, container is a container's CPU or memory percentage, net is latency in milliseconds, loss is packet loss in percent, active_tcp is a count of connections):
+%s
+
+Request: %s
+
+Return one edit per threshold the request changes. "Warn" or "alert" sets the warning level, "critical", "page" or "urgent" the critical level. Only include the levels the request names. Return {"edits": []} if the request does not ask to change a threshold.`, current, instruction)
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])), nil
+}
+
+// mentionedKnownHosts returns the graph's hosts named in question, or nil
+// when nothing would use them or the graph query fails.
+func (e *GraphRAGEngine) mentionedKnownHosts(ctx context.Context, question string) []string {
+	if e.comparer == nil && e.percentiles == nil {
+		return nil
+	}
+	rows, err := e.neo4jClient.ExecuteCypher(ctx, "MATCH (h:Host) WHERE h.hostname IS NOT NULL RETURN DISTINCT h.hostname AS hostname")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, row := range rows {
+		if name, ok := row["hostname"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return mentionedHosts(question, names)
+}
+
+// compareHosts returns the comparison of hosts, or nil when there is no
+// comparer, there are not exactly two hosts, or the comparison fails.
+func (e *GraphRAGEngine) compareHosts(ctx context.Context, hosts []string) *relational.HostComparison {
+	if e.comparer == nil || len(hosts) != 2 {
+		return nil
+	}
+	comparison, err := e.comparer.CompareHosts(ctx, hosts[0], hosts[1], compareWindow)
+	if err != nil {
+		return nil
+	}
+	return comparison
+}
+
+// hostPercentiles returns the sampled metric percentiles of the only host
+// in hosts, or nil when there is no source, not exactly one host, or the
+// query fails.
+func (e *GraphRAGEngine) hostPercentiles(ctx context.Context, hosts []string) []relational.MetricPercentile {
+	if e.percentiles == nil || len(hosts) != 1 {
+		return nil
+	}
+	ps, err := e.percentiles.MetricPercentiles(ctx, hosts[0], nil, time.Now().Add(-compareWindow))
+	if err != nil {
+		return nil
+	}
+	var sampled []relational.MetricPercentile
+	for _, p := range ps {
+		if p.Samples > 0 {
+			sampled = append(sampled, p)
+		}
+	}
+	return sampled
+}
+
+// mentionedHosts returns the names that appear as whole words in question,
+// in order of first appearance.
+func mentionedHosts(question string, names []string) []string {
+	pos := map[string]int{}
+	for _, name := range names {
+		re, err := regexp.Compile(`(?i)(^|[^\w.-])` + regexp.QuoteMeta(name) + `($|[^\w-])`)
+		if err != nil {
+			continue
+		}
+		if loc := re.FindStringIndex(question); loc != nil {
+			pos[name] = loc[0]
+		}
+	}
+	found := make([]string, 0, len(pos))
+	for name := range pos {
+		found = append(found, name)
+	}
+	sort.Slice(found, func(i, j int) bool { return pos[found[i]] < pos[found[j]] })
+	return found
+}
+
 // cleanCypherQuery removes markdown code blocks from Cypher queries.
 func cleanCypherQuery(query string) string {
 	// Remove ```cypher and ``` markers
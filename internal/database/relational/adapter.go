@@ -23,7 +23,7 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 			Device:      p.Device,
 			Fstype:      p.Fstype,
 			UsedPercent: p.UsedPercent,
-			TotalBytes:  p.TotalGB * 1024 * 1024 * 1024, // GB to Bytes
+			TotalBytes:  p.TotalBytes,
 			InodeUsage:  p.InodeUsage,
 			TotalInodes: p.TotalInodes,
 		})
@@ -50,6 +50,7 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 			Device:  h.Device,
 			Status:  h.Status,
 			Message: h.Message,
+			Raw:     h.Raw,
 		})
 	}
 
@@ -66,6 +67,12 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 			ErrOut:      ni.ErrOut,
 			DropIn:      ni.DropIn,
 			DropOut:     ni.DropOut,
+
+			OperState:      ni.OperState,
+			SpeedMbps:      ni.SpeedMbps,
+			MaxSpeedMbps:   ni.MaxSpeedMbps,
+			Duplex:         ni.Duplex,
+			CarrierChanges: ni.CarrierChanges,
 		})
 	}
 
@@ -103,11 +110,34 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 			Name:   p.Name,
 			CPUPct: p.CPU,
 			MemPct: p.Memory,
+
+			Cmdline:     p.Cmdline,
+			Cgroup:      p.Cgroup,
+			ContainerID: p.ContainerID,
+		})
+	}
+
+	// Convert per-user totals
+	var users []UserUsageFixed
+	for _, u := range cs.UserUsage {
+		users = append(users, UserUsageFixed{
+			UID:       u.UID,
+			User:      u.User,
+			Processes: u.Processes,
+			CPUPct:    u.CPU,
+			MemPct:    u.Memory,
+			RSSBytes:  u.RSSBytes,
 		})
 	}
 
+	// Convert GPUs
+	var gpus []GPUStatFixed
+	for _, g := range cs.GPUs {
+		gpus = append(gpus, GPUStatFixed(g))
+	}
+
 	return RawStatsFixed{
-		CollectedAt: now,
+		CollectedAt: now.UTC(),
 		Kind:        kind,
 		AgentID:     agentID,
 		MachineID:   machineID,
@@ -123,19 +153,20 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 		CPUCoresLogical: cs.CPUCores,
 
 		RAMUsagePct:       cs.RAMUsage,
-		RAMTotalBytes:     cs.TotalRAM_GB * 1024 * 1024 * 1024,
-		RAMAvailableBytes: cs.RAMAvailable * 1024 * 1024 * 1024,
-		RAMUsedBytes:      cs.RAMUsed * 1024 * 1024 * 1024,
-		RAMFreeBytes:      cs.RAMFree * 1024 * 1024 * 1024,
-		RAMCachedBytes:    cs.RAMCached * 1024 * 1024 * 1024,
-		RAMBufferedBytes:  cs.RAMBuffered * 1024 * 1024 * 1024,
+		RAMTotalBytes:     cs.RAMTotalBytes,
+		RAMAvailableBytes: cs.RAMAvailableBytes,
+		RAMUsedBytes:      cs.RAMUsedBytes,
+		RAMFreeBytes:      cs.RAMFreeBytes,
+		RAMCachedBytes:    cs.RAMCachedBytes,
+		RAMBufferedBytes:  cs.RAMBufferedBytes,
 
 		SwapUsagePct:   cs.SwapUsage,
-		SwapTotalBytes: cs.SwapTotal * 1024 * 1024 * 1024,
-		SwapUsedBytes:  cs.SwapUsed * 1024 * 1024 * 1024,
+		SwapTotalBytes: cs.SwapTotalBytes,
+		SwapUsedBytes:  cs.SwapUsedBytes,
+		SwapInBytes:    cs.SwapInBytes,
 
 		DiskUsagePct:   cs.DiskUsage,
-		DiskTotalBytes: cs.TotalDisk_GB * 1024 * 1024 * 1024,
+		DiskTotalBytes: cs.DiskTotalBytes,
 		InodeUsagePct:  cs.InodeUsage,
 		InodeTotal:     cs.TotalInodes,
 
@@ -146,6 +177,11 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 		NetLatencyMS:  cs.NetLatency_ms,
 		IsConnected:   cs.IsConnected,
 		ActiveTCP:     cs.ActiveTCP,
+		NetJitterMS:   cs.NetJitter_ms,
+		NetLossPct:    cs.NetLoss_pct,
+		NetProbes:     cs.NetProbes,
+		VPNInterface:  cs.VPNInterface,
+		NetDirectMS:   cs.NetDirect_ms,
 		NetInterfaces: netInterfaces,
 
 		DockerAvailable:  cs.DockerAvailable,
@@ -157,8 +193,14 @@ func ToRawStatsFixed(cs *collector.RawStats, kind SnapshotKind, agentID, machine
 		UptimeSeconds: cs.Uptime,
 		Procs:         cs.Procs,
 
+		Containerized:       cs.Containerized,
+		CgroupMemLimitBytes: cs.CgroupMemLimitBytes,
+		CgroupCPULimit:      cs.CgroupCPULimit,
+
 		Temperatures: temps,
 		TopProcesses: procs,
+		UserUsage:    users,
+		GPUs:         gpus,
 	}
 }
 
@@ -167,9 +209,14 @@ func MergeStats(fast, slow *collector.RawStats, agentID, machineID, bootID strin
 	merged := ToRawStatsFixed(fast, KindMerged, agentID, machineID, bootID)
 
 	if slow != nil {
-		// Overlay slow metrics
-		merged.NetLatencyMS = slow.NetLatency_ms
-		merged.IsConnected = slow.IsConnected
+		// Overlay slow metrics; the background prober's rolling figures
+		// beat the slow path's single probe.
+		if merged.NetProbes == 0 {
+			merged.NetLatencyMS = slow.NetLatency_ms
+			merged.IsConnected = slow.IsConnected
+		}
+		merged.VPNInterface = slow.VPNInterface
+		merged.NetDirectMS = slow.NetDirect_ms
 		merged.ActiveTCP = slow.ActiveTCP
 
 		merged.DiskHealth = make([]DiskHealthInfoFixed, 0, len(slow.DiskHealth))
@@ -178,6 +225,7 @@ func MergeStats(fast, slow *collector.RawStats, agentID, machineID, bootID strin
 				Device:  h.Device,
 				Status:  h.Status,
 				Message: h.Message,
+				Raw:     h.Raw,
 			})
 		}
 
@@ -188,6 +236,22 @@ func MergeStats(fast, slow *collector.RawStats, agentID, machineID, bootID strin
 		merged.UptimeSeconds = slow.Uptime
 		merged.Procs = slow.Procs
 
+		merged.Environment = slow.Environment
+		merged.Hypervisor = slow.Hypervisor
+		merged.CloudProvider = slow.CloudProvider
+		merged.LatencyNAT = slow.LatencyNAT
+
+		merged.CPUEClusterPct = slow.CPUEClusterPct
+		merged.CPUPClusterPct = slow.CPUPClusterPct
+		merged.PowerCPUWatts = slow.PowerCPUWatts
+		merged.PowerGPUWatts = slow.PowerGPUWatts
+		merged.PowerANEWatts = slow.PowerANEWatts
+		merged.PowerTotalWatts = slow.PowerTotalWatts
+		merged.ThermalPressure = slow.ThermalPressure
+
+		merged.ThrottledBits = slow.ThrottledBits
+		merged.CoreVolts = slow.CoreVolts
+
 		merged.Temperatures = make([]TemperatureStatFixed, 0, len(slow.Temperatures))
 		for _, t := range slow.Temperatures {
 			merged.Temperatures = append(merged.Temperatures, TemperatureStatFixed{
@@ -195,6 +259,40 @@ func MergeStats(fast, slow *collector.RawStats, agentID, machineID, bootID strin
 				TemperatureC: t.Temperature,
 			})
 		}
+
+		for _, k := range slow.OOMKills {
+			merged.OOMKills = append(merged.OOMKills, OOMKillFixed{
+				At:           k.At,
+				PID:          k.PID,
+				Process:      k.Process,
+				Cgroup:       k.Cgroup,
+				UID:          k.UID,
+				AnonRSSBytes: k.AnonRSSBytes,
+				Constraint:   k.Constraint,
+			})
+		}
+
+		for _, g := range slow.LogGrowers {
+			merged.LogGrowers = append(merged.LogGrowers, FileGrowthFixed{
+				Path:      g.Path,
+				SizeBytes: g.SizeBytes,
+				GrowthBps: g.GrowthBps,
+			})
+		}
+
+		for _, u := range slow.TempUsage {
+			merged.TempUsage = append(merged.TempUsage, TempUsageFixed{
+				Path:       u.Path,
+				Kind:       u.Kind,
+				Files:      u.Files,
+				TotalBytes: u.TotalBytes,
+				StaleBytes: u.StaleBytes,
+			})
+		}
+
+		for _, c := range slow.Checks {
+			merged.Checks = append(merged.Checks, CheckResultFixed(c))
+		}
 	}
 
 	return merged
@@ -0,0 +1,42 @@
+package relational
+
+import (
+	"context"
+	"testing"
+
+	"syschecker/internal/collector"
+)
+
+// A 512 MiB machine used to report 0 GB total and available memory.
+func TestToRawStatsFixedKeepsBytes(t *testing.T) {
+	cs := &collector.RawStats{
+		Hostname:          "pi-1",
+		RAMUsage:          41.4,
+		RAMTotalBytes:     512 << 20,
+		RAMAvailableBytes: 300<<20 + 123,
+		RAMUsedBytes:      212 << 20,
+		SwapTotalBytes:    100 << 20,
+		DiskTotalBytes:    7_948_206_080,
+		Partitions:        []collector.PartitionUsage{{Mountpoint: "/", TotalBytes: 7_948_206_080}},
+	}
+	s := ToRawStatsFixed(cs, KindFast, "a1", "", "")
+	if s.RAMTotalBytes != 512<<20 || s.RAMAvailableBytes != 300<<20+123 || s.SwapTotalBytes != 100<<20 {
+		t.Errorf("memory = %d total, %d available, %d swap", s.RAMTotalBytes, s.RAMAvailableBytes, s.SwapTotalBytes)
+	}
+	if s.DiskTotalBytes != 7_948_206_080 || s.Partitions[0].TotalBytes != 7_948_206_080 {
+		t.Errorf("disk = %d, partition = %d", s.DiskTotalBytes, s.Partitions[0].TotalBytes)
+	}
+
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewSnapshotProvider(repo, "pi-1").GetFastMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RAMTotalBytes != cs.RAMTotalBytes || got.RAMAvailableBytes != cs.RAMAvailableBytes || got.DiskTotalBytes != cs.DiskTotalBytes {
+		t.Errorf("replayed = %d total, %d available, %d disk", got.RAMTotalBytes, got.RAMAvailableBytes, got.DiskTotalBytes)
+	}
+}
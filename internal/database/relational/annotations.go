@@ -0,0 +1,114 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Annotation kinds.
+const (
+	AnnotationBookmark = "bookmark" // a snapshot the user marked for later
+)
+
+// Annotation is a user note attached to a snapshot.
+type Annotation struct {
+	ID          int64     `json:"annotation_id"`
+	SnapshotID  int64     `json:"snapshot_id"`
+	Hostname    string    `json:"hostname"`
+	CollectedAt time.Time `json:"collected_at"` // when the annotated snapshot was taken
+	Kind        string    `json:"kind"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AddAnnotation attaches a note to an existing snapshot and returns its ID.
+func (r *Repo) AddAnnotation(ctx context.Context, snapshotID int64, kind, note string) (int64, error) {
+	if kind == "" {
+		return 0, fmt.Errorf("annotation kind is required")
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT true FROM snapshots WHERE snapshot_id = ?`, snapshotID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("snapshot %d not found", snapshotID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query snapshot failed: %w", err)
+	}
+
+	id := r.ids.NextID()
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO annotations(annotation_id, snapshot_id, kind, note, created_at)
+		VALUES (?,?,?,?,?)
+	`, id, snapshotID, kind, nullStr(note), r.clock.Now())
+	if err != nil {
+		return 0, fmt.Errorf("insert annotation failed: %w", err)
+	}
+	return id, nil
+}
+
+// BookmarkLatest bookmarks the most recent snapshot of hostname, or of any
+// host when empty, and returns the bookmark.
+func (r *Repo) BookmarkLatest(ctx context.Context, hostname, note string) (*Annotation, error) {
+	latest, err := r.GetLatestSnapshot(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	id, err := r.AddAnnotation(ctx, latest.SnapshotID, AnnotationBookmark, note)
+	if err != nil {
+		return nil, err
+	}
+	return &Annotation{
+		ID:          id,
+		SnapshotID:  latest.SnapshotID,
+		Hostname:    latest.Hostname,
+		CollectedAt: latest.CollectedAt,
+		Kind:        AnnotationBookmark,
+		Note:        note,
+		CreatedAt:   r.clock.Now(),
+	}, nil
+}
+
+// ListAnnotations returns annotations, newest snapshot first, optionally
+// filtered by host and kind and limited to snapshots taken since since.
+func (r *Repo) ListAnnotations(ctx context.Context, hostname, kind string, since time.Time, limit int) ([]Annotation, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `
+		SELECT a.annotation_id, a.snapshot_id, COALESCE(h.hostname, 'unknown'), s.collected_at,
+		  a.kind, COALESCE(a.note, ''), a.created_at
+		FROM annotations a
+		JOIN snapshots s ON s.snapshot_id = a.snapshot_id
+		LEFT JOIN hosts h ON h.host_id = s.host_id
+		WHERE s.collected_at >= ?`
+	args := []any{since}
+	if hostname != "" {
+		query += ` AND h.hostname = ?`
+		args = append(args, hostname)
+	}
+	if kind != "" {
+		query += ` AND a.kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY s.collected_at DESC, a.created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query annotations failed: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Annotation{}
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.ID, &a.SnapshotID, &a.Hostname, &a.CollectedAt, &a.Kind, &a.Note, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan annotation failed: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,53 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, agent := range []string{"web-1", "web-1", "db-1"} {
+		stats := RawStatsFixed{AgentID: agent, Hostname: agent, CollectedAt: start.Add(time.Duration(i) * time.Minute)}
+		if _, err := repo.InsertRawStats(ctx, stats, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := repo.BookmarkLatest(ctx, "web-1", "before deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Hostname != "web-1" || !b.CollectedAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("bookmark = %+v", b)
+	}
+	if _, err := repo.AddAnnotation(ctx, b.SnapshotID, AnnotationBookmark, "spike"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AddAnnotation(ctx, 42, AnnotationBookmark, ""); err == nil {
+		t.Error("annotating a missing snapshot succeeded")
+	}
+
+	list, err := repo.ListAnnotations(ctx, "web-1", AnnotationBookmark, start, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Note != "spike" || list[1].Note != "before deploy" {
+		t.Errorf("bookmarks = %+v", list)
+	}
+	if other, _ := repo.ListAnnotations(ctx, "db-1", "", start, 10); len(other) != 0 {
+		t.Errorf("db-1 annotations = %+v", other)
+	}
+
+	history, err := repo.QuerySnapshots(ctx, "web-1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Bookmark != "before deploy; spike" || history[1].Bookmark != "" {
+		t.Errorf("history bookmarks = %q, %q", history[0].Bookmark, history[1].Bookmark)
+	}
+}
@@ -0,0 +1,248 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Artifact kinds.
+const (
+	ArtifactForensics = "forensics"
+	ArtifactSMART     = "smart"  // raw smartctl output for a failing disk
+	ArtifactReport    = "report" // rendered report files
+	ArtifactDigest    = "digest" // periodic written health digests
+	ArtifactGraph     = "graph"  // exported subgraph viewers
+)
+
+// ArtifactLimits caps artifact storage. Payloads above InlineMaxBytes are
+// written to Dir and only their path is kept in DuckDB.
+type ArtifactLimits struct {
+	InlineMaxBytes int           // largest payload stored in the table (default: 256 KiB)
+	MaxBytes       int           // payloads above this are rejected (default: 16 MiB)
+	Retention      time.Duration // artifacts older than this are pruned (default: 30 days)
+	Dir            string        // directory for large payloads (default: "artifacts")
+}
+
+// DefaultArtifactLimits returns the limits used when none are configured.
+func DefaultArtifactLimits() ArtifactLimits {
+	return ArtifactLimits{
+		InlineMaxBytes: 256 << 10,
+		MaxBytes:       16 << 20,
+		Retention:      30 * 24 * time.Hour,
+		Dir:            "artifacts",
+	}
+}
+
+// artifactPruneInterval bounds how often inserts trigger a retention sweep.
+const artifactPruneInterval = time.Hour
+
+// Artifact is a large payload, such as a forensic capture, stored outside
+// the snapshot row and linked to it.
+type Artifact struct {
+	ID         int64     `json:"artifact_id"`
+	SnapshotID int64     `json:"snapshot_id,omitempty"` // 0 when not linked to a snapshot
+	Kind       string    `json:"kind"`
+	MIME       string    `json:"mime"`
+	Name       string    `json:"name,omitempty"` // e.g. device or file name
+	SizeBytes  uint64    `json:"size_bytes"`
+	Path       string    `json:"path,omitempty"` // set when the payload lives on disk
+	Data       []byte    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// InsertArtifact stores an artifact and returns its ID. Payloads over the
+// inline limit are written to the artifact directory.
+func (r *Repo) InsertArtifact(ctx context.Context, a Artifact) (int64, error) {
+	lim := r.artifactLimits
+	if len(a.Data) > lim.MaxBytes {
+		return 0, fmt.Errorf("artifact of %d bytes exceeds the %d byte cap", len(a.Data), lim.MaxBytes)
+	}
+	id := r.ids.NextID()
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = r.clock.Now()
+	}
+
+	data, path := a.Data, ""
+	if len(a.Data) > lim.InlineMaxBytes {
+		if err := os.MkdirAll(lim.Dir, 0o750); err != nil {
+			return 0, fmt.Errorf("create artifact dir: %w", err)
+		}
+		path = filepath.Join(lim.Dir, strconv.FormatInt(id, 10))
+		if err := os.WriteFile(path, a.Data, 0o640); err != nil {
+			return 0, fmt.Errorf("write artifact: %w", err)
+		}
+		data = nil
+	}
+
+	snapshotID := sql.NullInt64{Int64: a.SnapshotID, Valid: a.SnapshotID != 0}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO artifacts(artifact_id, snapshot_id, kind, mime, name, size_bytes, data, path, created_at)
+		VALUES (?,?,?,?,?,?,?,?,?)
+	`, id, snapshotID, a.Kind, a.MIME, nullStr(a.Name), uint64(len(a.Data)), data, nullStr(path), a.CreatedAt)
+	if err != nil {
+		if path != "" {
+			_ = os.Remove(path)
+		}
+		return 0, fmt.Errorf("insert artifact failed: %w", err)
+	}
+
+	r.maybePruneArtifacts(ctx)
+	return id, nil
+}
+
+// GetArtifact returns an artifact with its data.
+func (r *Repo) GetArtifact(ctx context.Context, id int64) (*Artifact, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+artifactColumns+`, data FROM artifacts WHERE artifact_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query artifact failed: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query artifact failed: %w", err)
+		}
+		return nil, fmt.Errorf("artifact %d not found", id)
+	}
+	a, err := scanArtifact(rows, true)
+	if err != nil {
+		return nil, err
+	}
+	if a.Path != "" {
+		if a.Data, err = os.ReadFile(a.Path); err != nil {
+			return nil, fmt.Errorf("read artifact: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// ListArtifacts returns artifact metadata, newest first, optionally filtered
+// by snapshot and kind.
+func (r *Repo) ListArtifacts(ctx context.Context, snapshotID int64, kind string, limit int) ([]Artifact, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT ` + artifactColumns + ` FROM artifacts WHERE 1=1`
+	var args []any
+	if snapshotID != 0 {
+		query += ` AND snapshot_id = ?`
+		args = append(args, snapshotID)
+	}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query artifacts failed: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Artifact{}
+	for rows.Next() {
+		a, err := scanArtifact(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// DeleteArtifact removes an artifact and its file, if any.
+func (r *Repo) DeleteArtifact(ctx context.Context, id int64) error {
+	var path sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT path FROM artifacts WHERE artifact_id = ?`, id).Scan(&path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("query artifact failed: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM artifacts WHERE artifact_id = ?`, id); err != nil {
+		return fmt.Errorf("delete artifact failed: %w", err)
+	}
+	if path.String != "" {
+		if err := os.Remove(path.String); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove artifact file: %w", err)
+		}
+	}
+	return nil
+}
+
+// PruneArtifacts deletes artifacts older than the retention period and
+// returns how many were removed.
+func (r *Repo) PruneArtifacts(ctx context.Context) (int, error) {
+	if r.artifactLimits.Retention <= 0 {
+		return 0, nil
+	}
+	cutoff := r.clock.Now().Add(-r.artifactLimits.Retention)
+	rows, err := r.db.QueryContext(ctx, `SELECT artifact_id FROM artifacts WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("query expired artifacts failed: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan artifact failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		if err := r.DeleteArtifact(ctx, id); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// maybePruneArtifacts runs a retention sweep at most once per artifactPruneInterval.
+func (r *Repo) maybePruneArtifacts(ctx context.Context) {
+	now := r.clock.Now()
+	r.mu.Lock()
+	due := now.Sub(r.lastArtifactPrune) >= artifactPruneInterval
+	if due {
+		r.lastArtifactPrune = now
+	}
+	r.mu.Unlock()
+	if due {
+		if _, err := r.PruneArtifacts(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Artifact retention failed: %v\n", err)
+		}
+	}
+}
+
+const artifactColumns = `artifact_id, snapshot_id, kind, mime, name, size_bytes, path, created_at`
+
+// scanArtifact scans artifactColumns, followed by data when withData is set.
+func scanArtifact(rows *sql.Rows, withData bool) (*Artifact, error) {
+	var a Artifact
+	var snapshotID sql.NullInt64
+	var name, path sql.NullString
+	dest := []any{&a.ID, &snapshotID, &a.Kind, &a.MIME, &name, &a.SizeBytes, &path, &a.CreatedAt}
+	if withData {
+		dest = append(dest, &a.Data)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("scan artifact failed: %w", err)
+	}
+	a.SnapshotID = snapshotID.Int64
+	a.Name = name.String
+	a.Path = path.String
+	return &a, nil
+}
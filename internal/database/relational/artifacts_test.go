@@ -0,0 +1,70 @@
+package relational
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestArtifactLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewDuckDBClient("")
+	if err != nil {
+		t.Fatalf("failed to create duckdb client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	repo := NewRepo(client.DB(), WithClock(clk), WithArtifactLimits(ArtifactLimits{
+		InlineMaxBytes: 8,
+		MaxBytes:       64,
+		Retention:      24 * time.Hour,
+		Dir:            dir,
+	}))
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate schema: %v", err)
+	}
+
+	small, err := repo.InsertArtifact(ctx, Artifact{SnapshotID: 7, Kind: ArtifactSMART, MIME: "text/plain", Name: "/dev/sda", Data: []byte("FAILED")})
+	if err != nil {
+		t.Fatalf("insert small: %v", err)
+	}
+	large := bytes.Repeat([]byte("x"), 32)
+	big, err := repo.InsertArtifact(ctx, Artifact{Kind: ArtifactForensics, MIME: "application/json", Data: large})
+	if err != nil {
+		t.Fatalf("insert large: %v", err)
+	}
+	if _, err := repo.InsertArtifact(ctx, Artifact{Kind: ArtifactReport, MIME: "text/html", Data: make([]byte, 65)}); err == nil {
+		t.Error("expected the size cap to reject a 65 byte artifact")
+	}
+
+	got, err := repo.GetArtifact(ctx, big)
+	if err != nil {
+		t.Fatalf("get large: %v", err)
+	}
+	if got.Path == "" || !bytes.Equal(got.Data, large) {
+		t.Errorf("large artifact should spill to disk and read back, got path %q, %d bytes", got.Path, len(got.Data))
+	}
+
+	list, err := repo.ListArtifacts(ctx, 7, "", 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != small || list[0].Name != "/dev/sda" {
+		t.Errorf("list by snapshot = %+v", list)
+	}
+
+	clk.Advance(25 * time.Hour)
+	n, err := repo.PruneArtifacts(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("prune = %d, %v; want 2", n, err)
+	}
+	if _, err := os.Stat(got.Path); !os.IsNotExist(err) {
+		t.Errorf("pruned artifact file still exists: %v", err)
+	}
+}
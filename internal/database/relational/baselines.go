@@ -0,0 +1,148 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BaselineMetrics lists the snapshot columns that support learned thresholds.
+var BaselineMetrics = []string{
+	"cpu_usage_pct",
+	"ram_usage_pct",
+	"disk_usage_pct",
+	"inode_usage_pct",
+	"net_latency_ms",
+}
+
+// MetricBaseline summarizes the typical range of a metric for a host.
+type MetricBaseline struct {
+	Metric  string  `json:"metric"`
+	Samples int64   `json:"samples"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stddev"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+}
+
+// HostThreshold is a per-host threshold derived from a learned baseline.
+type HostThreshold struct {
+	AgentID   string    `json:"agent_id"`
+	Metric    string    `json:"metric"`
+	Warning   float64   `json:"warning"`
+	Critical  float64   `json:"critical"`
+	Applied   bool      `json:"applied"`
+	LearnedAt time.Time `json:"learned_at"`
+}
+
+func isBaselineMetric(metric string) bool {
+	for _, m := range BaselineMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// FirstSnapshotAt returns when the host's first snapshot was collected (zero if none).
+func (r *Repo) FirstSnapshotAt(ctx context.Context, agentID string) (time.Time, error) {
+	var first sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT MIN(s.collected_at)
+		FROM snapshots s
+		JOIN hosts h ON s.host_id = h.host_id
+		WHERE h.agent_id = ?
+	`, agentID).Scan(&first)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("query first snapshot failed: %w", err)
+	}
+	if !first.Valid {
+		return time.Time{}, nil
+	}
+	return first.Time, nil
+}
+
+// ComputeBaseline summarizes each baseline metric for a host over [since, until).
+func (r *Repo) ComputeBaseline(ctx context.Context, agentID string, since, until time.Time) ([]MetricBaseline, error) {
+	baselines := make([]MetricBaseline, 0, len(BaselineMetrics))
+	for _, metric := range BaselineMetrics {
+		// metric comes from the BaselineMetrics allow-list, so interpolation is safe.
+		query := fmt.Sprintf(`
+			SELECT
+			  COUNT(m),
+			  COALESCE(AVG(m), 0),
+			  COALESCE(STDDEV_SAMP(m), 0),
+			  COALESCE(MIN(m), 0),
+			  COALESCE(MAX(m), 0),
+			  COALESCE(quantile_cont(m, 0.50), 0),
+			  COALESCE(quantile_cont(m, 0.95), 0),
+			  COALESCE(quantile_cont(m, 0.99), 0)
+			FROM (
+			  SELECT s.%s AS m
+			  FROM snapshots s
+			  JOIN hosts h ON s.host_id = h.host_id
+			  WHERE h.agent_id = ? AND s.collected_at >= ? AND s.collected_at < ? AND s.%s IS NOT NULL
+			)
+		`, metric, metric)
+
+		b := MetricBaseline{Metric: metric}
+		err := r.db.QueryRowContext(ctx, query, agentID, since, until).Scan(
+			&b.Samples, &b.Mean, &b.StdDev, &b.Min, &b.Max, &b.P50, &b.P95, &b.P99,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("compute baseline for %s failed: %w", metric, err)
+		}
+		baselines = append(baselines, b)
+	}
+	return baselines, nil
+}
+
+// SaveHostThresholds upserts learned thresholds.
+func (r *Repo) SaveHostThresholds(ctx context.Context, thresholds []HostThreshold) error {
+	for _, t := range thresholds {
+		if !isBaselineMetric(t.Metric) {
+			return fmt.Errorf("unknown baseline metric %q", t.Metric)
+		}
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO host_thresholds(agent_id, metric, warning, critical, applied, learned_at)
+			VALUES (?,?,?,?,?,?)
+			ON CONFLICT(agent_id, metric) DO UPDATE SET
+			  warning    = excluded.warning,
+			  critical   = excluded.critical,
+			  applied    = excluded.applied,
+			  learned_at = excluded.learned_at
+		`, t.AgentID, t.Metric, t.Warning, t.Critical, t.Applied, t.LearnedAt)
+		if err != nil {
+			return fmt.Errorf("save threshold %s failed: %w", t.Metric, err)
+		}
+	}
+	return nil
+}
+
+// LoadHostThresholds returns the learned thresholds for a host.
+func (r *Repo) LoadHostThresholds(ctx context.Context, agentID string) ([]HostThreshold, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT agent_id, metric, warning, critical, applied, learned_at
+		FROM host_thresholds
+		WHERE agent_id = ?
+		ORDER BY metric
+	`, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("query host thresholds failed: %w", err)
+	}
+	defer rows.Close()
+
+	thresholds := []HostThreshold{}
+	for rows.Next() {
+		var t HostThreshold
+		if err := rows.Scan(&t.AgentID, &t.Metric, &t.Warning, &t.Critical, &t.Applied, &t.LearnedAt); err != nil {
+			return nil, fmt.Errorf("scan host threshold failed: %w", err)
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, rows.Err()
+}
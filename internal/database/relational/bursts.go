@@ -0,0 +1,121 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"syschecker/internal/collector"
+)
+
+// Burst is a bounded window of high-frequency collection opened by a critical flag.
+type Burst struct {
+	IncidentID   string    `json:"incident_id"`
+	AgentID      string    `json:"agent_id"`
+	StartedAt    time.Time `json:"started_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	EndedAt      time.Time `json:"ended_at,omitempty"`
+	TriggerFlags []string  `json:"trigger_flags"`
+	Severity     int       `json:"severity"`
+}
+
+// BurstSample is the extended detail recorded alongside one burst snapshot.
+type BurstSample struct {
+	IncidentID  string                  `json:"incident_id"`
+	SnapshotID  int64                   `json:"snapshot_id"`
+	CollectedAt time.Time               `json:"collected_at"`
+	Processes   []collector.ProcessStat `json:"processes"`
+	Connections []collector.ConnStat    `json:"connections"`
+}
+
+// StartBurst records a new burst.
+func (r *Repo) StartBurst(ctx context.Context, b Burst) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO bursts(incident_id, agent_id, started_at, ends_at, trigger_flags, severity)
+		VALUES (?,?,?,?,?,?)
+	`, b.IncidentID, b.AgentID, b.StartedAt, b.EndsAt, strings.Join(b.TriggerFlags, ","), b.Severity)
+	if err != nil {
+		return fmt.Errorf("start burst failed: %w", err)
+	}
+	return nil
+}
+
+// EndBurst marks a burst as finished.
+func (r *Repo) EndBurst(ctx context.Context, incidentID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE bursts SET ended_at = ? WHERE incident_id = ?`, at, incidentID)
+	if err != nil {
+		return fmt.Errorf("end burst failed: %w", err)
+	}
+	return nil
+}
+
+// InsertBurstSample stores the detail captured with a burst snapshot.
+func (r *Repo) InsertBurstSample(ctx context.Context, s BurstSample) error {
+	procs, err := json.Marshal(s.Processes)
+	if err != nil {
+		return fmt.Errorf("encode processes: %w", err)
+	}
+	conns, err := json.Marshal(s.Connections)
+	if err != nil {
+		return fmt.Errorf("encode connections: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO burst_samples(incident_id, snapshot_id, collected_at, processes, connections)
+		VALUES (?,?,?,?,?)
+		ON CONFLICT(incident_id, snapshot_id) DO NOTHING
+	`, s.IncidentID, s.SnapshotID, s.CollectedAt, string(procs), string(conns))
+	if err != nil {
+		return fmt.Errorf("insert burst sample failed: %w", err)
+	}
+	return nil
+}
+
+// GetBurst returns a burst and its samples, oldest first.
+func (r *Repo) GetBurst(ctx context.Context, incidentID string) (*Burst, []BurstSample, error) {
+	var b Burst
+	var ended sql.NullTime
+	var flags sql.NullString
+	var severity sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT incident_id, agent_id, started_at, ends_at, ended_at, trigger_flags, severity
+		FROM bursts WHERE incident_id = ?
+	`, incidentID).Scan(&b.IncidentID, &b.AgentID, &b.StartedAt, &b.EndsAt, &ended, &flags, &severity)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query burst failed: %w", err)
+	}
+	b.EndedAt = ended.Time
+	b.Severity = int(severity.Int64)
+	if flags.String != "" {
+		b.TriggerFlags = strings.Split(flags.String, ",")
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT snapshot_id, collected_at, processes, connections
+		FROM burst_samples WHERE incident_id = ?
+		ORDER BY collected_at
+	`, incidentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query burst samples failed: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []BurstSample{}
+	for rows.Next() {
+		s := BurstSample{IncidentID: incidentID}
+		var procs, conns sql.NullString
+		if err := rows.Scan(&s.SnapshotID, &s.CollectedAt, &procs, &conns); err != nil {
+			return nil, nil, fmt.Errorf("scan burst sample failed: %w", err)
+		}
+		if procs.Valid {
+			_ = json.Unmarshal([]byte(procs.String), &s.Processes)
+		}
+		if conns.Valid {
+			_ = json.Unmarshal([]byte(conns.String), &s.Connections)
+		}
+		samples = append(samples, s)
+	}
+	return &b, samples, rows.Err()
+}
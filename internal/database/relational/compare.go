@@ -0,0 +1,152 @@
+package relational
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MetricAggregate summarises one metric for one host over a window.
+type MetricAggregate struct {
+	Avg *float64 `json:"avg,omitempty"`
+	P50 *float64 `json:"p50,omitempty"`
+	P95 *float64 `json:"p95,omitempty"`
+	P99 *float64 `json:"p99,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// MetricComparison sets one metric of two hosts side by side.
+type MetricComparison struct {
+	Metric  string          `json:"metric"`
+	A       MetricAggregate `json:"a"`
+	B       MetricAggregate `json:"b"`
+	AvgDiff *float64        `json:"avg_diff,omitempty"` // A.Avg - B.Avg
+	RelDiff float64         `json:"rel_diff"`           // |AvgDiff| / max(|A.Avg|, |B.Avg|), 0..1
+}
+
+// FlagComparison counts the snapshots in which a flag was active on each host.
+type FlagComparison struct {
+	Flag   string `json:"flag"`
+	CountA int64  `json:"count_a"`
+	CountB int64  `json:"count_b"`
+}
+
+// HostComparison is a structured diff of two hosts over a window.
+type HostComparison struct {
+	HostA    string             `json:"host_a"`
+	HostB    string             `json:"host_b"`
+	Since    time.Time          `json:"since"`
+	SamplesA int64              `json:"samples_a"`
+	SamplesB int64              `json:"samples_b"`
+	Metrics  []MetricComparison `json:"metrics"` // most different first
+	Flags    []FlagComparison   `json:"flags"`   // flags whose counts differ, largest gap first
+}
+
+// CompareHosts aggregates every metric column and flag for two hosts, each
+// named by hostname or agent ID, over the last window.
+func (r *Repo) CompareHosts(ctx context.Context, hostA, hostB string, window time.Duration) (*HostComparison, error) {
+	if hostA == "" || hostB == "" {
+		return nil, fmt.Errorf("two hosts are required")
+	}
+	if hostA == hostB {
+		return nil, fmt.Errorf("cannot compare %q with itself", hostA)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+
+	// Metric names come from MetricColumns, never from input.
+	cols := []string{"COUNT(*)"}
+	for _, m := range MetricColumns {
+		v := fmt.Sprintf("CAST(s.%s AS DOUBLE)", m)
+		cols = append(cols, aggregateSQL(v)...)
+	}
+	for i := range FlagNames {
+		cols = append(cols, fmt.Sprintf("CAST(sum((COALESCE(s.flags_bitmask, 0) >> %d) & 1) AS BIGINT)", i))
+	}
+	query := `
+		SELECT CASE WHEN h.hostname = ? OR h.agent_id = ? THEN 0 ELSE 1 END AS side,
+		  ` + strings.Join(cols, ",\n\t\t  ") + `
+		FROM snapshots s
+		JOIN hosts h ON h.host_id = s.host_id
+		WHERE s.collected_at >= ? AND (h.hostname IN (?, ?) OR h.agent_id IN (?, ?))
+		GROUP BY side`
+
+	since := r.clock.Now().Add(-window)
+	rows, err := r.db.QueryContext(ctx, query, hostA, hostA, since, hostA, hostB, hostA, hostB)
+	if err != nil {
+		return nil, fmt.Errorf("compare hosts failed: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		samples [2]int64
+		aggs    [2][]MetricAggregate
+		flags   [2][]int64
+	)
+	for rows.Next() {
+		var side int
+		var count int64
+		vals := make([]sql.NullFloat64, aggregateCols*len(MetricColumns))
+		counts := make([]int64, len(FlagNames))
+		dest := []any{&side, &count}
+		for i := range vals {
+			dest = append(dest, &vals[i])
+		}
+		for i := range counts {
+			dest = append(dest, &counts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan host comparison failed: %w", err)
+		}
+		samples[side], flags[side] = count, counts
+		aggs[side] = make([]MetricAggregate, len(MetricColumns))
+		for i := range MetricColumns {
+			aggs[side][i] = scanAggregate(vals[aggregateCols*i : aggregateCols*(i+1)])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	for side, host := range []string{hostA, hostB} {
+		if samples[side] == 0 {
+			return nil, fmt.Errorf("no snapshots for host %q since %s", host, since.Format(time.RFC3339))
+		}
+	}
+
+	res := &HostComparison{
+		HostA: hostA, HostB: hostB, Since: since,
+		SamplesA: samples[0], SamplesB: samples[1],
+		Metrics: []MetricComparison{},
+		Flags:   []FlagComparison{},
+	}
+	for i, m := range MetricColumns {
+		mc := MetricComparison{Metric: m, A: aggs[0][i], B: aggs[1][i]}
+		if a, b := mc.A.Avg, mc.B.Avg; a != nil && b != nil {
+			d := *a - *b
+			mc.AvgDiff = &d
+			if scale := math.Max(math.Abs(*a), math.Abs(*b)); scale > 0 {
+				mc.RelDiff = math.Abs(d) / scale
+			}
+		}
+		res.Metrics = append(res.Metrics, mc)
+	}
+	slices.SortStableFunc(res.Metrics, func(x, y MetricComparison) int {
+		return cmp.Compare(y.RelDiff, x.RelDiff)
+	})
+	for i, name := range FlagNames {
+		if flags[0][i] != flags[1][i] {
+			res.Flags = append(res.Flags, FlagComparison{name, flags[0][i], flags[1][i]})
+		}
+	}
+	gap := func(f FlagComparison) int64 { return max(f.CountA-f.CountB, f.CountB-f.CountA) }
+	slices.SortStableFunc(res.Flags, func(x, y FlagComparison) int {
+		return cmp.Compare(gap(y), gap(x))
+	})
+	return res, nil
+}
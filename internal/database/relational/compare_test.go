@@ -0,0 +1,45 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCompareHosts(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		at := now.Add(-time.Duration(i) * time.Minute)
+		slow := RawStatsFixed{AgentID: "a3", Hostname: "node3", CollectedAt: at, CPUUsagePct: 90, NetLatencyMS: 40}
+		fast := RawStatsFixed{AgentID: "a4", Hostname: "node4", CollectedAt: at, CPUUsagePct: 30, NetLatencyMS: 40}
+		if _, err := repo.InsertRawStats(ctx, slow, DerivedRates{}, SnapshotFlags{FlagCPUOverloaded: true}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.InsertRawStats(ctx, fast, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Hosts may be named by hostname or agent ID.
+	got, err := repo.CompareHosts(ctx, "node3", "a4", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.SamplesA != 3 || got.SamplesB != 3 {
+		t.Errorf("samples = %d, %d, want 3, 3", got.SamplesA, got.SamplesB)
+	}
+	top := got.Metrics[0]
+	if top.Metric != "cpu_usage_pct" || top.AvgDiff == nil || *top.AvgDiff != 60 {
+		t.Errorf("most different metric = %+v, want cpu_usage_pct with diff 60", top)
+	}
+	if len(got.Flags) != 1 || got.Flags[0] != (FlagComparison{"cpu_overloaded", 3, 0}) {
+		t.Errorf("flags = %+v", got.Flags)
+	}
+
+	if _, err := repo.CompareHosts(ctx, "node3", "node9", time.Hour); err == nil {
+		t.Error("expected an error for a host without snapshots")
+	}
+}
@@ -0,0 +1,40 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ComparisonOps are the operators ThresholdCompliance accepts.
+var ComparisonOps = []string{"<", "<=", ">", ">="}
+
+// ThresholdCompliance counts the snapshots of hostname (every host when
+// empty) collected since since in which metric was recorded, and how many
+// of them satisfied "metric op threshold". metric must be one of
+// MetricColumns and op one of ComparisonOps.
+func (r *Repo) ThresholdCompliance(ctx context.Context, hostname, metric, op string, threshold float64, since time.Time) (good, total int64, err error) {
+	if !IsMetricColumn(metric) {
+		return 0, 0, fmt.Errorf("unknown metric %q", metric)
+	}
+	if !slices.Contains(ComparisonOps, op) {
+		return 0, 0, fmt.Errorf("unknown operator %q", op)
+	}
+	// Both are validated above, so they can be spliced into the query.
+	v := fmt.Sprintf("CAST(s.%s AS DOUBLE)", metric)
+	query := `
+		SELECT count(*) FILTER (WHERE ` + v + ` ` + op + ` ?), count(` + v + `)
+		FROM snapshots s
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.collected_at >= ?`
+	args := []any{threshold, since.UTC()}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&good, &total); err != nil {
+		return 0, 0, fmt.Errorf("threshold compliance failed: %w", err)
+	}
+	return good, total, nil
+}
@@ -0,0 +1,31 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThresholdCompliance(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	now := time.Now()
+	for i := range 10 {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: now.Add(-time.Duration(i) * time.Minute), NetLatencyMS: float64(10 * i)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	good, total, err := repo.ThresholdCompliance(ctx, "web-1", "net_latency_ms", "<", 50, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != 5 || total != 10 {
+		t.Errorf("got %d of %d good, want 5 of 10", good, total)
+	}
+
+	if _, _, err := repo.ThresholdCompliance(ctx, "", "net_latency_ms", "; DROP TABLE snapshots; --", 50, now); err == nil {
+		t.Error("accepted an unknown operator")
+	}
+}
@@ -0,0 +1,118 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// containerRollupTableSQL creates containers_hourly: one row per container
+// and UTC hour. Like snapshots_hourly it has no primary key so that
+// RefreshRollups can delete and reinsert hours.
+const containerRollupTableSQL = `CREATE TABLE IF NOT EXISTS containers_hourly (
+  host_id              BIGINT NOT NULL,
+  docker_container_key BIGINT NOT NULL,
+  hour                 TIMESTAMP NOT NULL,
+  name                 VARCHAR, -- latest name seen in the hour
+  image                VARCHAR,
+  samples              BIGINT NOT NULL,
+  cpu_avg_pct          DOUBLE,
+  cpu_max_pct          DOUBLE,
+  mem_max_bytes        BIGINT,
+  restarts             BIGINT NOT NULL -- stopped-to-running transitions seen
+);`
+
+// containerRollupSelectSQL aggregates container stats of snapshots
+// collected at or after the second parameter. The first parameter, an hour
+// earlier, lets a restart across the hour boundary be seen.
+const containerRollupSelectSQL = `
+WITH c AS (
+  SELECT s.host_id, c.docker_container_key, s.collected_at, c.name, c.image,
+    c.running, c.cpu_usage_pct, c.mem_usage_bytes,
+    lag(c.running) OVER (PARTITION BY c.docker_container_key ORDER BY s.collected_at) AS prev_running
+  FROM snapshot_docker_container_stats c
+  JOIN snapshots s ON s.snapshot_id = c.snapshot_id
+  WHERE s.collected_at >= ?
+)
+SELECT host_id, docker_container_key, date_trunc('hour', collected_at) AS hour,
+  arg_max(name, collected_at), arg_max(image, collected_at), count(*),
+  avg(cpu_usage_pct), max(cpu_usage_pct), max(mem_usage_bytes),
+  count_if(running AND prev_running = false)
+FROM c
+WHERE collected_at >= ?
+GROUP BY host_id, docker_container_key, hour`
+
+// ContainerHour summarizes one container over one hour.
+type ContainerHour struct {
+	Hostname    string    `json:"hostname"`
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Image       string    `json:"image"`
+	Hour        time.Time `json:"hour"`
+	Samples     int64     `json:"samples"`
+	CPUAvgPct   float64   `json:"cpu_avg_pct"`
+	CPUMaxPct   float64   `json:"cpu_max_pct"`
+	MemMaxBytes int64     `json:"mem_max_bytes"`
+	Restarts    int64     `json:"restarts"` // times the container was seen stopped, then running again
+}
+
+// ContainerHistory returns the hourly rollups of containers since since,
+// oldest first. container, when set, matches a container name or an ID
+// prefix; hostname, when set, restricts the host.
+func (r *Repo) ContainerHistory(ctx context.Context, hostname, container string, since time.Time) ([]ContainerHour, error) {
+	if err := r.updateRollups(ctx); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT COALESCE(h.hostname, 'unknown'), d.container_id, COALESCE(r.name, ''), COALESCE(r.image, ''),
+		  r.hour, r.samples, COALESCE(r.cpu_avg_pct, 0), COALESCE(r.cpu_max_pct, 0),
+		  COALESCE(r.mem_max_bytes, 0), r.restarts
+		FROM containers_hourly r
+		JOIN docker_containers d ON d.docker_container_key = r.docker_container_key
+		LEFT JOIN hosts h ON r.host_id = h.host_id
+		WHERE r.hour >= ?
+	`
+	args := []any{since.UTC().Truncate(time.Hour)}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	if container = strings.TrimPrefix(container, "/"); container != "" {
+		query += " AND (ltrim(r.name, '/') = ? OR starts_with(d.container_id, ?))"
+		args = append(args, container, container)
+	}
+	query += " ORDER BY r.hour, h.hostname, r.name"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("container history query failed: %w", err)
+	}
+	defer rows.Close()
+
+	hours := []ContainerHour{}
+	for rows.Next() {
+		var c ContainerHour
+		if err := rows.Scan(&c.Hostname, &c.ContainerID, &c.Name, &c.Image, &c.Hour, &c.Samples,
+			&c.CPUAvgPct, &c.CPUMaxPct, &c.MemMaxBytes, &c.Restarts); err != nil {
+			return nil, fmt.Errorf("scan container hour failed: %w", err)
+		}
+		hours = append(hours, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return hours, nil
+}
+
+// refreshContainerRollups replaces containers_hourly rows from since onwards
+// inside the caller's transaction.
+func refreshContainerRollups(ctx context.Context, tx *sql.Tx, since time.Time) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM containers_hourly WHERE hour >= ?`, since); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO containers_hourly"+containerRollupSelectSQL, since.Add(-time.Hour), since)
+	return err
+}
@@ -0,0 +1,137 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// CorrelationPoint is the correlation of two metrics within one time bucket.
+type CorrelationPoint struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Samples     int64     `json:"samples"`
+	Correlation *float64  `json:"correlation,omitempty"` // nil when a metric is constant in the bucket
+}
+
+// CorrelationResult summarizes the Pearson correlation between two metrics over a window.
+type CorrelationResult struct {
+	MetricA     string             `json:"metric_a"`
+	MetricB     string             `json:"metric_b"`
+	Hostname    string             `json:"hostname,omitempty"`
+	Since       time.Time          `json:"since"`
+	Samples     int64              `json:"samples"`
+	Correlation *float64           `json:"correlation,omitempty"`
+	Strength    string             `json:"strength"`
+	Rolling     []CorrelationPoint `json:"rolling"`
+}
+
+// CorrelateMetrics computes the overall and bucketed correlation between two snapshot
+// metrics over the last window. buckets controls the rolling resolution.
+func (r *Repo) CorrelateMetrics(ctx context.Context, hostname, metricA, metricB string, window time.Duration, buckets int) (*CorrelationResult, error) {
+	if !IsMetricColumn(metricA) {
+		return nil, fmt.Errorf("unknown metric %q", metricA)
+	}
+	if !IsMetricColumn(metricB) {
+		return nil, fmt.Errorf("unknown metric %q", metricB)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+	if buckets <= 0 {
+		buckets = 12
+	}
+
+	since := r.clock.Now().Add(-window)
+	res := &CorrelationResult{
+		MetricA:  metricA,
+		MetricB:  metricB,
+		Hostname: hostname,
+		Since:    since,
+		Rolling:  []CorrelationPoint{},
+	}
+
+	// Metric names are validated against MetricColumns above.
+	from := fmt.Sprintf(`
+		FROM snapshots s
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.collected_at >= ? AND s.%s IS NOT NULL AND s.%s IS NOT NULL
+	`, metricA, metricB)
+	args := []interface{}{since}
+	if hostname != "" {
+		from += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+
+	var overall sql.NullFloat64
+	err := r.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*), corr(s.%s, s.%s) %s", metricA, metricB, from), args...,
+	).Scan(&res.Samples, &overall)
+	if err != nil {
+		return nil, fmt.Errorf("correlate metrics failed: %w", err)
+	}
+	res.Correlation = validFloat(overall)
+	res.Strength = correlationStrength(res.Correlation)
+
+	bucketSecs := math.Max(window.Seconds()/float64(buckets), 1)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+		  CAST(floor(epoch(s.collected_at) / ?) AS BIGINT) AS bucket,
+		  COUNT(*),
+		  corr(s.%s, s.%s)
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, metricA, metricB, from), append([]interface{}{bucketSecs}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("rolling correlation failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket int64
+		var p CorrelationPoint
+		var c sql.NullFloat64
+		if err := rows.Scan(&bucket, &p.Samples, &c); err != nil {
+			return nil, fmt.Errorf("scan rolling correlation failed: %w", err)
+		}
+		p.BucketStart = time.Unix(int64(float64(bucket)*bucketSecs), 0).UTC()
+		p.Correlation = validFloat(c)
+		res.Rolling = append(res.Rolling, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return res, nil
+}
+
+func validFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid || math.IsNaN(v.Float64) || math.IsInf(v.Float64, 0) {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+// correlationStrength describes a Pearson coefficient in words.
+func correlationStrength(c *float64) string {
+	if c == nil {
+		return "undefined"
+	}
+	direction := "positive"
+	if *c < 0 {
+		direction = "negative"
+	}
+	switch abs := math.Abs(*c); {
+	case abs >= 0.7:
+		return "strong " + direction
+	case abs >= 0.4:
+		return "moderate " + direction
+	case abs >= 0.2:
+		return "weak " + direction
+	default:
+		return "none"
+	}
+}
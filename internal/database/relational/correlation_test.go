@@ -0,0 +1,31 @@
+package relational
+
+import "testing"
+
+func TestCorrelationStrength(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	cases := []struct {
+		in   *float64
+		want string
+	}{
+		{nil, "undefined"},
+		{f(0.92), "strong positive"},
+		{f(-0.55), "moderate negative"},
+		{f(0.25), "weak positive"},
+		{f(-0.05), "none"},
+	}
+	for _, c := range cases {
+		if got := correlationStrength(c.in); got != c.want {
+			t.Errorf("correlationStrength(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIsMetricColumn(t *testing.T) {
+	if !IsMetricColumn("disk_write_bps") {
+		t.Error("expected disk_write_bps to be allowed")
+	}
+	if IsMetricColumn("hostname; DROP TABLE snapshots") {
+		t.Error("expected arbitrary input to be rejected")
+	}
+}
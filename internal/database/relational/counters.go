@@ -0,0 +1,97 @@
+package relational
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// NullUint64 is a nullable uint64 that binds as UBIGINT instead of being cast to int64.
+type NullUint64 struct {
+	Uint64 uint64
+	Valid  bool
+}
+
+// Value implements driver.Valuer. database/sql rejects uint64 driver values,
+// so values above math.MaxInt64 bind as their decimal text, which DuckDB
+// casts to the UBIGINT column.
+func (n NullUint64) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if n.Uint64 > math.MaxInt64 {
+		return strconv.FormatUint(n.Uint64, 10), nil
+	}
+	return int64(n.Uint64), nil
+}
+
+// Scan implements sql.Scanner. Negative int64 values are reinterpreted as uint64,
+// which recovers counters written to BIGINT columns by older versions.
+func (n *NullUint64) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		n.Uint64, n.Valid = 0, false
+	case uint64:
+		n.Uint64, n.Valid = v, true
+	case int64:
+		n.Uint64, n.Valid = uint64(v), true
+	case uint32:
+		n.Uint64, n.Valid = uint64(v), true
+	case int32:
+		n.Uint64, n.Valid = uint64(uint32(v)), true
+	default:
+		return fmt.Errorf("cannot scan %T into NullUint64", src)
+	}
+	return nil
+}
+
+// counterColumns are cumulative kernel counters that may exceed math.MaxInt64.
+var counterColumns = map[string][]string{
+	"snapshot_disk_io":             {"read_bytes", "write_bytes", "read_count", "write_count", "read_time_ms", "write_time_ms"},
+	"snapshot_net_interface_stats": {"bytes_sent", "bytes_recv", "packets_sent", "packets_recv", "err_in", "err_out", "drop_in", "drop_out"},
+}
+
+// migrateCounterColumns widens counter columns created as BIGINT by older versions.
+func (r *Repo) migrateCounterColumns(ctx context.Context) error {
+	for table, cols := range counterColumns {
+		for _, col := range cols {
+			var dataType string
+			err := r.db.QueryRowContext(ctx, `
+				SELECT data_type FROM information_schema.columns
+				WHERE table_name = ? AND column_name = ?
+			`, table, col).Scan(&dataType)
+			if err != nil {
+				return fmt.Errorf("inspect %s.%s: %w", table, col, err)
+			}
+			if dataType == "UBIGINT" {
+				continue
+			}
+			// Values corrupted by the old int64 cast are negative; reinterpret them.
+			stmt := fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s SET DATA TYPE UBIGINT
+				USING CASE WHEN %s < 0 THEN CAST(%s AS HUGEINT) + 18446744073709551616 ELSE %s END`,
+				table, col, col, col, col)
+			if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("widen %s.%s: %w", table, col, err)
+			}
+		}
+	}
+	return nil
+}
+
+// counterDelta returns how much a cumulative counter grew between two samples.
+// A decrease is treated as a 32- or 64-bit wraparound when prev was in the upper
+// half of that range, and as a counter reset (zero growth) otherwise.
+func counterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	if prev <= math.MaxUint32 && prev > math.MaxUint32/2 && cur <= math.MaxUint32 {
+		return (math.MaxUint32 - prev) + cur + 1
+	}
+	if prev > math.MaxUint64/2 {
+		return (math.MaxUint64 - prev) + cur + 1
+	}
+	return 0
+}
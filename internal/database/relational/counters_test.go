@@ -0,0 +1,140 @@
+package relational
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCounterDelta(t *testing.T) {
+	cases := []struct {
+		name      string
+		prev, cur uint64
+		want      uint64
+	}{
+		{"increase", 100, 250, 150},
+		{"unchanged", 42, 42, 0},
+		{"64-bit wrap", math.MaxUint64 - 9, 5, 15},
+		{"32-bit wrap", math.MaxUint32 - 4, 10, 15},
+		{"reset", 1_000_000, 10, 0},
+		{"beyond int64", math.MaxInt64 + 10, math.MaxInt64 + 110, 100},
+	}
+	for _, c := range cases {
+		if got := counterDelta(c.prev, c.cur); got != c.want {
+			t.Errorf("%s: counterDelta(%d, %d) = %d, want %d", c.name, c.prev, c.cur, got, c.want)
+		}
+	}
+}
+
+func TestComputeDerivedRatesPerDevice(t *testing.T) {
+	t0 := time.Now()
+	prev := PrevCounters{
+		CollectedAt: t0,
+		IOCounters: []DiskIOCountersFixed{
+			{Device: "sda", ReadBytes: math.MaxUint64 - 99, ReadCount: 10, ReadTimeMS: 50},
+			{Device: "sdb", ReadBytes: 5_000_000},
+		},
+		NetInterfaces: []NetInterfaceStatsFixed{{Name: "eth0", BytesSent: 1000, CarrierChanges: 4}},
+	}
+	now := RawStatsFixed{
+		CollectedAt: t0.Add(10 * time.Second),
+		IOCounters: []DiskIOCountersFixed{
+			{Device: "sda", ReadBytes: 900, ReadCount: 20, ReadTimeMS: 150}, // wrapped: +1000 bytes
+			{Device: "sdb", ReadBytes: 100},                                 // reset: ignored
+			{Device: "sdc", ReadBytes: 1 << 40},                             // new device: ignored
+		},
+		NetInterfaces: []NetInterfaceStatsFixed{
+			{Name: "eth0", BytesSent: 3000, CarrierChanges: 6, SpeedMbps: 1},
+			{Name: "wg0", BytesSent: 1 << 30}, // no speed, no previous sample
+		},
+	}
+
+	d := ComputeDerivedRates(prev, now)
+	if d.DiskReadBps != 100 {
+		t.Errorf("DiskReadBps = %v, want 100", d.DiskReadBps)
+	}
+	if d.DiskReadIops != 1 || d.DiskAvgReadLatMs != 10 {
+		t.Errorf("unexpected read iops/latency: %v / %v", d.DiskReadIops, d.DiskAvgReadLatMs)
+	}
+	if d.NetTxBps != 200 {
+		t.Errorf("NetTxBps = %v, want 200", d.NetTxBps)
+	}
+	if d.NetCarrierChanges["eth0"] != 2 {
+		t.Errorf("NetCarrierChanges = %v, want eth0: 2", d.NetCarrierChanges)
+	}
+	// 200 B/s on a 1 Mb/s link.
+	if len(d.NetUtilization) != 1 || math.Abs(d.NetUtilization["eth0"]-0.16) > 1e-9 {
+		t.Errorf("NetUtilization = %v, want eth0: 0.16", d.NetUtilization)
+	}
+}
+
+func TestNullUint64Scan(t *testing.T) {
+	var n NullUint64
+	if err := n.Scan(int64(-1)); err != nil || n.Uint64 != math.MaxUint64 {
+		t.Errorf("expected legacy negative value to map to MaxUint64, got %d (%v)", n.Uint64, err)
+	}
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Errorf("expected NULL to be invalid, got %+v (%v)", n, err)
+	}
+	if v, _ := (NullUint64{Uint64: 42, Valid: true}).Value(); v != int64(42) {
+		t.Errorf("Value() = %#v, want int64(42)", v)
+	}
+}
+
+func TestNullUint64RoundTrip(t *testing.T) {
+	client, err := NewDuckDBClient("")
+	if err != nil {
+		t.Fatalf("failed to create duckdb client: %v", err)
+	}
+	defer client.Close()
+	var got NullUint64
+	err = client.DB().QueryRow(`SELECT CAST(? AS UBIGINT)`, NullUint64{Uint64: math.MaxUint64, Valid: true}).Scan(&got)
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	if got.Uint64 != math.MaxUint64 {
+		t.Errorf("round trip = %d, want MaxUint64", got.Uint64)
+	}
+}
+
+func TestSnapshotFlagsMask(t *testing.T) {
+	f := SnapshotFlags{FlagHostOffline: true, FlagMemoryPressure: true}
+	if got := f.Mask(); got != 0b101 {
+		t.Errorf("Mask() = %b, want 101", got)
+	}
+}
+
+func TestFlagsFromBitmask(t *testing.T) {
+	// Stored masks depend on these positions; they must never change.
+	for name, bit := range map[string]int{"host_offline": 0, "memory_pressure": 2, "check_failed": 25} {
+		if got, ok := FlagBit(name); !ok || got != 1<<bit {
+			t.Errorf("FlagBit(%s) = %b, %v; want bit %d", name, got, ok, bit)
+		}
+	}
+	if _, ok := FlagBit("nope"); ok {
+		t.Error("FlagBit accepted an unknown flag")
+	}
+
+	want := SnapshotFlags{FlagCPUOverloaded: true, FlagLinkSaturated: true, FlagCheckFailed: true}
+	mask := want.Mask()
+	got := FlagsFromBitmask(mask | 1<<62)
+	if got.Mask() != mask || !got.FlagLinkSaturated || got.FlagHostOffline {
+		t.Errorf("FlagsFromBitmask(%b) = %+v", mask, got)
+	}
+	if names := BitmaskNames(mask); !slices.Equal(names, []string{"cpu_overloaded", "link_saturated", "check_failed"}) {
+		t.Errorf("BitmaskNames = %v", names)
+	}
+
+	repo := newTestRepo(t)
+	var has, missing, unknown bool
+	var names string
+	err := repo.db.QueryRow(`SELECT has_flag(?, 'link_saturated'), has_flag(?, 'host_offline'), has_flag(?, 'nope'),
+		array_to_string(flag_names(?), ',')`, mask, mask, mask, mask).Scan(&has, &missing, &unknown, &names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has || missing || unknown || names != "cpu_overloaded,link_saturated,check_failed" {
+		t.Errorf("macros = %v %v %v %q", has, missing, unknown, names)
+	}
+}
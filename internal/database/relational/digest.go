@@ -0,0 +1,144 @@
+package relational
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DigestMetrics are the rollup columns summarised per host in a digest.
+var DigestMetrics = []string{
+	"cpu_usage_pct",
+	"load_avg_1",
+	"ram_usage_pct",
+	"disk_usage_pct",
+	"net_latency_ms",
+}
+
+// DigestMetric is the average and peak of one metric over a digest period.
+type DigestMetric struct {
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+}
+
+// FlagIncidents counts how a flag fired on one host over a digest period.
+// An incident is a run of consecutive hours with the flag raised.
+type FlagIncidents struct {
+	Flag      string    `json:"flag"`
+	Incidents int       `json:"incidents"`
+	Hours     int       `json:"hours"`     // hours with the flag raised at least once
+	Snapshots int64     `json:"snapshots"` // snapshots with the flag raised
+	LastSeen  time.Time `json:"last_seen"` // start of the last flagged hour
+}
+
+// HostDigest summarises one host over a digest period.
+type HostDigest struct {
+	Hostname    string                  `json:"hostname"`
+	Samples     int64                   `json:"samples"`
+	Hours       int                     `json:"hours"` // hours with snapshots
+	MaxSeverity int                     `json:"max_severity"`
+	Metrics     map[string]DigestMetric `json:"metrics"`   // keyed by DigestMetrics
+	Incidents   []FlagIncidents         `json:"incidents"` // most flagged hours first
+}
+
+// HostDigests summarises every host from snapshots_hourly since since,
+// ordered by hostname: the averages and peaks of DigestMetrics and, per
+// flag, its incidents.
+func (r *Repo) HostDigests(ctx context.Context, since time.Time) ([]HostDigest, error) {
+	if err := r.updateRollups(ctx); err != nil {
+		return nil, err
+	}
+
+	cols := []string{"COALESCE(h.hostname, '')", "r.hour", "r.samples", "r.severity_level_max"}
+	for _, m := range DigestMetrics {
+		cols = append(cols, "r."+m+"_avg", "r."+m+"_max")
+	}
+	cols = append(cols, "r.flag_counts")
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+strings.Join(cols, ", ")+`
+		FROM snapshots_hourly r
+		LEFT JOIN hosts h ON r.host_id = h.host_id
+		WHERE r.hour >= ?
+		ORDER BY 1, r.hour`, since.UTC().Truncate(time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("host digest query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var out []HostDigest
+	var weights map[string]float64 // samples behind each metric's average
+	var open map[string]time.Time  // last flagged hour per flag
+	for rows.Next() {
+		var (
+			host     string
+			hour     time.Time
+			samples  int64
+			severity sql.NullFloat64
+			vals     = make([]sql.NullFloat64, 2*len(DigestMetrics))
+			counts   []any
+		)
+		dest := []any{&host, &hour, &samples, &severity}
+		for i := range vals {
+			dest = append(dest, &vals[i])
+		}
+		dest = append(dest, &counts)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan host digest failed: %w", err)
+		}
+
+		if len(out) == 0 || out[len(out)-1].Hostname != host {
+			out = append(out, HostDigest{Hostname: host, Metrics: map[string]DigestMetric{}})
+			weights, open = map[string]float64{}, map[string]time.Time{}
+		}
+		d := &out[len(out)-1]
+		d.Samples += samples
+		d.Hours++
+		if severity.Valid {
+			d.MaxSeverity = max(d.MaxSeverity, int(severity.Float64))
+		}
+		for i, m := range DigestMetrics {
+			avg, peak := vals[2*i], vals[2*i+1]
+			if !avg.Valid {
+				continue
+			}
+			agg, w := d.Metrics[m], weights[m]
+			agg.Avg = (agg.Avg*w + avg.Float64*float64(samples)) / (w + float64(samples))
+			if w == 0 || peak.Float64 > agg.Max {
+				agg.Max = peak.Float64
+			}
+			d.Metrics[m], weights[m] = agg, w+float64(samples)
+		}
+		for i, c := range counts {
+			n, _ := c.(int64)
+			if n == 0 || i >= len(FlagNames) {
+				continue
+			}
+			flag := FlagNames[i]
+			j := slices.IndexFunc(d.Incidents, func(inc FlagIncidents) bool { return inc.Flag == flag })
+			if j < 0 {
+				d.Incidents = append(d.Incidents, FlagIncidents{Flag: flag})
+				j = len(d.Incidents) - 1
+			}
+			inc := &d.Incidents[j]
+			if last, ok := open[flag]; !ok || hour.Sub(last) > time.Hour {
+				inc.Incidents++
+			}
+			inc.Hours++
+			inc.Snapshots += n
+			inc.LastSeen = hour
+			open[flag] = hour
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	for _, d := range out {
+		slices.SortStableFunc(d.Incidents, func(x, y FlagIncidents) int { return cmp.Compare(y.Hours, x.Hours) })
+	}
+	return out, nil
+}
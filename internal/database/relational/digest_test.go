@@ -0,0 +1,45 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostDigests(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Now().UTC().Add(-5 * time.Hour).Truncate(time.Hour)
+	for i, f := range []SnapshotFlags{
+		{},
+		{FlagCPUOverloaded: true, SeverityLevel: 2},
+		{FlagCPUOverloaded: true, SeverityLevel: 3},
+		{},
+		{FlagCPUOverloaded: true, SeverityLevel: 2},
+	} {
+		s := RawStatsFixed{AgentID: "web-1", Hostname: "web-1", CollectedAt: start.Add(time.Duration(i)*time.Hour + time.Minute), CPUUsagePct: float64(20 * i)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.HostDigests(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("digests = %+v", got)
+	}
+	d := got[0]
+	if d.Hostname != "web-1" || d.Samples != 5 || d.Hours != 5 || d.MaxSeverity != 3 {
+		t.Errorf("digest = %+v", d)
+	}
+	if cpu := d.Metrics["cpu_usage_pct"]; cpu.Avg != 40 || cpu.Max != 80 {
+		t.Errorf("cpu = %+v", cpu)
+	}
+	// Hours 1-2 and hour 4 are two incidents.
+	if len(d.Incidents) != 1 || d.Incidents[0].Flag != "cpu_overloaded" || d.Incidents[0].Incidents != 2 || d.Incidents[0].Hours != 3 {
+		t.Errorf("incidents = %+v", d.Incidents)
+	}
+}
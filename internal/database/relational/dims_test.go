@@ -0,0 +1,116 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	client, err := NewDuckDBClient("")
+	if err != nil {
+		t.Fatalf("failed to create duckdb client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	repo := NewRepo(client.DB())
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate schema: %v", err)
+	}
+	return repo
+}
+
+func TestDimensionCacheDiscardedOnRollback(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	tx, err := repo.beginDimTx(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	staleID, err := repo.upsertDiskDeviceTx(ctx, tx, 1, "sda")
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+
+	if _, ok := repo.ensureCache(1).diskDevice["sda"]; ok {
+		t.Fatal("rolled back dimension ID must not be cached")
+	}
+
+	tx, err = repo.beginDimTx(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	id, err := repo.upsertDiskDeviceTx(ctx, tx, 1, "sda")
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if id == staleID {
+		t.Fatal("expected a fresh ID after rollback")
+	}
+	if err := repo.commitDimTx(tx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if cached := repo.ensureCache(1).diskDevice["sda"]; cached != id {
+		t.Errorf("cache = %d, want committed id %d", cached, id)
+	}
+	var count int
+	if err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM disk_devices WHERE disk_device_id = ?`, id).Scan(&count); err != nil || count != 1 {
+		t.Errorf("committed id %d not found in disk_devices (count=%d, err=%v)", id, count, err)
+	}
+}
+
+func TestInsertRawStatsRetryAfterFailedInsert(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	stats := RawStatsFixed{
+		CollectedAt: time.Now(),
+		Kind:        KindMerged,
+		AgentID:     "agent",
+		Hostname:    "host",
+		IOCounters:  []DiskIOCountersFixed{{Device: "nvme0n1", ReadBytes: 1}},
+		// Duplicate ranks violate the snapshot_top_processes primary key and abort the insert.
+		TopProcesses: []ProcessStatFixed{{Rank: 1, PID: 1, Name: "a"}, {Rank: 1, PID: 2, Name: "b"}},
+	}
+	if _, err := repo.InsertRawStats(ctx, stats, DerivedRates{}, SnapshotFlags{}); err == nil {
+		t.Fatal("expected insert to fail")
+	}
+
+	stats.TopProcesses = stats.TopProcesses[:1]
+	res, err := repo.InsertRawStats(ctx, stats, DerivedRates{}, SnapshotFlags{})
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+
+	var orphans int
+	err = repo.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM snapshot_disk_io io
+		LEFT JOIN disk_devices dd ON dd.disk_device_id = io.disk_device_id
+		WHERE io.snapshot_id = ? AND dd.disk_device_id IS NULL
+	`, res.SnapshotID).Scan(&orphans)
+	if err != nil {
+		t.Fatalf("orphan check: %v", err)
+	}
+	if orphans != 0 {
+		t.Errorf("found %d disk_io rows referencing missing devices", orphans)
+	}
+
+	var procOrphans int
+	err = repo.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM snapshot_top_processes tp
+		LEFT JOIN process_names pn ON pn.process_name_id = tp.process_name_id
+		WHERE tp.snapshot_id = ? AND pn.process_name_id IS NULL
+	`, res.SnapshotID).Scan(&procOrphans)
+	if err != nil {
+		t.Fatalf("orphan check: %v", err)
+	}
+	if procOrphans != 0 {
+		t.Errorf("found %d top process rows referencing missing names", procOrphans)
+	}
+}
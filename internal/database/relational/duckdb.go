@@ -4,10 +4,13 @@ package relational
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
-	_ "github.com/marcboeker/go-duckdb" // Register DuckDB driver
+	"github.com/marcboeker/go-duckdb" // also registers the DuckDB driver
 )
 
 // =============================================================================
@@ -31,8 +34,12 @@ type DatabaseConfig struct {
 	Threads       int           // Number of threads for DuckDB (0 = default)
 	MemoryLimitGB int           // Memory limit in GB (0 = default)
 	Timeout       time.Duration // Query timeout (0 = no timeout)
+	Compression   string        // Forced column compression, e.g. "rle" ("" or "auto" = DuckDB's choice)
 }
 
+// CompressionMethods are the values WithCompression accepts.
+var CompressionMethods = []string{"auto", "uncompressed", "constant", "rle", "dictionary", "pfor", "bitpacking", "fsst", "alp", "alprd"}
+
 // =============================================================================
 // DUCKDB CLIENT IMPLEMENTATION
 // =============================================================================
@@ -41,6 +48,7 @@ type DatabaseConfig struct {
 type DuckDBClient struct {
 	db     *sql.DB
 	config DatabaseConfig
+	init   []string // run on every new connection
 }
 
 // DuckDBOption configures the DuckDB client.
@@ -60,6 +68,22 @@ func WithMemoryLimit(gb int) DuckDBOption {
 	}
 }
 
+// WithCompression forces a column compression method for data written from
+// now on; see CompressionMethods. Existing blocks keep their compression.
+func WithCompression(method string) DuckDBOption {
+	return func(c *DuckDBClient) {
+		c.config.Compression = method
+	}
+}
+
+// WithInit runs stmts on every new connection before it is used, for
+// state DuckDB keeps per connection, such as the default catalog.
+func WithInit(stmts ...string) DuckDBOption {
+	return func(c *DuckDBClient) {
+		c.init = append(c.init, stmts...)
+	}
+}
+
 // WithTimeout sets the query timeout.
 func WithTimeout(d time.Duration) DuckDBOption {
 	return func(c *DuckDBClient) {
@@ -95,7 +119,7 @@ func NewDuckDBClient(dsn string, opts ...DuckDBOption) (*DuckDBClient, error) {
 	}
 
 	// Open database connection
-	db, err := sql.Open("duckdb", dsn)
+	db, err := client.open(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open duckdb: %w", err)
 	}
@@ -129,6 +153,27 @@ func NewDuckDBClient(dsn string, opts ...DuckDBOption) (*DuckDBClient, error) {
 	return client, nil
 }
 
+// open opens dsn, through a connector running the WithInit statements
+// when there are any.
+func (c *DuckDBClient) open(dsn string) (*sql.DB, error) {
+	if len(c.init) == 0 {
+		return sql.Open("duckdb", dsn)
+	}
+	connector, err := duckdb.NewConnector(dsn, func(execer driver.ExecerContext) error {
+		for i, stmt := range c.init {
+			// Statements may carry credentials: identify them by position.
+			if _, err := execer.ExecContext(context.Background(), stmt, nil); err != nil {
+				return fmt.Errorf("connection init statement %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
 // DB returns the underlying sql.DB instance.
 func (c *DuckDBClient) DB() *sql.DB {
 	return c.db
@@ -162,6 +207,15 @@ func (c *DuckDBClient) Configure(cfg DatabaseConfig) error {
 		}
 	}
 
+	if cfg.Compression != "" {
+		if !slices.Contains(CompressionMethods, cfg.Compression) {
+			return fmt.Errorf("unknown compression %q (want one of %s)", cfg.Compression, strings.Join(CompressionMethods, ", "))
+		}
+		if _, err := c.db.Exec(fmt.Sprintf("SET force_compression='%s'", cfg.Compression)); err != nil {
+			return fmt.Errorf("setting compression: %w", err)
+		}
+	}
+
 	c.config = cfg
 	return nil
 }
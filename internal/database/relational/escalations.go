@@ -0,0 +1,60 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// LoadEscalations returns the persisted escalation state for a host.
+func (r *Repo) LoadEscalations(ctx context.Context, agentID string) ([]EscalationState, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT agent_id, flag, first_seen_at, level, last_notified_at, notify_count
+		FROM flag_escalations
+		WHERE agent_id = ?
+	`, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("query escalations failed: %w", err)
+	}
+	defer rows.Close()
+
+	states := []EscalationState{}
+	for rows.Next() {
+		var st EscalationState
+		var lastNotified sql.NullTime
+		if err := rows.Scan(&st.AgentID, &st.Flag, &st.FirstSeenAt, &st.Level, &lastNotified, &st.NotifyCount); err != nil {
+			return nil, fmt.Errorf("scan escalation failed: %w", err)
+		}
+		if lastNotified.Valid {
+			st.LastNotifiedAt = lastNotified.Time
+		}
+		states = append(states, st)
+	}
+	return states, rows.Err()
+}
+
+// SaveEscalation upserts the escalation state for a host flag.
+func (r *Repo) SaveEscalation(ctx context.Context, st EscalationState) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO flag_escalations(agent_id, flag, first_seen_at, level, last_notified_at, notify_count)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT(agent_id, flag) DO UPDATE SET
+		  first_seen_at    = excluded.first_seen_at,
+		  level            = excluded.level,
+		  last_notified_at = excluded.last_notified_at,
+		  notify_count     = excluded.notify_count
+	`, st.AgentID, st.Flag, st.FirstSeenAt, st.Level, st.LastNotifiedAt, st.NotifyCount)
+	if err != nil {
+		return fmt.Errorf("save escalation failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteEscalation clears the escalation state once a flag is no longer active.
+func (r *Repo) DeleteEscalation(ctx context.Context, agentID, flag string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM flag_escalations WHERE agent_id = ? AND flag = ?`, agentID, flag)
+	if err != nil {
+		return fmt.Errorf("delete escalation failed: %w", err)
+	}
+	return nil
+}
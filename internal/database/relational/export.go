@@ -0,0 +1,55 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExportFormats lists the file formats ExportSnapshots writes.
+var ExportFormats = []string{"csv", "json", "parquet"}
+
+// ExportFilter selects the snapshots ExportSnapshots writes.
+type ExportFilter struct {
+	Hostname string
+	Since    time.Time
+}
+
+// ExportSnapshots writes the snapshots matching f, oldest first, to path in
+// format (see ExportFormats) and returns how many were written.
+func (r *Repo) ExportSnapshots(ctx context.Context, path, format string, f ExportFilter) (int64, error) {
+	if !slices.Contains(ExportFormats, format) {
+		return 0, fmt.Errorf("unknown export format %q (want one of %s)", format, strings.Join(ExportFormats, ", "))
+	}
+	// COPY takes neither the target nor the filter as parameters.
+	where := []string{"TRUE"}
+	if f.Hostname != "" {
+		where = append(where, "hostname = "+sqlString(f.Hostname))
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "collected_at >= "+sqlString(f.Since.UTC().Format("2006-01-02 15:04:05.999999"))+"::TIMESTAMP")
+	}
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf(`COPY (SELECT * FROM snapshots WHERE %s ORDER BY collected_at, snapshot_id) TO %s (FORMAT %s)`,
+		strings.Join(where, " AND "), sqlString(path), format))
+	if err != nil {
+		return 0, fmt.Errorf("export snapshots: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// Backup writes the schema and every table to dir as Parquet files. A
+// fresh database is restored from it with IMPORT DATABASE.
+func (r *Repo) Backup(ctx context.Context, dir string) error {
+	if _, err := r.db.ExecContext(ctx, `EXPORT DATABASE `+sqlString(dir)+` (FORMAT parquet)`); err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
+
+// sqlString quotes s as a SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,54 @@
+package relational
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Second)
+	for i, host := range []string{"web-1", "web-1", "db-1"} {
+		s := RawStatsFixed{AgentID: host, Hostname: host, CollectedAt: start.Add(time.Duration(i) * time.Hour)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "it's.csv")
+	n, err := repo.ExportSnapshots(ctx, path, "csv", ExportFilter{Hostname: "web-1", Since: start.Add(30 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("exported %d snapshots, want 1", n)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(b)), "\n"); len(lines) != 2 {
+		t.Errorf("got %d CSV lines, want a header and one row", len(lines))
+	}
+
+	if _, err := repo.ExportSnapshots(ctx, path, "xlsx", ExportFilter{}); err == nil {
+		t.Error("unknown format accepted")
+	}
+
+	backup := filepath.Join(dir, "backup")
+	if err := repo.Backup(ctx, backup); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"schema.sql", "load.sql"} {
+		if _, err := os.Stat(filepath.Join(backup, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
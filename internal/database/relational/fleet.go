@@ -0,0 +1,154 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FleetHost is one host's current state in a fleet summary.
+type FleetHost struct {
+	AgentID       string            `json:"agent_id"`
+	Hostname      string            `json:"hostname,omitempty"`
+	AgentVersion  string            `json:"agent_version,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CollectedAt   time.Time         `json:"collected_at"`
+	SeverityLevel int               `json:"severity_level"`
+	RiskScore     int               `json:"risk_score"`
+	Flags         []string          `json:"flags"`
+	Explanation   string            `json:"explanation,omitempty"`
+}
+
+// FleetSummary aggregates current_state across hosts.
+type FleetSummary struct {
+	Hosts         int64            `json:"hosts"`
+	Disconnected  int64            `json:"disconnected"`
+	WorstSeverity int              `json:"worst_severity"`
+	FlagCounts    map[string]int64 `json:"flag_counts"` // hosts with each active flag; zero counts omitted
+	TopHosts      []FleetHost      `json:"top_hosts"`   // by severity, then risk score
+	// AgentVersions counts hosts per agent build; more than one entry means
+	// a mixed-version fleet. Hosts never reporting a version count as "unknown".
+	AgentVersions map[string]int64 `json:"agent_versions"`
+}
+
+// FleetSummary summarises the current state of every host matching labels
+// (all hosts when empty), returning at most limit of the riskiest hosts.
+// Totals are window aggregates, so a single query yields both.
+func (r *Repo) FleetSummary(ctx context.Context, labels map[string]string, limit int) (*FleetSummary, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	counts := make([]string, len(FlagNames))
+	for i := range FlagNames {
+		counts[i] = fmt.Sprintf("CAST(sum((COALESCE(c.flags_bitmask, 0) >> %d) & 1) OVER () AS BIGINT)", i)
+	}
+	query := `
+		SELECT h.agent_id, h.hostname, h.agent_version, h.labels, c.collected_at,
+		  COALESCE(c.severity_level, 0), COALESCE(c.risk_score, 0),
+		  COALESCE(c.flags_bitmask, 0), c.explanation,
+		  count(*) OVER (),
+		  CAST(sum(CASE WHEN COALESCE(c.is_connected, false) THEN 0 ELSE 1 END) OVER () AS BIGINT),
+		  COALESCE(max(c.severity_level) OVER (), 0),
+		  ` + strings.Join(counts, ",\n\t\t  ") + `
+		FROM current_state c
+		JOIN hosts h ON h.host_id = c.host_id`
+	var args []any
+	if len(labels) > 0 {
+		cond, labelArgs := labelFilter(labels)
+		query += " WHERE " + cond
+		args = append(args, labelArgs...)
+	}
+	query += " ORDER BY 6 DESC, 7 DESC, h.agent_id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fleet summary failed: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &FleetSummary{FlagCounts: map[string]int64{}, TopHosts: []FleetHost{}, AgentVersions: map[string]int64{}}
+	flagCounts := make([]int64, len(FlagNames))
+	for rows.Next() {
+		var (
+			h              FleetHost
+			hostname, expl sql.NullString
+			version        sql.NullString
+			rawLabels      sql.NullString
+			collectedAt    sql.NullTime
+			mask           int64
+			worst          int
+		)
+		dest := []any{&h.AgentID, &hostname, &version, &rawLabels, &collectedAt,
+			&h.SeverityLevel, &h.RiskScore, &mask, &expl,
+			&summary.Hosts, &summary.Disconnected, &worst}
+		for i := range flagCounts {
+			dest = append(dest, &flagCounts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan fleet host failed: %w", err)
+		}
+		if h.Labels, err = decodeLabels(rawLabels); err != nil {
+			return nil, err
+		}
+		h.Hostname, h.AgentVersion, h.Explanation, h.CollectedAt = hostname.String, version.String, expl.String, collectedAt.Time
+		h.Flags = maskFlags(mask)
+		summary.WorstSeverity = worst
+		summary.TopHosts = append(summary.TopHosts, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	for i, n := range flagCounts {
+		if n > 0 {
+			summary.FlagCounts[FlagNames[i]] = n
+		}
+	}
+	if err := r.fleetVersions(ctx, labels, summary.AgentVersions); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// fleetVersions counts the hosts matching labels per agent version into
+// counts.
+func (r *Repo) fleetVersions(ctx context.Context, labels map[string]string, counts map[string]int64) error {
+	query := `
+		SELECT COALESCE(h.agent_version, 'unknown'), count(*)
+		FROM current_state c
+		JOIN hosts h ON h.host_id = c.host_id`
+	var args []any
+	if len(labels) > 0 {
+		cond, labelArgs := labelFilter(labels)
+		query += " WHERE " + cond
+		args = labelArgs
+	}
+	rows, err := r.db.QueryContext(ctx, query+" GROUP BY 1", args...)
+	if err != nil {
+		return fmt.Errorf("fleet versions failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		var n int64
+		if err := rows.Scan(&v, &n); err != nil {
+			return fmt.Errorf("scan fleet version failed: %w", err)
+		}
+		counts[v] = n
+	}
+	return rows.Err()
+}
+
+// maskFlags is the inverse of SnapshotFlags.Mask.
+func maskFlags(mask int64) []string {
+	flags := []string{}
+	for i, name := range FlagNames {
+		if mask&(1<<i) != 0 {
+			flags = append(flags, name)
+		}
+	}
+	return flags
+}
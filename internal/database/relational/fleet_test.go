@@ -0,0 +1,61 @@
+package relational
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFleetSummary(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	hosts := []struct {
+		agent    string
+		severity int
+		flags    SnapshotFlags
+	}{
+		{"web-1", 1, SnapshotFlags{FlagCPUOverloaded: true}},
+		{"web-2", 3, SnapshotFlags{FlagCPUOverloaded: true, FlagMemoryPressure: true}},
+		{"db-1", 0, SnapshotFlags{}},
+	}
+	for _, h := range hosts {
+		h.flags.SeverityLevel = h.severity
+		stats := RawStatsFixed{AgentID: h.agent, Hostname: h.agent, CollectedAt: time.Now(), IsConnected: h.agent != "db-1"}
+		if h.agent != "db-1" {
+			stats.AgentVersion = "v1.2.0"
+		}
+		if _, err := repo.InsertRawStats(ctx, stats, DerivedRates{}, h.flags); err != nil {
+			t.Fatalf("insert %s: %v", h.agent, err)
+		}
+	}
+	if _, err := repo.SetHostLabels(ctx, "db-1", map[string]string{"env": "staging"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.FleetSummary(ctx, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hosts != 3 || got.Disconnected != 1 || got.WorstSeverity != 3 {
+		t.Errorf("totals = %d hosts, %d disconnected, worst %d", got.Hosts, got.Disconnected, got.WorstSeverity)
+	}
+	if want := map[string]int64{"cpu_overloaded": 2, "memory_pressure": 1}; !reflect.DeepEqual(got.FlagCounts, want) {
+		t.Errorf("flag counts = %v, want %v", got.FlagCounts, want)
+	}
+	if len(got.TopHosts) != 2 || got.TopHosts[0].AgentID != "web-2" || len(got.TopHosts[0].Flags) != 2 {
+		t.Errorf("top hosts = %+v", got.TopHosts)
+	}
+	if got.TopHosts[0].AgentVersion != "v1.2.0" {
+		t.Errorf("agent version = %q", got.TopHosts[0].AgentVersion)
+	}
+	if want := map[string]int64{"v1.2.0": 2, "unknown": 1}; !reflect.DeepEqual(got.AgentVersions, want) {
+		t.Errorf("agent versions = %v, want %v", got.AgentVersions, want)
+	}
+
+	staging, err := repo.FleetSummary(ctx, map[string]string{"env": "staging"}, 10)
+	if err != nil || staging.Hosts != 1 || staging.TopHosts[0].AgentID != "db-1" {
+		t.Errorf("env=staging summary = %+v (%v)", staging, err)
+	}
+}
@@ -0,0 +1,13 @@
+package relational
+
+import "time"
+
+// MemoryForecast projects when available memory will run out at the current rate.
+type MemoryForecast struct {
+	AvailableBytes   uint64        `json:"available_bytes"`
+	TrendBytesPerSec float64       `json:"trend_bytes_per_sec"` // negative while memory is being consumed
+	SwapInBps        float64       `json:"swap_in_bps"`
+	TimeToExhaustion time.Duration `json:"time_to_exhaustion"` // 0 when memory is not trending down
+	Samples          int           `json:"samples"`
+	Predicted        bool          `json:"predicted"` // exhaustion expected within the configured horizon
+}
@@ -0,0 +1,69 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HeatmapCell aggregates snapshots for one weekday/hour slot.
+type HeatmapCell struct {
+	MaxSeverity int32 `json:"max_severity"`
+	Samples     int64 `json:"samples"`
+}
+
+// SeverityHeatmap is a day-by-hour grid of max severity. Rows are weekdays
+// (0 = Sunday) and columns are UTC hours.
+type SeverityHeatmap struct {
+	Hostname string             `json:"hostname,omitempty"`
+	Since    time.Time          `json:"since"`
+	Cells    [7][24]HeatmapCell `json:"cells"`
+}
+
+// SeverityHeatmap builds an hour-by-day heatmap of max severity from the
+// hourly rollups, so month-long windows don't scan raw snapshots.
+func (r *Repo) SeverityHeatmap(ctx context.Context, hostname string, since time.Time) (*SeverityHeatmap, error) {
+	if err := r.updateRollups(ctx); err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT
+		  dayofweek(s.hour) AS dow,
+		  hour(s.hour) AS hr,
+		  CAST(COALESCE(MAX(s.severity_level_max), 0) AS INTEGER),
+		  CAST(SUM(s.samples) AS BIGINT)
+		FROM snapshots_hourly s
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.hour >= ?
+	`
+	args := []interface{}{since.UTC().Truncate(time.Hour)}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	query += " GROUP BY dow, hr"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("severity heatmap query failed: %w", err)
+	}
+	defer rows.Close()
+
+	hm := &SeverityHeatmap{Hostname: hostname, Since: since}
+	for rows.Next() {
+		var dow, hr int
+		var cell HeatmapCell
+		if err := rows.Scan(&dow, &hr, &cell.MaxSeverity, &cell.Samples); err != nil {
+			return nil, fmt.Errorf("scan heatmap cell failed: %w", err)
+		}
+		if dow < 0 || dow > 6 || hr < 0 || hr > 23 {
+			continue
+		}
+		hm.Cells[dow][hr] = cell
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return hm, nil
+}
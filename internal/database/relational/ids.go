@@ -0,0 +1,122 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+// EnvNodeID supplies the snowflake node ID when -node-id is not given.
+const EnvNodeID = "SYSCHECKER_NODE_ID"
+
+// IDGenerator produces unique, increasing int64 primary keys.
+type IDGenerator interface {
+	NextID() int64
+}
+
+// Snowflake layout: 41 bits of milliseconds since snowflakeEpoch, 10 bits of node ID,
+// 12 bits of per-millisecond sequence.
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSeq   = 1<<snowflakeSeqBits - 1
+)
+
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake is a snowflake-style IDGenerator. IDs are unique per node and strictly
+// increasing even if the wall clock steps backwards or more than 4096 IDs are
+// requested within one millisecond.
+type Snowflake struct {
+	mu     sync.Mutex
+	node   int64
+	lastMs int64
+	seq    int64
+	clock  clock.Clock
+}
+
+// NewSnowflake creates a generator for the given node ID (masked to 10 bits).
+// A nil clock uses the wall clock.
+func NewSnowflake(node int64, clk clock.Clock) *Snowflake {
+	return &Snowflake{node: node & snowflakeMaxNode, clock: clock.OrReal(clk)}
+}
+
+// NextID returns the next unique ID.
+func (g *Snowflake) NextID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.clock.Now().Sub(snowflakeEpoch).Milliseconds()
+	if ms > g.lastMs {
+		g.lastMs = ms
+		g.seq = 0
+	} else {
+		// Same millisecond or clock went backwards: keep counting from the last timestamp.
+		g.seq++
+		if g.seq > snowflakeMaxSeq {
+			g.lastMs++
+			g.seq = 0
+		}
+	}
+	return g.lastMs<<(snowflakeNodeBits+snowflakeSeqBits) | g.node<<snowflakeSeqBits | g.seq
+}
+
+// ParseNodeID parses a snowflake node ID, which must fit in 10 bits.
+func ParseNodeID(s string) (int64, error) {
+	node, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || node < 0 || node > snowflakeMaxNode {
+		return 0, fmt.Errorf("node ID %q must be between 0 and %d", s, snowflakeMaxNode)
+	}
+	return node, nil
+}
+
+// leaseNodeID returns the node ID leased to holder in this database,
+// leasing the lowest unused one on first use. Holders writing to the same
+// database therefore never share a node ID, and a holder keeps its ID
+// across restarts.
+func (r *Repo) leaseNodeID(ctx context.Context, holder string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var node int64
+	err = tx.QueryRowContext(ctx, `SELECT node_id FROM id_node_leases WHERE holder = ?`, holder).Scan(&node)
+	if err == nil {
+		return node, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("read node lease: %w", err)
+	}
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(MIN(n), -1) FROM range(0, %d) t(n)
+		WHERE n NOT IN (SELECT node_id FROM id_node_leases)
+	`, snowflakeMaxNode+1)).Scan(&node)
+	if err != nil {
+		return 0, fmt.Errorf("find free node ID: %w", err)
+	}
+	if node < 0 {
+		return 0, errors.New("all snowflake node IDs are leased; set one with -node-id")
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO id_node_leases (node_id, holder, leased_at) VALUES (?, ?, ?)`,
+		node, holder, r.clock.Now().UTC()); err != nil {
+		return 0, fmt.Errorf("lease node ID: %w", err)
+	}
+	return node, tx.Commit()
+}
+
+// defaultIDs serves repos that have not leased a node ID yet.
+var defaultIDs IDGenerator = NewSnowflake(0, nil)
+
+// NewID generates a unique ID from the process-wide generator.
+func NewID() int64 {
+	return defaultIDs.NextID()
+}
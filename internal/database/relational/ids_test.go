@@ -0,0 +1,75 @@
+package relational
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestSnowflakeUniqueUnderConcurrency(t *testing.T) {
+	g := NewSnowflake(7, clock.NewFake(time.Now())) // every ID lands in one millisecond
+
+	const workers, perWorker = 8, 2000
+	ids := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ids <- g.NextID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, workers*perWorker)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestSnowflakeMonotonicWhenClockGoesBack(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	g := NewSnowflake(1, clk)
+
+	first := g.NextID()
+	clk.Advance(-time.Hour)
+	second := g.NextID()
+	if second <= first {
+		t.Errorf("expected increasing ids, got %d then %d", first, second)
+	}
+	if node := (second >> snowflakeSeqBits) & snowflakeMaxNode; node != 1 {
+		t.Errorf("node bits = %d, want 1", node)
+	}
+}
+
+func TestLeaseNodeIDUniquePerHolder(t *testing.T) {
+	repo := newTestRepo(t) // Migrate already leased a node ID for this host
+	ctx := context.Background()
+
+	a, err := repo.leaseNodeID(ctx, "web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := repo.leaseNodeID(ctx, "web-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("web-1 and web-2 both leased node %d", a)
+	}
+	if again, _ := repo.leaseNodeID(ctx, "web-1"); again != a {
+		t.Errorf("web-1 re-leased node %d, want its lease %d", again, a)
+	}
+	if _, err := ParseNodeID("1024"); err == nil {
+		t.Error("ParseNodeID should reject IDs beyond 10 bits")
+	}
+}
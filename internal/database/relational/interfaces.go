@@ -3,6 +3,7 @@ package relational
 
 import (
 	"context"
+	"time"
 
 	"syschecker/internal/collector"
 )
@@ -25,6 +26,73 @@ type StatsFlagger interface {
 	Flag(stats *RawStatsFixed, derived *DerivedRates) *SnapshotFlags
 }
 
+// FlagEscalator raises severity for flags that stay active over time.
+type FlagEscalator interface {
+	// Apply updates escalation state for the host and bumps severity/risk in place.
+	Apply(ctx context.Context, agentID string, now time.Time, flags *SnapshotFlags) ([]EscalationNotice, error)
+}
+
+// BaselineObserver learns per-host thresholds from accumulated history.
+type BaselineObserver interface {
+	// Observe returns newly learned thresholds once the learning period has elapsed.
+	Observe(ctx context.Context, agentID string, now time.Time) ([]HostThreshold, error)
+}
+
+// DeviationDetector compares a snapshot against its seasonal expected ranges.
+type DeviationDetector interface {
+	// Detect returns metrics that deviate from their usual hour-of-week level.
+	Detect(ctx context.Context, stats *RawStatsFixed, flags *SnapshotFlags) ([]SeasonalDeviation, error)
+}
+
+// MemoryForecaster projects memory exhaustion from recent snapshots.
+type MemoryForecaster interface {
+	// Forecast records the snapshot and raises the predictive flag when exhaustion is imminent.
+	Forecast(stats *RawStatsFixed, flags *SnapshotFlags) *MemoryForecast
+}
+
+// DiskInvestigator looks for what is filling the disk when space runs out.
+type DiskInvestigator interface {
+	// Investigate returns the largest directories when a scan is due, annotating flags in place.
+	Investigate(ctx context.Context, stats *RawStatsFixed, flags *SnapshotFlags) []DirUsageFixed
+}
+
+// StorageChecker watches the database size.
+type StorageChecker interface {
+	// Check raises the storage budget flag when the database nears its budget.
+	Check(ctx context.Context, flags *SnapshotFlags)
+}
+
+// BurstController switches to high-frequency, high-detail collection while
+// critical flags fire.
+type BurstController interface {
+	// Observe starts or ends a burst for the host and returns the active incident ID, if any.
+	Observe(ctx context.Context, agentID string, now time.Time, flags *SnapshotFlags) (string, error)
+	// Record persists extended detail captured during a burst.
+	Record(ctx context.Context, sample BurstSample) error
+}
+
+// DetailCollector gathers the extended detail recorded during bursts.
+type DetailCollector interface {
+	GetBurstDetail(ctx context.Context) (*collector.BurstDetail, error)
+}
+
+// SnapshotPublisher is told about each snapshot once it is persisted.
+type SnapshotPublisher interface {
+	// Publish pushes the new state, e.g. to streaming clients. It must not block.
+	Publish(res InsertResult, stats *RawStatsFixed, derived *DerivedRates, flags *SnapshotFlags)
+}
+
+// SnapshotRepeater records collections that change-only persistence skipped.
+type SnapshotRepeater interface {
+	// RecordRepeat counts a skipped collection against the snapshot it repeated.
+	RecordRepeat(ctx context.Context, snapshotID int64, at time.Time) error
+}
+
+// HostLabeler looks up the labels attached to a host.
+type HostLabeler interface {
+	HostLabels(ctx context.Context, agentID string) (map[string]string, error)
+}
+
 // StatsRepository persists flagged metrics to storage.
 type StatsRepository interface {
 	// Migrate creates or updates the database schema.
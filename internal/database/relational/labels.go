@@ -0,0 +1,131 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// labelKeyPattern restricts label keys to a safe, query-friendly charset.
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// ParseLabel splits "key=value" and validates the key.
+func ParseLabel(kv string) (key, value string, err error) {
+	key, value, ok := strings.Cut(kv, "=")
+	if !ok {
+		return "", "", fmt.Errorf("label %q: want key=value", kv)
+	}
+	if !labelKeyPattern.MatchString(key) {
+		return "", "", fmt.Errorf("label key %q: use letters, digits, '_', '.' or '-'", key)
+	}
+	return key, value, nil
+}
+
+// FormatLabels renders labels as sorted "key=value" strings.
+func FormatLabels(labels map[string]string) []string {
+	out := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		out = append(out, k+"="+labels[k])
+	}
+	return out
+}
+
+// HostLabels returns the labels of a host; a host without labels, or not
+// yet seen, has none.
+func (r *Repo) HostLabels(ctx context.Context, agentID string) (map[string]string, error) {
+	var raw sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT labels FROM hosts WHERE agent_id = ?`, agentID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read labels of %s: %w", agentID, err)
+	}
+	return decodeLabels(raw)
+}
+
+// AllHostLabels returns the labels of every known host by agent ID.
+func (r *Repo) AllHostLabels(ctx context.Context) (map[string]map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT agent_id, labels FROM hosts ORDER BY agent_id`)
+	if err != nil {
+		return nil, fmt.Errorf("list host labels: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]map[string]string)
+	for rows.Next() {
+		var agentID string
+		var raw sql.NullString
+		if err := rows.Scan(&agentID, &raw); err != nil {
+			return nil, fmt.Errorf("scan host labels: %w", err)
+		}
+		if out[agentID], err = decodeLabels(raw); err != nil {
+			return nil, err
+		}
+	}
+	return out, rows.Err()
+}
+
+// SetHostLabels stores labels for a host, creating it if needed. With
+// replace unset the labels are merged into the existing ones, and an empty
+// value removes its key.
+func (r *Repo) SetHostLabels(ctx context.Context, agentID string, labels map[string]string, replace bool) (map[string]string, error) {
+	for k := range labels {
+		if !labelKeyPattern.MatchString(k) {
+			return nil, fmt.Errorf("label key %q: use letters, digits, '_', '.' or '-'", k)
+		}
+	}
+	if _, err := r.UpsertHost(ctx, agentID, "", "", ""); err != nil {
+		return nil, fmt.Errorf("set labels of %s: %w", agentID, err)
+	}
+	merged := map[string]string{}
+	if !replace {
+		current, err := r.HostLabels(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		merged = current
+	}
+	for k, v := range labels {
+		if v == "" {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("encode labels: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE hosts SET labels = ? WHERE agent_id = ?`, string(data), agentID); err != nil {
+		return nil, fmt.Errorf("set labels of %s: %w", agentID, err)
+	}
+	return merged, nil
+}
+
+func decodeLabels(raw sql.NullString) (map[string]string, error) {
+	labels := map[string]string{}
+	if !raw.Valid || raw.String == "" {
+		return labels, nil
+	}
+	if err := json.Unmarshal([]byte(raw.String), &labels); err != nil {
+		return nil, fmt.Errorf("decode labels: %w", err)
+	}
+	return labels, nil
+}
+
+// labelFilter returns a SQL condition on the hosts alias h requiring every
+// label, and its arguments.
+func labelFilter(labels map[string]string) (string, []any) {
+	var conds []string
+	var args []any
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		conds = append(conds, "json_extract_string(h.labels, ?) = ?")
+		args = append(args, `$."`+k+`"`, labels[k])
+	}
+	return strings.Join(conds, " AND "), args
+}
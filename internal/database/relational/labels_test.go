@@ -0,0 +1,61 @@
+package relational
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHostLabelsFilterSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	for _, agent := range []string{"web-1", "db-1"} {
+		stats := RawStatsFixed{AgentID: agent, Hostname: agent, CollectedAt: time.Now()}
+		if _, err := repo.InsertRawStats(ctx, stats, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatalf("insert %s: %v", agent, err)
+		}
+	}
+	if _, err := repo.SetHostLabels(ctx, "web-1", map[string]string{"env": "prod", "team": "web"}, false); err != nil {
+		t.Fatal(err)
+	}
+	// Merging keeps other keys; an empty value removes one.
+	got, err := repo.SetHostLabels(ctx, "web-1", map[string]string{"team": "", "tier": "1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"env": "prod", "tier": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+	if _, err := repo.SetHostLabels(ctx, "db-1", map[string]string{"env": "staging"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	snaps, err := repo.QuerySnapshotsByLabels(ctx, "", map[string]string{"env": "prod"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 1 || snaps[0].Hostname != "web-1" {
+		t.Errorf("env=prod matched %+v, want web-1 only", snaps)
+	}
+
+	all, err := repo.AllHostLabels(ctx)
+	if err != nil || all["db-1"]["env"] != "staging" {
+		t.Errorf("AllHostLabels = %v (%v)", all, err)
+	}
+	if _, err := repo.SetHostLabels(ctx, "db-1", map[string]string{"bad key": "x"}, false); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	if k, v, err := ParseLabel("team=payments"); err != nil || k != "team" || v != "payments" {
+		t.Errorf("ParseLabel = %q, %q, %v", k, v, err)
+	}
+	for _, bad := range []string{"team", "=x", "a b=c"} {
+		if _, _, err := ParseLabel(bad); err == nil {
+			t.Errorf("ParseLabel(%q): expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// IngestLease records which process may collect a host into this database.
+type IngestLease struct {
+	AgentID    string
+	Holder     string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+}
+
+// LeaseHolder names this process for ingest leases, e.g. "agent@web-1:4242".
+func LeaseHolder(component string) string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s:%d", component, host, os.Getpid())
+}
+
+// AcquireIngestLease takes or renews the lease on agentID for holder until
+// ttl from now, unless another holder's lease is still running. It returns
+// the lease in force afterwards; holder got it if lease.Holder == holder.
+func (r *Repo) AcquireIngestLease(ctx context.Context, agentID, holder string, ttl time.Duration) (IngestLease, error) {
+	now := r.clock.Now().UTC()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return IngestLease{}, err
+	}
+	defer tx.Rollback()
+
+	cur := IngestLease{AgentID: agentID}
+	err = tx.QueryRowContext(ctx, `SELECT holder, acquired_at, expires_at FROM ingest_leases WHERE agent_id = ?`, agentID).
+		Scan(&cur.Holder, &cur.AcquiredAt, &cur.ExpiresAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return IngestLease{}, fmt.Errorf("read ingest lease: %w", err)
+	case cur.Holder != holder && cur.ExpiresAt.After(now):
+		return cur, tx.Commit()
+	}
+
+	next := IngestLease{AgentID: agentID, Holder: holder, AcquiredAt: now, ExpiresAt: now.Add(ttl)}
+	if cur.Holder == holder {
+		next.AcquiredAt = cur.AcquiredAt
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ingest_leases (agent_id, holder, acquired_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (agent_id) DO UPDATE SET
+		  holder = excluded.holder, acquired_at = excluded.acquired_at, expires_at = excluded.expires_at`,
+		agentID, holder, next.AcquiredAt, next.ExpiresAt)
+	if err != nil {
+		return IngestLease{}, fmt.Errorf("take ingest lease: %w", err)
+	}
+	return next, tx.Commit()
+}
+
+// ReleaseIngestLease gives up holder's lease on agentID so another
+// pipeline can take over without waiting for it to expire.
+func (r *Repo) ReleaseIngestLease(ctx context.Context, agentID, holder string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM ingest_leases WHERE agent_id = ? AND holder = ?`, agentID, holder); err != nil {
+		return fmt.Errorf("release ingest lease: %w", err)
+	}
+	return nil
+}
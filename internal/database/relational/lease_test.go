@@ -0,0 +1,51 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestIngestLease(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewDuckDBClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := NewRepo(client.DB(), WithClock(clk))
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := repo.AcquireIngestLease(ctx, "web-1", "agent", time.Minute)
+	if err != nil || l.Holder != "agent" {
+		t.Fatalf("first acquire = %+v, %v", l, err)
+	}
+	if l, _ := repo.AcquireIngestLease(ctx, "web-1", "mcp", time.Minute); l.Holder != "agent" {
+		t.Errorf("a running lease was taken over by %s", l.Holder)
+	}
+	if l, _ := repo.AcquireIngestLease(ctx, "web-2", "mcp", time.Minute); l.Holder != "mcp" {
+		t.Errorf("leases are per host; web-2 held by %s", l.Holder)
+	}
+
+	clk.Advance(30 * time.Second)
+	renewed, err := repo.AcquireIngestLease(ctx, "web-1", "agent", time.Minute)
+	if err != nil || !renewed.AcquiredAt.Equal(l.AcquiredAt) || !renewed.ExpiresAt.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("renewal = %+v, %v", renewed, err)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if l, _ := repo.AcquireIngestLease(ctx, "web-1", "mcp", time.Minute); l.Holder != "mcp" {
+		t.Errorf("an expired lease should pass to the next holder, got %s", l.Holder)
+	}
+	if err := repo.ReleaseIngestLease(ctx, "web-1", "mcp"); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := repo.AcquireIngestLease(ctx, "web-1", "agent", time.Minute); l.Holder != "agent" {
+		t.Errorf("a released lease should be free, got %s", l.Holder)
+	}
+}
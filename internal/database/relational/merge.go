@@ -0,0 +1,447 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MergeResult counts what MergeBackup added.
+type MergeResult struct {
+	Hosts      []string // agent IDs found in the backup
+	NewHosts   int
+	Snapshots  int // snapshots added
+	Duplicates int // snapshots already present, e.g. from an earlier backup of the same agent
+}
+
+// mergeTable is a table whose rows MergeBackup matches against the ones
+// already stored by a natural key, since the IDs of two databases say
+// nothing about each other.
+type mergeTable struct {
+	name string
+	id   string   // primary key; unmatched rows get a new one here
+	key  []string // natural key, compared after remapping the IDs it holds
+}
+
+// mergeTables lists the matched tables, each after the tables its IDs
+// refer to.
+var mergeTables = []mergeTable{
+	{"hosts", "host_id", []string{"agent_id"}},
+	{"disk_devices", "disk_device_id", []string{"host_id", "device"}},
+	{"mountpoints", "mountpoint_id", []string{"host_id", "mountpoint"}},
+	{"net_interfaces", "net_interface_id", []string{"host_id", "name"}},
+	{"temp_sensors", "temp_sensor_id", []string{"host_id", "sensor_key"}},
+	{"docker_containers", "docker_container_key", []string{"host_id", "container_id"}},
+	{"process_names", "process_name_id", []string{"name"}},
+	{"snapshots", "snapshot_id", []string{"host_id", "kind", "collected_at"}},
+	{"oom_events", "event_id", []string{"host_id", "occurred_at", "pid"}},
+}
+
+// mergeRefs names the ID columns whose name differs from the primary key
+// they refer to.
+var mergeRefs = map[string]string{"last_snapshot_id": "snapshot_id"}
+
+// mergeIDBatch is how many new IDs are recorded per statement.
+const mergeIDBatch = 500
+
+// MergeBackup adds the contents of a directory written by Backup on
+// another database, such as an agent's, to this one. Hosts are matched
+// by agent ID, snapshots by host, kind and collection time, and
+// dimensions by name, so merging the same or a later backup again only
+// adds what is new. Every row gets an ID from this database's generator,
+// so agents need not have distinct node IDs.
+//
+// The per-snapshot tables follow their snapshots, and a host's current
+// state is replaced when the backup's is newer. Incidents, bookmarks and
+// artifacts stay on the agent. The agent's host labels are merged into
+// the stored ones, then labels are applied to every host in the backup.
+func (r *Repo) MergeBackup(ctx context.Context, dir string, labels map[string]string) (MergeResult, error) {
+	for k := range labels {
+		if !labelKeyPattern.MatchString(k) {
+			return MergeResult{}, fmt.Errorf("label key %q: use letters, digits, '_', '.' or '-'", k)
+		}
+	}
+	files, err := backupFiles(dir)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	for _, required := range []string{"hosts", "snapshots"} {
+		if files[required] == "" {
+			return MergeResult{}, fmt.Errorf("%s is not a syschecker backup: no %s table", dir, required)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("merge %s: %w", dir, err)
+	}
+	defer tx.Rollback()
+
+	m := &merger{tx: tx, ids: r.ids, files: files, mapped: map[string]bool{}}
+
+	var res MergeResult
+	for _, t := range mergeTables {
+		added, matched, err := m.mergeKeyed(ctx, t)
+		if err != nil {
+			return MergeResult{}, fmt.Errorf("merge %s from %s: %w", t.name, dir, err)
+		}
+		switch t.name {
+		case "hosts":
+			res.NewHosts = added
+		case "snapshots":
+			res.Snapshots, res.Duplicates = added, matched
+		}
+	}
+	if err := m.updateHosts(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("merge hosts from %s: %w", dir, err)
+	}
+	children, err := snapshotChildTables(ctx, tx)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	for _, table := range children {
+		if !strings.HasPrefix(table, "snapshot_") {
+			continue // oom_events is matched above; burst samples stay with their incidents
+		}
+		if err := m.mergeChildren(ctx, table); err != nil {
+			return MergeResult{}, fmt.Errorf("merge %s from %s: %w", table, dir, err)
+		}
+	}
+	if err := m.mergeCurrentState(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("merge current_state from %s: %w", dir, err)
+	}
+	hostLabels, err := m.incomingLabels(ctx)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	if err := m.dropMaps(ctx); err != nil {
+		return MergeResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return MergeResult{}, fmt.Errorf("merge %s: %w", dir, err)
+	}
+
+	for _, agentID := range slices.Sorted(maps.Keys(hostLabels)) {
+		res.Hosts = append(res.Hosts, agentID)
+		own := hostLabels[agentID]
+		maps.Copy(own, labels)
+		if len(own) == 0 {
+			continue
+		}
+		if _, err := r.SetHostLabels(ctx, agentID, own, false); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// copyLine matches the statements of the load.sql file EXPORT DATABASE
+// writes, which name each table's file.
+var copyLine = regexp.MustCompile(`(?i)COPY\s+"?([A-Za-z0-9_]+)"?\s+FROM\s+'([^']+)'`)
+
+// backupFiles maps the table names of the backup in dir to their Parquet
+// files. The paths in load.sql are those of the machine that wrote it, so
+// only their base names are kept.
+func backupFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, "load.sql"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read backup %s: %w", dir, err)
+	}
+	for _, m := range copyLine.FindAllStringSubmatch(string(data), -1) {
+		files[m[1]] = filepath.Join(dir, filepath.Base(m[2]))
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.parquet"))
+	if err != nil {
+		return nil, fmt.Errorf("read backup %s: %w", dir, err)
+	}
+	for _, f := range matches {
+		files[strings.TrimSuffix(filepath.Base(f), ".parquet")] = f
+	}
+	return files, nil
+}
+
+// merger carries one MergeBackup's transaction and ID maps. The map of a
+// primary key column, merge_map_<column>, pairs each ID in the backup
+// with the ID of the same row here and marks the rows added.
+type merger struct {
+	tx     *sql.Tx
+	ids    IDGenerator
+	files  map[string]string
+	mapped map[string]bool // primary key columns with a map
+}
+
+func mapTable(column string) string {
+	return "merge_map_" + column
+}
+
+// dropMaps removes the ID maps, which are temporary tables, so they do not
+// outlive the merge on the pooled connection. A rollback removes them too.
+func (m *merger) dropMaps(ctx context.Context) error {
+	for column := range m.mapped {
+		if _, err := m.tx.ExecContext(ctx, `DROP TABLE `+mapTable(column)); err != nil {
+			return fmt.Errorf("drop %s: %w", mapTable(column), err)
+		}
+	}
+	return nil
+}
+
+// source returns the backup's rows of table as a SELECT of the columns
+// both sides have, with every ID referring to a mapped table replaced by
+// its ID here. Rows whose references are not mapped are left out. The
+// table's own primary key, self, is kept as it is in the backup.
+func (m *merger) source(ctx context.Context, table, self string) (query string, cols []string, err error) {
+	file := m.files[table]
+	if file == "" {
+		return "", nil, nil
+	}
+	have := map[string]bool{}
+	rows, err := m.tx.QueryContext(ctx, `SELECT column_name FROM (DESCRIBE SELECT * FROM read_parquet(`+sqlString(file)+`))`)
+	if err != nil {
+		return "", nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			rows.Close()
+			return "", nil, err
+		}
+		have[c] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	rows, err = m.tx.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_name = ? AND table_schema = current_schema()
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return "", nil, fmt.Errorf("list columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	var exprs, joins []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return "", nil, err
+		}
+		if !have[c] {
+			continue // added here by a later migration than the backup's
+		}
+		cols = append(cols, c)
+		ref := c
+		if to, ok := mergeRefs[c]; ok {
+			ref = to
+		}
+		if c == self || !m.mapped[ref] {
+			exprs = append(exprs, "s."+c)
+			continue
+		}
+		alias := "m_" + c
+		exprs = append(exprs, alias+".dst AS "+c)
+		joins = append(joins, fmt.Sprintf("JOIN %s %s ON %s.src = s.%s", mapTable(ref), alias, alias, c))
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+	query = "SELECT " + strings.Join(exprs, ", ") + " FROM read_parquet(" + sqlString(file) + ") s"
+	if len(joins) > 0 {
+		query += " " + strings.Join(joins, " ")
+	}
+	return query, cols, nil
+}
+
+// mergeKeyed maps the backup's rows of t to the matching stored rows and
+// inserts the others under new IDs. It returns how many rows were added
+// and how many matched.
+func (m *merger) mergeKeyed(ctx context.Context, t mergeTable) (added, matched int, err error) {
+	src, cols, err := m.source(ctx, t.name, t.id)
+	if err != nil || src == "" {
+		return 0, 0, err
+	}
+	mt := mapTable(t.id)
+	if _, err := m.tx.ExecContext(ctx, `CREATE OR REPLACE TEMP TABLE `+mt+` (src BIGINT PRIMARY KEY, dst BIGINT NOT NULL, added BOOLEAN NOT NULL)`); err != nil {
+		return 0, 0, err
+	}
+	m.mapped[t.id] = true
+
+	on := make([]string, len(t.key))
+	for i, k := range t.key {
+		on[i] = "t." + k + " = i." + k
+	}
+	res, err := m.tx.ExecContext(ctx, fmt.Sprintf(
+		`INSERT OR IGNORE INTO %s SELECT i.%s, t.%s, false FROM (%s) i JOIN %s t ON %s`,
+		mt, t.id, t.id, src, t.name, strings.Join(on, " AND ")))
+	if err != nil {
+		return 0, 0, fmt.Errorf("match rows: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	matched = int(n)
+
+	rows, err := m.tx.QueryContext(ctx, fmt.Sprintf(
+		`SELECT i.%s FROM (%s) i WHERE NOT EXISTS (SELECT 1 FROM %s m WHERE m.src = i.%s)`,
+		t.id, src, mt, t.id))
+	if err != nil {
+		return 0, 0, fmt.Errorf("find new rows: %w", err)
+	}
+	var fresh []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		fresh = append(fresh, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(fresh) == 0 {
+		return 0, matched, nil
+	}
+	if err := m.recordNew(ctx, mt, fresh); err != nil {
+		return 0, 0, err
+	}
+
+	sel := make([]string, len(cols))
+	for i, c := range cols {
+		sel[i] = "i." + c
+		if c == t.id {
+			sel[i] = "m.dst"
+		}
+	}
+	if _, err := m.tx.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s (%s) SELECT %s FROM (%s) i JOIN %s m ON m.src = i.%s WHERE m.added`,
+		t.name, strings.Join(cols, ", "), strings.Join(sel, ", "), src, mt, t.id)); err != nil {
+		return 0, 0, fmt.Errorf("insert rows: %w", err)
+	}
+	return len(fresh), matched, nil
+}
+
+// recordNew gives each of the backup's IDs a new one from the generator.
+func (m *merger) recordNew(ctx context.Context, mt string, srcIDs []int64) error {
+	for len(srcIDs) > 0 {
+		batch := srcIDs[:min(len(srcIDs), mergeIDBatch)]
+		srcIDs = srcIDs[len(batch):]
+		values := make([]string, len(batch))
+		args := make([]any, 0, 2*len(batch))
+		for i, id := range batch {
+			values[i] = "(?, ?, true)"
+			args = append(args, id, m.ids.NextID())
+		}
+		if _, err := m.tx.ExecContext(ctx, `INSERT INTO `+mt+` VALUES `+strings.Join(values, ", "), args...); err != nil {
+			return fmt.Errorf("record new IDs: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateHosts refreshes the hostname, IDs and agent version of hosts
+// already stored from the backup's.
+func (m *merger) updateHosts(ctx context.Context) error {
+	_, err := m.tx.ExecContext(ctx, `
+		UPDATE hosts SET
+		  hostname = coalesce(s.hostname, hosts.hostname),
+		  machine_id = coalesce(s.machine_id, hosts.machine_id),
+		  boot_id = coalesce(s.boot_id, hosts.boot_id),
+		  agent_version = coalesce(s.agent_version, hosts.agent_version)
+		FROM read_parquet(`+sqlString(m.files["hosts"])+`) s
+		JOIN `+mapTable("host_id")+` mh ON mh.src = s.host_id
+		WHERE hosts.host_id = mh.dst AND NOT mh.added`)
+	return err
+}
+
+// mergeChildren copies the backup's rows of a per-snapshot table for the
+// snapshots just added.
+func (m *merger) mergeChildren(ctx context.Context, table string) error {
+	src, cols, err := m.source(ctx, table, "")
+	if err != nil || src == "" {
+		return err
+	}
+	_, err = m.tx.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s (%s) %s WHERE m_snapshot_id.added`,
+		table, strings.Join(cols, ", "), src))
+	return err
+}
+
+// mergeCurrentState replaces a host's current state with the backup's
+// when that is newer.
+func (m *merger) mergeCurrentState(ctx context.Context) error {
+	src, cols, err := m.source(ctx, "current_state", "")
+	if err != nil || src == "" {
+		return err
+	}
+	_, err = m.tx.ExecContext(ctx, fmt.Sprintf(
+		`INSERT OR REPLACE INTO current_state (%s) SELECT * FROM (%s) i
+		WHERE NOT EXISTS (SELECT 1 FROM current_state c WHERE c.host_id = i.host_id AND c.collected_at >= i.collected_at)`,
+		strings.Join(cols, ", "), src))
+	return err
+}
+
+// incomingLabels returns the labels of each host in the backup by agent
+// ID.
+func (m *merger) incomingLabels(ctx context.Context) (map[string]map[string]string, error) {
+	rows, err := m.tx.QueryContext(ctx, `SELECT agent_id, labels FROM read_parquet(`+sqlString(m.files["hosts"])+`)`)
+	if err != nil {
+		return nil, fmt.Errorf("read host labels: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]map[string]string{}
+	for rows.Next() {
+		var agentID string
+		var raw sql.NullString
+		if err := rows.Scan(&agentID, &raw); err != nil {
+			return nil, fmt.Errorf("scan host labels: %w", err)
+		}
+		if out[agentID], err = decodeLabels(raw); err != nil {
+			return nil, err
+		}
+	}
+	return out, rows.Err()
+}
+
+// Publish writes a copy of the database to path, replacing it at once, so
+// readers can open it while this process keeps the database locked for
+// writing.
+func (r *Repo) Publish(ctx context.Context, path string) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+	defer conn.Close()
+	var current string
+	if err := conn.QueryRowContext(ctx, `SELECT current_database()`).Scan(&current); err != nil {
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if _, err := conn.ExecContext(ctx, `ATTACH `+sqlString(tmp)+` AS publish`); err != nil {
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+	_, err = conn.ExecContext(ctx, `COPY FROM DATABASE "`+current+`" TO publish`)
+	if _, derr := conn.ExecContext(context.WithoutCancel(ctx), `DETACH publish`); err == nil {
+		err = derr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+	return nil
+}
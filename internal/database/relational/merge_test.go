@@ -0,0 +1,109 @@
+package relational
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMergeBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	// Both agents lease the same node ID, so their IDs collide.
+	var backups []string
+	for _, host := range []string{"web-1", "db-1"} {
+		agent := newTestRepo(t)
+		if _, err := agent.SetHostLabels(ctx, host, map[string]string{"role": host[:len(host)-2]}, false); err != nil {
+			t.Fatal(err)
+		}
+		for i := range 2 {
+			s := RawStatsFixed{
+				AgentID: host, Hostname: host, CollectedAt: start.Add(time.Duration(i) * time.Minute),
+				Partitions: []PartitionUsageFixed{{Mountpoint: "/", Device: "sda1", UsedPercent: 40}},
+			}
+			if _, err := agent.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		backup := filepath.Join(dir, host)
+		if err := agent.Backup(ctx, backup); err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, backup)
+	}
+
+	central := newTestRepo(t)
+	for _, b := range backups {
+		res, err := central.MergeBackup(ctx, b, map[string]string{"env": "prod"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.NewHosts != 1 || res.Snapshots != 2 || res.Duplicates != 0 {
+			t.Errorf("merge %s: %+v, want 1 new host and 2 snapshots", b, res)
+		}
+	}
+	res, err := central.MergeBackup(ctx, backups[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Snapshots != 0 || res.Duplicates != 2 {
+		t.Errorf("second merge: %+v, want 2 duplicates and nothing added", res)
+	}
+
+	for table, want := range map[string]int{"hosts": 2, "snapshots": 4, "snapshot_partition_usage": 4, "mountpoints": 2, "current_state": 2} {
+		var n int
+		if err := central.db.QueryRowContext(ctx, `SELECT count(*) FROM `+table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("%s has %d rows, want %d", table, n, want)
+		}
+	}
+	labels, err := central.HostLabels(ctx, "db-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["role"] != "db" || labels["env"] != "prod" {
+		t.Errorf("labels of db-1 = %v, want role=db and env=prod", labels)
+	}
+
+	if _, err := central.MergeBackup(ctx, t.TempDir(), nil); err == nil {
+		t.Error("merged a directory without a backup")
+	}
+
+	published := filepath.Join(dir, "fleet.db")
+	if err := central.Publish(ctx, published); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(published); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackupFiles(t *testing.T) {
+	dir := t.TempDir()
+	load := "COPY hosts FROM '/var/lib/agent/backup/hosts.parquet' (FORMAT 'parquet');\n" +
+		"COPY \"snapshots\" FROM '/var/lib/agent/backup/snapshots.parquet' (FORMAT 'parquet');\n"
+	if err := os.WriteFile(filepath.Join(dir, "load.sql"), []byte(load), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := backupFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := files["snapshots"], filepath.Join(dir, "snapshots.parquet"); got != want {
+		t.Errorf("snapshots file = %q, want %q", got, want)
+	}
+
+	bare := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bare, "hosts.parquet"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if files, err = backupFiles(bare); err != nil || files["hosts"] == "" {
+		t.Errorf("backupFiles without load.sql = %v, %v", files, err)
+	}
+}
@@ -0,0 +1,42 @@
+package relational
+
+// MetricColumns lists the numeric snapshot columns that can be queried as time series.
+// Queries interpolate these names into SQL, so callers must validate with IsMetricColumn.
+var MetricColumns = []string{
+	"cpu_usage_pct",
+	"cpu_e_cluster_pct",
+	"cpu_p_cluster_pct",
+	"power_total_w",
+	"load_avg_1",
+	"load_avg_5",
+	"load_avg_15",
+	"ram_usage_pct",
+	"ram_available_bytes",
+	"swap_usage_pct",
+	"disk_usage_pct",
+	"inode_usage_pct",
+	"net_latency_ms",
+	"active_tcp",
+	"disk_read_bps",
+	"disk_write_bps",
+	"disk_read_iops",
+	"disk_write_iops",
+	"disk_avg_read_lat_ms",
+	"disk_avg_write_lat_ms",
+	"net_tx_bps",
+	"net_rx_bps",
+	"net_err_per_s",
+	"net_drop_per_s",
+	"severity_level",
+	"risk_score",
+}
+
+// IsMetricColumn reports whether name is an allowed numeric snapshot column.
+func IsMetricColumn(name string) bool {
+	for _, m := range MetricColumns {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
@@ -89,6 +89,7 @@ type Snapshot struct {
 	SwapUsagePct   float64
 	SwapTotalBytes int64
 	SwapUsedBytes  int64
+	SwapInBytes    int64
 
 	// ---- Raw Disk root "/" (bytes + inodes) ----
 	DiskUsagePct   float64
@@ -100,6 +101,10 @@ type Snapshot struct {
 	NetLatencyMS float64
 	IsConnected  bool
 	ActiveTCP    int32
+	NetJitterMS  float64 // over the prober window
+	NetLossPct   float64
+	VPNInterface string
+	NetDirectMS  float64 // via the physical interface when a VPN is up
 
 	// ---- Docker availability ----
 	DockerAvailable bool
@@ -111,6 +116,30 @@ type Snapshot struct {
 	UptimeSeconds int64
 	Procs         int64
 
+	// ---- Cgroup limits (0 = unlimited) ----
+	Containerized       bool
+	CgroupMemLimitBytes int64
+	CgroupCPULimit      float64
+
+	// ---- Environment profile ----
+	Environment   string // bare-metal|vm|wsl|cloud
+	Hypervisor    string
+	CloudProvider string
+	LatencyNAT    bool
+
+	// ---- SoC power (macOS powermetrics) ----
+	CPUEClusterPct  float64
+	CPUPClusterPct  float64
+	PowerCPUWatts   float64
+	PowerGPUWatts   float64
+	PowerANEWatts   float64
+	PowerTotalWatts float64
+	ThermalPressure string // Nominal|Moderate|Heavy|Trapping|Sleeping
+
+	// ---- Raspberry Pi firmware ----
+	ThrottledBits int32 // get_throttled bitmask
+	CoreVolts     float64
+
 	// ---- Derived rates (from deltas of counters) ----
 	DiskReadBps       float64
 	DiskWriteBps      float64
@@ -129,32 +158,41 @@ type Snapshot struct {
 	RiskScore     int32 // 0..100
 	FlagsBitmask  int64
 
-	PrimaryCause    string // cpu|memory|disk|network|docker|thermal|unknown
-	CauseEntityType string // container|process|disk|netif|mount|sensor|none
+	PrimaryCause    string // cpu|memory|disk|network|docker|thermal|power|unknown
+	CauseEntityType string // container|process|disk|netif|mount|sensor|file|directory|user|none
 	CauseEntityKey  string // container_id, process name, device, interface name...
 	Explanation     string // short human explanation
 
 	// ---- Boolean flags (fast WHERE filtering) ----
-	FlagHostOffline             bool
-	FlagCPUOverloaded           bool
-	FlagMemoryPressure          bool
-	FlagMemoryStarvation        bool
-	FlagSwapThrashing           bool
-	FlagDiskSpaceCritical       bool
-	FlagInodeExhaustion         bool
-	FlagDiskIOSaturation        bool
-	FlagDiskHealthFailed        bool
-	FlagNetworkLatencyDegraded  bool
-	FlagNetworkPacketLoss       bool
-	FlagNetworkInterfaceErrors  bool
-	FlagDockerUnavailable       bool
-	FlagContainerCPUHog         bool
-	FlagContainerMemoryPressure bool
-	FlagContainerOOMRisk        bool
-	FlagRunawayProcessCPU       bool
-	FlagRunawayProcessMemory    bool
-	FlagThermalPressure         bool
-	FlagSystemAtRisk            bool
+	FlagHostOffline               bool
+	FlagCPUOverloaded             bool
+	FlagMemoryPressure            bool
+	FlagMemoryStarvation          bool
+	FlagSwapThrashing             bool
+	FlagDiskSpaceCritical         bool
+	FlagInodeExhaustion           bool
+	FlagDiskIOSaturation          bool
+	FlagDiskHealthFailed          bool
+	FlagNetworkLatencyDegraded    bool
+	FlagNetworkPacketLoss         bool
+	FlagNetworkInterfaceErrors    bool
+	FlagDockerUnavailable         bool
+	FlagContainerCPUHog           bool
+	FlagContainerMemoryPressure   bool
+	FlagContainerOOMRisk          bool
+	FlagRunawayProcessCPU         bool
+	FlagRunawayProcessMemory      bool
+	FlagThermalPressure           bool
+	FlagSystemAtRisk              bool
+	FlagMemoryExhaustionPredicted bool
+	FlagUserResourceHog           bool
+	FlagUnderVoltage              bool
+	FlagLinkDegraded              bool
+	FlagLinkSaturated             bool
+	FlagCheckFailed               bool
+	FlagStorageBudget             bool
+	FlagGPUOverloaded             bool
+	FlagGPUMemoryPressure         bool
 
 	CreatedAt time.Time
 }
@@ -242,6 +280,9 @@ type SnapshotTopProcess struct {
 	ProcessNameID int64
 	CPUPct        float64
 	MemPct        float32
+	Cmdline       string // redacted
+	Cgroup        string
+	ContainerID   string // owning Docker container
 	// Top processes mapped to process name dictionary
 }
 
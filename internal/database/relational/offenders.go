@@ -0,0 +1,164 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// topOffenderLimit caps the number of entries returned per entity kind.
+const topOffenderLimit = 5
+
+// Offender is an entity that repeatedly caused flags or dominated resource usage.
+type Offender struct {
+	Key        string  `json:"key"`
+	Label      string  `json:"label"`
+	CauseCount int64   `json:"cause_count"` // snapshots naming it as the flag cause
+	TopCount   int64   `json:"top_count"`   // snapshots where it was the top consumer
+	AvgValue   float64 `json:"avg_value"`   // average of Metric while it was top
+	PeakValue  float64 `json:"peak_value"`
+	Metric     string  `json:"metric"`
+}
+
+// TopOffendersReport groups offenders by entity kind.
+type TopOffendersReport struct {
+	Since      time.Time  `json:"since"`
+	Processes  []Offender `json:"processes"`
+	Containers []Offender `json:"containers"`
+	Disks      []Offender `json:"disks"`
+	Interfaces []Offender `json:"interfaces"`
+}
+
+// offenderQuery describes how to rank one entity kind. Candidates must select
+// (snapshot_id, key, label, value) and take the window start as its only parameter.
+type offenderQuery struct {
+	causeType  string
+	metric     string
+	candidates string
+}
+
+var (
+	processOffenders = offenderQuery{
+		causeType: "process",
+		metric:    "cpu_pct",
+		candidates: `
+			SELECT tp.snapshot_id, pn.name AS key, pn.name AS label, tp.cpu_pct AS value
+			FROM snapshot_top_processes tp
+			JOIN process_names pn ON pn.process_name_id = tp.process_name_id
+			JOIN snapshots s ON s.snapshot_id = tp.snapshot_id
+			WHERE s.collected_at >= ?`,
+	}
+	containerOffenders = offenderQuery{
+		causeType: "container",
+		metric:    "cpu_pct",
+		candidates: `
+			SELECT c.snapshot_id, dc.container_id AS key, COALESCE(c.name, dc.container_id) AS label, c.cpu_usage_pct AS value
+			FROM snapshot_docker_container_stats c
+			JOIN docker_containers dc ON dc.docker_container_key = c.docker_container_key
+			JOIN snapshots s ON s.snapshot_id = c.snapshot_id
+			WHERE s.collected_at >= ?`,
+	}
+	diskOffenders = offenderQuery{
+		causeType: "disk",
+		metric:    "bytes_per_sec",
+		candidates: `
+			SELECT io.snapshot_id, dd.device AS key, dd.device AS label,
+			  (CAST(io.read_bytes AS DOUBLE) + CAST(io.write_bytes AS DOUBLE)
+			    - LAG(CAST(io.read_bytes AS DOUBLE) + CAST(io.write_bytes AS DOUBLE)) OVER w)
+			    / NULLIF(epoch(s.collected_at) - LAG(epoch(s.collected_at)) OVER w, 0) AS value
+			FROM snapshot_disk_io io
+			JOIN disk_devices dd ON dd.disk_device_id = io.disk_device_id
+			JOIN snapshots s ON s.snapshot_id = io.snapshot_id
+			WHERE s.collected_at >= ?
+			WINDOW w AS (PARTITION BY io.disk_device_id ORDER BY s.collected_at)`,
+	}
+	interfaceOffenders = offenderQuery{
+		causeType: "netif",
+		metric:    "bytes_per_sec",
+		candidates: `
+			SELECT n.snapshot_id, ni.name AS key, ni.name AS label,
+			  (CAST(n.bytes_sent AS DOUBLE) + CAST(n.bytes_recv AS DOUBLE)
+			    - LAG(CAST(n.bytes_sent AS DOUBLE) + CAST(n.bytes_recv AS DOUBLE)) OVER w)
+			    / NULLIF(epoch(s.collected_at) - LAG(epoch(s.collected_at)) OVER w, 0) AS value
+			FROM snapshot_net_interface_stats n
+			JOIN net_interfaces ni ON ni.net_interface_id = n.net_interface_id
+			JOIN snapshots s ON s.snapshot_id = n.snapshot_id
+			WHERE s.collected_at >= ?
+			WINDOW w AS (PARTITION BY n.net_interface_id ORDER BY s.collected_at)`,
+	}
+)
+
+// TopOffenders returns the processes, containers, disks, and interfaces that most
+// often appeared as flag causes or top consumers over the last window.
+func (r *Repo) TopOffenders(ctx context.Context, window time.Duration) (*TopOffendersReport, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+
+	report := &TopOffendersReport{Since: r.clock.Now().Add(-window)}
+	var err error
+	if report.Processes, err = r.topOffenders(ctx, processOffenders, report.Since); err != nil {
+		return nil, err
+	}
+	if report.Containers, err = r.topOffenders(ctx, containerOffenders, report.Since); err != nil {
+		return nil, err
+	}
+	if report.Disks, err = r.topOffenders(ctx, diskOffenders, report.Since); err != nil {
+		return nil, err
+	}
+	if report.Interfaces, err = r.topOffenders(ctx, interfaceOffenders, report.Since); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *Repo) topOffenders(ctx context.Context, q offenderQuery, since time.Time) ([]Offender, error) {
+	query := fmt.Sprintf(`
+		WITH candidates AS (%s),
+		top AS (
+		  SELECT snapshot_id, key, label, value FROM candidates
+		  WHERE value IS NOT NULL AND value > 0
+		  QUALIFY row_number() OVER (PARTITION BY snapshot_id ORDER BY value DESC) = 1
+		),
+		tops AS (
+		  SELECT key, max(label) AS label, COUNT(*) AS top_count, avg(value) AS avg_value, max(value) AS peak_value
+		  FROM top GROUP BY key
+		),
+		causes AS (
+		  SELECT cause_entity_key AS key, COUNT(*) AS cause_count
+		  FROM snapshots
+		  WHERE collected_at >= ? AND cause_entity_type = ? AND cause_entity_key IS NOT NULL
+		  GROUP BY cause_entity_key
+		)
+		SELECT
+		  COALESCE(t.key, c.key),
+		  COALESCE(t.label, c.key),
+		  COALESCE(c.cause_count, 0),
+		  COALESCE(t.top_count, 0),
+		  COALESCE(t.avg_value, 0),
+		  COALESCE(t.peak_value, 0)
+		FROM tops t
+		FULL OUTER JOIN causes c ON t.key = c.key
+		ORDER BY 3 DESC, 4 DESC
+		LIMIT ?
+	`, q.candidates)
+
+	rows, err := r.db.QueryContext(ctx, query, since, since, q.causeType, topOffenderLimit)
+	if err != nil {
+		return nil, fmt.Errorf("top %s offenders failed: %w", q.causeType, err)
+	}
+	defer rows.Close()
+
+	out := []Offender{}
+	for rows.Next() {
+		o := Offender{Metric: q.metric}
+		if err := rows.Scan(&o.Key, &o.Label, &o.CauseCount, &o.TopCount, &o.AvgValue, &o.PeakValue); err != nil {
+			return nil, fmt.Errorf("scan %s offender failed: %w", q.causeType, err)
+		}
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return out, nil
+}
@@ -17,8 +17,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"syschecker/internal/chaos"
+	"syschecker/internal/clock"
 )
 
 // =============================================================================
@@ -32,6 +37,8 @@ CREATE TABLE IF NOT EXISTS hosts (
   machine_id     VARCHAR,
   boot_id        VARCHAR,
   hostname       VARCHAR,
+  labels         VARCHAR, -- JSON object of key/value labels
+  agent_version  VARCHAR, -- build of the agent that last wrote the host's snapshots
   created_at     TIMESTAMP NOT NULL DEFAULT now()
 );
 
@@ -82,6 +89,7 @@ CREATE TABLE IF NOT EXISTS snapshots (
   host_id            BIGINT NOT NULL,
   kind               VARCHAR NOT NULL,
   collected_at       TIMESTAMP NOT NULL,
+  schema_version     VARCHAR,
 
   cpu_usage_pct      DOUBLE,
   load_avg_1         DOUBLE,
@@ -101,6 +109,7 @@ CREATE TABLE IF NOT EXISTS snapshots (
   swap_usage_pct     DOUBLE,
   swap_total_bytes   BIGINT,
   swap_used_bytes    BIGINT,
+  swap_in_bytes      UBIGINT,
 
   disk_usage_pct     DOUBLE,
   disk_total_bytes   BIGINT,
@@ -110,6 +119,10 @@ CREATE TABLE IF NOT EXISTS snapshots (
   net_latency_ms     DOUBLE,
   is_connected       BOOLEAN,
   active_tcp         INTEGER,
+  net_jitter_ms      DOUBLE,
+  net_loss_pct       DOUBLE,
+  vpn_interface      VARCHAR,
+  net_direct_ms      DOUBLE,
 
   docker_available   BOOLEAN,
 
@@ -119,6 +132,26 @@ CREATE TABLE IF NOT EXISTS snapshots (
   uptime_seconds     BIGINT,
   procs              BIGINT,
 
+  containerized      BOOLEAN,
+  cgroup_mem_limit_bytes UBIGINT, -- 0 = unlimited
+  cgroup_cpu_limit   DOUBLE,      -- cores, 0 = unlimited
+
+  environment        VARCHAR,     -- bare-metal|vm|wsl|cloud
+  hypervisor         VARCHAR,
+  cloud_provider     VARCHAR,
+  latency_nat        BOOLEAN,
+
+  cpu_e_cluster_pct  DOUBLE,
+  cpu_p_cluster_pct  DOUBLE,
+  power_cpu_w        DOUBLE,
+  power_gpu_w        DOUBLE,
+  power_ane_w        DOUBLE,
+  power_total_w      DOUBLE,
+  thermal_pressure   VARCHAR,
+
+  throttled_bits     UINTEGER,
+  core_volts         DOUBLE,
+
   disk_read_bps      DOUBLE,
   disk_write_bps     DOUBLE,
   disk_read_iops     DOUBLE,
@@ -160,6 +193,19 @@ CREATE TABLE IF NOT EXISTS snapshots (
   flag_runaway_process_memory    BOOLEAN,
   flag_thermal_pressure          BOOLEAN,
   flag_system_at_risk            BOOLEAN,
+  flag_memory_exhaustion_predicted BOOLEAN,
+  flag_user_resource_hog         BOOLEAN,
+  flag_under_voltage             BOOLEAN,
+  flag_link_degraded             BOOLEAN,
+  flag_link_saturated            BOOLEAN,
+  flag_check_failed              BOOLEAN,
+  flag_storage_budget            BOOLEAN,
+  flag_gpu_overloaded            BOOLEAN,
+  flag_gpu_memory_pressure       BOOLEAN,
+
+  repeat_count       INTEGER,   -- later collections skipped as unchanged
+  last_repeat_at     TIMESTAMP,
+  trace_id           VARCHAR,   -- collection cycle, shared with graph nodes, alerts and logs
 
   created_at         TIMESTAMP NOT NULL DEFAULT now()
 );
@@ -184,12 +230,12 @@ CREATE TABLE IF NOT EXISTS snapshot_partition_usage (
 CREATE TABLE IF NOT EXISTS snapshot_disk_io (
   snapshot_id     BIGINT NOT NULL,
   disk_device_id  BIGINT NOT NULL,
-  read_bytes      BIGINT,
-  write_bytes     BIGINT,
-  read_count      BIGINT,
-  write_count     BIGINT,
-  read_time_ms    BIGINT,
-  write_time_ms   BIGINT,
+  read_bytes      UBIGINT,
+  write_bytes     UBIGINT,
+  read_count      UBIGINT,
+  write_count     UBIGINT,
+  read_time_ms    UBIGINT,
+  write_time_ms   UBIGINT,
   PRIMARY KEY(snapshot_id, disk_device_id)
 );
 
@@ -204,14 +250,20 @@ CREATE TABLE IF NOT EXISTS snapshot_disk_health (
 CREATE TABLE IF NOT EXISTS snapshot_net_interface_stats (
   snapshot_id       BIGINT NOT NULL,
   net_interface_id  BIGINT NOT NULL,
-  bytes_sent        BIGINT,
-  bytes_recv        BIGINT,
-  packets_sent      BIGINT,
-  packets_recv      BIGINT,
-  err_in            BIGINT,
-  err_out           BIGINT,
-  drop_in           BIGINT,
-  drop_out          BIGINT,
+  bytes_sent        UBIGINT,
+  bytes_recv        UBIGINT,
+  packets_sent      UBIGINT,
+  packets_recv      UBIGINT,
+  err_in            UBIGINT,
+  err_out           UBIGINT,
+  drop_in           UBIGINT,
+  drop_out          UBIGINT,
+  oper_state        VARCHAR,
+  speed_mbps        INTEGER,
+  max_speed_mbps    INTEGER,
+  duplex            VARCHAR,
+  carrier_changes   UBIGINT,
+  util_pct          DOUBLE,
   PRIMARY KEY(snapshot_id, net_interface_id)
 );
 
@@ -243,9 +295,88 @@ CREATE TABLE IF NOT EXISTS snapshot_top_processes (
   process_name_id   BIGINT NOT NULL,
   cpu_pct           DOUBLE,
   mem_pct           REAL,
+  cmdline           VARCHAR, -- redacted
+  cgroup            VARCHAR,
+  container_id      VARCHAR,
+  PRIMARY KEY(snapshot_id, rank)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_log_growth (
+  snapshot_id  BIGINT NOT NULL,
+  rank         INTEGER NOT NULL,
+  path         VARCHAR NOT NULL,
+  size_bytes   UBIGINT,
+  growth_bps   DOUBLE,
   PRIMARY KEY(snapshot_id, rank)
 );
 
+CREATE TABLE IF NOT EXISTS snapshot_largest_dirs (
+  snapshot_id  BIGINT NOT NULL,
+  rank         INTEGER NOT NULL,
+  path         VARCHAR NOT NULL,
+  size_bytes   UBIGINT NOT NULL,
+  PRIMARY KEY(snapshot_id, rank)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_temp_usage (
+  snapshot_id  BIGINT NOT NULL,
+  path         VARCHAR NOT NULL,
+  kind         VARCHAR NOT NULL,
+  files        INTEGER,
+  total_bytes  UBIGINT,
+  stale_bytes  UBIGINT,
+  PRIMARY KEY(snapshot_id, path)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_check_results (
+  snapshot_id  BIGINT NOT NULL,
+  name         VARCHAR NOT NULL,
+  ok           BOOLEAN NOT NULL,
+  exit_code    INTEGER,
+  duration_ms  DOUBLE,
+  value        DOUBLE,
+  output       VARCHAR,
+  severity     INTEGER,
+  PRIMARY KEY(snapshot_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_user_usage (
+  snapshot_id  BIGINT NOT NULL,
+  uid          INTEGER NOT NULL,
+  username     VARCHAR,
+  processes    INTEGER,
+  cpu_pct      DOUBLE,
+  mem_pct      DOUBLE,
+  rss_bytes    UBIGINT,
+  PRIMARY KEY(snapshot_id, uid)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_gpu_stats (
+  snapshot_id      BIGINT NOT NULL,
+  gpu_index        INTEGER NOT NULL,
+  vendor           VARCHAR,
+  name             VARCHAR,
+  util_pct         DOUBLE,
+  mem_used_bytes   UBIGINT,
+  mem_total_bytes  UBIGINT,
+  temperature_c    DOUBLE,
+  power_w          DOUBLE,
+  PRIMARY KEY(snapshot_id, gpu_index)
+);
+
+CREATE TABLE IF NOT EXISTS oom_events (
+  event_id          BIGINT PRIMARY KEY,
+  host_id           BIGINT NOT NULL,
+  snapshot_id       BIGINT NOT NULL,
+  occurred_at       TIMESTAMP NOT NULL,
+  pid               INTEGER NOT NULL,
+  process_name_id   BIGINT NOT NULL,
+  cgroup            VARCHAR,
+  uid               INTEGER,
+  anon_rss_bytes    UBIGINT,
+  oom_constraint    VARCHAR
+);
+
 CREATE TABLE IF NOT EXISTS current_state (
   host_id          BIGINT PRIMARY KEY,
   last_snapshot_id BIGINT,
@@ -274,6 +405,80 @@ CREATE TABLE IF NOT EXISTS current_state (
 
   updated_at       TIMESTAMP NOT NULL DEFAULT now()
 );
+
+CREATE TABLE IF NOT EXISTS flag_escalations (
+  agent_id         VARCHAR NOT NULL,
+  flag             VARCHAR NOT NULL,
+  first_seen_at    TIMESTAMP NOT NULL,
+  level            INTEGER NOT NULL DEFAULT 0,
+  last_notified_at TIMESTAMP,
+  notify_count     INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(agent_id, flag)
+);
+
+CREATE TABLE IF NOT EXISTS host_thresholds (
+  agent_id   VARCHAR NOT NULL,
+  metric     VARCHAR NOT NULL,
+  warning    DOUBLE NOT NULL,
+  critical   DOUBLE NOT NULL,
+  applied    BOOLEAN NOT NULL DEFAULT false,
+  learned_at TIMESTAMP NOT NULL,
+  PRIMARY KEY(agent_id, metric)
+);
+
+CREATE TABLE IF NOT EXISTS bursts (
+  incident_id   VARCHAR PRIMARY KEY,
+  agent_id      VARCHAR NOT NULL,
+  started_at    TIMESTAMP NOT NULL,
+  ends_at       TIMESTAMP NOT NULL,
+  ended_at      TIMESTAMP,
+  trigger_flags VARCHAR,
+  severity      INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS burst_samples (
+  incident_id  VARCHAR NOT NULL,
+  snapshot_id  BIGINT NOT NULL,
+  collected_at TIMESTAMP NOT NULL,
+  processes    VARCHAR, -- JSON array of collector.ProcessStat
+  connections  VARCHAR, -- JSON array of collector.ConnStat
+  PRIMARY KEY(incident_id, snapshot_id)
+);
+
+CREATE TABLE IF NOT EXISTS artifacts (
+  artifact_id BIGINT PRIMARY KEY,
+  snapshot_id BIGINT,
+  kind        VARCHAR NOT NULL,
+  mime        VARCHAR NOT NULL,
+  name        VARCHAR,
+  size_bytes  UBIGINT NOT NULL,
+  data        BLOB,     -- inline payload
+  path        VARCHAR,  -- payload file when above the inline limit
+  created_at  TIMESTAMP NOT NULL
+);
+
+-- Snowflake node IDs handed out to the agents writing to this database.
+CREATE TABLE IF NOT EXISTS id_node_leases (
+  node_id   INTEGER PRIMARY KEY,
+  holder    VARCHAR NOT NULL UNIQUE, -- hostname
+  leased_at TIMESTAMP NOT NULL
+);
+
+-- The one ingestion pipeline allowed to collect each host, renewed every cycle.
+CREATE TABLE IF NOT EXISTS ingest_leases (
+  agent_id    VARCHAR PRIMARY KEY,
+  holder      VARCHAR NOT NULL, -- process, e.g. "agent@web-1:4242"
+  acquired_at TIMESTAMP NOT NULL,
+  expires_at  TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS annotations (
+  annotation_id BIGINT PRIMARY KEY,
+  snapshot_id   BIGINT NOT NULL,
+  kind          VARCHAR NOT NULL, -- e.g. bookmark
+  note          VARCHAR,
+  created_at    TIMESTAMP NOT NULL
+);
 `
 
 // =============================================================================
@@ -281,8 +486,18 @@ CREATE TABLE IF NOT EXISTS current_state (
 // =============================================================================
 
 type Repo struct {
-	db *sql.DB
-	mu sync.RWMutex
+	db     *sql.DB
+	ids    IDGenerator
+	nodeID int64 // configured snowflake node ID, or -1 to lease one on Migrate
+	clock  clock.Clock
+	mu     sync.RWMutex
+
+	artifactLimits    ArtifactLimits
+	lastArtifactPrune time.Time
+	budget            StorageBudget
+	lastBudgetCheck   time.Time
+	lastRollup        time.Time
+	rollupMu          sync.Mutex // serializes RefreshRollups
 	// Simple in-memory cache for dimensions to reduce DB round-trips
 	cache map[int64]*hostCache
 }
@@ -296,25 +511,127 @@ type hostCache struct {
 	procName   map[string]int64
 }
 
-func NewRepo(db *sql.DB) *Repo {
-	return &Repo{
-		db:    db,
-		cache: make(map[int64]*hostCache),
+// RepoOption configures a Repo.
+type RepoOption func(*Repo)
+
+// WithClock sets the clock used for relative query windows (defaults to the wall clock).
+func WithClock(c clock.Clock) RepoOption {
+	return func(r *Repo) {
+		r.clock = clock.OrReal(c)
+	}
+}
+
+// WithArtifactLimits overrides the artifact size caps, retention and spill directory.
+func WithArtifactLimits(l ArtifactLimits) RepoOption {
+	return func(r *Repo) {
+		r.artifactLimits = l
 	}
 }
 
+// WithIDGenerator overrides the primary key generator (defaults to a
+// snowflake whose node ID is leased from the database on Migrate).
+func WithIDGenerator(g IDGenerator) RepoOption {
+	return func(r *Repo) {
+		r.ids = g
+	}
+}
+
+// WithNodeID fixes the snowflake node ID instead of leasing one. Agents
+// whose databases are later merged need distinct node IDs, since leases
+// are only unique within one database.
+func WithNodeID(node int64) RepoOption {
+	return func(r *Repo) {
+		r.nodeID = node & snowflakeMaxNode
+	}
+}
+
+func NewRepo(db *sql.DB, opts ...RepoOption) *Repo {
+	r := &Repo{
+		db:     db,
+		ids:    defaultIDs,
+		nodeID: -1,
+		clock:  clock.Real,
+		cache:  make(map[int64]*hostCache),
+
+		artifactLimits: DefaultArtifactLimits(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.ids == defaultIDs && r.nodeID >= 0 {
+		r.ids = NewSnowflake(r.nodeID, r.clock)
+	}
+	return r
+}
+
 func (r *Repo) Close() error {
 	return r.db.Close()
 }
 
+// Ping checks that the database still answers.
+func (r *Repo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Checkpoint writes the write-ahead log into the database file.
+func (r *Repo) Checkpoint(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `CHECKPOINT`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
+
 func (r *Repo) Migrate(ctx context.Context) error {
-	_, err := r.db.ExecContext(ctx, SchemaSQL)
-	return err
+	if _, err := r.db.ExecContext(ctx, SchemaSQL); err != nil {
+		return err
+	}
+	for _, stmt := range schemaMigrations {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %q: %w", stmt, err)
+		}
+	}
+	if _, err := r.db.ExecContext(ctx, flagMacrosSQL()); err != nil {
+		return fmt.Errorf("flag macros: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, rollupTableSQL()); err != nil {
+		return fmt.Errorf("hourly rollup table: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, containerRollupTableSQL); err != nil {
+		return fmt.Errorf("container rollup table: %w", err)
+	}
+	if err := r.migrateCounterColumns(ctx); err != nil {
+		return err
+	}
+	// Without a configured node ID, take a lease so no other agent writing
+	// to this database generates the same primary keys.
+	if r.ids == defaultIDs {
+		holder, _ := os.Hostname()
+		node, err := r.leaseNodeID(ctx, holder)
+		if err != nil {
+			return err
+		}
+		r.ids = NewSnowflake(node, r.clock)
+	}
+	return nil
 }
 
-// NewID generates a unique ID (time-based).
-func NewID() int64 {
-	return time.Now().UnixNano()
+// flagMacrosSQL defines SQL helpers over flags_bitmask, rebuilt from
+// FlagNames on every migration so they follow new flags:
+//
+//	has_flag(mask, name)  true when the named flag's bit is set
+//	flag_names(mask)      the names of all set flags, in FlagNames order
+func flagMacrosSQL() string {
+	quoted := make([]string, len(FlagNames))
+	for i, name := range FlagNames {
+		quoted[i] = "'" + name + "'"
+	}
+	names := "[" + strings.Join(quoted, ", ") + "]"
+	return fmt.Sprintf(`
+CREATE OR REPLACE MACRO has_flag(mask, name) AS
+  COALESCE(((mask >> (list_position(%[1]s, name) - 1)) & 1) = 1, false);
+CREATE OR REPLACE MACRO flag_names(mask) AS
+  list_transform(list_filter(range(%[2]d), i -> ((COALESCE(mask, 0) >> i) & 1) = 1), i -> %[1]s[i + 1]);
+`, names, len(FlagNames))
 }
 
 // UpsertHost ensures the host exists and returns its ID.
@@ -339,9 +656,9 @@ func (r *Repo) UpsertHost(ctx context.Context, agentID, machineID, bootID, hostn
 		return 0, err
 	}
 
-	hostID = NewID()
-	_, err = r.db.ExecContext(ctx, `INSERT INTO hosts(host_id, agent_id, machine_id, boot_id, hostname) VALUES(?,?,?,?,?)`,
-		hostID, agentID, nullEmpty(machineID), nullEmpty(bootID), nullEmpty(hostname),
+	hostID = r.ids.NextID()
+	_, err = r.db.ExecContext(ctx, `INSERT INTO hosts(host_id, agent_id, machine_id, boot_id, hostname, created_at) VALUES(?,?,?,?,?,?)`,
+		hostID, agentID, nullEmpty(machineID), nullEmpty(bootID), nullEmpty(hostname), r.clock.Now().UTC(),
 	)
 	if err != nil {
 		// Race condition fallback
@@ -375,30 +692,46 @@ func (r *Repo) GetDerivedRates(ctx context.Context, current RawStatsFixed) (*Der
 
 // InsertRawStats persists the snapshot.
 func (r *Repo) InsertRawStats(ctx context.Context, s RawStatsFixed, d DerivedRates, f SnapshotFlags) (InsertResult, error) {
+	if err := chaos.Err(chaos.DBWriteError); err != nil {
+		return InsertResult{}, err
+	}
+	f.Bitmask = f.Mask()
+	// Timestamps are stored as UTC; TIMESTAMP columns carry no zone.
+	s.CollectedAt = s.CollectedAt.UTC()
+	now := r.clock.Now().UTC()
 	hostID, err := r.UpsertHost(ctx, s.AgentID, s.MachineID, s.BootID, s.Hostname)
 	if err != nil {
 		return InsertResult{}, err
 	}
+	if s.AgentVersion != "" {
+		// Best effort, like UpsertHost's updates of the other mutable fields.
+		_, _ = r.db.ExecContext(ctx, `UPDATE hosts SET agent_version = ? WHERE host_id = ? AND agent_version IS DISTINCT FROM ?`,
+			s.AgentVersion, hostID, s.AgentVersion)
+	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := r.beginDimTx(ctx)
 	if err != nil {
 		return InsertResult{}, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	snapshotID := NewID()
+	snapshotID := r.ids.NextID()
 
 	// Insert Snapshot
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO snapshots(
-		  snapshot_id, host_id, kind, collected_at,
+		  snapshot_id, host_id, kind, collected_at, schema_version,
 		  cpu_usage_pct, load_avg_1, load_avg_5, load_avg_15, cpu_model, cpu_cores_logical,
 		  ram_usage_pct, ram_total_bytes, ram_available_bytes, ram_used_bytes, ram_free_bytes, ram_cached_bytes, ram_buffered_bytes,
-		  swap_usage_pct, swap_total_bytes, swap_used_bytes,
+		  swap_usage_pct, swap_total_bytes, swap_used_bytes, swap_in_bytes,
 		  disk_usage_pct, disk_total_bytes, inode_usage_pct, inode_total,
-		  net_latency_ms, is_connected, active_tcp,
+		  net_latency_ms, is_connected, active_tcp, net_jitter_ms, net_loss_pct, vpn_interface, net_direct_ms,
 		  docker_available,
 		  os, platform, kernel_version, uptime_seconds, procs,
+		  containerized, cgroup_mem_limit_bytes, cgroup_cpu_limit,
+		  environment, hypervisor, cloud_provider, latency_nat,
+		  cpu_e_cluster_pct, cpu_p_cluster_pct, power_cpu_w, power_gpu_w, power_ane_w, power_total_w, thermal_pressure,
+		  throttled_bits, core_volts,
 		  disk_read_bps, disk_write_bps, disk_read_iops, disk_write_iops, disk_avg_read_lat_ms, disk_avg_write_lat_ms,
 		  net_tx_bps, net_rx_bps, net_err_per_s, net_drop_per_s,
 		  severity_level, risk_score, flags_bitmask,
@@ -407,31 +740,43 @@ func (r *Repo) InsertRawStats(ctx context.Context, s RawStatsFixed, d DerivedRat
 		  flag_disk_space_critical, flag_inode_exhaustion, flag_disk_io_saturation, flag_disk_health_failed,
 		  flag_network_latency_degraded, flag_network_packet_loss, flag_network_interface_errors,
 		  flag_docker_unavailable, flag_container_cpu_hog, flag_container_memory_pressure, flag_container_oom_risk,
-		  flag_runaway_process_cpu, flag_runaway_process_memory, flag_thermal_pressure, flag_system_at_risk
+		  flag_runaway_process_cpu, flag_runaway_process_memory, flag_thermal_pressure, flag_system_at_risk,
+		  flag_memory_exhaustion_predicted, flag_user_resource_hog, flag_under_voltage, flag_link_degraded, flag_link_saturated,
+		  flag_check_failed, flag_storage_budget, flag_gpu_overloaded, flag_gpu_memory_pressure, trace_id, created_at
 		) VALUES (
-		  ?,?,?,?,
+		  ?,?,?,?,?,
 		  ?,?,?,?,?, ?,
 		  ?,?,?,?,?,?,?,
-		  ?,?,?,
 		  ?,?,?,?,
-		  ?,?,?,
+		  ?,?,?,?,
+		  ?,?,?,?,?,?,?,
 		  ?,
 		  ?,?,?,?,?,
+		  ?,?,?,
+		  ?,?,?,?,
+		  ?,?,?,?,?,?,?,
+		  ?,?,
 		  ?,?,?,?,?, ?,
 		  ?,?,?,?,
 		  ?,?,?,
 		  ?,?,?,?,
-		  ?,?,?,?,?, ?,?,?,?, ?,?,?, ?,?,?,?, ?,?,?,?
+		  ?,?,?,?,?, ?,?,?,?, ?,?,?, ?,?,?,?, ?,?,?,?,
+		  ?,?,?,?,?,
+		  ?,?,?,?,?,?
 		)
 	`,
-		snapshotID, hostID, string(s.Kind), s.CollectedAt,
+		snapshotID, hostID, string(s.Kind), s.CollectedAt, SchemaVersion,
 		nullFloat(s.CPUUsagePct), nullFloat(s.LoadAvg1), nullFloat(s.LoadAvg5), nullFloat(s.LoadAvg15), nullStr(s.CPUModel), nullInt(int64(s.CPUCoresLogical)),
 		nullFloat(s.RAMUsagePct), nullUInt64(s.RAMTotalBytes), nullUInt64(s.RAMAvailableBytes), nullUInt64(s.RAMUsedBytes), nullUInt64(s.RAMFreeBytes), nullUInt64(s.RAMCachedBytes), nullUInt64(s.RAMBufferedBytes),
-		nullFloat(s.SwapUsagePct), nullUInt64(s.SwapTotalBytes), nullUInt64(s.SwapUsedBytes),
+		nullFloat(s.SwapUsagePct), nullUInt64(s.SwapTotalBytes), nullUInt64(s.SwapUsedBytes), nullUInt64(s.SwapInBytes),
 		nullFloat(s.DiskUsagePct), nullUInt64(s.DiskTotalBytes), nullFloat(s.InodeUsagePct), nullUInt64(s.InodeTotal),
-		nullFloat(s.NetLatencyMS), s.IsConnected, nullInt(int64(s.ActiveTCP)),
+		nullFloat(s.NetLatencyMS), s.IsConnected, nullInt(int64(s.ActiveTCP)), nullFloat(s.NetJitterMS), nullFloat(s.NetLossPct), nullStr(s.VPNInterface), nullFloat(s.NetDirectMS),
 		s.DockerAvailable,
 		nullStr(s.OS), nullStr(s.Platform), nullStr(s.KernelVersion), nullUInt64(s.UptimeSeconds), nullUInt64(s.Procs),
+		s.Containerized, nullUInt64(s.CgroupMemLimitBytes), nullFloat(s.CgroupCPULimit),
+		nullStr(s.Environment), nullStr(s.Hypervisor), nullStr(s.CloudProvider), s.LatencyNAT,
+		nullFloat(s.CPUEClusterPct), nullFloat(s.CPUPClusterPct), nullFloat(s.PowerCPUWatts), nullFloat(s.PowerGPUWatts), nullFloat(s.PowerANEWatts), nullFloat(s.PowerTotalWatts), nullStr(s.ThermalPressure),
+		s.ThrottledBits, nullFloat(s.CoreVolts),
 		nullFloat(d.DiskReadBps), nullFloat(d.DiskWriteBps), nullFloat(d.DiskReadIops), nullFloat(d.DiskWriteIops), nullFloat(d.DiskAvgReadLatMs), nullFloat(d.DiskAvgWriteLatMs),
 		nullFloat(d.NetTxBps), nullFloat(d.NetRxBps), nullFloat(d.NetErrPerS), nullFloat(d.NetDropPerS),
 		f.SeverityLevel, f.RiskScore, f.Bitmask,
@@ -441,13 +786,15 @@ func (r *Repo) InsertRawStats(ctx context.Context, s RawStatsFixed, d DerivedRat
 		f.FlagNetworkLatencyDegraded, f.FlagNetworkPacketLoss, f.FlagNetworkInterfaceErrors,
 		f.FlagDockerUnavailable, f.FlagContainerCPUHog, f.FlagContainerMemoryPressure, f.FlagContainerOOMRisk,
 		f.FlagRunawayProcessCPU, f.FlagRunawayProcessMemory, f.FlagThermalPressure, f.FlagSystemAtRisk,
+		f.FlagMemoryExhaustionPredicted, f.FlagUserResourceHog, f.FlagUnderVoltage, f.FlagLinkDegraded, f.FlagLinkSaturated,
+		f.FlagCheckFailed, f.FlagStorageBudget, f.FlagGPUOverloaded, f.FlagGPUMemoryPressure, nullStr(s.TraceID), now,
 	)
 	if err != nil {
 		return InsertResult{}, fmt.Errorf("insert snapshot: %w", err)
 	}
 
 	// Insert Children
-	if err := r.insertChildrenTx(ctx, tx, hostID, snapshotID, s); err != nil {
+	if err := r.insertChildrenTx(ctx, tx, hostID, snapshotID, s, d); err != nil {
 		return InsertResult{}, err
 	}
 
@@ -458,8 +805,8 @@ func (r *Repo) InsertRawStats(ctx context.Context, s RawStatsFixed, d DerivedRat
 		  cpu_usage_pct, load_avg_1, ram_usage_pct, ram_available_bytes, swap_usage_pct,
 		  disk_usage_pct, inode_usage_pct, net_latency_ms, is_connected, docker_available,
 		  disk_read_bps, disk_write_bps, net_tx_bps, net_rx_bps,
-		  severity_level, risk_score, flags_bitmask, explanation
-		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		  severity_level, risk_score, flags_bitmask, explanation, updated_at
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
 		ON CONFLICT(host_id) DO UPDATE SET
 		  last_snapshot_id     = excluded.last_snapshot_id,
 		  collected_at         = excluded.collected_at,
@@ -481,25 +828,74 @@ func (r *Repo) InsertRawStats(ctx context.Context, s RawStatsFixed, d DerivedRat
 		  risk_score           = excluded.risk_score,
 		  flags_bitmask        = excluded.flags_bitmask,
 		  explanation          = excluded.explanation,
-		  updated_at           = now()
+		  updated_at           = excluded.updated_at
 	`,
 		hostID, snapshotID, s.CollectedAt,
 		nullFloat(s.CPUUsagePct), nullFloat(s.LoadAvg1), nullFloat(s.RAMUsagePct), nullUInt64(s.RAMAvailableBytes), nullFloat(s.SwapUsagePct),
 		nullFloat(s.DiskUsagePct), nullFloat(s.InodeUsagePct), nullFloat(s.NetLatencyMS), s.IsConnected, s.DockerAvailable,
 		nullFloat(d.DiskReadBps), nullFloat(d.DiskWriteBps), nullFloat(d.NetTxBps), nullFloat(d.NetRxBps),
-		f.SeverityLevel, f.RiskScore, f.Bitmask, nullStr(f.Explanation),
+		f.SeverityLevel, f.RiskScore, f.Bitmask, nullStr(f.Explanation), now,
 	)
 	if err != nil {
 		return InsertResult{}, fmt.Errorf("update current_state: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := r.commitDimTx(tx); err != nil {
 		return InsertResult{}, err
 	}
 
+	// Keep the raw smartctl report of failing disks for later inspection
+	for _, h := range s.DiskHealth {
+		if h.Raw == "" {
+			continue
+		}
+		if _, err := r.InsertArtifact(ctx, Artifact{
+			SnapshotID: snapshotID,
+			Kind:       ArtifactSMART,
+			MIME:       "text/plain",
+			Name:       h.Device,
+			Data:       []byte(h.Raw),
+			CreatedAt:  s.CollectedAt,
+		}); err != nil {
+			// The snapshot is already committed; the report is best-effort.
+			fmt.Fprintf(os.Stderr, "Warning: storing smartctl output for %s failed: %v\n", h.Device, err)
+		}
+	}
+
+	r.maybeUpdateRollups(ctx)
+	r.maybeEnforceStorageBudget(ctx)
 	return InsertResult{SnapshotID: snapshotID, HostID: hostID}, nil
 }
 
+// RecordRepeat counts a collection at at that was not stored because it
+// matched snapshot snapshotID, and marks the host's current state as seen.
+func (r *Repo) RecordRepeat(ctx context.Context, snapshotID int64, at time.Time) error {
+	at = at.UTC()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("record repeat: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE snapshots SET repeat_count = COALESCE(repeat_count, 0) + 1, last_repeat_at = ?
+		WHERE snapshot_id = ?
+	`, at, snapshotID)
+	if err != nil {
+		return fmt.Errorf("record repeat: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("snapshot %d not found", snapshotID)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE current_state SET updated_at = ? WHERE last_snapshot_id = ?`, at, snapshotID); err != nil {
+		return fmt.Errorf("record repeat: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("record repeat: %w", err)
+	}
+	return nil
+}
+
 func (r *Repo) GetCurrentState(ctx context.Context, hostID int64) (map[string]any, error) {
 	// Implementation omitted for brevity, similar to previous
 	return nil, nil
@@ -509,20 +905,11 @@ func (r *Repo) GetCurrentState(ctx context.Context, hostID int64) (map[string]an
 // HELPERS
 // =============================================================================
 
+// PrevCounters holds the per-device cumulative counters of the previous snapshot.
 type PrevCounters struct {
-	CollectedAt     time.Time
-	DiskReadBytes   uint64
-	DiskWriteBytes  uint64
-	DiskReadCount   uint64
-	DiskWriteCount  uint64
-	DiskReadTimeMS  uint64
-	DiskWriteTimeMS uint64
-	NetBytesSent    uint64
-	NetBytesRecv    uint64
-	NetErrIn        uint64
-	NetErrOut       uint64
-	NetDropIn       uint64
-	NetDropOut      uint64
+	CollectedAt   time.Time
+	IOCounters    []DiskIOCountersFixed
+	NetInterfaces []NetInterfaceStatsFixed
 }
 
 func (r *Repo) getPrevCounters(ctx context.Context, hostID int64) (PrevCounters, error) {
@@ -539,30 +926,69 @@ func (r *Repo) getPrevCounters(ctx context.Context, hostID int64) (PrevCounters,
 		return PrevCounters{}, err
 	}
 
-	var prev PrevCounters
-	prev.CollectedAt = t
+	prev := PrevCounters{CollectedAt: t}
 
-	// Sum disk counters
-	_ = r.db.QueryRowContext(ctx, `
-		SELECT
-		  COALESCE(SUM(read_bytes),0), COALESCE(SUM(write_bytes),0),
-		  COALESCE(SUM(read_count),0), COALESCE(SUM(write_count),0),
-		  COALESCE(SUM(read_time_ms),0), COALESCE(SUM(write_time_ms),0)
-		FROM snapshot_disk_io WHERE snapshot_id = ?
-	`, sid).Scan(&prev.DiskReadBytes, &prev.DiskWriteBytes, &prev.DiskReadCount, &prev.DiskWriteCount, &prev.DiskReadTimeMS, &prev.DiskWriteTimeMS)
+	// Disk counters per device
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT dd.device, io.read_bytes, io.write_bytes, io.read_count, io.write_count, io.read_time_ms, io.write_time_ms
+		FROM snapshot_disk_io io
+		JOIN disk_devices dd ON dd.disk_device_id = io.disk_device_id
+		WHERE io.snapshot_id = ?
+	`, sid)
+	if err != nil {
+		return PrevCounters{}, fmt.Errorf("query previous disk counters: %w", err)
+	}
+	for rows.Next() {
+		var io DiskIOCountersFixed
+		var c [6]NullUint64
+		if err := rows.Scan(&io.Device, &c[0], &c[1], &c[2], &c[3], &c[4], &c[5]); err != nil {
+			rows.Close()
+			return PrevCounters{}, fmt.Errorf("scan previous disk counters: %w", err)
+		}
+		io.ReadBytes, io.WriteBytes = c[0].Uint64, c[1].Uint64
+		io.ReadCount, io.WriteCount = c[2].Uint64, c[3].Uint64
+		io.ReadTimeMS, io.WriteTimeMS = c[4].Uint64, c[5].Uint64
+		prev.IOCounters = append(prev.IOCounters, io)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return PrevCounters{}, fmt.Errorf("rows iteration error: %w", err)
+	}
 
-	// Sum net counters
-	_ = r.db.QueryRowContext(ctx, `
-		SELECT
-		  COALESCE(SUM(bytes_sent),0), COALESCE(SUM(bytes_recv),0),
-		  COALESCE(SUM(err_in),0), COALESCE(SUM(err_out),0),
-		  COALESCE(SUM(drop_in),0), COALESCE(SUM(drop_out),0)
-		FROM snapshot_net_interface_stats WHERE snapshot_id = ?
-	`, sid).Scan(&prev.NetBytesSent, &prev.NetBytesRecv, &prev.NetErrIn, &prev.NetErrOut, &prev.NetDropIn, &prev.NetDropOut)
+	// Net counters per interface
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT ni.name, n.bytes_sent, n.bytes_recv, n.packets_sent, n.packets_recv, n.err_in, n.err_out, n.drop_in, n.drop_out, n.carrier_changes
+		FROM snapshot_net_interface_stats n
+		JOIN net_interfaces ni ON ni.net_interface_id = n.net_interface_id
+		WHERE n.snapshot_id = ?
+	`, sid)
+	if err != nil {
+		return PrevCounters{}, fmt.Errorf("query previous net counters: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ni NetInterfaceStatsFixed
+		var c [9]NullUint64
+		if err := rows.Scan(&ni.Name, &c[0], &c[1], &c[2], &c[3], &c[4], &c[5], &c[6], &c[7], &c[8]); err != nil {
+			return PrevCounters{}, fmt.Errorf("scan previous net counters: %w", err)
+		}
+		ni.BytesSent, ni.BytesRecv = c[0].Uint64, c[1].Uint64
+		ni.PacketsSent, ni.PacketsRecv = c[2].Uint64, c[3].Uint64
+		ni.ErrIn, ni.ErrOut = c[4].Uint64, c[5].Uint64
+		ni.DropIn, ni.DropOut = c[6].Uint64, c[7].Uint64
+		ni.CarrierChanges = c[8].Uint64
+		prev.NetInterfaces = append(prev.NetInterfaces, ni)
+	}
+	if err := rows.Err(); err != nil {
+		return PrevCounters{}, fmt.Errorf("rows iteration error: %w", err)
+	}
 
 	return prev, nil
 }
 
+// ComputeDerivedRates turns counter growth since prev into per-second rates.
+// Deltas are computed per device so a reset or wrap on one device does not
+// hide traffic on the others; devices without a previous sample contribute nothing.
 func ComputeDerivedRates(prev PrevCounters, now RawStatsFixed) *DerivedRates {
 	if prev.CollectedAt.IsZero() {
 		return &DerivedRates{}
@@ -572,61 +998,82 @@ func ComputeDerivedRates(prev PrevCounters, now RawStatsFixed) *DerivedRates {
 		return &DerivedRates{}
 	}
 
-	// Sum current
-	var cur PrevCounters
+	prevIO := make(map[string]DiskIOCountersFixed, len(prev.IOCounters))
+	for _, io := range prev.IOCounters {
+		prevIO[io.Device] = io
+	}
+	var dReadB, dWriteB, dReadC, dWriteC, dReadT, dWriteT uint64
 	for _, io := range now.IOCounters {
-		cur.DiskReadBytes += io.ReadBytes
-		cur.DiskWriteBytes += io.WriteBytes
-		cur.DiskReadCount += io.ReadCount
-		cur.DiskWriteCount += io.WriteCount
-		cur.DiskReadTimeMS += io.ReadTimeMS
-		cur.DiskWriteTimeMS += io.WriteTimeMS
+		p, ok := prevIO[io.Device]
+		if !ok {
+			continue
+		}
+		dReadB += counterDelta(p.ReadBytes, io.ReadBytes)
+		dWriteB += counterDelta(p.WriteBytes, io.WriteBytes)
+		dReadC += counterDelta(p.ReadCount, io.ReadCount)
+		dWriteC += counterDelta(p.WriteCount, io.WriteCount)
+		dReadT += counterDelta(p.ReadTimeMS, io.ReadTimeMS)
+		dWriteT += counterDelta(p.WriteTimeMS, io.WriteTimeMS)
+	}
+
+	prevNet := make(map[string]NetInterfaceStatsFixed, len(prev.NetInterfaces))
+	for _, ni := range prev.NetInterfaces {
+		prevNet[ni.Name] = ni
 	}
+	var dSent, dRecv, dErr, dDrop uint64
+	var carrier map[string]uint64
+	var util map[string]float64
 	for _, ni := range now.NetInterfaces {
-		cur.NetBytesSent += ni.BytesSent
-		cur.NetBytesRecv += ni.BytesRecv
-		cur.NetErrIn += ni.ErrIn
-		cur.NetErrOut += ni.ErrOut
-		cur.NetDropIn += ni.DropIn
-		cur.NetDropOut += ni.DropOut
+		p, ok := prevNet[ni.Name]
+		if !ok {
+			continue
+		}
+		if n := counterDelta(p.CarrierChanges, ni.CarrierChanges); n > 0 {
+			if carrier == nil {
+				carrier = make(map[string]uint64)
+			}
+			carrier[ni.Name] = n
+		}
+		if ni.SpeedMbps > 0 {
+			// Full duplex: each direction has the whole link speed.
+			busiest := max(counterDelta(p.BytesSent, ni.BytesSent), counterDelta(p.BytesRecv, ni.BytesRecv))
+			if util == nil {
+				util = make(map[string]float64)
+			}
+			util[ni.Name] = float64(busiest) * 8 / dt / (float64(ni.SpeedMbps) * 1e6) * 100
+		}
+		dSent += counterDelta(p.BytesSent, ni.BytesSent)
+		dRecv += counterDelta(p.BytesRecv, ni.BytesRecv)
+		dErr += counterDelta(p.ErrIn, ni.ErrIn) + counterDelta(p.ErrOut, ni.ErrOut)
+		dDrop += counterDelta(p.DropIn, ni.DropIn) + counterDelta(p.DropOut, ni.DropOut)
 	}
 
 	d := &DerivedRates{
-		DiskReadBps:   rate(prev.DiskReadBytes, cur.DiskReadBytes, dt),
-		DiskWriteBps:  rate(prev.DiskWriteBytes, cur.DiskWriteBytes, dt),
-		DiskReadIops:  rate(prev.DiskReadCount, cur.DiskReadCount, dt),
-		DiskWriteIops: rate(prev.DiskWriteCount, cur.DiskWriteCount, dt),
-		NetTxBps:      rate(prev.NetBytesSent, cur.NetBytesSent, dt),
-		NetRxBps:      rate(prev.NetBytesRecv, cur.NetBytesRecv, dt),
-		NetErrPerS:    rate(prev.NetErrIn+prev.NetErrOut, cur.NetErrIn+cur.NetErrOut, dt),
-		NetDropPerS:   rate(prev.NetDropIn+prev.NetDropOut, cur.NetDropIn+cur.NetDropOut, dt),
+		DiskReadBps:   float64(dReadB) / dt,
+		DiskWriteBps:  float64(dWriteB) / dt,
+		DiskReadIops:  float64(dReadC) / dt,
+		DiskWriteIops: float64(dWriteC) / dt,
+		NetTxBps:      float64(dSent) / dt,
+		NetRxBps:      float64(dRecv) / dt,
+		NetErrPerS:    float64(dErr) / dt,
+		NetDropPerS:   float64(dDrop) / dt,
+
+		NetCarrierChanges: carrier,
+		NetUtilization:    util,
 	}
 
 	// Latency
-	dReadC := delta(prev.DiskReadCount, cur.DiskReadCount)
-	dWriteC := delta(prev.DiskWriteCount, cur.DiskWriteCount)
 	if dReadC > 0 {
-		d.DiskAvgReadLatMs = float64(delta(prev.DiskReadTimeMS, cur.DiskReadTimeMS)) / float64(dReadC)
+		d.DiskAvgReadLatMs = float64(dReadT) / float64(dReadC)
 	}
 	if dWriteC > 0 {
-		d.DiskAvgWriteLatMs = float64(delta(prev.DiskWriteTimeMS, cur.DiskWriteTimeMS)) / float64(dWriteC)
+		d.DiskAvgWriteLatMs = float64(dWriteT) / float64(dWriteC)
 	}
 
 	return d
 }
 
-func rate(prev, cur uint64, dt float64) float64 {
-	return float64(delta(prev, cur)) / dt
-}
-
-func delta(prev, cur uint64) uint64 {
-	if cur >= prev {
-		return cur - prev
-	}
-	return 0 // reset
-}
-
-func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapshotID int64, s RawStatsFixed) error {
+func (r *Repo) insertChildrenTx(ctx context.Context, tx *dimTx, hostID, snapshotID int64, s RawStatsFixed, d DerivedRates) error {
 	// CPU Cores
 	if len(s.CPUPerCorePct) > 0 {
 		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_cpu_cores(snapshot_id, core_index, usage_pct) VALUES(?,?,?)`)
@@ -693,7 +1140,7 @@ func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapsho
 	}
 	// Net Interfaces
 	if len(s.NetInterfaces) > 0 {
-		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_net_interface_stats(snapshot_id, net_interface_id, bytes_sent, bytes_recv, packets_sent, packets_recv, err_in, err_out, drop_in, drop_out) VALUES(?,?,?,?,?,?,?,?,?,?)`)
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_net_interface_stats(snapshot_id, net_interface_id, bytes_sent, bytes_recv, packets_sent, packets_recv, err_in, err_out, drop_in, drop_out, oper_state, speed_mbps, max_speed_mbps, duplex, carrier_changes, util_pct) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
 		if err != nil {
 			return err
 		}
@@ -703,7 +1150,8 @@ func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapsho
 			if err != nil {
 				return err
 			}
-			if _, err := stmt.ExecContext(ctx, snapshotID, ifID, ni.BytesSent, ni.BytesRecv, ni.PacketsSent, ni.PacketsRecv, ni.ErrIn, ni.ErrOut, ni.DropIn, ni.DropOut); err != nil {
+			if _, err := stmt.ExecContext(ctx, snapshotID, ifID, ni.BytesSent, ni.BytesRecv, ni.PacketsSent, ni.PacketsRecv, ni.ErrIn, ni.ErrOut, ni.DropIn, ni.DropOut,
+				nullStr(ni.OperState), nullInt(int64(ni.SpeedMbps)), nullInt(int64(ni.MaxSpeedMbps)), nullStr(ni.Duplex), ni.CarrierChanges, nullUtil(d.NetUtilization, ni.Name)); err != nil {
 				return err
 			}
 		}
@@ -744,7 +1192,7 @@ func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapsho
 	}
 	// Processes
 	if len(s.TopProcesses) > 0 {
-		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_top_processes(snapshot_id, rank, pid, process_name_id, cpu_pct, mem_pct) VALUES(?,?,?,?,?,?)`)
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_top_processes(snapshot_id, rank, pid, process_name_id, cpu_pct, mem_pct, cmdline, cgroup, container_id) VALUES(?,?,?,?,?,?,?,?,?)`)
 		if err != nil {
 			return err
 		}
@@ -754,7 +1202,106 @@ func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapsho
 			if err != nil {
 				return err
 			}
-			if _, err := stmt.ExecContext(ctx, snapshotID, p.Rank, p.PID, pnID, nullFloat(p.CPUPct), nullFloat(float64(p.MemPct))); err != nil {
+			if _, err := stmt.ExecContext(ctx, snapshotID, p.Rank, p.PID, pnID, nullFloat(p.CPUPct), nullFloat(float64(p.MemPct)), nullStr(p.Cmdline), nullStr(p.Cgroup), nullStr(p.ContainerID)); err != nil {
+				return err
+			}
+		}
+	}
+	// Per-user totals
+	if len(s.UserUsage) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_user_usage(snapshot_id, uid, username, processes, cpu_pct, mem_pct, rss_bytes) VALUES(?,?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, u := range s.UserUsage {
+			if _, err := stmt.ExecContext(ctx, snapshotID, u.UID, nullStr(u.User), u.Processes, u.CPUPct, u.MemPct, u.RSSBytes); err != nil {
+				return err
+			}
+		}
+	}
+	// GPUs
+	if len(s.GPUs) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_gpu_stats(snapshot_id, gpu_index, vendor, name, util_pct, mem_used_bytes, mem_total_bytes, temperature_c, power_w) VALUES(?,?,?,?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, g := range s.GPUs {
+			if _, err := stmt.ExecContext(ctx, snapshotID, g.Index, g.Vendor, nullStr(g.Name), g.UtilPct, g.MemUsedBytes, nullUInt64(g.MemTotalBytes), nullFloat(g.TemperatureC), nullFloat(g.PowerWatts)); err != nil {
+				return err
+			}
+		}
+	}
+	// Log growth
+	if len(s.LogGrowers) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_log_growth(snapshot_id, rank, path, size_bytes, growth_bps) VALUES(?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i, g := range s.LogGrowers {
+			if _, err := stmt.ExecContext(ctx, snapshotID, i+1, g.Path, nullUInt64(g.SizeBytes), g.GrowthBps); err != nil {
+				return err
+			}
+		}
+	}
+	// Largest directories
+	if len(s.LargestDirs) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_largest_dirs(snapshot_id, rank, path, size_bytes) VALUES(?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i, d := range s.LargestDirs {
+			if _, err := stmt.ExecContext(ctx, snapshotID, i+1, d.Path, d.SizeBytes); err != nil {
+				return err
+			}
+		}
+	}
+	// Temp and core dump usage
+	if len(s.TempUsage) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_temp_usage(snapshot_id, path, kind, files, total_bytes, stale_bytes) VALUES(?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, u := range s.TempUsage {
+			if _, err := stmt.ExecContext(ctx, snapshotID, u.Path, u.Kind, u.Files, u.TotalBytes, u.StaleBytes); err != nil {
+				return err
+			}
+		}
+	}
+	// User-defined checks
+	if len(s.Checks) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshot_check_results(snapshot_id, name, ok, exit_code, duration_ms, value, output, severity) VALUES(?,?,?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, c := range s.Checks {
+			var value sql.NullFloat64
+			if c.Value != nil {
+				value = sql.NullFloat64{Float64: *c.Value, Valid: true}
+			}
+			if _, err := stmt.ExecContext(ctx, snapshotID, c.Name, c.OK, c.ExitCode, c.DurationMS, value, nullStr(c.Output), c.Severity); err != nil {
+				return err
+			}
+		}
+	}
+	// OOM kills
+	if len(s.OOMKills) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO oom_events(event_id, host_id, snapshot_id, occurred_at, pid, process_name_id, cgroup, uid, anon_rss_bytes, oom_constraint) VALUES(?,?,?,?,?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, k := range s.OOMKills {
+			pnID, err := r.upsertProcessNameTx(ctx, tx, k.Process)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, r.ids.NextID(), hostID, snapshotID, k.At, k.PID, pnID, nullStr(k.Cgroup), k.UID, nullUInt64(k.AnonRSSBytes), nullStr(k.Constraint)); err != nil {
 				return err
 			}
 		}
@@ -762,7 +1309,56 @@ func (r *Repo) insertChildrenTx(ctx context.Context, tx *sql.Tx, hostID, snapsho
 	return nil
 }
 
-// Dimension Upserts (simplified)
+// Dimension Upserts
+//
+// Dimension IDs resolved inside a transaction are staged on the dimTx and only
+// published to the repo cache after the transaction commits. Otherwise a rollback
+// would leave IDs in the cache for rows that no longer exist.
+
+// dimTx is a transaction that tracks dimension IDs pending commit.
+type dimTx struct {
+	*sql.Tx
+	staged map[*map[string]int64]map[string]int64
+}
+
+func (r *Repo) beginDimTx(ctx context.Context) (*dimTx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &dimTx{Tx: tx, staged: make(map[*map[string]int64]map[string]int64)}, nil
+}
+
+// commitDimTx commits tx and then publishes its staged dimension IDs to the cache.
+func (r *Repo) commitDimTx(tx *dimTx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for cache, entries := range tx.staged {
+		for k, id := range entries {
+			(*cache)[k] = id
+		}
+	}
+	tx.staged = nil
+	return nil
+}
+
+func (tx *dimTx) lookup(cache *map[string]int64, key string) (int64, bool) {
+	id, ok := tx.staged[cache][key]
+	return id, ok
+}
+
+func (tx *dimTx) stage(cache *map[string]int64, key string, id int64) {
+	m, ok := tx.staged[cache]
+	if !ok {
+		m = make(map[string]int64)
+		tx.staged[cache] = m
+	}
+	m[key] = id
+}
+
 func (r *Repo) ensureCache(hostID int64) *hostCache {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -779,116 +1375,89 @@ func (r *Repo) ensureCache(hostID int64) *hostCache {
 	return r.cache[hostID]
 }
 
-func (r *Repo) upsertDim(ctx context.Context, tx *sql.Tx, hostID int64, cache map[string]int64, key string, querySel, queryIns string, argsIns ...any) (int64, error) {
+// cachedDim returns a committed ID from the cache or an ID staged by tx.
+func (r *Repo) cachedDim(tx *dimTx, cache *map[string]int64, key string) (int64, bool) {
 	r.mu.RLock()
-	if id, ok := cache[key]; ok {
-		r.mu.RUnlock()
+	id, ok := (*cache)[key]
+	r.mu.RUnlock()
+	if ok {
+		return id, true
+	}
+	return tx.lookup(cache, key)
+}
+
+// upsertDim resolves a dimension ID, inserting the row if needed. querySel is run
+// with selArgs; queryIns with a new ID followed by insArgs.
+func (r *Repo) upsertDim(ctx context.Context, tx *dimTx, cache *map[string]int64, key string, querySel string, selArgs []any, queryIns string, insArgs ...any) (int64, error) {
+	if id, ok := r.cachedDim(tx, cache, key); ok {
 		return id, nil
 	}
-	r.mu.RUnlock()
 
 	var id int64
-	err := tx.QueryRowContext(ctx, querySel, hostID, key).Scan(&id)
+	err := tx.QueryRowContext(ctx, querySel, selArgs...).Scan(&id)
 	if err == nil {
-		r.mu.Lock()
-		cache[key] = id
-		r.mu.Unlock()
+		tx.stage(cache, key, id)
 		return id, nil
 	}
 	if !errors.Is(err, sql.ErrNoRows) {
 		return 0, err
 	}
 
-	id = NewID()
-	args := append([]any{id}, argsIns...)
-	_, err = tx.ExecContext(ctx, queryIns, args...)
-	if err != nil {
-		if e2 := tx.QueryRowContext(ctx, querySel, hostID, key).Scan(&id); e2 == nil {
-			r.mu.Lock()
-			cache[key] = id
-			r.mu.Unlock()
+	id = r.ids.NextID()
+	args := append([]any{id}, insArgs...)
+	if _, err := tx.ExecContext(ctx, queryIns, args...); err != nil {
+		if e2 := tx.QueryRowContext(ctx, querySel, selArgs...).Scan(&id); e2 == nil {
+			tx.stage(cache, key, id)
 			return id, nil
 		}
 		return 0, err
 	}
-	r.mu.Lock()
-	cache[key] = id
-	r.mu.Unlock()
+	tx.stage(cache, key, id)
 	return id, nil
 }
 
-func (r *Repo) upsertDiskDeviceTx(ctx context.Context, tx *sql.Tx, hostID int64, device string) (int64, error) {
-	return r.upsertDim(ctx, tx, hostID, r.ensureCache(hostID).diskDevice, device,
-		`SELECT disk_device_id FROM disk_devices WHERE host_id=? AND device=?`,
+func (r *Repo) upsertDiskDeviceTx(ctx context.Context, tx *dimTx, hostID int64, device string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(hostID).diskDevice, device,
+		`SELECT disk_device_id FROM disk_devices WHERE host_id=? AND device=?`, []any{hostID, device},
 		`INSERT INTO disk_devices(disk_device_id, host_id, device) VALUES(?,?,?)`,
 		hostID, device)
 }
 
-func (r *Repo) upsertMountpointTx(ctx context.Context, tx *sql.Tx, hostID int64, mp, dev, fs string) (int64, error) {
-	return r.upsertDim(ctx, tx, hostID, r.ensureCache(hostID).mountpoint, mp,
-		`SELECT mountpoint_id FROM mountpoints WHERE host_id=? AND mountpoint=?`,
+func (r *Repo) upsertMountpointTx(ctx context.Context, tx *dimTx, hostID int64, mp, dev, fs string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(hostID).mountpoint, mp,
+		`SELECT mountpoint_id FROM mountpoints WHERE host_id=? AND mountpoint=?`, []any{hostID, mp},
 		`INSERT INTO mountpoints(mountpoint_id, host_id, mountpoint, device, fstype) VALUES(?,?,?,?,?)`,
 		hostID, mp, nullEmpty(dev), nullEmpty(fs))
 }
 
-func (r *Repo) upsertNetInterfaceTx(ctx context.Context, tx *sql.Tx, hostID int64, name string) (int64, error) {
-	return r.upsertDim(ctx, tx, hostID, r.ensureCache(hostID).netIf, name,
-		`SELECT net_interface_id FROM net_interfaces WHERE host_id=? AND name=?`,
+func (r *Repo) upsertNetInterfaceTx(ctx context.Context, tx *dimTx, hostID int64, name string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(hostID).netIf, name,
+		`SELECT net_interface_id FROM net_interfaces WHERE host_id=? AND name=?`, []any{hostID, name},
 		`INSERT INTO net_interfaces(net_interface_id, host_id, name) VALUES(?,?,?)`,
 		hostID, name)
 }
 
-func (r *Repo) upsertTempSensorTx(ctx context.Context, tx *sql.Tx, hostID int64, key string) (int64, error) {
-	return r.upsertDim(ctx, tx, hostID, r.ensureCache(hostID).tempSensor, key,
-		`SELECT temp_sensor_id FROM temp_sensors WHERE host_id=? AND sensor_key=?`,
+func (r *Repo) upsertTempSensorTx(ctx context.Context, tx *dimTx, hostID int64, key string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(hostID).tempSensor, key,
+		`SELECT temp_sensor_id FROM temp_sensors WHERE host_id=? AND sensor_key=?`, []any{hostID, key},
 		`INSERT INTO temp_sensors(temp_sensor_id, host_id, sensor_key) VALUES(?,?,?)`,
 		hostID, key)
 }
 
-func (r *Repo) upsertDockerContainerTx(ctx context.Context, tx *sql.Tx, hostID int64, cid string) (int64, error) {
-	return r.upsertDim(ctx, tx, hostID, r.ensureCache(hostID).container, cid,
-		`SELECT docker_container_key FROM docker_containers WHERE host_id=? AND container_id=?`,
+func (r *Repo) upsertDockerContainerTx(ctx context.Context, tx *dimTx, hostID int64, cid string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(hostID).container, cid,
+		`SELECT docker_container_key FROM docker_containers WHERE host_id=? AND container_id=?`, []any{hostID, cid},
 		`INSERT INTO docker_containers(docker_container_key, host_id, container_id) VALUES(?,?,?)`,
 		hostID, cid)
 }
 
-func (r *Repo) upsertProcessNameTx(ctx context.Context, tx *sql.Tx, name string) (int64, error) {
-	// Process names are global, but we use host cache for simplicity or need global cache
-	// For now, just use a separate query without host_id in WHERE
-	// But upsertDim expects hostID.
-	// Let's just implement it manually.
-	hc := r.ensureCache(0) // Use 0 for global
-	r.mu.RLock()
-	if id, ok := hc.procName[name]; ok {
-		r.mu.RUnlock()
-		return id, nil
-	}
-	r.mu.RUnlock()
-
-	var id int64
-	err := tx.QueryRowContext(ctx, `SELECT process_name_id FROM process_names WHERE name=?`, name).Scan(&id)
-	if err == nil {
-		r.mu.Lock()
-		hc.procName[name] = id
-		r.mu.Unlock()
-		return id, nil
-	}
-
-	id = NewID()
-	_, err = tx.ExecContext(ctx, `INSERT INTO process_names(process_name_id, name) VALUES(?,?)`, id, name)
-	if err != nil {
-		if e2 := tx.QueryRowContext(ctx, `SELECT process_name_id FROM process_names WHERE name=?`, name).Scan(&id); e2 == nil {
-			r.mu.Lock()
-			hc.procName[name] = id
-			r.mu.Unlock()
-			return id, nil
-		}
-		return 0, err
-	}
-	r.mu.Lock()
-	hc.procName[name] = id
-	r.mu.Unlock()
-	return id, nil
+// upsertProcessNameTx resolves a process name. Process names are global, so they
+// live in the cache under host 0.
+func (r *Repo) upsertProcessNameTx(ctx context.Context, tx *dimTx, name string) (int64, error) {
+	return r.upsertDim(ctx, tx, &r.ensureCache(0).procName, name,
+		`SELECT process_name_id FROM process_names WHERE name=?`, []any{name},
+		`INSERT INTO process_names(process_name_id, name) VALUES(?,?)`,
+		name)
 }
 
 // Null helpers
@@ -913,10 +1482,16 @@ func nullFloat(v float64) sql.NullFloat64 {
 	return sql.NullFloat64{Float64: v, Valid: true}
 }
 
+// nullUtil is NULL for interfaces without a utilization figure.
+func nullUtil(util map[string]float64, name string) sql.NullFloat64 {
+	v, ok := util[name]
+	return sql.NullFloat64{Float64: v, Valid: ok}
+}
+
 func nullInt(v int64) sql.NullInt64 {
 	return sql.NullInt64{Int64: v, Valid: true}
 }
 
-func nullUInt64(v uint64) sql.NullInt64 {
-	return sql.NullInt64{Int64: int64(v), Valid: true}
+func nullUInt64(v uint64) NullUint64 {
+	return NullUint64{Uint64: v, Valid: true}
 }
@@ -0,0 +1,112 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PercentileMetrics are the CPU, latency and IO columns whose tail says
+// more than their average: a disk at 2 ms on average can still stall at
+// p99.
+var PercentileMetrics = []string{
+	"cpu_usage_pct",
+	"load_avg_1",
+	"net_latency_ms",
+	"disk_read_bps",
+	"disk_write_bps",
+	"disk_read_iops",
+	"disk_write_iops",
+	"disk_avg_read_lat_ms",
+	"disk_avg_write_lat_ms",
+	"net_tx_bps",
+	"net_rx_bps",
+}
+
+// MetricPercentile summarises one metric of one host over a range.
+type MetricPercentile struct {
+	Metric  string `json:"metric"`
+	Samples int64  `json:"samples"`
+	MetricAggregate
+}
+
+// aggregateSQL returns the expressions scanned by scanAggregate for column
+// expression v: average, p50, p95, p99 and maximum.
+func aggregateSQL(v string) []string {
+	return []string{
+		"avg(" + v + ")",
+		"quantile_cont(" + v + ", 0.5)",
+		"quantile_cont(" + v + ", 0.95)",
+		"quantile_cont(" + v + ", 0.99)",
+		"max(" + v + ")",
+	}
+}
+
+// aggregateCols is the number of expressions aggregateSQL returns.
+const aggregateCols = 5
+
+// scanAggregate builds a MetricAggregate from the values of aggregateSQL.
+func scanAggregate(vals []sql.NullFloat64) MetricAggregate {
+	return MetricAggregate{
+		Avg: validFloat(vals[0]),
+		P50: validFloat(vals[1]),
+		P95: validFloat(vals[2]),
+		P99: validFloat(vals[3]),
+		Max: validFloat(vals[4]),
+	}
+}
+
+// MetricPercentiles returns the average, p50, p95, p99 and maximum of each
+// metric over snapshots of hostname (every host when empty) collected
+// since since, computed with DuckDB's quantile_cont. metrics default to
+// PercentileMetrics; each must be one of MetricColumns.
+func (r *Repo) MetricPercentiles(ctx context.Context, hostname string, metrics []string, since time.Time) ([]MetricPercentile, error) {
+	if len(metrics) == 0 {
+		metrics = PercentileMetrics
+	}
+	var cols []string
+	for _, m := range metrics {
+		if !IsMetricColumn(m) {
+			return nil, fmt.Errorf("unknown metric %q", m)
+		}
+		// Metric names are validated against MetricColumns above.
+		v := fmt.Sprintf("CAST(s.%s AS DOUBLE)", m)
+		cols = append(cols, "count("+v+")")
+		cols = append(cols, aggregateSQL(v)...)
+	}
+	query := `
+		SELECT ` + strings.Join(cols, ",\n\t\t  ") + `
+		FROM snapshots s
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.collected_at >= ?`
+	args := []any{since.UTC()}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+
+	counts := make([]int64, len(metrics))
+	vals := make([]sql.NullFloat64, aggregateCols*len(metrics))
+	dest := make([]any, 0, len(counts)+len(vals))
+	for i := range metrics {
+		dest = append(dest, &counts[i])
+		for j := range aggregateCols {
+			dest = append(dest, &vals[aggregateCols*i+j])
+		}
+	}
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("metric percentiles failed: %w", err)
+	}
+
+	out := make([]MetricPercentile, len(metrics))
+	for i, m := range metrics {
+		out[i] = MetricPercentile{
+			Metric:          m,
+			Samples:         counts[i],
+			MetricAggregate: scanAggregate(vals[aggregateCols*i : aggregateCols*(i+1)]),
+		}
+	}
+	return out, nil
+}
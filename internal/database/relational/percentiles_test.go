@@ -0,0 +1,32 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMetricPercentiles(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	now := time.Now()
+	for i := range 101 {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: now.Add(-time.Duration(i) * time.Second), NetLatencyMS: float64(i)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.MetricPercentiles(ctx, "web-1", []string{"net_latency_ms"}, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := got[0]
+	if p.Samples != 101 || *p.Avg != 50 || *p.P50 != 50 || *p.P95 != 95 || *p.P99 != 99 || *p.Max != 100 {
+		t.Errorf("percentiles = %+v", p)
+	}
+
+	if _, err := repo.MetricPercentiles(ctx, "", []string{"hostname; DROP TABLE snapshots"}, now); err == nil {
+		t.Error("accepted an unknown metric")
+	}
+}
@@ -21,15 +21,62 @@ type SnapshotSummary struct {
 	RiskScore     int32     `json:"risk_score"`
 	PrimaryCause  string    `json:"primary_cause"`
 	Explanation   string    `json:"explanation"`
+	SchemaVersion string    `json:"schema_version"`
+	Bookmark      string    `json:"bookmark,omitempty"`     // bookmark notes, joined by "; "
+	RepeatCount   int32     `json:"repeat_count,omitempty"` // later collections skipped as unchanged
+	TraceID       string    `json:"trace_id,omitempty"`     // collection cycle
 }
 
 // QuerySnapshots retrieves recent snapshots with optional filtering.
 func (r *Repo) QuerySnapshots(ctx context.Context, hostname string, limit int) ([]SnapshotSummary, error) {
+	return r.QuerySnapshotsByLabels(ctx, hostname, nil, limit)
+}
+
+// QuerySnapshotsByLabels is QuerySnapshots restricted to hosts carrying
+// every given label.
+func (r *Repo) QuerySnapshotsByLabels(ctx context.Context, hostname string, labels map[string]string, limit int) ([]SnapshotSummary, error) {
+	return r.QuerySnapshotsFiltered(ctx, SnapshotFilter{Hostname: hostname, Labels: labels, Limit: limit})
+}
+
+// QuerySnapshotsByFlags returns snapshots collected since since (zero for
+// all) on which every named flag was set, e.g. []string{"memory_pressure"}.
+func (r *Repo) QuerySnapshotsByFlags(ctx context.Context, flags []string, since time.Time, limit int) ([]SnapshotSummary, error) {
+	return r.QuerySnapshotsFiltered(ctx, SnapshotFilter{Flags: flags, Since: since, Limit: limit})
+}
+
+// SnapshotFilter selects snapshots for QuerySnapshotsFiltered. Zero fields
+// do not filter.
+type SnapshotFilter struct {
+	Hostname string
+	Labels   map[string]string // host labels that must all match
+	Flags    []string          // flags (see FlagNames) that must all be set
+	Since    time.Time
+	TraceID  string // the snapshot of one collection cycle
+	Limit    int    // default 10, at most 500
+
+	// BeforeAt and BeforeID, when BeforeAt is set, keep only snapshots
+	// after that one in newest-first order, for keyset paging.
+	BeforeAt time.Time
+	BeforeID int64
+}
+
+// QuerySnapshotsFiltered retrieves recent snapshots matching f, newest
+// first, ties broken by descending snapshot ID.
+func (r *Repo) QuerySnapshotsFiltered(ctx context.Context, f SnapshotFilter) ([]SnapshotSummary, error) {
+	limit := f.Limit
 	if limit <= 0 {
 		limit = 10
 	}
-	if limit > 100 {
-		limit = 100 // Safety limit
+	if limit > 500 {
+		limit = 500 // Safety limit
+	}
+	var mask int64
+	for _, name := range f.Flags {
+		bit, ok := FlagBit(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown flag %q", name)
+		}
+		mask |= bit
 	}
 
 	query := `
@@ -45,19 +92,49 @@ func (r *Repo) QuerySnapshots(ctx context.Context, hostname string, limit int) (
 			s.severity_level,
 			s.risk_score,
 			COALESCE(s.primary_cause, '') as primary_cause,
-			COALESCE(s.explanation, '') as explanation
+			COALESCE(s.explanation, '') as explanation,
+			COALESCE(s.schema_version, ?) as schema_version,
+			COALESCE(s.repeat_count, 0) as repeat_count,
+			COALESCE(s.trace_id, '') as trace_id,
+			(SELECT string_agg(COALESCE(a.note, ''), '; ' ORDER BY a.created_at)
+			 FROM annotations a
+			 WHERE a.snapshot_id = s.snapshot_id AND a.kind = ?) as bookmark
 		FROM snapshots s
 		LEFT JOIN hosts h ON s.host_id = h.host_id
 		WHERE 1=1
 	`
 
-	args := []interface{}{}
-	if hostname != "" {
+	args := []interface{}{LegacySchemaVersion, AnnotationBookmark}
+	if f.Hostname != "" {
 		query += " AND h.hostname = ?"
-		args = append(args, hostname)
+		args = append(args, f.Hostname)
+	}
+	if len(f.Labels) > 0 {
+		cond, labelArgs := labelFilter(f.Labels)
+		query += " AND " + cond
+		args = append(args, labelArgs...)
+	}
+	if f.TraceID != "" {
+		query += " AND s.trace_id = ?"
+		args = append(args, f.TraceID)
+	}
+	if !f.Since.IsZero() {
+		query += " AND s.collected_at >= ?"
+		args = append(args, f.Since)
+	}
+	if !f.BeforeAt.IsZero() {
+		query += " AND (s.collected_at < ? OR (s.collected_at = ? AND s.snapshot_id < ?))"
+		args = append(args, f.BeforeAt, f.BeforeAt, f.BeforeID)
+	}
+	if mask != 0 {
+		// A mask containing every wanted bit is at least their sum, so the
+		// range predicate lets DuckDB's zone maps skip row groups where no
+		// snapshot was flagged before the exact bit test runs.
+		query += " AND s.flags_bitmask >= ? AND (s.flags_bitmask & ?) = ?"
+		args = append(args, mask, mask, mask)
 	}
 
-	query += " ORDER BY s.collected_at DESC LIMIT ?"
+	query += " ORDER BY s.collected_at DESC, s.snapshot_id DESC LIMIT ?"
 	args = append(args, limit)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -69,7 +146,7 @@ func (r *Repo) QuerySnapshots(ctx context.Context, hostname string, limit int) (
 	snapshots := []SnapshotSummary{} // Initialize as empty slice, not nil
 	for rows.Next() {
 		var s SnapshotSummary
-		var primaryCause, explanation sql.NullString
+		var primaryCause, explanation, bookmark sql.NullString
 
 		err := rows.Scan(
 			&s.SnapshotID,
@@ -84,6 +161,10 @@ func (r *Repo) QuerySnapshots(ctx context.Context, hostname string, limit int) (
 			&s.RiskScore,
 			&primaryCause,
 			&explanation,
+			&s.SchemaVersion,
+			&s.RepeatCount,
+			&s.TraceID,
+			&bookmark,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan snapshot failed: %w", err)
@@ -95,6 +176,7 @@ func (r *Repo) QuerySnapshots(ctx context.Context, hostname string, limit int) (
 		if explanation.Valid {
 			s.Explanation = explanation.String
 		}
+		s.Bookmark = bookmark.String
 
 		snapshots = append(snapshots, s)
 	}
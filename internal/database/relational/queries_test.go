@@ -0,0 +1,115 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuerySnapshotsByFlags(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	for i, f := range []SnapshotFlags{
+		{FlagMemoryPressure: true, FlagSwapThrashing: true}, // too old for the window below
+		{FlagMemoryPressure: true},
+		{FlagCPUOverloaded: true},
+		{FlagMemoryPressure: true, FlagSwapThrashing: true},
+	} {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: start.Add(time.Duration(i) * time.Hour)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.QuerySnapshotsByFlags(ctx, []string{"memory_pressure"}, time.Time{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("memory_pressure matched %d snapshots, want 3", len(got))
+	}
+
+	got, err = repo.QuerySnapshotsByFlags(ctx, []string{"memory_pressure", "swap_thrashing"}, start.Add(30*time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].CollectedAt.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("both flags since +30m = %+v, want only the newest snapshot", got)
+	}
+
+	if _, err := repo.QuerySnapshotsByFlags(ctx, []string{"no_such_flag"}, time.Time{}, 10); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestInsertRawStatsStoresUTC(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	berlin := time.FixedZone("CET", 3600)
+	at := time.Date(2026, 1, 2, 13, 0, 0, 0, berlin)
+	if _, err := repo.InsertRawStats(ctx, RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: at}, DerivedRates{}, SnapshotFlags{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.QuerySnapshots(ctx, "web-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].CollectedAt.Equal(at) || got[0].CollectedAt.Location() != time.UTC {
+		t.Errorf("snapshots = %+v, want one at %s in UTC", got, at.UTC())
+	}
+}
+
+func TestQuerySnapshotsFilteredKeyset(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	insert := func(at time.Time) {
+		t.Helper()
+		if _, err := repo.InsertRawStats(ctx, RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: at}, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 5 {
+		insert(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	first, err := repo.QuerySnapshotsFiltered(ctx, SnapshotFilter{Limit: 2})
+	if err != nil || len(first) != 2 {
+		t.Fatalf("first page = %d rows, %v", len(first), err)
+	}
+	// A snapshot stored between pages must not shift the next one.
+	insert(start.Add(10 * time.Minute))
+	last := first[len(first)-1]
+	second, err := repo.QuerySnapshotsFiltered(ctx, SnapshotFilter{Limit: 2, BeforeAt: last.CollectedAt, BeforeID: last.SnapshotID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 2 || !second[0].CollectedAt.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("second page = %+v, want the snapshots at +2m and +1m", second)
+	}
+}
+
+func TestQuerySnapshotsByTraceID(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	now := time.Now().Truncate(time.Second)
+	for i, trace := range []string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b700f067aa0ba902b7"} {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: now.Add(time.Duration(i) * time.Minute), TraceID: trace}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.QuerySnapshotsFiltered(ctx, SnapshotFilter{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || !got[0].CollectedAt.Equal(now) {
+		t.Errorf("snapshots for trace = %+v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package relational
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables read by RemoteSourceFromEnv. S3 credentials come
+// from the usual AWS variables.
+const (
+	EnvRemoteToken = "SYSCHECKER_REMOTE_DB_TOKEN" // bearer token sent with HTTP(S) requests
+	EnvS3Endpoint  = "AWS_ENDPOINT_URL"           // S3-compatible endpoint, e.g. http://minio:9000
+)
+
+// remoteSchemes are the URL prefixes DuckDB's httpfs extension reads.
+var remoteSchemes = []string{"http://", "https://", "s3://", "s3a://", "s3n://", "gcs://", "gs://", "r2://"}
+
+// remoteCatalog is the name the remote database is attached under.
+const remoteCatalog = "remote"
+
+// IsRemote reports whether dsn is a URL OpenRemote reads rather than a
+// local file.
+func IsRemote(dsn string) bool {
+	lower := strings.ToLower(dsn)
+	for _, scheme := range remoteSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteSource locates a snapshot database published on S3 or a web
+// server, such as a central collector's periodic copy.
+type RemoteSource struct {
+	URL string
+
+	BearerToken string // HTTP(S) only
+
+	S3KeyID        string
+	S3Secret       string
+	S3SessionToken string
+	S3Region       string
+	S3Endpoint     string // host[:port] of an S3-compatible store; empty for AWS
+	S3UseSSL       bool   // with S3Endpoint
+}
+
+// RemoteSourceFromEnv returns the source at url with the credentials of
+// EnvRemoteToken, EnvS3Endpoint and the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION variables.
+func RemoteSourceFromEnv(url string) RemoteSource {
+	src := RemoteSource{
+		URL:            url,
+		BearerToken:    os.Getenv(EnvRemoteToken),
+		S3KeyID:        os.Getenv("AWS_ACCESS_KEY_ID"),
+		S3Secret:       os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		S3SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		S3Region:       os.Getenv("AWS_REGION"),
+		S3UseSSL:       true,
+	}
+	if src.S3Region == "" {
+		src.S3Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if ep := os.Getenv(EnvS3Endpoint); ep != "" {
+		src.S3UseSSL = !strings.HasPrefix(ep, "http://")
+		src.S3Endpoint = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(ep, "http://"), "https://"), "/")
+	}
+	return src
+}
+
+// statements load httpfs, register the credentials and make the remote
+// database the connection's default catalog. Each is idempotent, so they
+// can run on every new connection.
+func (r RemoteSource) statements() []string {
+	stmts := []string{"INSTALL httpfs", "LOAD httpfs"}
+	if r.BearerToken != "" {
+		stmts = append(stmts, fmt.Sprintf(
+			"CREATE SECRET IF NOT EXISTS syschecker_http (TYPE HTTP, EXTRA_HTTP_HEADERS MAP {'Authorization': %s})",
+			sqlString("Bearer "+r.BearerToken)))
+	}
+	if r.S3KeyID != "" || r.S3Endpoint != "" || r.S3Region != "" {
+		params := []string{"TYPE S3"}
+		add := func(key, value string) {
+			if value != "" {
+				params = append(params, key+" "+sqlString(value))
+			}
+		}
+		add("KEY_ID", r.S3KeyID)
+		add("SECRET", r.S3Secret)
+		add("SESSION_TOKEN", r.S3SessionToken)
+		add("REGION", r.S3Region)
+		if r.S3Endpoint != "" {
+			// Self-hosted stores rarely support virtual-host buckets.
+			add("ENDPOINT", r.S3Endpoint)
+			params = append(params, "URL_STYLE 'path'", fmt.Sprintf("USE_SSL %t", r.S3UseSSL))
+		}
+		stmts = append(stmts, "CREATE SECRET IF NOT EXISTS syschecker_s3 ("+strings.Join(params, ", ")+")")
+	}
+	return append(stmts,
+		fmt.Sprintf("ATTACH IF NOT EXISTS %s AS %s (READ_ONLY)", sqlString(r.URL), remoteCatalog),
+		"USE "+remoteCatalog)
+}
+
+// OpenRemote opens the snapshot database at src.URL read-only over
+// DuckDB's httpfs extension, without downloading it first: queries fetch
+// the blocks they read. Unqualified table names resolve to it, so a Repo
+// on the client serves it like a local file. It is never migrated.
+func OpenRemote(src RemoteSource, opts ...DuckDBOption) (*DuckDBClient, error) {
+	if !IsRemote(src.URL) {
+		return nil, fmt.Errorf("remote database %q: want a URL starting with one of %s", src.URL, strings.Join(remoteSchemes, ", "))
+	}
+	client, err := NewDuckDBClient(":memory:", append(opts, WithInit(src.statements()...))...)
+	if err != nil {
+		return nil, fmt.Errorf("attach %s: %w", src.URL, err)
+	}
+	return client, nil
+}
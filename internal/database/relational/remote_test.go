@@ -0,0 +1,52 @@
+package relational
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRemoteSourceStatements(t *testing.T) {
+	for dsn, want := range map[string]bool{
+		"s3://fleet/syschecker.db":          true,
+		"HTTPS://example.com/snap.duckdb":   true,
+		"/var/lib/syschecker/syschecker.db": false,
+		"syschecker.db":                     false,
+	} {
+		if got := IsRemote(dsn); got != want {
+			t.Errorf("IsRemote(%q) = %v", dsn, got)
+		}
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIA")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "it's secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	t.Setenv(EnvS3Endpoint, "http://minio:9000/")
+	t.Setenv(EnvRemoteToken, "")
+	stmts := RemoteSourceFromEnv("s3://fleet/o'brien.db").statements()
+
+	want := []string{
+		"INSTALL httpfs",
+		"LOAD httpfs",
+		"CREATE SECRET IF NOT EXISTS syschecker_s3 (TYPE S3, KEY_ID 'AKIA', SECRET 'it''s secret', REGION 'eu-west-1', ENDPOINT 'minio:9000', URL_STYLE 'path', USE_SSL false)",
+		"ATTACH IF NOT EXISTS 's3://fleet/o''brien.db' AS remote (READ_ONLY)",
+		"USE remote",
+	}
+	if !slices.Equal(stmts, want) {
+		t.Errorf("statements:\n%s\nwant:\n%s", strings.Join(stmts, "\n"), strings.Join(want, "\n"))
+	}
+
+	stmts = RemoteSource{URL: "https://example.com/snap.duckdb", BearerToken: "tok"}.statements()
+	if !slices.Contains(stmts, "CREATE SECRET IF NOT EXISTS syschecker_http (TYPE HTTP, EXTRA_HTTP_HEADERS MAP {'Authorization': 'Bearer tok'})") {
+		t.Errorf("no bearer secret in %q", stmts)
+	}
+	if slices.ContainsFunc(stmts, func(s string) bool { return strings.Contains(s, "TYPE S3") }) {
+		t.Errorf("S3 secret without S3 credentials in %q", stmts)
+	}
+
+	if _, err := OpenRemote(RemoteSource{URL: "syschecker.db"}); err == nil {
+		t.Error("OpenRemote accepted a local path")
+	}
+}
@@ -0,0 +1,253 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"syschecker/internal/collector"
+)
+
+// SnapshotProvider serves the latest stored snapshot as collector stats, so
+// views built on collector.StatsProvider can run from a database alone,
+// e.g. a copied DuckDB file opened read-only.
+type SnapshotProvider struct {
+	repo     *Repo
+	hostname string
+}
+
+// NewSnapshotProvider reads snapshots of hostname, or of any host when empty.
+func NewSnapshotProvider(repo *Repo, hostname string) *SnapshotProvider {
+	return &SnapshotProvider{repo: repo, hostname: hostname}
+}
+
+func (p *SnapshotProvider) GetFastMetrics(ctx context.Context) (*collector.RawStats, error) {
+	return p.repo.LatestRawStats(ctx, p.hostname)
+}
+
+func (p *SnapshotProvider) GetSlowMetrics(ctx context.Context) (*collector.RawStats, error) {
+	return p.repo.LatestRawStats(ctx, p.hostname)
+}
+
+// StoredSnapshot is a snapshot rebuilt from the database, for replaying
+// it through the pipeline.
+type StoredSnapshot struct {
+	ID          int64
+	CollectedAt time.Time
+	AgentID     string
+	MachineID   string
+	BootID      string
+	Stats       *collector.RawStats
+	Derived     DerivedRates // the rates stored with the snapshot
+}
+
+// SnapshotRef locates a stored snapshot.
+type SnapshotRef struct {
+	ID          int64
+	CollectedAt time.Time
+}
+
+// snapshotSelect reads the columns scanned by loadSnapshot.
+const snapshotSelect = `
+		SELECT s.snapshot_id, s.collected_at, h.agent_id, h.machine_id, h.boot_id,
+		  s.cpu_usage_pct, s.load_avg_1, s.load_avg_5, s.load_avg_15, s.cpu_model, s.cpu_cores_logical,
+		  s.ram_usage_pct, s.ram_total_bytes, s.ram_available_bytes, s.ram_used_bytes, s.ram_free_bytes,
+		  s.swap_usage_pct, s.swap_total_bytes, s.swap_used_bytes,
+		  s.disk_usage_pct, s.disk_total_bytes, s.inode_usage_pct,
+		  s.net_latency_ms, s.is_connected, s.active_tcp, s.vpn_interface,
+		  s.docker_available, h.hostname, s.os, s.platform, s.kernel_version, s.uptime_seconds, s.procs,
+		  s.disk_read_bps, s.disk_write_bps, s.disk_read_iops, s.disk_write_iops,
+		  s.disk_avg_read_lat_ms, s.disk_avg_write_lat_ms,
+		  s.net_tx_bps, s.net_rx_bps, s.net_err_per_s, s.net_drop_per_s
+		FROM snapshots s
+		JOIN hosts h ON h.host_id = s.host_id`
+
+// LatestRawStats rebuilds the host-level metrics and top processes of the
+// most recent snapshot. Per-device details are not restored.
+func (r *Repo) LatestRawStats(ctx context.Context, hostname string) (*collector.RawStats, error) {
+	query := snapshotSelect
+	var args []any
+	if hostname != "" {
+		query += " WHERE h.hostname = ?"
+		args = append(args, hostname)
+	}
+	query += " ORDER BY s.collected_at DESC LIMIT 1"
+
+	snap, err := r.loadSnapshot(ctx, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no snapshots found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load latest snapshot: %w", err)
+	}
+	return snap.Stats, nil
+}
+
+// SnapshotRange lists the snapshots of hostname collected in [from, to),
+// oldest first. An empty hostname picks the most recently seen host; a
+// zero to means up to now.
+func (r *Repo) SnapshotRange(ctx context.Context, hostname string, from, to time.Time) ([]SnapshotRef, error) {
+	if hostname == "" {
+		err := r.db.QueryRowContext(ctx, `
+			SELECT h.hostname FROM snapshots s JOIN hosts h ON h.host_id = s.host_id
+			ORDER BY s.collected_at DESC LIMIT 1`).Scan(&hostname)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no snapshots found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find latest host: %w", err)
+		}
+	}
+	query := `
+		SELECT s.snapshot_id, s.collected_at
+		FROM snapshots s
+		JOIN hosts h ON h.host_id = s.host_id
+		WHERE h.hostname = ? AND s.collected_at >= ?`
+	args := []any{hostname, from.UTC()}
+	if !to.IsZero() {
+		query += " AND s.collected_at < ?"
+		args = append(args, to.UTC())
+	}
+	query += " ORDER BY s.collected_at, s.snapshot_id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	defer rows.Close()
+	var refs []SnapshotRef
+	for rows.Next() {
+		var ref SnapshotRef
+		if err := rows.Scan(&ref.ID, &ref.CollectedAt); err != nil {
+			return nil, fmt.Errorf("scan snapshot: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return refs, nil
+}
+
+// LoadSnapshot rebuilds the snapshot with the given ID like LatestRawStats,
+// along with its identity and stored rates.
+func (r *Repo) LoadSnapshot(ctx context.Context, id int64) (*StoredSnapshot, error) {
+	snap, err := r.loadSnapshot(ctx, snapshotSelect+" WHERE s.snapshot_id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("snapshot %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot %d: %w", id, err)
+	}
+	return snap, nil
+}
+
+// loadSnapshot scans the first row of a snapshotSelect query and its CPU
+// cores and top processes.
+func (r *Repo) loadSnapshot(ctx context.Context, query string, args ...any) (*StoredSnapshot, error) {
+	var (
+		snap                                           StoredSnapshot
+		agentID, machineID, bootID                     sql.NullString
+		cpu, load1, load5, load15, ram, swap, disk     sql.NullFloat64
+		inode, latency                                 sql.NullFloat64
+		cpuModel, vpn, host, osName, platform, kernel  sql.NullString
+		coreCount, activeTCP                           sql.NullInt64
+		ramAvail, ramUsed, ramFree, swapTotal, swapUse NullUint64
+		ramTotal, diskTotal, uptime, procs             NullUint64
+		connected, docker                              sql.NullBool
+		rates                                          [10]sql.NullFloat64
+	)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&snap.ID, &snap.CollectedAt, &agentID, &machineID, &bootID,
+		&cpu, &load1, &load5, &load15, &cpuModel, &coreCount,
+		&ram, &ramTotal, &ramAvail, &ramUsed, &ramFree,
+		&swap, &swapTotal, &swapUse,
+		&disk, &diskTotal, &inode,
+		&latency, &connected, &activeTCP, &vpn,
+		&docker, &host, &osName, &platform, &kernel, &uptime, &procs,
+		&rates[0], &rates[1], &rates[2], &rates[3], &rates[4], &rates[5],
+		&rates[6], &rates[7], &rates[8], &rates[9])
+	if err != nil {
+		return nil, err
+	}
+	snapshotID := snap.ID
+	snap.CollectedAt = snap.CollectedAt.UTC()
+	snap.AgentID, snap.MachineID, snap.BootID = agentID.String, machineID.String, bootID.String
+	snap.Derived = DerivedRates{
+		DiskReadBps: rates[0].Float64, DiskWriteBps: rates[1].Float64,
+		DiskReadIops: rates[2].Float64, DiskWriteIops: rates[3].Float64,
+		DiskAvgReadLatMs: rates[4].Float64, DiskAvgWriteLatMs: rates[5].Float64,
+		NetTxBps: rates[6].Float64, NetRxBps: rates[7].Float64,
+		NetErrPerS: rates[8].Float64, NetDropPerS: rates[9].Float64,
+	}
+
+	stats := &collector.RawStats{
+		CPUUsage:          cpu.Float64,
+		LoadAvg1:          load1.Float64,
+		LoadAvg5:          load5.Float64,
+		LoadAvg15:         load15.Float64,
+		CPUModel:          cpuModel.String,
+		CPUCores:          int(coreCount.Int64),
+		RAMUsage:          ram.Float64,
+		RAMAvailableBytes: ramAvail.Uint64,
+		RAMUsedBytes:      ramUsed.Uint64,
+		RAMFreeBytes:      ramFree.Uint64,
+		RAMTotalBytes:     ramTotal.Uint64,
+		SwapUsage:         swap.Float64,
+		SwapTotalBytes:    swapTotal.Uint64,
+		SwapUsedBytes:     swapUse.Uint64,
+		DiskUsage:         disk.Float64,
+		DiskTotalBytes:    diskTotal.Uint64,
+		InodeUsage:        inode.Float64,
+		NetLatency_ms:     latency.Float64,
+		IsConnected:       connected.Bool,
+		ActiveTCP:         int(activeTCP.Int64),
+		VPNInterface:      vpn.String,
+		DockerAvailable:   docker.Bool,
+		Hostname:          host.String,
+		OS:                osName.String,
+		Platform:          platform.String,
+		KernelVersion:     kernel.String,
+		Uptime:            uptime.Uint64,
+		Procs:             procs.Uint64,
+	}
+	snap.Stats = stats
+
+	coreRows, err := r.db.QueryContext(ctx, `SELECT usage_pct FROM snapshot_cpu_cores WHERE snapshot_id = ? ORDER BY core_index`, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("load cpu cores: %w", err)
+	}
+	defer coreRows.Close()
+	for coreRows.Next() {
+		var pct float64
+		if err := coreRows.Scan(&pct); err != nil {
+			return nil, fmt.Errorf("scan cpu core: %w", err)
+		}
+		stats.CPUPerCore = append(stats.CPUPerCore, pct)
+	}
+	if err := coreRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tp.pid, pn.name, COALESCE(tp.cpu_pct, 0), COALESCE(tp.mem_pct, 0)
+		FROM snapshot_top_processes tp
+		JOIN process_names pn ON pn.process_name_id = tp.process_name_id
+		WHERE tp.snapshot_id = ?
+		ORDER BY tp.rank`, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("load top processes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p collector.ProcessStat
+		if err := rows.Scan(&p.PID, &p.Name, &p.CPU, &p.Memory); err != nil {
+			return nil, fmt.Errorf("scan top process: %w", err)
+		}
+		stats.TopProcesses = append(stats.TopProcesses, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,77 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSnapshotProviderReplaysLatest(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	for i, cpu := range []float64{10, 55} {
+		s := RawStatsFixed{
+			AgentID: "a1", Hostname: "web-1", CollectedAt: time.Now().Add(time.Duration(i) * time.Minute),
+			CPUUsagePct: cpu, CPUPerCorePct: []float64{cpu - 5, cpu + 5}, RAMTotalBytes: 8 << 30, IsConnected: true,
+			TopProcesses: []ProcessStatFixed{{Rank: 1, PID: 42, Name: "postgres", CPUPct: cpu, MemPct: 12}},
+		}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := NewSnapshotProvider(repo, "web-1").GetFastMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CPUUsage != 55 || got.Hostname != "web-1" || got.RAMTotalBytes != 8<<30 || !got.IsConnected {
+		t.Errorf("stats = %+v", got)
+	}
+	if len(got.CPUPerCore) != 2 || got.CPUPerCore[1] != 60 {
+		t.Errorf("per-core = %v", got.CPUPerCore)
+	}
+	if len(got.TopProcesses) != 1 || got.TopProcesses[0].Name != "postgres" || got.TopProcesses[0].PID != 42 {
+		t.Errorf("top processes = %+v", got.TopProcesses)
+	}
+
+	if _, err := NewSnapshotProvider(repo, "db-1").GetSlowMetrics(ctx); err == nil {
+		t.Error("expected an error for a host without snapshots")
+	}
+}
+
+func TestSnapshotRangeAndLoad(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, host := range []string{"web-1", "web-1", "db-1", "web-1"} {
+		s := RawStatsFixed{
+			AgentID: host, Hostname: host, CollectedAt: start.Add(time.Duration(i) * time.Minute),
+			CPUUsagePct: float64(10 * (i + 1)),
+		}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{NetRxBps: float64(i)}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs, err := repo.SnapshotRange(ctx, "web-1", start.Add(time.Minute), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || !refs[0].CollectedAt.Equal(start.Add(time.Minute)) || !refs[1].CollectedAt.Equal(start.Add(3*time.Minute)) {
+		t.Fatalf("refs = %+v", refs)
+	}
+	snap, err := repo.LoadSnapshot(ctx, refs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.AgentID != "web-1" || snap.Stats.CPUUsage != 40 || snap.Derived.NetRxBps != 3 {
+		t.Errorf("snapshot = %+v, stats %+v", snap, snap.Stats)
+	}
+
+	// Without a host, the most recently seen one is replayed.
+	if refs, err := repo.SnapshotRange(ctx, "", start, start.Add(2*time.Minute)); err != nil || len(refs) != 2 {
+		t.Errorf("got %+v, %v", refs, err)
+	}
+}
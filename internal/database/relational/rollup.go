@@ -0,0 +1,197 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// RollupMetrics are the snapshot columns summarized in snapshots_hourly,
+// each as <metric>_avg, <metric>_min and <metric>_max. Adding one needs an
+// ALTER TABLE migration for existing databases.
+var RollupMetrics = []string{
+	"cpu_usage_pct",
+	"load_avg_1",
+	"ram_usage_pct",
+	"swap_usage_pct",
+	"disk_usage_pct",
+	"inode_usage_pct",
+	"net_latency_ms",
+	"active_tcp",
+	"disk_read_bps",
+	"disk_write_bps",
+	"net_tx_bps",
+	"net_rx_bps",
+	"severity_level",
+	"risk_score",
+}
+
+// rollupInterval bounds how often inserts refresh snapshots_hourly.
+const rollupInterval = 5 * time.Minute
+
+// rollupTableSQL creates snapshots_hourly: one row per host and UTC hour
+// with per-metric aggregates and, in flag_counts, how many snapshots had
+// each flag set, indexed like FlagNames. RefreshRollups keeps (host_id,
+// hour) unique; a primary key would make its delete-and-reinsert fail in
+// DuckDB.
+func rollupTableSQL() string {
+	var b strings.Builder
+	b.WriteString(`CREATE TABLE IF NOT EXISTS snapshots_hourly (
+  host_id     BIGINT NOT NULL,
+  hour        TIMESTAMP NOT NULL,
+  samples     BIGINT NOT NULL,
+`)
+	for _, m := range RollupMetrics {
+		fmt.Fprintf(&b, "  %[1]s_avg DOUBLE,\n  %[1]s_min DOUBLE,\n  %[1]s_max DOUBLE,\n", m)
+	}
+	b.WriteString(`  flag_counts BIGINT[]
+);`)
+	return b.String()
+}
+
+// rollupWeight counts a snapshot once plus once per repeat that change-only
+// persistence skipped, so idle stretches keep their share of each hour.
+// Repeats are attributed to the hour of the snapshot they repeat.
+const rollupWeight = "(1 + COALESCE(repeat_count, 0))"
+
+// rollupSelectSQL aggregates snapshots collected at or after the bound
+// parameter into rows shaped like snapshots_hourly.
+func rollupSelectSQL() string {
+	cols := []string{"host_id", "date_trunc('hour', collected_at) AS hour", "CAST(sum(" + rollupWeight + ") AS BIGINT)"}
+	for _, m := range RollupMetrics {
+		cols = append(cols, fmt.Sprintf("sum(%[1]s * %[2]s) / sum(CASE WHEN %[1]s IS NOT NULL THEN %[2]s END), min(%[1]s), max(%[1]s)", m, rollupWeight))
+	}
+	counts := make([]string, len(FlagNames))
+	for i := range FlagNames {
+		counts[i] = fmt.Sprintf("CAST(sum(((COALESCE(flags_bitmask, 0) >> %d) & 1) * %s) AS BIGINT)", i, rollupWeight)
+	}
+	cols = append(cols, "["+strings.Join(counts, ", ")+"]")
+	return "SELECT " + strings.Join(cols, ",\n  ") + `
+FROM snapshots
+WHERE collected_at >= ?
+GROUP BY host_id, hour`
+}
+
+// RefreshRollups recomputes snapshots_hourly and containers_hourly for
+// every hour from since onwards. Whole hours are replaced, so a partial hour
+// is simply refreshed again by the next call.
+func (r *Repo) RefreshRollups(ctx context.Context, since time.Time) error {
+	since = since.UTC().Truncate(time.Hour)
+	r.rollupMu.Lock()
+	defer r.rollupMu.Unlock()
+	// DuckDB cannot update list columns in place, so replace rows by
+	// deleting and reinserting them.
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("refresh hourly rollups: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM snapshots_hourly WHERE hour >= ?`, since); err != nil {
+		return fmt.Errorf("refresh hourly rollups: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO snapshots_hourly\n"+rollupSelectSQL(), since); err != nil {
+		return fmt.Errorf("refresh hourly rollups: %w", err)
+	}
+	if err := refreshContainerRollups(ctx, tx, since); err != nil {
+		return fmt.Errorf("refresh container rollups: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("refresh hourly rollups: %w", err)
+	}
+	return nil
+}
+
+// updateRollups refreshes the hours from the newest rolled-up hour onwards,
+// which is all that can have changed since the last refresh unless older
+// snapshots were backfilled (use RefreshRollups for those).
+func (r *Repo) updateRollups(ctx context.Context) error {
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT max(hour) FROM snapshots_hourly`).Scan(&last); err != nil {
+		return fmt.Errorf("read rollup watermark: %w", err)
+	}
+	return r.RefreshRollups(ctx, last.Time)
+}
+
+// maybeUpdateRollups runs updateRollups at most once per rollupInterval.
+func (r *Repo) maybeUpdateRollups(ctx context.Context) {
+	now := r.clock.Now()
+	r.mu.Lock()
+	due := now.Sub(r.lastRollup) >= rollupInterval
+	if due {
+		r.lastRollup = now
+	}
+	r.mu.Unlock()
+	if due {
+		if err := r.updateRollups(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Hourly rollup failed: %v\n", err)
+		}
+	}
+}
+
+// HourlyPoint summarizes one metric over one hour.
+type HourlyPoint struct {
+	Hour    time.Time `json:"hour"`
+	Samples int64     `json:"samples"`
+	Avg     float64   `json:"avg"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+}
+
+// HourlyTrend returns hourly aggregates of a RollupMetrics column since
+// since, oldest first, read from snapshots_hourly instead of raw snapshots.
+// With hostname empty the hours of all hosts are combined.
+func (r *Repo) HourlyTrend(ctx context.Context, hostname, metric string, since time.Time) ([]HourlyPoint, error) {
+	if !isRollupMetric(metric) {
+		return nil, fmt.Errorf("metric %q is not rolled up (want one of %s)", metric, strings.Join(RollupMetrics, ", "))
+	}
+	if err := r.updateRollups(ctx); err != nil {
+		return nil, err
+	}
+
+	// Combining hosts weights each host's hourly average by its samples.
+	query := fmt.Sprintf(`
+		SELECT r.hour, sum(r.samples),
+		  COALESCE(sum(r.%[1]s_avg * r.samples) / sum(r.samples), 0),
+		  COALESCE(min(r.%[1]s_min), 0), COALESCE(max(r.%[1]s_max), 0)
+		FROM snapshots_hourly r
+		LEFT JOIN hosts h ON r.host_id = h.host_id
+		WHERE r.hour >= ?
+	`, metric)
+	args := []any{since.UTC().Truncate(time.Hour)}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	query += " GROUP BY r.hour ORDER BY r.hour"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("hourly trend query failed: %w", err)
+	}
+	defer rows.Close()
+
+	points := []HourlyPoint{}
+	for rows.Next() {
+		var p HourlyPoint
+		if err := rows.Scan(&p.Hour, &p.Samples, &p.Avg, &p.Min, &p.Max); err != nil {
+			return nil, fmt.Errorf("scan hourly point failed: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return points, nil
+}
+
+func isRollupMetric(name string) bool {
+	for _, m := range RollupMetrics {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
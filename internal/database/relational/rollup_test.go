@@ -0,0 +1,149 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHourlyRollups(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	hour := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	insert := func(host string, at time.Time, cpu float64, f SnapshotFlags) {
+		t.Helper()
+		s := RawStatsFixed{AgentID: host, Hostname: host, CollectedAt: at, CPUUsagePct: cpu}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("web-1", hour.Add(10*time.Minute), 20, SnapshotFlags{})
+	insert("web-1", hour.Add(40*time.Minute), 60, SnapshotFlags{FlagCPUOverloaded: true, SeverityLevel: 2})
+	insert("db-1", hour.Add(20*time.Minute), 90, SnapshotFlags{FlagCPUOverloaded: true, SeverityLevel: 3})
+	insert("web-1", hour.Add(70*time.Minute), 30, SnapshotFlags{})
+
+	points, err := repo.HourlyTrend(ctx, "web-1", "cpu_usage_pct", hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("points = %+v, want 2 hours", points)
+	}
+	if p := points[0]; !p.Hour.Equal(hour) || p.Samples != 2 || p.Avg != 40 || p.Min != 20 || p.Max != 60 {
+		t.Errorf("first hour = %+v", p)
+	}
+
+	// A late snapshot in an already rolled-up hour is picked up by the next read.
+	insert("web-1", hour.Add(80*time.Minute), 50, SnapshotFlags{})
+	points, err = repo.HourlyTrend(ctx, "", "cpu_usage_pct", hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[0].Samples != 3 || points[0].Max != 90 || points[1].Avg != 40 {
+		t.Errorf("all hosts = %+v", points)
+	}
+
+	var counts []any
+	err = repo.db.QueryRow(`SELECT flag_counts FROM snapshots_hourly r JOIN hosts h USING (host_id)
+		WHERE h.hostname = 'web-1' AND r.hour = ?`, hour).Scan(&counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != len(FlagNames) || counts[1] != int64(1) || counts[0] != int64(0) {
+		t.Errorf("flag counts = %v", counts)
+	}
+
+	hm, err := repo.SeverityHeatmap(ctx, "", hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cell := hm.Cells[hour.Weekday()][hour.Hour()]; cell.MaxSeverity != 3 || cell.Samples != 3 {
+		t.Errorf("heatmap cell = %+v", cell)
+	}
+
+	if _, err := repo.HourlyTrend(ctx, "", "cpu_model", hour); err == nil {
+		t.Error("expected an error for a metric that is not rolled up")
+	}
+}
+
+func TestRecordRepeatWeightsRollups(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	hour := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	idle, err := repo.InsertRawStats(ctx, RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: hour.Add(5 * time.Minute), CPUUsagePct: 10}, DerivedRates{}, SnapshotFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if err := repo.RecordRepeat(ctx, idle.SnapshotID, hour.Add(time.Duration(6+i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.InsertRawStats(ctx, RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: hour.Add(30 * time.Minute), CPUUsagePct: 50}, DerivedRates{}, SnapshotFlags{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.QuerySnapshots(ctx, "web-1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].RepeatCount != 2 || got[0].RepeatCount != 0 {
+		t.Errorf("snapshots = %+v, want the older one repeated twice", got)
+	}
+	points, err := repo.HourlyTrend(ctx, "web-1", "cpu_usage_pct", hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].Samples != 4 || points[0].Avg != 20 {
+		t.Errorf("points = %+v, want 4 samples averaging 20", points)
+	}
+
+	if err := repo.RecordRepeat(ctx, 42, hour); err == nil {
+		t.Error("expected an error for an unknown snapshot")
+	}
+}
+
+func TestContainerHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	hour := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	for i, c := range []DockerContainerInfoFixed{
+		{Running: true, CPUUsagePct: 10, MemUsageBytes: 100 << 20},
+		{Running: false},
+		{Running: true, CPUUsagePct: 50, MemUsageBytes: 300 << 20},
+		{Running: true, CPUUsagePct: 20, MemUsageBytes: 200 << 20}, // next hour
+	} {
+		c.ID, c.Name, c.Image = "abc123def456", "/api", "api:1"
+		s := RawStatsFixed{
+			AgentID: "web-1", Hostname: "web-1", CollectedAt: hour.Add(time.Duration(i) * 20 * time.Minute),
+			DockerContainers: []DockerContainerInfoFixed{c, {ID: "fff000", Name: "/db", Running: true, CPUUsagePct: 5}},
+		}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hours, err := repo.ContainerHistory(ctx, "web-1", "api", hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hours) != 2 {
+		t.Fatalf("hours = %+v, want 2", hours)
+	}
+	if h := hours[0]; h.Samples != 3 || h.CPUMaxPct != 50 || h.MemMaxBytes != 300<<20 || h.Restarts != 1 || h.Image != "api:1" {
+		t.Errorf("first hour = %+v", h)
+	}
+	if hours[1].Restarts != 0 || hours[1].CPUAvgPct != 20 {
+		t.Errorf("second hour = %+v", hours[1])
+	}
+
+	if all, err := repo.ContainerHistory(ctx, "", "abc1", hour); err != nil || len(all) != 2 {
+		t.Errorf("by ID prefix = %+v (%v)", all, err)
+	}
+	if all, err := repo.ContainerHistory(ctx, "", "", hour); err != nil || len(all) != 4 {
+		t.Errorf("all containers = %d hours (%v), want 4", len(all), err)
+	}
+}
@@ -0,0 +1,88 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SeasonalRange is the expected range of a metric for one hour-of-week slot.
+type SeasonalRange struct {
+	Metric     string  `json:"metric"`
+	HourOfWeek int     `json:"hour_of_week"` // 0 = Sunday 00:00 UTC
+	Samples    int64   `json:"samples"`
+	Mean       float64 `json:"mean"`
+	P10        float64 `json:"p10"`
+	P90        float64 `json:"p90"`
+}
+
+// SeasonalDeviation records a metric that is well outside its usual range for the time of week.
+type SeasonalDeviation struct {
+	Metric       string  `json:"metric"`
+	Slot         string  `json:"slot"` // e.g. "Tuesday 14:00"
+	Actual       float64 `json:"actual"`
+	ExpectedMean float64 `json:"expected_mean"`
+	ExpectedLow  float64 `json:"expected_low"`
+	ExpectedHigh float64 `json:"expected_high"`
+	Ratio        float64 `json:"ratio"` // actual / expected mean
+}
+
+// HourOfWeek returns the UTC hour-of-week slot (0..167) for t.
+func HourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// MetricValue returns the value of a snapshot column by name.
+func (s *RawStatsFixed) MetricValue(metric string) (float64, bool) {
+	switch metric {
+	case "cpu_usage_pct":
+		return s.CPUUsagePct, true
+	case "ram_usage_pct":
+		return s.RAMUsagePct, true
+	case "disk_usage_pct":
+		return s.DiskUsagePct, true
+	case "inode_usage_pct":
+		return s.InodeUsagePct, true
+	case "net_latency_ms":
+		return s.NetLatencyMS, true
+	}
+	return 0, false
+}
+
+// ExpectedRange computes the metric's range for the hour-of-week slot of at,
+// using history in [at-lookback, start of at's hour).
+func (r *Repo) ExpectedRange(ctx context.Context, agentID, metric string, at time.Time, lookback time.Duration) (SeasonalRange, error) {
+	if !isBaselineMetric(metric) {
+		return SeasonalRange{}, fmt.Errorf("unknown metric %q", metric)
+	}
+	at = at.UTC()
+	slot := HourOfWeek(at)
+
+	// metric is validated against BaselineMetrics above.
+	query := fmt.Sprintf(`
+		SELECT
+		  COUNT(m),
+		  COALESCE(AVG(m), 0),
+		  COALESCE(quantile_cont(m, 0.10), 0),
+		  COALESCE(quantile_cont(m, 0.90), 0)
+		FROM (
+		  SELECT s.%s AS m
+		  FROM snapshots s
+		  JOIN hosts h ON s.host_id = h.host_id
+		  WHERE h.agent_id = ?
+		    AND s.collected_at >= ? AND s.collected_at < ?
+		    AND dayofweek(s.collected_at) = ? AND hour(s.collected_at) = ?
+		    AND s.%s IS NOT NULL
+		)
+	`, metric, metric)
+
+	sr := SeasonalRange{Metric: metric, HourOfWeek: slot}
+	err := r.db.QueryRowContext(ctx, query,
+		agentID, at.Add(-lookback), at.Truncate(time.Hour), int(at.Weekday()), at.Hour(),
+	).Scan(&sr.Samples, &sr.Mean, &sr.P10, &sr.P90)
+	if err != nil {
+		return SeasonalRange{}, fmt.Errorf("expected range for %s failed: %w", metric, err)
+	}
+	return sr, nil
+}
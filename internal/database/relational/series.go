@@ -0,0 +1,117 @@
+package relational
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultSeriesPoints is how many points MetricSeries returns when asked
+// for none; enough for a chart a few hundred pixels wide.
+const DefaultSeriesPoints = 500
+
+// SeriesPoint is one sample of a metric series.
+type SeriesPoint struct {
+	At    time.Time `json:"at"`
+	Value float64   `json:"value"`
+}
+
+// MetricSeries returns metric, one of MetricColumns, for snapshots of
+// hostname (any host when empty) collected in [from, to), oldest first. A
+// zero to means now. When there are more than points samples the series is
+// downsampled with LTTB, keeping the shape of spikes that averaging would
+// flatten; points <= 0 uses DefaultSeriesPoints.
+func (r *Repo) MetricSeries(ctx context.Context, hostname, metric string, from, to time.Time, points int) ([]SeriesPoint, error) {
+	if !IsMetricColumn(metric) {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+	if to.IsZero() {
+		to = r.clock.Now()
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("empty range %s to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if points <= 0 {
+		points = DefaultSeriesPoints
+	}
+
+	// The metric name is validated against MetricColumns above.
+	query := fmt.Sprintf(`
+		SELECT s.collected_at, s.%s
+		FROM snapshots s
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.collected_at >= ? AND s.collected_at < ? AND s.%s IS NOT NULL`, metric, metric)
+	args := []any{from.UTC(), to.UTC()}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	query += " ORDER BY s.collected_at"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s series: %w", metric, err)
+	}
+	defer rows.Close()
+	series := []SeriesPoint{}
+	for rows.Next() {
+		var p SeriesPoint
+		if err := rows.Scan(&p.At, &p.Value); err != nil {
+			return nil, fmt.Errorf("scan %s series: %w", metric, err)
+		}
+		p.At = p.At.UTC()
+		series = append(series, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return LTTB(series, points), nil
+}
+
+// LTTB downsamples series to n points with Largest-Triangle-Three-Buckets:
+// the first and last points are kept, and from each of n-2 equal buckets
+// in between the point forming the largest triangle with the point kept
+// before it and the average of the next bucket. Series of n points or
+// fewer, or n < 3, are returned unchanged.
+func LTTB(series []SeriesPoint, n int) []SeriesPoint {
+	if n < 3 || len(series) <= n {
+		return series
+	}
+	x := func(p SeriesPoint) float64 { return float64(p.At.UnixMilli()) }
+
+	out := make([]SeriesPoint, 0, n)
+	out = append(out, series[0])
+	every := float64(len(series)-2) / float64(n-2)
+	kept := 0
+	for i := range n - 2 {
+		start := int(float64(i)*every) + 1
+		end := int(float64(i+1)*every) + 1
+
+		// Average of the next bucket, or the last point for the final one.
+		nextEnd := min(int(float64(i+2)*every)+1, len(series))
+		var avgX, avgY float64
+		for _, p := range series[end:nextEnd] {
+			avgX += x(p)
+			avgY += p.Value
+		}
+		if count := nextEnd - end; count > 0 {
+			avgX /= float64(count)
+			avgY /= float64(count)
+		} else {
+			avgX, avgY = x(series[len(series)-1]), series[len(series)-1].Value
+		}
+
+		ax, ay := x(series[kept]), series[kept].Value
+		best, bestArea := start, -1.0
+		for j := start; j < end; j++ {
+			area := math.Abs((ax-avgX)*(series[j].Value-ay) - (ax-x(series[j]))*(avgY-ay))
+			if area > bestArea {
+				best, bestArea = j, area
+			}
+		}
+		out = append(out, series[best])
+		kept = best
+	}
+	return append(out, series[len(series)-1])
+}
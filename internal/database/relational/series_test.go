@@ -0,0 +1,64 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLTTB(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var series []SeriesPoint
+	for i := range 1000 {
+		v := 10.0
+		if i == 517 {
+			v = 95 // a one-sample spike averaging would flatten
+		}
+		series = append(series, SeriesPoint{At: start.Add(time.Duration(i) * time.Second), Value: v})
+	}
+
+	got := LTTB(series, 50)
+	if len(got) != 50 {
+		t.Fatalf("got %d points, want 50", len(got))
+	}
+	if got[0] != series[0] || got[49] != series[999] {
+		t.Error("first and last points not kept")
+	}
+	spike := false
+	for i, p := range got {
+		if i > 0 && !p.At.After(got[i-1].At) {
+			t.Fatalf("points out of order at %d", i)
+		}
+		spike = spike || p.Value == 95
+	}
+	if !spike {
+		t.Error("spike lost")
+	}
+
+	if got := LTTB(series[:10], 50); len(got) != 10 {
+		t.Errorf("short series changed: %d points", len(got))
+	}
+}
+
+func TestMetricSeries(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 20 {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: start.Add(time.Duration(i) * time.Minute), CPUUsagePct: float64(i)}
+		if _, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.MetricSeries(ctx, "web-1", "cpu_usage_pct", start, start.Add(time.Hour), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[0].Value != 0 || got[4].Value != 19 {
+		t.Errorf("series = %+v", got)
+	}
+	if _, err := repo.MetricSeries(ctx, "web-1", "hostname; DROP TABLE hosts", start, time.Time{}, 0); err == nil {
+		t.Error("unknown metric accepted")
+	}
+}
@@ -0,0 +1,250 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// StorageBudget caps the database size. Once live data exceeds MaxBytes,
+// retention runs early: expired artifacts are pruned and the oldest
+// snapshots deleted until usage is back under TargetPct of the budget.
+// Hourly rollups, bookmarked snapshots and each host's latest snapshot are
+// kept. DuckDB reuses freed blocks rather than shrinking the file, so the
+// file settles near the budget.
+type StorageBudget struct {
+	MaxBytes   int64
+	TargetPct  float64       // prune down to this share of MaxBytes (default 80)
+	KeepRecent time.Duration // never delete snapshots newer than this (default 24h)
+}
+
+// DefaultStorageBudget returns a budget of maxBytes with the default target
+// and recent window.
+func DefaultStorageBudget(maxBytes int64) StorageBudget {
+	return StorageBudget{MaxBytes: maxBytes, TargetPct: 80, KeepRecent: 24 * time.Hour}
+}
+
+// storageCheckInterval bounds how often inserts check the budget.
+const storageCheckInterval = 5 * time.Minute
+
+// WithStorageBudget enforces b after inserts.
+func WithStorageBudget(b StorageBudget) RepoOption {
+	return func(r *Repo) {
+		r.budget = b
+	}
+}
+
+// StorageUsage is the size of the database.
+type StorageUsage struct {
+	Path        string `json:"path,omitempty"` // empty for in-memory databases
+	FileBytes   int64  `json:"file_bytes"`     // database file plus WAL
+	UsedBytes   int64  `json:"used_bytes"`     // blocks holding data plus WAL
+	BudgetBytes int64  `json:"budget_bytes,omitempty"`
+}
+
+// BudgetPct is UsedBytes as a percent of the budget, or 0 without one.
+func (u StorageUsage) BudgetPct() float64 {
+	if u.BudgetBytes <= 0 {
+		return 0
+	}
+	return float64(u.UsedBytes) / float64(u.BudgetBytes) * 100
+}
+
+// StorageUsage reports the database size and the configured budget.
+func (r *Repo) StorageUsage(ctx context.Context) (StorageUsage, error) {
+	u := StorageUsage{BudgetBytes: r.budget.MaxBytes}
+	var path sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT path FROM duckdb_databases() WHERE database_name = current_database()`).Scan(&path)
+	if err != nil {
+		return u, fmt.Errorf("query database path: %w", err)
+	}
+	var blockSize, usedBlocks int64
+	err = r.db.QueryRowContext(ctx, `SELECT block_size, used_blocks FROM pragma_database_size() WHERE database_name = current_database()`).Scan(&blockSize, &usedBlocks)
+	if err != nil {
+		return u, fmt.Errorf("query database size: %w", err)
+	}
+	u.UsedBytes = blockSize * usedBlocks
+	if u.Path = path.String; u.Path != "" {
+		wal := fileSize(u.Path + ".wal")
+		u.FileBytes = fileSize(u.Path) + wal
+		u.UsedBytes += wal
+	}
+	return u, nil
+}
+
+// fileSize returns the size of path, or 0 if it is missing.
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// prunableSnapshot selects snapshots retention may delete: collected at
+// or before the bound parameter, not bookmarked and not a host's latest.
+const prunableSnapshot = `s.collected_at <= ?
+	AND NOT EXISTS (SELECT 1 FROM annotations a WHERE a.snapshot_id = s.snapshot_id)
+	AND NOT EXISTS (SELECT 1 FROM current_state c WHERE c.last_snapshot_id = s.snapshot_id)`
+
+// snapshotChildTables lists the tables holding per-snapshot rows deleted
+// with their snapshot: every table keyed by snapshot_id except snapshots
+// itself, annotations (bookmarked snapshots are never pruned) and artifacts
+// (removed with their spill files by deleteSnapshotArtifacts). Reading the
+// schema keeps tables added by later migrations from leaking orphans.
+func snapshotChildTables(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT c.table_name FROM information_schema.columns c
+		JOIN information_schema.tables t
+		  ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.column_name = 'snapshot_id' AND t.table_type = 'BASE TABLE'
+		  AND c.table_schema = current_schema()
+		  AND c.table_name NOT IN ('snapshots', 'annotations', 'artifacts')
+		ORDER BY c.table_name`)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshot tables: %w", err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("scan snapshot table: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// EnforceStorageBudget runs retention early when the database is over its
+// budget and returns how many snapshots were deleted.
+func (r *Repo) EnforceStorageBudget(ctx context.Context) (int, error) {
+	b := r.budget
+	if b.MaxBytes <= 0 {
+		return 0, nil
+	}
+	u, err := r.StorageUsage(ctx)
+	if err != nil || u.UsedBytes <= b.MaxBytes {
+		return 0, err
+	}
+	if _, err := r.PruneArtifacts(ctx); err != nil {
+		return 0, err
+	}
+	// Keep the long-term trend of the hours about to lose their snapshots.
+	if err := r.updateRollups(ctx); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT count(*) FROM snapshots`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count snapshots: %w", err)
+	}
+	target := float64(b.MaxBytes) * b.TargetPct / 100
+	n := int64(math.Ceil(float64(total) * (float64(u.UsedBytes) - target) / float64(u.UsedBytes)))
+	if n <= 0 || total == 0 {
+		return 0, nil
+	}
+
+	// The collected_at of the n-th oldest prunable snapshot bounds the delete.
+	var cutoff sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+		SELECT max(collected_at) FROM (
+		  SELECT s.collected_at FROM snapshots s WHERE `+prunableSnapshot+`
+		  ORDER BY s.collected_at LIMIT ?
+		)`, r.clock.Now().UTC().Add(-b.KeepRecent), n).Scan(&cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("find retention cutoff: %w", err)
+	}
+	if !cutoff.Valid {
+		return 0, nil
+	}
+
+	if err := r.deleteSnapshotArtifacts(ctx, cutoff.Time); err != nil {
+		return 0, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("delete snapshots: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	tables, err := snapshotChildTables(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	for _, table := range tables {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE snapshot_id IN (SELECT s.snapshot_id FROM snapshots s WHERE `+prunableSnapshot+`)`, cutoff.Time)
+		if err != nil {
+			return 0, fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM snapshots s WHERE `+prunableSnapshot, cutoff.Time)
+	if err != nil {
+		return 0, fmt.Errorf("delete snapshots: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("delete snapshots: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+
+	// Fold the WAL into the file so the freed blocks can be reused.
+	if _, err := r.db.ExecContext(ctx, `CHECKPOINT`); err != nil {
+		return int(deleted), fmt.Errorf("checkpoint: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// deleteSnapshotArtifacts removes the artifacts of snapshots about to be
+// pruned, including any spilled files.
+func (r *Repo) deleteSnapshotArtifacts(ctx context.Context, cutoff time.Time) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT artifact_id FROM artifacts WHERE snapshot_id IN (SELECT s.snapshot_id FROM snapshots s WHERE `+prunableSnapshot+`)`, cutoff)
+	if err != nil {
+		return fmt.Errorf("query snapshot artifacts: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan artifact failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := r.DeleteArtifact(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maybeEnforceStorageBudget runs EnforceStorageBudget at most once per
+// storageCheckInterval.
+func (r *Repo) maybeEnforceStorageBudget(ctx context.Context) {
+	if r.budget.MaxBytes <= 0 {
+		return
+	}
+	now := r.clock.Now()
+	r.mu.Lock()
+	due := now.Sub(r.lastBudgetCheck) >= storageCheckInterval
+	if due {
+		r.lastBudgetCheck = now
+	}
+	r.mu.Unlock()
+	if !due {
+		return
+	}
+	n, err := r.EnforceStorageBudget(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Storage budget retention failed: %v\n", err)
+	}
+	if n > 0 {
+		fmt.Fprintf(os.Stderr, "Storage budget exceeded: deleted the %d oldest snapshots\n", n)
+	}
+}
@@ -0,0 +1,104 @@
+package relational
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEnforceStorageBudget(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewDuckDBClient(filepath.Join(t.TempDir(), "budget.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	repo := NewRepo(client.DB())
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().UTC().Add(-48 * time.Hour)
+	var ids []int64
+	for i := range 10 {
+		s := RawStatsFixed{AgentID: "a1", Hostname: "web-1", CollectedAt: start.Add(time.Duration(i) * time.Hour), CPUPerCorePct: []float64{1, 2},
+			UserUsage: []UserUsageFixed{{UID: 1000, User: "app", Processes: 3, CPUPct: 5, MemPct: 2, RSSBytes: 1 << 20}}}
+		res, err := repo.InsertRawStats(ctx, s, DerivedRates{}, SnapshotFlags{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, res.SnapshotID)
+	}
+	if _, err := repo.AddAnnotation(ctx, ids[0], AnnotationBookmark, "keep"); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := repo.StorageUsage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Path == "" || usage.FileBytes <= 0 || usage.UsedBytes <= 0 || usage.BudgetBytes != 0 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if n, err := repo.EnforceStorageBudget(ctx); n != 0 || err != nil {
+		t.Errorf("without a budget deleted %d, err %v", n, err)
+	}
+
+	repo.budget = StorageBudget{MaxBytes: usage.UsedBytes / 2, TargetPct: 50, KeepRecent: time.Hour}
+	n, err := repo.EnforceStorageBudget(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 || n > 8 {
+		t.Errorf("deleted %d snapshots, want some but not the bookmarked or latest one", n)
+	}
+	var left int
+	if err := repo.db.QueryRow(`SELECT count(*) FROM snapshots WHERE snapshot_id IN (?, ?)`, ids[0], ids[9]).Scan(&left); err != nil {
+		t.Fatal(err)
+	}
+	if left != 2 {
+		t.Errorf("the bookmarked and latest snapshots must be kept, %d left", left)
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := snapshotChildTables(ctx, tx)
+	_ = tx.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(tables, "snapshot_cpu_cores") || !slices.Contains(tables, "snapshot_user_usage") {
+		t.Errorf("child tables = %v", tables)
+	}
+	for _, table := range tables {
+		var orphans int
+		if err := repo.db.QueryRow(`SELECT count(*) FROM ` + table + ` WHERE snapshot_id NOT IN (SELECT snapshot_id FROM snapshots)`).Scan(&orphans); err != nil {
+			t.Fatal(err)
+		}
+		if orphans != 0 {
+			t.Errorf("%d orphaned rows in %s", orphans, table)
+		}
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	client, err := NewDuckDBClient("", WithCompression("rle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var got string
+	if err := client.DB().QueryRow(`SELECT current_setting('force_compression')`).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "RLE" {
+		t.Errorf("force_compression = %q, want RLE", got)
+	}
+	if _, err := NewDuckDBClient("", WithCompression("zstd")); err == nil {
+		t.Error("expected an error for an unsupported method")
+	}
+}
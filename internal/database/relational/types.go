@@ -1,6 +1,9 @@
 package relational
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type SnapshotKind string
 
@@ -20,6 +23,15 @@ type RawStatsFixed struct {
 	MachineID string
 	BootID    string
 	Hostname  string
+	Labels    map[string]string // from the hosts table, e.g. env=prod
+
+	// TraceID identifies the collection cycle that produced the snapshot.
+	// Its DuckDB row, graph node, alerts and log lines all carry it.
+	TraceID string
+
+	// AgentVersion is the build of the agent that collected the snapshot;
+	// the hosts table keeps the latest one per host.
+	AgentVersion string
 
 	// CPU
 	CPUUsagePct     float64
@@ -43,6 +55,7 @@ type RawStatsFixed struct {
 	SwapUsagePct   float64
 	SwapTotalBytes uint64
 	SwapUsedBytes  uint64
+	SwapInBytes    uint64 // cumulative since boot
 
 	// Disk root "/" bytes + inodes
 	DiskUsagePct   float64
@@ -59,6 +72,11 @@ type RawStatsFixed struct {
 	NetLatencyMS  float64
 	IsConnected   bool
 	ActiveTCP     int
+	NetJitterMS   float64 // background prober; 0 when disabled
+	NetLossPct    float64
+	NetProbes     int
+	VPNInterface  string  // active tunnel, if any
+	NetDirectMS   float64 // latency bypassing the VPN; 0 when unknown
 	NetInterfaces []NetInterfaceStatsFixed
 
 	// Docker
@@ -72,11 +90,54 @@ type RawStatsFixed struct {
 	UptimeSeconds uint64
 	Procs         uint64
 
+	// Cgroup limits; when set, CPU and RAM usage are relative to them
+	Containerized       bool
+	CgroupMemLimitBytes uint64
+	CgroupCPULimit      float64 // cores
+
+	// Environment profile (bare-metal|vm|wsl|cloud)
+	Environment   string
+	Hypervisor    string
+	CloudProvider string
+	LatencyNAT    bool
+
 	// Physical
 	Temperatures []TemperatureStatFixed
 
+	// SoC power and cluster utilization (macOS powermetrics)
+	CPUEClusterPct  float64
+	CPUPClusterPct  float64
+	PowerCPUWatts   float64
+	PowerGPUWatts   float64
+	PowerANEWatts   float64
+	PowerTotalWatts float64
+	ThermalPressure string
+
+	// Raspberry Pi firmware state
+	ThrottledBits uint32 // get_throttled; low bits now, bits 16+ since boot
+	CoreVolts     float64
+
+	// GPUs
+	GPUs []GPUStatFixed
+
 	// Processes (top N)
 	TopProcesses []ProcessStatFixed
+	UserUsage    []UserUsageFixed
+
+	// Kernel OOM kills since the previous snapshot
+	OOMKills []OOMKillFixed
+
+	// Fastest growing files under the watched log directories
+	LogGrowers []FileGrowthFixed
+
+	// Largest directories, only present on snapshots that triggered a disk scan
+	LargestDirs []DirUsageFixed
+
+	// Temp directories and core dump locations
+	TempUsage []TempUsageFixed
+
+	// User-defined checks, run on the slow path
+	Checks []CheckResultFixed
 }
 
 type DockerContainerInfoFixed struct {
@@ -102,6 +163,85 @@ type ProcessStatFixed struct {
 	Name   string
 	CPUPct float64
 	MemPct float32
+
+	Cmdline     string // redacted; empty unless detail capture is on
+	Cgroup      string
+	ContainerID string // owning Docker container, if any
+}
+
+// FileGrowthFixed is a file that grew since the previous snapshot.
+type FileGrowthFixed struct {
+	Path      string
+	SizeBytes uint64
+	GrowthBps float64
+}
+
+// DirUsageFixed is the apparent size of a directory tree.
+type DirUsageFixed struct {
+	Path      string
+	SizeBytes uint64
+}
+
+// TempUsageFixed summarises a temp or core-dump location.
+type TempUsageFixed struct {
+	Path       string
+	Kind       string // "temp" or "core"
+	Files      int
+	TotalBytes uint64
+	StaleBytes uint64
+}
+
+// CheckResultFixed is the outcome of a user-defined check.
+type CheckResultFixed struct {
+	Name       string
+	OK         bool
+	ExitCode   int // -1 when the command could not run or timed out
+	DurationMS float64
+	Value      *float64 // first output line, when numeric
+	Output     string
+	Severity   int // raised when the check fails
+}
+
+// OOMKillFixed is a process terminated by the kernel OOM killer.
+type OOMKillFixed struct {
+	At           time.Time
+	PID          int32
+	Process      string
+	Cgroup       string
+	UID          int32
+	AnonRSSBytes uint64
+	Constraint   string
+}
+
+// UserUsageFixed aggregates the processes owned by one UID.
+// GPUStatFixed is one GPU's load. Readings its vendor does not expose are 0.
+type GPUStatFixed struct {
+	Index         int
+	Vendor        string // nvidia, amd or apple
+	Name          string
+	UtilPct       float64
+	MemUsedBytes  uint64
+	MemTotalBytes uint64 // 0 for unified memory
+	TemperatureC  float64
+	PowerWatts    float64
+}
+
+// MemPct returns VRAM usage as a percent of its total, or 0 when the total
+// is unknown.
+func (g GPUStatFixed) MemPct() float64 {
+	if g.MemTotalBytes == 0 {
+		return 0
+	}
+	return float64(g.MemUsedBytes) / float64(g.MemTotalBytes) * 100
+}
+
+type UserUsageFixed struct {
+	UID       int32
+	User      string
+	Processes int
+	CPUPct    float64 // sum of per-process CPU percent (100 = one core)
+	MemPct    float64 // percent of total RAM
+	RSSBytes  uint64
 }
 
 type NetInterfaceStatsFixed struct {
@@ -114,6 +254,12 @@ type NetInterfaceStatsFixed struct {
 	ErrOut      uint64
 	DropIn      uint64
 	DropOut     uint64
+
+	OperState      string
+	SpeedMbps      int
+	MaxSpeedMbps   int
+	Duplex         string
+	CarrierChanges uint64
 }
 
 type PartitionUsageFixed struct {
@@ -140,6 +286,7 @@ type DiskHealthInfoFixed struct {
 	Device  string
 	Status  string // passed|failed|unknown
 	Message string
+	Raw     string // smartctl output, kept for failing disks
 }
 
 // DerivedRates contains rates computed from deltas.
@@ -154,34 +301,51 @@ type DerivedRates struct {
 	NetRxBps          float64
 	NetErrPerS        float64
 	NetDropPerS       float64
+
+	// NetCarrierChanges counts link up/down transitions per interface since
+	// the previous snapshot. It is used for flagging and not persisted.
+	NetCarrierChanges map[string]uint64
+
+	// NetUtilization is each interface's busier direction as a percent of
+	// its negotiated link speed; interfaces without a known speed are absent.
+	NetUtilization map[string]float64
 }
 
 // SnapshotFlags contains analysis results.
 type SnapshotFlags struct {
-	FlagHostOffline             bool
-	FlagCPUOverloaded           bool
-	FlagMemoryPressure          bool
-	FlagMemoryStarvation        bool
-	FlagSwapThrashing           bool
-	FlagDiskSpaceCritical       bool
-	FlagInodeExhaustion         bool
-	FlagDiskIOSaturation        bool
-	FlagDiskHealthFailed        bool
-	FlagNetworkLatencyDegraded  bool
-	FlagNetworkPacketLoss       bool
-	FlagNetworkInterfaceErrors  bool
-	FlagDockerUnavailable       bool
-	FlagContainerCPUHog         bool
-	FlagContainerMemoryPressure bool
-	FlagContainerOOMRisk        bool
-	FlagRunawayProcessCPU       bool
-	FlagRunawayProcessMemory    bool
-	FlagThermalPressure         bool
-	FlagSystemAtRisk            bool
+	FlagHostOffline               bool
+	FlagCPUOverloaded             bool
+	FlagMemoryPressure            bool
+	FlagMemoryStarvation          bool
+	FlagSwapThrashing             bool
+	FlagDiskSpaceCritical         bool
+	FlagInodeExhaustion           bool
+	FlagDiskIOSaturation          bool
+	FlagDiskHealthFailed          bool
+	FlagNetworkLatencyDegraded    bool
+	FlagNetworkPacketLoss         bool
+	FlagNetworkInterfaceErrors    bool
+	FlagDockerUnavailable         bool
+	FlagContainerCPUHog           bool
+	FlagContainerMemoryPressure   bool
+	FlagContainerOOMRisk          bool
+	FlagRunawayProcessCPU         bool
+	FlagRunawayProcessMemory      bool
+	FlagThermalPressure           bool
+	FlagSystemAtRisk              bool
+	FlagMemoryExhaustionPredicted bool
+	FlagUserResourceHog           bool
+	FlagUnderVoltage              bool
+	FlagLinkDegraded              bool
+	FlagLinkSaturated             bool
+	FlagCheckFailed               bool
+	FlagStorageBudget             bool
+	FlagGPUOverloaded             bool
+	FlagGPUMemoryPressure         bool
 
 	SeverityLevel int
 	RiskScore     int
-	Bitmask       int64
+	Bitmask       int64 // see Mask; set by the flagger and again when the snapshot is persisted
 
 	PrimaryCause    string
 	CauseEntityType string
@@ -194,3 +358,162 @@ type InsertResult struct {
 	SnapshotID int64
 	HostID     int64
 }
+
+// FlagNames lists the canonical snapshot flag names in column order. A
+// flag's index is also its bit in flags_bitmask, so stored masks stay
+// decodable only if existing entries never move: append new flags at the end.
+var FlagNames = []string{
+	"host_offline",
+	"cpu_overloaded",
+	"memory_pressure",
+	"memory_starvation",
+	"swap_thrashing",
+	"disk_space_critical",
+	"inode_exhaustion",
+	"disk_io_saturation",
+	"disk_health_failed",
+	"network_latency_degraded",
+	"network_packet_loss",
+	"network_interface_errors",
+	"docker_unavailable",
+	"container_cpu_hog",
+	"container_memory_pressure",
+	"container_oom_risk",
+	"runaway_process_cpu",
+	"runaway_process_memory",
+	"thermal_pressure",
+	"system_at_risk",
+	"memory_exhaustion_predicted",
+	"user_resource_hog",
+	"under_voltage",
+	"link_degraded",
+	"link_saturated",
+	"check_failed",
+	"storage_budget",
+	"gpu_overloaded",
+	"gpu_memory_pressure",
+}
+
+// flagFields returns pointers to the boolean flags in the same order as FlagNames.
+func (f *SnapshotFlags) flagFields() []*bool {
+	return []*bool{
+		&f.FlagHostOffline,
+		&f.FlagCPUOverloaded,
+		&f.FlagMemoryPressure,
+		&f.FlagMemoryStarvation,
+		&f.FlagSwapThrashing,
+		&f.FlagDiskSpaceCritical,
+		&f.FlagInodeExhaustion,
+		&f.FlagDiskIOSaturation,
+		&f.FlagDiskHealthFailed,
+		&f.FlagNetworkLatencyDegraded,
+		&f.FlagNetworkPacketLoss,
+		&f.FlagNetworkInterfaceErrors,
+		&f.FlagDockerUnavailable,
+		&f.FlagContainerCPUHog,
+		&f.FlagContainerMemoryPressure,
+		&f.FlagContainerOOMRisk,
+		&f.FlagRunawayProcessCPU,
+		&f.FlagRunawayProcessMemory,
+		&f.FlagThermalPressure,
+		&f.FlagSystemAtRisk,
+		&f.FlagMemoryExhaustionPredicted,
+		&f.FlagUserResourceHog,
+		&f.FlagUnderVoltage,
+		&f.FlagLinkDegraded,
+		&f.FlagLinkSaturated,
+		&f.FlagCheckFailed,
+		&f.FlagStorageBudget,
+		&f.FlagGPUOverloaded,
+		&f.FlagGPUMemoryPressure,
+	}
+}
+
+// flagValues returns the boolean flags in the same order as FlagNames.
+func (f *SnapshotFlags) flagValues() []bool {
+	fields := f.flagFields()
+	values := make([]bool, len(fields))
+	for i, p := range fields {
+		values[i] = *p
+	}
+	return values
+}
+
+// ActiveFlags returns the canonical names of all flags that are set.
+func (f *SnapshotFlags) ActiveFlags() []string {
+	var active []string
+	for i, set := range f.flagValues() {
+		if set {
+			active = append(active, FlagNames[i])
+		}
+	}
+	return active
+}
+
+// Mask returns the active flags as a bitmask, bit i standing for FlagNames[i].
+func (f *SnapshotFlags) Mask() int64 {
+	var m int64
+	for i, set := range f.flagValues() {
+		if set {
+			m |= 1 << i
+		}
+	}
+	return m
+}
+
+// FlagsFromBitmask decodes a stored flags_bitmask. Only the boolean flags
+// and Bitmask are set; bits beyond FlagNames are ignored.
+func FlagsFromBitmask(mask int64) SnapshotFlags {
+	f := SnapshotFlags{Bitmask: mask}
+	for i, p := range f.flagFields() {
+		*p = mask&(1<<i) != 0
+	}
+	return f
+}
+
+// ClearFlag unsets the named flag and reports whether it was set.
+func (f *SnapshotFlags) ClearFlag(name string) bool {
+	i := slices.Index(FlagNames, name)
+	if i < 0 {
+		return false
+	}
+	p := f.flagFields()[i]
+	was := *p
+	*p = false
+	return was
+}
+
+// BitmaskNames returns the canonical names of the flags set in mask.
+func BitmaskNames(mask int64) []string {
+	f := FlagsFromBitmask(mask)
+	return f.ActiveFlags()
+}
+
+// FlagBit returns the bit of the named flag in flags_bitmask.
+func FlagBit(name string) (int64, bool) {
+	i := slices.Index(FlagNames, name)
+	if i < 0 {
+		return 0, false
+	}
+	return 1 << i, true
+}
+
+// EscalationState tracks how long a flag has been continuously active for a host.
+type EscalationState struct {
+	AgentID        string
+	Flag           string
+	FirstSeenAt    time.Time
+	Level          int // number of escalation steps reached
+	LastNotifiedAt time.Time
+	NotifyCount    int
+}
+
+// EscalationNotice is emitted when a flag escalates or a repeat notification is due.
+type EscalationNotice struct {
+	AgentID     string
+	Flag        string
+	Level       int
+	ActiveFor   time.Duration
+	Repeat      bool // true for a repeat reminder, false for a level change
+	NotifyCount int
+}
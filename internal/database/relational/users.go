@@ -0,0 +1,89 @@
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UserUsageSummary is one user's resource usage over a window.
+type UserUsageSummary struct {
+	UID          int32   `json:"uid"`
+	User         string  `json:"user"`
+	Samples      int64   `json:"samples"`
+	AvgCPUPct    float64 `json:"avg_cpu_pct"`
+	PeakCPUPct   float64 `json:"peak_cpu_pct"`
+	AvgMemPct    float64 `json:"avg_mem_pct"`
+	PeakMemPct   float64 `json:"peak_mem_pct"`
+	PeakRSSBytes uint64  `json:"peak_rss_bytes"`
+	FlaggedCount int64   `json:"flagged_count"` // snapshots where this user was the cause
+}
+
+// UserUsageReport ranks users by average memory share.
+type UserUsageReport struct {
+	Hostname string             `json:"hostname,omitempty"`
+	Since    time.Time          `json:"since"`
+	Users    []UserUsageSummary `json:"users"`
+}
+
+// UserUsage aggregates per-user CPU and memory over the window, highest average memory first.
+func (r *Repo) UserUsage(ctx context.Context, hostname string, window time.Duration, limit int) (*UserUsageReport, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	since := r.clock.Now().Add(-window)
+	report := &UserUsageReport{Hostname: hostname, Since: since, Users: []UserUsageSummary{}}
+
+	query := `
+		SELECT
+		  u.uid,
+		  any_value(u.username),
+		  COUNT(*),
+		  avg(u.cpu_pct), max(u.cpu_pct),
+		  avg(u.mem_pct), max(u.mem_pct),
+		  max(u.rss_bytes),
+		  count(*) FILTER (WHERE s.cause_entity_type = 'user' AND s.cause_entity_key = u.username)
+		FROM snapshot_user_usage u
+		JOIN snapshots s ON s.snapshot_id = u.snapshot_id
+		LEFT JOIN hosts h ON s.host_id = h.host_id
+		WHERE s.collected_at >= ?
+	`
+	args := []interface{}{since}
+	if hostname != "" {
+		query += " AND h.hostname = ?"
+		args = append(args, hostname)
+	}
+	query += `
+		GROUP BY u.uid
+		ORDER BY avg(u.mem_pct) DESC, u.uid
+		LIMIT ?
+	`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("user usage query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u UserUsageSummary
+		var name sql.NullString
+		var peakRSS NullUint64
+		if err := rows.Scan(&u.UID, &name, &u.Samples, &u.AvgCPUPct, &u.PeakCPUPct, &u.AvgMemPct, &u.PeakMemPct, &peakRSS, &u.FlaggedCount); err != nil {
+			return nil, fmt.Errorf("scan user usage: %w", err)
+		}
+		u.User = name.String
+		u.PeakRSSBytes = peakRSS.Uint64
+		report.Users = append(report.Users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("user usage rows: %w", err)
+	}
+	return report, nil
+}
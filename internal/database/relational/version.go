@@ -0,0 +1,60 @@
+package relational
+
+// SchemaVersion is the semantic version of the snapshot payload and row format.
+// Bump the minor version for additive changes and the major version for
+// changes older readers cannot ignore; register a converter in the output package.
+const SchemaVersion = "1.25.0"
+
+// LegacySchemaVersion identifies payloads and rows written before versioning existed.
+const LegacySchemaVersion = "0.0.0"
+
+// schemaMigrations upgrade databases created by older versions. Each statement must be idempotent.
+var schemaMigrations = []string{
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS schema_version VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS swap_in_bytes UBIGINT`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_memory_exhaustion_predicted BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_user_resource_hog BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS containerized BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS cgroup_mem_limit_bytes UBIGINT`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS cgroup_cpu_limit DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS environment VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS hypervisor VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS cloud_provider VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS latency_nat BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS cpu_e_cluster_pct DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS cpu_p_cluster_pct DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS power_cpu_w DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS power_gpu_w DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS power_ane_w DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS power_total_w DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS thermal_pressure VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS throttled_bits UINTEGER`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS core_volts DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_under_voltage BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS net_jitter_ms DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS net_loss_pct DOUBLE`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS oper_state VARCHAR`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS speed_mbps INTEGER`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS max_speed_mbps INTEGER`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS duplex VARCHAR`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS carrier_changes UBIGINT`,
+	`ALTER TABLE snapshot_net_interface_stats ADD COLUMN IF NOT EXISTS util_pct DOUBLE`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_link_degraded BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_link_saturated BOOLEAN`,
+	`ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS name VARCHAR`,
+	`ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS path VARCHAR`,
+	`ALTER TABLE snapshot_top_processes ADD COLUMN IF NOT EXISTS cmdline VARCHAR`,
+	`ALTER TABLE snapshot_top_processes ADD COLUMN IF NOT EXISTS cgroup VARCHAR`,
+	`ALTER TABLE snapshot_top_processes ADD COLUMN IF NOT EXISTS container_id VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS vpn_interface VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS net_direct_ms DOUBLE`,
+	`ALTER TABLE hosts ADD COLUMN IF NOT EXISTS labels VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_check_failed BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS repeat_count INTEGER`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS last_repeat_at TIMESTAMP`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_storage_budget BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS trace_id VARCHAR`,
+	`ALTER TABLE hosts ADD COLUMN IF NOT EXISTS agent_version VARCHAR`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_gpu_overloaded BOOLEAN`,
+	`ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS flag_gpu_memory_pressure BOOLEAN`,
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// SeriesSource returns downsampled metric series.
+type SeriesSource interface {
+	MetricSeries(ctx context.Context, hostname, metric string, from, to time.Time, points int) ([]relational.SeriesPoint, error)
+}
+
+// SeriesHandler serves a chart-ready metric series on GET:
+// ?metric=NAME (required, one of relational.MetricColumns), ?host=NAME,
+// ?from= and ?to= as RFC 3339 times (default: the last 24 hours) and
+// ?points=N, the most points to return (default
+// relational.DefaultSeriesPoints). Long ranges are downsampled with LTTB.
+func SeriesHandler(s SeriesSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		metric := q.Get("metric")
+		if !relational.IsMetricColumn(metric) {
+			http.Error(w, "metric must be one of the snapshot metric columns", http.StatusBadRequest)
+			return
+		}
+		var from, to time.Time
+		for _, t := range []struct {
+			name string
+			dst  *time.Time
+		}{{"from", &from}, {"to", &to}} {
+			if v := q.Get(t.name); v != "" {
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					http.Error(w, t.name+" must be an RFC 3339 time", http.StatusBadRequest)
+					return
+				}
+				*t.dst = parsed
+			}
+		}
+		if from.IsZero() {
+			from = time.Now().Add(-24 * time.Hour)
+		}
+		points, _ := strconv.Atoi(q.Get("points"))
+
+		series, err := s.MetricSeries(r.Context(), q.Get("host"), metric, from, to, points)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"metric": metric, "points": series})
+	})
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+type fakeSeries struct {
+	host   string
+	points int
+}
+
+func (f *fakeSeries) MetricSeries(_ context.Context, hostname, metric string, from, to time.Time, points int) ([]relational.SeriesPoint, error) {
+	f.host, f.points = hostname, points
+	return []relational.SeriesPoint{{At: from, Value: 42}}, nil
+}
+
+func TestSeriesHandler(t *testing.T) {
+	src := &fakeSeries{}
+	h := SeriesHandler(src)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/series?metric=cpu_usage_pct&host=web-1&points=200&from=2026-03-01T00:00:00Z", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"value":42`) || src.host != "web-1" || src.points != 200 {
+		t.Errorf("GET = %d %s, source %+v", rec.Code, rec.Body, src)
+	}
+
+	for _, target := range []string{"/api/v1/series?metric=hostname", "/api/v1/series?metric=cpu_usage_pct&from=yesterday"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", target, rec.Code)
+		}
+	}
+}
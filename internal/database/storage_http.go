@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"syschecker/internal/database/relational"
+)
+
+// StorageReporter reports the database size against its budget.
+type StorageReporter interface {
+	StorageUsage(ctx context.Context) (relational.StorageUsage, error)
+}
+
+// StorageHandler serves the database size and budget on GET.
+func StorageHandler(s StorageReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		u, err := s.StorageUsage(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(u)
+	})
+}
@@ -0,0 +1,117 @@
+// Package debugserver exposes pprof profiles and Go runtime stats on an optional
+// debug address so performance problems can be profiled in the field.
+package debugserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"time"
+
+	"syschecker/internal/buildinfo"
+)
+
+// EnvAddr is the environment variable consulted when no debug address flag is given.
+const EnvAddr = "SYSCHECKER_DEBUG_ADDR"
+
+// RuntimeStats is the JSON payload served at /debug/runtime.
+type RuntimeStats struct {
+	Goroutines     int       `json:"goroutines"`
+	GOMAXPROCS     int       `json:"gomaxprocs"`
+	HeapAllocBytes uint64    `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64    `json:"heap_inuse_bytes"`
+	HeapObjects    uint64    `json:"heap_objects"`
+	SysBytes       uint64    `json:"sys_bytes"`
+	NumGC          uint32    `json:"num_gc"`
+	LastGC         time.Time `json:"last_gc"`
+	LastPauseNs    uint64    `json:"last_pause_ns"`
+	TotalPauseNs   uint64    `json:"total_pause_ns"`
+	GCCPUFraction  float64   `json:"gc_cpu_fraction"`
+}
+
+// ReadRuntimeStats snapshots the Go runtime.
+func ReadRuntimeStats() RuntimeStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	st := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+		HeapAllocBytes: ms.HeapAlloc,
+		HeapInuseBytes: ms.HeapInuse,
+		HeapObjects:    ms.HeapObjects,
+		SysBytes:       ms.Sys,
+		NumGC:          ms.NumGC,
+		TotalPauseNs:   ms.PauseTotalNs,
+		GCCPUFraction:  ms.GCCPUFraction,
+	}
+	if ms.NumGC > 0 {
+		st.LastGC = time.Unix(0, int64(ms.LastGC))
+		st.LastPauseNs = ms.PauseNs[(ms.NumGC+255)%256]
+	}
+	return st
+}
+
+// Route mounts an extra handler, such as a control endpoint, on the debug mux.
+type Route struct {
+	Pattern string
+	Handler http.Handler
+}
+
+// Handler returns the debug mux: /debug/pprof/*, /debug/runtime, the build
+// at /version and any routes.
+func Handler(routes ...Route) http.Handler {
+	mux := http.NewServeMux()
+	for _, r := range routes {
+		mux.Handle(r.Pattern, r.Handler)
+	}
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ReadRuntimeStats())
+	})
+	mux.Handle("/version", buildinfo.Handler())
+	return mux
+}
+
+// Addr returns flagValue if set, otherwise the value of EnvAddr.
+func Addr(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(EnvAddr)
+}
+
+// Start serves the debug handler on addr in the background. An empty addr
+// disables the server and returns nil. Logs go to stderr so stdio transports
+// are not disturbed.
+func Start(addr string, routes ...Route) (*http.Server, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("debug server listen on %s: %w", addr, err)
+	}
+	if host, _, err := net.SplitHostPort(ln.Addr().String()); err == nil {
+		if ip := net.ParseIP(host); ip != nil && !ip.IsLoopback() {
+			fmt.Fprintf(os.Stderr, "Warning: debug server on %s is reachable from other hosts\n", ln.Addr())
+		}
+	}
+
+	srv := &http.Server{Handler: Handler(routes...), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "Debug server stopped: %v\n", err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Debug server listening on http://%s/debug/pprof/\n", ln.Addr())
+	return srv, nil
+}
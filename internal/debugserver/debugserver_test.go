@@ -0,0 +1,53 @@
+package debugserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"syschecker/internal/buildinfo"
+)
+
+func TestRuntimeEndpoint(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var st RuntimeStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Goroutines <= 0 || st.HeapAllocBytes == 0 {
+		t.Errorf("implausible runtime stats: %+v", st)
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var info buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.Version == "" || info.GoVersion == "" {
+		t.Errorf("incomplete build info: %+v", info)
+	}
+}
+
+func TestPprofIndex(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
+
+func TestStartDisabled(t *testing.T) {
+	srv, err := Start("")
+	if srv != nil || err != nil {
+		t.Errorf("expected disabled server, got %v, %v", srv, err)
+	}
+}
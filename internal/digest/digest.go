@@ -0,0 +1,184 @@
+// Package digest writes a periodic "your system at a glance" digest: the
+// stored hourly rollups and incidents of every host are handed to a
+// language model, which writes a short summary that is stored as an
+// artifact and optionally delivered through the alert notifiers.
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/timefmt"
+)
+
+// DefaultPeriod is how often digests are written and the span each covers.
+const DefaultPeriod = 7 * 24 * time.Hour
+
+// checkInterval is how often Run checks whether a digest is due.
+const checkInterval = time.Hour
+
+// ErrNoData is returned by Generate when no host has snapshots in the
+// period.
+var ErrNoData = errors.New("no snapshots in the digest period")
+
+// Source reads rollups and stores digests; *relational.Repo implements it.
+type Source interface {
+	HostDigests(ctx context.Context, since time.Time) ([]relational.HostDigest, error)
+	ListArtifacts(ctx context.Context, snapshotID int64, kind string, limit int) ([]relational.Artifact, error)
+	InsertArtifact(ctx context.Context, a relational.Artifact) (int64, error)
+}
+
+// Writer turns the facts of a period, as JSON, into prose; the RAG
+// engine implements it.
+type Writer interface {
+	WriteDigest(ctx context.Context, facts string) (string, error)
+}
+
+// Deliverer sends a finished digest on, e.g. *alert.Notifier.
+type Deliverer interface {
+	NotifyDigest(ctx context.Context, title, body string, at time.Time) error
+}
+
+// Digest is one written digest.
+type Digest struct {
+	From, To   time.Time
+	Title      string
+	Body       string // Markdown
+	Hosts      []relational.HostDigest
+	ArtifactID int64
+}
+
+// Facts is what the Writer is given.
+type Facts struct {
+	From  time.Time               `json:"from"`
+	To    time.Time               `json:"to"`
+	Hosts []relational.HostDigest `json:"hosts"`
+}
+
+// Option configures a Job.
+type Option func(*Job)
+
+// WithPeriod sets how often digests are written and the span each covers.
+func WithPeriod(d time.Duration) Option {
+	return func(j *Job) {
+		if d > 0 {
+			j.period = d
+		}
+	}
+}
+
+// WithDeliverer sends each digest to d after storing it.
+func WithDeliverer(d Deliverer) Option {
+	return func(j *Job) { j.deliver = d }
+}
+
+// WithClock replaces the wall clock, for tests.
+func WithClock(c clock.Clock) Option {
+	return func(j *Job) { j.clock = clock.OrReal(c) }
+}
+
+// Job writes digests.
+type Job struct {
+	src     Source
+	writer  Writer
+	deliver Deliverer
+	period  time.Duration
+	clock   clock.Clock
+}
+
+// New returns a job writing digests of src with w.
+func New(src Source, w Writer, opts ...Option) *Job {
+	j := &Job{src: src, writer: w, period: DefaultPeriod, clock: clock.Real}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// Generate writes, stores and delivers a digest of the period up to now.
+// A failed delivery is returned with the stored digest.
+func (j *Job) Generate(ctx context.Context) (*Digest, error) {
+	to := j.clock.Now()
+	from := to.Add(-j.period)
+	hosts, err := j.src.HostDigests(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, ErrNoData
+	}
+	facts, err := json.Marshal(Facts{From: from, To: to, Hosts: hosts})
+	if err != nil {
+		return nil, err
+	}
+	text, err := j.writer.WriteDigest(ctx, string(facts))
+	if err != nil {
+		return nil, fmt.Errorf("write digest: %w", err)
+	}
+
+	d := &Digest{
+		From: from, To: to, Hosts: hosts,
+		Title: fmt.Sprintf("syschecker digest %s to %s", from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)),
+	}
+	d.Body = fmt.Sprintf("# %s\n\n%s\n", d.Title, text)
+	if d.ArtifactID, err = j.src.InsertArtifact(ctx, relational.Artifact{
+		Kind:      relational.ArtifactDigest,
+		MIME:      "text/markdown",
+		Name:      "digest-" + to.UTC().Format(time.DateOnly) + ".md",
+		Data:      []byte(d.Body),
+		CreatedAt: to,
+	}); err != nil {
+		return nil, fmt.Errorf("store digest: %w", err)
+	}
+	if j.deliver != nil {
+		if err := j.deliver.NotifyDigest(ctx, d.Title, text, to); err != nil {
+			return d, fmt.Errorf("deliver digest: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// Due returns when the next digest is due: a period after the last stored
+// one, or now when there is none.
+func (j *Job) Due(ctx context.Context) (time.Time, error) {
+	last, err := j.src.ListArtifacts(ctx, 0, relational.ArtifactDigest, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(last) == 0 {
+		return j.clock.Now(), nil
+	}
+	return last[0].CreatedAt.Add(j.period), nil
+}
+
+// Run writes a digest whenever one is due until ctx is done. Due times
+// follow the stored digests, so restarts neither skip nor repeat one.
+func (j *Job) Run(ctx context.Context) {
+	ticker := j.clock.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		due, err := j.Due(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Digest schedule unavailable: %v\n", err)
+		} else if !j.clock.Now().Before(due) {
+			d, err := j.Generate(ctx)
+			if d != nil {
+				fmt.Fprintf(os.Stderr, "Digest for %s stored as artifact %d\n", timefmt.Format(d.To, nil), d.ArtifactID)
+			}
+			if err != nil && !errors.Is(err, ErrNoData) && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Digest failed: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
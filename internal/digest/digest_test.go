@@ -0,0 +1,122 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/relational"
+)
+
+type fakeSource struct {
+	mu        sync.Mutex
+	hosts     []relational.HostDigest
+	artifacts []relational.Artifact // newest first
+}
+
+func (f *fakeSource) HostDigests(context.Context, time.Time) ([]relational.HostDigest, error) {
+	return f.hosts, nil
+}
+
+func (f *fakeSource) ListArtifacts(_ context.Context, _ int64, kind string, limit int) ([]relational.Artifact, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.artifacts[:min(limit, len(f.artifacts))], nil
+}
+
+func (f *fakeSource) InsertArtifact(_ context.Context, a relational.Artifact) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a.ID = int64(len(f.artifacts) + 1)
+	f.artifacts = append([]relational.Artifact{a}, f.artifacts...)
+	return a.ID, nil
+}
+
+func (f *fakeSource) stored() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.artifacts)
+}
+
+type fakeWriter struct{ facts []string }
+
+func (w *fakeWriter) WriteDigest(_ context.Context, facts string) (string, error) {
+	w.facts = append(w.facts, facts)
+	return "All quiet on web-1.", nil
+}
+
+type fakeDeliverer struct{ titles []string }
+
+func (d *fakeDeliverer) NotifyDigest(_ context.Context, title, _ string, _ time.Time) error {
+	d.titles = append(d.titles, title)
+	return nil
+}
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)
+	src := &fakeSource{hosts: []relational.HostDigest{{Hostname: "web-1", Samples: 10}}}
+	w, del := &fakeWriter{}, &fakeDeliverer{}
+	j := New(src, w, WithClock(clock.NewFake(now)), WithDeliverer(del))
+
+	d, err := j.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Title != "syschecker digest 2026-03-01 to 2026-03-08" || !strings.Contains(d.Body, "All quiet on web-1.") {
+		t.Errorf("digest = %+v", d)
+	}
+	if len(w.facts) != 1 || !strings.Contains(w.facts[0], `"hostname":"web-1"`) {
+		t.Errorf("facts = %v", w.facts)
+	}
+	if a := src.artifacts[0]; a.Kind != relational.ArtifactDigest || a.MIME != "text/markdown" || string(a.Data) != d.Body {
+		t.Errorf("artifact = %+v", a)
+	}
+	if len(del.titles) != 1 {
+		t.Errorf("delivered %d digests", len(del.titles))
+	}
+
+	if _, err := New(&fakeSource{}, w).Generate(context.Background()); !errors.Is(err, ErrNoData) {
+		t.Errorf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestRunFollowsStoredDigests(t *testing.T) {
+	now := time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	// The last digest was written six days ago, so the next is due in a day.
+	src := &fakeSource{
+		hosts:     []relational.HostDigest{{Hostname: "web-1"}},
+		artifacts: []relational.Artifact{{Kind: relational.ArtifactDigest, CreatedAt: now.Add(-6 * 24 * time.Hour)}},
+	}
+	w := &fakeWriter{}
+	j := New(src, w, WithClock(fake))
+
+	if due, err := j.Due(context.Background()); err != nil || !due.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("due = %v, %v", due, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { j.Run(ctx); close(done) }()
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for src.stored() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d artifacts, want %d", src.stored(), n)
+			}
+			fake.Advance(checkInterval)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(2)
+	if got := fake.Now().Sub(now); got < 24*time.Hour {
+		t.Errorf("digest written after %s, before it was due", got)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,167 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/graph"
+)
+
+// Smartctl checks that smartctl is installed and can read SMART data.
+func Smartctl() Check {
+	return Check{Name: "smartctl", Run: func(context.Context) Result {
+		path, err := exec.LookPath("smartctl")
+		if err != nil {
+			return Result{Status: Warn, Detail: "not found; disk health is not checked",
+				Fix: "install smartmontools (apt install smartmontools, brew install smartmontools)"}
+		}
+		if os.Geteuid() != 0 {
+			return Result{Status: Warn, Detail: path + " found, but SMART data needs root",
+				Fix: "run syschecker as root or grant it CAP_SYS_RAWIO"}
+		}
+		return Result{Status: OK, Detail: path}
+	}}
+}
+
+// DockerSocket checks that the Docker API named by $DOCKER_HOST, or the
+// default socket, answers a ping.
+func DockerSocket() Check {
+	return Check{Name: "docker", Run: func(ctx context.Context) Result {
+		network, addr := "unix", "/var/run/docker.sock"
+		if host := os.Getenv("DOCKER_HOST"); host != "" {
+			scheme, rest, ok := strings.Cut(host, "://")
+			if !ok {
+				return Result{Status: Warn, Detail: "cannot parse DOCKER_HOST=" + host,
+					Fix: "set DOCKER_HOST to unix:///path/to/docker.sock or tcp://host:port"}
+			}
+			network, addr = scheme, rest
+		}
+		if network == "unix" {
+			if _, err := os.Stat(addr); errors.Is(err, os.ErrNotExist) {
+				return Result{Status: Warn, Detail: addr + " does not exist; container metrics are disabled",
+					Fix: "start Docker, or ignore this if the host runs no containers"}
+			}
+		}
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/_ping", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return Result{Status: Warn, Detail: "permission denied on " + addr,
+					Fix: "add the user to the docker group (usermod -aG docker $USER) and log in again"}
+			}
+			return Result{Status: Warn, Detail: fmt.Sprintf("%s: %v", addr, err),
+				Fix: "check that the Docker daemon is running"}
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return Result{Status: Warn, Detail: fmt.Sprintf("%s answered %s", addr, resp.Status),
+				Fix: "check the Docker daemon logs"}
+		}
+		return Result{Status: OK, Detail: addr}
+	}}
+}
+
+// Neo4j checks that the graph database is reachable with the given
+// credentials and options. An empty uri skips the check.
+func Neo4j(uri, user, password string, opts ...graph.Neo4jOption) Check {
+	return Check{Name: "neo4j", Run: func(ctx context.Context) Result {
+		if uri == "" {
+			return Result{Status: Skip, Detail: "NEO4J_URI not set; graph features are off"}
+		}
+		c, err := graph.NewNeo4jClient(uri, user, password, "", opts...)
+		if err != nil {
+			return Result{Status: Fail, Detail: fmt.Sprintf("%s: %v", uri, err),
+				Fix: "start Neo4j and check NEO4J_URI, NEO4J_USER and NEO4J_PASSWORD (or " + graph.EnvNeo4jBearerToken + "), and " + graph.EnvNeo4jCACert + " for a private CA"}
+		}
+		c.Close(ctx)
+		return Result{Status: OK, Detail: uri}
+	}}
+}
+
+// GeminiKey checks that the API key used by the MCP server is accepted.
+// An empty key skips the check.
+func GeminiKey(key string) Check {
+	return Check{Name: "gemini api key", Run: func(ctx context.Context) Result {
+		if key == "" {
+			return Result{Status: Skip, Detail: "GEMINI_API_KEY not set; only the MCP server needs it"}
+		}
+		client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+		if err != nil {
+			return Result{Status: Fail, Detail: err.Error(), Fix: "check GEMINI_API_KEY"}
+		}
+		defer client.Close()
+		if _, err := client.ListModels(ctx).Next(); err != nil && !errors.Is(err, iterator.Done) {
+			return Result{Status: Fail, Detail: "key rejected or API unreachable: " + err.Error(),
+				Fix: "create a key at https://aistudio.google.com/apikey and export it as GEMINI_API_KEY"}
+		}
+		return Result{Status: OK, Detail: "key accepted"}
+	}}
+}
+
+// DBWritable checks that the DuckDB file, or the directory it will be
+// created in, is writable.
+func DBWritable(path string) Check {
+	return Check{Name: "database", Run: func(context.Context) Result {
+		dir := filepath.Dir(path)
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			f.Close()
+			return Result{Status: OK, Detail: path + " is writable"}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return Result{Status: Fail, Detail: fmt.Sprintf("cannot write %s: %v", path, err),
+				Fix: "fix the file's ownership or permissions, or pass a different -db path"}
+		}
+		f, err := os.CreateTemp(dir, ".syschecker-doctor-*")
+		if err != nil {
+			return Result{Status: Fail, Detail: fmt.Sprintf("cannot create %s: %v", path, err),
+				Fix: "create " + dir + " with write access, or pass a different -db path"}
+		}
+		f.Close()
+		os.Remove(f.Name())
+		return Result{Status: OK, Detail: path + " will be created"}
+	}}
+}
+
+// minSaneTime is earlier than any build of syschecker; a clock before it
+// was never set.
+var minSaneTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock checks that the wall clock looks set and has not moved behind the
+// newest stored snapshot, which would corrupt rates and retention. latest
+// may be nil or return a zero time when no snapshot is available.
+func Clock(c clock.Clock, latest func(context.Context) (time.Time, error)) Check {
+	c = clock.OrReal(c)
+	return Check{Name: "clock", Run: func(ctx context.Context) Result {
+		now := c.Now()
+		if now.Before(minSaneTime) {
+			return Result{Status: Fail, Detail: "system time is " + now.Format(time.RFC3339),
+				Fix: "set the clock and enable NTP (timedatectl set-ntp true)"}
+		}
+		if latest != nil {
+			if at, err := latest(ctx); err == nil && at.Sub(now) > time.Minute {
+				return Result{Status: Warn, Detail: fmt.Sprintf("newest snapshot is %s in the future", at.Sub(now).Round(time.Second)),
+					Fix: "enable NTP (timedatectl set-ntp true); the clock was moved back"}
+			}
+		}
+		return Result{Status: OK, Detail: now.UTC().Format(time.RFC3339)}
+	}}
+}
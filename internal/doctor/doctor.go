@@ -0,0 +1,80 @@
+// Package doctor checks the environment syschecker depends on and explains
+// how to fix what is missing, so failures that the agent would otherwise
+// only degrade around silently are visible up front.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	OK   Status = iota
+	Skip        // not configured, nothing to check
+	Warn        // works with reduced functionality
+	Fail        // syschecker cannot work as configured
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Skip:
+		return "SKIP"
+	case Warn:
+		return "WARN"
+	}
+	return "FAIL"
+}
+
+// Result is what a check found. Fix says how to resolve a Warn or Fail.
+type Result struct {
+	Status Status
+	Detail string
+	Fix    string
+}
+
+// Check is one named prerequisite.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Report is a check's name with its result.
+type Report struct {
+	Name string
+	Result
+}
+
+// Run runs checks in order, giving each at most timeout.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) []Report {
+	reports := make([]Report, 0, len(checks))
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		reports = append(reports, Report{Name: c.Name, Result: c.Run(cctx)})
+		cancel()
+	}
+	return reports
+}
+
+// Write prints one line per report, followed by its fix when it has one,
+// and returns whether any check failed.
+func Write(w io.Writer, reports []Report) (failed bool, err error) {
+	for _, r := range reports {
+		if _, err := fmt.Fprintf(w, "[%-4s] %-16s %s\n", r.Status, r.Name, r.Detail); err != nil {
+			return failed, err
+		}
+		if r.Fix != "" && r.Status >= Warn {
+			if _, err := fmt.Fprintf(w, "       %-16s fix: %s\n", "", r.Fix); err != nil {
+				return failed, err
+			}
+		}
+		failed = failed || r.Status == Fail
+	}
+	return failed, nil
+}
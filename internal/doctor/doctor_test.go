@@ -0,0 +1,73 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestRunAndWrite(t *testing.T) {
+	fixed := func(r Result) func(context.Context) Result {
+		return func(context.Context) Result { return r }
+	}
+	reports := Run(context.Background(), time.Second,
+		Check{Name: "good", Run: fixed(Result{Status: OK, Detail: "fine"})},
+		Check{Name: "meh", Run: fixed(Result{Status: Warn, Detail: "degraded", Fix: "do this"})},
+		Check{Name: "off", Run: fixed(Result{Status: Skip, Detail: "not configured", Fix: "ignored"})},
+	)
+	var b strings.Builder
+	failed, err := Write(&b, reports)
+	if err != nil || failed {
+		t.Fatalf("Write = %v, %v; want no failure", failed, err)
+	}
+	out := b.String()
+	if !strings.Contains(out, "[WARN] meh") || !strings.Contains(out, "fix: do this") || strings.Contains(out, "ignored") {
+		t.Errorf("output:\n%s", out)
+	}
+
+	failed, _ = Write(&b, []Report{{Name: "bad", Result: Result{Status: Fail}}})
+	if !failed {
+		t.Error("a failing check should be reported")
+	}
+}
+
+func TestDBWritable(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	if r := DBWritable(filepath.Join(dir, "new.db")).Run(ctx); r.Status != OK {
+		t.Errorf("new file = %+v", r)
+	}
+	existing := filepath.Join(dir, "old.db")
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r := DBWritable(existing).Run(ctx); r.Status != OK {
+		t.Errorf("existing file = %+v", r)
+	}
+	if r := DBWritable(filepath.Join(dir, "missing", "x.db")).Run(ctx); r.Status != Fail || r.Fix == "" {
+		t.Errorf("missing directory = %+v", r)
+	}
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	latest := func(at time.Time) func(context.Context) (time.Time, error) {
+		return func(context.Context) (time.Time, error) { return at, nil }
+	}
+
+	if r := Clock(clock.NewFake(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)), nil).Run(ctx); r.Status != Fail {
+		t.Errorf("unset clock = %+v", r)
+	}
+	if r := Clock(clock.NewFake(now), latest(now.Add(-time.Minute))).Run(ctx); r.Status != OK {
+		t.Errorf("sane clock = %+v", r)
+	}
+	if r := Clock(clock.NewFake(now), latest(now.Add(time.Hour))).Run(ctx); r.Status != Warn || !strings.Contains(r.Detail, "1h0m0s") {
+		t.Errorf("clock behind the data = %+v", r)
+	}
+}
@@ -0,0 +1,176 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// BaselineStore provides history and persistence for baseline learning.
+type BaselineStore interface {
+	FirstSnapshotAt(ctx context.Context, agentID string) (time.Time, error)
+	ComputeBaseline(ctx context.Context, agentID string, since, until time.Time) ([]relational.MetricBaseline, error)
+	SaveHostThresholds(ctx context.Context, thresholds []relational.HostThreshold) error
+	LoadHostThresholds(ctx context.Context, agentID string) ([]relational.HostThreshold, error)
+}
+
+// BaselineLearner observes each host for a learning period and then suggests
+// (or applies) per-host thresholds derived from its typical ranges.
+type BaselineLearner struct {
+	cfg     LearningConfig
+	base    Config
+	store   BaselineStore
+	flagger *FlaggerService
+
+	mu       sync.Mutex
+	learned  map[string]bool
+	learning map[string]time.Time // agent ID -> first snapshot, for hosts still learning
+}
+
+// NewBaselineLearner creates a learner that applies thresholds to flagger when AutoApply is set.
+func NewBaselineLearner(base Config, store BaselineStore, flagger *FlaggerService) *BaselineLearner {
+	return &BaselineLearner{
+		cfg:      base.Learning,
+		base:     base,
+		store:    store,
+		flagger:  flagger,
+		learned:  make(map[string]bool),
+		learning: make(map[string]time.Time),
+	}
+}
+
+// SetConfig replaces the config at runtime. Hosts still learning are
+// looked up again, as a new period may already have elapsed for them.
+func (l *BaselineLearner) SetConfig(base Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = base.Learning
+	l.base = base
+	clear(l.learning)
+}
+
+// Observe learns thresholds once the host's learning period has elapsed.
+// It returns the newly learned thresholds, or nil if nothing changed. The
+// store is only asked for a host's thresholds and first snapshot once, not
+// on every cycle of its learning period.
+func (l *BaselineLearner) Observe(ctx context.Context, agentID string, now time.Time) ([]relational.HostThreshold, error) {
+	if !l.cfg.Enabled {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.learned[agentID] {
+		return nil, nil
+	}
+
+	first, ok := l.learning[agentID]
+	if !ok {
+		// Thresholds learned by a previous run are reused as-is.
+		existing, err := l.store.LoadHostThresholds(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			l.learned[agentID] = true
+			l.applyIfEnabled(agentID, existing)
+			return nil, nil
+		}
+		if first, err = l.store.FirstSnapshotAt(ctx, agentID); err != nil {
+			return nil, err
+		}
+		if first.IsZero() {
+			return nil, nil // no history yet
+		}
+		l.learning[agentID] = first
+	}
+	if now.Sub(first) < l.cfg.Period {
+		return nil, nil // still learning
+	}
+
+	baselines, err := l.store.ComputeBaseline(ctx, agentID, first, first.Add(l.cfg.Period))
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds := make([]relational.HostThreshold, 0, len(baselines))
+	for _, b := range baselines {
+		if b.Samples < l.cfg.MinSamples {
+			continue
+		}
+		t, ok := l.suggest(b)
+		if !ok {
+			continue
+		}
+		t.AgentID = agentID
+		t.Applied = l.cfg.AutoApply
+		t.LearnedAt = now
+		thresholds = append(thresholds, t)
+	}
+
+	if err := l.store.SaveHostThresholds(ctx, thresholds); err != nil {
+		return nil, fmt.Errorf("save learned thresholds: %w", err)
+	}
+	l.learned[agentID] = true
+	delete(l.learning, agentID)
+	l.applyIfEnabled(agentID, thresholds)
+
+	return thresholds, nil
+}
+
+// suggest derives thresholds from a baseline. Thresholds are only ever raised
+// above the defaults so a busy host stops flagging its normal load.
+func (l *BaselineLearner) suggest(b relational.MetricBaseline) (relational.HostThreshold, bool) {
+	cfg := l.base
+	def := cfg.thresholdsFor(b.Metric)
+	if def == nil {
+		return relational.HostThreshold{}, false
+	}
+
+	headroom := l.cfg.Headroom
+	if headroom < 1 {
+		headroom = 1
+	}
+
+	warning := math.Max(def.Warning, b.P95*headroom)
+	critical := math.Max(def.Critical, b.P99*headroom)
+
+	if critical <= warning {
+		critical = warning + 1
+	}
+	// Percent metrics cannot exceed 100.
+	if b.Metric != "net_latency_ms" {
+		warning = math.Min(warning, 99)
+		critical = math.Min(critical, 100)
+	}
+
+	if warning == def.Warning && critical == def.Critical {
+		return relational.HostThreshold{}, false // defaults already fit
+	}
+
+	return relational.HostThreshold{
+		Metric:   b.Metric,
+		Warning:  math.Round(warning*10) / 10,
+		Critical: math.Round(critical*10) / 10,
+	}, true
+}
+
+func (l *BaselineLearner) applyIfEnabled(agentID string, thresholds []relational.HostThreshold) {
+	if l.flagger == nil {
+		return
+	}
+	var applied []relational.HostThreshold
+	for _, t := range thresholds {
+		if t.Applied {
+			applied = append(applied, t)
+		}
+	}
+	if len(applied) > 0 {
+		l.flagger.SetHostThresholds(agentID, applied)
+	}
+}
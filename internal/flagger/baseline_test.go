@@ -0,0 +1,112 @@
+package flagger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+func TestBaselineLearner_SuggestRaisesForBusyHost(t *testing.T) {
+	l := NewBaselineLearner(DefaultConfig(), nil, nil)
+
+	got, ok := l.suggest(relational.MetricBaseline{Metric: "cpu_usage_pct", Samples: 500, P95: 85, P99: 95})
+	if !ok {
+		t.Fatal("expected a suggestion for a host running hot")
+	}
+	if got.Warning != 93.5 {
+		t.Errorf("expected warning 93.5, got %.1f", got.Warning)
+	}
+	if got.Critical != 100 {
+		t.Errorf("expected critical capped at 100, got %.1f", got.Critical)
+	}
+}
+
+func TestBaselineLearner_NoSuggestionForQuietHost(t *testing.T) {
+	l := NewBaselineLearner(DefaultConfig(), nil, nil)
+
+	if _, ok := l.suggest(relational.MetricBaseline{Metric: "ram_usage_pct", Samples: 500, P95: 30, P99: 40}); ok {
+		t.Error("quiet host should keep default thresholds")
+	}
+}
+
+func TestFlaggerService_HostThresholdOverride(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	fs.SetHostThresholds("db-1", []relational.HostThreshold{
+		{Metric: "cpu_usage_pct", Warning: 93, Critical: 98},
+	})
+
+	busy := &relational.RawStatsFixed{AgentID: "db-1", CPUUsagePct: 95, DockerAvailable: true}
+	if f := fs.Flag(busy, &relational.DerivedRates{}); f.FlagCPUOverloaded {
+		t.Error("db-1 should not be flagged below its learned critical threshold")
+	}
+
+	other := &relational.RawStatsFixed{AgentID: "web-1", CPUUsagePct: 95, DockerAvailable: true}
+	f := fs.Flag(other, &relational.DerivedRates{})
+	if !f.FlagCPUOverloaded {
+		t.Error("web-1 should still use default thresholds")
+	}
+	if f.Bitmask != f.Mask() || f.Bitmask == 0 {
+		t.Errorf("Bitmask = %b, want %b", f.Bitmask, f.Mask())
+	}
+}
+
+// countingStore is a BaselineStore counting its lookups.
+type countingStore struct {
+	first         time.Time
+	loads, firsts int
+	saved         []relational.HostThreshold
+}
+
+func (s *countingStore) FirstSnapshotAt(context.Context, string) (time.Time, error) {
+	s.firsts++
+	return s.first, nil
+}
+
+func (s *countingStore) ComputeBaseline(context.Context, string, time.Time, time.Time) ([]relational.MetricBaseline, error) {
+	return []relational.MetricBaseline{{Metric: "cpu_usage_pct", Samples: 500, P95: 85, P99: 95}}, nil
+}
+
+func (s *countingStore) SaveHostThresholds(_ context.Context, t []relational.HostThreshold) error {
+	s.saved = t
+	return nil
+}
+
+func (s *countingStore) LoadHostThresholds(context.Context, string) ([]relational.HostThreshold, error) {
+	s.loads++
+	return s.saved, nil
+}
+
+func TestBaselineLearner_CachesWhileLearning(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+	cfg.Learning.Enabled = true
+	cfg.Learning.Period = time.Hour
+	store := &countingStore{first: start}
+	l := NewBaselineLearner(cfg, store, nil)
+
+	for i := range 10 {
+		if got, err := l.Observe(ctx, "agent", start.Add(time.Duration(i)*time.Minute)); err != nil || got != nil {
+			t.Fatalf("still learning: %v, %v", got, err)
+		}
+	}
+	if store.loads != 1 || store.firsts != 1 {
+		t.Errorf("store queried %d/%d times while learning, want once", store.loads, store.firsts)
+	}
+
+	// A new config is picked up, with the store asked again.
+	cfg.Learning.Period = 5 * time.Minute
+	l.SetConfig(cfg)
+	got, err := l.Observe(ctx, "agent", start.Add(10*time.Minute))
+	if err != nil || len(got) != 1 {
+		t.Fatalf("after a shorter period: %v, %v", got, err)
+	}
+	if store.loads != 2 || store.firsts != 2 {
+		t.Errorf("store queried %d/%d times after SetConfig, want twice", store.loads, store.firsts)
+	}
+	if got, _ := l.Observe(ctx, "agent", start.Add(11*time.Minute)); got != nil || store.loads != 2 {
+		t.Errorf("learned host observed again: %v, %d loads", got, store.loads)
+	}
+}
@@ -0,0 +1,110 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/schedule"
+)
+
+// BurstStore persists bursts and the detail captured during them.
+type BurstStore interface {
+	StartBurst(ctx context.Context, b relational.Burst) error
+	EndBurst(ctx context.Context, incidentID string, at time.Time) error
+	InsertBurstSample(ctx context.Context, s relational.BurstSample) error
+}
+
+// BurstTrigger implements relational.BurstController. A flag at or above
+// MinSeverity opens a burst: the scheduler switches to the burst profile
+// and every snapshot until the window closes is recorded with full detail
+// under one incident ID.
+type BurstTrigger struct {
+	cfg       BurstConfig
+	store     BurstStore
+	scheduler *schedule.Scheduler
+
+	mu        sync.Mutex
+	active    map[string]*relational.Burst // agentID -> open burst
+	quietTill map[string]time.Time         // agentID -> end of cooldown
+}
+
+// NewBurstTrigger creates a trigger. store may be nil to skip persistence
+// and scheduler may be nil to leave collection intervals alone.
+func NewBurstTrigger(cfg BurstConfig, store BurstStore, scheduler *schedule.Scheduler) *BurstTrigger {
+	if scheduler != nil {
+		scheduler.Register(schedule.Profile{
+			Name:        schedule.Burst,
+			Description: "automatic capture after a critical flag",
+			Fast:        cfg.Interval,
+			Slow:        cfg.Interval,
+			Duration:    cfg.Window,
+		})
+	}
+	return &BurstTrigger{
+		cfg:       cfg,
+		store:     store,
+		scheduler: scheduler,
+		active:    make(map[string]*relational.Burst),
+		quietTill: make(map[string]time.Time),
+	}
+}
+
+// Observe opens a burst on a critical flag, closes it once its window has
+// passed, and returns the incident ID while one is open.
+func (t *BurstTrigger) Observe(ctx context.Context, agentID string, now time.Time, flags *relational.SnapshotFlags) (string, error) {
+	if !t.cfg.Enabled || flags == nil {
+		return "", nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if b := t.active[agentID]; b != nil {
+		if now.Before(b.EndsAt) {
+			return b.IncidentID, nil
+		}
+		delete(t.active, agentID)
+		t.quietTill[agentID] = now.Add(t.cfg.Cooldown)
+		if t.store != nil {
+			if err := t.store.EndBurst(ctx, b.IncidentID, now); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if flags.SeverityLevel < t.cfg.MinSeverity || now.Before(t.quietTill[agentID]) {
+		return "", nil
+	}
+
+	b := &relational.Burst{
+		IncidentID:   fmt.Sprintf("%s-%s", agentID, now.UTC().Format("20060102T150405Z")),
+		AgentID:      agentID,
+		StartedAt:    now,
+		EndsAt:       now.Add(t.cfg.Window),
+		TriggerFlags: flags.ActiveFlags(),
+		Severity:     flags.SeverityLevel,
+	}
+	if t.store != nil {
+		if err := t.store.StartBurst(ctx, *b); err != nil {
+			return "", err
+		}
+	}
+	t.active[agentID] = b
+	if t.scheduler != nil {
+		if _, err := t.scheduler.Activate(schedule.Burst); err != nil {
+			return "", err
+		}
+	}
+	return b.IncidentID, nil
+}
+
+// Record persists the detail captured with a burst snapshot.
+func (t *BurstTrigger) Record(ctx context.Context, sample relational.BurstSample) error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.InsertBurstSample(ctx, sample)
+}
@@ -0,0 +1,77 @@
+package flagger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/schedule"
+)
+
+// memBurstStore is an in-memory BurstStore for tests.
+type memBurstStore struct {
+	bursts  map[string]relational.Burst
+	samples []relational.BurstSample
+}
+
+func (m *memBurstStore) StartBurst(ctx context.Context, b relational.Burst) error {
+	m.bursts[b.IncidentID] = b
+	return nil
+}
+
+func (m *memBurstStore) EndBurst(ctx context.Context, incidentID string, at time.Time) error {
+	b := m.bursts[incidentID]
+	b.EndedAt = at
+	m.bursts[incidentID] = b
+	return nil
+}
+
+func (m *memBurstStore) InsertBurstSample(ctx context.Context, s relational.BurstSample) error {
+	m.samples = append(m.samples, s)
+	return nil
+}
+
+func TestBurstTrigger_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sched := schedule.NewScheduler(schedule.WithClock(clock.NewFake(start)))
+	store := &memBurstStore{bursts: make(map[string]relational.Burst)}
+	cfg := DefaultConfig().Burst
+	trig := NewBurstTrigger(cfg, store, sched)
+
+	id, err := trig.Observe(ctx, "agent", start, &relational.SnapshotFlags{FlagMemoryPressure: true, SeverityLevel: 2})
+	if err != nil || id != "" {
+		t.Fatalf("warning opened a burst: %q, %v", id, err)
+	}
+
+	id, err = trig.Observe(ctx, "agent", start, cpuCritical())
+	if err != nil || id == "" {
+		t.Fatalf("critical flag did not open a burst: %v", err)
+	}
+	if got := sched.Current().Profile; got.Name != schedule.Burst || got.Slow != cfg.Interval {
+		t.Errorf("scheduler profile = %+v, want burst", got)
+	}
+	if b := store.bursts[id]; len(b.TriggerFlags) != 1 || b.TriggerFlags[0] != "cpu_overloaded" {
+		t.Errorf("trigger flags = %v", b.TriggerFlags)
+	}
+
+	// Later snapshots in the window join the same incident, flagged or not.
+	if got, _ := trig.Observe(ctx, "agent", start.Add(time.Minute), &relational.SnapshotFlags{}); got != id {
+		t.Errorf("in-window incident = %q, want %q", got, id)
+	}
+
+	// The window closes, and the cooldown suppresses an immediate re-trigger.
+	end := start.Add(cfg.Window)
+	if got, _ := trig.Observe(ctx, "agent", end, cpuCritical()); got != "" {
+		t.Errorf("burst re-opened during cooldown: %q", got)
+	}
+	if store.bursts[id].EndedAt != end {
+		t.Errorf("burst not ended at %v", end)
+	}
+
+	if got, _ := trig.Observe(ctx, "agent", end.Add(cfg.Cooldown), cpuCritical()); got == "" || got == id {
+		t.Errorf("expected a new incident after cooldown, got %q", got)
+	}
+}
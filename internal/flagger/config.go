@@ -1,9 +1,126 @@
 package flagger
 
+import (
+	"slices"
+	"time"
+
+	"syschecker/internal/i18n"
+	"syschecker/internal/units"
+)
+
 // Thresholds defines warning and critical levels for metrics
 type Thresholds struct {
-	Warning  float64
-	Critical float64
+	Warning  float64 `json:"warning"`
+	Critical float64 `json:"critical"`
+}
+
+// EscalationStep bumps severity once a flag has been active for After.
+type EscalationStep struct {
+	After     time.Duration
+	Severity  int // added to SeverityLevel (capped at 4)
+	RiskBoost int // added to RiskScore (capped at 100)
+}
+
+// CriticalFlags are the flags raised at critical severity. Only these
+// escalate by default; warning-level flags such as link_degraded would
+// otherwise reach the top severity just by staying active.
+var CriticalFlags = []string{
+	"host_offline",
+	"cpu_overloaded",
+	"memory_pressure",
+	"memory_starvation",
+	"disk_space_critical",
+	"inode_exhaustion",
+	"disk_health_failed",
+	"under_voltage",
+}
+
+// EscalationConfig controls escalation of long-running flags and repeat notifications.
+type EscalationConfig struct {
+	Enabled bool
+	Steps   []EscalationStep // ordered by After ascending
+	Flags   []string         // flags that escalate; empty means every active flag
+
+	RepeatInterval time.Duration // first repeat notification delay
+	RepeatFactor   float64       // multiplier applied to each subsequent repeat
+	RepeatMax      time.Duration // upper bound on the repeat delay
+
+	Locale string // language of the escalation note
+}
+
+// LearningConfig controls the per-host baseline learning period.
+type LearningConfig struct {
+	Enabled    bool
+	Period     time.Duration // how much history to observe before suggesting thresholds
+	MinSamples int64         // minimum snapshots required in the period
+	AutoApply  bool          // apply learned thresholds instead of only suggesting them
+	Headroom   float64       // multiplier applied to observed percentiles
+}
+
+// SeasonalityConfig controls hour-of-week deviation detection.
+type SeasonalityConfig struct {
+	Enabled    bool
+	Lookback   time.Duration // history used for expected ranges
+	MinSamples int64         // samples needed in a slot before it is trusted
+	Factor     float64       // actual/mean ratio that counts as a deviation
+
+	Locale string // language of the deviation note
+}
+
+// ForecastConfig controls predictive out-of-memory flagging.
+type ForecastConfig struct {
+	Enabled    bool
+	Window     time.Duration // trend window for available memory
+	MinSamples int           // observations needed before projecting
+	Horizon    time.Duration // flag when exhaustion is projected within this time
+	SwapInBps  float64       // swap-in rate that doubles the horizon (0 disables)
+
+	Locale string       // language of the forecast note
+	Units  units.System // byte units of the forecast note
+}
+
+// StorageConfig sets when the storage_budget flag is raised.
+type StorageConfig struct {
+	WarnPct float64 // percent of the database size budget in use (0 disables)
+
+	Locale string       // language of the budget note
+	Units  units.System // byte units of the budget note
+}
+
+// TempDataConfig sets the sizes at which temp data and core dumps raise a warning.
+type TempDataConfig struct {
+	StaleTempBytes uint64 // stale bytes in a single temp directory
+	CoreDumpBytes  uint64 // total bytes in a core dump location
+}
+
+// SaturationConfig sets when a link counts as saturated: utilization above
+// Percent of its negotiated speed for Samples consecutive snapshots.
+type SaturationConfig struct {
+	Percent float64
+	Samples int
+}
+
+// BurstConfig controls high-frequency capture when a critical flag fires.
+type BurstConfig struct {
+	Enabled     bool
+	MinSeverity int           // flag severity that opens a burst
+	Interval    time.Duration // collection interval during the burst
+	Window      time.Duration // how long a burst lasts
+	Cooldown    time.Duration // quiet period after a burst before another can start
+}
+
+// DiskScanConfig controls the largest-directories scan run when disk space is critical.
+type DiskScanConfig struct {
+	Enabled     bool
+	Roots       []string
+	MaxDepth    int           // directory depth reported below each root
+	MaxEntries  int           // entries visited per scan
+	Timeout     time.Duration // wall-clock bound per scan
+	MinInterval time.Duration // minimum time between scans
+	Top         int
+
+	Locale string       // language of the largest-directories note
+	Units  units.System // byte units of the largest-directories note
 }
 
 type Config struct {
@@ -12,7 +129,79 @@ type Config struct {
 	Disk      Thresholds
 	Inode     Thresholds
 	Net       Thresholds // ms
+	Loss      Thresholds // percent of background probes lost
 	ActiveTCP Thresholds
+	UserShare Thresholds // percent of total RAM or CPU capacity used by one user
+	Container Thresholds // percent of host CPU capacity used by one container's top processes
+	GPU       Thresholds // percent busy, per GPU
+	GPUMem    Thresholds // percent of VRAM in use, per GPU
+
+	Escalation EscalationConfig
+	Learning   LearningConfig
+	Seasonal   SeasonalityConfig
+	Forecast   ForecastConfig
+	DiskScan   DiskScanConfig
+	TempData   TempDataConfig
+	Storage    StorageConfig
+	Saturation SaturationConfig
+	Burst      BurstConfig
+
+	// Ignore suppresses flags the operator has decided not to act on.
+	Ignore []IgnoreRule
+
+	// Locale is the language of explanations; see i18n.Supported. Set it
+	// with WithLocale so the sub-configs that add notes follow.
+	Locale string
+	// Units selects binary or SI byte units in explanations. Set it with
+	// WithUnits for the same reason.
+	Units units.System
+	// Location is the time zone times are displayed in by the TUI and
+	// reports; nil means UTC. Stored times are always UTC.
+	Location *time.Location
+}
+
+// WithLocale returns a copy of c whose explanations are written in lang.
+func (c Config) WithLocale(lang string) Config {
+	lang = i18n.Normalize(lang)
+	c.Locale = lang
+	c.Escalation.Locale = lang
+	c.Seasonal.Locale = lang
+	c.Forecast.Locale = lang
+	c.DiskScan.Locale = lang
+	c.Storage.Locale = lang
+	return c
+}
+
+// WithUnits returns a copy of c whose explanations use sys byte units.
+func (c Config) WithUnits(sys units.System) Config {
+	c.Units = sys
+	c.Forecast.Units = sys
+	c.DiskScan.Units = sys
+	c.Storage.Units = sys
+	return c
+}
+
+// WithLocation returns a copy of c that displays times in loc.
+func (c Config) WithLocation(loc *time.Location) Config {
+	c.Location = loc
+	return c
+}
+
+// thresholdsFor returns a pointer to the thresholds backing a baseline metric column.
+func (c *Config) thresholdsFor(metric string) *Thresholds {
+	switch metric {
+	case "cpu_usage_pct":
+		return &c.CPU
+	case "ram_usage_pct":
+		return &c.RAM
+	case "disk_usage_pct":
+		return &c.Disk
+	case "inode_usage_pct":
+		return &c.Inode
+	case "net_latency_ms":
+		return &c.Net
+	}
+	return nil
 }
 
 func DefaultConfig() Config {
@@ -22,6 +211,67 @@ func DefaultConfig() Config {
 		Disk:      Thresholds{Warning: 80.0, Critical: 90.0},
 		Inode:     Thresholds{Warning: 80.0, Critical: 90.0},
 		Net:       Thresholds{Warning: 150.0, Critical: 500.0},
+		Loss:      Thresholds{Warning: 2.0, Critical: 10.0},
 		ActiveTCP: Thresholds{Warning: 200.0, Critical: 500.0},
+		UserShare: Thresholds{Warning: 50.0, Critical: 80.0},
+		Container: Thresholds{Warning: 50.0, Critical: 80.0},
+		GPU:       Thresholds{Warning: 90.0, Critical: 98.0},
+		GPUMem:    Thresholds{Warning: 85.0, Critical: 95.0},
+		Escalation: EscalationConfig{
+			Enabled: true,
+			Steps: []EscalationStep{
+				{After: 15 * time.Minute, Severity: 1, RiskBoost: 10},
+				{After: 1 * time.Hour, Severity: 1, RiskBoost: 20},
+			},
+			Flags:          slices.Clone(CriticalFlags),
+			RepeatInterval: 15 * time.Minute,
+			RepeatFactor:   2.0,
+			RepeatMax:      4 * time.Hour,
+		},
+		Learning: LearningConfig{
+			Enabled:    true,
+			Period:     24 * time.Hour,
+			MinSamples: 100,
+			AutoApply:  false,
+			Headroom:   1.1,
+		},
+		Seasonal: SeasonalityConfig{
+			Enabled:    true,
+			Lookback:   28 * 24 * time.Hour,
+			MinSamples: 30,
+			Factor:     2.0,
+		},
+		Forecast: ForecastConfig{
+			Enabled:    true,
+			Window:     15 * time.Minute,
+			MinSamples: 5,
+			Horizon:    30 * time.Minute,
+			SwapInBps:  1 << 20,
+		},
+		DiskScan: DiskScanConfig{
+			Enabled:     true,
+			Roots:       []string{"/"},
+			MaxDepth:    3,
+			MaxEntries:  500_000,
+			Timeout:     15 * time.Second,
+			MinInterval: 30 * time.Minute,
+			Top:         10,
+		},
+		TempData: TempDataConfig{
+			StaleTempBytes: 5 << 30,
+			CoreDumpBytes:  2 << 30,
+		},
+		Storage: StorageConfig{WarnPct: 90},
+		Saturation: SaturationConfig{
+			Percent: 90,
+			Samples: 3,
+		},
+		Burst: BurstConfig{
+			Enabled:     true,
+			MinSeverity: 3,
+			Interval:    time.Second,
+			Window:      5 * time.Minute,
+			Cooldown:    15 * time.Minute,
+		},
 	}
 }
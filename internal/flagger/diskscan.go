@@ -0,0 +1,80 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/collector/services"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// DiskScanner runs a bounded, rate-limited du-style scan when disk space goes
+// critical and records the largest directories on the snapshot.
+type DiskScanner struct {
+	cfg   DiskScanConfig
+	clock clock.Clock
+
+	mu       sync.Mutex
+	lastScan time.Time
+}
+
+// NewDiskScanner creates a scanner. clk may be nil for the wall clock.
+func NewDiskScanner(cfg DiskScanConfig, clk clock.Clock) *DiskScanner {
+	return &DiskScanner{cfg: cfg, clock: clock.OrReal(clk)}
+}
+
+// Investigate scans the configured roots if disk space is critical and no scan ran
+// within MinInterval, adding the largest directories to the explanation.
+func (d *DiskScanner) Investigate(ctx context.Context, s *relational.RawStatsFixed, flags *relational.SnapshotFlags) []relational.DirUsageFixed {
+	if !d.cfg.Enabled || flags == nil || !flags.FlagDiskSpaceCritical {
+		return nil
+	}
+
+	d.mu.Lock()
+	now := d.clock.Now()
+	if !d.lastScan.IsZero() && now.Sub(d.lastScan) < d.cfg.MinInterval {
+		d.mu.Unlock()
+		return nil
+	}
+	d.lastScan = now
+	d.mu.Unlock()
+
+	if d.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.cfg.Timeout)
+		defer cancel()
+	}
+	found, partial := services.ScanLargestDirs(ctx, d.cfg.Roots, services.DirScanLimits{
+		MaxDepth:   d.cfg.MaxDepth,
+		MaxEntries: d.cfg.MaxEntries,
+		Top:        d.cfg.Top,
+	})
+	if len(found) == 0 {
+		return nil
+	}
+
+	dirs := make([]relational.DirUsageFixed, 0, len(found))
+	parts := make([]string, 0, 3)
+	for i, f := range found {
+		dirs = append(dirs, relational.DirUsageFixed{Path: f.Path, SizeBytes: f.SizeBytes})
+		if i < cap(parts) {
+			parts = append(parts, fmt.Sprintf("%s %s", f.Path, d.cfg.Units.Bytes(f.SizeBytes)))
+		}
+	}
+	msg := i18n.NewPrinter(d.cfg.Locale)
+	note := msg.Sprintf("largest dirs: %s", strings.Join(parts, ", "))
+	if partial {
+		note += msg.Text(" (partial scan)")
+	}
+	if flags.Explanation == "" {
+		flags.Explanation = note
+	} else {
+		flags.Explanation += "; " + note
+	}
+	return dirs
+}
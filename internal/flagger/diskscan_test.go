@@ -0,0 +1,51 @@
+package flagger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/relational"
+)
+
+func TestDiskScannerRateLimited(t *testing.T) {
+	root := t.TempDir()
+	big := filepath.Join(root, "srv", "dumps")
+	if err := os.MkdirAll(big, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(big, "core.1"), make([]byte, 3<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ds := NewDiskScanner(DiskScanConfig{Enabled: true, Roots: []string{root}, MaxDepth: 2, MinInterval: time.Hour, Top: 10}, clk)
+	ctx := context.Background()
+	stats := &relational.RawStatsFixed{}
+
+	if dirs := ds.Investigate(ctx, stats, &relational.SnapshotFlags{}); dirs != nil {
+		t.Fatalf("scanned without disk pressure: %+v", dirs)
+	}
+
+	flags := &relational.SnapshotFlags{FlagDiskSpaceCritical: true, Explanation: "Disk critical: 97.0%"}
+	dirs := ds.Investigate(ctx, stats, flags)
+	if len(dirs) != 2 || dirs[0].Path != filepath.Join(root, "srv") || dirs[0].SizeBytes != 3<<20 {
+		t.Fatalf("unexpected dirs: %+v", dirs)
+	}
+	if !strings.Contains(flags.Explanation, "largest dirs: "+filepath.Join(root, "srv")+" 3.0 MiB") {
+		t.Errorf("explanation = %q", flags.Explanation)
+	}
+
+	clk.Advance(10 * time.Minute)
+	if dirs := ds.Investigate(ctx, stats, &relational.SnapshotFlags{FlagDiskSpaceCritical: true}); dirs != nil {
+		t.Error("scan repeated within MinInterval")
+	}
+	clk.Advance(time.Hour)
+	if dirs := ds.Investigate(ctx, stats, &relational.SnapshotFlags{FlagDiskSpaceCritical: true}); dirs == nil {
+		t.Error("scan not repeated after MinInterval")
+	}
+}
@@ -0,0 +1,224 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// EscalationStore persists escalation state so restarts don't reset it.
+type EscalationStore interface {
+	LoadEscalations(ctx context.Context, agentID string) ([]relational.EscalationState, error)
+	SaveEscalation(ctx context.Context, st relational.EscalationState) error
+	DeleteEscalation(ctx context.Context, agentID, flag string) error
+}
+
+// Escalator implements relational.FlagEscalator.
+type Escalator struct {
+	cfg   EscalationConfig
+	store EscalationStore
+
+	mu     sync.Mutex
+	loaded map[string]bool                                   // agentID -> state loaded from store
+	states map[string]map[string]*relational.EscalationState // agentID -> flag -> state
+}
+
+// NewEscalator creates an escalator. store may be nil for in-memory only tracking.
+func NewEscalator(cfg EscalationConfig, store EscalationStore) *Escalator {
+	return &Escalator{
+		cfg:    cfg,
+		store:  store,
+		loaded: make(map[string]bool),
+		states: make(map[string]map[string]*relational.EscalationState),
+	}
+}
+
+// Apply tracks active flags for the host, escalates severity for long-running ones,
+// and returns notices for level changes and due repeat notifications.
+func (e *Escalator) Apply(ctx context.Context, agentID string, now time.Time, flags *relational.SnapshotFlags) ([]relational.EscalationNotice, error) {
+	if !e.cfg.Enabled || flags == nil {
+		return nil, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	hostStates, err := e.hostStates(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool)
+	var notices []relational.EscalationNotice
+	maxLevel := 0
+	var worst *relational.EscalationState
+
+	for _, name := range flags.ActiveFlags() {
+		if len(e.cfg.Flags) > 0 && !slices.Contains(e.cfg.Flags, name) {
+			continue
+		}
+		active[name] = true
+
+		st, ok := hostStates[name]
+		if !ok {
+			// First sighting: start the clock, the initial alert is not an escalation.
+			st = &relational.EscalationState{
+				AgentID:        agentID,
+				Flag:           name,
+				FirstSeenAt:    now,
+				LastNotifiedAt: now,
+				NotifyCount:    1,
+			}
+			hostStates[name] = st
+			if err := e.save(ctx, *st); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		activeFor := now.Sub(st.FirstSeenAt)
+		level := e.levelFor(activeFor)
+		changed := false
+
+		if level > st.Level {
+			st.Level = level
+			st.LastNotifiedAt = now
+			st.NotifyCount++
+			changed = true
+			notices = append(notices, relational.EscalationNotice{
+				AgentID:     agentID,
+				Flag:        name,
+				Level:       level,
+				ActiveFor:   activeFor,
+				NotifyCount: st.NotifyCount,
+			})
+		} else if !now.Before(st.LastNotifiedAt.Add(e.repeatDelay(st.NotifyCount))) {
+			st.LastNotifiedAt = now
+			st.NotifyCount++
+			changed = true
+			notices = append(notices, relational.EscalationNotice{
+				AgentID:     agentID,
+				Flag:        name,
+				Level:       st.Level,
+				ActiveFor:   activeFor,
+				Repeat:      true,
+				NotifyCount: st.NotifyCount,
+			})
+		}
+
+		if changed {
+			if err := e.save(ctx, *st); err != nil {
+				return nil, err
+			}
+		}
+
+		if st.Level > maxLevel {
+			maxLevel = st.Level
+			worst = st
+		}
+	}
+
+	// Cleared flags, and flags no longer configured to escalate, reset.
+	for name := range hostStates {
+		if active[name] {
+			continue
+		}
+		delete(hostStates, name)
+		if e.store != nil {
+			if err := e.store.DeleteEscalation(ctx, agentID, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if worst != nil {
+		e.bump(flags, maxLevel)
+		flags.Explanation += i18n.NewPrinter(e.cfg.Locale).Sprintf(" [escalated L%d: %s active %s]",
+			maxLevel, worst.Flag, now.Sub(worst.FirstSeenAt).Truncate(time.Minute))
+	}
+
+	return notices, nil
+}
+
+// hostStates returns the cached states for agentID, loading them from the store once.
+func (e *Escalator) hostStates(ctx context.Context, agentID string) (map[string]*relational.EscalationState, error) {
+	hostStates, ok := e.states[agentID]
+	if !ok {
+		hostStates = make(map[string]*relational.EscalationState)
+		e.states[agentID] = hostStates
+	}
+	if e.loaded[agentID] || e.store == nil {
+		return hostStates, nil
+	}
+
+	persisted, err := e.store.LoadEscalations(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("load escalations: %w", err)
+	}
+	for i := range persisted {
+		st := persisted[i]
+		hostStates[st.Flag] = &st
+	}
+	e.loaded[agentID] = true
+	return hostStates, nil
+}
+
+func (e *Escalator) save(ctx context.Context, st relational.EscalationState) error {
+	if e.store == nil {
+		return nil
+	}
+	if err := e.store.SaveEscalation(ctx, st); err != nil {
+		return fmt.Errorf("save escalation %s: %w", st.Flag, err)
+	}
+	return nil
+}
+
+// levelFor returns how many escalation steps a flag active for d has reached.
+func (e *Escalator) levelFor(d time.Duration) int {
+	level := 0
+	for _, step := range e.cfg.Steps {
+		if d >= step.After {
+			level++
+		}
+	}
+	return level
+}
+
+// repeatDelay returns the wait before the next reminder after n notifications.
+func (e *Escalator) repeatDelay(n int) time.Duration {
+	if e.cfg.RepeatInterval <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	factor := e.cfg.RepeatFactor
+	if factor < 1 {
+		factor = 1
+	}
+	delay := float64(e.cfg.RepeatInterval) * math.Pow(factor, float64(max(n-1, 0)))
+	if e.cfg.RepeatMax > 0 && delay > float64(e.cfg.RepeatMax) {
+		return e.cfg.RepeatMax
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// bump raises severity and risk for the given escalation level.
+func (e *Escalator) bump(flags *relational.SnapshotFlags, level int) {
+	for i := 0; i < level && i < len(e.cfg.Steps); i++ {
+		flags.SeverityLevel += e.cfg.Steps[i].Severity
+		flags.RiskScore += e.cfg.Steps[i].RiskBoost
+	}
+	if flags.SeverityLevel > 4 {
+		flags.SeverityLevel = 4
+	}
+	if flags.RiskScore > 100 {
+		flags.RiskScore = 100
+	}
+}
@@ -0,0 +1,146 @@
+package flagger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// memEscalationStore is an in-memory EscalationStore for tests.
+type memEscalationStore struct {
+	states map[string]relational.EscalationState
+}
+
+func newMemEscalationStore() *memEscalationStore {
+	return &memEscalationStore{states: make(map[string]relational.EscalationState)}
+}
+
+func (m *memEscalationStore) LoadEscalations(ctx context.Context, agentID string) ([]relational.EscalationState, error) {
+	var out []relational.EscalationState
+	for _, st := range m.states {
+		if st.AgentID == agentID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (m *memEscalationStore) SaveEscalation(ctx context.Context, st relational.EscalationState) error {
+	m.states[st.AgentID+"/"+st.Flag] = st
+	return nil
+}
+
+func (m *memEscalationStore) DeleteEscalation(ctx context.Context, agentID, flag string) error {
+	delete(m.states, agentID+"/"+flag)
+	return nil
+}
+
+func cpuCritical() *relational.SnapshotFlags {
+	return &relational.SnapshotFlags{FlagCPUOverloaded: true, SeverityLevel: 3, RiskScore: 30}
+}
+
+func TestEscalator_EscalatesLongRunningFlag(t *testing.T) {
+	ctx := context.Background()
+	store := newMemEscalationStore()
+	esc := NewEscalator(DefaultConfig().Escalation, store)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := cpuCritical()
+	notices, err := esc.Apply(ctx, "agent", start, f)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(notices) != 0 || f.SeverityLevel != 3 {
+		t.Fatalf("first sighting should not escalate, got %d notices, severity %d", len(notices), f.SeverityLevel)
+	}
+
+	f = cpuCritical()
+	notices, err = esc.Apply(ctx, "agent", start.Add(16*time.Minute), f)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(notices) != 1 || notices[0].Level != 1 || notices[0].Repeat {
+		t.Fatalf("expected one level-1 escalation notice, got %+v", notices)
+	}
+	if f.SeverityLevel != 4 || f.RiskScore != 40 {
+		t.Errorf("expected severity 4 / risk 40, got %d / %d", f.SeverityLevel, f.RiskScore)
+	}
+}
+
+func TestEscalator_StateSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := newMemEscalationStore()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := NewEscalator(DefaultConfig().Escalation, store).Apply(ctx, "agent", start, cpuCritical()); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// A fresh escalator (simulated restart) must pick up the original first-seen time.
+	notices, err := NewEscalator(DefaultConfig().Escalation, store).Apply(ctx, "agent", start.Add(61*time.Minute), cpuCritical())
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(notices) != 1 || notices[0].Level != 2 {
+		t.Fatalf("expected level-2 escalation after restart, got %+v", notices)
+	}
+}
+
+func TestEscalator_ClearedFlagResets(t *testing.T) {
+	ctx := context.Background()
+	store := newMemEscalationStore()
+	esc := NewEscalator(DefaultConfig().Escalation, store)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _ = esc.Apply(ctx, "agent", start, cpuCritical())
+	_, _ = esc.Apply(ctx, "agent", start.Add(time.Minute), &relational.SnapshotFlags{})
+
+	if len(store.states) != 0 {
+		t.Fatalf("expected escalation state to be cleared, got %v", store.states)
+	}
+}
+
+func TestEscalator_RepeatBackoff(t *testing.T) {
+	cfg := EscalationConfig{
+		Enabled:        true,
+		RepeatInterval: 10 * time.Minute,
+		RepeatFactor:   2,
+		RepeatMax:      30 * time.Minute,
+	}
+	esc := NewEscalator(cfg, nil)
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 30 * time.Minute}, // capped
+	}
+	for _, tt := range tests {
+		if got := esc.repeatDelay(tt.n); got != tt.want {
+			t.Errorf("repeatDelay(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEscalator_OnlyConfiguredFlagsEscalate(t *testing.T) {
+	ctx := context.Background()
+	esc := NewEscalator(DefaultConfig().Escalation, nil)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	degraded := func() *relational.SnapshotFlags {
+		return &relational.SnapshotFlags{FlagLinkDegraded: true, SeverityLevel: 2}
+	}
+
+	_, _ = esc.Apply(ctx, "agent", start, degraded())
+	f := degraded()
+	notices, err := esc.Apply(ctx, "agent", start.Add(2*time.Hour), f)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(notices) != 0 || f.SeverityLevel != 2 {
+		t.Errorf("warning-level flag escalated: %d notices, severity %d", len(notices), f.SeverityLevel)
+	}
+}
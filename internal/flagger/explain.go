@@ -0,0 +1,167 @@
+package flagger
+
+import (
+	"syschecker/internal/collector/services"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// FlagExplanation is an active flag with the measurements that raised it
+// and what to do about it.
+type FlagExplanation struct {
+	Flag        string     `json:"flag"`
+	Label       string     `json:"label"`
+	Evidence    []Evidence `json:"evidence,omitempty"`
+	Remediation string     `json:"remediation"`
+}
+
+// Evidence is one metric value compared against its thresholds. Flags
+// raised by state across snapshots, such as escalations or forecasts,
+// carry none.
+type Evidence struct {
+	Metric   string  `json:"metric"`
+	Value    float64 `json:"value"`
+	Warning  float64 `json:"warning,omitempty"`
+	Critical float64 `json:"critical,omitempty"`
+	Entity   string  `json:"entity,omitempty"` // mount, interface, user, container, process, check or GPU
+}
+
+// remediations are the suggested first steps per flag, in English.
+var remediations = map[string]string{
+	"host_offline":                "Check that the agent is running and the host is reachable; restart the agent service if it stopped.",
+	"cpu_overloaded":              "Find the busiest processes in TopProcesses and stop, throttle or move them; add cores if the load is expected.",
+	"memory_pressure":             "Find the largest processes by RSS and restart leaking ones; raise the memory limit or add RAM if the load is expected.",
+	"memory_starvation":           "The OOM killer ended a process: raise its memory limit or fix its leak, and check what else competes for RAM.",
+	"swap_thrashing":              "Reduce memory use or add RAM; lowering vm.swappiness only hides the shortage.",
+	"disk_space_critical":         "Remove or rotate the largest files on the mount, starting with growing logs, core dumps and stale temp data; grow the filesystem if needed.",
+	"inode_exhaustion":            "Delete directories holding many small files, such as caches, mail spools or session stores, on the mount.",
+	"disk_io_saturation":          "Find the process doing the I/O with iotop or pidstat -d and reschedule or throttle it; check the disk's health.",
+	"disk_health_failed":          "Back up the data on the disk now and replace it; SMART failures precede total loss.",
+	"network_latency_degraded":    "Compare with direct latency: a slow VPN points at the tunnel, a slow uplink at the ISP or local network.",
+	"network_packet_loss":         "Check cabling, Wi-Fi signal and interface errors; trace the path with mtr to find where packets drop.",
+	"network_interface_errors":    "Check the cable, switch port and driver of the interface reporting errors.",
+	"docker_unavailable":          "Start the Docker daemon or give the agent access to its socket; disable the docker sensor if Docker is not used.",
+	"container_cpu_hog":           "Set a CPU limit on the container or investigate its busiest process.",
+	"container_memory_pressure":   "Raise the container's memory limit or fix the leak in its largest process.",
+	"container_oom_risk":          "Raise the container's memory limit before the kernel kills it.",
+	"runaway_process_cpu":         "Check whether the process is stuck in a loop and restart or limit it.",
+	"runaway_process_memory":      "Restart the process to reclaim memory and look for a leak.",
+	"thermal_pressure":            "Improve cooling or airflow and reduce sustained load; the CPU is slowing down to protect itself.",
+	"system_at_risk":              "Several critical conditions coincide: address the flags with the highest severity first.",
+	"memory_exhaustion_predicted": "Memory will run out at the current trend: restart the growing process or free memory before it does.",
+	"user_resource_hog":           "Ask the user to reduce their jobs, or enforce per-user limits with cgroups or ulimits.",
+	"under_voltage":               "Replace the power supply or cable with one rated for the board; under-voltage corrupts SD cards.",
+	"link_degraded":               "Reseat or replace the cable and check the switch port; the link negotiated below its capability or flapped.",
+	"link_saturated":              "Find the traffic source with iftop or nethogs and rate-limit it, or move to a faster link.",
+	"check_failed":                "Run the check command by hand to see why it failed.",
+	"storage_budget":              "Shorten retention or raise the storage budget of the syschecker database.",
+	"gpu_overloaded":              "Check which jobs share the GPU with nvidia-smi or radeontop and queue or move some of them; a saturated GPU is expected while training.",
+	"gpu_memory_pressure":         "Lower batch sizes or model sizes, or stop idle processes holding GPU memory, before allocations start failing.",
+}
+
+// Explain lists the active flags of f with the values of s that raised
+// them, the thresholds in effect for the host and suggested remediation.
+// It only reads its arguments, so it is safe to call on any flagged
+// snapshot without affecting later flagging.
+func (fs *FlaggerService) Explain(s *relational.RawStatsFixed, d *relational.DerivedRates, f *relational.SnapshotFlags) []FlagExplanation {
+	cfg := fs.configFor(s.AgentID)
+	msg := i18n.NewPrinter(cfg.Locale)
+	cores := float64(max(s.CPUCoresLogical, 1))
+
+	out := make([]FlagExplanation, 0, len(f.ActiveFlags()))
+	for _, flag := range f.ActiveFlags() {
+		e := FlagExplanation{Flag: flag, Label: msg.FlagLabel(flag), Remediation: msg.Text(remediations[flag])}
+		add := func(metric string, value float64, th Thresholds, entity string) {
+			e.Evidence = append(e.Evidence, Evidence{Metric: metric, Value: value, Warning: th.Warning, Critical: th.Critical, Entity: entity})
+		}
+		switch flag {
+		case "cpu_overloaded":
+			add("cpu_usage_pct", s.CPUUsagePct, cfg.CPU, "")
+		case "memory_pressure":
+			add("ram_usage_pct", s.RAMUsagePct, cfg.RAM, "")
+		case "memory_starvation":
+			for _, k := range s.OOMKills {
+				add("oom_kill_anon_rss_bytes", float64(k.AnonRSSBytes), Thresholds{}, k.Process)
+			}
+		case "disk_space_critical":
+			mount, pct := fullestMount(s, s.DiskUsagePct, func(p relational.PartitionUsageFixed) float64 { return p.UsedPercent })
+			add("disk_usage_pct", pct, cfg.Disk, mount)
+			if len(s.LogGrowers) > 0 && s.LogGrowers[0].GrowthBps > 0 {
+				add("file_growth_bps", s.LogGrowers[0].GrowthBps, Thresholds{}, s.LogGrowers[0].Path)
+			}
+		case "inode_exhaustion":
+			mount, pct := fullestMount(s, s.InodeUsagePct, func(p relational.PartitionUsageFixed) float64 { return p.InodeUsage })
+			add("inode_usage_pct", pct, cfg.Inode, mount)
+		case "disk_io_saturation":
+			if d != nil {
+				add("disk_read_bps", d.DiskReadBps, Thresholds{Critical: diskReadSaturationBps}, "")
+			}
+		case "network_latency_degraded":
+			add("net_latency_ms", s.NetLatencyMS, cfg.Net, s.VPNInterface)
+			if s.VPNInterface != "" && s.NetDirectMS > 0 {
+				add("net_direct_ms", s.NetDirectMS, cfg.Net, "")
+			}
+		case "network_packet_loss":
+			add("net_loss_pct", s.NetLossPct, cfg.Loss, "")
+		case "link_degraded":
+			for _, ni := range s.NetInterfaces {
+				if d != nil && d.NetCarrierChanges[ni.Name] >= 2 {
+					add("net_carrier_changes", float64(d.NetCarrierChanges[ni.Name]), Thresholds{Critical: 2}, ni.Name)
+				} else if ni.OperState == "up" && ni.SpeedMbps > 0 && ni.MaxSpeedMbps > ni.SpeedMbps {
+					add("net_speed_mbps", float64(ni.SpeedMbps), Thresholds{Warning: float64(ni.MaxSpeedMbps)}, ni.Name)
+				}
+			}
+		case "link_saturated":
+			if d != nil {
+				for name, util := range d.NetUtilization {
+					if util > cfg.Saturation.Percent {
+						add("net_utilization_pct", util, Thresholds{Critical: cfg.Saturation.Percent}, name)
+					}
+				}
+			}
+		case "user_resource_hog":
+			for _, u := range s.UserUsage {
+				metric, share := "user_ram_pct", u.MemPct
+				if cpuShare := u.CPUPct / cores; cpuShare > share {
+					metric, share = "user_cpu_pct", cpuShare
+				}
+				if share > cfg.UserShare.Warning {
+					add(metric, share, cfg.UserShare, u.User)
+				}
+			}
+		case "container_cpu_hog":
+			if u, ok := busiestContainer(s); ok {
+				add("container_cpu_pct", u.cpu/cores, cfg.Container, u.name)
+			}
+		case "thermal_pressure":
+			if s.PowerTotalWatts > 0 {
+				add("power_total_watts", s.PowerTotalWatts, Thresholds{}, s.ThermalPressure)
+			}
+			if s.ThrottledBits&(services.ThrottleThrottled|services.ThrottleSoftTempLimit) != 0 {
+				add("throttled_bits", float64(s.ThrottledBits), Thresholds{}, "")
+			}
+		case "under_voltage":
+			add("throttled_bits", float64(s.ThrottledBits), Thresholds{}, "")
+		case "check_failed":
+			for _, c := range s.Checks {
+				if !c.OK {
+					add("check_exit_code", float64(c.ExitCode), Thresholds{}, c.Name)
+				}
+			}
+		case "gpu_overloaded":
+			for _, g := range s.GPUs {
+				if g.UtilPct > cfg.GPU.Critical {
+					add("gpu_util_pct", g.UtilPct, cfg.GPU, gpuKey(g))
+				}
+			}
+		case "gpu_memory_pressure":
+			for _, g := range s.GPUs {
+				if g.MemPct() > cfg.GPUMem.Critical {
+					add("gpu_mem_pct", g.MemPct(), cfg.GPUMem, gpuKey(g))
+				}
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
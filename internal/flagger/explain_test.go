@@ -0,0 +1,57 @@
+package flagger
+
+import (
+	"testing"
+
+	"syschecker/internal/database/relational"
+)
+
+func TestRemediationsCoverFlagNames(t *testing.T) {
+	for _, flag := range relational.FlagNames {
+		if remediations[flag] == "" {
+			t.Errorf("no remediation for flag %s", flag)
+		}
+	}
+}
+
+func TestExplainReportsValuesAndHostThresholds(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	fs.SetHostThresholds("a1", []relational.HostThreshold{{Metric: "cpu_usage_pct", Warning: 80, Critical: 95}})
+	s := &relational.RawStatsFixed{
+		AgentID:         "a1",
+		DockerAvailable: true,
+		CPUUsagePct:     97,
+		DiskUsagePct:    50,
+		Partitions: []relational.PartitionUsageFixed{
+			{Mountpoint: "/data", Fstype: "ext4", TotalBytes: 1 << 30, UsedPercent: 96},
+		},
+		Checks: []relational.CheckResultFixed{{Name: "backup", ExitCode: 2}},
+	}
+	d := &relational.DerivedRates{}
+	f := fs.Flag(s, d)
+
+	got := make(map[string]FlagExplanation)
+	for _, e := range fs.Explain(s, d, f) {
+		got[e.Flag] = e
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected cpu, disk and check explanations, got %+v", got)
+	}
+	cpu := got["cpu_overloaded"]
+	if len(cpu.Evidence) != 1 || cpu.Evidence[0] != (Evidence{Metric: "cpu_usage_pct", Value: 97, Warning: 80, Critical: 95}) {
+		t.Errorf("cpu evidence = %+v", cpu.Evidence)
+	}
+	if cpu.Label != "CPU overloaded" || cpu.Remediation == "" {
+		t.Errorf("cpu explanation = %+v", cpu)
+	}
+	if disk := got["disk_space_critical"]; len(disk.Evidence) != 1 || disk.Evidence[0].Entity != "/data" || disk.Evidence[0].Value != 96 {
+		t.Errorf("disk evidence = %+v", disk.Evidence)
+	}
+	if check := got["check_failed"]; len(check.Evidence) != 1 || check.Evidence[0].Entity != "backup" || check.Evidence[0].Value != 2 {
+		t.Errorf("check evidence = %+v", check.Evidence)
+	}
+
+	if e := fs.Explain(s, d, &relational.SnapshotFlags{}); len(e) != 0 {
+		t.Errorf("expected no explanations without flags, got %+v", e)
+	}
+}
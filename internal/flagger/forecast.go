@@ -0,0 +1,134 @@
+package flagger
+
+import (
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// memPoint is one observation in a host's memory trend window.
+type memPoint struct {
+	at        time.Time
+	available float64
+	swapIn    uint64
+}
+
+// MemoryForecaster predicts imminent OOM from the available memory trend and
+// swap-in rate ("available memory will hit zero in ~12 minutes at current rate").
+type MemoryForecaster struct {
+	cfg ForecastConfig
+
+	mu     sync.Mutex
+	points map[string][]memPoint // agentID -> observations within the window
+}
+
+// NewMemoryForecaster creates a forecaster with in-memory trend windows.
+func NewMemoryForecaster(cfg ForecastConfig) *MemoryForecaster {
+	return &MemoryForecaster{cfg: cfg, points: make(map[string][]memPoint)}
+}
+
+// Forecast records the snapshot and, when exhaustion is expected within the
+// horizon, sets FlagMemoryExhaustionPredicted and adds the projection to the explanation.
+func (mf *MemoryForecaster) Forecast(s *relational.RawStatsFixed, flags *relational.SnapshotFlags) *relational.MemoryForecast {
+	if !mf.cfg.Enabled || s == nil {
+		return nil
+	}
+
+	pts := mf.observe(s)
+	if len(pts) < mf.cfg.MinSamples || len(pts) < 2 {
+		return nil
+	}
+
+	first, last := pts[0], pts[len(pts)-1]
+	fc := &relational.MemoryForecast{
+		AvailableBytes:   s.RAMAvailableBytes,
+		TrendBytesPerSec: availableSlope(pts),
+		Samples:          len(pts),
+	}
+	if secs := last.at.Sub(first.at).Seconds(); secs > 0 && last.swapIn >= first.swapIn {
+		fc.SwapInBps = float64(last.swapIn-first.swapIn) / secs
+	}
+	if fc.TrendBytesPerSec < 0 {
+		fc.TimeToExhaustion = time.Duration(last.available / -fc.TrendBytesPerSec * float64(time.Second))
+	}
+
+	// Sustained swap-in means the kernel is already reclaiming, so trust a longer projection.
+	horizon := mf.cfg.Horizon
+	if mf.cfg.SwapInBps > 0 && fc.SwapInBps >= mf.cfg.SwapInBps {
+		horizon *= 2
+	}
+	fc.Predicted = fc.TimeToExhaustion > 0 && fc.TimeToExhaustion <= horizon
+
+	if fc.Predicted && flags != nil {
+		flags.FlagMemoryExhaustionPredicted = true
+		flags.SeverityLevel = max(flags.SeverityLevel, 2)
+		if flags.PrimaryCause == "" {
+			flags.PrimaryCause = "memory"
+		}
+		msg := i18n.NewPrinter(mf.cfg.Locale)
+		note := msg.Sprintf("available memory will hit zero in ~%s at current rate (-%s/min",
+			roundForecast(msg, fc.TimeToExhaustion), mf.cfg.Units.Bytes(uint64(-fc.TrendBytesPerSec*60)))
+		if fc.SwapInBps > 0 {
+			note += msg.Sprintf(", swap-in %s", mf.cfg.Units.Rate(fc.SwapInBps))
+		}
+		note += ")"
+		if flags.Explanation == "" {
+			flags.Explanation = note
+		} else {
+			flags.Explanation += "; " + note
+		}
+	}
+
+	return fc
+}
+
+// observe appends the snapshot to the host's window and returns a copy of it.
+func (mf *MemoryForecaster) observe(s *relational.RawStatsFixed) []memPoint {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	pts := mf.points[s.AgentID]
+	// A counter reset or clock step back means a reboot or restart; start over.
+	if n := len(pts); n > 0 && (s.SwapInBytes < pts[n-1].swapIn || !s.CollectedAt.After(pts[n-1].at)) {
+		pts = nil
+	}
+	pts = append(pts, memPoint{at: s.CollectedAt, available: float64(s.RAMAvailableBytes), swapIn: s.SwapInBytes})
+
+	cutoff := s.CollectedAt.Add(-mf.cfg.Window)
+	i := 0
+	for i < len(pts) && pts[i].at.Before(cutoff) {
+		i++
+	}
+	pts = pts[i:]
+	mf.points[s.AgentID] = pts
+
+	return append([]memPoint(nil), pts...)
+}
+
+// availableSlope returns the least-squares slope of available bytes over time, in bytes/sec.
+func availableSlope(pts []memPoint) float64 {
+	t0 := pts[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(pts))
+	for _, p := range pts {
+		x := p.at.Sub(t0).Seconds()
+		sumX += x
+		sumY += p.available
+		sumXY += x * p.available
+		sumXX += x * x
+	}
+	den := n*sumXX - sumX*sumX
+	if den == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / den
+}
+
+func roundForecast(msg *i18n.Printer, d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return msg.Sprintf("%d minutes", int(d.Round(time.Minute).Minutes()))
+}
@@ -0,0 +1,91 @@
+package flagger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+func forecastConfig() ForecastConfig {
+	return ForecastConfig{Enabled: true, Window: 15 * time.Minute, MinSamples: 3, Horizon: 30 * time.Minute, SwapInBps: 1 << 20}
+}
+
+func memSnapshot(at time.Time, available, swapIn uint64) *relational.RawStatsFixed {
+	return &relational.RawStatsFixed{AgentID: "a1", CollectedAt: at, RAMAvailableBytes: available, SwapInBytes: swapIn}
+}
+
+func TestMemoryForecasterPredictsExhaustion(t *testing.T) {
+	mf := NewMemoryForecaster(forecastConfig())
+	start := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	// Lose 100 MiB per minute starting from 1200 MiB: zero in ~12 minutes.
+	var fc *relational.MemoryForecast
+	var flags *relational.SnapshotFlags
+	for i := 0; i < 3; i++ {
+		flags = &relational.SnapshotFlags{}
+		fc = mf.Forecast(memSnapshot(start.Add(time.Duration(i)*time.Minute), uint64(1200-100*i)<<20, 0), flags)
+	}
+	if fc == nil || !fc.Predicted {
+		t.Fatalf("expected prediction, got %+v", fc)
+	}
+	if fc.TimeToExhaustion < 9*time.Minute || fc.TimeToExhaustion > 11*time.Minute {
+		t.Errorf("time to exhaustion = %s, want ~10m from the last sample", fc.TimeToExhaustion)
+	}
+	if !flags.FlagMemoryExhaustionPredicted || flags.SeverityLevel < 2 {
+		t.Errorf("flag not raised: %+v", flags)
+	}
+	if !strings.Contains(flags.Explanation, "hit zero in ~10 minutes") {
+		t.Errorf("explanation = %q", flags.Explanation)
+	}
+}
+
+func TestMemoryForecasterStableMemory(t *testing.T) {
+	mf := NewMemoryForecaster(forecastConfig())
+	start := time.Now()
+	var fc *relational.MemoryForecast
+	for i := 0; i < 5; i++ {
+		flags := &relational.SnapshotFlags{}
+		fc = mf.Forecast(memSnapshot(start.Add(time.Duration(i)*time.Minute), 4<<30, 0), flags)
+		if flags.FlagMemoryExhaustionPredicted {
+			t.Fatal("flag raised for flat memory")
+		}
+	}
+	if fc == nil || fc.TimeToExhaustion != 0 {
+		t.Errorf("expected no projection, got %+v", fc)
+	}
+}
+
+func TestMemoryForecasterSwapExtendsHorizon(t *testing.T) {
+	cfg := forecastConfig()
+	cfg.Horizon = 20 * time.Minute
+	start := time.Now()
+
+	// ~40 minutes to exhaustion: outside the horizon without swap, inside it with heavy swap-in.
+	run := func(swapPerMin uint64) bool {
+		mf := NewMemoryForecaster(cfg)
+		flags := &relational.SnapshotFlags{}
+		for i := 0; i < 3; i++ {
+			flags = &relational.SnapshotFlags{}
+			mf.Forecast(memSnapshot(start.Add(time.Duration(i)*time.Minute), uint64(2080-50*i)<<20, uint64(i)*swapPerMin), flags)
+		}
+		return flags.FlagMemoryExhaustionPredicted
+	}
+	if run(0) {
+		t.Error("flag raised without swap-in")
+	}
+	if !run(600 << 20) {
+		t.Error("flag not raised with heavy swap-in")
+	}
+}
+
+func TestMemoryForecasterResetsOnCounterReset(t *testing.T) {
+	mf := NewMemoryForecaster(forecastConfig())
+	start := time.Now()
+	mf.Forecast(memSnapshot(start, 2<<30, 5<<30), nil)
+	mf.Forecast(memSnapshot(start.Add(time.Minute), 1<<30, 6<<30), nil)
+	if fc := mf.Forecast(memSnapshot(start.Add(2*time.Minute), 3<<30, 0), nil); fc != nil {
+		t.Errorf("expected history reset after swap counter reset, got %+v", fc)
+	}
+}
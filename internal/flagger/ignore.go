@@ -0,0 +1,37 @@
+package flagger
+
+import (
+	"path"
+
+	"syschecker/internal/database/relational"
+)
+
+// IgnoreRule suppresses a flag, optionally only on hosts matching a glob.
+type IgnoreRule struct {
+	Flag string `json:"flag"`           // a relational.FlagNames entry
+	Host string `json:"host,omitempty"` // path.Match pattern on the hostname; empty matches all
+}
+
+// Matches reports whether the rule applies to hostname.
+func (r IgnoreRule) Matches(hostname string) bool {
+	if r.Host == "" {
+		return true
+	}
+	ok, _ := path.Match(r.Host, hostname)
+	return ok
+}
+
+// applyIgnoreRules clears ignored flags. A snapshot left without any flag
+// is reset to healthy, since its severity and explanation came from them.
+func applyIgnoreRules(rules []IgnoreRule, hostname string, f *relational.SnapshotFlags) {
+	cleared := false
+	for _, r := range rules {
+		if r.Matches(hostname) && f.ClearFlag(r.Flag) {
+			cleared = true
+		}
+	}
+	if cleared && len(f.ActiveFlags()) == 0 {
+		f.SeverityLevel, f.RiskScore = 0, 0
+		f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey, f.Explanation = "", "", "", ""
+	}
+}
@@ -0,0 +1,138 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// SeasonalStore provides hour-of-week expected ranges from history.
+type SeasonalStore interface {
+	ExpectedRange(ctx context.Context, agentID, metric string, at time.Time, lookback time.Duration) (relational.SeasonalRange, error)
+}
+
+// SeasonalDetector flags metrics that deviate from their usual level for the
+// current hour of the week ("CPU is 3x its usual Tuesday 14:00 level").
+type SeasonalDetector struct {
+	cfg   SeasonalityConfig
+	store SeasonalStore
+
+	mu    sync.Mutex
+	cache map[string]relational.SeasonalRange // agentID/metric/slot -> range
+}
+
+// NewSeasonalDetector creates a detector backed by store.
+func NewSeasonalDetector(cfg SeasonalityConfig, store SeasonalStore) *SeasonalDetector {
+	return &SeasonalDetector{
+		cfg:   cfg,
+		store: store,
+		cache: make(map[string]relational.SeasonalRange),
+	}
+}
+
+// Detect compares the snapshot against its expected ranges, annotating the
+// explanation and raising severity to informational when a metric deviates.
+func (d *SeasonalDetector) Detect(ctx context.Context, s *relational.RawStatsFixed, flags *relational.SnapshotFlags) ([]relational.SeasonalDeviation, error) {
+	if !d.cfg.Enabled {
+		return nil, nil
+	}
+
+	var deviations []relational.SeasonalDeviation
+	for _, metric := range relational.BaselineMetrics {
+		actual, ok := s.MetricValue(metric)
+		if !ok {
+			continue
+		}
+		sr, err := d.expected(ctx, s.AgentID, metric, s.CollectedAt)
+		if err != nil {
+			return nil, err
+		}
+		if sr.Samples < d.cfg.MinSamples || sr.Mean <= 0.5 {
+			continue // not enough history, or an idle baseline where ratios are meaningless
+		}
+
+		ratio := actual / sr.Mean
+		if actual <= sr.P90 || ratio < d.cfg.Factor {
+			continue
+		}
+		deviations = append(deviations, relational.SeasonalDeviation{
+			Metric:       metric,
+			Slot:         slotName(s.CollectedAt),
+			Actual:       actual,
+			ExpectedMean: sr.Mean,
+			ExpectedLow:  sr.P10,
+			ExpectedHigh: sr.P90,
+			Ratio:        ratio,
+		})
+	}
+
+	if len(deviations) > 0 && flags != nil {
+		flags.SeverityLevel = max(flags.SeverityLevel, 1)
+		dev := deviations[0]
+		msg := i18n.NewPrinter(d.cfg.Locale)
+		note := msg.Sprintf("%s is %.1fx its usual %s level (expected %.1f-%.1f, actual %.1f)",
+			msg.Text(metricLabel(dev.Metric)), dev.Ratio, dev.Slot, dev.ExpectedLow, dev.ExpectedHigh, dev.Actual)
+		if flags.Explanation == "" {
+			flags.Explanation = note
+		} else {
+			flags.Explanation += "; " + note
+		}
+	}
+
+	return deviations, nil
+}
+
+// expected returns the cached expected range for the metric's current slot.
+func (d *SeasonalDetector) expected(ctx context.Context, agentID, metric string, at time.Time) (relational.SeasonalRange, error) {
+	key := fmt.Sprintf("%s/%s/%d", agentID, metric, relational.HourOfWeek(at))
+
+	d.mu.Lock()
+	sr, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok {
+		return sr, nil
+	}
+
+	sr, err := d.store.ExpectedRange(ctx, agentID, metric, at, d.cfg.Lookback)
+	if err != nil {
+		return relational.SeasonalRange{}, err
+	}
+
+	d.mu.Lock()
+	// Keep only the current slot's entries; ranges are recomputed each hour.
+	for k, v := range d.cache {
+		if v.HourOfWeek != sr.HourOfWeek {
+			delete(d.cache, k)
+		}
+	}
+	d.cache[key] = sr
+	d.mu.Unlock()
+
+	return sr, nil
+}
+
+func slotName(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s %02d:00", t.Weekday(), t.Hour())
+}
+
+func metricLabel(metric string) string {
+	switch metric {
+	case "cpu_usage_pct":
+		return "CPU"
+	case "ram_usage_pct":
+		return "RAM"
+	case "disk_usage_pct":
+		return "Disk"
+	case "inode_usage_pct":
+		return "Inode usage"
+	case "net_latency_ms":
+		return "Latency"
+	}
+	return strings.ToUpper(metric)
+}
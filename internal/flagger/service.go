@@ -1,87 +1,410 @@
 package flagger
 
 import (
-	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
+	"syschecker/internal/collector/services"
 	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
 )
 
+// diskReadSaturationBps is the read rate that raises disk_io_saturation.
+const diskReadSaturationBps = 100 * 1024 * 1024
+
 // FlaggerService implements relational.StatsFlagger
 type FlaggerService struct {
-	cfg Config
+	mu             sync.RWMutex
+	cfg            Config
+	hostCfg        map[string]Config                              // per-host overrides keyed by agent ID
+	hostThresholds map[string]map[string]relational.HostThreshold // agent ID -> metric -> override; reapplied by SetConfig
+
+	satMu     sync.Mutex
+	saturated map[string]int // "agentID/interface" -> consecutive saturated samples
 }
 
 func NewFlaggerService(cfg Config) *FlaggerService {
-	return &FlaggerService{cfg: cfg}
+	return &FlaggerService{
+		cfg:            cfg,
+		hostCfg:        make(map[string]Config),
+		hostThresholds: make(map[string]map[string]relational.HostThreshold),
+		saturated:      make(map[string]int),
+	}
+}
+
+// SetHostThresholds overrides thresholds for a single host on top of the base config.
+func (fs *FlaggerService) SetHostThresholds(agentID string, thresholds []relational.HostThreshold) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	byMetric := fs.hostThresholds[agentID]
+	if byMetric == nil {
+		byMetric = make(map[string]relational.HostThreshold)
+		fs.hostThresholds[agentID] = byMetric
+	}
+	for _, t := range thresholds {
+		byMetric[t.Metric] = t
+	}
+	fs.hostCfg[agentID] = withHostThresholds(fs.cfg, byMetric)
+}
+
+// Config returns the base config.
+func (fs *FlaggerService) Config() Config {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.cfg
+}
+
+// SetConfig replaces the base config at runtime, keeping per-host
+// threshold overrides on top of it. Snapshots flagged afterwards use it.
+func (fs *FlaggerService) SetConfig(cfg Config) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.cfg = cfg
+	for agentID, thresholds := range fs.hostThresholds {
+		fs.hostCfg[agentID] = withHostThresholds(cfg, thresholds)
+	}
+}
+
+func withHostThresholds(cfg Config, thresholds map[string]relational.HostThreshold) Config {
+	for _, t := range thresholds {
+		if th := cfg.thresholdsFor(t.Metric); th != nil {
+			th.Warning = t.Warning
+			th.Critical = t.Critical
+		}
+	}
+	return cfg
+}
+
+// configFor returns the effective config for a host.
+func (fs *FlaggerService) configFor(agentID string) Config {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if cfg, ok := fs.hostCfg[agentID]; ok {
+		return cfg
+	}
+	return fs.cfg
 }
 
 func (fs *FlaggerService) Flag(s *relational.RawStatsFixed, d *relational.DerivedRates) *relational.SnapshotFlags {
 	f := &relational.SnapshotFlags{}
 	var explanations []string
+	cfg := fs.configFor(s.AgentID)
+	msg := i18n.NewPrinter(cfg.Locale)
+
+	// Inside a limited cgroup, CPU and RAM percentages are of the limit.
+	cpuScope, ramScope := "", ""
+	if s.CgroupCPULimit > 0 {
+		cpuScope = msg.Sprintf(" of %.1f-core cgroup limit", s.CgroupCPULimit)
+	}
+	if s.CgroupMemLimitBytes > 0 {
+		ramScope = msg.Sprintf(" of cgroup limit %s", cfg.Units.Bytes(s.CgroupMemLimitBytes))
+	}
 
 	// 1. CPU
-	if s.CPUUsagePct > fs.cfg.CPU.Critical {
+	if s.CPUUsagePct > cfg.CPU.Critical {
 		f.FlagCPUOverloaded = true
 		f.SeverityLevel = 3
-		explanations = append(explanations, fmt.Sprintf("CPU critical: %.1f%%", s.CPUUsagePct))
-	} else if s.CPUUsagePct > fs.cfg.CPU.Warning {
+		explanations = append(explanations, msg.Sprintf("CPU critical: %.1f%%%s", s.CPUUsagePct, cpuScope))
+	} else if s.CPUUsagePct > cfg.CPU.Warning {
 		f.SeverityLevel = max(f.SeverityLevel, 2)
-		explanations = append(explanations, fmt.Sprintf("CPU warning: %.1f%%", s.CPUUsagePct))
+		explanations = append(explanations, msg.Sprintf("CPU warning: %.1f%%%s", s.CPUUsagePct, cpuScope))
 	}
 
 	// 2. RAM
-	if s.RAMUsagePct > fs.cfg.RAM.Critical {
+	if s.RAMUsagePct > cfg.RAM.Critical {
 		f.FlagMemoryPressure = true
 		f.SeverityLevel = 3
-		explanations = append(explanations, fmt.Sprintf("RAM critical: %.1f%%", s.RAMUsagePct))
-	} else if s.RAMUsagePct > fs.cfg.RAM.Warning {
+		explanations = append(explanations, msg.Sprintf("RAM critical: %.1f%%%s", s.RAMUsagePct, ramScope))
+	} else if s.RAMUsagePct > cfg.RAM.Warning {
 		f.SeverityLevel = max(f.SeverityLevel, 2)
-		explanations = append(explanations, fmt.Sprintf("RAM warning: %.1f%%", s.RAMUsagePct))
+		explanations = append(explanations, msg.Sprintf("RAM warning: %.1f%%%s", s.RAMUsagePct, ramScope))
 	}
 
-	// 3. Disk
-	if s.DiskUsagePct > fs.cfg.Disk.Critical {
+	// 3. Disk, on the fullest mount rather than just the root filesystem
+	diskMount, diskPct := fullestMount(s, s.DiskUsagePct, func(p relational.PartitionUsageFixed) float64 { return p.UsedPercent })
+	if diskPct > cfg.Disk.Critical {
 		f.FlagDiskSpaceCritical = true
 		f.SeverityLevel = 3
-		explanations = append(explanations, fmt.Sprintf("Disk critical: %.1f%%", s.DiskUsagePct))
-	} else if s.DiskUsagePct > fs.cfg.Disk.Warning {
+		note := msg.Sprintf("Disk critical: %.1f%% on %s", diskPct, diskMount)
+		// Point at the runaway log, if one is growing.
+		if len(s.LogGrowers) > 0 && s.LogGrowers[0].GrowthBps > 0 {
+			g := s.LogGrowers[0]
+			f.PrimaryCause = "disk"
+			f.CauseEntityType = "file"
+			f.CauseEntityKey = g.Path
+			note += msg.Sprintf(", fastest growing file %s (+%s/min)", g.Path, cfg.Units.Bytes(uint64(g.GrowthBps*60)))
+		}
+		explanations = append(explanations, note)
+	} else if diskPct > cfg.Disk.Warning {
 		f.SeverityLevel = max(f.SeverityLevel, 2)
-		explanations = append(explanations, fmt.Sprintf("Disk warning: %.1f%%", s.DiskUsagePct))
+		explanations = append(explanations, msg.Sprintf("Disk warning: %.1f%% on %s", diskPct, diskMount))
 	}
 
 	// 4. Inodes
-	if s.InodeUsagePct > fs.cfg.Inode.Critical {
+	inodeMount, inodePct := fullestMount(s, s.InodeUsagePct, func(p relational.PartitionUsageFixed) float64 { return p.InodeUsage })
+	if inodePct > cfg.Inode.Critical {
 		f.FlagInodeExhaustion = true
 		f.SeverityLevel = 3
-		explanations = append(explanations, fmt.Sprintf("Inode critical: %.1f%%", s.InodeUsagePct))
+		explanations = append(explanations, msg.Sprintf("Inode critical: %.1f%% on %s", inodePct, inodeMount))
 	}
 
-	// 5. Network Latency
-	if s.NetLatencyMS > fs.cfg.Net.Critical {
+	// 5. Network Latency (not flagged behind a host NAT such as WSL2's,
+	// whose overhead the guest cannot act on)
+	if s.NetLatencyMS > cfg.Net.Critical && !s.LatencyNAT {
 		f.FlagNetworkLatencyDegraded = true
 		f.SeverityLevel = max(f.SeverityLevel, 2)
-		explanations = append(explanations, fmt.Sprintf("High latency: %.1fms", s.NetLatencyMS))
+		switch {
+		case s.VPNInterface == "" || s.NetDirectMS == 0:
+			explanations = append(explanations, msg.Sprintf("High latency: %.1fms", s.NetLatencyMS))
+		case s.NetDirectMS > cfg.Net.Critical:
+			explanations = append(explanations, msg.Sprintf("High latency: %.1fms via VPN %s, %.1fms direct (uplink is slow)", s.NetLatencyMS, s.VPNInterface, s.NetDirectMS))
+		default:
+			// The uplink is fine, so the tunnel is to blame.
+			if f.CauseEntityType == "" {
+				f.PrimaryCause = "network"
+				f.CauseEntityType = "netif"
+				f.CauseEntityKey = s.VPNInterface
+			}
+			explanations = append(explanations, msg.Sprintf("High latency: %.1fms via VPN %s, %.1fms direct (VPN is slow)", s.NetLatencyMS, s.VPNInterface, s.NetDirectMS))
+		}
+	}
+
+	// 5b. Packet loss over the background prober's window
+	if s.NetProbes > 0 && s.NetLossPct > cfg.Loss.Warning {
+		note := msg.Sprintf("Packet loss: %.0f%% of %d probes, jitter %.1fms", s.NetLossPct, s.NetProbes, s.NetJitterMS)
+		if s.NetLossPct > cfg.Loss.Critical {
+			f.FlagNetworkPacketLoss = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.PrimaryCause == "" {
+				f.PrimaryCause = "network"
+			}
+		} else {
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+		}
+		explanations = append(explanations, note)
 	}
 
+	// 5c. Physical links that negotiated below their capability or flapped
+	for _, ni := range s.NetInterfaces {
+		if ni.SpeedMbps == 0 && ni.MaxSpeedMbps == 0 {
+			continue // virtual interfaces report no speed
+		}
+		var note string
+		switch {
+		case d.NetCarrierChanges[ni.Name] >= 2:
+			note = msg.Sprintf("Link %s flapped (%d carrier changes)", ni.Name, d.NetCarrierChanges[ni.Name])
+		case ni.OperState == "up" && ni.SpeedMbps > 0 && ni.MaxSpeedMbps > ni.SpeedMbps:
+			note = msg.Sprintf("Link %s negotiated %s, capable of %s", ni.Name, linkSpeed(ni.SpeedMbps), linkSpeed(ni.MaxSpeedMbps))
+		case ni.OperState == "up" && ni.Duplex == "half":
+			note = msg.Sprintf("Link %s is running half duplex at %s", ni.Name, linkSpeed(ni.SpeedMbps))
+		default:
+			continue
+		}
+		f.FlagLinkDegraded = true
+		f.SeverityLevel = max(f.SeverityLevel, 2)
+		if f.CauseEntityType == "" {
+			f.PrimaryCause = "network"
+			f.CauseEntityType = "netif"
+			f.CauseEntityKey = ni.Name
+		}
+		explanations = append(explanations, note)
+	}
+
+	// 5d. Links running near their negotiated speed for several samples
+	explanations = append(explanations, fs.linkSaturation(msg, s, d, cfg.Saturation, f)...)
+
 	// 6. Derived Rates Checks (e.g. Disk IO Saturation)
 	// Simple heuristic: if read/write bps is very high (arbitrary threshold for now, or from config)
 	// For now, just checking if we have rates
-	if d.DiskReadBps > 100*1024*1024 { // 100MB/s example
+	if d.DiskReadBps > diskReadSaturationBps {
 		f.FlagDiskIOSaturation = true
-		explanations = append(explanations, "High Disk Read IO")
+		explanations = append(explanations, msg.Text("High Disk Read IO"))
 	}
 
-	// 7. Docker
-	if !s.DockerAvailable {
+	// 7. Docker (an agent in a container usually has no socket mounted)
+	if !s.DockerAvailable && !s.Containerized {
 		f.FlagDockerUnavailable = true
 		// Not necessarily critical unless expected
 	}
 
+	// 8. A single user dominating RAM or CPU (shared build servers, multi-tenant boxes)
+	cores := float64(max(s.CPUCoresLogical, 1))
+	for _, u := range s.UserUsage {
+		metric, share := "RAM", u.MemPct
+		if cpuShare := u.CPUPct / cores; cpuShare > share {
+			metric, share = "CPU", cpuShare
+		}
+		if share <= cfg.UserShare.Warning {
+			continue
+		}
+		note := msg.Sprintf("User %s is consuming %.0f%% of %s (%d processes)", u.User, share, metric, u.Processes)
+		if share > cfg.UserShare.Critical {
+			f.FlagUserResourceHog = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.CauseEntityType == "" {
+				f.PrimaryCause = strings.ToLower(metric)
+				f.CauseEntityType = "user"
+				f.CauseEntityKey = u.User
+			}
+		} else {
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+		}
+		explanations = append(explanations, note)
+	}
+
+	// 9. Stale temp data and core dumps, frequently the real cause of "disk full"
+	for _, u := range s.TempUsage {
+		var note string
+		switch {
+		case u.Kind == "core" && cfg.TempData.CoreDumpBytes > 0 && u.TotalBytes > cfg.TempData.CoreDumpBytes:
+			note = msg.Sprintf("Core dumps in %s: %s", u.Path, cfg.Units.Bytes(u.TotalBytes))
+		case u.Kind == "temp" && cfg.TempData.StaleTempBytes > 0 && u.StaleBytes > cfg.TempData.StaleTempBytes:
+			note = msg.Sprintf("Stale temp data in %s: %s", u.Path, cfg.Units.Bytes(u.StaleBytes))
+		default:
+			continue
+		}
+		f.SeverityLevel = max(f.SeverityLevel, 2)
+		explanations = append(explanations, note)
+		if f.FlagDiskSpaceCritical && f.CauseEntityType == "" {
+			f.PrimaryCause = "disk"
+			f.CauseEntityType = "directory"
+			f.CauseEntityKey = u.Path
+		}
+	}
+
+	// 10. OOM kills: the victim is the most direct answer to "why did my app die"
+	if len(s.OOMKills) > 0 {
+		victim := s.OOMKills[len(s.OOMKills)-1]
+		f.FlagMemoryStarvation = true
+		f.SeverityLevel = 3
+		f.PrimaryCause = "memory"
+		f.CauseEntityType = "process"
+		f.CauseEntityKey = victim.Process
+		note := msg.Sprintf("OOM killer terminated %s (pid %d", victim.Process, victim.PID)
+		if victim.Cgroup != "" {
+			note += msg.Sprintf(", cgroup %s", victim.Cgroup)
+		}
+		note += ")"
+		if n := len(s.OOMKills); n > 1 {
+			note += msg.Sprintf(" and %d other process(es)", n-1)
+		}
+		explanations = append([]string{note}, explanations...)
+	}
+
+	// 11. SoC thermal pressure (macOS reports a level rather than temperatures)
+	switch s.ThermalPressure {
+	case "Heavy", "Trapping", "Sleeping":
+		f.FlagThermalPressure = true
+		f.SeverityLevel = max(f.SeverityLevel, 2)
+		if f.PrimaryCause == "" {
+			f.PrimaryCause = "thermal"
+		}
+		explanations = append(explanations, msg.Sprintf("Thermal pressure %s, CPU is being throttled (%.1f W package power)", s.ThermalPressure, s.PowerTotalWatts))
+	case "Moderate":
+		f.SeverityLevel = max(f.SeverityLevel, 1)
+		explanations = append(explanations, msg.Text("Thermal pressure Moderate"))
+	}
+
+	// 12. Raspberry Pi firmware: under-voltage corrupts SD cards, so it outranks load
+	if bits := s.ThrottledBits; bits != 0 {
+		now, past := bits&0xffff, bits>>services.ThrottleOccurredShift
+		switch {
+		case now&services.ThrottleUnderVoltage != 0:
+			f.FlagUnderVoltage = true
+			f.SeverityLevel = max(f.SeverityLevel, 3)
+			f.PrimaryCause = "power"
+			explanations = append([]string{msg.Text("Under-voltage detected, check the power supply")}, explanations...)
+		case past&services.ThrottleUnderVoltage != 0:
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+			explanations = append(explanations, msg.Text("Under-voltage occurred since boot"))
+		}
+		if now&(services.ThrottleThrottled|services.ThrottleSoftTempLimit) != 0 {
+			f.FlagThermalPressure = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.PrimaryCause == "" {
+				f.PrimaryCause = "thermal"
+			}
+			explanations = append(explanations, msg.Text("CPU throttled by firmware"))
+		} else if now&services.ThrottleFreqCapped != 0 {
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+			explanations = append(explanations, msg.Text("CPU frequency capped by firmware"))
+		}
+	}
+
+	// 13. A container hogging CPU, named by its busiest process
+	if note, ok := containerCPUHog(msg, s, cfg.Container, cores, f); ok {
+		explanations = append(explanations, note)
+	}
+
+	// 14. User-defined checks that did not exit as expected
+	for _, c := range s.Checks {
+		if c.OK {
+			continue
+		}
+		f.FlagCheckFailed = true
+		f.SeverityLevel = max(f.SeverityLevel, max(c.Severity, 1))
+		if f.CauseEntityType == "" {
+			f.PrimaryCause = "check"
+			f.CauseEntityType = "check"
+			f.CauseEntityKey = c.Name
+		}
+		note := msg.Sprintf("Check %s failed (exit %d)", c.Name, c.ExitCode)
+		if line, _, _ := strings.Cut(c.Output, "\n"); line != "" {
+			note += ": " + line
+		}
+		explanations = append(explanations, note)
+	}
+
+	// 15. GPUs, each against the same thresholds
+	for _, g := range s.GPUs {
+		switch {
+		case g.UtilPct > cfg.GPU.Critical:
+			f.FlagGPUOverloaded = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.CauseEntityType == "" {
+				f.PrimaryCause = "gpu"
+				f.CauseEntityType = "gpu"
+				f.CauseEntityKey = gpuKey(g)
+			}
+			explanations = append(explanations, msg.Sprintf("GPU %d (%s) critical: %.1f%% busy", g.Index, g.Name, g.UtilPct))
+		case g.UtilPct > cfg.GPU.Warning:
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+			explanations = append(explanations, msg.Sprintf("GPU %d (%s) warning: %.1f%% busy", g.Index, g.Name, g.UtilPct))
+		}
+		switch pct := g.MemPct(); {
+		case pct > cfg.GPUMem.Critical:
+			f.FlagGPUMemoryPressure = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.CauseEntityType == "" {
+				f.PrimaryCause = "gpu"
+				f.CauseEntityType = "gpu"
+				f.CauseEntityKey = gpuKey(g)
+			}
+			explanations = append(explanations, msg.Sprintf("GPU %d (%s) memory critical: %.1f%% of %s", g.Index, g.Name, pct, cfg.Units.Bytes(g.MemTotalBytes)))
+		case pct > cfg.GPUMem.Warning:
+			f.SeverityLevel = max(f.SeverityLevel, 1)
+			explanations = append(explanations, msg.Sprintf("GPU %d (%s) memory warning: %.1f%% of %s", g.Index, g.Name, pct, cfg.Units.Bytes(g.MemTotalBytes)))
+		}
+	}
+
+	// Without a more specific culprit, blame the full mount itself.
+	if f.CauseEntityType == "" {
+		switch {
+		case f.FlagDiskSpaceCritical:
+			f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey = "disk", "mount", diskMount
+		case f.FlagInodeExhaustion:
+			f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey = "inodes", "mount", inodeMount
+		}
+	}
+
 	// Aggregate
 	if len(explanations) > 0 {
 		f.Explanation = explanations[0] // Just take the first one for primary explanation
 		if len(explanations) > 1 {
-			f.Explanation += fmt.Sprintf(" (+%d more)", len(explanations)-1)
+			f.Explanation += msg.Sprintf(" (+%d more)", len(explanations)-1)
 		}
 	}
 
@@ -90,10 +413,150 @@ func (fs *FlaggerService) Flag(s *relational.RawStatsFixed, d *relational.Derive
 	if f.FlagHostOffline {
 		f.RiskScore = 100
 	}
+	applyIgnoreRules(cfg.Ignore, s.Hostname, f)
+	f.Bitmask = f.Mask()
 
 	return f
 }
 
+// linkSaturation tracks consecutive saturated samples per interface and
+// flags those that reached cfg.Samples.
+func (fs *FlaggerService) linkSaturation(msg *i18n.Printer, s *relational.RawStatsFixed, d *relational.DerivedRates, cfg SaturationConfig, f *relational.SnapshotFlags) []string {
+	if cfg.Percent <= 0 || d.NetUtilization == nil {
+		return nil
+	}
+	fs.satMu.Lock()
+	defer fs.satMu.Unlock()
+
+	var notes []string
+	for _, ni := range s.NetInterfaces {
+		key := s.AgentID + "/" + ni.Name
+		util, ok := d.NetUtilization[ni.Name]
+		if !ok || util <= cfg.Percent {
+			delete(fs.saturated, key)
+			continue
+		}
+		fs.saturated[key]++
+		if n := fs.saturated[key]; n >= max(cfg.Samples, 1) {
+			f.FlagLinkSaturated = true
+			f.SeverityLevel = max(f.SeverityLevel, 2)
+			if f.CauseEntityType == "" {
+				f.PrimaryCause = "network"
+				f.CauseEntityType = "netif"
+				f.CauseEntityKey = ni.Name
+			}
+			notes = append(notes, msg.Sprintf("Link %s saturated: %.0f%% of %s for %d samples", ni.Name, util, linkSpeed(ni.SpeedMbps), n))
+		}
+	}
+	return notes
+}
+
+// linkSpeed renders a link speed the way NICs are marketed (100Mb, 1Gb, 2.5Gb).
+func linkSpeed(mbps int) string {
+	if mbps >= 1000 {
+		return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + "Gb"
+	}
+	return strconv.Itoa(mbps) + "Mb"
+}
+
+// gpuKey names a GPU as a cause entity. Identical cards share a model
+// name, so it is keyed by index.
+func gpuKey(g relational.GPUStatFixed) string {
+	return "gpu" + strconv.Itoa(g.Index)
+}
+
+// readOnlyFstypes are image mounts (snaps, ISOs) that always report full.
+var readOnlyFstypes = map[string]bool{"squashfs": true, "iso9660": true, "udf": true, "erofs": true, "cramfs": true}
+
+// fullestMount returns the partition with the highest usage, starting from
+// the root filesystem's figure so hosts without partition data keep working.
+func fullestMount(s *relational.RawStatsFixed, rootPct float64, usage func(relational.PartitionUsageFixed) float64) (string, float64) {
+	mount, pct := "/", rootPct
+	for _, p := range s.Partitions {
+		if readOnlyFstypes[p.Fstype] || p.TotalBytes == 0 {
+			continue
+		}
+		if u := usage(p); u > pct {
+			mount, pct = p.Mountpoint, u
+		}
+	}
+	return mount, pct
+}
+
+// containerCPUHog sums top-process CPU per container and reports the
+// heaviest container above the warning threshold, naming the process inside
+// it that uses the most CPU.
+func containerCPUHog(msg *i18n.Printer, s *relational.RawStatsFixed, th Thresholds, cores float64, f *relational.SnapshotFlags) (string, bool) {
+	u, ok := busiestContainer(s)
+	if !ok {
+		return "", false
+	}
+	worst := u.id
+	share := u.cpu / cores
+	if share <= th.Warning {
+		return "", false
+	}
+
+	note := msg.Sprintf("Container %s is using %.0f%% of CPU", u.name, share)
+	if u.top.Name != "" {
+		note += msg.Sprintf(", mostly %s (pid %d, %.0f%%)", u.top.Name, u.top.PID, u.top.CPUPct/cores)
+	}
+
+	if share > th.Critical {
+		f.FlagContainerCPUHog = true
+		f.SeverityLevel = max(f.SeverityLevel, 2)
+		if f.CauseEntityType == "" {
+			f.PrimaryCause = "cpu"
+			f.CauseEntityType = "container"
+			f.CauseEntityKey = worst
+		}
+	} else {
+		f.SeverityLevel = max(f.SeverityLevel, 1)
+	}
+	return note, true
+}
+
+// containerUsage is the top-process CPU of one container.
+type containerUsage struct {
+	id, name string
+	cpu      float64 // sum of its top processes' CPU percent (100 = one core)
+	top      relational.ProcessStatFixed
+}
+
+// busiestContainer returns the container whose top processes use the most
+// CPU, named as docker names it when known.
+func busiestContainer(s *relational.RawStatsFixed) (containerUsage, bool) {
+	byContainer := make(map[string]*containerUsage)
+	var worst *containerUsage
+	for _, p := range s.TopProcesses {
+		if p.ContainerID == "" {
+			continue
+		}
+		u := byContainer[p.ContainerID]
+		if u == nil {
+			u = &containerUsage{id: p.ContainerID, name: p.ContainerID}
+			byContainer[p.ContainerID] = u
+		}
+		u.cpu += p.CPUPct
+		if p.CPUPct > u.top.CPUPct {
+			u.top = p
+		}
+		if worst == nil || u.cpu > worst.cpu {
+			worst = u
+		}
+	}
+	if worst == nil {
+		return containerUsage{}, false
+	}
+	for _, c := range s.DockerContainers {
+		if c.ID == worst.id && c.Name != "" {
+			worst.name = c.Name
+			break
+		}
+	}
+	return *worst, true
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,424 @@
+package flagger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+func TestFlagAttributesOOMKillToVictim(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		AgentID:         "a1",
+		DockerAvailable: true,
+		CPUUsagePct:     95,
+		OOMKills: []relational.OOMKillFixed{{
+			At:      time.Date(2025, 3, 4, 3, 0, 0, 0, time.UTC),
+			PID:     4242,
+			Process: "myapp",
+			Cgroup:  "/system.slice/myapp.service",
+		}},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagMemoryStarvation || f.SeverityLevel != 3 {
+		t.Errorf("expected memory starvation at severity 3, got %+v", f)
+	}
+	if f.PrimaryCause != "memory" || f.CauseEntityType != "process" || f.CauseEntityKey != "myapp" {
+		t.Errorf("cause = %q %q %q", f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey)
+	}
+	if !strings.HasPrefix(f.Explanation, "OOM killer terminated myapp (pid 4242, cgroup /system.slice/myapp.service)") {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+}
+
+func TestFlagDiskCriticalPointsAtFastestLog(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		DiskUsagePct:    97,
+		LogGrowers: []relational.FileGrowthFixed{
+			{Path: "/var/log/app/debug.log", SizeBytes: 40 << 30, GrowthBps: 2 << 20},
+			{Path: "/var/log/syslog", SizeBytes: 1 << 20, GrowthBps: 100},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagDiskSpaceCritical {
+		t.Fatal("expected disk space critical")
+	}
+	if f.CauseEntityType != "file" || f.CauseEntityKey != "/var/log/app/debug.log" {
+		t.Errorf("cause = %q %q", f.CauseEntityType, f.CauseEntityKey)
+	}
+	if !strings.Contains(f.Explanation, "fastest growing file /var/log/app/debug.log (+120.0 MiB/min)") {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+}
+
+func TestFlagWarnsOnStaleTempAndCoreDumps(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		DiskUsagePct:    95,
+		TempUsage: []relational.TempUsageFixed{
+			{Path: "/tmp", Kind: "temp", TotalBytes: 7 << 30, StaleBytes: 6 << 30},
+			{Path: "/var/tmp", Kind: "temp", TotalBytes: 8 << 30, StaleBytes: 1 << 30},
+			{Path: "/var/lib/systemd/coredump", Kind: "core", TotalBytes: 3 << 30},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if f.CauseEntityType != "directory" || f.CauseEntityKey != "/tmp" {
+		t.Errorf("cause = %q %q, want directory /tmp", f.CauseEntityType, f.CauseEntityKey)
+	}
+	if !strings.Contains(f.Explanation, "(+2 more)") {
+		t.Errorf("expected disk, temp and core notes, got %q", f.Explanation)
+	}
+
+	s.DiskUsagePct = 10
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.SeverityLevel != 2 || !strings.HasPrefix(f.Explanation, "Stale temp data in /tmp: 6.0 GiB") {
+		t.Errorf("severity %d, explanation %q", f.SeverityLevel, f.Explanation)
+	}
+	if f.CauseEntityType != "" {
+		t.Errorf("cause set without disk pressure: %q", f.CauseEntityType)
+	}
+}
+
+func TestFlagUserResourceHog(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		CPUCoresLogical: 4,
+		RAMUsagePct:     70,
+		UserUsage: []relational.UserUsageFixed{
+			{UID: 1001, User: "alice", Processes: 12, CPUPct: 40, MemPct: 84},
+			{UID: 1002, User: "bob", Processes: 3, CPUPct: 240, MemPct: 5},
+			{UID: 0, User: "root", Processes: 90, CPUPct: 20, MemPct: 3},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagUserResourceHog {
+		t.Fatal("expected FlagUserResourceHog")
+	}
+	if f.CauseEntityType != "user" || f.CauseEntityKey != "alice" || f.PrimaryCause != "ram" {
+		t.Errorf("cause = %q %q %q, want ram user alice", f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey)
+	}
+	// bob uses 60% of a 4-core box: a warning note, not the cause.
+	if f.Explanation != "User alice is consuming 84% of RAM (12 processes) (+1 more)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+	if f.SeverityLevel != 2 {
+		t.Errorf("severity = %d, want 2", f.SeverityLevel)
+	}
+}
+
+func TestFlagSuppressedByEnvironment(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{NetLatencyMS: 900}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagNetworkLatencyDegraded || !f.FlagDockerUnavailable {
+		t.Fatalf("bare metal: latency=%v docker=%v, want both flagged", f.FlagNetworkLatencyDegraded, f.FlagDockerUnavailable)
+	}
+
+	s.Environment, s.LatencyNAT, s.Containerized = "wsl", true, true
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.FlagNetworkLatencyDegraded || f.FlagDockerUnavailable || f.SeverityLevel != 0 {
+		t.Errorf("wsl container: latency=%v docker=%v severity=%d, want nothing flagged", f.FlagNetworkLatencyDegraded, f.FlagDockerUnavailable, f.SeverityLevel)
+	}
+}
+
+func TestFlagLatencyBlamesVPN(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{DockerAvailable: true, NetLatencyMS: 800, VPNInterface: "wg0", NetDirectMS: 20}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagNetworkLatencyDegraded || f.CauseEntityType != "netif" || f.CauseEntityKey != "wg0" {
+		t.Fatalf("flags = %+v", f)
+	}
+	if f.Explanation != "High latency: 800.0ms via VPN wg0, 20.0ms direct (VPN is slow)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	s.NetDirectMS = 700
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.CauseEntityType != "" || f.Explanation != "High latency: 800.0ms via VPN wg0, 700.0ms direct (uplink is slow)" {
+		t.Errorf("cause = %q, explanation = %q", f.CauseEntityType, f.Explanation)
+	}
+}
+
+func TestFlagFailedCheck(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{DockerAvailable: true, Checks: []relational.CheckResultFixed{
+		{Name: "disk-backup", OK: true, Severity: 3},
+		{Name: "nginx", ExitCode: 7, Output: "connection refused\nretrying", Severity: 3},
+	}}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagCheckFailed || f.SeverityLevel != 3 || f.CauseEntityType != "check" || f.CauseEntityKey != "nginx" {
+		t.Fatalf("flags = %+v", f)
+	}
+	if f.Explanation != "Check nginx failed (exit 7): connection refused" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+}
+
+func TestFlagThermalPressure(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{DockerAvailable: true, ThermalPressure: "Heavy", PowerTotalWatts: 31.5}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagThermalPressure || f.PrimaryCause != "thermal" || f.SeverityLevel != 2 {
+		t.Errorf("flag=%v cause=%q severity=%d", f.FlagThermalPressure, f.PrimaryCause, f.SeverityLevel)
+	}
+	if f.Explanation != "Thermal pressure Heavy, CPU is being throttled (31.5 W package power)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	s.ThermalPressure = "Nominal"
+	if f = fs.Flag(s, &relational.DerivedRates{}); f.FlagThermalPressure || f.SeverityLevel != 0 {
+		t.Errorf("nominal pressure flagged: %+v", f)
+	}
+}
+
+func TestFlagRaspberryPiUnderVoltage(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{DockerAvailable: true, CPUUsagePct: 95, ThrottledBits: 0x50005}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagUnderVoltage || !f.FlagThermalPressure || f.PrimaryCause != "power" {
+		t.Errorf("under_voltage=%v thermal=%v cause=%q", f.FlagUnderVoltage, f.FlagThermalPressure, f.PrimaryCause)
+	}
+	if !strings.HasPrefix(f.Explanation, "Under-voltage detected") {
+		t.Errorf("explanation = %q, want under-voltage first", f.Explanation)
+	}
+
+	// Only the sticky "occurred" bit: a warning, not a flag.
+	s.CPUUsagePct, s.ThrottledBits = 10, 0x10000
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.FlagUnderVoltage || f.SeverityLevel != 1 || f.Explanation != "Under-voltage occurred since boot" {
+		t.Errorf("flag=%v severity=%d explanation=%q", f.FlagUnderVoltage, f.SeverityLevel, f.Explanation)
+	}
+}
+
+func TestFlagGPU(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{DockerAvailable: true, GPUs: []relational.GPUStatFixed{
+		{Index: 0, Vendor: "nvidia", Name: "Tesla T4", UtilPct: 20, MemUsedBytes: 1 << 30, MemTotalBytes: 16 << 30},
+		{Index: 1, Vendor: "nvidia", Name: "Tesla T4", UtilPct: 99, MemUsedBytes: 15800 << 20, MemTotalBytes: 16 << 30},
+	}}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagGPUOverloaded || !f.FlagGPUMemoryPressure || f.SeverityLevel != 2 {
+		t.Errorf("overloaded=%v memory=%v severity=%d", f.FlagGPUOverloaded, f.FlagGPUMemoryPressure, f.SeverityLevel)
+	}
+	if f.CauseEntityType != "gpu" || f.CauseEntityKey != "gpu1" {
+		t.Errorf("cause = %s/%s, want gpu/gpu1", f.CauseEntityType, f.CauseEntityKey)
+	}
+	if !strings.HasPrefix(f.Explanation, "GPU 1 (Tesla T4) critical: 99.0% busy") {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// Unified memory reports no total and never raises memory pressure.
+	s.GPUs = []relational.GPUStatFixed{{Vendor: "apple", Name: "Apple M2", UtilPct: 93, MemUsedBytes: 8 << 30}}
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.FlagGPUOverloaded || f.FlagGPUMemoryPressure || f.SeverityLevel != 1 {
+		t.Errorf("overloaded=%v memory=%v severity=%d, want a warning only", f.FlagGPUOverloaded, f.FlagGPUMemoryPressure, f.SeverityLevel)
+	}
+}
+
+func TestFlagContainerCPUHogNamesProcess(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		CPUCoresLogical: 4,
+		DockerContainers: []relational.DockerContainerInfoFixed{
+			{ID: "abc123", Name: "billing", Running: true},
+			{ID: "def456", Name: "cache", Running: true},
+		},
+		TopProcesses: []relational.ProcessStatFixed{
+			{Rank: 1, PID: 100, Name: "java", CPUPct: 300, ContainerID: "abc123"},
+			{Rank: 2, PID: 101, Name: "sh", CPUPct: 40, ContainerID: "abc123"},
+			{Rank: 3, PID: 200, Name: "redis-server", CPUPct: 20, ContainerID: "def456"},
+			{Rank: 4, PID: 300, Name: "sshd", CPUPct: 5},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagContainerCPUHog {
+		t.Fatal("expected FlagContainerCPUHog")
+	}
+	if f.CauseEntityType != "container" || f.CauseEntityKey != "abc123" || f.PrimaryCause != "cpu" {
+		t.Errorf("cause = %q %q %q, want cpu container abc123", f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey)
+	}
+	if f.Explanation != "Container billing is using 85% of CPU, mostly java (pid 100, 75%)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// Spread across containers, nothing is flagged.
+	s.TopProcesses[0].CPUPct = 100
+	if f := fs.Flag(s, &relational.DerivedRates{}); f.FlagContainerCPUHog {
+		t.Error("did not expect FlagContainerCPUHog below the critical share")
+	}
+}
+
+func TestFlagAttributesFullMount(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		DiskUsagePct:    40,
+		InodeUsagePct:   10,
+		Partitions: []relational.PartitionUsageFixed{
+			{Mountpoint: "/", Fstype: "ext4", UsedPercent: 40, TotalBytes: 100 << 30, InodeUsage: 10},
+			{Mountpoint: "/var/lib/docker", Fstype: "xfs", UsedPercent: 96, TotalBytes: 500 << 30, InodeUsage: 30},
+			{Mountpoint: "/srv/mail", Fstype: "ext4", UsedPercent: 50, TotalBytes: 50 << 30, InodeUsage: 99},
+			{Mountpoint: "/snap/core/1", Fstype: "squashfs", UsedPercent: 100, TotalBytes: 64 << 20, InodeUsage: 100},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagDiskSpaceCritical || !f.FlagInodeExhaustion {
+		t.Fatalf("expected disk and inode flags, got %+v", f)
+	}
+	if f.CauseEntityType != "mount" || f.CauseEntityKey != "/var/lib/docker" || f.PrimaryCause != "disk" {
+		t.Errorf("cause = %q %q %q, want disk mount /var/lib/docker", f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey)
+	}
+	if f.Explanation != "Disk critical: 96.0% on /var/lib/docker (+1 more)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// Inodes alone point at their own mount.
+	s.Partitions[1].UsedPercent = 60
+	f = fs.Flag(s, &relational.DerivedRates{})
+	if f.FlagDiskSpaceCritical || f.CauseEntityKey != "/srv/mail" || f.PrimaryCause != "inodes" {
+		t.Errorf("cause = %q %q %q, want inodes mount /srv/mail", f.PrimaryCause, f.CauseEntityType, f.CauseEntityKey)
+	}
+}
+
+func TestFlagPacketLossFromProber(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		IsConnected:     true,
+		NetLatencyMS:    35,
+		NetJitterMS:     12.5,
+		NetLossPct:      15,
+		NetProbes:       60,
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagNetworkPacketLoss || f.PrimaryCause != "network" || f.SeverityLevel != 2 {
+		t.Fatalf("flags = %+v", f)
+	}
+	if f.Explanation != "Packet loss: 15% of 60 probes, jitter 12.5ms" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// Without the prober there is nothing to judge.
+	s.NetProbes = 0
+	if f := fs.Flag(s, &relational.DerivedRates{}); f.FlagNetworkPacketLoss {
+		t.Error("did not expect FlagNetworkPacketLoss without probes")
+	}
+}
+
+func TestFlagLinkDegraded(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		DockerAvailable: true,
+		NetInterfaces: []relational.NetInterfaceStatsFixed{
+			{Name: "lo", OperState: "unknown"},
+			{Name: "eno1", OperState: "up", SpeedMbps: 100, MaxSpeedMbps: 1000, Duplex: "full"},
+		},
+	}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if !f.FlagLinkDegraded || f.CauseEntityType != "netif" || f.CauseEntityKey != "eno1" {
+		t.Fatalf("flags = %+v", f)
+	}
+	if f.Explanation != "Link eno1 negotiated 100Mb, capable of 1Gb" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// A flap outranks the speed note; virtual interfaces are ignored.
+	s.NetInterfaces[1].SpeedMbps = 1000
+	d := &relational.DerivedRates{NetCarrierChanges: map[string]uint64{"eno1": 2, "veth1": 6}}
+	f = fs.Flag(s, d)
+	if f.Explanation != "Link eno1 flapped (2 carrier changes)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	if f := fs.Flag(s, &relational.DerivedRates{}); f.FlagLinkDegraded {
+		t.Error("did not expect FlagLinkDegraded on a healthy link")
+	}
+}
+
+func TestFlagLinkSaturatedAfterSamples(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	s := &relational.RawStatsFixed{
+		AgentID:         "a1",
+		DockerAvailable: true,
+		NetInterfaces: []relational.NetInterfaceStatsFixed{
+			{Name: "eth0", OperState: "up", SpeedMbps: 1000, Duplex: "full"},
+		},
+	}
+	busy := &relational.DerivedRates{NetUtilization: map[string]float64{"eth0": 95}}
+
+	for i := 1; i <= 2; i++ {
+		if f := fs.Flag(s, busy); f.FlagLinkSaturated {
+			t.Fatalf("saturated after %d samples", i)
+		}
+	}
+	f := fs.Flag(s, busy)
+	if !f.FlagLinkSaturated || f.CauseEntityKey != "eth0" {
+		t.Fatalf("flags = %+v", f)
+	}
+	if f.Explanation != "Link eth0 saturated: 95% of 1Gb for 3 samples" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+
+	// A quiet sample resets the count.
+	fs.Flag(s, &relational.DerivedRates{NetUtilization: map[string]float64{"eth0": 20}})
+	if f := fs.Flag(s, busy); f.FlagLinkSaturated {
+		t.Error("count not reset by a quiet sample")
+	}
+}
+
+func TestFlagLocalizedExplanation(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig().WithLocale("de_DE.UTF-8"))
+	s := &relational.RawStatsFixed{DockerAvailable: true, CPUUsagePct: 95, RAMUsagePct: 75}
+
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if f.Explanation != "CPU kritisch: 95.0% (+1 weitere)" {
+		t.Errorf("explanation = %q", f.Explanation)
+	}
+}
+
+func TestSetConfigAppliesIgnoreRulesAndKeepsHostOverrides(t *testing.T) {
+	fs := NewFlaggerService(DefaultConfig())
+	fs.SetHostThresholds("db-1", []relational.HostThreshold{{Metric: "cpu_usage_pct", Warning: 93, Critical: 98}})
+	s := &relational.RawStatsFixed{AgentID: "web-1", Hostname: "build-7", CPUUsagePct: 95, DockerAvailable: true}
+	if f := fs.Flag(s, &relational.DerivedRates{}); !f.FlagCPUOverloaded {
+		t.Fatal("want cpu_overloaded before the ignore rule")
+	}
+
+	cfg := fs.Config()
+	cfg.Ignore = []IgnoreRule{{Flag: "cpu_overloaded", Host: "build-*"}}
+	fs.SetConfig(cfg)
+	f := fs.Flag(s, &relational.DerivedRates{})
+	if f.FlagCPUOverloaded || f.SeverityLevel != 0 || f.Explanation != "" || f.Bitmask != 0 {
+		t.Errorf("ignored flag still reported: %+v", f)
+	}
+	s.Hostname = "web-7"
+	if f := fs.Flag(s, &relational.DerivedRates{}); !f.FlagCPUOverloaded {
+		t.Error("rule should only match build-* hosts")
+	}
+
+	db := &relational.RawStatsFixed{AgentID: "db-1", CPUUsagePct: 95, DockerAvailable: true}
+	if f := fs.Flag(db, &relational.DerivedRates{}); f.FlagCPUOverloaded {
+		t.Error("db-1 lost its host threshold override after SetConfig")
+	}
+}
@@ -0,0 +1,59 @@
+package flagger
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/i18n"
+)
+
+// StorageReporter reports the database size against its budget, e.g. a
+// relational.Repo.
+type StorageReporter interface {
+	StorageUsage(ctx context.Context) (relational.StorageUsage, error)
+}
+
+// StorageGuard raises storage_budget when the database uses WarnPct of its
+// size budget, before retention starts deleting old snapshots.
+type StorageGuard struct {
+	cfg  StorageConfig
+	repo StorageReporter
+}
+
+// NewStorageGuard creates a guard reading usage from repo.
+func NewStorageGuard(cfg StorageConfig, repo StorageReporter) *StorageGuard {
+	return &StorageGuard{cfg: cfg, repo: repo}
+}
+
+// Check sets FlagStorageBudget and notes the usage when the budget is near.
+// Databases without a budget are never flagged.
+func (g *StorageGuard) Check(ctx context.Context, flags *relational.SnapshotFlags) {
+	if g.cfg.WarnPct <= 0 || g.repo == nil || flags == nil {
+		return
+	}
+	u, err := g.repo.StorageUsage(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Storage usage check failed: %v\n", err)
+		return
+	}
+	pct := u.BudgetPct()
+	if u.BudgetBytes <= 0 || pct < g.cfg.WarnPct {
+		return
+	}
+
+	flags.FlagStorageBudget = true
+	flags.SeverityLevel = max(flags.SeverityLevel, 1)
+	if flags.PrimaryCause == "" {
+		flags.PrimaryCause = "storage"
+	}
+	msg := i18n.NewPrinter(g.cfg.Locale)
+	note := msg.Sprintf("syschecker database at %.0f%% of its %s budget (%s used); the oldest snapshots are deleted beyond it",
+		pct, g.cfg.Units.Bytes(uint64(u.BudgetBytes)), g.cfg.Units.Bytes(uint64(u.UsedBytes)))
+	if flags.Explanation == "" {
+		flags.Explanation = note
+	} else {
+		flags.Explanation += "; " + note
+	}
+}
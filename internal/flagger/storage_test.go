@@ -0,0 +1,33 @@
+package flagger
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+)
+
+type fixedUsage relational.StorageUsage
+
+func (u fixedUsage) StorageUsage(context.Context) (relational.StorageUsage, error) {
+	return relational.StorageUsage(u), nil
+}
+
+func TestStorageGuard(t *testing.T) {
+	cfg := DefaultConfig().Storage
+	ctx := context.Background()
+
+	var f relational.SnapshotFlags
+	NewStorageGuard(cfg, fixedUsage{UsedBytes: 950 << 20, BudgetBytes: 1 << 30}).Check(ctx, &f)
+	if !f.FlagStorageBudget || f.SeverityLevel != 1 || !strings.Contains(f.Explanation, "93% of its 1.0 GiB budget") {
+		t.Errorf("near budget: %+v", f)
+	}
+
+	f = relational.SnapshotFlags{}
+	NewStorageGuard(cfg, fixedUsage{UsedBytes: 100 << 20, BudgetBytes: 1 << 30}).Check(ctx, &f)
+	NewStorageGuard(cfg, fixedUsage{UsedBytes: 5 << 30}).Check(ctx, &f)
+	if f.FlagStorageBudget {
+		t.Error("flagged below the warning level or without a budget")
+	}
+}
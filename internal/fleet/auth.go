@@ -0,0 +1,146 @@
+package fleet
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+// Headers of a signed fleet request. The Authorization header carries an
+// HMAC-SHA256, keyed by the agent's token, of the method, request URI,
+// timestamp and nonce, so the token itself never crosses the wire.
+const (
+	HeaderTimestamp = "X-Syschecker-Timestamp" // Unix seconds the request was signed at
+	HeaderNonce     = "X-Syschecker-Nonce"     // random, used once
+	authScheme      = "Syschecker-HMAC"
+)
+
+// MaxSkew is how far a signed request's timestamp may be from the agent's
+// clock. Agents remember nonces for as long, so within it a captured
+// request cannot be replayed, and after it the timestamp is refused.
+const MaxSkew = 5 * time.Minute
+
+// AgentToken returns the token in file, or $EnvToken when file is empty.
+// An empty result means the agent relies on mutual TLS alone.
+func AgentToken(file string) (string, error) {
+	if file == "" {
+		return strings.TrimSpace(os.Getenv(EnvToken)), nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read fleet token: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("fleet token file %s is empty", file)
+	}
+	return token, nil
+}
+
+// LoadTokens reads the aggregator's tokens: a JSON object mapping the
+// name of each agent, as discovered or given in -peers, to its token.
+func LoadTokens(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fleet tokens: %w", err)
+	}
+	var tokens map[string]string
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("parse fleet tokens %s: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// Sign signs req with token as of now.
+func Sign(req *http.Request, token string, now time.Time) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	ts, nonce := strconv.FormatInt(now.Unix(), 10), hex.EncodeToString(b)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set("Authorization", authScheme+" "+signature(token, req.Method, req.URL.RequestURI(), ts, nonce))
+	return nil
+}
+
+func signature(token, method, uri, ts, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", method, uri, ts, nonce)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier admits requests signed with an agent's token, each only once.
+type Verifier struct {
+	token string
+	clock clock.Clock
+
+	mu   sync.Mutex
+	seen map[string]time.Time // nonce -> when its timestamp goes stale
+}
+
+func NewVerifier(token string, clk clock.Clock) *Verifier {
+	return &Verifier{token: token, clock: clock.OrReal(clk), seen: map[string]time.Time{}}
+}
+
+// Verify returns an error unless r is signed with the token, within
+// MaxSkew of now, with a nonce not seen before.
+func (v *Verifier) Verify(r *http.Request) error {
+	sig, ok := strings.CutPrefix(r.Header.Get("Authorization"), authScheme+" ")
+	if !ok {
+		return errors.New("request is not signed")
+	}
+	ts, nonce := r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s", HeaderTimestamp)
+	}
+	if nonce == "" || len(nonce) > 64 {
+		return fmt.Errorf("invalid %s", HeaderNonce)
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(v.token, r.Method, r.URL.RequestURI(), ts, nonce))) {
+		return errors.New("bad signature")
+	}
+
+	now, signed := v.clock.Now(), time.Unix(sec, 0)
+	if now.Sub(signed).Abs() > MaxSkew {
+		return fmt.Errorf("request signed at %s is outside the %s allowed", signed.UTC().Format(time.RFC3339), MaxSkew)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for n, stale := range v.seen {
+		if now.After(stale) {
+			delete(v.seen, n)
+		}
+	}
+	if _, ok := v.seen[nonce]; ok {
+		return errors.New("replayed request")
+	}
+	v.seen[nonce] = signed.Add(MaxSkew)
+	return nil
+}
+
+// Wrap answers 401 to requests that fail Verify instead of passing them
+// to next.
+func (v *Verifier) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			w.Header().Set("WWW-Authenticate", authScheme)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
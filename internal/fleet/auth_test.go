@@ -0,0 +1,97 @@
+package fleet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestVerifier(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	v := NewVerifier("s3cret", clk)
+	signed := func(token string, at time.Time) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, BackupPath, nil)
+		if err := Sign(req, token, at); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	req := signed("s3cret", start)
+	if err := v.Verify(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(req); err == nil {
+		t.Error("replayed request accepted")
+	}
+	if err := v.Verify(signed("s3cret", start.Add(-time.Minute))); err != nil {
+		t.Errorf("request a minute old: %v", err)
+	}
+	for name, req := range map[string]*http.Request{
+		"wrong token": signed("guess", start),
+		"unsigned":    httptest.NewRequest(http.MethodGet, BackupPath, nil),
+		"stale":       signed("s3cret", start.Add(-MaxSkew-time.Second)),
+		"future":      signed("s3cret", start.Add(MaxSkew+time.Second)),
+	} {
+		if err := v.Verify(req); err == nil {
+			t.Errorf("%s request accepted", name)
+		}
+	}
+	tampered := signed("s3cret", start)
+	tampered.URL.Path = "/version"
+	if err := v.Verify(tampered); err == nil {
+		t.Error("request for another path accepted")
+	}
+
+	// Nonces are forgotten once their timestamp is stale anyway.
+	clk.Advance(MaxSkew + time.Second)
+	if err := v.Verify(req); err == nil {
+		t.Error("stale replay accepted")
+	}
+	if err := v.Verify(signed("s3cret", clk.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.seen) != 1 {
+		t.Errorf("%d nonces kept, want only the last", len(v.seen))
+	}
+
+	rec := httptest.NewRecorder()
+	v.Wrap(http.NotFoundHandler()).ServeHTTP(rec, signed("guess", clk.Now()))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != authScheme {
+		t.Errorf("wrapped: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestTokens(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	os.WriteFile(file, []byte("s3cret\n"), 0o600)
+	if token, err := AgentToken(file); err != nil || token != "s3cret" {
+		t.Errorf("AgentToken = %q, %v", token, err)
+	}
+	os.WriteFile(file, []byte("\n"), 0o600)
+	if _, err := AgentToken(file); err == nil {
+		t.Error("empty token file accepted")
+	}
+	t.Setenv(EnvToken, "from-env")
+	if token, _ := AgentToken(""); token != "from-env" {
+		t.Errorf("AgentToken from env = %q", token)
+	}
+
+	tokens := filepath.Join(dir, "tokens.json")
+	os.WriteFile(tokens, []byte(`{"web-1": "a", "db-1": "b"}`), 0o600)
+	got, err := LoadTokens(tokens)
+	if err != nil || got["web-1"] != "a" || got["db-1"] != "b" {
+		t.Errorf("LoadTokens = %v, %v", got, err)
+	}
+	os.WriteFile(tokens, []byte(`["web-1"]`), 0o600)
+	if _, err := LoadTokens(tokens); err == nil {
+		t.Error("tokens that are not an object accepted")
+	}
+}
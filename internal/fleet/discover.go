@@ -0,0 +1,153 @@
+package fleet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Discovery sources accepted by Discover.
+const (
+	SourceTailscale = "tailscale"
+	SourceWireGuard = "wireguard"
+)
+
+// Sources lists the discovery sources.
+var Sources = []string{SourceTailscale, SourceWireGuard}
+
+// DiscoverOptions select where agents are looked for.
+type DiscoverOptions struct {
+	Sources      []string // any of Sources
+	Static       []string // host or host:port of agents to pull from as well
+	Port         string   // fleet API port of discovered agents and static hosts without one
+	TailscaleTag string   // only tailnet peers with this ACL tag, e.g. tag:syschecker
+	WGInterface  string   // WireGuard interface whose peers are agents; empty reads all
+}
+
+// Discover lists the agents reachable now: the static peers, the online
+// peers of this machine's tailnet as `tailscale status` reports them and
+// the peers of its WireGuard interfaces as `wg show` reports them. A
+// WireGuard peer is reached at the first single address it routes; peers
+// routing only subnets are gateways and skipped. Peers found twice are
+// listed once. A source that fails is reported in the error, along with
+// the peers the others found.
+func Discover(ctx context.Context, o DiscoverOptions) ([]Peer, error) {
+	port := o.Port
+	if port == "" {
+		port = DefaultPort
+	}
+	var peers []Peer
+	for _, s := range o.Static {
+		host, p, err := net.SplitHostPort(s)
+		if err != nil {
+			host, p = s, port
+		}
+		peers = append(peers, Peer{Name: host, Addr: net.JoinHostPort(host, p)})
+	}
+	var errs []error
+	for _, src := range o.Sources {
+		switch src {
+		case SourceTailscale:
+			out, err := exec.CommandContext(ctx, "tailscale", "status", "--json").Output()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tailscale status: %w", err))
+				continue
+			}
+			found, err := parseTailscaleStatus(out, port, o.TailscaleTag)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			peers = append(peers, found...)
+		case SourceWireGuard:
+			iface := o.WGInterface
+			if iface == "" {
+				iface = "all"
+			}
+			out, err := exec.CommandContext(ctx, "wg", "show", iface, "allowed-ips").Output()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("wg show %s: %w", iface, err))
+				continue
+			}
+			peers = append(peers, parseWGAllowedIPs(out, port)...)
+		default:
+			errs = append(errs, fmt.Errorf("unknown discovery source %q (want %s)", src, strings.Join(Sources, " or ")))
+		}
+	}
+
+	seen := map[string]bool{}
+	return slices.DeleteFunc(peers, func(p Peer) bool {
+		dup := seen[p.Addr]
+		seen[p.Addr] = true
+		return dup
+	}), errors.Join(errs...)
+}
+
+// tailscaleStatus is the part of `tailscale status --json` Discover reads.
+type tailscaleStatus struct {
+	Peer map[string]struct {
+		HostName     string
+		DNSName      string
+		TailscaleIPs []string
+		Online       bool
+		Tags         []string
+	}
+}
+
+// parseTailscaleStatus returns the online tailnet peers, those with tag
+// if given, addressed by their MagicDNS name when they have one.
+func parseTailscaleStatus(out []byte, port, tag string) ([]Peer, error) {
+	var st tailscaleStatus
+	if err := json.Unmarshal(out, &st); err != nil {
+		return nil, fmt.Errorf("parse tailscale status: %w", err)
+	}
+	var peers []Peer
+	for _, p := range st.Peer {
+		if !p.Online || (tag != "" && !slices.Contains(p.Tags, tag)) {
+			continue
+		}
+		host := strings.TrimSuffix(p.DNSName, ".")
+		if host == "" && len(p.TailscaleIPs) > 0 {
+			host = p.TailscaleIPs[0]
+		}
+		if host == "" {
+			continue
+		}
+		name := p.HostName
+		if name == "" {
+			name = host
+		}
+		peers = append(peers, Peer{Name: name, Addr: net.JoinHostPort(host, port)})
+	}
+	slices.SortFunc(peers, func(a, b Peer) int { return strings.Compare(a.Name, b.Name) })
+	return peers, nil
+}
+
+// parseWGAllowedIPs returns a peer for each line of `wg show <interface>
+// allowed-ips` output routing a single address, addressed by the first.
+// Lines hold a peer's public key and allowed IPs, led by the interface
+// with "all".
+func parseWGAllowedIPs(out []byte, port string) []Peer {
+	var peers []Peer
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		for _, field := range strings.Fields(sc.Text()) {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil || !prefix.IsSingleIP() {
+				continue
+			}
+			host := prefix.Addr().String()
+			peers = append(peers, Peer{Name: host, Addr: net.JoinHostPort(host, port)})
+			break // a second address of the same peer, e.g. its IPv6 one
+		}
+	}
+	return peers
+}
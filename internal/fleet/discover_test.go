@@ -0,0 +1,73 @@
+package fleet
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseTailscaleStatus(t *testing.T) {
+	out := []byte(`{
+		"Self": {"HostName": "central"},
+		"Peer": {
+			"nodekey:1": {"HostName": "web-1", "DNSName": "web-1.tail1234.ts.net.", "TailscaleIPs": ["100.64.0.2"], "Online": true, "Tags": ["tag:syschecker"]},
+			"nodekey:2": {"HostName": "laptop", "DNSName": "laptop.tail1234.ts.net.", "TailscaleIPs": ["100.64.0.3"], "Online": true},
+			"nodekey:3": {"HostName": "db-1", "TailscaleIPs": ["100.64.0.4"], "Online": false, "Tags": ["tag:syschecker"]},
+			"nodekey:4": {"HostName": "db-2", "TailscaleIPs": ["100.64.0.5", "fd7a::5"], "Online": true, "Tags": ["tag:syschecker"]}
+		}
+	}`)
+	peers, err := parseTailscaleStatus(out, "7443", "tag:syschecker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Peer{
+		{Name: "db-2", Addr: "100.64.0.5:7443"},
+		{Name: "web-1", Addr: "web-1.tail1234.ts.net:7443"},
+	}
+	if !reflect.DeepEqual(peers, want) {
+		t.Errorf("peers = %v, want %v", peers, want)
+	}
+
+	if peers, _ := parseTailscaleStatus(out, "7443", ""); len(peers) != 3 {
+		t.Errorf("untagged: %d peers, want the 3 online", len(peers))
+	}
+	if _, err := parseTailscaleStatus([]byte("not json"), "7443", ""); err == nil {
+		t.Error("invalid status accepted")
+	}
+}
+
+func TestParseWGAllowedIPs(t *testing.T) {
+	out := []byte("wg0\tkeyA=\t10.8.0.2/32 fd00::2/128\n" +
+		"wg0\tkeyB=\t10.8.0.3/32 192.168.10.0/24\n" +
+		"wg0\tkeyC=\t0.0.0.0/0\n" +
+		"wg1\tkeyD=\t(none)\n")
+	want := []Peer{
+		{Name: "10.8.0.2", Addr: "10.8.0.2:7443"},
+		{Name: "10.8.0.3", Addr: "10.8.0.3:7443"},
+	}
+	if peers := parseWGAllowedIPs(out, "7443"); !reflect.DeepEqual(peers, want) {
+		t.Errorf("peers = %v, want %v", peers, want)
+	}
+}
+
+func TestDiscoverStatic(t *testing.T) {
+	peers, err := Discover(context.Background(), DiscoverOptions{Static: []string{"web-1", "db-1:9000", "web-1:7443"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Peer{
+		{Name: "web-1", Addr: "web-1:7443"},
+		{Name: "db-1", Addr: "db-1:9000"},
+	}
+	if !reflect.DeepEqual(peers, want) {
+		t.Errorf("peers = %v, want %v", peers, want)
+	}
+
+	peers, err = Discover(context.Background(), DiscoverOptions{Sources: []string{"consul"}, Static: []string{"web-1"}})
+	if err == nil {
+		t.Error("unknown source accepted")
+	}
+	if len(peers) != 1 {
+		t.Errorf("static peers lost to a failed source: %v", peers)
+	}
+}
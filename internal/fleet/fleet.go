@@ -0,0 +1,248 @@
+// Package fleet lets a central aggregator find agents and pull their
+// data. Agents serve a backup of their database over mutual TLS and, given
+// a token, admit only requests signed with it; the aggregator enumerates
+// them from a tailnet, a WireGuard interface or a static list, so agents
+// need no registration.
+package fleet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"syschecker/internal/buildinfo"
+)
+
+// Environment variables consulted when the matching flags are not given.
+const (
+	EnvAddr  = "SYSCHECKER_FLEET_ADDR"  // address agents serve the fleet API on
+	EnvCert  = "SYSCHECKER_FLEET_CERT"  // this member's PEM certificate
+	EnvKey   = "SYSCHECKER_FLEET_KEY"   // its PEM private key
+	EnvCA    = "SYSCHECKER_FLEET_CA"    // PEM bundle of the CA signing every member's certificate
+	EnvToken = "SYSCHECKER_FLEET_TOKEN" // token an agent requires requests to be signed with
+)
+
+// DefaultPort is the port agents are pulled from when discovery or a
+// static peer names only the host.
+const DefaultPort = "7443"
+
+// BackupPath is where agents serve their backup.
+const BackupPath = "/api/v1/backup"
+
+// Peer is an agent the aggregator pulls from.
+type Peer struct {
+	Name string // directory the agent's backups are kept under
+	Addr string // host:port of its fleet API
+}
+
+// TLSFiles locate a fleet member's mutual TLS identity. Agents and the
+// aggregator each hold a certificate signed by the fleet CA and accept
+// only peers presenting one too.
+type TLSFiles struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+// TLSFilesFromEnv returns the files named by EnvCert, EnvKey and EnvCA.
+func TLSFilesFromEnv() TLSFiles {
+	return TLSFiles{Cert: os.Getenv(EnvCert), Key: os.Getenv(EnvKey), CA: os.Getenv(EnvCA)}
+}
+
+// Register adds -fleet-cert, -fleet-key and -fleet-ca to fs, defaulting
+// to the environment.
+func (f *TLSFiles) Register(fs *flag.FlagSet) {
+	env := TLSFilesFromEnv()
+	fs.StringVar(&f.Cert, "fleet-cert", env.Cert, "PEM certificate presented to fleet peers, signed by -fleet-ca (or $"+EnvCert+")")
+	fs.StringVar(&f.Key, "fleet-key", env.Key, "PEM private key of -fleet-cert (or $"+EnvKey+")")
+	fs.StringVar(&f.CA, "fleet-ca", env.CA, "PEM bundle of the CA whose certificates fleet peers must present (or $"+EnvCA+")")
+}
+
+func (f TLSFiles) load() (tls.Certificate, *x509.CertPool, error) {
+	if f.Cert == "" || f.Key == "" || f.CA == "" {
+		return tls.Certificate{}, nil, fmt.Errorf("fleet mutual TLS needs a certificate, key and CA (or $%s, $%s and $%s)", EnvCert, EnvKey, EnvCA)
+	}
+	cert, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("load fleet certificate: %w", err)
+	}
+	pem, err := os.ReadFile(f.CA)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("read fleet CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return tls.Certificate{}, nil, fmt.Errorf("no PEM certificates in %s", f.CA)
+	}
+	return cert, pool, nil
+}
+
+// reloadInterval bounds how often the TLS files are checked for changes.
+var reloadInterval = 10 * time.Second
+
+// identity is a member's certificate and CA, reloaded when their files
+// change so both can be rotated without restarting the agent or the
+// aggregator. Connections already open keep the identity they started with.
+type identity struct {
+	files TLSFiles
+
+	mu      sync.Mutex
+	checked time.Time
+	stamp   string // modification times and sizes of the files loaded
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+func (f TLSFiles) identity() (*identity, error) {
+	id := &identity{files: f, checked: time.Now()}
+	if err := id.reload(); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// current returns the certificate and CA, reloading them first if a file
+// changed. A rotation that fails to load, e.g. a certificate written
+// before its key, keeps the previous identity until the next check.
+func (id *identity) current() (*tls.Certificate, *x509.CertPool) {
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	if time.Since(id.checked) >= reloadInterval {
+		id.checked = time.Now()
+		if err := id.reload(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: keeping the previous fleet certificate: %v\n", err)
+		}
+	}
+	return id.cert, id.pool
+}
+
+func (id *identity) reload() error {
+	var stamp strings.Builder
+	for _, name := range []string{id.files.Cert, id.files.Key, id.files.CA} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&stamp, "%d/%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	if stamp.String() == id.stamp {
+		return nil
+	}
+	cert, pool, err := id.files.load()
+	if err != nil {
+		return err
+	}
+	id.cert, id.pool, id.stamp = &cert, pool, stamp.String()
+	return nil
+}
+
+// verify checks the certificate a peer presented against the current CA,
+// and against host unless it is empty.
+func (id *identity) verify(cs tls.ConnectionState, host string, usage x509.ExtKeyUsage) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("fleet peer presented no certificate")
+	}
+	_, pool := id.current()
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       host,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// ServerConfig is the TLS config of an agent, which requires the client
+// to present a certificate signed by the CA. Each handshake uses the
+// certificate and CA files as they are now; see identity.
+func (f TLSFiles) ServerConfig() (*tls.Config, error) {
+	id, err := f.identity()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := id.current()
+			return cert, nil
+		},
+		// Client certificates are verified by VerifyConnection, as
+		// ClientCAs could not follow a rotated CA.
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return id.verify(cs, "", x509.ExtKeyUsageClientAuth)
+		},
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig is the TLS config of the aggregator. Agents' certificates
+// must name the host they are reached by: the tailnet DNS name or IP, the
+// WireGuard IP or the static peer's host.
+func (f TLSFiles) ClientConfig() (*tls.Config, error) {
+	id, err := f.identity()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := id.current()
+			return cert, nil
+		},
+		// The agent's certificate is verified by VerifyConnection instead,
+		// against the current CA rather than a fixed RootCAs.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return id.verify(cs, cs.ServerName, x509.ExtKeyUsageServerAuth)
+		},
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// Handler returns the fleet API of an agent: its backup at BackupPath and
+// its build at /version.
+func Handler(backup http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(BackupPath, backup)
+	mux.Handle("/version", buildinfo.Handler())
+	return mux
+}
+
+// Serve serves the fleet API on addr over mutual TLS in the background,
+// admitting only requests v verifies unless v is nil. An empty addr
+// disables it and returns nil.
+func Serve(addr string, cfg *tls.Config, v *Verifier, backup http.Handler) (*http.Server, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("fleet API listen on %s: %w", addr, err)
+	}
+	h := Handler(backup)
+	if v != nil {
+		h = v.Wrap(h)
+	}
+	srv := &http.Server{Handler: h, TLSConfig: cfg, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "Fleet API stopped: %v\n", err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Fleet API listening on https://%s%s\n", ln.Addr(), BackupPath)
+	return srv, nil
+}
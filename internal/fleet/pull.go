@@ -0,0 +1,120 @@
+package fleet
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PartialSuffix marks a backup directory Pull is still writing.
+const PartialSuffix = ".partial"
+
+// NewClient returns an HTTP client presenting the aggregator's certificate
+// from cfg, with timeout bounding each whole pull.
+func NewClient(cfg *tls.Config, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: cfg, ForceAttemptHTTP2: true},
+	}
+}
+
+// Pull downloads p's backup into the directory named after it under root,
+// replacing the previous one only once the new one is complete, and
+// returns that directory. A non-empty token signs the request; see Sign.
+func Pull(ctx context.Context, client *http.Client, p Peer, token, root string) (string, error) {
+	dir := filepath.Join(root, dirName(p.Name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+p.Addr+BackupPath, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		if err := Sign(req, token, time.Now()); err != nil {
+			return "", fmt.Errorf("pull %s: %w", p.Name, err)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("pull %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("pull %s: %s: %s", p.Name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	partial := dir + PartialSuffix
+	os.RemoveAll(partial)
+	if err := untar(resp.Body, partial); err != nil {
+		os.RemoveAll(partial)
+		return "", fmt.Errorf("pull %s: %w", p.Name, err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("pull %s: %w", p.Name, err)
+	}
+	if err := os.Rename(partial, dir); err != nil {
+		return "", fmt.Errorf("pull %s: %w", p.Name, err)
+	}
+	return dir, nil
+}
+
+// untar writes the regular files of a backup archive into a new
+// directory. The archive is flat, so any path in a name is dropped.
+func untar(r io.Reader, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	files := 0
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read backup archive: %w", err)
+		}
+		name := filepath.Base(filepath.Clean("/" + hdr.Name))
+		if hdr.Typeflag != tar.TypeReg || name == "/" || name == "." {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		files++
+	}
+	if files == 0 {
+		return errors.New("empty backup archive")
+	}
+	return nil
+}
+
+// dirName makes a peer name safe as a directory name.
+func dirName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(name, ".") == "" {
+		return "_" + name
+	}
+	return name
+}
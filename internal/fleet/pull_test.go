@@ -0,0 +1,208 @@
+package fleet
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeIdentity writes a certificate for name signed by ca, or a CA when
+// ca is nil, and its key as PEM files in dir.
+func writeIdentity(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (TLSFiles, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, signer := tmpl, key
+	if ca == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		parent, signer = ca, caKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := TLSFiles{Cert: filepath.Join(dir, name+".crt"), Key: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(f.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, cert, key
+}
+
+// serveTLS serves h over a TLS listener with cfg, which unlike
+// httptest's StartTLS does not add a certificate of its own, and returns
+// the peer reaching it.
+func serveTLS(t *testing.T, cfg *tls.Config, h http.Handler) Peer {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(h)
+	srv.Listener = tls.NewListener(srv.Listener, cfg)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return Peer{Name: "web/1", Addr: srv.Listener.Addr().String()}
+}
+
+func tarBackup(w http.ResponseWriter, r *http.Request) {
+	tw := tar.NewWriter(w)
+	for _, name := range []string{"load.sql", "../hosts.parquet"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(name)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(name))
+	}
+	tw.Close()
+}
+
+func TestPullOverMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFiles, ca, caKey := writeIdentity(t, dir, "ca", nil, nil)
+	agent, _, _ := writeIdentity(t, dir, "agent", ca, caKey)
+	agent.CA = caFiles.Cert
+	central, _, _ := writeIdentity(t, dir, "central", ca, caKey)
+	central.CA = caFiles.Cert
+
+	serverCfg, err := agent.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := serveTLS(t, serverCfg, NewVerifier("s3cret", nil).Wrap(Handler(http.HandlerFunc(tarBackup))))
+
+	clientCfg, err := central.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(clientCfg, 10*time.Second)
+	root := t.TempDir()
+	got, err := Pull(context.Background(), client, peer, "s3cret", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(root, "web_1") {
+		t.Errorf("pulled into %s", got)
+	}
+	for _, name := range []string{"load.sql", "hosts.parquet"} {
+		if _, err := os.Stat(filepath.Join(got, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, token := range []string{"", "another agent's"} {
+		if _, err := Pull(context.Background(), client, peer, token, root); err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("pull with token %q: %v, want 401", token, err)
+		}
+	}
+	// Without a client certificate the agent refuses the connection.
+	anonymous := clientCfg.Clone()
+	anonymous.GetClientCertificate = nil
+	if _, err := Pull(context.Background(), NewClient(anonymous, 10*time.Second), peer, "s3cret", root); err == nil {
+		t.Error("pulled without a client certificate")
+	}
+	if _, err := (TLSFiles{Cert: agent.Cert}).ServerConfig(); err == nil {
+		t.Error("server config without a key and CA accepted")
+	}
+}
+
+func TestCertRotation(t *testing.T) {
+	defer func(d time.Duration) { reloadInterval = d }(reloadInterval)
+	reloadInterval = 0
+
+	// replace overwrites dst with src, dated later so the change is seen.
+	replace := func(dst, src string) {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(dst, later, later)
+	}
+	dir := t.TempDir()
+	oldCA, ca, caKey := writeIdentity(t, dir, "ca", nil, nil)
+	agent, _, _ := writeIdentity(t, dir, "agent", ca, caKey)
+	agent.CA = oldCA.Cert
+	central, _, _ := writeIdentity(t, dir, "central", ca, caKey)
+	central.CA = filepath.Join(dir, "central-ca.crt")
+	replace(central.CA, oldCA.Cert)
+
+	serverCfg, err := agent.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := serveTLS(t, serverCfg, Handler(http.HandlerFunc(tarBackup)))
+	clientCfg, err := central.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pull := func() error {
+		// A fresh client per pull, so no connection outlives a rotation.
+		_, err := Pull(context.Background(), NewClient(clientCfg, 10*time.Second), peer, "", t.TempDir())
+		return err
+	}
+	if err := pull(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the agent to a new CA: the aggregator no longer trusts it.
+	newDir := t.TempDir()
+	newCA, ca2, ca2Key := writeIdentity(t, newDir, "ca", nil, nil)
+	rotated, _, _ := writeIdentity(t, newDir, "agent", ca2, ca2Key)
+	replace(agent.Cert, rotated.Cert)
+	replace(agent.Key, rotated.Key)
+	replace(agent.CA, newCA.Cert)
+	if err := pull(); err == nil {
+		t.Fatal("pulled from an agent with a certificate of an untrusted CA")
+	}
+
+	// Rotate the aggregator too and they trust each other again.
+	central2, _, _ := writeIdentity(t, newDir, "central", ca2, ca2Key)
+	replace(central.Cert, central2.Cert)
+	replace(central.Key, central2.Key)
+	replace(central.CA, newCA.Cert)
+	if err := pull(); err != nil {
+		t.Fatalf("after rotating both: %v", err)
+	}
+
+	// A half-written rotation keeps the previous identity.
+	if err := os.WriteFile(central.Key, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := pull(); err != nil {
+		t.Errorf("broken key file not ignored: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package health watches the connections syschecker depends on, such as
+// Neo4j and DuckDB: each is pinged periodically, reconnected when a ping
+// fails, and its status kept for current_state, the API and MCP clients.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+// Defaults for NewMonitor.
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// Dependency is a connection to watch.
+type Dependency struct {
+	Name string
+	Ping func(ctx context.Context) error
+	// Reconnect replaces a connection whose ping failed; nil when the
+	// client recovers on its own, like database/sql's pool.
+	Reconnect func(ctx context.Context) error
+}
+
+// Status is the last known state of a dependency.
+type Status struct {
+	Name      string    `json:"name"`
+	Up        bool      `json:"up"`
+	CheckedAt time.Time `json:"checked_at"`
+	Since     time.Time `json:"since"` // when Up last changed
+	Error     string    `json:"error,omitempty"`
+	// Failures counts consecutive failed checks; Reconnects counts
+	// successful reconnects since start.
+	Failures   int `json:"failures,omitempty"`
+	Reconnects int `json:"reconnects,omitempty"`
+}
+
+// Option configures a Monitor.
+type Option func(*Monitor)
+
+// WithInterval sets how often dependencies are checked.
+func WithInterval(d time.Duration) Option {
+	return func(m *Monitor) {
+		if d > 0 {
+			m.interval = d
+		}
+	}
+}
+
+// WithTimeout bounds each ping and reconnect.
+func WithTimeout(d time.Duration) Option {
+	return func(m *Monitor) {
+		if d > 0 {
+			m.timeout = d
+		}
+	}
+}
+
+// WithClock replaces the wall clock, for tests.
+func WithClock(c clock.Clock) Option {
+	return func(m *Monitor) { m.clock = clock.OrReal(c) }
+}
+
+// Monitor checks dependencies. It is safe for concurrent use.
+type Monitor struct {
+	deps     []Dependency
+	interval time.Duration
+	timeout  time.Duration
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	statuses []Status // parallel to deps; zero CheckedAt until first check
+}
+
+// NewMonitor returns a monitor of deps. Nothing is checked until Check or
+// Run is called.
+func NewMonitor(deps []Dependency, opts ...Option) *Monitor {
+	m := &Monitor{
+		deps:     deps,
+		interval: DefaultInterval,
+		timeout:  DefaultTimeout,
+		clock:    clock.Real,
+		statuses: make([]Status, len(deps)),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for i, d := range deps {
+		m.statuses[i].Name = d.Name
+	}
+	return m
+}
+
+// Check pings every dependency once, reconnecting those that fail, and
+// returns the new statuses.
+func (m *Monitor) Check(ctx context.Context) []Status {
+	for i, d := range m.deps {
+		err := m.call(ctx, d.Ping)
+		reconnected := false
+		if err != nil && d.Reconnect != nil {
+			if rerr := m.call(ctx, d.Reconnect); rerr == nil {
+				err, reconnected = nil, true
+			}
+		}
+		m.record(i, err, reconnected)
+	}
+	return m.Statuses()
+}
+
+// call runs f under the monitor's timeout.
+func (m *Monitor) call(ctx context.Context, f func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	return f(ctx)
+}
+
+func (m *Monitor) record(i int, err error, reconnected bool) {
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := &m.statuses[i]
+	up := err == nil
+	if st.CheckedAt.IsZero() || st.Up != up {
+		st.Since = now
+	}
+	st.Up, st.CheckedAt = up, now
+	st.Error = ""
+	if up {
+		st.Failures = 0
+	} else {
+		st.Error = err.Error()
+		st.Failures++
+	}
+	if reconnected {
+		st.Reconnects++
+	}
+}
+
+// Run checks dependencies at once and then every interval until ctx is
+// done.
+func (m *Monitor) Run(ctx context.Context) {
+	m.Check(ctx)
+	ticker := m.clock.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.Check(ctx)
+		}
+	}
+}
+
+// Statuses returns the dependencies' last known states, in the order they
+// were given. Dependencies not yet checked are reported down.
+func (m *Monitor) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Status, len(m.statuses))
+	copy(out, m.statuses)
+	return out
+}
+
+// Handler serves the statuses as JSON on GET, with 503 Service
+// Unavailable when any dependency is down.
+func Handler(m *Monitor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		statuses := m.Statuses()
+		w.Header().Set("Content-Type", "application/json")
+		for _, st := range statuses {
+			if !st.Up {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				break
+			}
+		}
+		_ = json.NewEncoder(w).Encode(statuses)
+	})
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestMonitorCheck(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	var pingErr, reconnectErr error
+	reconnects := 0
+	m := NewMonitor([]Dependency{
+		{Name: "neo4j", Ping: func(context.Context) error { return pingErr },
+			Reconnect: func(context.Context) error { reconnects++; return reconnectErr }},
+		{Name: "duckdb", Ping: func(context.Context) error { return pingErr }},
+	}, WithClock(clk))
+
+	if st := m.Statuses(); st[0].Up || st[0].Name != "neo4j" {
+		t.Errorf("before first check: %+v", st[0])
+	}
+
+	st := m.Check(context.Background())
+	if !st[0].Up || !st[1].Up || reconnects != 0 {
+		t.Fatalf("healthy: %+v, %d reconnects", st, reconnects)
+	}
+	up := st[0].Since
+
+	// A failed ping is repaired by a reconnect where there is one.
+	pingErr = errors.New("connection reset")
+	clk.Advance(time.Minute)
+	st = m.Check(context.Background())
+	if !st[0].Up || st[0].Reconnects != 1 || !st[0].Since.Equal(up) {
+		t.Errorf("reconnected: %+v", st[0])
+	}
+	if st[1].Up || st[1].Failures != 1 || st[1].Error != "connection reset" || !st[1].Since.Equal(clk.Now()) {
+		t.Errorf("no reconnect: %+v", st[1])
+	}
+
+	reconnectErr = errors.New("refused")
+	clk.Advance(time.Minute)
+	st = m.Check(context.Background())
+	if st[0].Up || st[0].Failures != 1 || st[0].Error != "connection reset" || st[1].Failures != 2 {
+		t.Errorf("down: %+v", st)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dependencies", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"name":"duckdb"`) {
+		t.Errorf("GET = %d: %s", rec.Code, rec.Body)
+	}
+
+	pingErr = nil
+	st = m.Check(context.Background())
+	if !st[0].Up || st[0].Failures != 0 || st[0].Error != "" {
+		t.Errorf("recovered: %+v", st[0])
+	}
+}
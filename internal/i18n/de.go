@@ -0,0 +1,89 @@
+package i18n
+
+// de is the German catalog.
+var de = map[string]string{
+	// Flag explanations
+	" of %.1f-core cgroup limit":                                         " des cgroup-Limits von %.1f Kernen",
+	" of cgroup limit %s":                                                " des cgroup-Limits von %s",
+	"CPU critical: %.1f%%%s":                                             "CPU kritisch: %.1f%%%s",
+	"CPU warning: %.1f%%%s":                                              "CPU Warnung: %.1f%%%s",
+	"RAM critical: %.1f%%%s":                                             "RAM kritisch: %.1f%%%s",
+	"RAM warning: %.1f%%%s":                                              "RAM Warnung: %.1f%%%s",
+	"Disk critical: %.1f%% on %s":                                        "Festplatte kritisch: %.1f%% auf %s",
+	", fastest growing file %s (+%s/min)":                                ", am schnellsten wachsende Datei %s (+%s/min)",
+	"Disk warning: %.1f%% on %s":                                         "Festplatte Warnung: %.1f%% auf %s",
+	"Inode critical: %.1f%% on %s":                                       "Inodes kritisch: %.1f%% auf %s",
+	"High latency: %.1fms":                                               "Hohe Latenz: %.1fms",
+	"High latency: %.1fms via VPN %s, %.1fms direct (uplink is slow)":    "Hohe Latenz: %.1fms über VPN %s, %.1fms direkt (Uplink ist langsam)",
+	"High latency: %.1fms via VPN %s, %.1fms direct (VPN is slow)":       "Hohe Latenz: %.1fms über VPN %s, %.1fms direkt (VPN ist langsam)",
+	"Packet loss: %.0f%% of %d probes, jitter %.1fms":                    "Paketverlust: %.0f%% von %d Proben, Jitter %.1fms",
+	"Link %s flapped (%d carrier changes)":                               "Link %s instabil (%d Carrier-Wechsel)",
+	"Link %s negotiated %s, capable of %s":                               "Link %s hat %s ausgehandelt, kann %s",
+	"Link %s is running half duplex at %s":                               "Link %s läuft im Halbduplex mit %s",
+	"Link %s saturated: %.0f%% of %s for %d samples":                     "Link %s ausgelastet: %.0f%% von %s über %d Messungen",
+	"High Disk Read IO":                                                  "Hohe Lese-I/O auf der Festplatte",
+	"User %s is consuming %.0f%% of %s (%d processes)":                   "Benutzer %s verbraucht %.0f%% von %s (%d Prozesse)",
+	"Core dumps in %s: %s":                                               "Core-Dumps in %s: %s",
+	"Stale temp data in %s: %s":                                          "Veraltete temporäre Daten in %s: %s",
+	"OOM killer terminated %s (pid %d":                                   "OOM-Killer hat %s beendet (PID %d",
+	", cgroup %s":                                                        ", cgroup %s",
+	" and %d other process(es)":                                          " und %d weitere(n) Prozess(e)",
+	"Thermal pressure %s, CPU is being throttled (%.1f W package power)": "Thermischer Druck %s, CPU wird gedrosselt (%.1f W Package-Leistung)",
+	"Thermal pressure Moderate":                                          "Thermischer Druck mäßig",
+	"Under-voltage detected, check the power supply":                     "Unterspannung erkannt, Netzteil prüfen",
+	"Under-voltage occurred since boot":                                  "Unterspannung seit dem Start aufgetreten",
+	"CPU throttled by firmware":                                          "CPU von der Firmware gedrosselt",
+	"CPU frequency capped by firmware":                                   "CPU-Takt von der Firmware begrenzt",
+	"Container %s is using %.0f%% of CPU":                                "Container %s nutzt %.0f%% der CPU",
+	", mostly %s (pid %d, %.0f%%)":                                       ", hauptsächlich %s (PID %d, %.0f%%)",
+	"Check %s failed (exit %d)":                                          "Prüfung %s fehlgeschlagen (Exit-Code %d)",
+	"GPU %d (%s) critical: %.1f%% busy":                                  "GPU %d (%s) kritisch: %.1f%% ausgelastet",
+	"GPU %d (%s) warning: %.1f%% busy":                                   "GPU %d (%s) Warnung: %.1f%% ausgelastet",
+	"GPU %d (%s) memory critical: %.1f%% of %s":                          "GPU %d (%s) Speicher kritisch: %.1f%% von %s",
+	"GPU %d (%s) memory warning: %.1f%% of %s":                           "GPU %d (%s) Speicher Warnung: %.1f%% von %s",
+	" (+%d more)":                                                        " (+%d weitere)",
+
+	// Notes added after flagging
+	"largest dirs: %s":               "größte Verzeichnisse: %s",
+	" (partial scan)":                " (unvollständiger Scan)",
+	" [escalated L%d: %s active %s]": " [eskaliert L%d: %s aktiv seit %s]",
+	"available memory will hit zero in ~%s at current rate (-%s/min": "verfügbarer Speicher erreicht beim aktuellen Verlauf in ~%s null (-%s/min",
+	", swap-in %s": ", Swap-in %s",
+	"syschecker database at %.0f%% of its %s budget (%s used); the oldest snapshots are deleted beyond it": "syschecker-Datenbank bei %.0f%% ihres Budgets von %s (%s belegt); darüber hinaus werden die ältesten Snapshots gelöscht",
+	"%d minutes": "%d Minuten",
+	"%s is %.1fx its usual %s level (expected %.1f-%.1f, actual %.1f)": "%s liegt beim %.1f-fachen des üblichen Werts für %s (erwartet %.1f-%.1f, tatsächlich %.1f)",
+	"Disk":        "Festplatte",
+	"Inode usage": "Inode-Nutzung",
+	"Latency":     "Latenz",
+
+	// Flag labels
+	"Host offline":                "Host offline",
+	"CPU overloaded":              "CPU überlastet",
+	"Memory pressure":             "Speicherdruck",
+	"Memory starvation":           "Speichermangel",
+	"Swap thrashing":              "Swap-Thrashing",
+	"Disk space critical":         "Speicherplatz kritisch",
+	"Inode exhaustion":            "Inodes erschöpft",
+	"Disk I/O saturation":         "Festplatten-I/O ausgelastet",
+	"Disk health failed":          "Festplattenzustand fehlerhaft",
+	"Network latency degraded":    "Netzwerklatenz erhöht",
+	"Network packet loss":         "Paketverlust im Netzwerk",
+	"Network interface errors":    "Fehler an Netzwerkschnittstellen",
+	"Docker unavailable":          "Docker nicht verfügbar",
+	"Container CPU hog":           "Container belegt CPU",
+	"Container memory pressure":   "Speicherdruck im Container",
+	"Container OOM risk":          "OOM-Risiko im Container",
+	"Runaway process (CPU)":       "Außer Kontrolle geratener Prozess (CPU)",
+	"Runaway process (memory)":    "Außer Kontrolle geratener Prozess (Speicher)",
+	"Thermal pressure":            "Thermischer Druck",
+	"System at risk":              "System gefährdet",
+	"Memory exhaustion predicted": "Speichererschöpfung vorhergesagt",
+	"User resource hog":           "Benutzer belegt Ressourcen",
+	"Under-voltage":               "Unterspannung",
+	"Link degraded":               "Link beeinträchtigt",
+	"Link saturated":              "Link ausgelastet",
+	"Check failed":                "Prüfung fehlgeschlagen",
+	"Storage budget near":         "Speicherbudget fast erreicht",
+	"GPU overloaded":              "GPU überlastet",
+	"GPU memory pressure":         "GPU-Speicherdruck",
+}
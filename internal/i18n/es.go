@@ -0,0 +1,89 @@
+package i18n
+
+// es is the Spanish catalog.
+var es = map[string]string{
+	// Flag explanations
+	" of %.1f-core cgroup limit":                                         " del límite de cgroup de %.1f núcleos",
+	" of cgroup limit %s":                                                " del límite de cgroup de %s",
+	"CPU critical: %.1f%%%s":                                             "CPU crítica: %.1f%%%s",
+	"CPU warning: %.1f%%%s":                                              "CPU en advertencia: %.1f%%%s",
+	"RAM critical: %.1f%%%s":                                             "RAM crítica: %.1f%%%s",
+	"RAM warning: %.1f%%%s":                                              "RAM en advertencia: %.1f%%%s",
+	"Disk critical: %.1f%% on %s":                                        "Disco crítico: %.1f%% en %s",
+	", fastest growing file %s (+%s/min)":                                ", archivo de crecimiento más rápido %s (+%s/min)",
+	"Disk warning: %.1f%% on %s":                                         "Disco en advertencia: %.1f%% en %s",
+	"Inode critical: %.1f%% on %s":                                       "Inodos críticos: %.1f%% en %s",
+	"High latency: %.1fms":                                               "Latencia alta: %.1fms",
+	"High latency: %.1fms via VPN %s, %.1fms direct (uplink is slow)":    "Latencia alta: %.1fms por la VPN %s, %.1fms directa (el enlace de subida es lento)",
+	"High latency: %.1fms via VPN %s, %.1fms direct (VPN is slow)":       "Latencia alta: %.1fms por la VPN %s, %.1fms directa (la VPN es lenta)",
+	"Packet loss: %.0f%% of %d probes, jitter %.1fms":                    "Pérdida de paquetes: %.0f%% de %d sondeos, jitter %.1fms",
+	"Link %s flapped (%d carrier changes)":                               "El enlace %s es inestable (%d cambios de portadora)",
+	"Link %s negotiated %s, capable of %s":                               "El enlace %s negoció %s, admite %s",
+	"Link %s is running half duplex at %s":                               "El enlace %s funciona en semidúplex a %s",
+	"Link %s saturated: %.0f%% of %s for %d samples":                     "Enlace %s saturado: %.0f%% de %s durante %d muestras",
+	"High Disk Read IO":                                                  "E/S de lectura de disco alta",
+	"User %s is consuming %.0f%% of %s (%d processes)":                   "El usuario %s consume el %.0f%% de %s (%d procesos)",
+	"Core dumps in %s: %s":                                               "Volcados de memoria en %s: %s",
+	"Stale temp data in %s: %s":                                          "Datos temporales obsoletos en %s: %s",
+	"OOM killer terminated %s (pid %d":                                   "El OOM killer terminó %s (pid %d",
+	", cgroup %s":                                                        ", cgroup %s",
+	" and %d other process(es)":                                          " y %d proceso(s) más",
+	"Thermal pressure %s, CPU is being throttled (%.1f W package power)": "Presión térmica %s, la CPU está siendo limitada (%.1f W de potencia del paquete)",
+	"Thermal pressure Moderate":                                          "Presión térmica moderada",
+	"Under-voltage detected, check the power supply":                     "Subtensión detectada, revise la fuente de alimentación",
+	"Under-voltage occurred since boot":                                  "Hubo subtensión desde el arranque",
+	"CPU throttled by firmware":                                          "CPU limitada por el firmware",
+	"CPU frequency capped by firmware":                                   "Frecuencia de CPU limitada por el firmware",
+	"Container %s is using %.0f%% of CPU":                                "El contenedor %s usa el %.0f%% de la CPU",
+	", mostly %s (pid %d, %.0f%%)":                                       ", sobre todo %s (pid %d, %.0f%%)",
+	"Check %s failed (exit %d)":                                          "La comprobación %s falló (código de salida %d)",
+	"GPU %d (%s) critical: %.1f%% busy":                                  "GPU %d (%s) crítica: %.1f%% ocupada",
+	"GPU %d (%s) warning: %.1f%% busy":                                   "GPU %d (%s) en advertencia: %.1f%% ocupada",
+	"GPU %d (%s) memory critical: %.1f%% of %s":                          "Memoria de la GPU %d (%s) crítica: %.1f%% de %s",
+	"GPU %d (%s) memory warning: %.1f%% of %s":                           "Memoria de la GPU %d (%s) en advertencia: %.1f%% de %s",
+	" (+%d more)":                                                        " (+%d más)",
+
+	// Notes added after flagging
+	"largest dirs: %s":               "directorios más grandes: %s",
+	" (partial scan)":                " (análisis parcial)",
+	" [escalated L%d: %s active %s]": " [escalado N%d: %s activo desde hace %s]",
+	"available memory will hit zero in ~%s at current rate (-%s/min": "la memoria disponible llegará a cero en ~%s al ritmo actual (-%s/min",
+	", swap-in %s": ", swap-in %s",
+	"syschecker database at %.0f%% of its %s budget (%s used); the oldest snapshots are deleted beyond it": "base de datos de syschecker al %.0f%% de su presupuesto de %s (%s en uso); por encima se eliminan las instantáneas más antiguas",
+	"%d minutes": "%d minutos",
+	"%s is %.1fx its usual %s level (expected %.1f-%.1f, actual %.1f)": "%s está a %.1fx de su nivel habitual de %s (esperado %.1f-%.1f, real %.1f)",
+	"Disk":        "Disco",
+	"Inode usage": "Uso de inodos",
+	"Latency":     "Latencia",
+
+	// Flag labels
+	"Host offline":                "Host sin conexión",
+	"CPU overloaded":              "CPU sobrecargada",
+	"Memory pressure":             "Presión de memoria",
+	"Memory starvation":           "Memoria agotada",
+	"Swap thrashing":              "Hiperpaginación de swap",
+	"Disk space critical":         "Espacio en disco crítico",
+	"Inode exhaustion":            "Inodos agotados",
+	"Disk I/O saturation":         "E/S de disco saturada",
+	"Disk health failed":          "Fallo de salud del disco",
+	"Network latency degraded":    "Latencia de red degradada",
+	"Network packet loss":         "Pérdida de paquetes de red",
+	"Network interface errors":    "Errores de interfaz de red",
+	"Docker unavailable":          "Docker no disponible",
+	"Container CPU hog":           "Contenedor acaparando CPU",
+	"Container memory pressure":   "Presión de memoria en contenedor",
+	"Container OOM risk":          "Riesgo de OOM en contenedor",
+	"Runaway process (CPU)":       "Proceso desbocado (CPU)",
+	"Runaway process (memory)":    "Proceso desbocado (memoria)",
+	"Thermal pressure":            "Presión térmica",
+	"System at risk":              "Sistema en riesgo",
+	"Memory exhaustion predicted": "Agotamiento de memoria previsto",
+	"User resource hog":           "Usuario acaparando recursos",
+	"Under-voltage":               "Subtensión",
+	"Link degraded":               "Enlace degradado",
+	"Link saturated":              "Enlace saturado",
+	"Check failed":                "Comprobación fallida",
+	"Storage budget near":         "Presupuesto de almacenamiento casi agotado",
+	"GPU overloaded":              "GPU sobrecargada",
+	"GPU memory pressure":         "Presión de memoria en la GPU",
+}
@@ -0,0 +1,135 @@
+// Package i18n translates operator-facing text. Messages are keyed by their
+// English format string, so English needs no catalog and untranslated
+// messages fall back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvLang selects the locale when no flag is given; LC_ALL, LC_MESSAGES and
+// LANG are consulted after it.
+const EnvLang = "SYSCHECKER_LANG"
+
+// English is the source language of every message.
+const English = "en"
+
+// locale is one language's catalog.
+type locale struct {
+	name     string            // English name of the language, for LLM prompts
+	messages map[string]string // English format -> translated format
+}
+
+var locales = map[string]locale{
+	English: {name: "English"},
+	"de":    {name: "German", messages: de},
+	"es":    {name: "Spanish", messages: es},
+}
+
+// Supported returns the available locale codes, sorted.
+func Supported() []string {
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Normalize reduces a locale such as "de_DE.UTF-8" to a supported language
+// code, falling back to English.
+func Normalize(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := locales[lang]; ok {
+		return lang
+	}
+	return English
+}
+
+// FromEnv returns the locale requested by the environment.
+func FromEnv() string {
+	for _, key := range []string{EnvLang, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" && v != "C" && v != "POSIX" {
+			return Normalize(v)
+		}
+	}
+	return English
+}
+
+// LanguageName returns the English name of a locale, e.g. "German".
+func LanguageName(lang string) string {
+	return locales[Normalize(lang)].name
+}
+
+// Printer formats messages in one locale. The zero value and nil print English.
+type Printer struct {
+	messages map[string]string
+}
+
+// NewPrinter returns a printer for lang; unsupported locales print English.
+func NewPrinter(lang string) *Printer {
+	return &Printer{messages: locales[Normalize(lang)].messages}
+}
+
+// Sprintf formats the translation of format, or format itself when it has none.
+func (p *Printer) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(p.Text(format), args...)
+}
+
+// Text returns the translation of msg, or msg itself when it has none.
+func (p *Printer) Text(msg string) string {
+	if p != nil {
+		if t, ok := p.messages[msg]; ok {
+			return t
+		}
+	}
+	return msg
+}
+
+// flagLabels are the English display names of snapshot flags.
+var flagLabels = map[string]string{
+	"host_offline":                "Host offline",
+	"cpu_overloaded":              "CPU overloaded",
+	"memory_pressure":             "Memory pressure",
+	"memory_starvation":           "Memory starvation",
+	"swap_thrashing":              "Swap thrashing",
+	"disk_space_critical":         "Disk space critical",
+	"inode_exhaustion":            "Inode exhaustion",
+	"disk_io_saturation":          "Disk I/O saturation",
+	"disk_health_failed":          "Disk health failed",
+	"network_latency_degraded":    "Network latency degraded",
+	"network_packet_loss":         "Network packet loss",
+	"network_interface_errors":    "Network interface errors",
+	"docker_unavailable":          "Docker unavailable",
+	"container_cpu_hog":           "Container CPU hog",
+	"container_memory_pressure":   "Container memory pressure",
+	"container_oom_risk":          "Container OOM risk",
+	"runaway_process_cpu":         "Runaway process (CPU)",
+	"runaway_process_memory":      "Runaway process (memory)",
+	"thermal_pressure":            "Thermal pressure",
+	"system_at_risk":              "System at risk",
+	"memory_exhaustion_predicted": "Memory exhaustion predicted",
+	"user_resource_hog":           "User resource hog",
+	"under_voltage":               "Under-voltage",
+	"link_degraded":               "Link degraded",
+	"link_saturated":              "Link saturated",
+	"check_failed":                "Check failed",
+	"storage_budget":              "Storage budget near",
+	"gpu_overloaded":              "GPU overloaded",
+	"gpu_memory_pressure":         "GPU memory pressure",
+}
+
+// FlagLabel returns the display name of a flag such as "cpu_overloaded",
+// or the flag itself when it has none.
+func (p *Printer) FlagLabel(flag string) string {
+	if label, ok := flagLabels[flag]; ok {
+		return p.Text(label)
+	}
+	return flag
+}
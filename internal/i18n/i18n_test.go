@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+)
+
+var verbRE = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+// sampleArgs returns one argument per verb of format.
+func sampleArgs(format string) []any {
+	var args []any
+	for _, v := range verbRE.FindAllString(format, -1) {
+		switch v[len(v)-1] {
+		case '%':
+		case 'd':
+			args = append(args, 1)
+		case 'f':
+			args = append(args, 1.5)
+		default:
+			args = append(args, "x")
+		}
+	}
+	return args
+}
+
+func TestCatalogsMatchVerbs(t *testing.T) {
+	for code, l := range locales {
+		for en, tr := range l.messages {
+			args := sampleArgs(en)
+			if got := fmt.Sprintf(tr, args...); strings.Contains(got, "%!") {
+				t.Errorf("%s: %q does not take the arguments of %q: %s", code, tr, en, got)
+			}
+		}
+	}
+}
+
+func TestFlagLabelsCoverFlagNames(t *testing.T) {
+	for _, flag := range relational.FlagNames {
+		if _, ok := flagLabels[flag]; !ok {
+			t.Errorf("no label for flag %s", flag)
+		}
+		for code, l := range locales {
+			if code != English {
+				if _, ok := l.messages[flagLabels[flag]]; !ok {
+					t.Errorf("%s: no translation of the %s label", code, flag)
+				}
+			}
+		}
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	if got := Normalize("de_DE.UTF-8"); got != "de" {
+		t.Errorf("Normalize(de_DE.UTF-8) = %q", got)
+	}
+	if got := Normalize("fr"); got != English {
+		t.Errorf("unsupported locale normalized to %q", got)
+	}
+
+	de := NewPrinter("de")
+	if got := de.Sprintf("CPU critical: %.1f%%%s", 95.0, ""); got != "CPU kritisch: 95.0%" {
+		t.Errorf("German CPU explanation = %q", got)
+	}
+	if got := de.Sprintf("untranslated %d", 3); got != "untranslated 3" {
+		t.Errorf("fallback = %q", got)
+	}
+	if got := de.FlagLabel("cpu_overloaded"); got != "CPU überlastet" {
+		t.Errorf("German label = %q", got)
+	}
+	var none *Printer
+	if got := none.FlagLabel("cpu_overloaded"); got != "CPU overloaded" {
+		t.Errorf("nil printer label = %q", got)
+	}
+}
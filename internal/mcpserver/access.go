@@ -0,0 +1,156 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Role is a caller's level of access to tools. Each role includes the ones
+// below it.
+type Role int
+
+const (
+	RoleReader  Role = iota // metric, history and graph read tools
+	RoleAnalyst             // also ask_syschecker, capture_forensics and switching profiles
+	RoleAdmin               // also graph writes and the admin tools
+)
+
+var roleNames = []string{"reader", "analyst", "admin"}
+
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// ParseRole parses a role name such as "analyst".
+func ParseRole(name string) (Role, error) {
+	for i, n := range roleNames {
+		if strings.EqualFold(strings.TrimSpace(name), n) {
+			return Role(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q (want one of %s)", name, strings.Join(roleNames, ", "))
+}
+
+// Environment variables read by AccessFromEnv.
+const (
+	// EnvRole is the role of calls that present no token, e.g. "analyst"
+	// for a trusted local stdio client. Default: reader.
+	EnvRole = "SYSCHECKER_MCP_ROLE"
+	// EnvTokens maps tokens to roles as "token=role,token=role".
+	EnvTokens = "SYSCHECKER_MCP_TOKENS"
+)
+
+// metaToken is the _meta key a client puts its token in.
+const metaToken = "token"
+
+// Access decides which role a tool call runs with. A call presents a token
+// in its _meta.token field or, over HTTP, an "Authorization: Bearer" header;
+// calls without one get DefaultRole.
+type Access struct {
+	Tokens      map[string]Role
+	DefaultRole Role
+}
+
+// AccessFromEnv reads $SYSCHECKER_MCP_ROLE and $SYSCHECKER_MCP_TOKENS.
+func AccessFromEnv() (*Access, error) {
+	a := &Access{Tokens: make(map[string]Role)}
+	if v := os.Getenv(EnvRole); v != "" {
+		role, err := ParseRole(v)
+		if err != nil {
+			return nil, fmt.Errorf("$%s: %w", EnvRole, err)
+		}
+		a.DefaultRole = role
+	}
+	for _, pair := range strings.Split(os.Getenv(EnvTokens), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		token, name, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(token) == "" {
+			return nil, fmt.Errorf("$%s: want token=role, got %q", EnvTokens, pair)
+		}
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("$%s: %w", EnvTokens, err)
+		}
+		a.Tokens[strings.TrimSpace(token)] = role
+	}
+	return a, nil
+}
+
+// role returns the caller's role, or an error for an unknown token.
+func (a *Access) role(req *mcp.CallToolRequest) (Role, error) {
+	token := requestToken(req)
+	if token == "" {
+		return a.DefaultRole, nil
+	}
+	role, ok := a.Tokens[token]
+	if !ok {
+		return 0, fmt.Errorf("invalid token")
+	}
+	return role, nil
+}
+
+func requestToken(req *mcp.CallToolRequest) string {
+	if req == nil {
+		return ""
+	}
+	if req.Params != nil {
+		if t, ok := req.Params.Meta[metaToken].(string); ok && t != "" {
+			return t
+		}
+	}
+	if req.Extra != nil && req.Extra.Header != nil {
+		if t, ok := strings.CutPrefix(req.Extra.Header.Get("Authorization"), "Bearer "); ok {
+			return strings.TrimSpace(t)
+		}
+	}
+	return ""
+}
+
+// authorize returns an error unless the call may use a tool needing role
+// need. A server without an Access policy allows everything.
+func (s *Server) authorize(req *mcp.CallToolRequest, tool string, need Role) error {
+	if s.access == nil {
+		return nil
+	}
+	role, err := s.access.role(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", tool, err)
+	}
+	if role < need {
+		return fmt.Errorf("%s needs the %s role, caller is %s; pass a token in _meta.%s or set $%s", tool, need, role, metaToken, EnvRole)
+	}
+	return nil
+}
+
+// guard wraps a tool handler so it only runs for callers with role need.
+func guard[In, Out any](s *Server, tool string, need Role, h func(context.Context, *mcp.CallToolRequest, In) (*mcp.CallToolResult, Out, error)) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, Out, error) {
+		if err := s.authorize(req, tool, need); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		return h(ctx, req, in)
+	}
+}
+
+var (
+	cypherLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|` + "`[^`]*`")
+	cypherWrite   = regexp.MustCompile(`(?i)\b(CREATE|MERGE|DELETE|DETACH|SET|REMOVE|DROP|FOREACH|LOAD\s+CSV)\b|\bCALL\s+(apoc|db|dbms|gds)\.`)
+)
+
+// isCypherWrite reports whether a query may modify the graph. Keywords
+// inside string literals and quoted names are ignored; anything else that
+// looks like a write is treated as one.
+func isCypherWrite(query string) bool {
+	return cypherWrite.MatchString(cypherLiteral.ReplaceAllString(query, ""))
+}
@@ -0,0 +1,94 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func callWithToken(token string) *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Meta: mcp.Meta{"token": token}}}
+}
+
+func TestAccessFromEnv(t *testing.T) {
+	t.Setenv(EnvRole, "analyst")
+	t.Setenv(EnvTokens, "s3cret=admin, ro=reader")
+	a, err := AccessFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DefaultRole != RoleAnalyst || a.Tokens["s3cret"] != RoleAdmin || a.Tokens["ro"] != RoleReader {
+		t.Errorf("access = %+v", a)
+	}
+
+	t.Setenv(EnvTokens, "s3cret=root")
+	if _, err := AccessFromEnv(); err == nil {
+		t.Error("Expected error for unknown role")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	s := &Server{access: &Access{Tokens: map[string]Role{"adm": RoleAdmin, "ana": RoleAnalyst}}}
+
+	if err := s.authorize(nil, "get_realtime_metrics", RoleReader); err != nil {
+		t.Errorf("read tools should stay open: %v", err)
+	}
+	if err := s.authorize(nil, "ask_syschecker", RoleAnalyst); err == nil || !strings.Contains(err.Error(), "analyst role") {
+		t.Errorf("ask without a token = %v, want role error", err)
+	}
+	if err := s.authorize(callWithToken("ana"), "ask_syschecker", RoleAnalyst); err != nil {
+		t.Errorf("analyst token: %v", err)
+	}
+	if err := s.authorize(callWithToken("ana"), "set_threshold", RoleAdmin); err == nil {
+		t.Error("analyst must not use admin tools")
+	}
+	if err := s.authorize(callWithToken("nope"), "get_realtime_metrics", RoleReader); err == nil {
+		t.Error("Expected error for an invalid token")
+	}
+
+	bearer := &mcp.CallToolRequest{Extra: &mcp.RequestExtra{Header: http.Header{"Authorization": {"Bearer adm"}}}}
+	if err := s.authorize(bearer, "set_threshold", RoleAdmin); err != nil {
+		t.Errorf("bearer admin token: %v", err)
+	}
+}
+
+func TestQueryGraphWritesNeedAdmin(t *testing.T) {
+	mock := &MockGraphClient{}
+	s := &Server{neo4jClient: mock, access: &Access{Tokens: map[string]Role{"adm": RoleAdmin}}}
+	ctx := context.Background()
+
+	if _, _, err := s.handleQueryGraph(ctx, nil, QueryGraphArgs{Cypher: "MATCH (h:Host {note: 'DELETE me'}) RETURN h"}); err != nil {
+		t.Errorf("read query rejected: %v", err)
+	}
+	if mock.LastOpts.Write {
+		t.Error("read query ran in a write transaction")
+	}
+	write := QueryGraphArgs{Cypher: "MATCH (h:Host) DETACH DELETE h"}
+	if _, _, err := s.handleQueryGraph(ctx, nil, write); err == nil {
+		t.Error("write query without a token should be rejected")
+	}
+	if _, _, err := s.handleQueryGraph(ctx, callWithToken("adm"), write); err != nil {
+		t.Errorf("admin write query: %v", err)
+	}
+	if !mock.LastOpts.Write {
+		t.Error("admin write query ran in a read transaction, which Neo4j rejects")
+	}
+}
+
+func TestIsCypherWrite(t *testing.T) {
+	for query, want := range map[string]bool{
+		"MATCH (n) RETURN n LIMIT 5":                     false,
+		"MATCH (s:Snapshot) WHERE s.offset > 3 RETURN s": false,
+		"MATCH (n) SET n.x = 1":                          true,
+		"merge (h:Host {name: 'a'})":                     true,
+		"CALL apoc.periodic.iterate('x', 'y', {})":       true,
+		"MATCH (f:Flag {name: \"CREATE\"}) RETURN f":     false,
+	} {
+		if got := isCypherWrite(query); got != want {
+			t.Errorf("isCypherWrite(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
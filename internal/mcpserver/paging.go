@@ -0,0 +1,219 @@
+package mcpserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"syschecker/internal/collector"
+	"syschecker/internal/database/relational"
+)
+
+// Result size limits. Tools return at most one page of items; a page is
+// cut short when its JSON would exceed maxResultBytes, and the remaining
+// items are reached with the returned next_cursor.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	maxPageOffset   = 10000
+	maxResultBytes  = 256 << 10
+)
+
+// pageSize clamps a requested page size, with def for zero.
+func pageSize(limit, def int) int {
+	switch {
+	case limit <= 0:
+		return def
+	case limit > maxPageSize:
+		return maxPageSize
+	}
+	return limit
+}
+
+// queryKey fingerprints a tool's arguments, so a cursor is only accepted
+// for the query that produced it. args should have its cursor cleared.
+func queryKey(tool string, args any) string {
+	data, _ := json.Marshal(args)
+	h := fnv.New32a()
+	h.Write([]byte(tool))
+	h.Write(data)
+	return strconv.FormatUint(uint64(h.Sum32()), 36)
+}
+
+func encodeCursor(key string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key + ":" + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset a cursor points at; "" is the first page.
+func decodeCursor(key, cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	k, off, ok := strings.Cut(string(raw), ":")
+	offset, err := strconv.Atoi(off)
+	if !ok || err != nil || offset < 0 || offset > maxPageOffset {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	if k != key {
+		return 0, fmt.Errorf("cursor belongs to a different query; repeat the original arguments with it")
+	}
+	return offset, nil
+}
+
+// paginate returns the page of items starting at cursor with at most limit
+// items and maxResultBytes of JSON, and the cursor of the next page, or ""
+// on the last page. items must start at the first page; callers that query
+// a database fetch offset+limit+1 rows so the next page can be detected.
+func paginate[T any](items []T, key, cursor string, limit int) ([]T, string, error) {
+	offset, err := decodeCursor(key, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if offset >= len(items) {
+		return []T{}, "", nil
+	}
+	page := fitPage(items[offset:min(offset+limit, len(items))])
+
+	next := ""
+	if end := offset + len(page); end < len(items) && end <= maxPageOffset {
+		next = encodeCursor(key, end)
+	}
+	return page, next, nil
+}
+
+// fitPage cuts page short once its JSON would exceed maxResultBytes,
+// always keeping the first item.
+func fitPage[T any](page []T) []T {
+	size := 0
+	for i, item := range page {
+		data, _ := json.Marshal(item)
+		if size += len(data) + 1; size > maxResultBytes && i > 0 {
+			return page[:i]
+		}
+	}
+	return page
+}
+
+// keyCursor encodes the position of the last row of a page in a table read
+// newest first by (at, id). Unlike offsets, it stays valid while new rows
+// are inserted ahead of it, so pages neither repeat nor skip rows.
+func keyCursor(key string, at time.Time, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key + ":" + strconv.FormatInt(at.UnixNano(), 10) + "." + strconv.FormatInt(id, 10)))
+}
+
+// decodeKeyCursor returns the position a keyCursor points after, or the
+// zero time for "", the first page.
+func decodeKeyCursor(key, cursor string) (time.Time, int64, error) {
+	if cursor == "" {
+		return time.Time{}, 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	k, pos, found := strings.Cut(string(raw), ":")
+	nanos, idText, dot := strings.Cut(pos, ".")
+	ns, err1 := strconv.ParseInt(nanos, 10, 64)
+	id, err2 := strconv.ParseInt(idText, 10, 64)
+	if !found || !dot || err1 != nil || err2 != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	if k != key {
+		return time.Time{}, 0, fmt.Errorf("cursor belongs to a different query; repeat the original arguments with it")
+	}
+	return time.Unix(0, ns).UTC(), id, nil
+}
+
+// pageFetchLimit is how many rows a database-backed tool fetches to serve
+// the page at cursor: everything before it, the page, and one more to tell
+// whether another page follows.
+func pageFetchLimit(key, cursor string, limit int) (int, error) {
+	offset, err := decodeCursor(key, cursor)
+	if err != nil {
+		return 0, err
+	}
+	return offset + limit + 1, nil
+}
+
+// statsFields are the top-level fields of the structs trimStats accepts,
+// by lower-cased name.
+var statsFields = map[reflect.Type]map[string]int{
+	reflect.TypeFor[collector.RawStats]():       fieldIndex(reflect.TypeFor[collector.RawStats]()),
+	reflect.TypeFor[relational.RawStatsFixed](): fieldIndex(reflect.TypeFor[relational.RawStatsFixed]()),
+}
+
+func fieldIndex(t reflect.Type) map[string]int {
+	m := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			m[strings.ToLower(t.Field(i).Name)] = i
+		}
+	}
+	return m
+}
+
+// trimStats returns a copy of stats, a RawStats or RawStatsFixed, with only
+// fields kept, or all when fields is empty, and every list cut to limit
+// items. truncated maps each cut list to its full length.
+func trimStats[T collector.RawStats | relational.RawStatsFixed](stats *T, fields []string, limit int) (trimmed *T, selected map[string]any, truncated map[string]int, err error) {
+	index := statsFields[reflect.TypeFor[T]()]
+	keep := make(map[int]bool, len(fields))
+	for _, f := range fields {
+		i, ok := index[strings.ToLower(f)]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("unknown field %q", f)
+		}
+		keep[i] = true
+	}
+
+	cp := *stats
+	v := reflect.ValueOf(&cp).Elem()
+	for i := range v.NumField() {
+		field := v.Field(i)
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		if len(keep) > 0 && !keep[i] {
+			field.SetZero()
+			continue
+		}
+		if field.Kind() == reflect.Slice && field.Len() > limit {
+			if truncated == nil {
+				truncated = make(map[string]int)
+			}
+			truncated[v.Type().Field(i).Name] = field.Len()
+			field.Set(field.Slice(0, limit))
+		}
+		if len(keep) > 0 {
+			if selected == nil {
+				selected = make(map[string]any, len(keep))
+			}
+			selected[v.Type().Field(i).Name] = field.Interface()
+		}
+	}
+	return &cp, selected, truncated, nil
+}
+
+// truncationNote describes lists trimmed by trimStats.
+func truncationNote(truncated map[string]int, limit int) string {
+	names := make([]string, 0, len(truncated))
+	for name := range truncated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d of %d)", name, limit, truncated[name])
+	}
+	return "Lists cut to the first " + strconv.Itoa(limit) + " items: " + strings.Join(parts, ", ") + ". Raise limit or select fields to see more."
+}
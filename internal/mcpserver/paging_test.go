@@ -0,0 +1,160 @@
+package mcpserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"syschecker/internal/collector"
+)
+
+func TestPaginate(t *testing.T) {
+	items := make([]int, 12)
+	for i := range items {
+		items[i] = i
+	}
+	key := queryKey("test", "args")
+
+	var got []int
+	cursor := ""
+	for pages := 0; ; pages++ {
+		page, next, err := paginate(items, key, cursor, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, page...)
+		if next == "" {
+			if pages != 2 {
+				t.Errorf("pages = %d, want 3", pages+1)
+			}
+			break
+		}
+		cursor = next
+	}
+	if len(got) != 12 || got[11] != 11 {
+		t.Errorf("items = %v", got)
+	}
+
+	if _, _, err := paginate(items, queryKey("test", "other"), encodeCursor(key, 5), 5); err == nil {
+		t.Error("Expected error for a cursor from another query")
+	}
+	if _, _, err := paginate(items, key, "garbage!", 5); err == nil {
+		t.Error("Expected error for a malformed cursor")
+	}
+}
+
+func TestPaginateCapsBytes(t *testing.T) {
+	big := strings.Repeat("x", maxResultBytes/3)
+	items := []string{big, big, big, big}
+	page, next, err := paginate(items, "k", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || next == "" {
+		t.Errorf("page of %d items, next %q; want 2 items and a cursor", len(page), next)
+	}
+}
+
+func TestTrimStats(t *testing.T) {
+	stats := &collector.RawStats{CPUUsage: 42, Hostname: "h", TopProcesses: make([]collector.ProcessStat, 8)}
+
+	trimmed, selected, truncated, err := trimStats(stats, []string{"cpuusage", "TopProcesses"}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trimmed.CPUUsage != 42 || trimmed.Hostname != "" || len(trimmed.TopProcesses) != 3 {
+		t.Errorf("trimmed = %+v", trimmed)
+	}
+	if len(selected) != 2 || truncated["TopProcesses"] != 8 {
+		t.Errorf("selected = %v, truncated = %v", selected, truncated)
+	}
+	if len(stats.TopProcesses) != 8 {
+		t.Error("trimStats modified its input")
+	}
+	if _, _, _, err := trimStats(stats, []string{"gpu"}, 3); err == nil {
+		t.Error("Expected error for an unknown field")
+	}
+}
+
+func TestHandleGetRealtimeMetrics_Fields(t *testing.T) {
+	s := &Server{sensorProvider: &MockStatsProvider{FastStats: &collector.RawStats{
+		CPUUsage: 12, Hostname: "h", DockerContainers: make([]collector.DockerContainerInfo, 4),
+	}}}
+	res, stats, err := s.handleGetRealtimeMetrics(context.Background(), nil, MetricsArgs{Fields: []string{"CPUUsage", "DockerContainers"}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CPUUsage != 12 || stats.Hostname != "" || len(stats.DockerContainers) != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if res == nil || len(res.Content) != 2 {
+		t.Fatalf("Expected the selected fields and a truncation note, got %+v", res)
+	}
+	text := contentText(res.Content[0])
+	if !strings.Contains(text, `"CPUUsage":12`) || strings.Contains(text, "Hostname") {
+		t.Errorf("text = %s", text)
+	}
+	if note := contentText(res.Content[1]); !strings.Contains(note, "DockerContainers (2 of 4)") {
+		t.Errorf("note = %s", note)
+	}
+}
+
+func contentText(c mcp.Content) string {
+	if tc, ok := c.(*mcp.TextContent); ok {
+		return tc.Text
+	}
+	return ""
+}
+
+func TestHandleQueryGraph_Pages(t *testing.T) {
+	rows := make([]map[string]any, 7)
+	for i := range rows {
+		rows[i] = map[string]any{"i": i}
+	}
+	mock := &MockGraphClient{CypherResult: rows}
+	s := &Server{neo4jClient: mock}
+	ctx := context.Background()
+	args := QueryGraphArgs{Cypher: "MATCH (n) RETURN n", Limit: 5}
+
+	_, first, err := s.handleQueryGraph(ctx, nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Data.([]map[string]any)) != 5 || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if mock.LastOpts.MaxRows != 6 {
+		t.Errorf("read %d rows for the first page, want the page and one more", mock.LastOpts.MaxRows)
+	}
+	args.Cursor = first.NextCursor
+	_, second, err := s.handleQueryGraph(ctx, nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := second.Data.([]map[string]any); len(page) != 2 || page[0]["i"] != 5 || second.NextCursor != "" {
+		t.Errorf("second page = %+v", second)
+	}
+
+	args.Cypher = "MATCH (h:Host) RETURN h"
+	if _, _, err := s.handleQueryGraph(ctx, nil, args); err == nil {
+		t.Error("Expected error when reusing a cursor with another query")
+	}
+}
+
+func TestKeyCursorRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 5, time.UTC)
+	cursor := keyCursor("k", at, 42)
+	gotAt, gotID, err := decodeKeyCursor("k", cursor)
+	if err != nil || !gotAt.Equal(at) || gotID != 42 {
+		t.Errorf("decodeKeyCursor = %v, %d, %v", gotAt, gotID, err)
+	}
+	if _, _, err := decodeKeyCursor("other", cursor); err == nil {
+		t.Error("a cursor from another query should be rejected")
+	}
+	if _, _, err := decodeKeyCursor("k", "bm90LWEtY3Vyc29y"); err == nil {
+		t.Error("a malformed cursor should be rejected")
+	}
+}
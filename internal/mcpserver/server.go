@@ -2,21 +2,38 @@ package mcpserver
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"google.golang.org/api/option"
 
+	"syschecker/internal/actions"
+	"syschecker/internal/buildinfo"
+	"syschecker/internal/chaos"
 	"syschecker/internal/collector"
+	"syschecker/internal/config"
+	"syschecker/internal/database"
 	"syschecker/internal/database/graph"
 	"syschecker/internal/database/rag"
 	"syschecker/internal/database/relational"
+	"syschecker/internal/digest"
 	"syschecker/internal/flagger"
+	"syschecker/internal/health"
 	"syschecker/internal/output"
+	"syschecker/internal/remediate"
+	"syschecker/internal/schedule"
+	"syschecker/internal/schema"
+	"syschecker/internal/slo"
 )
 
 // Server wraps the MCP server with SysChecker capabilities.
@@ -28,23 +45,130 @@ type Server struct {
 	neo4jClient    graph.GraphClient
 	geminiClient   *genai.Client
 	flaggerSvc     *flagger.FlaggerService
+	scheduler      *schedule.Scheduler
+	admin          bool
+	access         *Access
+	graphPool      *database.GraphIngestPool
+	agentID        string
+	lease          *database.LeaseKeeper
+	executor       *actions.Executor
+	slos           *slo.Tracker
+	configPath     string
+	version        string
+	startedAt      time.Time
+	readOnly       bool
+
+	// Connection checks of Neo4j and DuckDB; nil monitor in tests
+	health       *health.Monitor
+	healthCancel context.CancelFunc
+	healthDone   chan struct{}
+
+	// Slow metrics reused by metric_type merged
+	slowMu    sync.Mutex
+	slowStats *collector.RawStats
+	slowAt    time.Time
 
 	// Data ingestion background worker
 	ingestMu     sync.Mutex
 	ingestCancel context.CancelFunc
 	ingestWg     sync.WaitGroup
+
+	// Tool calls in flight, drained on shutdown
+	callsMu         sync.Mutex
+	draining        bool
+	calls           sync.WaitGroup
+	shutdownTimeout time.Duration
+
+	// Periodic digest job; nil cancel when disabled
+	digestCancel context.CancelFunc
+	digestDone   chan struct{}
 }
 
 // Config holds configuration for the MCP server.
 type Config struct {
 	ServerName    string
-	ServerVersion string
+	ServerVersion string // default: the build's version
 	GeminiAPIKey  string
 	GeminiModel   string // Model key: flash, pro, flash-8b, experimental
 	Neo4jURI      string
 	Neo4jUser     string
 	Neo4jPassword string
 	Neo4jDatabase string
+
+	// Neo4jOptions set token auth or a CA bundle for managed deployments
+	// such as Aura; see graph.Neo4jOptionsFromEnv.
+	Neo4jOptions []graph.Neo4jOption
+
+	// Topology, when set, is written to the graph at startup so root-cause
+	// questions can follow service dependencies across hosts.
+	Topology *graph.Topology
+
+	// Language is the locale of flag explanations and answers, e.g. "de";
+	// empty means English.
+	Language string
+
+	// Scheduler times background ingestion and is switched by the
+	// set_collection_profile tool. When nil the server uses its own, with
+	// a 30s default interval.
+	Scheduler *schedule.Scheduler
+
+	// Flagger flags ingested snapshots. When nil the server uses its own
+	// with the default config; pass one to change its config at runtime.
+	Flagger *flagger.FlaggerService
+
+	// Admin registers the set_collection_interval, toggle_sensor and
+	// set_threshold tools, which change the running system.
+	Admin bool
+
+	// Access maps callers to roles. Expensive and state-changing tools need
+	// the analyst or admin role; nil gives callers without a token reader
+	// access only.
+	Access *Access
+
+	// AgentID is the host snapshots are stored under. Give it the ID of an
+	// agent collecting this host into the same database (its hostname) so
+	// the two share an ingest lease and only one stores snapshots; empty
+	// means "mcp-server".
+	AgentID string
+
+	// GraphBuffer keeps snapshots that could not be ingested while Neo4j
+	// was unreachable and replays them on the next ingest. When nil they
+	// are dropped.
+	GraphBuffer *database.GraphBuffer
+
+	// GraphIngestBatch is how many queued snapshots, of any hosts, are
+	// written to Neo4j in one transaction; 0 or 1 writes each on its own.
+	GraphIngestBatch int
+
+	// Actions runs the remediation actions of execute_action, within its
+	// allow-list. When nil the tool is not registered.
+	Actions *actions.Executor
+
+	// SLOs are the objectives get_slo_status evaluates. When nil the tool
+	// reports that none are defined.
+	SLOs *slo.Tracker
+
+	// DigestPeriod, when positive, makes the server write a health digest
+	// of the stored rollups and incidents this often, stored as a digest
+	// artifact.
+	DigestPeriod time.Duration
+
+	// DigestDelivery also sends each digest on, e.g. to Slack. Optional.
+	DigestDelivery digest.Deliverer
+
+	// ConfigPath is the config file watched for changes. With Admin set,
+	// the edit_thresholds tool writes threshold changes to it; empty
+	// leaves the tool off.
+	ConfigPath string
+
+	// ShutdownTimeout is how long tool calls in flight may run once
+	// Start's context is done; zero means DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	// ReadOnly serves a database the server cannot write, such as one
+	// attached with relational.OpenRemote: nothing is collected into it or
+	// checkpointed, and tools storing artifacts fail.
+	ReadOnly bool
 }
 
 // NewServer creates a new MCP server instance.
@@ -58,7 +182,7 @@ func NewServer(cfg Config, repo *relational.Repo, sensorProvider collector.Stats
 	}
 
 	// Initialize Neo4j client
-	neo4jClient, err := graph.NewNeo4jClient(cfg.Neo4jURI, cfg.Neo4jUser, cfg.Neo4jPassword, cfg.Neo4jDatabase)
+	neo4jClient, err := graph.NewNeo4jClient(cfg.Neo4jURI, cfg.Neo4jUser, cfg.Neo4jPassword, cfg.Neo4jDatabase, cfg.Neo4jOptions...)
 	if err != nil {
 		geminiClient.Close()
 		return nil, fmt.Errorf("failed to create neo4j client: %w", err)
@@ -70,19 +194,31 @@ func NewServer(cfg Config, repo *relational.Repo, sensorProvider collector.Stats
 		modelKey = "pro" // Default to pro for best reasoning
 	}
 	fmt.Fprintf(os.Stderr, "Using Gemini model: %s\n", modelKey)
-	ragEngine := rag.NewGraphRAGEngine(neo4jClient, geminiClient, modelKey)
+	ragEngine := rag.NewGraphRAGEngine(neo4jClient, geminiClient, modelKey, rag.WithHostComparer(repo), rag.WithPercentiles(repo), rag.WithLanguage(cfg.Language))
 
 	// Initialize Flagger service for data pipeline
-	flaggerCfg := flagger.DefaultConfig()
-	flaggerSvc := flagger.NewFlaggerService(flaggerCfg)
+	flaggerSvc := cfg.Flagger
+	if flaggerSvc == nil {
+		flaggerSvc = flagger.NewFlaggerService(flagger.DefaultConfig().WithLocale(cfg.Language))
+	}
 
 	// Create MCP server with Implementation
 	impl := &mcp.Implementation{
 		Name:    cfg.ServerName,
 		Version: cfg.ServerVersion,
 	}
+	if impl.Version == "" {
+		impl.Version = buildinfo.Get().Version
+	}
 	mcpServer := mcp.NewServer(impl, nil)
 
+	scheduler := cfg.Scheduler
+	if scheduler == nil {
+		scheduler = schedule.NewScheduler(schedule.WithProfiles(schedule.Profile{
+			Name: schedule.Default, Description: "normal collection", Fast: time.Second, Slow: 30 * time.Second,
+		}))
+	}
+
 	s := &Server{
 		mcpServer:      mcpServer,
 		ragEngine:      ragEngine,
@@ -91,21 +227,85 @@ func NewServer(cfg Config, repo *relational.Repo, sensorProvider collector.Stats
 		neo4jClient:    neo4jClient,
 		geminiClient:   geminiClient,
 		flaggerSvc:     flaggerSvc,
+		scheduler:      scheduler,
+		admin:          cfg.Admin,
+		executor:       cfg.Actions,
+		slos:           cfg.SLOs,
+		configPath:     cfg.ConfigPath,
+		readOnly:       cfg.ReadOnly,
+		version:        impl.Version,
+		startedAt:      time.Now(),
+		access:         cfg.Access,
+		graphPool:      database.NewGraphIngestPool(neo4jClient, database.WithIngestBuffer(cfg.GraphBuffer), database.WithIngestBatch(cfg.GraphIngestBatch)),
+	}
+	if s.access == nil {
+		s.access = &Access{}
+	}
+	s.shutdownTimeout = cfg.ShutdownTimeout
+	if s.shutdownTimeout <= 0 {
+		s.shutdownTimeout = DefaultShutdownTimeout
+	}
+	mcpServer.AddReceivingMiddleware(s.trackCalls)
+	s.agentID = cfg.AgentID
+	if s.agentID == "" {
+		s.agentID = "mcp-server"
+	}
+	if !s.readOnly {
+		s.lease = database.NewLeaseKeeper(repo, s.agentID, relational.LeaseHolder("mcp"))
 	}
 
-	// Register tools
+	// Register tools and resources
 	s.registerTools()
+	s.registerResources()
 
-	// Ingest initial data into Neo4j so RAG has something to query
-	fmt.Fprintf(os.Stderr, "Ingesting initial system snapshot into Neo4j...\n")
-	if err := s.ingestSnapshot(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: initial ingest failed: %v\n", err)
+	if cfg.Topology != nil {
+		if err := neo4jClient.SyncTopology(ctx, cfg.Topology); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: topology sync failed: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "✓ %d service dependencies written to Neo4j\n", len(cfg.Topology.Dependencies))
+		}
+	}
+
+	if s.readOnly {
+		fmt.Fprintf(os.Stderr, "Serving a read-only database: this host is not collected\n")
 	} else {
-		fmt.Fprintf(os.Stderr, "✓ Initial snapshot ingested into Neo4j\n")
+		// Ingest initial data into Neo4j so RAG has something to query
+		fmt.Fprintf(os.Stderr, "Ingesting initial system snapshot into Neo4j...\n")
+		if err := s.ingestSnapshot(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: initial ingest failed: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "✓ Initial snapshot queued for Neo4j\n")
+		}
+
+		// Start background ingestion, timed by the active collection profile
+		s.startBackgroundIngest()
 	}
 
-	// Start background ingestion (every 30 seconds)
-	s.startBackgroundIngest(30 * time.Second)
+	// Watch the connections, reconnecting Neo4j when it stops answering
+	s.health = health.NewMonitor([]health.Dependency{
+		{Name: "neo4j", Ping: neo4jClient.Ping, Reconnect: neo4jClient.Reconnect},
+		{Name: "duckdb", Ping: repo.Ping},
+	})
+	healthCtx, cancelHealth := context.WithCancel(context.Background())
+	s.healthCancel, s.healthDone = cancelHealth, make(chan struct{})
+	go func() {
+		defer close(s.healthDone)
+		s.health.Run(healthCtx)
+	}()
+
+	if cfg.DigestPeriod > 0 {
+		opts := []digest.Option{digest.WithPeriod(cfg.DigestPeriod)}
+		if cfg.DigestDelivery != nil {
+			opts = append(opts, digest.WithDeliverer(cfg.DigestDelivery))
+		}
+		job := digest.New(repo, ragEngine, opts...)
+		digestCtx, cancel := context.WithCancel(context.Background())
+		s.digestCancel, s.digestDone = cancel, make(chan struct{})
+		go func() {
+			defer close(s.digestDone)
+			job.Run(digestCtx)
+		}()
+	}
 
 	return s, nil
 }
@@ -122,7 +322,9 @@ type AskSysCheckerResult struct {
 
 // MetricsArgs defines the input for get_realtime_metrics tool.
 type MetricsArgs struct {
-	MetricType string `json:"metric_type" jsonschema:"metrics type: fast or slow"`
+	MetricType string   `json:"metric_type" jsonschema:"metrics type: fast, slow, or merged for fast metrics overlaid with recently cached slow ones"`
+	Fields     []string `json:"fields,omitempty" jsonschema:"only return these top-level fields, e.g. [\"CPUUsage\", \"DockerContainers\"]; others are returned empty"`
+	Limit      int      `json:"limit,omitempty" jsonschema:"maximum items per list such as TopProcesses or NetInterfaces (default 50, max 500)"`
 }
 
 // MetricsResult wraps RawStats for tool output.
@@ -133,22 +335,285 @@ type MetricsResult struct {
 // QueryGraphArgs defines the input for query_graph tool.
 type QueryGraphArgs struct {
 	Cypher string `json:"cypher" jsonschema:"Cypher query to execute"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"rows per page (default 50, max 500)"`
+	Cursor string `json:"cursor,omitempty" jsonschema:"next_cursor of the previous page, with the same query"`
 }
 
 // QueryGraphResult wraps graph query results.
 type QueryGraphResult struct {
-	Data interface{} `json:"data" jsonschema:"query results"`
+	Data       interface{} `json:"data" jsonschema:"query results"`
+	NextCursor string      `json:"next_cursor,omitempty" jsonschema:"pass as cursor to get the next page; absent on the last page"`
 }
 
 // HistoricalSnapshotsArgs defines the input for get_historical_snapshots tool.
 type HistoricalSnapshotsArgs struct {
-	Hostname string `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
-	Limit    int    `json:"limit,omitempty" jsonschema:"number of snapshots to return"`
+	Hostname string            `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
+	Labels   map[string]string `json:"labels,omitempty" jsonschema:"host labels that must all match, e.g. {\"env\": \"prod\"}"`
+	Flags    []string          `json:"flags,omitempty" jsonschema:"flags that must all be set on returned snapshots, e.g. [\"memory_pressure\"]"`
+	Window   string            `json:"window,omitempty" jsonschema:"only snapshots within this lookback window, as a Go duration, e.g. 6h (max 720h; default unbounded)"`
+	TraceID  string            `json:"trace_id,omitempty" jsonschema:"trace ID of a collection cycle, as found on graph nodes, alerts and log lines"`
+	Limit    int               `json:"limit,omitempty" jsonschema:"number of snapshots to return"`
+	Cursor   string            `json:"cursor,omitempty" jsonschema:"next_cursor of the previous page, with the same filters"`
 }
 
 // HistoricalSnapshotsResult wraps snapshot results.
 type HistoricalSnapshotsResult struct {
-	Snapshots []relational.SnapshotSummary `json:"snapshots" jsonschema:"historical snapshots"`
+	Snapshots  []relational.SnapshotSummary `json:"snapshots" jsonschema:"historical snapshots"`
+	NextCursor string                       `json:"next_cursor,omitempty" jsonschema:"pass as cursor to get older snapshots; absent on the last page"`
+}
+
+// CorrelateMetricsArgs defines the input for correlate_metrics tool.
+type CorrelateMetricsArgs struct {
+	MetricA  string `json:"metric_a" jsonschema:"first snapshot metric, e.g. net_latency_ms"`
+	MetricB  string `json:"metric_b" jsonschema:"second snapshot metric, e.g. disk_write_bps"`
+	Window   string `json:"window,omitempty" jsonschema:"lookback window as a Go duration, e.g. 6h (default 24h, max 720h)"`
+	Hostname string `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
+}
+
+// TopOffendersArgs defines the input for get_top_offenders tool.
+type TopOffendersArgs struct {
+	Window string `json:"window,omitempty" jsonschema:"lookback window as a Go duration, e.g. 6h (default 24h, max 720h)"`
+}
+
+// CollectionProfileArgs defines the input for set_collection_profile tool.
+type CollectionProfileArgs struct {
+	Name string `json:"name,omitempty" jsonschema:"profile to activate, e.g. default, low-power or incident; omit to only list profiles"`
+}
+
+// CollectionProfileResult reports the active and available collection profiles.
+type CollectionProfileResult struct {
+	Active   schedule.State     `json:"active" jsonschema:"active profile and when a timed profile reverts"`
+	Profiles []schedule.Profile `json:"profiles" jsonschema:"available profiles"`
+}
+
+// CaptureForensicsArgs defines the input for capture_forensics tool.
+type CaptureForensicsArgs struct {
+	Containers []string `json:"containers,omitempty" jsonschema:"containers to docker inspect; defaults to running containers above 80% CPU or memory"`
+}
+
+// CaptureForensicsResult summarises a stored forensic capture.
+type CaptureForensicsResult struct {
+	ArtifactID   int64                     `json:"artifact_id" jsonschema:"ID of the stored capture"`
+	SnapshotID   int64                     `json:"snapshot_id,omitempty" jsonschema:"snapshot the capture is linked to"`
+	SizeBytes    int                       `json:"size_bytes"`
+	CapturedAt   time.Time                 `json:"captured_at"`
+	ProcessCount int                       `json:"process_count"`
+	Ports        []collector.ListeningPort `json:"ports" jsonschema:"listening ports and their processes"`
+	KernelLog    []string                  `json:"kernel_log" jsonschema:"most recent kernel log lines"`
+	Containers   []string                  `json:"containers,omitempty" jsonschema:"containers whose inspect output was captured"`
+	Errors       []string                  `json:"errors,omitempty" jsonschema:"parts of the capture that failed"`
+}
+
+// GetArtifactArgs defines the input for get_artifact tool.
+type GetArtifactArgs struct {
+	ArtifactID int64  `json:"artifact_id,omitempty" jsonschema:"artifact to fetch; omit to list artifacts"`
+	SnapshotID int64  `json:"snapshot_id,omitempty" jsonschema:"when listing, only artifacts linked to this snapshot"`
+	Kind       string `json:"kind,omitempty" jsonschema:"when listing, only this kind: forensics, smart, report, digest or graph"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"when listing, artifacts per page (default 50, max 500)"`
+	Cursor     string `json:"cursor,omitempty" jsonschema:"when listing, next_cursor of the previous page"`
+}
+
+// GetArtifactResult carries one artifact's content or a listing.
+type GetArtifactResult struct {
+	Artifact   *relational.Artifact  `json:"artifact,omitempty" jsonschema:"artifact metadata"`
+	Content    string                `json:"content,omitempty" jsonschema:"artifact content; base64 when encoding is base64"`
+	Encoding   string                `json:"encoding,omitempty" jsonschema:"text or base64"`
+	Artifacts  []relational.Artifact `json:"artifacts,omitempty" jsonschema:"artifact listing, newest first"`
+	NextCursor string                `json:"next_cursor,omitempty" jsonschema:"pass as cursor to list more artifacts"`
+}
+
+// UserUsageArgs defines the input for get_user_usage tool.
+type UserUsageArgs struct {
+	Window   string `json:"window,omitempty" jsonschema:"lookback window as a Go duration, e.g. 6h (default 24h, max 720h)"`
+	Hostname string `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
+}
+
+// FleetSummaryArgs defines the input for get_fleet_summary tool.
+type FleetSummaryArgs struct {
+	Labels map[string]string `json:"labels,omitempty" jsonschema:"only hosts whose labels all match, e.g. {\"env\": \"prod\"}"`
+	Limit  int               `json:"limit,omitempty" jsonschema:"number of riskiest hosts to list (default 10)"`
+}
+
+// CompareHostsArgs defines the input for compare_hosts tool.
+type CompareHostsArgs struct {
+	HostA  string `json:"host_a" jsonschema:"first hostname or agent ID"`
+	HostB  string `json:"host_b" jsonschema:"second hostname or agent ID"`
+	Window string `json:"window,omitempty" jsonschema:"lookback window as a Go duration, e.g. 6h (default 24h, max 720h)"`
+}
+
+// BookmarksArgs defines the input for get_bookmarks tool.
+type BookmarksArgs struct {
+	Hostname string `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
+	Window   string `json:"window,omitempty" jsonschema:"only bookmarks of snapshots this recent, as a Go duration (default and max 720h)"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"maximum number of bookmarks per page (default 50, max 500)"`
+	Cursor   string `json:"cursor,omitempty" jsonschema:"next_cursor of the previous page, with the same filters"`
+}
+
+// BookmarksResult lists bookmarked snapshots.
+type BookmarksResult struct {
+	Bookmarks  []relational.Annotation `json:"bookmarks" jsonschema:"bookmarks, newest snapshot first"`
+	NextCursor string                  `json:"next_cursor,omitempty" jsonschema:"pass as cursor to get older bookmarks; absent on the last page"`
+}
+
+// ContainerHistoryArgs defines the input for get_container_history tool.
+type ContainerHistoryArgs struct {
+	Hostname  string `json:"hostname,omitempty" jsonschema:"hostname to filter by"`
+	Container string `json:"container,omitempty" jsonschema:"container name or ID prefix; omit for all containers"`
+	Window    string `json:"window,omitempty" jsonschema:"lookback window as a Go duration, e.g. 168h (default 24h, max 720h)"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"entries per page (default 50, max 500)"`
+	Cursor    string `json:"cursor,omitempty" jsonschema:"next_cursor of the previous page, with the same filters"`
+}
+
+// ContainerHistoryResult lists hourly container rollups.
+type ContainerHistoryResult struct {
+	Hours      []relational.ContainerHour `json:"hours" jsonschema:"one entry per container and hour, oldest first"`
+	NextCursor string                     `json:"next_cursor,omitempty" jsonschema:"pass as cursor to get later hours; absent on the last page"`
+}
+
+// ExplainFlagsArgs defines the input for explain_flags tool.
+type ExplainFlagsArgs struct{}
+
+// ExplainFlagsResult explains the flags of the current snapshot.
+type ExplainFlagsResult struct {
+	Hostname      string                    `json:"hostname"`
+	CollectedAt   time.Time                 `json:"collected_at"`
+	SeverityLevel int                       `json:"severity_level" jsonschema:"0 (ok) to 3 (critical)"`
+	Flags         []flagger.FlagExplanation `json:"flags" jsonschema:"active flags with the metric values and thresholds that raised them and suggested remediation; empty when nothing is flagged"`
+}
+
+// RecommendActionsArgs defines the input for recommend_actions tool.
+type RecommendActionsArgs struct{}
+
+// RecommendActionsResult lists remediation actions for the current flags.
+type RecommendActionsResult struct {
+	Flags   []string            `json:"flags" jsonschema:"active flags of the current snapshot"`
+	Actions []RecommendedAction `json:"actions" jsonschema:"remediation actions; empty when no flag has one"`
+	Allowed []string            `json:"allowed,omitempty" jsonschema:"action kinds execute_action may run on this server; absent when it is disabled"`
+}
+
+// RecommendedAction is a remediation action for one flag.
+type RecommendedAction struct {
+	Flag   string       `json:"flag" jsonschema:"the flag this action addresses"`
+	Action actions.Spec `json:"action"`
+}
+
+// SLOStatusArgs defines the input for get_slo_status tool.
+type SLOStatusArgs struct {
+	Hostname string `json:"hostname,omitempty" jsonschema:"hostname to evaluate; omit to pool every host"`
+	Name     string `json:"name,omitempty" jsonschema:"only the SLO with this name"`
+}
+
+// SLOStatusResult reports the compliance of each SLO.
+type SLOStatusResult struct {
+	SLOs []slo.Status `json:"slos" jsonschema:"each objective with its compliance, remaining error budget (1 = untouched, negative = overspent) and burn rate over the last hour (1 = on budget)"`
+}
+
+// SuggestQuestionsArgs defines the input for suggest_questions tool.
+type SuggestQuestionsArgs struct {
+	Limit int `json:"limit,omitempty" jsonschema:"number of questions, 3 to 5 (default 5)"`
+}
+
+// SuggestQuestionsResult lists questions worth asking ask_syschecker.
+type SuggestQuestionsResult struct {
+	Hostname  string   `json:"hostname,omitempty"`
+	Flags     []string `json:"flags" jsonschema:"flags of the current snapshot the questions are based on"`
+	Questions []string `json:"questions" jsonschema:"questions about the current flags, recent incidents and SLOs at risk first, then general ones"`
+}
+
+// ExportGraphArgs defines the input for export_graph tool.
+type ExportGraphArgs struct {
+	SnapshotID string `json:"snapshot_id,omitempty" jsonschema:"graph snapshot_id property of the snapshot to export; omit to use the latest incident of hostname"`
+	Hostname   string `json:"hostname,omitempty" jsonschema:"host whose latest flagged snapshot, or else latest snapshot, is exported; default: this host"`
+	Format     string `json:"format,omitempty" jsonschema:"dot (default), graphml or html"`
+	Hops       int    `json:"hops,omitempty" jsonschema:"relationships to follow from the snapshot, 1 to 3 (default 2)"`
+}
+
+// ExportGraphResult is an exported subgraph.
+type ExportGraphResult struct {
+	SnapshotID string `json:"snapshot_id"`
+	Format     string `json:"format"`
+	Nodes      int    `json:"nodes"`
+	Edges      int    `json:"edges"`
+	Content    string `json:"content,omitempty" jsonschema:"the DOT or GraphML document; empty for html, which is only stored"`
+	ViewerID   int64  `json:"viewer_artifact_id,omitempty" jsonschema:"artifact holding an HTML viewer of the subgraph; fetch it with get_artifact and open it in a browser"`
+}
+
+// GetServerStatusArgs defines the input for get_server_status tool.
+type GetServerStatusArgs struct {
+	Check bool `json:"check,omitempty" jsonschema:"ping the dependencies now instead of reporting the last periodic check"`
+}
+
+// GetServerStatusResult is the server's own health.
+type GetServerStatusResult struct {
+	Version      string                    `json:"version"`
+	Uptime       string                    `json:"uptime"`
+	Admin        bool                      `json:"admin"`
+	Dependencies []health.Status           `json:"dependencies" jsonschema:"Neo4j and DuckDB connections; failures counts consecutive failed checks"`
+	GraphIngest  database.GraphIngestStats `json:"graph_ingest"`
+}
+
+// ExecuteActionArgs defines the input for execute_action tool.
+type ExecuteActionArgs struct {
+	ActionID string `json:"action_id" jsonschema:"id of an action returned by recommend_actions"`
+	DryRun   *bool  `json:"dry_run,omitempty" jsonschema:"only check the action's preconditions (default true); pass false to run it"`
+	Confirm  bool   `json:"confirm,omitempty" jsonschema:"confirm an action with requires_confirmation; ask the user first"`
+}
+
+// SetCollectionIntervalArgs defines the input for set_collection_interval tool.
+type SetCollectionIntervalArgs struct {
+	Profile string `json:"profile,omitempty" jsonschema:"profile to change; defaults to default"`
+	Fast    string `json:"fast,omitempty" jsonschema:"new sensor interval, e.g. 2s; omit to keep it"`
+	Slow    string `json:"slow,omitempty" jsonschema:"new snapshot interval, e.g. 10s; omit to keep it"`
+}
+
+// ToggleSensorArgs defines the input for toggle_sensor tool.
+type ToggleSensorArgs struct {
+	Sensor  string `json:"sensor,omitempty" jsonschema:"sensor to switch; omit to only list sensors"`
+	Enabled bool   `json:"enabled,omitempty" jsonschema:"true to switch the sensor on, false to switch it off"`
+}
+
+// ToggleSensorResult reports whether each optional sensor is enabled.
+type ToggleSensorResult struct {
+	Sensors map[string]bool `json:"sensors" jsonschema:"sensor name to enabled"`
+}
+
+// SetThresholdArgs defines the input for set_threshold tool.
+type SetThresholdArgs struct {
+	Metric   string  `json:"metric,omitempty" jsonschema:"threshold to change, e.g. cpu; omit to only list thresholds"`
+	Warning  float64 `json:"warning,omitempty" jsonschema:"new warning threshold"`
+	Critical float64 `json:"critical,omitempty" jsonschema:"new critical threshold, at least the warning one"`
+}
+
+// SetThresholdResult reports the flagger's current thresholds.
+type SetThresholdResult struct {
+	Thresholds map[string]flagger.Thresholds `json:"thresholds" jsonschema:"metric to warning and critical thresholds"`
+}
+
+// EditThresholdsArgs defines the input for edit_thresholds tool.
+type EditThresholdsArgs struct {
+	Instruction string                 `json:"instruction,omitempty" jsonschema:"the change in plain words, e.g. 'warn me when disk hits 85%'"`
+	Edits       []config.ThresholdEdit `json:"edits,omitempty" jsonschema:"edits returned by an earlier call, used instead of the instruction; pass them back with confirm true to apply exactly what was previewed"`
+	Confirm     bool                   `json:"confirm,omitempty" jsonschema:"write the edits to the config file; needs edits and the user's agreement to the diff"`
+}
+
+// EditThresholdsResult previews or reports a threshold change.
+type EditThresholdsResult struct {
+	Edits   []config.ThresholdEdit `json:"edits" jsonschema:"the parsed threshold changes"`
+	Diff    string                 `json:"diff" jsonschema:"line diff of the config file: removed lines start with -, added ones with +"`
+	Applied bool                   `json:"applied" jsonschema:"whether the config file was written; the server reloads it within seconds"`
+}
+
+// InjectFaultArgs defines the input for inject_fault tool.
+type InjectFaultArgs struct {
+	Fault    string `json:"fault,omitempty" jsonschema:"fault to inject or clear; omit to only list active faults"`
+	Duration string `json:"duration,omitempty" jsonschema:"how long the fault lasts as a Go duration such as '2m'; omit to keep it until cleared"`
+	Count    int    `json:"count,omitempty" jsonschema:"fire the fault this many times, then clear it; omit for no limit"`
+	Clear    bool   `json:"clear,omitempty" jsonschema:"clear the fault instead, or every fault when none is given"`
+}
+
+// InjectFaultResult lists the injected faults still in effect.
+type InjectFaultResult struct {
+	Active []chaos.Injection `json:"active" jsonschema:"faults in effect"`
 }
 
 // registerTools registers all available MCP tools.
@@ -156,26 +621,196 @@ func (s *Server) registerTools() {
 	// Tool 1: ask_syschecker - GraphRAG-powered Q&A
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
 		Name:        "ask_syschecker",
-		Description: "Ask complex questions about system health, performance issues, and root causes using AI-powered graph analysis. Use this for 'why' questions and causal reasoning about system behavior.",
-	}, s.handleAskSysChecker)
+		Description: "Ask complex questions about system health, performance issues, and root causes using AI-powered graph analysis. Use this for 'why' questions and causal reasoning about system behavior. Needs the analyst role.",
+	}, guard(s, "ask_syschecker", RoleAnalyst, s.handleAskSysChecker))
 
 	// Tool 2: get_realtime_metrics - Direct sensor access
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
 		Name:        "get_realtime_metrics",
-		Description: "Get the absolute latest system metrics directly from sensors. Use this to verify current state or when you need real-time data (not historical). Returns CPU, RAM, disk, network, and process information.",
-	}, s.handleGetRealtimeMetrics)
+		Description: "Get the absolute latest system metrics directly from sensors. Use this to verify current state or when you need real-time data (not historical). Returns CPU, RAM, disk, network, and process information. metric_type merged returns fast and slow metrics in one snapshot, with slow ones reused for up to a collection interval. Select fields to keep the result small; lists are capped at limit items.",
+	}, s.handleRealtimeMetrics)
 
 	// Tool 3: query_graph - Direct Cypher access for power users
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
 		Name:        "query_graph",
-		Description: "Execute Cypher queries directly on the Neo4j graph database. For advanced users who want to explore the graph structure. Available nodes: Host, Snapshot, Flag, Cause, DiskDevice, NetInterface, Container.",
+		Description: "Execute Cypher queries directly on the Neo4j graph database. For advanced users who want to explore the graph structure. Available nodes: Host, Snapshot, Flag, Cause, DiskDevice, NetInterface, Container, Deviation, Process, File, OOMKill, Directory, User, Service (linked by (Host)-[:RUNS]->(Service)-[:DEPENDS_ON]->(Service)). Queries that modify the graph need the admin role. Rows are paged: pass next_cursor back as cursor for more.",
 	}, s.handleQueryGraph)
 
 	// Tool 4: get_historical_snapshots - Query DuckDB for time series
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
 		Name:        "get_historical_snapshots",
-		Description: "Query historical snapshots from DuckDB. Use for time-series analysis and trend identification. Returns snapshot summaries with CPU, RAM, disk usage, severity levels, and explanations.",
+		Description: "Query historical snapshots from DuckDB. Use for time-series analysis and trend identification. Filter by hostname, host labels, lookback window, and flags (all must be set; names: " + strings.Join(relational.FlagNames, ", ") + "). Returns snapshot summaries with CPU, RAM, disk usage, severity levels, explanations, and bookmark notes. Paged: pass next_cursor back as cursor for older snapshots.",
 	}, s.handleGetHistoricalSnapshots)
+
+	// Tool 5: correlate_metrics - Quantify relationships between metrics
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "correlate_metrics",
+		Description: "Compute the Pearson correlation between two snapshot metrics over a time window, overall and per time bucket. Use this to quantify suspected relationships such as latency vs disk write throughput. Metrics: " + strings.Join(relational.MetricColumns, ", ") + ".",
+	}, s.handleCorrelateMetrics)
+
+	// Tool 6: get_top_offenders - Rank repeat offenders over a window
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_top_offenders",
+		Description: "List the processes, containers, disks, and network interfaces that most often caused flags or were the top resource consumer over a time window.",
+	}, s.handleGetTopOffenders)
+
+	// Tool 7: get_user_usage - Per-user CPU and memory accounting
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_user_usage",
+		Description: "Rank system users by their share of CPU and RAM over a time window, with peak values and how often each user was flagged as the cause. Use this for questions like 'which user is eating all the memory'.",
+	}, s.handleGetUserUsage)
+
+	// Tool 8: set_collection_profile - Switch collection intervals at runtime
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "set_collection_profile",
+		Description: "List collection profiles or switch to one. 'incident' samples every few seconds for 10 minutes then reverts; 'low-power' collects rarely. Intervals are Go durations in nanoseconds. Switching needs the analyst role.",
+	}, s.handleSetCollectionProfile)
+
+	// Tool 9: capture_forensics - Extended one-off snapshot stored as an artifact
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "capture_forensics",
+		Description: "Capture an extended one-off snapshot for incident analysis: the full process tree with command lines, listening ports, the kernel log tail, and docker inspect of hot containers. The capture is stored as an artifact linked to the latest snapshot; the result summarises it. Needs the analyst role.",
+	}, guard(s, "capture_forensics", RoleAnalyst, s.handleCaptureForensics))
+
+	// Tool 10: get_artifact - Fetch stored forensic captures, SMART output and reports
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_artifact",
+		Description: "Fetch a stored artifact (forensic capture, raw SMART output of a failing disk, rendered report, health digest, or graph viewer from export_graph) by ID, or list artifacts for a snapshot or kind when no ID is given.",
+	}, s.handleGetArtifact)
+
+	// Tool 11: get_fleet_summary - Aggregate current state across hosts
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_fleet_summary",
+		Description: "Summarise the current state of every monitored host: host count, disconnected hosts, worst severity, how many hosts have each flag active, and the riskiest hosts. Use this first in multi-host setups to see where to look.",
+	}, s.handleGetFleetSummary)
+
+	// Tool 12: compare_hosts - Side-by-side diff of two hosts
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "compare_hosts",
+		Description: "Compare two hosts over a time window: average, p50, p95, p99 and max of every snapshot metric side by side, sorted by how much they differ, and the flags raised more often on one host than the other. Use this for questions like 'why is node3 slower than node4'.",
+	}, s.handleCompareHosts)
+
+	// Tool 13: get_bookmarks - Snapshots the user bookmarked with a note
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_bookmarks",
+		Description: "List snapshots the user bookmarked from the TUI, with their notes. Use the snapshot IDs and times as anchors when the user refers to 'the spike I bookmarked' or similar.",
+	}, s.handleGetBookmarks)
+
+	// Tool 14: get_container_history - Hourly container trends
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_container_history",
+		Description: "Hourly history of Docker containers: average and peak CPU, peak memory, and restarts observed, from pre-aggregated rollups. Use for container trend questions such as 'has the api container's memory been growing this week' or 'which container keeps restarting'.",
+	}, s.handleGetContainerHistory)
+
+	// Tool 15: explain_flags - Deterministic flag evidence and remediation
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "explain_flags",
+		Description: "Flag the current metrics and explain each active flag: the exact metric values that raised it, the warning and critical thresholds in effect for this host, and suggested remediation. Deterministic and cheap, with no LLM involved; prefer it over ask_syschecker for 'why is this flagged' and 'what should I do'.",
+	}, s.handleExplainFlags)
+
+	// Tool 16: recommend_actions - Remediation commands for active flags
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "recommend_actions",
+		Description: "List concrete remediation commands for the flags of the current snapshot, such as restarting a container hogging CPU, vacuuming the systemd journal or clearing stale temp files. Nothing is run; pass an action id to execute_action, when enabled, to run it.",
+	}, s.handleRecommendActions)
+
+	// Tool 17: execute_action - Guarded remediation (opt-in)
+	if s.executor != nil {
+		mcp.AddTool(s.mcpServer, &mcp.Tool{
+			Name:        "execute_action",
+			Description: "Run an action returned by recommend_actions. Only actions still recommended for the current snapshot and of a kind the operator allowed can run, and every attempt is audited. dry_run defaults to true and only checks preconditions; pass dry_run false to run it, and confirm true for actions with requires_confirmation after the user agreed. Needs the admin role.",
+		}, guard(s, "execute_action", RoleAdmin, s.handleExecuteAction))
+	}
+
+	// Tool 18: get_slo_status - SLO compliance and error budget
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_slo_status",
+		Description: "Evaluate the service level objectives defined in the config file, such as 'latency under 50 ms 99% of the time', against stored snapshots: compliance, whether each is met, how much error budget is left and how fast it burned over the last hour. Use for 'are we meeting our SLOs' and 'how much budget is left'.",
+	}, s.handleGetSLOStatus)
+
+	// Tool 19: suggest_questions - Starter questions for an empty chat
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "suggest_questions",
+		Description: "Suggest 3 to 5 questions worth asking ask_syschecker, based on the flags raised now, flags raised in the last 24 hours and SLOs at risk. No LLM is involved. Use it to fill an empty chat or when the user asks what to look at.",
+	}, s.handleSuggestQuestions)
+
+	// Tool 20: export_graph - Subgraph around a snapshot as DOT, GraphML or HTML
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "export_graph",
+		Description: "Export the Neo4j subgraph around a snapshot, by default the latest incident of a host: its host, flags, causes and the entities they blame, as Graphviz DOT or GraphML. An HTML viewer of the same subgraph is stored as an artifact for get_artifact. Use it to inspect causal chains visually instead of reading Cypher rows.",
+	}, s.handleExportGraph)
+
+	// Tool 21: get_server_status - Connection health of the server itself
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "get_server_status",
+		Description: "Report the MCP server's own health: whether its Neo4j and DuckDB connections answer, since when, how often Neo4j was reconnected, and the graph ingest queue. Use it when other tools fail or answers look stale, before blaming the host.",
+	}, s.handleGetServerStatus)
+
+	if !s.admin {
+		return
+	}
+
+	// Tool 22: set_collection_interval - Retune a collection profile (admin)
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "set_collection_interval",
+		Description: "Admin: change the fast and/or slow interval of a collection profile (default: the default profile). Takes effect immediately if the profile is active. Intervals are Go durations such as '5s' or '1m'. Needs the admin role.",
+	}, guard(s, "set_collection_interval", RoleAdmin, s.handleSetCollectionInterval))
+
+	// Tool 23: toggle_sensor - Switch optional sensors on or off (admin)
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "toggle_sensor",
+		Description: "Admin: switch an optional sensor on or off, or list their states when no sensor is given. Use it to silence a slow or noisy sensor during an incident. Sensors: " + strings.Join(collector.ToggleableSensors, ", ") + ". Disabling docker raises docker_unavailable. Needs the admin role.",
+	}, guard(s, "toggle_sensor", RoleAdmin, s.handleToggleSensor))
+
+	// Tool 24: set_threshold - Change a flagging threshold (admin)
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "set_threshold",
+		Description: "Admin: set the warning and critical thresholds of a metric for snapshots flagged from now on, or list the current thresholds when no metric is given. A reload of the config file replaces these changes. Metrics: " + strings.Join(config.ThresholdNames(), ", ") + ". Needs the admin role.",
+	}, guard(s, "set_threshold", RoleAdmin, s.handleSetThreshold))
+
+	// Tool 25: edit_thresholds - Natural-language threshold changes (admin)
+	if s.configPath != "" {
+		mcp.AddTool(s.mcpServer, &mcp.Tool{
+			Name:        "edit_thresholds",
+			Description: "Admin: change flagging thresholds in the config file from a plain instruction such as 'warn me when disk hits 85%'. The first call parses the instruction and returns the edits and the config diff without writing anything. Show the diff to the user and, once they agree, call again with the returned edits and confirm true; the server reloads the file within seconds. Unlike set_threshold the change survives restarts. Needs the admin role.",
+		}, guard(s, "edit_thresholds", RoleAdmin, s.handleEditThresholds))
+	}
+
+	// Tool 26: inject_fault - Chaos testing (admin, chaos builds only)
+	if chaos.Enabled {
+		mcp.AddTool(s.mcpServer, &mcp.Tool{
+			Name:        "inject_fault",
+			Description: "Admin: inject a fault to exercise alerting, buffering and flagging, or clear it, or list active faults when no fault is given. Faults: " + strings.Join(chaos.Faults, ", ") + ". Limit a fault with a duration such as '2m' or a count of hits. Needs the admin role.",
+		}, guard(s, "inject_fault", RoleAdmin, s.handleInjectFault))
+	}
+}
+
+// registerResources publishes JSON Schemas for tool outputs as MCP resources.
+func (s *Server) registerResources() {
+	for _, name := range schema.Names() {
+		s.mcpServer.AddResource(&mcp.Resource{
+			URI:         schema.URIPrefix + name,
+			Name:        name,
+			Description: "JSON Schema for " + name,
+			MIMEType:    "application/schema+json",
+		}, s.handleReadSchema)
+	}
+}
+
+// handleReadSchema serves a published JSON Schema.
+func (s *Server) handleReadSchema(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	b, err := schema.JSON(strings.TrimPrefix(uri, schema.URIPrefix))
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      uri,
+			MIMEType: "application/schema+json",
+			Text:     string(b),
+		}},
+	}, nil
 }
 
 // handleAskSysChecker uses GraphRAG to answer complex questions.
@@ -189,6 +824,23 @@ func (s *Server) handleAskSysChecker(ctx context.Context, _ *mcp.CallToolRequest
 	return nil, AskSysCheckerResult{Answer: answer}, nil
 }
 
+// handleRealtimeMetrics serves get_realtime_metrics: RawStats for fast and
+// slow metrics, a RawStatsFixed snapshot for merged ones.
+func (s *Server) handleRealtimeMetrics(ctx context.Context, req *mcp.CallToolRequest, args MetricsArgs) (*mcp.CallToolResult, any, error) {
+	if args.MetricType == "merged" {
+		res, merged, err := s.handleGetMergedMetrics(ctx, req, args)
+		if merged == nil {
+			return res, nil, err
+		}
+		return res, merged, err
+	}
+	res, stats, err := s.handleGetRealtimeMetrics(ctx, req, args)
+	if stats == nil {
+		return res, nil, err
+	}
+	return res, stats, err
+}
+
 // handleGetRealtimeMetrics fetches live data from sensors.
 func (s *Server) handleGetRealtimeMetrics(ctx context.Context, _ *mcp.CallToolRequest, args MetricsArgs) (*mcp.CallToolResult, *collector.RawStats, error) {
 	metricType := args.MetricType
@@ -205,25 +857,146 @@ func (s *Server) handleGetRealtimeMetrics(ctx context.Context, _ *mcp.CallToolRe
 	case "slow":
 		stats, err = s.sensorProvider.GetSlowMetrics(ctx)
 	default:
-		return nil, nil, fmt.Errorf("invalid metric_type: %s (must be 'fast' or 'slow')", metricType)
+		return nil, nil, fmt.Errorf("invalid metric_type: %s (must be 'fast', 'slow' or 'merged')", metricType)
 	}
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get metrics: %w", err)
 	}
+	if stats == nil {
+		return nil, nil, nil
+	}
 
-	return nil, stats, nil
+	// Cap lists and, when fields are selected, return only those as text;
+	// the structured result keeps its schema with the rest left empty.
+	limit := pageSize(args.Limit, defaultPageSize)
+	stats, selected, truncated, err := trimStats(stats, args.Fields, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if selected == nil && truncated == nil {
+		return nil, stats, nil
+	}
+	var view any = stats
+	if selected != nil {
+		view = selected
+	}
+	text, err := json.Marshal(view)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	res := &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(text)}}}
+	if truncated != nil {
+		res.Content = append(res.Content, &mcp.TextContent{Text: truncationNote(truncated, limit)})
+	}
+	return res, stats, nil
+}
+
+// defaultSlowCacheTTL bounds the age of cached slow metrics when the
+// server has no scheduler.
+const defaultSlowCacheTTL = 20 * time.Second
+
+// handleGetMergedMetrics collects fast metrics and overlays slow metrics
+// with MergeStats, so clients need one call instead of two. Slow metrics
+// are reused for up to the active profile's slow interval.
+func (s *Server) handleGetMergedMetrics(ctx context.Context, _ *mcp.CallToolRequest, args MetricsArgs) (*mcp.CallToolResult, *relational.RawStatsFixed, error) {
+	merged, slowAt, err := s.mergedSnapshot(ctx)
+	if err != nil || merged == nil {
+		return nil, nil, err
+	}
+
+	limit := pageSize(args.Limit, defaultPageSize)
+	trimmed, selected, truncated, err := trimStats(merged, args.Fields, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	var view any = trimmed
+	if selected != nil {
+		view = selected
+	}
+	text, err := json.Marshal(view)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	res := &mcp.CallToolResult{Content: []mcp.Content{
+		&mcp.TextContent{Text: string(text)},
+		&mcp.TextContent{Text: fmt.Sprintf("Slow metrics (disk health, latency, host details) were collected %s ago.", time.Since(slowAt).Round(time.Second))},
+	}}
+	if truncated != nil {
+		res.Content = append(res.Content, &mcp.TextContent{Text: truncationNote(truncated, limit)})
+	}
+	return res, trimmed, nil
+}
+
+// mergedSnapshot collects fast metrics and merges them with cached slow
+// metrics, returning nil when the provider has no fast metrics. slowAt is
+// when the slow metrics were collected.
+func (s *Server) mergedSnapshot(ctx context.Context) (merged *relational.RawStatsFixed, slowAt time.Time, err error) {
+	fast, err := s.sensorProvider.GetFastMetrics(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get metrics: %w", err)
+	}
+	if fast == nil {
+		return nil, time.Time{}, nil
+	}
+	slow, slowAt, err := s.cachedSlowMetrics(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get slow metrics: %w", err)
+	}
+	m := relational.MergeStats(fast, slow, s.agentID, "", "")
+	m.CollectedAt = time.Now().UTC()
+	return &m, slowAt, nil
+}
+
+// cachedSlowMetrics returns the last slow metrics and when they were
+// collected, collecting them again once they are older than the active
+// profile's slow interval.
+func (s *Server) cachedSlowMetrics(ctx context.Context) (*collector.RawStats, time.Time, error) {
+	ttl := defaultSlowCacheTTL
+	if s.scheduler != nil {
+		ttl = s.scheduler.Current().Profile.Slow
+	}
+	s.slowMu.Lock()
+	defer s.slowMu.Unlock()
+	if s.slowStats != nil && time.Since(s.slowAt) < ttl {
+		return s.slowStats, s.slowAt, nil
+	}
+	slow, err := s.sensorProvider.GetSlowMetrics(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	s.slowStats, s.slowAt = slow, time.Now()
+	return s.slowStats, s.slowAt, nil
 }
 
 // handleQueryGraph executes Cypher queries.
-func (s *Server) handleQueryGraph(ctx context.Context, _ *mcp.CallToolRequest, args QueryGraphArgs) (*mcp.CallToolResult, QueryGraphResult, error) {
-	// Execute the query via Neo4j client
-	result, err := s.neo4jClient.ExecuteCypher(ctx, args.Cypher)
+func (s *Server) handleQueryGraph(ctx context.Context, req *mcp.CallToolRequest, args QueryGraphArgs) (*mcp.CallToolResult, QueryGraphResult, error) {
+	write := isCypherWrite(args.Cypher)
+	if write {
+		if err := s.authorize(req, "query_graph writes", RoleAdmin); err != nil {
+			return nil, QueryGraphResult{}, err
+		}
+	}
+
+	limit := pageSize(args.Limit, defaultPageSize)
+	key := queryKey("query_graph", args.Cypher)
+	fetch, err := pageFetchLimit(key, args.Cursor, limit)
+	if err != nil {
+		return nil, QueryGraphResult{}, err
+	}
+
+	// Neo4j streams rows, so reading stops after the requested page and one
+	// more row; the query is re-run for each page.
+	result, err := s.neo4jClient.RunCypher(ctx, args.Cypher, graph.CypherOptions{Write: write, MaxRows: fetch})
 	if err != nil {
 		return nil, QueryGraphResult{}, fmt.Errorf("cypher query failed: %w", err)
 	}
 
-	return nil, QueryGraphResult{Data: result}, nil
+	rows, next, err := paginate(result, key, args.Cursor, limit)
+	if err != nil {
+		return nil, QueryGraphResult{}, err
+	}
+	return nil, QueryGraphResult{Data: rows, NextCursor: next}, nil
 }
 
 // handleGetHistoricalSnapshots queries DuckDB.
@@ -236,49 +1009,755 @@ func (s *Server) handleGetHistoricalSnapshots(ctx context.Context, _ *mcp.CallTo
 		limit = 100
 	}
 
+	// Pages are keyed by the last snapshot returned, so snapshots stored
+	// between calls do not shift later pages.
+	key := queryKey("get_historical_snapshots", HistoricalSnapshotsArgs{Hostname: args.Hostname, Labels: args.Labels, Flags: args.Flags, Window: args.Window, Limit: limit})
+	beforeAt, beforeID, err := decodeKeyCursor(key, args.Cursor)
+	if err != nil {
+		return nil, HistoricalSnapshotsResult{}, err
+	}
+
+	filter := relational.SnapshotFilter{Hostname: args.Hostname, Labels: args.Labels, Flags: args.Flags, TraceID: args.TraceID, Limit: limit + 1, BeforeAt: beforeAt, BeforeID: beforeID}
+	if args.Window != "" {
+		window, err := parseWindow(args.Window)
+		if err != nil {
+			return nil, HistoricalSnapshotsResult{}, err
+		}
+		filter.Since = time.Now().Add(-window)
+	}
+
 	// Query snapshots from repo
-	snapshots, err := s.duckdbRepo.QuerySnapshots(ctx, args.Hostname, limit)
+	snapshots, err := s.duckdbRepo.QuerySnapshotsFiltered(ctx, filter)
 	if err != nil {
 		return nil, HistoricalSnapshotsResult{}, fmt.Errorf("failed to query snapshots: %w", err)
 	}
 
-	return nil, HistoricalSnapshotsResult{Snapshots: snapshots}, nil
+	page := fitPage(snapshots[:min(limit, len(snapshots))])
+	next := ""
+	if len(page) < len(snapshots) {
+		last := page[len(page)-1]
+		next = keyCursor(key, last.CollectedAt, last.SnapshotID)
+	}
+	return nil, HistoricalSnapshotsResult{Snapshots: page, NextCursor: next}, nil
+}
+
+// handleCorrelateMetrics computes metric correlations in DuckDB.
+func (s *Server) handleCorrelateMetrics(ctx context.Context, _ *mcp.CallToolRequest, args CorrelateMetricsArgs) (*mcp.CallToolResult, *relational.CorrelationResult, error) {
+	window, err := parseWindow(args.Window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.duckdbRepo.CorrelateMetrics(ctx, args.Hostname, args.MetricA, args.MetricB, window, 12)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to correlate metrics: %w", err)
+	}
+
+	return nil, result, nil
+}
+
+// handleGetTopOffenders ranks repeat offenders from DuckDB.
+func (s *Server) handleGetTopOffenders(ctx context.Context, _ *mcp.CallToolRequest, args TopOffendersArgs) (*mcp.CallToolResult, *relational.TopOffendersReport, error) {
+	window, err := parseWindow(args.Window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.duckdbRepo.TopOffenders(ctx, window)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get top offenders: %w", err)
+	}
+
+	return nil, report, nil
+}
+
+// handleGetFleetSummary aggregates current state across hosts from DuckDB.
+func (s *Server) handleGetFleetSummary(ctx context.Context, _ *mcp.CallToolRequest, args FleetSummaryArgs) (*mcp.CallToolResult, *relational.FleetSummary, error) {
+	summary, err := s.duckdbRepo.FleetSummary(ctx, args.Labels, args.Limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fleet summary: %w", err)
+	}
+
+	return nil, summary, nil
+}
+
+// handleExplainFlags flags the current merged snapshot and explains each
+// active flag.
+func (s *Server) handleExplainFlags(ctx context.Context, _ *mcp.CallToolRequest, _ ExplainFlagsArgs) (*mcp.CallToolResult, *ExplainFlagsResult, error) {
+	snap, flags, err := s.currentFlags(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &ExplainFlagsResult{
+		Hostname:      snap.Hostname,
+		CollectedAt:   snap.CollectedAt,
+		SeverityLevel: flags.SeverityLevel,
+		Flags:         s.flaggerSvc.Explain(snap, &relational.DerivedRates{}, flags),
+	}, nil
+}
+
+// currentFlags flags the current merged snapshot. Rates need a previous
+// snapshot, so flags derived from them are not raised.
+func (s *Server) currentFlags(ctx context.Context) (*relational.RawStatsFixed, *relational.SnapshotFlags, error) {
+	snap, _, err := s.mergedSnapshot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if snap == nil {
+		return nil, nil, fmt.Errorf("no metrics available")
+	}
+	return snap, s.flaggerSvc.Flag(snap, &relational.DerivedRates{}), nil
 }
 
-// Start starts the MCP server using stdio transport.
+// handleRecommendActions maps the current flags to remediation commands.
+func (s *Server) handleRecommendActions(ctx context.Context, _ *mcp.CallToolRequest, _ RecommendActionsArgs) (*mcp.CallToolResult, *RecommendActionsResult, error) {
+	snap, flags, err := s.currentFlags(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &RecommendActionsResult{Flags: flags.ActiveFlags(), Actions: []RecommendedAction{}}
+	for _, r := range remediate.Recommend(snap, flags) {
+		res.Actions = append(res.Actions, RecommendedAction{Flag: r.Flag, Action: r.Action.Spec()})
+	}
+	for _, kind := range actions.Kinds {
+		if s.executor.Allowed(kind) {
+			res.Allowed = append(res.Allowed, kind)
+		}
+	}
+	return nil, res, nil
+}
+
+// handleExecuteAction runs an action recommended for the current snapshot.
+// Recommendations are rebuilt rather than taken from the caller, so only
+// actions built by remediate can run.
+func (s *Server) handleExecuteAction(ctx context.Context, _ *mcp.CallToolRequest, args ExecuteActionArgs) (*mcp.CallToolResult, *actions.Result, error) {
+	snap, flags, err := s.currentFlags(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	recs := remediate.Recommend(snap, flags)
+	i := slices.IndexFunc(recs, func(r remediate.Recommendation) bool { return r.Action.Spec().ID == args.ActionID })
+	if i < 0 {
+		return nil, nil, fmt.Errorf("action %q is not recommended for the current snapshot; call recommend_actions again", args.ActionID)
+	}
+	res, err := s.executor.Execute(ctx, recs[i].Action, actions.Options{
+		DryRun:    args.DryRun == nil || *args.DryRun,
+		Confirmed: args.Confirm,
+		Actor:     "mcp",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &res, nil
+}
+
+// handleGetSLOStatus evaluates the configured SLOs from DuckDB.
+func (s *Server) handleGetSLOStatus(ctx context.Context, _ *mcp.CallToolRequest, args SLOStatusArgs) (*mcp.CallToolResult, *SLOStatusResult, error) {
+	var objectives []slo.Objective
+	if s.slos != nil {
+		objectives = s.slos.Objectives()
+	}
+	if args.Name != "" {
+		objectives = slices.DeleteFunc(objectives, func(o slo.Objective) bool { return o.Name != args.Name })
+		if len(objectives) == 0 {
+			return nil, nil, fmt.Errorf("no SLO named %q", args.Name)
+		}
+	}
+	if len(objectives) == 0 {
+		return nil, nil, errors.New(`no SLOs are defined; add an "slos" section to the config file`)
+	}
+
+	statuses, err := slo.Evaluate(ctx, s.duckdbRepo, objectives, args.Hostname, time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to evaluate SLOs: %w", err)
+	}
+	return nil, &SLOStatusResult{SLOs: statuses}, nil
+}
+
+// handleSuggestQuestions builds starter questions from the current flags,
+// this host's incidents over the last day and the configured SLOs. Only
+// the current snapshot is required; history that cannot be read just
+// leaves its questions out.
+func (s *Server) handleSuggestQuestions(ctx context.Context, _ *mcp.CallToolRequest, args SuggestQuestionsArgs) (*mcp.CallToolResult, *SuggestQuestionsResult, error) {
+	snap, flags, err := s.currentFlags(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &SuggestQuestionsResult{Hostname: snap.Hostname, Flags: flags.ActiveFlags()}
+	if res.Flags == nil {
+		res.Flags = []string{}
+	}
+
+	var incidents []relational.FlagIncidents
+	var statuses []slo.Status
+	if s.duckdbRepo != nil {
+		now := time.Now()
+		digests, err := s.duckdbRepo.HostDigests(ctx, now.Add(-24*time.Hour))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: suggest_questions could not read incidents: %v\n", err)
+		}
+		for _, d := range digests {
+			if d.Hostname == snap.Hostname {
+				incidents = d.Incidents
+			}
+		}
+		if s.slos != nil && len(s.slos.Objectives()) > 0 {
+			if statuses, err = slo.Evaluate(ctx, s.duckdbRepo, s.slos.Objectives(), snap.Hostname, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: suggest_questions could not evaluate SLOs: %v\n", err)
+			}
+		}
+	}
+
+	res.Questions = suggestQuestions(res.Flags, incidents, statuses, args.Limit)
+	return nil, res, nil
+}
+
+// handleGetServerStatus reports the dependencies' last checked state, or
+// checks them now when asked.
+func (s *Server) handleGetServerStatus(ctx context.Context, _ *mcp.CallToolRequest, args GetServerStatusArgs) (*mcp.CallToolResult, *GetServerStatusResult, error) {
+	res := &GetServerStatusResult{
+		Version:      s.version,
+		Uptime:       time.Since(s.startedAt).Round(time.Second).String(),
+		Admin:        s.admin,
+		Dependencies: []health.Status{},
+	}
+	if s.health != nil {
+		if args.Check {
+			res.Dependencies = s.health.Check(ctx)
+		} else {
+			res.Dependencies = s.health.Statuses()
+		}
+	}
+	if s.graphPool != nil {
+		res.GraphIngest = s.graphPool.Stats()
+	}
+	return nil, res, nil
+}
+
+// handleExportGraph exports the subgraph around a snapshot and stores an
+// HTML viewer of it as an artifact.
+func (s *Server) handleExportGraph(ctx context.Context, _ *mcp.CallToolRequest, args ExportGraphArgs) (*mcp.CallToolResult, *ExportGraphResult, error) {
+	format := args.Format
+	if format == "" {
+		format = graph.FormatDOT
+	}
+	if !slices.Contains(graph.ExportFormats, format) {
+		return nil, nil, fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(graph.ExportFormats, ", "))
+	}
+	hops := args.Hops
+	if hops == 0 {
+		hops = 2
+	}
+
+	snapshotID := args.SnapshotID
+	if snapshotID == "" {
+		host := args.Hostname
+		if host == "" {
+			host, _ = os.Hostname()
+		}
+		var err error
+		snapshotID, err = graph.LatestSnapshotID(ctx, s.neo4jClient, host, true)
+		if errors.Is(err, graph.ErrNoSnapshot) {
+			snapshotID, err = graph.LatestSnapshotID(ctx, s.neo4jClient, host, false)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("no snapshot of %s: %w", host, err)
+		}
+	}
+
+	sub, err := graph.SnapshotSubgraph(ctx, s.neo4jClient, snapshotID, hops)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read subgraph: %w", err)
+	}
+	res := &ExportGraphResult{SnapshotID: snapshotID, Format: format, Nodes: len(sub.Nodes), Edges: len(sub.Edges)}
+	if format != graph.FormatHTML {
+		content, err := sub.Export(format)
+		if err != nil {
+			return nil, nil, err
+		}
+		res.Content = string(content)
+	}
+
+	if s.duckdbRepo != nil {
+		page, err := sub.HTML()
+		if err != nil {
+			return nil, nil, err
+		}
+		res.ViewerID, err = s.duckdbRepo.InsertArtifact(ctx, relational.Artifact{
+			Kind: relational.ArtifactGraph,
+			MIME: graph.ExportMIME[graph.FormatHTML],
+			Name: "snapshot-" + snapshotID + ".html",
+			Data: page,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to store graph viewer: %w", err)
+		}
+	}
+	return nil, res, nil
+}
+
+// handleCompareHosts diffs two hosts' aggregates from DuckDB.
+func (s *Server) handleCompareHosts(ctx context.Context, _ *mcp.CallToolRequest, args CompareHostsArgs) (*mcp.CallToolResult, *relational.HostComparison, error) {
+	window, err := parseWindow(args.Window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comparison, err := s.duckdbRepo.CompareHosts(ctx, args.HostA, args.HostB, window)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compare hosts: %w", err)
+	}
+
+	return nil, comparison, nil
+}
+
+// handleGetBookmarks lists bookmarked snapshots from DuckDB.
+func (s *Server) handleGetBookmarks(ctx context.Context, _ *mcp.CallToolRequest, args BookmarksArgs) (*mcp.CallToolResult, *BookmarksResult, error) {
+	window := 30 * 24 * time.Hour
+	if args.Window != "" {
+		var err error
+		if window, err = parseWindow(args.Window); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	limit := pageSize(args.Limit, defaultPageSize)
+	key := queryKey("get_bookmarks", BookmarksArgs{Hostname: args.Hostname, Window: args.Window, Limit: limit})
+	fetch, err := pageFetchLimit(key, args.Cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := s.duckdbRepo.ListAnnotations(ctx, args.Hostname, relational.AnnotationBookmark, time.Now().Add(-window), fetch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list bookmarks: %w", err)
+	}
+
+	page, next, err := paginate(list, key, args.Cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &BookmarksResult{Bookmarks: page, NextCursor: next}, nil
+}
+
+// handleGetContainerHistory reads hourly container rollups from DuckDB.
+func (s *Server) handleGetContainerHistory(ctx context.Context, _ *mcp.CallToolRequest, args ContainerHistoryArgs) (*mcp.CallToolResult, *ContainerHistoryResult, error) {
+	window, err := parseWindow(args.Window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := pageSize(args.Limit, defaultPageSize)
+	key := queryKey("get_container_history", ContainerHistoryArgs{Hostname: args.Hostname, Container: args.Container, Window: args.Window, Limit: limit})
+	if _, err := decodeCursor(key, args.Cursor); err != nil {
+		return nil, nil, err
+	}
+
+	hours, err := s.duckdbRepo.ContainerHistory(ctx, args.Hostname, args.Container, time.Now().Add(-window))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query container history: %w", err)
+	}
+
+	page, next, err := paginate(hours, key, args.Cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &ContainerHistoryResult{Hours: page, NextCursor: next}, nil
+}
+
+// handleGetUserUsage aggregates per-user resource usage from DuckDB.
+func (s *Server) handleGetUserUsage(ctx context.Context, _ *mcp.CallToolRequest, args UserUsageArgs) (*mcp.CallToolResult, *relational.UserUsageReport, error) {
+	window, err := parseWindow(args.Window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.duckdbRepo.UserUsage(ctx, args.Hostname, window, 20)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user usage: %w", err)
+	}
+
+	return nil, report, nil
+}
+
+func (s *Server) handleSetCollectionProfile(ctx context.Context, req *mcp.CallToolRequest, args CollectionProfileArgs) (*mcp.CallToolResult, *CollectionProfileResult, error) {
+	if args.Name != "" {
+		if err := s.authorize(req, "set_collection_profile", RoleAnalyst); err != nil {
+			return nil, nil, err
+		}
+		if _, err := s.scheduler.Activate(args.Name); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, &CollectionProfileResult{Active: s.scheduler.Current(), Profiles: s.scheduler.Profiles()}, nil
+}
+
+func (s *Server) handleSetCollectionInterval(ctx context.Context, _ *mcp.CallToolRequest, args SetCollectionIntervalArgs) (*mcp.CallToolResult, *CollectionProfileResult, error) {
+	name := args.Profile
+	if name == "" {
+		name = schedule.Default
+	}
+	i := slices.IndexFunc(s.scheduler.Profiles(), func(p schedule.Profile) bool { return p.Name == name })
+	if i < 0 {
+		return nil, nil, fmt.Errorf("unknown profile %q", name)
+	}
+	p := s.scheduler.Profiles()[i]
+	for _, iv := range []struct {
+		name, value string
+		dst         *time.Duration
+	}{{"fast", args.Fast, &p.Fast}, {"slow", args.Slow, &p.Slow}} {
+		if iv.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(iv.value)
+		if err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("invalid %s interval %q", iv.name, iv.value)
+		}
+		*iv.dst = d
+	}
+	s.scheduler.Register(p)
+	if s.scheduler.Current().Profile.Name == name {
+		if _, err := s.scheduler.Activate(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Admin: profile %s now collects every %s/%s\n", name, p.Fast, p.Slow)
+	return nil, &CollectionProfileResult{Active: s.scheduler.Current(), Profiles: s.scheduler.Profiles()}, nil
+}
+
+func (s *Server) handleToggleSensor(ctx context.Context, _ *mcp.CallToolRequest, args ToggleSensorArgs) (*mcp.CallToolResult, *ToggleSensorResult, error) {
+	toggler, ok := s.sensorProvider.(collector.SensorToggler)
+	if !ok {
+		return nil, nil, fmt.Errorf("this collector's sensors cannot be toggled")
+	}
+	if args.Sensor != "" {
+		if err := toggler.SetSensorEnabled(args.Sensor, args.Enabled); err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Admin: sensor %s enabled=%v\n", args.Sensor, args.Enabled)
+	}
+	return nil, &ToggleSensorResult{Sensors: toggler.SensorStates()}, nil
+}
+
+func (s *Server) handleSetThreshold(ctx context.Context, _ *mcp.CallToolRequest, args SetThresholdArgs) (*mcp.CallToolResult, *SetThresholdResult, error) {
+	cfg := s.flaggerSvc.Config()
+	if args.Metric != "" {
+		var err error
+		cfg, err = config.SetThreshold(cfg, args.Metric, flagger.Thresholds{Warning: args.Warning, Critical: args.Critical})
+		if err != nil {
+			return nil, nil, err
+		}
+		s.flaggerSvc.SetConfig(cfg)
+		fmt.Fprintf(os.Stderr, "Admin: %s thresholds now warning %.1f, critical %.1f\n", args.Metric, args.Warning, args.Critical)
+	}
+	return nil, &SetThresholdResult{Thresholds: config.Thresholds(cfg)}, nil
+}
+
+// handleEditThresholds previews threshold edits as a config diff and, once
+// confirmed, writes them to the config file for config.Watch to apply.
+// Confirmation takes the previewed edits rather than the instruction, so
+// what is written is exactly what the user saw.
+func (s *Server) handleEditThresholds(ctx context.Context, _ *mcp.CallToolRequest, args EditThresholdsArgs) (*mcp.CallToolResult, *EditThresholdsResult, error) {
+	file, err := config.Load(s.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := s.flaggerSvc.Config()
+
+	edits := args.Edits
+	switch {
+	case args.Confirm && len(edits) == 0:
+		return nil, nil, errors.New("confirm needs the edits returned by the preview")
+	case len(edits) == 0 && strings.TrimSpace(args.Instruction) == "":
+		return nil, nil, errors.New("give an instruction, e.g. 'warn me when disk hits 85%'")
+	case len(edits) == 0:
+		levels, err := json.Marshal(config.Thresholds(current))
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := s.ragEngine.ParseThresholdEdits(ctx, args.Instruction, config.ThresholdNames(), string(levels))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse instruction: %w", err)
+		}
+		if edits, err = config.ParseThresholdEdits([]byte(raw)); err != nil {
+			return nil, nil, fmt.Errorf("instruction did not yield a valid threshold change: %w", err)
+		}
+	}
+
+	edited, err := file.WithThresholdEdits(current, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	before, err := file.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := edited.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &EditThresholdsResult{Edits: edits, Diff: config.Diff(string(before), string(after))}
+	if !args.Confirm || res.Diff == "" {
+		return nil, res, nil
+	}
+
+	if err := config.Save(s.configPath, edited); err != nil {
+		return nil, nil, err
+	}
+	res.Applied = true
+	fmt.Fprintf(os.Stderr, "Admin: thresholds edited in %s\n%s", s.configPath, res.Diff)
+	return nil, res, nil
+}
+
+func (s *Server) handleInjectFault(ctx context.Context, _ *mcp.CallToolRequest, args InjectFaultArgs) (*mcp.CallToolResult, *InjectFaultResult, error) {
+	switch {
+	case args.Clear:
+		chaos.Default.Clear(args.Fault)
+		fmt.Fprintf(os.Stderr, "Admin: cleared fault %q\n", args.Fault)
+	case args.Fault != "":
+		var d time.Duration
+		if args.Duration != "" {
+			var err error
+			if d, err = time.ParseDuration(args.Duration); err != nil || d <= 0 {
+				return nil, nil, fmt.Errorf("invalid duration %q", args.Duration)
+			}
+		}
+		if err := chaos.Default.Inject(args.Fault, d, args.Count); err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Admin: injected fault %s (duration %v, count %d)\n", args.Fault, d, args.Count)
+	}
+	return nil, &InjectFaultResult{Active: chaos.Default.Active()}, nil
+}
+
+// forensicContainerPct is the CPU or memory share above which a running
+// container is inspected by default.
+const forensicContainerPct = 80
+
+// forensicLogSummary is how many kernel log lines the tool result includes.
+const forensicLogSummary = 20
+
+func (s *Server) handleCaptureForensics(ctx context.Context, _ *mcp.CallToolRequest, args CaptureForensicsArgs) (*mcp.CallToolResult, *CaptureForensicsResult, error) {
+	containers := args.Containers
+	if len(containers) == 0 {
+		if stats, err := s.sensorProvider.GetFastMetrics(ctx); err == nil {
+			containers = hotContainers(stats.DockerContainers)
+		}
+	}
+
+	capture, err := collector.CaptureForensics(ctx, containers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to capture forensics: %w", err)
+	}
+	data, err := json.Marshal(capture)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode capture: %w", err)
+	}
+
+	var snapshotID int64
+	if latest, err := s.duckdbRepo.GetLatestSnapshot(ctx, ""); err == nil {
+		snapshotID = latest.SnapshotID
+	}
+	id, err := s.duckdbRepo.InsertArtifact(ctx, relational.Artifact{
+		SnapshotID: snapshotID,
+		Kind:       relational.ArtifactForensics,
+		MIME:       "application/json",
+		Data:       data,
+		CreatedAt:  capture.CapturedAt,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store capture: %w", err)
+	}
+
+	res := &CaptureForensicsResult{
+		ArtifactID:   id,
+		SnapshotID:   snapshotID,
+		SizeBytes:    len(data),
+		CapturedAt:   capture.CapturedAt,
+		ProcessCount: len(capture.Processes),
+		Ports:        capture.Ports,
+		KernelLog:    capture.Kernel[max(len(capture.Kernel)-forensicLogSummary, 0):],
+		Errors:       capture.Errors,
+	}
+	for _, c := range capture.Containers {
+		res.Containers = append(res.Containers, c.Name)
+	}
+	return nil, res, nil
+}
+
+func (s *Server) handleGetArtifact(ctx context.Context, _ *mcp.CallToolRequest, args GetArtifactArgs) (*mcp.CallToolResult, *GetArtifactResult, error) {
+	if args.ArtifactID == 0 {
+		limit := pageSize(args.Limit, defaultPageSize)
+		key := queryKey("get_artifact", GetArtifactArgs{SnapshotID: args.SnapshotID, Kind: args.Kind, Limit: limit})
+		fetch, err := pageFetchLimit(key, args.Cursor, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		list, err := s.duckdbRepo.ListArtifacts(ctx, args.SnapshotID, args.Kind, fetch)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list artifacts: %w", err)
+		}
+		page, next, err := paginate(list, key, args.Cursor, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &GetArtifactResult{Artifacts: page, NextCursor: next}, nil
+	}
+
+	a, err := s.duckdbRepo.GetArtifact(ctx, args.ArtifactID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get artifact: %w", err)
+	}
+	res := &GetArtifactResult{Artifact: a}
+	if isTextMIME(a.MIME) && utf8.Valid(a.Data) {
+		res.Content, res.Encoding = string(a.Data), "text"
+	} else {
+		res.Content, res.Encoding = base64.StdEncoding.EncodeToString(a.Data), "base64"
+	}
+	return nil, res, nil
+}
+
+// isTextMIME reports whether content of this type can be returned as text.
+func isTextMIME(mime string) bool {
+	return strings.HasPrefix(mime, "text/") || mime == "application/json" || strings.HasSuffix(mime, "+xml")
+}
+
+// hotContainers names running containers above forensicContainerPct CPU or memory.
+func hotContainers(cs []collector.DockerContainerInfo) []string {
+	var names []string
+	for _, c := range cs {
+		if c.Running && (c.CPUUsage >= forensicContainerPct || c.MemPercent >= forensicContainerPct) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// parseWindow parses a lookback window, defaulting to 24h and capping at 30 days.
+func parseWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("window must be positive")
+	}
+	return min(d, 30*24*time.Hour), nil
+}
+
+// GraphIngest returns the pool pushing snapshots into Neo4j.
+func (s *Server) GraphIngest() *database.GraphIngestPool {
+	return s.graphPool
+}
+
+// Dependencies returns the monitor checking the Neo4j and DuckDB
+// connections.
+func (s *Server) Dependencies() *health.Monitor {
+	return s.health
+}
+
+// Start serves MCP over stdio until the client disconnects or ctx is
+// done. In the latter case tool calls in flight get the shutdown timeout to
+// finish before the session is closed; see Drain.
 func (s *Server) Start(ctx context.Context) error {
 	fmt.Fprintf(os.Stderr, "Starting SysChecker MCP Server on stdio...\n")
-	transport := &mcp.StdioTransport{}
-	return s.mcpServer.Run(ctx, transport)
+	// The session outlives ctx so cancelling it does not abort the calls
+	// being drained.
+	ss, err := s.mcpServer.Connect(context.WithoutCancel(ctx), &mcp.StdioTransport{}, nil)
+	if err != nil {
+		return err
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- ss.Wait() }()
+
+	select {
+	case err := <-closed:
+		return err
+	case <-ctx.Done():
+	}
+	fmt.Fprintf(os.Stderr, "Shutting down, waiting up to %s for tool calls in flight...\n", s.shutdownTimeout)
+	if !s.Drain(s.shutdownTimeout) {
+		fmt.Fprintf(os.Stderr, "Warning: tool calls still running after %s, abandoning them\n", s.shutdownTimeout)
+	}
+	ss.Close()
+	<-closed
+	return ctx.Err()
 }
 
-// Close cleans up resources.
+// Close releases the server's resources in dependency order: the jobs
+// feeding Neo4j stop first, queued graph ingests are flushed until ctx is
+// done, and the clients close last. Ingests still failing by then land in
+// the graph buffer, when there is one. The caller closes the repo.
 func (s *Server) Close(ctx context.Context) error {
-	// Stop background ingestion
 	s.stopBackgroundIngest()
+	if s.digestCancel != nil {
+		s.digestCancel()
+		<-s.digestDone
+	}
+	if s.healthCancel != nil {
+		s.healthCancel()
+		<-s.healthDone
+	}
+	if s.graphPool != nil {
+		flushed := make(chan struct{})
+		go func() {
+			s.graphPool.Close()
+			close(flushed)
+		}()
+		select {
+		case <-flushed:
+		case <-ctx.Done():
+			st := s.graphPool.Stats()
+			fmt.Fprintf(os.Stderr, "Warning: closing Neo4j with %d graph ingests queued and %d in flight\n", st.Queued, st.InFlight)
+		}
+	}
+	if s.lease != nil {
+		if err := s.lease.Release(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 
+	var errs []error
+	if s.duckdbRepo != nil && !s.readOnly {
+		// Fold the WAL into the database file so the next start need not
+		// replay it.
+		if err := s.duckdbRepo.Checkpoint(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if s.geminiClient != nil {
 		s.geminiClient.Close()
 	}
 	if s.neo4jClient != nil {
 		// Note: Not calling Reset() to preserve data between sessions
 		// If ephemeral behavior is desired, uncomment: s.neo4jClient.Reset(ctx)
-		s.neo4jClient.Close(ctx)
+		if err := s.neo4jClient.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close neo4j: %w", err))
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-// ingestSnapshot runs the data pipeline once and ingests into Neo4j.
+// ingestSnapshot runs the data pipeline once and queues it for Neo4j.
 func (s *Server) ingestSnapshot(ctx context.Context) error {
+	// An agent already collecting this host into the database wins
+	if held, err := s.lease.Hold(ctx, 3*s.scheduler.Current().Profile.Slow); err != nil || !held {
+		return err
+	}
+
 	// Run the full pipeline: Collect -> Adapt -> Rates -> Flag -> Bundle
 	payload, err := output.RunPipeline(
 		ctx,
 		s.sensorProvider,
 		s.flaggerSvc,
 		s.duckdbRepo,
-		"mcp-server",
+		s.agentID,
 		"mcp-host",
 		"mcp-session",
+		output.WithLabeler(s.duckdbRepo),
 	)
 	if err != nil {
 		return fmt.Errorf("pipeline failed: %w", err)
@@ -289,8 +1768,8 @@ func (s *Server) ingestSnapshot(ctx context.Context) error {
 		fmt.Fprintf(os.Stderr, "Warning: DuckDB insert failed: %v\n", err)
 	}
 
-	// Ingest into Neo4j for RAG queries
-	if err := s.neo4jClient.IngestSnapshot(ctx, payload); err != nil {
+	// Queue for Neo4j for RAG queries; the pool reports ingest failures
+	if err := s.graphPool.Submit(payload); err != nil {
 		return fmt.Errorf("neo4j ingest failed: %w", err)
 	}
 
@@ -298,7 +1777,7 @@ func (s *Server) ingestSnapshot(ctx context.Context) error {
 }
 
 // startBackgroundIngest starts periodic data ingestion.
-func (s *Server) startBackgroundIngest(interval time.Duration) {
+func (s *Server) startBackgroundIngest() {
 	s.ingestMu.Lock()
 	defer s.ingestMu.Unlock()
 
@@ -312,22 +1791,31 @@ func (s *Server) startBackgroundIngest(interval time.Duration) {
 
 	go func() {
 		defer s.ingestWg.Done()
+		interval := s.scheduler.Current().Profile.Slow
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
+			changed := s.scheduler.Changed()
 			select {
 			case <-ctx.Done():
 				return
+			case <-changed:
 			case <-ticker.C:
 				if err := s.ingestSnapshot(ctx); err != nil {
 					fmt.Fprintf(os.Stderr, "Background ingest failed: %v\n", err)
 				}
 			}
+
+			if next := s.scheduler.Current().Profile.Slow; next != interval {
+				ticker.Reset(next)
+				interval = next
+				fmt.Fprintf(os.Stderr, "Background ingest interval now %v\n", interval)
+			}
 		}
 	}()
 
-	fmt.Fprintf(os.Stderr, "Background data ingestion started (interval: %v)\n", interval)
+	fmt.Fprintf(os.Stderr, "Background data ingestion started (profile: %s)\n", s.scheduler.Current().Profile.Name)
 }
 
 // stopBackgroundIngest stops the periodic ingestion worker.
@@ -3,11 +3,21 @@ package mcpserver
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"syschecker/internal/actions"
 	"syschecker/internal/collector"
+	"syschecker/internal/config"
+	"syschecker/internal/database/graph"
 	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/health"
 	"syschecker/internal/output"
+	"syschecker/internal/schedule"
 )
 
 // MockStatsProvider implements collector.StatsProvider for testing
@@ -37,6 +47,7 @@ type MockGraphClient struct {
 	CypherResult []map[string]any
 	CypherErr    error
 	Closed       bool
+	LastOpts     graph.CypherOptions
 }
 
 func (m *MockGraphClient) IngestSnapshot(ctx context.Context, payload *output.PipelinePayload) error {
@@ -48,9 +59,17 @@ func (m *MockGraphClient) Reset(ctx context.Context) error {
 }
 
 func (m *MockGraphClient) ExecuteCypher(ctx context.Context, query string) ([]map[string]any, error) {
+	return m.RunCypher(ctx, query, graph.CypherOptions{})
+}
+
+func (m *MockGraphClient) RunCypher(ctx context.Context, query string, opts graph.CypherOptions) ([]map[string]any, error) {
+	m.LastOpts = opts
 	if m.CypherErr != nil {
 		return nil, m.CypherErr
 	}
+	if opts.MaxRows > 0 && len(m.CypherResult) > opts.MaxRows {
+		return m.CypherResult[:opts.MaxRows], nil
+	}
 	return m.CypherResult, nil
 }
 
@@ -160,6 +179,119 @@ func TestHandleGetRealtimeMetrics_InvalidType(t *testing.T) {
 	}
 }
 
+// countingProvider counts slow collections.
+type countingProvider struct {
+	MockStatsProvider
+	slowCalls int
+}
+
+func (c *countingProvider) GetSlowMetrics(ctx context.Context) (*collector.RawStats, error) {
+	c.slowCalls++
+	return c.MockStatsProvider.GetSlowMetrics(ctx)
+}
+
+func TestHandleGetMergedMetrics(t *testing.T) {
+	provider := &countingProvider{MockStatsProvider: MockStatsProvider{
+		FastStats: &collector.RawStats{CPUUsage: 45.5},
+		SlowStats: &collector.RawStats{Hostname: "test-host", ActiveTCP: 100},
+	}}
+	s := &Server{sensorProvider: provider, agentID: "agent-1"}
+
+	ctx := context.Background()
+	for range 2 {
+		_, merged, err := s.handleGetMergedMetrics(ctx, nil, MetricsArgs{MetricType: "merged"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if merged.CPUUsagePct != 45.5 || merged.Hostname != "test-host" || merged.ActiveTCP != 100 {
+			t.Errorf("merged = %+v", merged)
+		}
+		if merged.Kind != relational.KindMerged || merged.AgentID != "agent-1" {
+			t.Errorf("Expected a merged snapshot of agent-1, got %s of %s", merged.Kind, merged.AgentID)
+		}
+	}
+	if provider.slowCalls != 1 {
+		t.Errorf("Expected slow metrics to be collected once, got %d", provider.slowCalls)
+	}
+
+	s.slowAt = s.slowAt.Add(-defaultSlowCacheTTL)
+	if _, _, err := s.handleGetMergedMetrics(ctx, nil, MetricsArgs{MetricType: "merged"}); err != nil {
+		t.Fatal(err)
+	}
+	if provider.slowCalls != 2 {
+		t.Errorf("Expected stale slow metrics to be collected again, got %d calls", provider.slowCalls)
+	}
+
+	_, out, err := s.handleRealtimeMetrics(ctx, nil, MetricsArgs{MetricType: "merged", Fields: []string{"cpuusagepct"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged, ok := out.(*relational.RawStatsFixed); !ok || merged.CPUUsagePct != 45.5 || merged.Hostname != "" {
+		t.Errorf("Expected only CPUUsagePct, got %+v", out)
+	}
+}
+
+func TestHandleExplainFlags(t *testing.T) {
+	s := &Server{
+		sensorProvider: &MockStatsProvider{
+			FastStats: &collector.RawStats{CPUUsage: 95, DockerAvailable: true},
+			SlowStats: &collector.RawStats{Hostname: "test-host"},
+		},
+		flaggerSvc: flagger.NewFlaggerService(flagger.DefaultConfig()),
+	}
+
+	_, result, err := s.handleExplainFlags(context.Background(), nil, ExplainFlagsArgs{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Hostname != "test-host" || result.SeverityLevel != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Flags) != 1 || result.Flags[0].Flag != "cpu_overloaded" {
+		t.Fatalf("Expected cpu_overloaded alone, got %+v", result.Flags)
+	}
+	if ev := result.Flags[0].Evidence; len(ev) != 1 || ev[0].Value != 95 || ev[0].Critical != 90 {
+		t.Errorf("evidence = %+v", ev)
+	}
+}
+
+func TestHandleExecuteAction(t *testing.T) {
+	executor, err := actions.NewExecutor([]string{actions.KindRestartContainer}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		sensorProvider: &MockStatsProvider{FastStats: &collector.RawStats{
+			DockerAvailable: true,
+			CPUCores:        1,
+			DockerContainers: []collector.DockerContainerInfo{
+				{ID: "aaa", Name: "api", Running: true, CPUUsage: 95},
+			},
+			TopProcesses: []collector.ProcessStat{{PID: 1, Name: "node", CPU: 95, ContainerID: "aaa"}},
+		}},
+		flaggerSvc: flagger.NewFlaggerService(flagger.DefaultConfig()),
+		executor:   executor,
+	}
+	ctx := context.Background()
+
+	_, recs, err := s.handleRecommendActions(ctx, nil, RecommendActionsArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs.Actions) != 1 || recs.Actions[0].Action.ID != "restart_container:api" || !recs.Actions[0].Action.Confirm || len(recs.Allowed) != 1 {
+		t.Fatalf("recommendations = %+v", recs)
+	}
+
+	// The container does not exist here, so even the dry run fails its
+	// precondition.
+	if _, _, err := s.handleExecuteAction(ctx, nil, ExecuteActionArgs{ActionID: "restart_container:api"}); err == nil || !strings.Contains(err.Error(), "precondition") {
+		t.Errorf("Expected a failed precondition, got %v", err)
+	}
+	if _, _, err := s.handleExecuteAction(ctx, nil, ExecuteActionArgs{ActionID: "restart_container:db"}); err == nil {
+		t.Error("Expected an error for an action that is not recommended")
+	}
+}
+
 func TestHandleGetRealtimeMetrics_ProviderError(t *testing.T) {
 	mockProvider := &MockStatsProvider{
 		FastErr: errors.New("sensor failure"),
@@ -304,3 +436,206 @@ func TestHistoricalSnapshotsResult(t *testing.T) {
 		t.Errorf("Expected 1 snapshot, got %d", len(result.Snapshots))
 	}
 }
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 24 * time.Hour, false},
+		{"6h", 6 * time.Hour, false},
+		{"2000h", 30 * 24 * time.Hour, false},
+		{"-1h", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseWindow(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWindow(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseWindow(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestHandleSetCollectionProfile(t *testing.T) {
+	s := &Server{scheduler: schedule.NewScheduler()}
+	ctx := context.Background()
+
+	_, result, err := s.handleSetCollectionProfile(ctx, nil, CollectionProfileArgs{Name: "incident"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Active.Profile.Name != schedule.Incident || result.Active.ExpiresAt.IsZero() {
+		t.Errorf("Expected timed incident profile, got %+v", result.Active)
+	}
+	if len(result.Profiles) != 3 {
+		t.Errorf("Expected 3 profiles, got %d", len(result.Profiles))
+	}
+
+	if _, _, err := s.handleSetCollectionProfile(ctx, nil, CollectionProfileArgs{Name: "turbo"}); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
+
+func TestAdminTools(t *testing.T) {
+	s := &Server{
+		scheduler:      schedule.NewScheduler(),
+		flaggerSvc:     flagger.NewFlaggerService(flagger.DefaultConfig()),
+		sensorProvider: &MockStatsProvider{},
+	}
+	ctx := context.Background()
+
+	_, profiles, err := s.handleSetCollectionInterval(ctx, nil, SetCollectionIntervalArgs{Slow: "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := profiles.Active.Profile; p.Name != schedule.Default || p.Slow != 5*time.Second || p.Fast != time.Second {
+		t.Errorf("active profile = %+v", p)
+	}
+	if _, _, err := s.handleSetCollectionInterval(ctx, nil, SetCollectionIntervalArgs{Profile: "incident", Fast: "-1s"}); err == nil {
+		t.Error("Expected error for a negative interval")
+	}
+
+	_, th, err := s.handleSetThreshold(ctx, nil, SetThresholdArgs{Metric: "cpu", Warning: 85, Critical: 97})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (flagger.Thresholds{Warning: 85, Critical: 97}); th.Thresholds["cpu"] != want || s.flaggerSvc.Config().CPU != want {
+		t.Errorf("cpu thresholds = %+v, flagger = %+v", th.Thresholds["cpu"], s.flaggerSvc.Config().CPU)
+	}
+	if _, _, err := s.handleSetThreshold(ctx, nil, SetThresholdArgs{Metric: "cpu", Warning: 90, Critical: 50}); err == nil {
+		t.Error("Expected error when warning is above critical")
+	}
+
+	if _, _, err := s.handleToggleSensor(ctx, nil, ToggleSensorArgs{Sensor: collector.SensorDocker}); err == nil {
+		t.Error("Expected error for a collector without toggles")
+	}
+	s.sensorProvider = collector.NewSystemCollector()
+	_, sensors, err := s.handleToggleSensor(ctx, nil, ToggleSensorArgs{Sensor: collector.SensorJournal, Enabled: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sensors.Sensors[collector.SensorJournal] || !sensors.Sensors[collector.SensorDocker] {
+		t.Errorf("sensors = %v", sensors.Sensors)
+	}
+}
+
+func TestHotContainers(t *testing.T) {
+	got := hotContainers([]collector.DockerContainerInfo{
+		{Name: "api", Running: true, CPUUsage: 95},
+		{Name: "cache", Running: true, MemPercent: 85},
+		{Name: "idle", Running: true, CPUUsage: 3, MemPercent: 10},
+		{Name: "dead", Running: false, CPUUsage: 99},
+	})
+	if len(got) != 2 || got[0] != "api" || got[1] != "cache" {
+		t.Errorf("Expected [api cache], got %v", got)
+	}
+}
+
+func TestIsTextMIME(t *testing.T) {
+	for mime, want := range map[string]bool{
+		"text/plain":       true,
+		"application/json": true,
+		"image/svg+xml":    true,
+		"application/gzip": false,
+	} {
+		if got := isTextMIME(mime); got != want {
+			t.Errorf("isTextMIME(%q) = %v, want %v", mime, got, want)
+		}
+	}
+}
+
+func TestHandleEditThresholds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syschecker.json")
+	if err := os.WriteFile(path, []byte(`{"ignore": [{"flag": "docker_unavailable"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{flaggerSvc: flagger.NewFlaggerService(flagger.DefaultConfig()), configPath: path}
+	ctx := context.Background()
+	warning := 85.0
+	edits := []config.ThresholdEdit{{Metric: "disk", Warning: &warning}}
+
+	if _, _, err := s.handleEditThresholds(ctx, nil, EditThresholdsArgs{Instruction: "warn me at 85% disk", Confirm: true}); err == nil {
+		t.Error("confirmed an instruction without previewed edits")
+	}
+
+	_, preview, err := s.handleEditThresholds(ctx, nil, EditThresholdsArgs{Edits: edits})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Applied || !strings.Contains(preview.Diff, `+    "disk": {`) {
+		t.Errorf("preview = %+v", preview)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "disk") {
+		t.Error("preview wrote the config file")
+	}
+
+	_, res, err := s.handleEditThresholds(ctx, nil, EditThresholdsArgs{Edits: edits, Confirm: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Applied || f.Thresholds["disk"].Warning != 85 || len(f.Ignore) != 1 {
+		t.Errorf("result = %+v, file = %+v", res, f)
+	}
+}
+
+func TestHandleExportGraph(t *testing.T) {
+	mockGraph := &MockGraphClient{CypherResult: []map[string]any{{
+		"anchors": []any{
+			map[string]any{"id": "h", "labels": []any{"Host"}, "properties": map[string]any{"hostname": "api-1"}},
+			map[string]any{"id": "s", "labels": []any{"Snapshot"}, "properties": map[string]any{"snapshot_id": "api-1-1"}},
+		},
+		"anchorRels": []any{map[string]any{"type": "HAS_SNAPSHOT", "startNode": "h", "endNode": "s"}},
+	}}}
+	s := &Server{neo4jClient: mockGraph}
+	ctx := context.Background()
+
+	_, res, err := s.handleExportGraph(ctx, nil, ExportGraphArgs{SnapshotID: "api-1-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Format != "dot" || res.Nodes != 2 || res.Edges != 1 || !strings.Contains(res.Content, `"Host api-1"`) {
+		t.Errorf("result = %+v", res)
+	}
+	if mockGraph.LastOpts.Params["snapshot_id"] != "api-1-1" {
+		t.Errorf("params = %v", mockGraph.LastOpts.Params)
+	}
+	if _, _, err := s.handleExportGraph(ctx, nil, ExportGraphArgs{SnapshotID: "api-1-1", Format: "svg"}); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+func TestHandleGetServerStatus(t *testing.T) {
+	neo4jDown := errors.New("connection refused")
+	s := &Server{
+		version:   "1.2.3",
+		startedAt: time.Now().Add(-time.Hour),
+		health: health.NewMonitor([]health.Dependency{
+			{Name: "neo4j", Ping: func(context.Context) error { return neo4jDown }},
+			{Name: "duckdb", Ping: func(context.Context) error { return nil }},
+		}),
+	}
+	ctx := context.Background()
+
+	_, res, err := s.handleGetServerStatus(ctx, nil, GetServerStatusArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != "1.2.3" || res.Uptime != "1h0m0s" || len(res.Dependencies) != 2 || !res.Dependencies[1].CheckedAt.IsZero() {
+		t.Errorf("unchecked = %+v", res)
+	}
+
+	_, res, _ = s.handleGetServerStatus(ctx, nil, GetServerStatusArgs{Check: true})
+	if deps := res.Dependencies; deps[0].Up || deps[0].Error != "connection refused" || !deps[1].Up {
+		t.Errorf("checked = %+v", deps)
+	}
+}
@@ -0,0 +1,59 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultShutdownTimeout is how long Start lets in-flight tool calls finish
+// once its context is done.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ErrShuttingDown is returned for tool calls received after shutdown began.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// trackCalls counts tool calls in flight so Drain can wait for them, and
+// turns away new ones once it has started.
+func (s *Server) trackCalls(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/call" {
+			return next(ctx, method, req)
+		}
+		s.callsMu.Lock()
+		if s.draining {
+			s.callsMu.Unlock()
+			return nil, ErrShuttingDown
+		}
+		s.calls.Add(1)
+		s.callsMu.Unlock()
+		defer s.calls.Done()
+		return next(ctx, method, req)
+	}
+}
+
+// Drain stops background ingestion and new tool calls, then waits up to
+// timeout for the calls in flight. It reports whether they all finished.
+func (s *Server) Drain(timeout time.Duration) bool {
+	s.stopBackgroundIngest()
+
+	s.callsMu.Lock()
+	s.draining = true
+	s.callsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.calls.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestDrainWaitsForToolCalls(t *testing.T) {
+	s := &Server{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := s.trackCalls(func(ctx context.Context, method string, _ mcp.Request) (mcp.Result, error) {
+		if method == "tools/call" {
+			close(started)
+			<-release
+		}
+		return nil, nil
+	})
+	ctx := context.Background()
+
+	finished := make(chan error, 1)
+	go func() {
+		_, err := handler(ctx, "tools/call", nil)
+		finished <- err
+	}()
+	<-started
+
+	if s.Drain(10 * time.Millisecond) {
+		t.Fatal("Drain returned true with a call in flight")
+	}
+	if _, err := handler(ctx, "tools/call", nil); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("call while draining: %v", err)
+	}
+	if _, err := handler(ctx, "tools/list", nil); err != nil {
+		t.Errorf("tools/list while draining: %v", err)
+	}
+
+	close(release)
+	if err := <-finished; err != nil {
+		t.Errorf("in-flight call: %v", err)
+	}
+	if !s.Drain(time.Second) {
+		t.Error("Drain returned false after the call finished")
+	}
+}
@@ -0,0 +1,112 @@
+package mcpserver
+
+import (
+	"fmt"
+	"slices"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/slo"
+)
+
+// Bounds on the number of suggested questions.
+const (
+	minSuggestions     = 3
+	maxSuggestions     = 5
+	maxFlagSuggestions = 2 // so recent incidents and SLOs still get a say
+)
+
+// flagQuestions are the questions worth asking while a flag is raised.
+// Flags without an entry get a generic question.
+var flagQuestions = map[string]string{
+	"host_offline":                "Why did this host stop reporting?",
+	"cpu_overloaded":              "What is using the most CPU right now, and since when?",
+	"memory_pressure":             "Which processes are using the most memory, and are any of them growing?",
+	"memory_starvation":           "Which process did the OOM killer end, and what else competes for memory?",
+	"swap_thrashing":              "Why is the system swapping, and which processes are affected?",
+	"disk_space_critical":         "What is filling up the disk, and how fast is it growing?",
+	"inode_exhaustion":            "Which directories hold the most files on the mount running out of inodes?",
+	"disk_io_saturation":          "Which process is saturating the disk with I/O?",
+	"disk_health_failed":          "Which disk is failing its SMART checks, and what does it hold?",
+	"network_latency_degraded":    "Is the latency spike on the VPN, the uplink or the local network?",
+	"network_packet_loss":         "Where are packets being lost, and since when?",
+	"docker_unavailable":          "Why is Docker unavailable?",
+	"container_cpu_hog":           "Which container is hogging CPU, and should it be limited?",
+	"container_memory_pressure":   "Which container is close to its memory limit?",
+	"container_oom_risk":          "Which container is about to be OOM-killed?",
+	"runaway_process_cpu":         "Which process is running away with the CPU, and is it stuck?",
+	"runaway_process_memory":      "Which process keeps growing in memory, and is it leaking?",
+	"thermal_pressure":            "Is the CPU throttling because of heat, and what load causes it?",
+	"system_at_risk":              "Which of the current problems should I fix first?",
+	"memory_exhaustion_predicted": "When will memory run out at the current trend, and what is growing?",
+	"user_resource_hog":           "Which user is using the most resources right now?",
+	"link_saturated":              "What traffic is saturating the network link?",
+	"check_failed":                "Which checks are failing, and why?",
+	"gpu_overloaded":              "Which jobs are keeping the GPU busy?",
+	"gpu_memory_pressure":         "What is filling the GPU's memory?",
+}
+
+// generalQuestions pad the suggestions when little is wrong.
+var generalQuestions = []string{
+	"How healthy is this host right now?",
+	"What changed in the last 24 hours?",
+	"Which processes used the most CPU and memory today?",
+	"Are any metrics trending toward a problem?",
+	"How does this host compare with the rest of the fleet?",
+}
+
+// suggestQuestions returns limit questions, clamped to minSuggestions and
+// maxSuggestions and maxSuggestions for zero, most pressing first: the
+// flags raised now, then a flag raised recently but since cleared, then an
+// SLO violated or at risk, padded with general questions.
+func suggestQuestions(active []string, incidents []relational.FlagIncidents, slos []slo.Status, limit int) []string {
+	if limit <= 0 {
+		limit = maxSuggestions
+	}
+	limit = min(max(limit, minSuggestions), maxSuggestions)
+	var out []string
+	add := func(q string) {
+		if len(out) < limit && !slices.Contains(out, q) {
+			out = append(out, q)
+		}
+	}
+
+	// system_at_risk asks for triage, which beats any single flag.
+	if slices.Contains(active, "system_at_risk") {
+		add(flagQuestions["system_at_risk"])
+	}
+	for _, flag := range active {
+		if len(out) >= maxFlagSuggestions {
+			break
+		}
+		add(flagQuestion(flag))
+	}
+	for _, inc := range incidents {
+		if !slices.Contains(active, inc.Flag) {
+			add(fmt.Sprintf("Why was %s raised recently, and is it likely to come back?", inc.Flag))
+			break
+		}
+	}
+	for _, st := range slos {
+		switch {
+		case st.Violated():
+			add(fmt.Sprintf("Why is the SLO %q not being met?", st.Name))
+		case st.AtRisk():
+			add(fmt.Sprintf("Why is the error budget of the SLO %q burning fast?", st.Name))
+		default:
+			continue
+		}
+		break
+	}
+	for _, q := range generalQuestions {
+		add(q)
+	}
+	return out
+}
+
+// flagQuestion is the question to ask while flag is raised.
+func flagQuestion(flag string) string {
+	if q, ok := flagQuestions[flag]; ok {
+		return q
+	}
+	return fmt.Sprintf("Why is %s raised, and how do I fix it?", flag)
+}
@@ -0,0 +1,62 @@
+package mcpserver
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"syschecker/internal/collector"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/slo"
+)
+
+func TestSuggestQuestions(t *testing.T) {
+	compliance := 90.0
+	got := suggestQuestions(
+		[]string{"cpu_overloaded", "disk_space_critical", "swap_thrashing", "system_at_risk"},
+		[]relational.FlagIncidents{{Flag: "cpu_overloaded"}, {Flag: "network_packet_loss"}},
+		[]slo.Status{{Objective: slo.Objective{Name: "ok"}, Met: true, BudgetRemaining: 1}, {Objective: slo.Objective{Name: "latency"}, Samples: 10, Compliance: &compliance}},
+		0,
+	)
+	want := []string{
+		flagQuestions["system_at_risk"],
+		flagQuestions["cpu_overloaded"],
+		"Why was network_packet_loss raised recently, and is it likely to come back?",
+		`Why is the SLO "latency" not being met?`,
+		generalQuestions[0],
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	// Nothing wrong: general questions, clamped to the minimum.
+	if got := suggestQuestions(nil, nil, nil, 1); !slices.Equal(got, generalQuestions[:minSuggestions]) {
+		t.Errorf("quiet host: %q", got)
+	}
+	if got := flagQuestion("storage_budget"); !strings.Contains(got, "storage_budget") {
+		t.Errorf("fallback question %q", got)
+	}
+}
+
+func TestHandleSuggestQuestions(t *testing.T) {
+	s := &Server{
+		sensorProvider: &MockStatsProvider{
+			FastStats: &collector.RawStats{CPUUsage: 95, DockerAvailable: true},
+			SlowStats: &collector.RawStats{Hostname: "test-host"},
+		},
+		flaggerSvc: flagger.NewFlaggerService(flagger.DefaultConfig()),
+	}
+
+	_, result, err := s.handleSuggestQuestions(context.Background(), nil, SuggestQuestionsArgs{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Hostname != "test-host" || !slices.Equal(result.Flags, []string{"cpu_overloaded"}) {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Questions) != 4 || result.Questions[0] != flagQuestions["cpu_overloaded"] {
+		t.Errorf("questions = %q", result.Questions)
+	}
+}
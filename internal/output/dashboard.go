@@ -0,0 +1,225 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/slo"
+	"syschecker/internal/units"
+)
+
+// Status is the health of a dashboard section or item.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusWarning  Status = "warning"
+	StatusCritical Status = "critical"
+)
+
+// rank orders statuses from healthy to critical.
+func (s Status) rank() int {
+	switch s {
+	case StatusCritical:
+		return 2
+	case StatusWarning:
+		return 1
+	}
+	return 0
+}
+
+// DashboardItem is one metric in a section. Sparkline holds its recent
+// history, oldest first, when known.
+type DashboardItem struct {
+	Label     string
+	Value     string
+	Status    Status
+	Sparkline []float64
+}
+
+// DashboardSection groups the items of one subsystem.
+type DashboardSection struct {
+	Title  string
+	Status Status // worst status of the section's flags
+	Items  []DashboardItem
+}
+
+// DashboardView is a render-neutral summary of one snapshot, shared by the
+// Markdown and HTML renderers.
+type DashboardView struct {
+	Title       string
+	Host        string
+	CollectedAt time.Time
+	Location    *time.Location // display zone of CollectedAt; nil means UTC
+	Severity    int
+	RiskScore   int
+	Explanation string
+	Flags       []string // active flags
+	Sections    []DashboardSection
+	Percentiles []PercentileRow // stored history of the host; empty without it
+	SLOs        []SLORow
+}
+
+// PercentileRow is the distribution of one metric over stored history,
+// formatted for display.
+type PercentileRow struct {
+	Metric  string
+	Samples int64
+	Avg     string
+	P50     string
+	P95     string
+	P99     string
+	Max     string
+}
+
+// NewDashboardView builds the view of p. history supplies sparklines and may
+// be nil; like QuerySnapshots results it is ordered newest first.
+func NewDashboardView(p *PipelinePayload, history []relational.SnapshotSummary) *DashboardView {
+	raw, flags := &p.Raw, &p.Flags
+	active := flags.ActiveFlags()
+	status := func(names ...string) Status {
+		for _, n := range names {
+			if slices.Contains(active, n) {
+				if flags.SeverityLevel >= 3 {
+					return StatusCritical
+				}
+				return StatusWarning
+			}
+		}
+		return StatusOK
+	}
+
+	past := slices.Clone(history)
+	slices.Reverse(past)
+	spark := func(v func(relational.SnapshotSummary) float64) []float64 {
+		if len(past) == 0 {
+			return nil
+		}
+		out := make([]float64, len(past))
+		for i, s := range past {
+			out[i] = v(s)
+		}
+		return out
+	}
+
+	v := &DashboardView{
+		Title:       "syschecker: " + raw.Hostname,
+		Host:        raw.Hostname,
+		CollectedAt: raw.CollectedAt,
+		Severity:    flags.SeverityLevel,
+		RiskScore:   flags.RiskScore,
+		Explanation: flags.Explanation,
+		Flags:       active,
+	}
+	v.add("CPU", status("cpu_overloaded", "runaway_process_cpu", "container_cpu_hog", "thermal_pressure", "under_voltage"),
+		DashboardItem{Label: "Usage", Value: pct(raw.CPUUsagePct), Status: status("cpu_overloaded"),
+			Sparkline: spark(func(s relational.SnapshotSummary) float64 { return s.CPUUsagePct })},
+		DashboardItem{Label: "Load", Value: fmt.Sprintf("%.2f / %.2f / %.2f", raw.LoadAvg1, raw.LoadAvg5, raw.LoadAvg15)},
+		DashboardItem{Label: "Cores", Value: fmt.Sprint(raw.CPUCoresLogical)},
+	)
+	v.add("Memory", status("memory_pressure", "memory_starvation", "swap_thrashing", "memory_exhaustion_predicted",
+		"runaway_process_memory", "container_memory_pressure", "container_oom_risk"),
+		DashboardItem{Label: "Usage", Value: pct(raw.RAMUsagePct), Status: status("memory_pressure", "memory_starvation"),
+			Sparkline: spark(func(s relational.SnapshotSummary) float64 { return s.RAMUsagePct })},
+		DashboardItem{Label: "Available", Value: units.Bytes(raw.RAMAvailableBytes)},
+		DashboardItem{Label: "Swap", Value: pct(raw.SwapUsagePct), Status: status("swap_thrashing")},
+	)
+	v.add("Disk", status("disk_space_critical", "inode_exhaustion", "disk_io_saturation", "disk_health_failed"),
+		DashboardItem{Label: "Root usage", Value: pct(raw.DiskUsagePct), Status: status("disk_space_critical"),
+			Sparkline: spark(func(s relational.SnapshotSummary) float64 { return s.DiskUsagePct })},
+		DashboardItem{Label: "Inodes", Value: pct(raw.InodeUsagePct), Status: status("inode_exhaustion")},
+	)
+	connected := "yes"
+	if !raw.IsConnected {
+		connected = "no"
+	}
+	v.add("Network", status("host_offline", "network_latency_degraded", "network_packet_loss",
+		"network_interface_errors", "link_degraded", "link_saturated"),
+		DashboardItem{Label: "Connected", Value: connected, Status: status("host_offline")},
+		DashboardItem{Label: "Latency", Value: fmt.Sprintf("%.0f ms", raw.NetLatencyMS), Status: status("network_latency_degraded")},
+		DashboardItem{Label: "Loss", Value: pct(raw.NetLossPct), Status: status("network_packet_loss")},
+		DashboardItem{Label: "Active TCP", Value: fmt.Sprint(raw.ActiveTCP)},
+	)
+	return v
+}
+
+// SLORow is the compliance of one SLO, formatted for display.
+type SLORow struct {
+	Name       string
+	Objective  string
+	Compliance string
+	Budget     string // share of the error budget left
+	Burn       string // burn rate over the last hour; empty when unknown
+	Status     Status // critical when violated, warning when at risk
+}
+
+// AddSLOs fills v.SLOs from statuses.
+func (v *DashboardView) AddSLOs(statuses []slo.Status) {
+	for _, s := range statuses {
+		row := SLORow{Name: s.Name, Objective: s.Describe(), Compliance: "no data",
+			Budget: fmt.Sprintf("%.0f%%", 100*s.BudgetRemaining), Status: StatusOK}
+		if s.Compliance != nil {
+			row.Compliance = fmt.Sprintf("%.2f%%", *s.Compliance)
+		}
+		if s.BurnRate != nil {
+			row.Burn = fmt.Sprintf("%.1fx", *s.BurnRate)
+		}
+		switch {
+		case s.Violated():
+			row.Status = StatusCritical
+		case s.AtRisk():
+			row.Status = StatusWarning
+		}
+		v.SLOs = append(v.SLOs, row)
+	}
+}
+
+// AddPercentiles fills v.Percentiles from ps, skipping metrics without
+// samples.
+func (v *DashboardView) AddPercentiles(ps []relational.MetricPercentile) {
+	for _, p := range ps {
+		if p.Samples == 0 {
+			continue
+		}
+		f := func(x *float64) string {
+			switch {
+			case x == nil:
+				return "-"
+			case strings.HasSuffix(p.Metric, "_bps"):
+				return units.Bytes(uint64(max(*x, 0))) + "/s"
+			}
+			return fmt.Sprintf("%.1f", *x)
+		}
+		v.Percentiles = append(v.Percentiles, PercentileRow{
+			Metric: p.Metric, Samples: p.Samples,
+			Avg: f(p.Avg), P50: f(p.P50), P95: f(p.P95), P99: f(p.P99), Max: f(p.Max),
+		})
+	}
+}
+
+func (v *DashboardView) add(title string, status Status, items ...DashboardItem) {
+	for i := range items {
+		if items[i].Status == "" {
+			items[i].Status = StatusOK
+		}
+	}
+	v.Sections = append(v.Sections, DashboardSection{Title: title, Status: status, Items: items})
+}
+
+// Status returns the worst status of the view's sections.
+func (v *DashboardView) Status() Status {
+	worst := StatusOK
+	for _, s := range v.Sections {
+		if s.Status.rank() > worst.rank() {
+			worst = s.Status
+		}
+	}
+	return worst
+}
+
+func pct(v float64) string {
+	return fmt.Sprintf("%.1f%%", v)
+}
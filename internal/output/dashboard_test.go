@@ -0,0 +1,117 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/slo"
+)
+
+func testDashboard() *DashboardView {
+	p := &PipelinePayload{
+		Raw: relational.RawStatsFixed{
+			Hostname: "web-1", CollectedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			CPUUsagePct: 97, RAMUsagePct: 40, IsConnected: true,
+		},
+		Flags: relational.SnapshotFlags{FlagCPUOverloaded: true, SeverityLevel: 3, Explanation: "CPU <saturated> by a|b"},
+	}
+	history := []relational.SnapshotSummary{{CPUUsagePct: 97}, {CPUUsagePct: 50}, {CPUUsagePct: 10}} // newest first
+	return NewDashboardView(p, history)
+}
+
+func TestNewDashboardView(t *testing.T) {
+	v := testDashboard()
+	if v.Status() != StatusCritical || v.Sections[0].Status != StatusCritical || v.Sections[1].Status != StatusOK {
+		t.Errorf("statuses: view %s, cpu %s, memory %s", v.Status(), v.Sections[0].Status, v.Sections[1].Status)
+	}
+	usage := v.Sections[0].Items[0]
+	if usage.Value != "97.0%" || usage.Status != StatusCritical {
+		t.Errorf("cpu usage item = %+v", usage)
+	}
+	if got := usage.Sparkline; len(got) != 3 || got[0] != 10 || got[2] != 97 {
+		t.Errorf("sparkline not oldest first: %v", got)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, testDashboard()); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{
+		"# syschecker: web-1", "status **critical**", "> CPU \\<saturated> by a\\|b",
+		"`cpu_overloaded`", "## CPU (critical)", "| Usage | 97.0% | critical | ▁▄█ |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestWriteMarkdownPercentiles(t *testing.T) {
+	v := testDashboard()
+	p95, rx := 42.0, 2048.0
+	v.AddPercentiles([]relational.MetricPercentile{
+		{Metric: "net_latency_ms", Samples: 10, MetricAggregate: relational.MetricAggregate{P95: &p95}},
+		{Metric: "net_rx_bps", Samples: 10, MetricAggregate: relational.MetricAggregate{Max: &rx}},
+		{Metric: "disk_read_bps"}, // no samples
+	})
+	var b strings.Builder
+	if err := WriteMarkdown(&b, v); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{"## Percentiles", "| net\\_latency\\_ms | 10 | - | - | 42.0 | - | - |", "| 2.0 KiB/s |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "disk") {
+		t.Error("metric without samples rendered")
+	}
+}
+
+func TestWriteMarkdownSLOs(t *testing.T) {
+	v := testDashboard()
+	compliance := 97.0
+	v.AddSLOs([]slo.Status{{
+		Objective: slo.Objective{Name: "disk", Metric: "disk_usage_pct", Op: "<", Threshold: 90, Target: 100},
+		Samples:   100, Compliance: &compliance,
+	}})
+	var b strings.Builder
+	if err := WriteMarkdown(&b, v); err != nil {
+		t.Fatal(err)
+	}
+	if want := "| disk | disk\\_usage\\_pct \\< 90 always | 97.00% | 0% |  | critical |"; !strings.Contains(b.String(), want) {
+		t.Errorf("markdown missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteMarkdownLocation(t *testing.T) {
+	v := testDashboard()
+	v.Location = time.FixedZone("EST", -5*3600)
+	var b strings.Builder
+	if err := WriteMarkdown(&b, v); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "2026-01-01 22:04:05 EST") {
+		t.Errorf("markdown not in the display zone:\n%s", b.String())
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	if err := WriteHTML(&b, testDashboard()); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "CPU &lt;saturated&gt;") {
+		t.Errorf("page not standalone or not escaped:\n%s", page)
+	}
+	if n := strings.Count(page, "<polyline"); n != 3 {
+		t.Errorf("expected 3 sparklines, got %d", n)
+	}
+}
@@ -2,8 +2,12 @@ package output
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 
+	"syschecker/internal/buildinfo"
+	"syschecker/internal/clock"
 	"syschecker/internal/collector"
 	"syschecker/internal/database/relational"
 )
@@ -11,9 +15,18 @@ import (
 // PipelinePayload represents the final data object ready for persistence.
 // The DataWorker pulls this from the Output layer to push to DuckDB.
 type PipelinePayload struct {
+	// SchemaVersion is the payload format version (relational.SchemaVersion when produced locally).
+	SchemaVersion string
+
 	Raw     relational.RawStatsFixed
 	Derived relational.DerivedRates
 	Flags   relational.SnapshotFlags
+
+	// Seasonal holds metrics deviating from their usual hour-of-week level (optional).
+	Seasonal []relational.SeasonalDeviation
+
+	// Forecast holds the memory exhaustion projection (optional).
+	Forecast *relational.MemoryForecast
 }
 
 // DataCollector defines the interface for collecting raw system stats.
@@ -32,6 +45,45 @@ type RateProvider interface {
 	GetDerivedRates(ctx context.Context, current relational.RawStatsFixed) (*relational.DerivedRates, error)
 }
 
+// PipelineOption configures a pipeline run.
+type PipelineOption func(*pipelineOptions)
+
+type pipelineOptions struct {
+	clock   clock.Clock
+	labeler relational.HostLabeler
+	traceID string
+}
+
+// WithClock stamps snapshots with the given clock instead of the wall clock.
+func WithClock(c clock.Clock) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.clock = clock.OrReal(c)
+	}
+}
+
+// WithLabeler attaches the host's labels to each snapshot.
+func WithLabeler(l relational.HostLabeler) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.labeler = l
+	}
+}
+
+// WithTraceID stamps the snapshot with id instead of a new trace ID, e.g.
+// to tie it to log lines written before the pipeline ran.
+func WithTraceID(id string) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.traceID = id
+	}
+}
+
+// NewTraceID returns a random 128-bit ID in the W3C trace-context format
+// (32 lowercase hex digits) for one collection cycle.
+func NewTraceID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
 // RunPipeline executes the full data pipeline: Collect -> Adapt -> Rates -> Flag -> Bundle.
 // It returns a PipelinePayload ready for persistence.
 func RunPipeline(
@@ -40,7 +92,13 @@ func RunPipeline(
 	flg DataFlagger,
 	rp RateProvider,
 	agentID, machineID, bootID string,
+	opts ...PipelineOption,
 ) (*PipelinePayload, error) {
+	o := pipelineOptions{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// 1. Collect Fast Metrics
 	fast, err := col.GetFastMetrics(ctx)
 	if err != nil {
@@ -55,6 +113,23 @@ func RunPipeline(
 
 	// 3. Merge & Adapt to Fixed/Relational Structure
 	fixed := relational.MergeStats(fast, slow, agentID, machineID, bootID)
+	// MergeStats copied everything out of fast, so its memory can go to
+	// the next cycle.
+	if r, ok := col.(collector.Releaser); ok {
+		r.Release(fast)
+	}
+	fixed.CollectedAt = o.clock.Now().UTC()
+	fixed.TraceID = o.traceID
+	if fixed.TraceID == "" {
+		fixed.TraceID = NewTraceID()
+	}
+	fixed.AgentVersion = buildinfo.Get().Version
+	if o.labeler != nil {
+		// Labels only annotate the snapshot; collection goes on without them.
+		if labels, err := o.labeler.HostLabels(ctx, agentID); err == nil {
+			fixed.Labels = labels
+		}
+	}
 
 	// 4. Get Derived Rates (requires DB access to previous snapshot)
 	derived, err := rp.GetDerivedRates(ctx, fixed)
@@ -70,8 +145,9 @@ func RunPipeline(
 
 	// 6. Bundle into Output Payload
 	return &PipelinePayload{
-		Raw:     fixed,
-		Derived: *derived,
-		Flags:   *flags,
+		SchemaVersion: relational.SchemaVersion,
+		Raw:           fixed,
+		Derived:       *derived,
+		Flags:         *flags,
 	}, nil
 }
@@ -0,0 +1,17 @@
+package output
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewTraceID(t *testing.T) {
+	valid := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	a, b := NewTraceID(), NewTraceID()
+	if !valid.MatchString(a) || !valid.MatchString(b) {
+		t.Errorf("trace IDs %q, %q are not 32 hex digits", a, b)
+	}
+	if a == b {
+		t.Error("trace IDs should differ between cycles")
+	}
+}
@@ -0,0 +1,176 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+
+	"syschecker/internal/timefmt"
+)
+
+// sparkBlocks draw Markdown sparklines, lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// WriteMarkdown renders v as a Markdown document with one table per
+// section; sparklines are drawn with block characters so they survive
+// plain-text channels such as alert bodies.
+func WriteMarkdown(w io.Writer, v *DashboardView) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", mdEscape(v.Title))
+	fmt.Fprintf(&b, "Collected %s · status **%s** · severity %d · risk %d\n\n",
+		timefmt.Format(v.CollectedAt, v.Location), v.Status(), v.Severity, v.RiskScore)
+	if v.Explanation != "" {
+		fmt.Fprintf(&b, "> %s\n\n", mdEscape(v.Explanation))
+	}
+	if len(v.Flags) > 0 {
+		b.WriteString("**Active flags:** ")
+		for i, f := range v.Flags {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("`" + f + "`")
+		}
+		b.WriteString("\n\n")
+	}
+
+	for _, s := range v.Sections {
+		fmt.Fprintf(&b, "## %s (%s)\n\n", mdEscape(s.Title), s.Status)
+		b.WriteString("| Metric | Value | Status | Trend |\n|---|---|---|---|\n")
+		for _, it := range s.Items {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", mdEscape(it.Label), mdEscape(it.Value), it.Status, sparkText(it.Sparkline))
+		}
+		b.WriteString("\n")
+	}
+	if len(v.SLOs) > 0 {
+		b.WriteString("## SLOs\n\n")
+		b.WriteString("| SLO | Objective | Compliance | Budget left | Burn | Status |\n|---|---|---|---|---|---|\n")
+		for _, s := range v.SLOs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", mdEscape(s.Name), mdEscape(s.Objective), s.Compliance, s.Budget, s.Burn, s.Status)
+		}
+		b.WriteString("\n")
+	}
+	if len(v.Percentiles) > 0 {
+		b.WriteString("## Percentiles\n\n")
+		b.WriteString("| Metric | Samples | Avg | p50 | p95 | p99 | Max |\n|---|---|---|---|---|---|---|\n")
+		for _, p := range v.Percentiles {
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s | %s |\n", mdEscape(p.Metric), p.Samples, p.Avg, p.P50, p.P95, p.P99, p.Max)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdEscape keeps s from breaking table cells or starting Markdown markup.
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", `\<`, "\n", " ").Replace(s)
+}
+
+// sparkText draws values as block characters scaled to their own range.
+func sparkText(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	out := make([]rune, len(values))
+	for i, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		out[i] = sparkBlocks[idx]
+	}
+	return string(out)
+}
+
+// Sparkline SVG size in pixels.
+const (
+	sparkWidth  = 120
+	sparkHeight = 24
+)
+
+// sparkSVG draws values as an inline SVG polyline scaled to their own range.
+func sparkSVG(values []float64) template.HTML {
+	if len(values) < 2 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	points := make([]string, len(values))
+	for i, v := range values {
+		y := float64(sparkHeight) / 2
+		if hi > lo {
+			y = float64(sparkHeight-2) - (v-lo)/(hi-lo)*float64(sparkHeight-4)
+		}
+		points[i] = fmt.Sprintf("%.1f,%.1f", float64(i)*sparkWidth/float64(len(values)-1), y)
+	}
+	// Only numbers are interpolated, so the markup is safe to mark as HTML.
+	return template.HTML(fmt.Sprintf(
+		`<svg class="spark" width="%d" height="%d" viewBox="0 0 %d %d"><polyline fill="none" stroke="currentColor" stroke-width="1.5" points="%s"/></svg>`,
+		sparkWidth, sparkHeight, sparkWidth, sparkHeight, strings.Join(points, " ")))
+}
+
+var dashboardHTML = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"spark": sparkSVG,
+	"time":  func(v *DashboardView) string { return timefmt.Format(v.CollectedAt, v.Location) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; min-width: 32em; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+.status { font-weight: 600; text-transform: uppercase; font-size: 0.8em; }
+.ok { color: #2e7d32; } .warning { color: #ef6c00; } .critical { color: #c62828; }
+.spark { color: #1565c0; vertical-align: middle; }
+code { background: #f3f3f3; padding: 1px 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Collected {{time .}} · <span class="status {{.Status}}">{{.Status}}</span> · severity {{.Severity}} · risk {{.RiskScore}}</p>
+{{- if .Explanation}}
+<blockquote>{{.Explanation}}</blockquote>
+{{- end}}
+{{- if .Flags}}
+<p>Active flags: {{range $i, $f := .Flags}}{{if $i}}, {{end}}<code>{{$f}}</code>{{end}}</p>
+{{- end}}
+{{- range .Sections}}
+<h2>{{.Title}} <span class="status {{.Status}}">{{.Status}}</span></h2>
+<table>
+<tr><th>Metric</th><th>Value</th><th>Status</th><th>Trend</th></tr>
+{{- range .Items}}
+<tr><td>{{.Label}}</td><td>{{.Value}}</td><td class="status {{.Status}}">{{.Status}}</td><td>{{spark .Sparkline}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .SLOs}}
+<h2>SLOs</h2>
+<table>
+<tr><th>SLO</th><th>Objective</th><th>Compliance</th><th>Budget left</th><th>Burn</th><th>Status</th></tr>
+{{- range .SLOs}}
+<tr><td>{{.Name}}</td><td>{{.Objective}}</td><td>{{.Compliance}}</td><td>{{.Budget}}</td><td>{{.Burn}}</td><td class="status {{.Status}}">{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Percentiles}}
+<h2>Percentiles</h2>
+<table>
+<tr><th>Metric</th><th>Samples</th><th>Avg</th><th>p50</th><th>p95</th><th>p99</th><th>Max</th></tr>
+{{- range .Percentiles}}
+<tr><td>{{.Metric}}</td><td>{{.Samples}}</td><td>{{.Avg}}</td><td>{{.P50}}</td><td>{{.P95}}</td><td>{{.P99}}</td><td>{{.Max}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders v as a standalone HTML page with inline styles and
+// SVG sparklines.
+func WriteHTML(w io.Writer, v *DashboardView) error {
+	return dashboardHTML.Execute(w, v)
+}
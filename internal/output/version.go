@@ -0,0 +1,205 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"syschecker/internal/database/relational"
+)
+
+// payloadConverter upgrades a JSON-decoded payload from one schema version to the next.
+type payloadConverter struct {
+	from, to string
+	convert  func(p map[string]interface{}) error
+}
+
+// payloadConverters forms a chain from the legacy format to relational.SchemaVersion.
+var payloadConverters = []payloadConverter{
+	// Unversioned payloads share the 1.0.0 layout.
+	{from: relational.LegacySchemaVersion, to: "1.0.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.1.0 added the optional Seasonal deviations list.
+	{from: "1.0.0", to: "1.1.0", convert: func(p map[string]interface{}) error {
+		if _, ok := p["Seasonal"]; !ok {
+			p["Seasonal"] = nil
+		}
+		return nil
+	}},
+	// 1.2.0 widened stored counters to UBIGINT; the payload layout is unchanged.
+	{from: "1.1.0", to: "1.2.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.3.0 added the swap-in counter, the memory exhaustion flag and the optional Forecast.
+	{from: "1.2.0", to: "1.3.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.4.0 added Raw.OOMKills; older payloads simply have none.
+	{from: "1.3.0", to: "1.4.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.5.0 added Raw.LogGrowers.
+	{from: "1.4.0", to: "1.5.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.6.0 added Raw.LargestDirs.
+	{from: "1.5.0", to: "1.6.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.7.0 added Raw.TempUsage.
+	{from: "1.6.0", to: "1.7.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.8.0 added Raw.UserUsage and the user_resource_hog flag.
+	{from: "1.7.0", to: "1.8.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.9.0 added Raw.Containerized and the cgroup limits.
+	{from: "1.8.0", to: "1.9.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.10.0 added the Raw environment profile.
+	{from: "1.9.0", to: "1.10.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.11.0 added the SoC power, cluster utilization and thermal pressure fields.
+	{from: "1.10.0", to: "1.11.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.12.0 added Raw.ThrottledBits, Raw.CoreVolts and the under_voltage flag.
+	{from: "1.11.0", to: "1.12.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.13.0 added the prober's Raw.NetJitterMS, Raw.NetLossPct and Raw.NetProbes,
+	// plus the top-process cmdline, cgroup and container and raw SMART output.
+	{from: "1.12.0", to: "1.13.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.14.0 added the link state of Raw.NetInterfaces, Derived.NetCarrierChanges
+	// and the link_degraded flag.
+	{from: "1.13.0", to: "1.14.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.15.0 added Derived.NetUtilization and the link_saturated flag.
+	{from: "1.14.0", to: "1.15.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.16.0 added Raw.VPNInterface and Raw.NetDirectMS.
+	{from: "1.15.0", to: "1.16.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.17.0 added the host's Raw.Labels.
+	{from: "1.16.0", to: "1.17.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.18.0 added Raw.Checks and the check_failed flag.
+	{from: "1.17.0", to: "1.18.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.19.0 added snapshot repeat counts; payloads are unchanged.
+	{from: "1.18.0", to: "1.19.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.20.0 added the storage_budget flag.
+	{from: "1.19.0", to: "1.20.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.21.0 added snowflake node ID leases; payloads are unchanged.
+	{from: "1.20.0", to: "1.21.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.22.0 added Raw.TraceID; older payloads have none.
+	{from: "1.21.0", to: "1.22.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.23.0 added per-host ingest leases; payloads are unchanged.
+	{from: "1.22.0", to: "1.23.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.24.0 added Raw.AgentVersion and hosts.agent_version; older payloads have none.
+	{from: "1.23.0", to: "1.24.0", convert: func(map[string]interface{}) error { return nil }},
+	// 1.25.0 added Raw.GPUs and the gpu_overloaded and gpu_memory_pressure flags.
+	{from: "1.24.0", to: "1.25.0", convert: func(map[string]interface{}) error { return nil }},
+}
+
+// EncodePayload serializes a payload, stamping the current schema version if unset.
+func EncodePayload(p *PipelinePayload) ([]byte, error) {
+	if p.SchemaVersion == "" {
+		p.SchemaVersion = relational.SchemaVersion
+	}
+	return json.Marshal(p)
+}
+
+// DecodePayload parses a payload written by any compatible syschecker version,
+// converting older formats to the current one. A current payload is decoded
+// directly; an older one goes through the converters with numbers kept as
+// json.Number, so counters above 2^53 survive the round trip.
+func DecodePayload(data []byte) (*PipelinePayload, error) {
+	var head struct{ SchemaVersion string }
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+	if head.SchemaVersion == relational.SchemaVersion {
+		var p PipelinePayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("decode payload v%s: %w", head.SchemaVersion, err)
+		}
+		return &p, nil
+	}
+
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	version := head.SchemaVersion
+	if version == "" {
+		version = relational.LegacySchemaVersion
+	}
+
+	upgraded, err := upgradePayload(doc, version)
+	if err != nil {
+		return nil, err
+	}
+	doc["SchemaVersion"] = upgraded
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("re-encode payload: %w", err)
+	}
+	var p PipelinePayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("decode payload v%s: %w", upgraded, err)
+	}
+	return &p, nil
+}
+
+// upgradePayload applies converters until doc reaches the current version and returns
+// the resulting version. Newer minor versions of the same major are accepted as-is.
+func upgradePayload(doc map[string]interface{}, version string) (string, error) {
+	cmp, err := compareVersions(version, relational.SchemaVersion)
+	if err != nil {
+		return "", err
+	}
+	if cmp >= 0 {
+		if majorOf(version) != majorOf(relational.SchemaVersion) {
+			return "", fmt.Errorf("payload schema version %s is not supported (current %s)", version, relational.SchemaVersion)
+		}
+		return version, nil
+	}
+
+	for _, c := range payloadConverters {
+		if c.from != version {
+			continue
+		}
+		if err := c.convert(doc); err != nil {
+			return "", fmt.Errorf("convert payload %s -> %s: %w", c.from, c.to, err)
+		}
+		version = c.to
+	}
+	if version != relational.SchemaVersion {
+		return "", fmt.Errorf("no converter from payload schema version %s", version)
+	}
+	return version, nil
+}
+
+func parseVersion(v string) ([3]int, error) {
+	var out [3]int
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, fmt.Errorf("invalid schema version %q", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, fmt.Errorf("invalid schema version %q", v)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// compareVersions returns -1, 0, or 1 as a is older than, equal to, or newer than b.
+func compareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1, nil
+		case va[i] > vb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func majorOf(v string) int {
+	parsed, _ := parseVersion(v)
+	return parsed[0]
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+)
+
+func TestPayloadRoundTrip(t *testing.T) {
+	in := &PipelinePayload{Raw: relational.RawStatsFixed{Hostname: "box"}}
+	b, err := EncodePayload(in)
+	if err != nil {
+		t.Fatalf("EncodePayload: %v", err)
+	}
+	out, err := DecodePayload(b)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if out.SchemaVersion != relational.SchemaVersion || out.Raw.Hostname != "box" {
+		t.Errorf("unexpected payload after round trip: %+v", out)
+	}
+}
+
+func TestDecodeLegacyPayload(t *testing.T) {
+	out, err := DecodePayload([]byte(`{"Raw":{"Hostname":"old"},"Flags":{"SeverityLevel":2}}`))
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if out.SchemaVersion != relational.SchemaVersion {
+		t.Errorf("expected upgrade to %s, got %s", relational.SchemaVersion, out.SchemaVersion)
+	}
+	if out.Raw.Hostname != "old" || out.Flags.SeverityLevel != 2 {
+		t.Errorf("fields lost during upgrade: %+v", out)
+	}
+}
+
+func TestDecodeKeepsLargeCounters(t *testing.T) {
+	const big = 1<<60 + 1
+	for _, version := range []string{relational.SchemaVersion, "1.0.0"} {
+		in := &PipelinePayload{SchemaVersion: version, Raw: relational.RawStatsFixed{SwapInBytes: big}}
+		b, err := EncodePayload(in)
+		if err != nil {
+			t.Fatalf("EncodePayload: %v", err)
+		}
+		out, err := DecodePayload(b)
+		if err != nil {
+			t.Fatalf("DecodePayload v%s: %v", version, err)
+		}
+		if out.Raw.SwapInBytes != big {
+			t.Errorf("v%s: SwapInBytes = %d, want %d", version, out.Raw.SwapInBytes, uint64(big))
+		}
+	}
+}
+
+func TestDecodeRejectsNewerMajor(t *testing.T) {
+	_, err := DecodePayload([]byte(`{"SchemaVersion":"99.0.0"}`))
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestDecodeRejectsMalformedVersion(t *testing.T) {
+	if _, err := DecodePayload([]byte(`{"SchemaVersion":"v1"}`)); err == nil {
+		t.Error("expected error for malformed version")
+	}
+}
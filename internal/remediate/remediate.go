@@ -0,0 +1,110 @@
+// Package remediate maps the flags of a snapshot to remediation actions.
+// The actions themselves, and the guards around running them, live in
+// package actions.
+package remediate
+
+import (
+	"cmp"
+	"slices"
+
+	"syschecker/internal/actions"
+	"syschecker/internal/database/relational"
+)
+
+// JournalMaxSize is what the systemd journal is vacuumed to.
+const JournalMaxSize = "500M"
+
+// TempMaxAgeDays is the age beyond which temp files are cleared, matching
+// the temp sensor's default stale age.
+const TempMaxAgeDays = 7
+
+// Recommendation is an action for one active flag.
+type Recommendation struct {
+	Flag   string
+	Action actions.Action
+}
+
+// Recommend returns the actions for the active flags of f on snapshot s,
+// at most one per action ID.
+func Recommend(s *relational.RawStatsFixed, f *relational.SnapshotFlags) []Recommendation {
+	var recs []Recommendation
+	add := func(flag string, a actions.Action) {
+		id := a.Spec().ID
+		if !slices.ContainsFunc(recs, func(r Recommendation) bool { return r.Action.Spec().ID == id }) {
+			recs = append(recs, Recommendation{Flag: flag, Action: a})
+		}
+	}
+	linux := s.OS == "" || s.OS == "linux"
+
+	for _, flag := range f.ActiveFlags() {
+		switch flag {
+		case "container_cpu_hog":
+			if name := hottestContainer(s, f, func(c relational.DockerContainerInfoFixed) float64 { return c.CPUUsagePct }); name != "" {
+				add(flag, actions.RestartContainer{Name: name})
+			}
+		case "container_memory_pressure", "container_oom_risk":
+			if name := hottestContainer(s, f, func(c relational.DockerContainerInfoFixed) float64 { return c.MemPercent }); name != "" {
+				add(flag, actions.RestartContainer{Name: name})
+			}
+		case "disk_space_critical", "inode_exhaustion":
+			if f.CauseEntityType == "file" && f.CauseEntityKey != "" {
+				add(flag, actions.TruncateLog{Path: f.CauseEntityKey})
+			}
+			if linux {
+				add(flag, actions.VacuumJournal{MaxSize: JournalMaxSize})
+			}
+			for _, u := range s.TempUsage {
+				switch {
+				case u.Kind == "temp" && u.StaleBytes > 0:
+					add(flag, actions.ClearTemp{Dir: u.Path, MaxAgeDays: TempMaxAgeDays})
+				case u.Kind == "core" && u.TotalBytes > 0:
+					add(flag, actions.RemoveCoreDumps{Dir: u.Path})
+				}
+			}
+		case "runaway_process_cpu", "runaway_process_memory":
+			if p, ok := topProcess(s, flag == "runaway_process_memory"); ok {
+				add(flag, actions.KillProcess{PID: p.PID, Name: p.Name})
+			}
+		case "docker_unavailable":
+			if linux {
+				add(flag, actions.StartService{Name: "docker"})
+			}
+		}
+	}
+	return recs
+}
+
+// hottestContainer names the container blamed by f, or else the running
+// one with the highest usage.
+func hottestContainer(s *relational.RawStatsFixed, f *relational.SnapshotFlags, usage func(relational.DockerContainerInfoFixed) float64) string {
+	var best *relational.DockerContainerInfoFixed
+	for i, c := range s.DockerContainers {
+		if f.CauseEntityType == "container" && c.ID == f.CauseEntityKey {
+			best = &s.DockerContainers[i]
+			break
+		}
+		if c.Running && (best == nil || usage(c) > usage(*best)) {
+			best = &s.DockerContainers[i]
+		}
+	}
+	switch {
+	case best == nil:
+		return ""
+	case best.Name != "":
+		return best.Name
+	}
+	return best.ID
+}
+
+// topProcess returns the process using the most CPU, or memory.
+func topProcess(s *relational.RawStatsFixed, memory bool) (relational.ProcessStatFixed, bool) {
+	if len(s.TopProcesses) == 0 {
+		return relational.ProcessStatFixed{}, false
+	}
+	return slices.MaxFunc(s.TopProcesses, func(a, b relational.ProcessStatFixed) int {
+		if memory {
+			return cmp.Compare(a.MemPct, b.MemPct)
+		}
+		return cmp.Compare(a.CPUPct, b.CPUPct)
+	}), true
+}
@@ -0,0 +1,55 @@
+package remediate
+
+import (
+	"slices"
+	"testing"
+
+	"syschecker/internal/actions"
+	"syschecker/internal/database/relational"
+)
+
+func ids(recs []Recommendation) []string {
+	var out []string
+	for _, r := range recs {
+		out = append(out, r.Action.Spec().ID)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestRecommend(t *testing.T) {
+	s := &relational.RawStatsFixed{
+		OS: "linux",
+		DockerContainers: []relational.DockerContainerInfoFixed{
+			{ID: "aaa", Name: "api", Running: true, CPUUsagePct: 10},
+			{ID: "bbb", Name: "worker", Running: true, CPUUsagePct: 90},
+		},
+		TempUsage: []relational.TempUsageFixed{
+			{Path: "/tmp", Kind: "temp", StaleBytes: 1 << 30},
+			{Path: "/var/lib/systemd/coredump", Kind: "core", TotalBytes: 1 << 30},
+		},
+	}
+	f := &relational.SnapshotFlags{
+		FlagContainerCPUHog: true, FlagDiskSpaceCritical: true, FlagInodeExhaustion: true,
+		CauseEntityType: "file", CauseEntityKey: "/var/log/app.log",
+	}
+
+	want := []string{
+		"clear_temp:/tmp", "remove_core_dumps:/var/lib/systemd/coredump", "restart_container:worker",
+		"truncate_log:/var/log/app.log", "vacuum_journal",
+	}
+	if got := ids(Recommend(s, f)); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// The flagger's culprit wins over the busiest container.
+	f = &relational.SnapshotFlags{FlagContainerCPUHog: true, CauseEntityType: "container", CauseEntityKey: "aaa"}
+	recs := Recommend(s, f)
+	if len(recs) != 1 || recs[0].Action != (actions.RestartContainer{Name: "api"}) || recs[0].Flag != "container_cpu_hog" {
+		t.Errorf("got %+v", recs)
+	}
+
+	if recs := Recommend(&relational.RawStatsFixed{OS: "darwin"}, &relational.SnapshotFlags{FlagDockerUnavailable: true}); len(recs) != 0 {
+		t.Errorf("systemctl recommended on darwin: %+v", recs)
+	}
+}
@@ -0,0 +1,215 @@
+// Package replay feeds stored snapshots back through the flagger, and
+// optionally the graph, at a chosen speed, to reproduce and debug how an
+// incident unfolded.
+//
+// Each snapshot is re-flagged with the current flagger configuration on a
+// virtual clock set to the time it was collected, so time-dependent logic
+// sees the original timeline. Pacing between snapshots uses a separate
+// clock, the wall clock unless a test supplies a fake one.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/collector"
+	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+// MaxGap caps the stored time between two snapshots that replay waits
+// out, so hours when the agent was down do not stall a replay.
+const MaxGap = 10 * time.Minute
+
+// Source reads stored snapshots; *relational.Repo implements it.
+type Source interface {
+	SnapshotRange(ctx context.Context, hostname string, from, to time.Time) ([]relational.SnapshotRef, error)
+	LoadSnapshot(ctx context.Context, id int64) (*relational.StoredSnapshot, error)
+}
+
+// Frame is one replayed snapshot.
+type Frame struct {
+	Index   int // 0-based position in the replay
+	Total   int
+	Payload *output.PipelinePayload
+	Prev    *output.PipelinePayload // nil for the first frame
+	Raised  []string                // flags active now but not in Prev
+	Cleared []string                // flags active in Prev but not now
+}
+
+// Summary describes a finished replay.
+type Summary struct {
+	Frames      int
+	From, To    time.Time // collection times of the first and last frame
+	GraphErrors int       // frames the graph rejected
+}
+
+// Option configures a Replayer.
+type Option func(*Replayer)
+
+// WithSpeed replays at x times the original pace; 0 replays without
+// waiting.
+func WithSpeed(x float64) Option {
+	return func(r *Replayer) {
+		if x >= 0 {
+			r.speed = x
+		}
+	}
+}
+
+// WithClock paces the replay with c instead of the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(r *Replayer) {
+		r.clock = clock.OrReal(c)
+	}
+}
+
+// WithGraph ingests every replayed snapshot into g.
+func WithGraph(g graph.GraphClient) Option {
+	return func(r *Replayer) {
+		r.graph = g
+	}
+}
+
+// WithPublisher publishes every replayed snapshot to p, e.g. a stream hub
+// a dashboard follows.
+func WithPublisher(p relational.SnapshotPublisher) Option {
+	return func(r *Replayer) {
+		r.publisher = p
+	}
+}
+
+// Replayer replays the snapshots of one host.
+type Replayer struct {
+	src       Source
+	flagger   output.DataFlagger
+	clock     clock.Clock
+	speed     float64
+	graph     graph.GraphClient
+	publisher relational.SnapshotPublisher
+}
+
+// New returns a replayer reading from src and flagging with flg, at 60x
+// speed unless an option says otherwise.
+func New(src Source, flg output.DataFlagger, opts ...Option) *Replayer {
+	r := &Replayer{src: src, flagger: flg, clock: clock.Real, speed: 60}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run replays the snapshots of hostname collected in [from, to), calling
+// fn, when not nil, for each frame. An empty hostname replays the most
+// recently seen host, a zero to runs up to the latest snapshot. It stops
+// early when ctx is done or fn fails.
+func (r *Replayer) Run(ctx context.Context, hostname string, from, to time.Time, fn func(Frame) error) (Summary, error) {
+	refs, err := r.src.SnapshotRange(ctx, hostname, from, to)
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(refs) == 0 {
+		return Summary{}, fmt.Errorf("no snapshots to replay between %s and %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	sum := Summary{From: refs[0].CollectedAt}
+	virtual := clock.NewFake(refs[0].CollectedAt)
+	var prev *output.PipelinePayload
+	for i, ref := range refs {
+		if i > 0 {
+			if err := r.wait(ctx, ref.CollectedAt.Sub(refs[i-1].CollectedAt)); err != nil {
+				return sum, err
+			}
+		}
+		snap, err := r.src.LoadSnapshot(ctx, ref.ID)
+		if err != nil {
+			return sum, err
+		}
+		virtual.Advance(snap.CollectedAt.Sub(virtual.Now()))
+
+		payload, err := output.RunPipeline(ctx, stored{snap}, r.flagger, stored{snap},
+			snap.AgentID, snap.MachineID, snap.BootID, output.WithClock(virtual))
+		if err != nil {
+			return sum, fmt.Errorf("replay snapshot %d: %w", snap.ID, err)
+		}
+		if r.graph != nil {
+			if err := r.graph.IngestSnapshot(ctx, payload); err != nil {
+				sum.GraphErrors++
+			}
+		}
+		if r.publisher != nil {
+			r.publisher.Publish(relational.InsertResult{SnapshotID: snap.ID}, &payload.Raw, &payload.Derived, &payload.Flags)
+		}
+
+		sum.Frames++
+		sum.To = snap.CollectedAt
+		if fn != nil {
+			f := Frame{Index: i, Total: len(refs), Payload: payload, Prev: prev}
+			if prev != nil {
+				f.Raised, f.Cleared = diff(prev.Flags.ActiveFlags(), payload.Flags.ActiveFlags())
+			} else {
+				f.Raised = payload.Flags.ActiveFlags()
+			}
+			if err := fn(f); err != nil {
+				return sum, err
+			}
+		}
+		prev = payload
+	}
+	return sum, nil
+}
+
+// wait sleeps for the replayed share of gap, capped at MaxGap.
+func (r *Replayer) wait(ctx context.Context, gap time.Duration) error {
+	if r.speed == 0 || gap <= 0 {
+		return ctx.Err()
+	}
+	d := time.Duration(float64(min(gap, MaxGap)) / r.speed)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := r.clock.NewTicker(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+// diff returns the flags only in cur and only in prev.
+func diff(prev, cur []string) (raised, cleared []string) {
+	for _, f := range cur {
+		if !slices.Contains(prev, f) {
+			raised = append(raised, f)
+		}
+	}
+	for _, f := range prev {
+		if !slices.Contains(cur, f) {
+			cleared = append(cleared, f)
+		}
+	}
+	return raised, cleared
+}
+
+// stored serves a stored snapshot as both collector and rate provider,
+// so the pipeline flags it with the rates computed when it was collected.
+type stored struct{ snap *relational.StoredSnapshot }
+
+func (s stored) GetFastMetrics(context.Context) (*collector.RawStats, error) {
+	return s.snap.Stats, nil
+}
+
+func (s stored) GetSlowMetrics(context.Context) (*collector.RawStats, error) {
+	return s.snap.Stats, nil
+}
+
+func (s stored) GetDerivedRates(context.Context, relational.RawStatsFixed) (*relational.DerivedRates, error) {
+	d := s.snap.Derived
+	return &d, nil
+}
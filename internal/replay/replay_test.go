@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"syschecker/internal/collector"
+	"syschecker/internal/database/relational"
+)
+
+type fakeSource struct {
+	snaps []*relational.StoredSnapshot
+}
+
+func (f *fakeSource) SnapshotRange(_ context.Context, _ string, from, to time.Time) ([]relational.SnapshotRef, error) {
+	var refs []relational.SnapshotRef
+	for _, s := range f.snaps {
+		if !s.CollectedAt.Before(from) && (to.IsZero() || s.CollectedAt.Before(to)) {
+			refs = append(refs, relational.SnapshotRef{ID: s.ID, CollectedAt: s.CollectedAt})
+		}
+	}
+	return refs, nil
+}
+
+func (f *fakeSource) LoadSnapshot(_ context.Context, id int64) (*relational.StoredSnapshot, error) {
+	return f.snaps[id-1], nil
+}
+
+// cpuFlagger raises cpu_overloaded above 90% CPU.
+type cpuFlagger struct{}
+
+func (cpuFlagger) Flag(s *relational.RawStatsFixed, _ *relational.DerivedRates) *relational.SnapshotFlags {
+	return &relational.SnapshotFlags{FlagCPUOverloaded: s.CPUUsagePct > 90}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := &fakeSource{}
+	for i, cpu := range []float64{20, 95, 97, 30} {
+		src.snaps = append(src.snaps, &relational.StoredSnapshot{
+			ID: int64(i + 1), CollectedAt: start.Add(time.Duration(i) * 30 * time.Second), AgentID: "web-1",
+			Stats: &collector.RawStats{Hostname: "web-1", CPUUsage: cpu},
+		})
+	}
+
+	var frames []Frame
+	sum, err := New(src, cpuFlagger{}, WithSpeed(0)).Run(context.Background(), "web-1", start, time.Time{}, func(f Frame) error {
+		frames = append(frames, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Frames != 4 || !sum.To.Equal(start.Add(90*time.Second)) {
+		t.Errorf("summary = %+v", sum)
+	}
+	// Snapshots keep the time they were collected at.
+	if got := frames[2].Payload.Raw.CollectedAt; !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("frame 2 collected at %v", got)
+	}
+	if !slices.Equal(frames[1].Raised, []string{"cpu_overloaded"}) || frames[2].Raised != nil || !slices.Equal(frames[3].Cleared, []string{"cpu_overloaded"}) {
+		t.Errorf("flag changes: %v/%v, %v, %v", frames[1].Raised, frames[1].Cleared, frames[2].Raised, frames[3].Cleared)
+	}
+
+	if _, err := New(src, cpuFlagger{}).Run(context.Background(), "web-1", start.Add(time.Hour), time.Time{}, nil); err == nil {
+		t.Error("expected an error for an empty range")
+	}
+}
+
+func TestWaitStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := New(&fakeSource{}, cpuFlagger{}, WithSpeed(60))
+	// A cancelled context ends the wait without a tick.
+	if err := r.wait(ctx, time.Hour); err == nil {
+		t.Error("wait ignored the cancelled context")
+	}
+	if err := New(&fakeSource{}, cpuFlagger{}, WithSpeed(0)).wait(context.Background(), time.Hour); err != nil {
+		t.Errorf("speed 0 waited: %v", err)
+	}
+}
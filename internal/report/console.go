@@ -0,0 +1,140 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/timefmt"
+)
+
+// ANSI styles used by the console report.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiReverse = "\x1b[7m"
+	ansiYellow  = "\x1b[33m"
+	ansiRed     = "\x1b[31m"
+)
+
+// consoleTopProcesses is how many top processes the console report lists.
+const consoleTopProcesses = 3
+
+// consoleMetric is one value in the console report.
+type consoleMetric struct {
+	label  string
+	format string
+	value  func(*relational.RawStatsFixed) float64
+	th     func(flagger.Config) *flagger.Thresholds // nil when the metric has no thresholds
+}
+
+var consoleMetrics = []consoleMetric{
+	{"CPU", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.CPUUsagePct }, func(c flagger.Config) *flagger.Thresholds { return &c.CPU }},
+	{"RAM", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.RAMUsagePct }, func(c flagger.Config) *flagger.Thresholds { return &c.RAM }},
+	{"Swap", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.SwapUsagePct }, nil},
+	{"Load", "%6.2f", func(s *relational.RawStatsFixed) float64 { return s.LoadAvg1 }, nil},
+	{"Disk", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.DiskUsagePct }, func(c flagger.Config) *flagger.Thresholds { return &c.Disk }},
+	{"Inode", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.InodeUsagePct }, func(c flagger.Config) *flagger.Thresholds { return &c.Inode }},
+	{"Ping", "%4.0fms", func(s *relational.RawStatsFixed) float64 { return s.NetLatencyMS }, func(c flagger.Config) *flagger.Thresholds { return &c.Net }},
+	{"Loss", "%5.1f%%", func(s *relational.RawStatsFixed) float64 { return s.NetLossPct }, func(c flagger.Config) *flagger.Thresholds { return &c.Loss }},
+	{"TCP", "%6.0f", func(s *relational.RawStatsFixed) float64 { return float64(s.ActiveTCP) }, func(c flagger.Config) *flagger.Thresholds { return &c.ActiveTCP }},
+}
+
+// RenderConsole writes a compact plain-text report of s, three metrics per
+// line. When prev is set, values that changed since prev are highlighted and
+// values that moved into another threshold band are marked. Without color
+// the marks are a trailing "*" (changed) or "!" (crossed a threshold).
+func RenderConsole(w io.Writer, s, prev *relational.RawStatsFixed, cfg flagger.Config, color bool) error {
+	var b strings.Builder
+	host := s.Hostname
+	if host == "" {
+		host = "unknown host"
+	}
+	fmt.Fprintf(&b, "%s  %s  up %s\n", host, timefmt.Format(s.CollectedAt, cfg.Location),
+		time.Duration(s.UptimeSeconds)*time.Second)
+
+	for i, m := range consoleMetrics {
+		cur := m.value(s)
+		text := fmt.Sprintf(m.format, cur)
+		level := 0
+		if m.th != nil {
+			level = thresholdLevel(cur, m.th(cfg))
+		}
+		changed, crossed := false, false
+		if prev != nil {
+			old := m.value(prev)
+			changed = fmt.Sprintf(m.format, old) != text
+			crossed = m.th != nil && thresholdLevel(old, m.th(cfg)) != level
+		}
+
+		fmt.Fprintf(&b, "%-5s ", m.label)
+		b.WriteString(styleValue(text, level, changed, crossed, color))
+		if i%3 == 2 || i == len(consoleMetrics)-1 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString("   ")
+		}
+	}
+
+	if !s.IsConnected {
+		b.WriteString(styleValue("offline", 2, false, prev != nil && prev.IsConnected, color) + "\n")
+	}
+	for i, p := range s.TopProcesses {
+		if i == consoleTopProcesses {
+			break
+		}
+		label := ""
+		if i == 0 {
+			label = "Top"
+		}
+		fmt.Fprintf(&b, "%-5s %-20.20s %7d %5.1f%% cpu %5.1f%% mem\n", label, p.Name, p.PID, p.CPUPct, p.MemPct)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// thresholdLevel is 0 below the warning level, 1 at warning and 2 at critical.
+func thresholdLevel(v float64, th *flagger.Thresholds) int {
+	switch {
+	case th.Critical > 0 && v >= th.Critical:
+		return 2
+	case th.Warning > 0 && v >= th.Warning:
+		return 1
+	}
+	return 0
+}
+
+// styleValue colors text by level and highlights changes.
+func styleValue(text string, level int, changed, crossed, color bool) string {
+	if !color {
+		switch {
+		case crossed:
+			return text + "!"
+		case changed:
+			return text + "*"
+		}
+		return text + " "
+	}
+
+	var style string
+	switch level {
+	case 2:
+		style += ansiRed
+	case 1:
+		style += ansiYellow
+	}
+	if changed || crossed {
+		style += ansiBold
+	}
+	if crossed {
+		style += ansiReverse
+	}
+	if style == "" {
+		return text + " "
+	}
+	return style + text + ansiReset + " "
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+)
+
+func TestRenderConsoleMarksChanges(t *testing.T) {
+	prev := &relational.RawStatsFixed{Hostname: "web-1", CPUUsagePct: 50, RAMUsagePct: 40, DiskUsagePct: 85, IsConnected: true}
+	cur := *prev
+	cur.CPUUsagePct = 95 // crosses critical
+	cur.RAMUsagePct = 41 // changes within the ok band
+	cur.TopProcesses = []relational.ProcessStatFixed{{PID: 7, Name: "postgres", CPUPct: 80, MemPct: 12}}
+
+	var b strings.Builder
+	if err := RenderConsole(&b, &cur, prev, flagger.DefaultConfig(), false); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{"CPU    95.0%!", "RAM    41.0%*", "Disk   85.0% ", "Top   postgres"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+
+	b.Reset()
+	if err := RenderConsole(&b, &cur, prev, flagger.DefaultConfig(), true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), ansiRed+ansiBold+ansiReverse+" 95.0%"+ansiReset) {
+		t.Errorf("crossed CPU not highlighted:\n%q", b.String())
+	}
+}
@@ -0,0 +1,112 @@
+// Package report renders stored syschecker data into human-friendly formats.
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+var weekdays = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// severityColors maps severity 0..4 to fill colors; index -1 (no data) uses noDataColor.
+var severityColors = [5]string{"#2e7d32", "#9e9d24", "#f9a825", "#ef6c00", "#c62828"}
+
+const noDataColor = "#e0e0e0"
+
+// severityGlyphs are used by the text renderer, one per severity level.
+var severityGlyphs = [5]rune{'·', '░', '▒', '▓', '█'}
+
+func cellColor(c relational.HeatmapCell) string {
+	if c.Samples == 0 {
+		return noDataColor
+	}
+	return severityColors[clampSeverity(c.MaxSeverity)]
+}
+
+func clampSeverity(s int32) int {
+	return int(min(max(s, 0), 4))
+}
+
+// RenderHeatmapText renders the heatmap as a terminal grid, one row per weekday.
+func RenderHeatmapText(hm *relational.SeverityHeatmap) string {
+	var b strings.Builder
+	b.WriteString("    ")
+	for h := 0; h < 24; h += 3 {
+		fmt.Fprintf(&b, "%-3d", h)
+	}
+	b.WriteString(" (UTC)\n")
+
+	for d := 0; d < 7; d++ {
+		b.WriteString(weekdays[d] + " ")
+		for h := 0; h < 24; h++ {
+			c := hm.Cells[d][h]
+			if c.Samples == 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteRune(severityGlyphs[clampSeverity(c.MaxSeverity)])
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("legend: · ok  ░ low  ▒ medium  ▓ high  █ critical\n")
+	return b.String()
+}
+
+// WriteHeatmapSVG writes the heatmap as a standalone SVG image.
+func WriteHeatmapSVG(w io.Writer, hm *relational.SeverityHeatmap) error {
+	const (
+		cell   = 24
+		left   = 40
+		top    = 24
+		width  = left + 24*cell + 8
+		height = top + 7*cell + 8
+	)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="11">`+"\n", width, height)
+	for h := 0; h < 24; h += 3 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%02d</text>`+"\n", left+h*cell+4, top-8, h)
+	}
+	for d := 0; d < 7; d++ {
+		y := top + d*cell
+		fmt.Fprintf(&b, `<text x="4" y="%d">%s</text>`+"\n", y+cell-8, weekdays[d])
+		for h := 0; h < 24; h++ {
+			c := hm.Cells[d][h]
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s %02d:00 UTC: max severity %d (%d samples)</title></rect>`+"\n",
+				left+h*cell, y, cell-2, cell-2, cellColor(c), weekdays[d], h, c.MaxSeverity, c.Samples)
+		}
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHeatmapHTML writes a self-contained HTML page embedding the SVG heatmap.
+func WriteHeatmapHTML(w io.Writer, hm *relational.SeverityHeatmap) error {
+	title := "Severity heatmap"
+	if hm.Hostname != "" {
+		title += " – " + hm.Hostname
+	}
+
+	var svg strings.Builder
+	if err := WriteHeatmapSVG(&svg, hm); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>%[1]s</title></head>
+<body style="font-family: sans-serif">
+<h1>%[1]s</h1>
+<p>Max severity per weekday and hour since %[2]s. Grey cells have no data.</p>
+%[3]s</body>
+</html>
+`, html.EscapeString(title), hm.Since.UTC().Format(time.RFC3339), svg.String())
+	return err
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"syschecker/internal/database/relational"
+)
+
+func TestRenderHeatmapText(t *testing.T) {
+	hm := &relational.SeverityHeatmap{}
+	hm.Cells[2][14] = relational.HeatmapCell{MaxSeverity: 4, Samples: 10}
+	hm.Cells[2][15] = relational.HeatmapCell{MaxSeverity: 0, Samples: 10}
+
+	lines := strings.Split(RenderHeatmapText(hm), "\n")
+	tue := []rune(lines[3])
+	if string(tue[:3]) != "Tue" {
+		t.Fatalf("expected Tuesday row, got %q", lines[3])
+	}
+	if tue[4+14] != '█' || tue[4+15] != '·' || tue[4+13] != ' ' {
+		t.Errorf("unexpected Tuesday row %q", lines[3])
+	}
+}
+
+func TestWriteHeatmapSVG(t *testing.T) {
+	hm := &relational.SeverityHeatmap{}
+	hm.Cells[0][0] = relational.HeatmapCell{MaxSeverity: 9, Samples: 1}
+
+	var b strings.Builder
+	if err := WriteHeatmapSVG(&b, hm); err != nil {
+		t.Fatalf("WriteHeatmapSVG: %v", err)
+	}
+	svg := b.String()
+	if n := strings.Count(svg, "<rect"); n != 7*24 {
+		t.Errorf("expected %d cells, got %d", 7*24, n)
+	}
+	if !strings.Contains(svg, severityColors[4]) {
+		t.Error("expected out-of-range severity to be clamped to critical color")
+	}
+}
@@ -0,0 +1,42 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"syschecker/internal/slo"
+)
+
+// RenderSLOs writes an SLO panel for the console report, one objective per
+// line. Violated objectives are marked "!" (red with color) and those at
+// risk "*" (yellow).
+func RenderSLOs(w io.Writer, statuses []slo.Status, color bool) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("SLOs\n")
+	for _, s := range statuses {
+		compliance, level := "no data", 0
+		if s.Compliance != nil {
+			compliance = fmt.Sprintf("%.2f%%", *s.Compliance)
+		}
+		switch {
+		case s.Violated():
+			level = 2
+		case s.AtRisk():
+			level = 1
+		}
+		fmt.Fprintf(&b, "  %-12.12s %-40.40s ", s.Name, s.Describe())
+		// Reuse the change marks: at risk reads as "*", violated as "!".
+		b.WriteString(styleValue(fmt.Sprintf("%8s", compliance), level, level == 1, level == 2, color))
+		fmt.Fprintf(&b, " budget %4.0f%%", 100*s.BudgetRemaining)
+		if s.BurnRate != nil {
+			fmt.Fprintf(&b, "  burn %.1fx", *s.BurnRate)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"syschecker/internal/slo"
+)
+
+func TestRenderSLOs(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	statuses := []slo.Status{
+		{Objective: slo.Objective{Name: "latency", Metric: "net_latency_ms", Op: "<", Threshold: 50, Target: 99},
+			Samples: 100, Compliance: ptr(99.5), Met: true, BudgetRemaining: 0.5, BurnRate: ptr(2)},
+		{Objective: slo.Objective{Name: "disk", Metric: "disk_usage_pct", Op: "<", Threshold: 90, Target: 100},
+			Samples: 100, Compliance: ptr(97)},
+	}
+	var b strings.Builder
+	if err := RenderSLOs(&b, statuses, false); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"latency      net_latency_ms < 50 for 99% of 720h0m0s",
+		"  99.50%* budget   50%  burn 2.0x",
+		"disk_usage_pct < 90 always",
+		"  97.00%! budget    0%\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
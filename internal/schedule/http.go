@@ -0,0 +1,30 @@
+package schedule
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves the scheduler over HTTP: GET returns the active profile
+// and the available ones, POST ?name=<profile> switches profile.
+func Handler(s *Scheduler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if _, err := s.Activate(r.URL.Query().Get("name")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Active   State     `json:"active"`
+			Profiles []Profile `json:"profiles"`
+		}{s.Current(), s.Profiles()})
+	})
+}
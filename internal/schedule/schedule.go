@@ -0,0 +1,156 @@
+// Package schedule holds named collection profiles and the runtime switch
+// between them. Collection loops read the active profile's intervals and
+// watch Changed to re-time themselves without a restart.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+// Built-in profile names.
+const (
+	Default  = "default"
+	LowPower = "low-power"
+	Incident = "incident"
+	Burst    = "burst" // registered by the flagger's burst trigger
+)
+
+// Profile is a named pair of collection intervals.
+type Profile struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Fast        time.Duration `json:"fast"`               // live metrics polling (TUI)
+	Slow        time.Duration `json:"slow"`               // snapshot pipeline (DataWorker, MCP ingest)
+	Duration    time.Duration `json:"duration,omitempty"` // revert to the default after this long; 0 keeps it active
+}
+
+// Builtin returns the profiles every scheduler starts with.
+func Builtin() []Profile {
+	return []Profile{
+		{Name: Default, Description: "normal collection", Fast: time.Second, Slow: 20 * time.Second},
+		{Name: LowPower, Description: "battery and idle hosts", Fast: time.Minute, Slow: 10 * time.Minute},
+		{Name: Incident, Description: "high-frequency collection while investigating", Fast: time.Second, Slow: 5 * time.Second, Duration: 10 * time.Minute},
+	}
+}
+
+// State is the active profile and, for timed profiles, when it reverts.
+type State struct {
+	Profile   Profile   `json:"profile"`
+	Since     time.Time `json:"since"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Scheduler tracks the active profile. It is safe for concurrent use.
+type Scheduler struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	profiles map[string]Profile
+	state    State
+	changed  chan struct{}
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithProfiles adds profiles, replacing built-ins of the same name.
+func WithProfiles(ps ...Profile) Option {
+	return func(s *Scheduler) {
+		for _, p := range ps {
+			s.profiles[p.Name] = p
+		}
+	}
+}
+
+// WithClock drives expiry from c.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = clock.OrReal(c)
+	}
+}
+
+// NewScheduler creates a scheduler running the default profile.
+func NewScheduler(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		clock:    clock.Real,
+		profiles: make(map[string]Profile),
+		changed:  make(chan struct{}),
+	}
+	for _, p := range Builtin() {
+		s.profiles[p.Name] = p
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	s.state = State{Profile: s.profiles[Default], Since: s.clock.Now()}
+	return s
+}
+
+// Profiles lists the known profiles by name.
+func (s *Scheduler) Profiles() []Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Register adds or replaces a profile.
+func (s *Scheduler) Register(p Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[p.Name] = p
+}
+
+// Activate switches to the named profile. Timed profiles revert to the
+// default once their duration has passed.
+func (s *Scheduler) Activate(name string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[name]
+	if !ok {
+		return State{}, fmt.Errorf("unknown profile %q", name)
+	}
+	s.setLocked(p)
+	return s.state, nil
+}
+
+// Current returns the active profile, reverting an expired timed profile first.
+func (s *Scheduler) Current() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.state.ExpiresAt.IsZero() && !s.clock.Now().Before(s.state.ExpiresAt) {
+		s.setLocked(s.profiles[Default])
+	}
+	return s.state
+}
+
+// Changed returns a channel that is closed on the next profile switch.
+// Callers fetch a fresh channel after each change. Expiry is noticed on the
+// next call to Current, so loops polling at the timed profile's interval
+// revert within one tick.
+func (s *Scheduler) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+func (s *Scheduler) setLocked(p Profile) {
+	now := s.clock.Now()
+	s.state = State{Profile: p, Since: now}
+	if p.Duration > 0 {
+		s.state.ExpiresAt = now.Add(p.Duration)
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"syschecker/internal/clock"
+)
+
+func TestActivateAndExpire(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := NewScheduler(WithClock(clk))
+
+	if got := s.Current().Profile.Name; got != Default {
+		t.Fatalf("initial profile = %q", got)
+	}
+	changed := s.Changed()
+	st, err := s.Activate(Incident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Changed not closed on switch")
+	}
+	if st.Profile.Slow != 5*time.Second || !st.ExpiresAt.Equal(time.Unix(600, 0)) {
+		t.Errorf("incident state = %+v", st)
+	}
+
+	clk.Advance(9 * time.Minute)
+	if got := s.Current().Profile.Name; got != Incident {
+		t.Errorf("profile before expiry = %q", got)
+	}
+	clk.Advance(time.Minute)
+	if got := s.Current().Profile.Name; got != Default {
+		t.Errorf("profile after expiry = %q, want default", got)
+	}
+
+	if _, err := s.Activate("turbo"); err == nil {
+		t.Error("expected unknown profile to fail")
+	}
+}
+
+func TestWithProfilesOverridesBuiltin(t *testing.T) {
+	s := NewScheduler(WithProfiles(Profile{Name: Default, Fast: time.Second, Slow: 30 * time.Second}))
+	if got := s.Current().Profile.Slow; got != 30*time.Second {
+		t.Errorf("default slow = %v, want 30s", got)
+	}
+	if n := len(s.Profiles()); n != 3 {
+		t.Errorf("profiles = %d, want 3", n)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := NewScheduler()
+	h := Handler(s)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/profile?name=low-power", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := s.Current().Profile.Name; got != LowPower {
+		t.Errorf("profile = %q, want low-power", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/profile?name=nope", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown profile status = %d", rec.Code)
+	}
+}
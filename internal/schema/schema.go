@@ -0,0 +1,78 @@
+// Package schema publishes JSON Schemas for the data shapes syschecker returns
+// from its tools, so clients can validate and parse them reliably.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"syschecker/internal/collector"
+	"syschecker/internal/database/relational"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// URIPrefix is the MCP resource URI prefix under which schemas are published.
+const URIPrefix = "syschecker://schema/"
+
+type entry struct {
+	title string
+	infer func() (*jsonschema.Schema, error)
+}
+
+var registry = map[string]entry{
+	"raw_stats": {
+		title: "RawStats: live metrics returned by get_realtime_metrics",
+		infer: func() (*jsonschema.Schema, error) { return jsonschema.For[collector.RawStats](nil) },
+	},
+	"merged_stats": {
+		title: "RawStatsFixed: fast and cached slow metrics returned by get_realtime_metrics with metric_type merged",
+		infer: func() (*jsonschema.Schema, error) { return jsonschema.For[relational.RawStatsFixed](nil) },
+	},
+	"snapshot_summary": {
+		title: "SnapshotSummary: stored snapshots returned by get_historical_snapshots",
+		infer: func() (*jsonschema.Schema, error) { return jsonschema.For[relational.SnapshotSummary](nil) },
+	},
+	"snapshot_flags": {
+		title: "SnapshotFlags: flagger analysis result for one snapshot",
+		infer: func() (*jsonschema.Schema, error) { return jsonschema.For[relational.SnapshotFlags](nil) },
+	},
+}
+
+// Names returns the published schema names in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// For returns the JSON Schema for a published name.
+func For(name string) (*jsonschema.Schema, error) {
+	e, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	s, err := e.infer()
+	if err != nil {
+		return nil, fmt.Errorf("infer schema %s: %w", name, err)
+	}
+	s.Schema = draft
+	s.ID = URIPrefix + name
+	s.Title = e.title
+	return s, nil
+}
+
+// JSON returns the indented JSON encoding of a published schema.
+func JSON(name string) ([]byte, error) {
+	s, err := For(name)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
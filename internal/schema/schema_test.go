@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPublishedSchemas(t *testing.T) {
+	for _, name := range Names() {
+		b, err := JSON(name)
+		if err != nil {
+			t.Fatalf("JSON(%q): %v", name, err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatalf("schema %s is not valid JSON: %v", name, err)
+		}
+		if doc["$id"] != URIPrefix+name {
+			t.Errorf("schema %s has $id %v", name, doc["$id"])
+		}
+		if doc["type"] != "object" {
+			t.Errorf("schema %s has type %v, want object", name, doc["type"])
+		}
+	}
+}
+
+func TestUnknownSchema(t *testing.T) {
+	if _, err := For("nope"); err == nil {
+		t.Error("expected error for unknown schema")
+	}
+}
@@ -0,0 +1,26 @@
+package selfstats
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerServesPanel(t *testing.T) {
+	s, err := NewSampler("")
+	if err != nil {
+		t.Skipf("self process unavailable: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	Handler(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/self?format=text", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "syschecker self\n") {
+		t.Errorf("GET ?format=text = %d:\n%s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/self", nil))
+	if !strings.Contains(rec.Body.String(), `"goroutines"`) {
+		t.Errorf("GET = %s, want JSON", rec.Body)
+	}
+}
@@ -0,0 +1,46 @@
+package selfstats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"syschecker/internal/units"
+)
+
+// FormatPanel renders a sample as the compact "syschecker itself" panel,
+// served by Handler as text.
+func FormatPanel(s Sample) string {
+	persist := "n/a"
+	if !s.LastPersistAt.IsZero() {
+		persist = fmt.Sprintf("%s (%s ago)", s.LastPersist.Round(time.Microsecond*100),
+			s.At.Sub(s.LastPersistAt).Round(time.Second))
+	}
+	db := "in-memory"
+	if s.DBBytes > 0 {
+		db = FormatBytes(uint64(s.DBBytes))
+		if s.DBBudget > 0 {
+			db += fmt.Sprintf(" of %s (%.0f%%)", FormatBytes(uint64(s.DBBudget)), float64(s.DBBytes)/float64(s.DBBudget)*100)
+		}
+	}
+
+	rows := [][2]string{
+		{"RSS", FormatBytes(s.RSSBytes)},
+		{"CPU", fmt.Sprintf("%.1f%%", s.CPUPct)},
+		{"Goroutines", fmt.Sprintf("%d", s.Goroutines)},
+		{"DB size", db},
+		{"Last persist", persist},
+	}
+
+	var b strings.Builder
+	b.WriteString("syschecker self\n")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "  %-13s %s\n", r[0], r[1])
+	}
+	return b.String()
+}
+
+// FormatBytes renders a byte count with a binary unit suffix.
+func FormatBytes(n uint64) string {
+	return units.Bytes(n)
+}
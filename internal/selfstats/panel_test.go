@@ -0,0 +1,46 @@
+package selfstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[uint64]string{
+		512:       "512 B",
+		1536:      "1.5 KiB",
+		50 << 20:  "50.0 MiB",
+		3 << 30:   "3.0 GiB",
+		1<<40 + 1: "1.0 TiB",
+	}
+	for in, want := range cases {
+		if got := FormatBytes(in); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatPanel(t *testing.T) {
+	now := time.Now()
+	out := FormatPanel(Sample{
+		At:            now,
+		RSSBytes:      42 << 20,
+		CPUPct:        1.25,
+		Goroutines:    17,
+		LastPersist:   3 * time.Millisecond,
+		LastPersistAt: now.Add(-5 * time.Second),
+	})
+	for _, want := range []string{"42.0 MiB", "1.2%", "17", "in-memory", "3ms (5s ago)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("panel missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatPanelBudget(t *testing.T) {
+	out := FormatPanel(Sample{At: time.Now(), DBBytes: 768 << 20, DBBudget: 1 << 30})
+	if !strings.Contains(out, "768.0 MiB of 1.0 GiB (75%)") {
+		t.Errorf("panel missing the budget:\n%s", out)
+	}
+}
@@ -0,0 +1,111 @@
+// Package selfstats samples syschecker's own resource footprint.
+package selfstats
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Sample is a point-in-time view of the current process.
+type Sample struct {
+	At         time.Time `json:"at"`
+	RSSBytes   uint64    `json:"rss_bytes"`
+	CPUPct     float64   `json:"cpu_pct"`
+	Goroutines int       `json:"goroutines"`
+	HeapAlloc  uint64    `json:"heap_alloc_bytes"`
+	NumGC      uint32    `json:"num_gc"`
+	DBBytes    int64     `json:"db_bytes"` // database file plus WAL, 0 if unknown
+	DBBudget   int64     `json:"db_budget_bytes,omitempty"`
+
+	LastPersist   time.Duration `json:"last_persist_ns"` // duration of the latest snapshot insert
+	LastPersistAt time.Time     `json:"last_persist_at"`
+}
+
+// PersistReporter reports the latency of the most recent persist, e.g. a DataWorker.
+type PersistReporter interface {
+	LastPersist() (time.Duration, time.Time)
+}
+
+// Option configures a Sampler.
+type Option func(*Sampler)
+
+// WithPersistReporter includes persist latency from r in each sample.
+func WithPersistReporter(r PersistReporter) Option {
+	return func(s *Sampler) {
+		s.persist = r
+	}
+}
+
+// WithDBBudget reports the database size budget alongside its size.
+func WithDBBudget(maxBytes int64) Option {
+	return func(s *Sampler) {
+		s.dbBudget = maxBytes
+	}
+}
+
+// Sampler collects Samples for this process.
+type Sampler struct {
+	proc     *process.Process
+	dbPath   string
+	dbBudget int64
+	persist  PersistReporter
+}
+
+// NewSampler creates a sampler. dbPath may be empty for in-memory databases.
+func NewSampler(dbPath string, opts ...Option) (*Sampler, error) {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil, fmt.Errorf("open self process: %w", err)
+	}
+	s := &Sampler{proc: p, dbPath: dbPath}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Sample reads the current footprint. CPUPct is averaged since the previous call.
+func (s *Sampler) Sample(ctx context.Context) (Sample, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	out := Sample{
+		At:         time.Now(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  ms.HeapAlloc,
+		NumGC:      ms.NumGC,
+		DBBytes:    FileSize(s.dbPath) + FileSize(s.dbPath+".wal"),
+		DBBudget:   s.dbBudget,
+	}
+	if s.persist != nil {
+		out.LastPersist, out.LastPersistAt = s.persist.LastPersist()
+	}
+
+	mem, err := s.proc.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return out, fmt.Errorf("read rss: %w", err)
+	}
+	out.RSSBytes = mem.RSS
+
+	if cpu, err := s.proc.PercentWithContext(ctx, 0); err == nil {
+		out.CPUPct = cpu
+	}
+	return out, nil
+}
+
+// FileSize returns the size of path, or 0 if it is empty or missing.
+func FileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
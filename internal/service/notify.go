@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifySignals cancels ctx on Ctrl+C or SIGTERM.
+func notifySignals(ctx context.Context, cancel context.CancelFunc) (context.Context, context.CancelFunc) {
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	return sigCtx, func() {
+		stop()
+		cancel()
+	}
+}
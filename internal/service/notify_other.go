@@ -0,0 +1,12 @@
+//go:build !windows
+
+package service
+
+import "context"
+
+// NotifyContext returns a context cancelled on Ctrl+C or SIGTERM, which is
+// how systemd and launchd stop the agent.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	return notifySignals(ctx, cancel)
+}
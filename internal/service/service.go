@@ -0,0 +1,172 @@
+// Package service installs the headless agent as a system service: a
+// systemd unit on Linux, a launchd daemon on macOS and a service of the
+// service control manager on Windows.
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the service name used when none is given.
+const DefaultName = "syschecker"
+
+// ErrUnsupported is returned on platforms without a known service manager.
+var ErrUnsupported = errors.New("service management is not supported on this platform")
+
+// Config describes the installed service.
+type Config struct {
+	Name        string
+	Description string
+	Executable  string            // absolute path of the binary
+	Args        []string          // e.g. the global flags and -headless
+	WorkDir     string            // relative paths in Args resolve here
+	Env         map[string]string // copied from the installing shell
+}
+
+// Status is what the service manager reports about an installed service.
+type Status struct {
+	Installed bool
+	State     string // as the service manager words it, e.g. "active" or "running"
+	Path      string // unit or plist file, empty on Windows
+}
+
+func (s Status) String() string {
+	if !s.Installed {
+		return "not installed"
+	}
+	if s.Path != "" {
+		return fmt.Sprintf("%s (%s)", s.State, s.Path)
+	}
+	return s.State
+}
+
+func (c Config) name() string {
+	if c.Name == "" {
+		return DefaultName
+	}
+	return c.Name
+}
+
+func (c Config) description() string {
+	if c.Description == "" {
+		return "SysChecker agent"
+	}
+	return c.Description
+}
+
+// sortedEnv returns c.Env as KEY=value pairs in key order.
+func (c Config) sortedEnv() []string {
+	env := make([]string, 0, len(c.Env))
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// SystemdUnit renders c as a systemd unit restarting the agent when it
+// exits with an error.
+func SystemdUnit(c Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target\n\n[Service]\n", c.description())
+	cmd := []string{systemdQuote(c.Executable)}
+	for _, a := range c.Args {
+		cmd = append(cmd, systemdQuote(a))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(cmd, " "))
+	if c.WorkDir != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(c.WorkDir))
+	}
+	for _, kv := range c.sortedEnv() {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(kv))
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=5\n\n[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes s for a unit file when it holds characters systemd
+// would split on or expand.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// LaunchdLabel is the launchd label of the service named name.
+func LaunchdLabel(name string) string {
+	return "com." + name + ".agent"
+}
+
+// LaunchdPlist renders c as a launchd daemon kept alive and started at
+// boot. Output goes to /var/log/<name>.log.
+func LaunchdPlist(c Config) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	plistKey(&b, "Label", LaunchdLabel(c.name()))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, a := range append([]string{c.Executable}, c.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(a))
+	}
+	b.WriteString("\t</array>\n")
+	if c.WorkDir != "" {
+		plistKey(&b, "WorkingDirectory", c.WorkDir)
+	}
+	if len(c.Env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, kv := range c.sortedEnv() {
+			k, v, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", xmlEscape(k), xmlEscape(v))
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n\t<key>KeepAlive</key>\n\t<true/>\n")
+	log := "/var/log/" + c.name() + ".log"
+	plistKey(&b, "StandardOutPath", log)
+	plistKey(&b, "StandardErrorPath", log)
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func plistKey(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// run runs a service manager command, returning its trimmed output and,
+// on failure, an error quoting it.
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	text := strings.TrimSpace(string(out))
+	if err != nil {
+		return text, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, text)
+	}
+	return text, nil
+}
+
+// writeFile writes a unit or plist, pointing at root when permission is
+// denied.
+func writeFile(path, content string) error {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("write %s: %w (run as root)", path, err)
+		}
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
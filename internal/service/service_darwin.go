@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// launchdDir holds the plists of system daemons.
+const launchdDir = "/Library/LaunchDaemons"
+
+func plistPath(name string) string {
+	return filepath.Join(launchdDir, LaunchdLabel(name)+".plist")
+}
+
+// Install writes a launchd plist for c and loads it.
+func Install(c Config) error {
+	path := plistPath(c.name())
+	if err := writeFile(path, LaunchdPlist(c)); err != nil {
+		return err
+	}
+	_, err := run("launchctl", "bootstrap", "system", path)
+	return err
+}
+
+// Uninstall unloads the daemon named name and removes its plist.
+func Uninstall(name string) error {
+	path := plistPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+	if _, err := run("launchctl", "bootout", "system/"+LaunchdLabel(name)); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// QueryStatus reports whether the daemon named name is installed and
+// running.
+func QueryStatus(name string) (Status, error) {
+	path := plistPath(name)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return Status{}, nil
+	}
+	out, err := run("launchctl", "print", "system/"+LaunchdLabel(name))
+	if err != nil {
+		return Status{Installed: true, State: "not loaded", Path: path}, nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), " = "); ok && k == "state" {
+			return Status{Installed: true, State: v, Path: path}, nil
+		}
+	}
+	return Status{Installed: true, State: "loaded", Path: path}, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// systemdDir holds the units of system services.
+const systemdDir = "/etc/systemd/system"
+
+func unitPath(name string) string {
+	return filepath.Join(systemdDir, name+".service")
+}
+
+// Install writes a systemd unit for c, then enables and starts it.
+func Install(c Config) error {
+	path := unitPath(c.name())
+	if err := writeFile(path, SystemdUnit(c)); err != nil {
+		return err
+	}
+	if _, err := run("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	_, err := run("systemctl", "enable", "--now", c.name()+".service")
+	return err
+}
+
+// Uninstall stops and disables the unit named name and removes it.
+func Uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+	if _, err := run("systemctl", "disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	_, err := run("systemctl", "daemon-reload")
+	return err
+}
+
+// QueryStatus reports whether the unit named name is installed and active.
+func QueryStatus(name string) (Status, error) {
+	path := unitPath(name)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return Status{}, nil
+	}
+	// is-active exits non-zero for inactive units but still prints the state.
+	state, err := run("systemctl", "is-active", name+".service")
+	if state == "" && err != nil {
+		return Status{}, err
+	}
+	return Status{Installed: true, State: state, Path: path}, nil
+}
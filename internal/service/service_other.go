@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+// Install is not supported on this platform.
+func Install(Config) error {
+	return ErrUnsupported
+}
+
+// Uninstall is not supported on this platform.
+func Uninstall(string) error {
+	return ErrUnsupported
+}
+
+// QueryStatus is not supported on this platform.
+func QueryStatus(string) (Status, error) {
+	return Status{}, ErrUnsupported
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testConfig() Config {
+	return Config{
+		Executable: "/opt/sys checker/syschecker",
+		Args:       []string{"-db", "/var/lib/syschecker/s.db", "-label", "team=50%", "-headless"},
+		WorkDir:    "/var/lib/syschecker",
+		Env:        map[string]string{"NEO4J_URI": "bolt://db:7687", "A": `x"y`},
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit(testConfig())
+	for _, want := range []string{
+		"Description=SysChecker agent\n",
+		`ExecStart="/opt/sys checker/syschecker" -db /var/lib/syschecker/s.db -label team=50%% -headless` + "\n",
+		"WorkingDirectory=/var/lib/syschecker\n",
+		`Environment="A=x\"y"` + "\nEnvironment=NEO4J_URI=bolt://db:7687\n",
+		"Restart=on-failure\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit lacks %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	c := testConfig()
+	c.Env["B"] = "<&>"
+	plist := LaunchdPlist(c)
+	if err := xml.Unmarshal([]byte(plist), new(struct{})); err != nil {
+		t.Fatalf("plist is not well-formed XML: %v\n%s", err, plist)
+	}
+	for _, want := range []string{
+		"<string>com.syschecker.agent</string>",
+		"<string>/opt/sys checker/syschecker</string>\n\t\t<string>-db</string>",
+		"<key>B</key>\n\t\t<string>&lt;&amp;&gt;</string>",
+		"<string>/var/log/syschecker.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist lacks %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	if got := (Status{}).String(); got != "not installed" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Status{Installed: true, State: "active", Path: "/etc/systemd/system/syschecker.service"}).String(); got != "active (/etc/systemd/system/syschecker.service)" {
+		t.Errorf("got %q", got)
+	}
+}
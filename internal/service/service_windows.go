@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Install registers c with the service control manager, starting at boot,
+// and starts it.
+func Install(c Config) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+	s, err := m.CreateService(c.name(), c.Executable, mgr.Config{
+		DisplayName: c.description(),
+		Description: c.description(),
+		StartType:   mgr.StartAutomatic,
+	}, c.Args...)
+	if err != nil {
+		return fmt.Errorf("create service %s: %w", c.name(), err)
+	}
+	defer s.Close()
+	// The service manager has no working directory setting, so WorkDir is
+	// dropped; the paths in Args are absolute.
+	if env := c.sortedEnv(); len(env) > 0 {
+		k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+c.name(), registry.SET_VALUE)
+		if err != nil {
+			return fmt.Errorf("set service environment: %w", err)
+		}
+		defer k.Close()
+		if err := k.SetStringsValue("Environment", env); err != nil {
+			return fmt.Errorf("set service environment: %w", err)
+		}
+	}
+	return s.Start()
+}
+
+// Uninstall stops the service named name and deletes it.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+	defer s.Close()
+	if st, err := s.Query(); err == nil && st.State != svc.Stopped {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("stop service %s: %w", name, err)
+		}
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(250 * time.Millisecond) {
+			if st, err := s.Query(); err != nil || st.State == svc.Stopped {
+				break
+			}
+		}
+	}
+	return s.Delete()
+}
+
+// QueryStatus reports whether the service named name is installed and
+// running.
+func QueryStatus(name string) (Status, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return Status{}, fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return Status{}, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	defer s.Close()
+	st, err := s.Query()
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{Installed: true, State: stateNames[st.State]}, nil
+}
+
+var stateNames = map[svc.State]string{
+	svc.Stopped:         "stopped",
+	svc.StartPending:    "starting",
+	svc.StopPending:     "stopping",
+	svc.Running:         "running",
+	svc.ContinuePending: "resuming",
+	svc.PausePending:    "pausing",
+	svc.Paused:          "paused",
+}
+
+// NotifyContext returns a context cancelled on Ctrl+C or, when running as
+// a Windows service, when the service manager stops the service.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return notifySignals(ctx, cancel)
+	}
+	go func() {
+		_ = svc.Run(DefaultName, handler{ctx: ctx, cancel: cancel})
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// handler reports the agent as running until the service manager asks it
+// to stop.
+type handler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (h handler) Execute(_ []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+	s <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-h.ctx.Done():
+			s <- svc.Status{State: svc.StopPending}
+			return false, 0
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				s <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				s <- svc.Status{State: svc.StopPending}
+				h.cancel()
+				return false, 0
+			}
+		}
+	}
+}
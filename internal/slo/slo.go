@@ -0,0 +1,192 @@
+// Package slo evaluates service level objectives, such as "latency under
+// 50 ms 99% of the time" or "disk below 90% always", against stored
+// snapshots: their compliance, how much of the error budget is left and how
+// fast it is burning.
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+// DefaultWindow is the compliance window of objectives that set none.
+const DefaultWindow = 30 * 24 * time.Hour
+
+// BurnWindow is the recent window the burn rate is measured over.
+const BurnWindow = time.Hour
+
+// Objective is one SLO: Metric must satisfy "Metric Op Threshold" in Target
+// percent of the snapshots of each Window.
+//
+// Example:
+//
+//	{"name": "latency", "metric": "net_latency_ms", "op": "<", "threshold": 50, "target": 99}
+type Objective struct {
+	Name      string  `json:"name"`
+	Metric    string  `json:"metric"`           // a snapshot column, e.g. net_latency_ms
+	Op        string  `json:"op"`               // <, <=, > or >=
+	Threshold float64 `json:"threshold"`        // in the metric's unit
+	Target    float64 `json:"target"`           // percent of snapshots; 100 means always
+	Window    string  `json:"window,omitempty"` // Go duration; default 720h
+}
+
+// Validate reports whether o is complete and names a known metric.
+func (o Objective) Validate() error {
+	switch {
+	case o.Name == "":
+		return errors.New("slo without a name")
+	case !relational.IsMetricColumn(o.Metric):
+		return fmt.Errorf("slo %s: unknown metric %q", o.Name, o.Metric)
+	case !slices.Contains(relational.ComparisonOps, o.Op):
+		return fmt.Errorf("slo %s: unknown op %q (want one of %v)", o.Name, o.Op, relational.ComparisonOps)
+	case o.Target <= 0 || o.Target > 100:
+		return fmt.Errorf("slo %s: target %.2f is not a percentage in (0, 100]", o.Name, o.Target)
+	}
+	if _, err := o.window(); err != nil {
+		return fmt.Errorf("slo %s: %w", o.Name, err)
+	}
+	return nil
+}
+
+// WindowDuration returns the objective's compliance window.
+func (o Objective) WindowDuration() time.Duration {
+	d, _ := o.window()
+	return d
+}
+
+func (o Objective) window() (time.Duration, error) {
+	if o.Window == "" {
+		return DefaultWindow, nil
+	}
+	d, err := time.ParseDuration(o.Window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", o.Window)
+	}
+	return d, nil
+}
+
+// Describe states o, e.g. "net_latency_ms < 50 for 99% of 720h0m0s".
+func (o Objective) Describe() string {
+	if o.Target == 100 {
+		return fmt.Sprintf("%s %s %g always", o.Metric, o.Op, o.Threshold)
+	}
+	return fmt.Sprintf("%s %s %g for %g%% of %s", o.Metric, o.Op, o.Threshold, o.Target, o.WindowDuration())
+}
+
+// Status is the state of one objective on one host, or the fleet.
+type Status struct {
+	Objective
+	Host    string `json:"host,omitempty"` // empty for every host
+	Samples int64  `json:"samples"`
+	Good    int64  `json:"good"`
+
+	// Compliance is the percentage of samples meeting the threshold; nil
+	// without samples.
+	Compliance *float64 `json:"compliance_pct,omitempty"`
+	// Met is whether Compliance reaches the target.
+	Met bool `json:"met"`
+	// BudgetRemaining is the fraction of the error budget left: 1 with no
+	// violations, 0 when spent and negative when overspent. An objective
+	// with target 100 has no budget, so any violation makes it 0.
+	BudgetRemaining float64 `json:"budget_remaining"`
+	// BurnRate is how fast the budget burned over the last BurnWindow
+	// relative to the pace that would spend it exactly over the window: 1
+	// is on budget, above 1 runs out early. Nil without recent samples or
+	// for target 100.
+	BurnRate *float64 `json:"burn_rate,omitempty"`
+}
+
+// LowBudget is the remaining budget fraction at or below which a met
+// objective is at risk.
+const LowBudget = 0.25
+
+// AtRisk reports whether s is met but has little budget left or is burning
+// it faster than the window allows.
+func (s Status) AtRisk() bool {
+	return s.Met && (s.BudgetRemaining <= LowBudget || (s.BurnRate != nil && *s.BurnRate > 1))
+}
+
+// Violated reports whether s has samples and misses its target.
+func (s Status) Violated() bool {
+	return s.Samples > 0 && !s.Met
+}
+
+// Source counts snapshots meeting a threshold; *relational.Repo is one.
+type Source interface {
+	ThresholdCompliance(ctx context.Context, hostname, metric, op string, threshold float64, since time.Time) (good, total int64, err error)
+}
+
+// Evaluate returns the status of each objective for hostname, or for every
+// host together when hostname is empty, as of now.
+func Evaluate(ctx context.Context, src Source, objectives []Objective, hostname string, now time.Time) ([]Status, error) {
+	out := make([]Status, 0, len(objectives))
+	for _, o := range objectives {
+		good, total, err := src.ThresholdCompliance(ctx, hostname, o.Metric, o.Op, o.Threshold, now.Add(-o.WindowDuration()))
+		if err != nil {
+			return nil, fmt.Errorf("slo %s: %w", o.Name, err)
+		}
+		recentGood, recentTotal, err := src.ThresholdCompliance(ctx, hostname, o.Metric, o.Op, o.Threshold, now.Add(-BurnWindow))
+		if err != nil {
+			return nil, fmt.Errorf("slo %s: %w", o.Name, err)
+		}
+		out = append(out, status(o, hostname, good, total, recentGood, recentTotal))
+	}
+	return out, nil
+}
+
+// status computes the budget figures of o from snapshot counts.
+func status(o Objective, host string, good, total, recentGood, recentTotal int64) Status {
+	s := Status{Objective: o, Host: host, Samples: total, Good: good, BudgetRemaining: 1}
+	if total == 0 {
+		return s
+	}
+	compliance := 100 * float64(good) / float64(total)
+	s.Compliance = &compliance
+	s.Met = compliance >= o.Target
+
+	budget := 1 - o.Target/100 // allowed share of bad samples
+	bad := float64(total - good)
+	switch {
+	case budget <= 0 && bad > 0:
+		s.BudgetRemaining = 0
+	case budget > 0:
+		s.BudgetRemaining = 1 - bad/(budget*float64(total))
+	}
+	if budget > 0 && recentTotal > 0 {
+		burn := float64(recentTotal-recentGood) / float64(recentTotal) / budget
+		s.BurnRate = &burn
+	}
+	return s
+}
+
+// Tracker holds the objectives in effect, so a config reload can replace
+// them while they are being evaluated.
+type Tracker struct {
+	mu         sync.RWMutex
+	objectives []Objective
+}
+
+// NewTracker returns a tracker of objectives.
+func NewTracker(objectives ...Objective) *Tracker {
+	return &Tracker{objectives: objectives}
+}
+
+// Objectives returns a copy of the objectives in effect.
+func (t *Tracker) Objectives() []Objective {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.objectives)
+}
+
+// SetObjectives replaces the objectives in effect.
+func (t *Tracker) SetObjectives(objectives []Objective) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.objectives = slices.Clone(objectives)
+}
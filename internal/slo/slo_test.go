@@ -0,0 +1,66 @@
+package slo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// counts serves fixed counts, the first pair for the full window and the
+// second for the burn window.
+type counts struct{ good, total, recentGood, recentTotal int64 }
+
+func (c counts) ThresholdCompliance(_ context.Context, _, _, _ string, _ float64, since time.Time) (int64, int64, error) {
+	if time.Since(since) <= BurnWindow+time.Minute {
+		return c.recentGood, c.recentTotal, nil
+	}
+	return c.good, c.total, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	latency := Objective{Name: "latency", Metric: "net_latency_ms", Op: "<", Threshold: 50, Target: 99}
+	disk := Objective{Name: "disk", Metric: "disk_usage_pct", Op: "<", Threshold: 90, Target: 100}
+
+	// 5 of 1000 samples were slow: half the 1% budget is spent. In the last
+	// hour 2 of 100 were, burning at twice the sustainable pace.
+	got, err := Evaluate(context.Background(), counts{995, 1000, 98, 100}, []Objective{latency, disk}, "web-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	l := got[0]
+	if !l.Met || *l.Compliance != 99.5 || !near(l.BudgetRemaining, 0.5) || l.BurnRate == nil || !near(*l.BurnRate, 2) {
+		t.Errorf("latency = %+v", l)
+	}
+	if !l.AtRisk() || l.Violated() {
+		t.Error("latency burning at twice the pace not at risk")
+	}
+	d := got[1]
+	if d.Met || !d.Violated() || d.BudgetRemaining != 0 || d.BurnRate != nil {
+		t.Errorf("an always-objective with violations = %+v", d)
+	}
+
+	got, _ = Evaluate(context.Background(), counts{}, []Objective{latency}, "", time.Now())
+	if got[0].Met || got[0].Violated() || got[0].Compliance != nil || got[0].BudgetRemaining != 1 {
+		t.Errorf("no samples = %+v", got[0])
+	}
+}
+
+func TestObjectiveValidate(t *testing.T) {
+	ok := Objective{Name: "latency", Metric: "net_latency_ms", Op: "<", Threshold: 50, Target: 99, Window: "168h"}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []Objective{
+		{Metric: "net_latency_ms", Op: "<", Target: 99},
+		{Name: "x", Metric: "hostname", Op: "<", Target: 99},
+		{Name: "x", Metric: "net_latency_ms", Op: "!=", Target: 99},
+		{Name: "x", Metric: "net_latency_ms", Op: "<", Target: 150},
+		{Name: "x", Metric: "net_latency_ms", Op: "<", Target: 99, Window: "a week"},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("accepted %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package stream pushes current state and flag transitions to HTTP clients
+// as Server-Sent Events, so dashboards can update without polling DuckDB.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/health"
+)
+
+// Event names on the wire.
+const (
+	EventCurrentState   = "current_state"
+	EventFlagTransition = "flag_transition"
+)
+
+// keepAlive is how often an idle stream sends a comment so proxies keep it open.
+const keepAlive = 15 * time.Second
+
+// subscriberBuffer bounds how far a client may fall behind before events are
+// dropped for it.
+const subscriberBuffer = 64
+
+// CurrentState mirrors a host's current_state row.
+type CurrentState struct {
+	AgentID     string            `json:"agent_id"`
+	HostID      int64             `json:"host_id"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	SnapshotID  int64             `json:"snapshot_id"`
+	CollectedAt time.Time         `json:"collected_at"`
+
+	CPUUsagePct       float64 `json:"cpu_usage_pct"`
+	LoadAvg1          float64 `json:"load_avg_1"`
+	RAMUsagePct       float64 `json:"ram_usage_pct"`
+	RAMAvailableBytes uint64  `json:"ram_available_bytes"`
+	SwapUsagePct      float64 `json:"swap_usage_pct"`
+	DiskUsagePct      float64 `json:"disk_usage_pct"`
+	InodeUsagePct     float64 `json:"inode_usage_pct"`
+	NetLatencyMS      float64 `json:"net_latency_ms"`
+	IsConnected       bool    `json:"is_connected"`
+	DockerAvailable   bool    `json:"docker_available"`
+
+	DiskReadBps  float64 `json:"disk_read_bps"`
+	DiskWriteBps float64 `json:"disk_write_bps"`
+	NetTxBps     float64 `json:"net_tx_bps"`
+	NetRxBps     float64 `json:"net_rx_bps"`
+
+	SeverityLevel int      `json:"severity_level"`
+	RiskScore     int      `json:"risk_score"`
+	Flags         []string `json:"flags"`
+	Explanation   string   `json:"explanation,omitempty"`
+
+	// Dependencies is the state of the agent's database and graph
+	// connections when the snapshot was published.
+	Dependencies []health.Status `json:"dependencies,omitempty"`
+}
+
+// FlagTransition reports a flag turning on or off for a host.
+type FlagTransition struct {
+	AgentID    string    `json:"agent_id"`
+	SnapshotID int64     `json:"snapshot_id"`
+	At         time.Time `json:"at"`
+	Flag       string    `json:"flag"`
+	Active     bool      `json:"active"`
+}
+
+// Event is one message on the stream.
+type Event struct {
+	Name string
+	Data any
+}
+
+// Hub fans persisted snapshots out to subscribers. It implements
+// relational.SnapshotPublisher.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	states map[string]CurrentState // latest per agent, replayed on subscribe
+	deps   func() []health.Status
+}
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// WithDependencies adds the statuses returned by deps, e.g. a
+// health.Monitor's Statuses, to every published current state.
+func WithDependencies(deps func() []health.Status) Option {
+	return func(h *Hub) { h.deps = deps }
+}
+
+func NewHub(opts ...Option) *Hub {
+	h := &Hub{
+		subs:   make(map[chan Event]struct{}),
+		states: make(map[string]CurrentState),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Publish emits the host's new current state, preceded by a transition for
+// each flag that changed since its previous snapshot. The first snapshot
+// of a host reports its active flags as transitions.
+func (h *Hub) Publish(res relational.InsertResult, s *relational.RawStatsFixed, d *relational.DerivedRates, f *relational.SnapshotFlags) {
+	state := CurrentState{
+		AgentID:           s.AgentID,
+		HostID:            res.HostID,
+		Labels:            s.Labels,
+		SnapshotID:        res.SnapshotID,
+		CollectedAt:       s.CollectedAt,
+		CPUUsagePct:       s.CPUUsagePct,
+		LoadAvg1:          s.LoadAvg1,
+		RAMUsagePct:       s.RAMUsagePct,
+		RAMAvailableBytes: s.RAMAvailableBytes,
+		SwapUsagePct:      s.SwapUsagePct,
+		DiskUsagePct:      s.DiskUsagePct,
+		InodeUsagePct:     s.InodeUsagePct,
+		NetLatencyMS:      s.NetLatencyMS,
+		IsConnected:       s.IsConnected,
+		DockerAvailable:   s.DockerAvailable,
+		SeverityLevel:     f.SeverityLevel,
+		RiskScore:         f.RiskScore,
+		Flags:             f.ActiveFlags(),
+		Explanation:       f.Explanation,
+	}
+	if h.deps != nil {
+		state.Dependencies = h.deps()
+	}
+	if d != nil {
+		state.DiskReadBps, state.DiskWriteBps = d.DiskReadBps, d.DiskWriteBps
+		state.NetTxBps, state.NetRxBps = d.NetTxBps, d.NetRxBps
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	prev := h.states[s.AgentID].Flags
+	for _, name := range relational.FlagNames {
+		was, is := slices.Contains(prev, name), slices.Contains(state.Flags, name)
+		if was != is {
+			h.broadcast(Event{EventFlagTransition, FlagTransition{
+				AgentID: s.AgentID, SnapshotID: res.SnapshotID, At: s.CollectedAt, Flag: name, Active: is,
+			}})
+		}
+	}
+	h.states[s.AgentID] = state
+	h.broadcast(Event{EventCurrentState, state})
+}
+
+// broadcast delivers ev without blocking; the caller holds mu.
+func (h *Hub) broadcast(ev Event) {
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that first receives the latest state of every
+// known host and then each new event. cancel releases it.
+func (h *Hub) Subscribe() (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, st := range h.states {
+		select {
+		case ch <- Event{EventCurrentState, st}:
+		default:
+		}
+	}
+	h.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, ch)
+	}
+}
+
+// Handler serves the hub as text/event-stream.
+func Handler(h *Hub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		events, cancel := h.Subscribe()
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case ev := <-events:
+				data, err := json.Marshal(ev.Data)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
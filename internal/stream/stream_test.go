@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+)
+
+func publish(h *Hub, id int64, f relational.SnapshotFlags) {
+	s := &relational.RawStatsFixed{AgentID: "a1", CollectedAt: time.Unix(id, 0)}
+	h.Publish(relational.InsertResult{SnapshotID: id, HostID: 1}, s, &relational.DerivedRates{}, &f)
+}
+
+func TestHubEmitsTransitions(t *testing.T) {
+	h := NewHub()
+	events, cancel := h.Subscribe()
+	defer cancel()
+
+	publish(h, 1, relational.SnapshotFlags{FlagCPUOverloaded: true})
+	publish(h, 2, relational.SnapshotFlags{FlagCPUOverloaded: true})
+	publish(h, 3, relational.SnapshotFlags{FlagMemoryPressure: true})
+
+	var got []string
+	for len(events) > 0 {
+		ev := <-events
+		if tr, ok := ev.Data.(FlagTransition); ok {
+			got = append(got, tr.Flag+"="+map[bool]string{true: "on", false: "off"}[tr.Active])
+		} else {
+			got = append(got, ev.Name)
+		}
+	}
+	want := "cpu_overloaded=on current_state current_state cpu_overloaded=off memory_pressure=on current_state"
+	if strings.Join(got, " ") != want {
+		t.Errorf("events = %v, want %s", got, want)
+	}
+}
+
+func TestHandlerStreamsEvents(t *testing.T) {
+	h := NewHub()
+	publish(h, 1, relational.SnapshotFlags{SeverityLevel: 2})
+
+	srv := httptest.NewServer(Handler(h))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	// The latest state is replayed on connect.
+	r := bufio.NewReader(resp.Body)
+	line, _ := r.ReadString('\n')
+	if line != "event: current_state\n" {
+		t.Fatalf("first line = %q", line)
+	}
+	line, _ = r.ReadString('\n')
+	if !strings.HasPrefix(line, "data: {") || !strings.Contains(line, `"severity_level":2`) {
+		t.Errorf("data = %q", line)
+	}
+}
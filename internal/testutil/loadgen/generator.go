@@ -0,0 +1,190 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"syschecker/internal/collector"
+)
+
+// Scenario describes a synthetic host. Nil patterns fall back to a quiet, healthy host.
+type Scenario struct {
+	Hostname string
+	Start    time.Time     // timestamp of step 0 (default: Monday 2025-01-06 00:00 UTC)
+	Interval time.Duration // time between steps (default 20s)
+
+	CPU, RAM, Swap, Disk, Inode Pattern // percent
+	Latency                     Pattern // milliseconds
+
+	DiskReadBps, DiskWriteBps Pattern // bytes per second on "sda"
+	NetTxBps, NetRxBps        Pattern // bytes per second on "eth0"
+
+	Processes int // number of top processes reported (default 5)
+}
+
+func (sc *Scenario) applyDefaults() {
+	if sc.Hostname == "" {
+		sc.Hostname = "loadgen-host"
+	}
+	if sc.Start.IsZero() {
+		sc.Start = time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	}
+	if sc.Interval <= 0 {
+		sc.Interval = 20 * time.Second
+	}
+	defaults := []struct {
+		p *Pattern
+		v float64
+	}{
+		{&sc.CPU, 15}, {&sc.RAM, 40}, {&sc.Swap, 0}, {&sc.Disk, 50}, {&sc.Inode, 10}, {&sc.Latency, 20},
+		{&sc.DiskReadBps, 0}, {&sc.DiskWriteBps, 0}, {&sc.NetTxBps, 0}, {&sc.NetRxBps, 0},
+	}
+	for _, d := range defaults {
+		if *d.p == nil {
+			*d.p = Constant(d.v)
+		}
+	}
+	if sc.Processes <= 0 {
+		sc.Processes = 5
+	}
+}
+
+// Generator produces a synthetic RawStats stream. It implements relational.StatsCollector:
+// each GetFastMetrics call advances one step, and GetSlowMetrics reports the same step.
+type Generator struct {
+	mu   sync.Mutex
+	sc   Scenario
+	step int // step most recently returned by GetFastMetrics, -1 before the first call
+
+	diskRead, diskWrite uint64
+	netTx, netRx        uint64
+}
+
+// NewGenerator creates a generator for the scenario.
+func NewGenerator(sc Scenario) *Generator {
+	sc.applyDefaults()
+	return &Generator{sc: sc, step: -1}
+}
+
+// Step returns the current step index (-1 before the first sample).
+func (g *Generator) Step() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.step
+}
+
+// Now returns the synthetic timestamp of the current step.
+func (g *Generator) Now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.timeAt(max(g.step, 0))
+}
+
+func (g *Generator) timeAt(step int) time.Time {
+	return g.sc.Start.Add(time.Duration(step) * g.sc.Interval)
+}
+
+// Hostname returns the scenario hostname, used as the agent ID.
+func (g *Generator) Hostname() string {
+	return g.sc.Hostname
+}
+
+// GetFastMetrics advances the stream by one step and returns its fast metrics.
+func (g *Generator) GetFastMetrics(ctx context.Context) (*collector.RawStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.step++
+	step := g.step
+	sc := &g.sc
+
+	// Counters grow by rate * interval; the first step only establishes a baseline.
+	if step > 0 {
+		secs := sc.Interval.Seconds()
+		g.diskRead += uint64(max(sc.DiskReadBps(step), 0) * secs)
+		g.diskWrite += uint64(max(sc.DiskWriteBps(step), 0) * secs)
+		g.netTx += uint64(max(sc.NetTxBps(step), 0) * secs)
+		g.netRx += uint64(max(sc.NetRxBps(step), 0) * secs)
+	}
+
+	cpu := clampPct(sc.CPU(step))
+	const totalRAM = 16 << 30
+	ram := clampPct(sc.RAM(step))
+
+	stats := &collector.RawStats{
+		CPUUsage:          cpu,
+		CPUPerCore:        []float64{cpu, cpu, cpu, cpu},
+		LoadAvg1:          cpu / 25,
+		LoadAvg5:          cpu / 25,
+		LoadAvg15:         cpu / 25,
+		CPUModel:          "loadgen virtual cpu",
+		CPUCores:          4,
+		RAMUsage:          ram,
+		RAMUsedBytes:      uint64(totalRAM * ram / 100),
+		RAMAvailableBytes: uint64(totalRAM * (100 - ram) / 100),
+		RAMTotalBytes:     totalRAM,
+		SwapUsage:         clampPct(sc.Swap(step)),
+		SwapTotalBytes:    4 << 30,
+		DiskUsage:         clampPct(sc.Disk(step)),
+		DiskTotalBytes:    512 << 30,
+		InodeUsage:        clampPct(sc.Inode(step)),
+		TotalInodes:       32_000_000,
+		Partitions: []collector.PartitionUsage{{
+			Mountpoint: "/", Device: "/dev/sda1", Fstype: "ext4",
+			UsedPercent: clampPct(sc.Disk(step)), TotalBytes: 512 << 30,
+			InodeUsage: clampPct(sc.Inode(step)), TotalInodes: 32_000_000,
+		}},
+		IOCounters: []collector.DiskIOCounters{{
+			Device: "sda", ReadBytes: g.diskRead, WriteBytes: g.diskWrite,
+			ReadCount: g.diskRead / 4096, WriteCount: g.diskWrite / 4096,
+		}},
+		NetInterfaces: []collector.NetInterfaceStats{{
+			Name: "eth0", BytesSent: g.netTx, BytesRecv: g.netRx,
+			PacketsSent: g.netTx / 1500, PacketsRecv: g.netRx / 1500,
+		}},
+		DockerAvailable: true,
+	}
+
+	// The first process takes the bulk of the CPU; the rest share what is left.
+	for i := 0; i < sc.Processes; i++ {
+		share := cpu * 0.6
+		if i > 0 {
+			share = cpu * 0.4 / float64(sc.Processes-1)
+		}
+		stats.TopProcesses = append(stats.TopProcesses, collector.ProcessStat{
+			PID:    int32(1000 + i),
+			Name:   fmt.Sprintf("proc-%d", i),
+			CPU:    share,
+			Memory: float32(ram / float64(sc.Processes)),
+		})
+	}
+
+	return stats, nil
+}
+
+// GetSlowMetrics returns slow metrics for the current step.
+func (g *Generator) GetSlowMetrics(ctx context.Context) (*collector.RawStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	step := max(g.step, 0)
+	return &collector.RawStats{
+		NetLatency_ms: max(g.sc.Latency(step), 0),
+		IsConnected:   true,
+		ActiveTCP:     50,
+		Hostname:      g.sc.Hostname,
+		OS:            "linux",
+		Platform:      "loadgen",
+		KernelVersion: "6.0.0-loadgen",
+		Uptime:        uint64(g.timeAt(step).Sub(g.sc.Start).Seconds()) + 3600,
+		Procs:         200,
+	}, nil
+}
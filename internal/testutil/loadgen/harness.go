@@ -0,0 +1,124 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"syschecker/internal/clock"
+	"syschecker/internal/database/graph"
+	"syschecker/internal/database/relational"
+	"syschecker/internal/flagger"
+	"syschecker/internal/output"
+)
+
+// MockGraph is an in-memory graph.GraphClient that records ingested payloads.
+type MockGraph struct {
+	mu       sync.Mutex
+	payloads []*output.PipelinePayload
+}
+
+func (m *MockGraph) Close(ctx context.Context) error { return nil }
+
+func (m *MockGraph) Reset(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payloads = nil
+	return nil
+}
+
+func (m *MockGraph) IngestSnapshot(ctx context.Context, payload *output.PipelinePayload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payloads = append(m.payloads, payload)
+	return nil
+}
+
+func (m *MockGraph) ExecuteCypher(ctx context.Context, query string) ([]map[string]any, error) {
+	return nil, nil
+}
+
+func (m *MockGraph) RunCypher(ctx context.Context, query string, opts graph.CypherOptions) ([]map[string]any, error) {
+	return nil, nil
+}
+
+// Ingested returns the payloads received so far.
+func (m *MockGraph) Ingested() []*output.PipelinePayload {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*output.PipelinePayload(nil), m.payloads...)
+}
+
+// Harness wires a Generator to a flagger, an in-memory DuckDB repo, and a MockGraph.
+// The fake Clock advances one scenario interval per step.
+type Harness struct {
+	Gen     *Generator
+	Clock   *clock.Fake
+	Repo    *relational.Repo
+	Flagger *flagger.FlaggerService
+	Graph   *MockGraph
+
+	client *relational.DuckDBClient
+}
+
+// NewHarness creates a harness with a migrated in-memory database.
+func NewHarness(sc Scenario, cfg flagger.Config) (*Harness, error) {
+	client, err := relational.NewDuckDBClient("")
+	if err != nil {
+		return nil, fmt.Errorf("create duckdb: %w", err)
+	}
+	gen := NewGenerator(sc)
+	clk := clock.NewFake(gen.sc.Start)
+	repo := relational.NewRepo(client.DB(),
+		relational.WithClock(clk),
+		relational.WithIDGenerator(relational.NewSnowflake(1, clk)),
+	)
+	if err := repo.Migrate(context.Background()); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return &Harness{
+		Gen:     gen,
+		Clock:   clk,
+		Repo:    repo,
+		Flagger: flagger.NewFlaggerService(cfg),
+		Graph:   &MockGraph{},
+		client:  client,
+	}, nil
+}
+
+// Close releases the database.
+func (h *Harness) Close() error {
+	return h.client.Close()
+}
+
+// Step runs one pipeline cycle at the current fake time, persists it, and
+// advances the clock by one interval.
+func (h *Harness) Step(ctx context.Context) (*output.PipelinePayload, error) {
+	payload, err := output.RunPipeline(ctx, h.Gen, h.Flagger, h.Repo, h.Gen.Hostname(), "", "",
+		output.WithClock(h.Clock))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.Repo.InsertRawStats(ctx, payload.Raw, payload.Derived, payload.Flags); err != nil {
+		return nil, fmt.Errorf("persist step %d: %w", h.Gen.Step(), err)
+	}
+	if err := h.Graph.IngestSnapshot(ctx, payload); err != nil {
+		return nil, fmt.Errorf("graph ingest: %w", err)
+	}
+	h.Clock.Advance(h.Gen.sc.Interval)
+	return payload, nil
+}
+
+// Run executes n steps and returns their payloads.
+func (h *Harness) Run(ctx context.Context, n int) ([]*output.PipelinePayload, error) {
+	out := make([]*output.PipelinePayload, 0, n)
+	for i := 0; i < n; i++ {
+		p, err := h.Step(ctx)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package loadgen
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"syschecker/internal/flagger"
+)
+
+func newHarness(t *testing.T, sc Scenario) *Harness {
+	t.Helper()
+	h, err := NewHarness(sc, flagger.DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewHarness: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestSpikesAreFlaggedExactly(t *testing.T) {
+	ctx := context.Background()
+	h := newHarness(t, Scenario{CPU: Spike(20, 97, 10, 2)})
+
+	payloads, err := h.Run(ctx, 40)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	flagged := 0
+	for i, p := range payloads {
+		spiking := i%10 < 2
+		if p.Flags.FlagCPUOverloaded != spiking {
+			t.Errorf("step %d: FlagCPUOverloaded=%v, want %v", i, p.Flags.FlagCPUOverloaded, spiking)
+		}
+		if p.Flags.FlagCPUOverloaded {
+			flagged++
+		}
+	}
+	if flagged != 8 {
+		t.Errorf("expected 8 flagged steps, got %d", flagged)
+	}
+
+	snaps, err := h.Repo.QuerySnapshots(ctx, "", 100)
+	if err != nil {
+		t.Fatalf("QuerySnapshots: %v", err)
+	}
+	if len(snaps) != 40 {
+		t.Errorf("expected 40 persisted snapshots, got %d", len(snaps))
+	}
+	if got := len(h.Graph.Ingested()); got != 40 {
+		t.Errorf("expected 40 graph ingests, got %d", got)
+	}
+}
+
+func TestFlappingMemory(t *testing.T) {
+	h := newHarness(t, Scenario{RAM: Flap(50, 95, 1)})
+	payloads, err := h.Run(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	for i, p := range payloads {
+		if want := i%2 == 1; p.Flags.FlagMemoryPressure != want {
+			t.Errorf("step %d: FlagMemoryPressure=%v, want %v", i, p.Flags.FlagMemoryPressure, want)
+		}
+	}
+}
+
+func TestDerivedRatesMatchScenario(t *testing.T) {
+	const writeBps = 1 << 20
+	h := newHarness(t, Scenario{DiskWriteBps: Constant(writeBps), NetRxBps: Ramp(0, 1000, 5)})
+	payloads, err := h.Run(context.Background(), 6)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if payloads[0].Derived.DiskWriteBps != 0 {
+		t.Errorf("first step should have no rate, got %v", payloads[0].Derived.DiskWriteBps)
+	}
+	for i, p := range payloads[1:] {
+		if math.Abs(p.Derived.DiskWriteBps-writeBps) > 1 {
+			t.Errorf("step %d: DiskWriteBps=%v, want %v", i+1, p.Derived.DiskWriteBps, writeBps)
+		}
+	}
+	if got := payloads[5].Derived.NetRxBps; math.Abs(got-1000) > 1 {
+		t.Errorf("ramp end NetRxBps=%v, want 1000", got)
+	}
+}
+
+func TestJitterIsDeterministic(t *testing.T) {
+	a := Jitter(Ramp(10, 80, 100), 5, 42)
+	b := Jitter(Ramp(10, 80, 100), 5, 42)
+	for step := 0; step < 100; step++ {
+		if a(step) != b(step) {
+			t.Fatalf("step %d: %v != %v", step, a(step), b(step))
+		}
+		if d := math.Abs(a(step) - Ramp(10, 80, 100)(step)); d > 5 {
+			t.Fatalf("step %d: jitter %v exceeds amplitude", step, d)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package loadgen synthesizes realistic metric streams and drives them through the
+// pipeline into an in-memory DuckDB and a mock graph for deterministic tests.
+package loadgen
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Pattern returns a metric value for a given step of a scenario.
+type Pattern func(step int) float64
+
+// Constant always returns v.
+func Constant(v float64) Pattern {
+	return func(int) float64 { return v }
+}
+
+// Ramp moves linearly from `from` to `to` over steps, then holds at `to`.
+func Ramp(from, to float64, steps int) Pattern {
+	return func(step int) float64 {
+		if steps <= 0 || step >= steps {
+			return to
+		}
+		return from + (to-from)*float64(step)/float64(steps)
+	}
+}
+
+// Spike returns peak for `width` steps out of every `every`, and base otherwise.
+func Spike(base, peak float64, every, width int) Pattern {
+	return func(step int) float64 {
+		if every > 0 && step%every < width {
+			return peak
+		}
+		return base
+	}
+}
+
+// Flap alternates between low and high every period steps.
+func Flap(low, high float64, period int) Pattern {
+	return func(step int) float64 {
+		if period > 0 && (step/period)%2 == 1 {
+			return high
+		}
+		return low
+	}
+}
+
+// Sine oscillates around mid with the given amplitude and period in steps.
+func Sine(mid, amplitude float64, period int) Pattern {
+	return func(step int) float64 {
+		if period <= 0 {
+			return mid
+		}
+		return mid + amplitude*math.Sin(2*math.Pi*float64(step)/float64(period))
+	}
+}
+
+// Jitter adds uniform noise in [-amp, amp] to p. The noise depends only on seed
+// and step, so repeated runs produce identical streams.
+func Jitter(p Pattern, amp float64, seed int64) Pattern {
+	return func(step int) float64 {
+		r := rand.New(rand.NewSource(seed*1_000_003 + int64(step)))
+		return p(step) + (r.Float64()*2-1)*amp
+	}
+}
+
+func clampPct(v float64) float64 {
+	return math.Min(math.Max(v, 0), 100)
+}
@@ -0,0 +1,42 @@
+// Package timefmt formats timestamps for display. Timestamps are stored and
+// exchanged in UTC; only what people read is shown in a chosen zone.
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layout is the display layout. It names the zone so readers comparing
+// hosts can tell which one a time is in.
+const Layout = "2006-01-02 15:04:05 MST"
+
+// ParseZone resolves a display zone: "utc" (or ""), "local" for the system
+// zone, or an IANA name such as "Europe/Berlin".
+func ParseZone(name string) (*time.Location, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q (want utc, local or an IANA name such as Europe/Berlin)", name)
+	}
+	return loc, nil
+}
+
+// In returns t in loc, or in UTC when loc is nil.
+func In(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc)
+}
+
+// Format formats t in loc with Layout.
+func Format(t time.Time, loc *time.Location) string {
+	return In(t, loc).Format(Layout)
+}
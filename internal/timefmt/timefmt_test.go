@@ -0,0 +1,31 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseZone(t *testing.T) {
+	for name, want := range map[string]*time.Location{"": time.UTC, "UTC": time.UTC, "local": time.Local} {
+		if loc, err := ParseZone(name); err != nil || loc != want {
+			t.Errorf("ParseZone(%q) = %v, %v", name, loc, err)
+		}
+	}
+	if _, err := ParseZone("Mars/Olympus"); err == nil {
+		t.Error("Expected error for an unknown zone")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	if got := Format(at, nil); got != "2026-03-01 12:30:00 UTC" {
+		t.Errorf("Format(UTC) = %q", got)
+	}
+	berlin, err := ParseZone("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	if got := Format(at, berlin); got != "2026-03-01 13:30:00 CET" {
+		t.Errorf("Format(Berlin) = %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Package units formats byte counts and rates for display. Collectors keep
+// raw bytes; scaling to KB/MB/GB happens only here, at the edge.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// System selects the unit prefixes used when scaling.
+type System int
+
+const (
+	// Binary scales by 1024 and labels KiB, MiB, GiB... It is the default.
+	Binary System = iota
+	// SI scales by 1000 and labels kB, MB, GB...
+	SI
+)
+
+// String returns the name accepted by ParseSystem.
+func (s System) String() string {
+	if s == SI {
+		return "si"
+	}
+	return "binary"
+}
+
+// ParseSystem parses "binary" (or "iec") and "si" (or "decimal").
+func ParseSystem(name string) (System, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "binary", "iec":
+		return Binary, nil
+	case "si", "decimal":
+		return SI, nil
+	}
+	return Binary, fmt.Errorf("unknown unit system %q (want binary or si)", name)
+}
+
+// Bytes renders n scaled to the largest unit that keeps it at or above one,
+// e.g. "512 B", "1.5 KiB" or "3.2 GB".
+func (s System) Bytes(n uint64) string {
+	base, prefixes, suffix := uint64(1024), "KMGTPE", "iB"
+	if s == SI {
+		base, prefixes, suffix = 1000, "kMGTPE", "B"
+	}
+	if n < base {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := base, 0
+	for m := n / base; m >= base; m /= base {
+		div *= base
+		exp++
+	}
+	return fmt.Sprintf("%.1f %c%s", float64(n)/float64(div), prefixes[exp], suffix)
+}
+
+// Rate renders a bytes-per-second rate, e.g. "4.0 MiB/s". Negative rates
+// keep their sign.
+func (s System) Rate(bps float64) string {
+	if bps < 0 {
+		return "-" + s.Bytes(uint64(-bps)) + "/s"
+	}
+	return s.Bytes(uint64(bps)) + "/s"
+}
+
+// ParseBytes parses a size such as "512", "2GiB", "1.5 GB" or "500M".
+// Binary suffixes (KiB, MiB...) and bare letters (K, M...) scale by 1024,
+// SI suffixes (kB, MB...) by 1000.
+func ParseBytes(s string) (int64, error) {
+	in := strings.TrimSpace(s)
+	i := strings.IndexFunc(in, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	num, unit := in, ""
+	if i >= 0 {
+		num, unit = in[:i], strings.TrimSpace(in[i:])
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	mult := 1.0
+	if unit != "" && !strings.EqualFold(unit, "B") {
+		exp := strings.IndexByte("KMGTPE", byte(strings.ToUpper(unit)[0]))
+		rest := strings.ToLower(unit[1:])
+		if exp < 0 || (rest != "" && rest != "ib" && rest != "b") {
+			return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+		}
+		base := 1024.0
+		if rest == "b" {
+			base = 1000
+		}
+		mult = math.Pow(base, float64(exp+1))
+	}
+	if v*mult > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(v * mult), nil
+}
+
+// Bytes renders n with binary units.
+func Bytes(n uint64) string { return Binary.Bytes(n) }
+
+// GiB converts a byte count to fractional gibibytes, for charts and
+// arithmetic that want a plain number.
+func GiB(n uint64) float64 { return float64(n) / (1 << 30) }
@@ -0,0 +1,62 @@
+package units
+
+import "testing"
+
+func TestBytes(t *testing.T) {
+	cases := []struct {
+		sys  System
+		in   uint64
+		want string
+	}{
+		{Binary, 0, "0 B"},
+		{Binary, 1023, "1023 B"},
+		{Binary, 1536, "1.5 KiB"},
+		{Binary, 50 << 20, "50.0 MiB"},
+		{Binary, 3 << 30, "3.0 GiB"},
+		{Binary, 5 << 40, "5.0 TiB"},
+		{SI, 999, "999 B"},
+		{SI, 1500, "1.5 kB"},
+		{SI, 3_200_000_000, "3.2 GB"},
+		{SI, 3 << 30, "3.2 GB"},
+	}
+	for _, c := range cases {
+		if got := c.sys.Bytes(c.in); got != c.want {
+			t.Errorf("%s.Bytes(%d) = %q, want %q", c.sys, c.in, got, c.want)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	if got := Binary.Rate(4 << 20); got != "4.0 MiB/s" {
+		t.Errorf("Rate = %q", got)
+	}
+	if got := SI.Rate(-2000); got != "-2.0 kB/s" {
+		t.Errorf("Rate = %q", got)
+	}
+}
+
+func TestParseSystem(t *testing.T) {
+	for in, want := range map[string]System{"": Binary, "IEC": Binary, "si": SI, " decimal ": SI} {
+		got, err := ParseSystem(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSystem(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseSystem("metric"); err == nil {
+		t.Error("ParseSystem(metric) should fail")
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	for in, want := range map[string]int64{"512": 512, "2GiB": 2 << 30, "1.5 GB": 1_500_000_000, "500M": 500 << 20, "10kB": 10_000, "7B": 7} {
+		got, err := ParseBytes(in)
+		if err != nil || got != want {
+			t.Errorf("ParseBytes(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "GB", "-1G", "3 parsecs", "2Gx"} {
+		if _, err := ParseBytes(in); err == nil {
+			t.Errorf("ParseBytes(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package update replaces the running binary with the latest GitHub
+// release, for agents installed outside a package manager.
+//
+// A release carries one binary per platform named by AssetName, a
+// checksums.txt listing their SHA-256 sums as sha256sum prints them, and
+// optionally checksums.txt.sig, the base64 Ed25519 signature of
+// checksums.txt. The binary is only swapped in when its sum matches and,
+// with a public key configured, the signature verifies.
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release asset names besides the binaries.
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// DefaultRepo is the GitHub repository releases are fetched from.
+const DefaultRepo = "RafiulPaceProjects/go_syschecker"
+
+// PublicKey is the base64 Ed25519 key release checksums are signed with,
+// set at build time with -ldflags "-X syschecker/internal/update.PublicKey=...".
+var PublicKey string
+
+// maxBinaryBytes bounds a downloaded asset.
+const maxBinaryBytes = 512 << 20
+
+// ErrUnsigned is returned by Apply when a public key is configured but the
+// release has no signature.
+var ErrUnsigned = errors.New("release checksums are not signed")
+
+// Config describes where releases come from and how they are verified.
+type Config struct {
+	Repo      string            // owner/name on GitHub
+	APIURL    string            // default https://api.github.com
+	PublicKey ed25519.PublicKey // verify checksums.txt.sig; nil verifies checksums only
+	Client    *http.Client
+}
+
+// DefaultConfig fetches from DefaultRepo, verifying with PublicKey when it
+// is set.
+func DefaultConfig() (Config, error) {
+	c := Config{Repo: DefaultRepo, APIURL: "https://api.github.com", Client: &http.Client{Timeout: 5 * time.Minute}}
+	if PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return c, fmt.Errorf("invalid release public key %q", PublicKey)
+		}
+		c.PublicKey = key
+	}
+	return c, nil
+}
+
+// Release is a published release and the download URLs of its assets.
+type Release struct {
+	Tag    string
+	Assets map[string]string // name -> download URL
+}
+
+// AssetName is the release asset holding the binary for goos and goarch,
+// e.g. syschecker_linux_amd64 or syschecker_windows_amd64.exe.
+func AssetName(goos, goarch string) string {
+	name := "syschecker_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the newest non-draft, non-prerelease release.
+func Latest(ctx context.Context, c Config) (Release, error) {
+	url := strings.TrimSuffix(c.APIURL, "/") + "/repos/" + c.Repo + "/releases/latest"
+	body, err := get(ctx, c, url, 1<<20)
+	if err != nil {
+		return Release{}, err
+	}
+	var resp struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Release{}, fmt.Errorf("decode release: %w", err)
+	}
+	r := Release{Tag: resp.TagName, Assets: make(map[string]string, len(resp.Assets))}
+	for _, a := range resp.Assets {
+		r.Assets[a.Name] = a.URL
+	}
+	return r, nil
+}
+
+// Apply downloads the binary of r for this platform, verifies it and
+// atomically replaces exe with it. exe is left untouched on any error.
+func Apply(ctx context.Context, c Config, r Release, exe string) error {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binURL, ok := r.Assets[name]
+	if !ok {
+		return fmt.Errorf("release %s has no %s asset", r.Tag, name)
+	}
+	sumsURL, ok := r.Assets[ChecksumsAsset]
+	if !ok {
+		return fmt.Errorf("release %s has no %s asset", r.Tag, ChecksumsAsset)
+	}
+	sums, err := get(ctx, c, sumsURL, 1<<20)
+	if err != nil {
+		return err
+	}
+	if c.PublicKey != nil {
+		sigURL, ok := r.Assets[SignatureAsset]
+		if !ok {
+			return fmt.Errorf("release %s: %w", r.Tag, ErrUnsigned)
+		}
+		sig, err := get(ctx, c, sigURL, 4<<10)
+		if err != nil {
+			return err
+		}
+		if err := verifySignature(c.PublicKey, sums, sig); err != nil {
+			return fmt.Errorf("release %s: %w", r.Tag, err)
+		}
+	}
+	want, err := checksum(sums, name)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", r.Tag, err)
+	}
+	bin, err := get(ctx, c, binURL, maxBinaryBytes)
+	if err != nil {
+		return err
+	}
+	if got := sha256.Sum256(bin); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("release %s: %s does not match its checksum", r.Tag, name)
+	}
+	return replace(exe, bin)
+}
+
+// verifySignature checks the base64 Ed25519 signature sig of sums.
+func verifySignature(key ed25519.PublicKey, sums, sig []byte) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("decode checksum signature: %w", err)
+	}
+	if !ed25519.Verify(key, sums, raw) {
+		return errors.New("checksum signature does not verify")
+	}
+	return nil
+}
+
+// checksum finds the hex SHA-256 of name in sha256sum output.
+func checksum(sums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// replace writes bin next to exe and renames it over exe, keeping exe's
+// mode. Windows cannot overwrite a running binary but can rename it, so
+// there the old one is moved to exe + ".old" first.
+func replace(exe string, bin []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".update-*")
+	if err != nil {
+		return fmt.Errorf("stage update: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("stage update: %w", err)
+	}
+	var old string
+	if runtime.GOOS == "windows" {
+		old = exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("move aside %s: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		if old != "" {
+			_ = os.Rename(old, exe)
+		}
+		return fmt.Errorf("replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// get fetches url, failing on non-2xx responses and bodies over limit.
+func get(ctx context.Context, c Config, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json, application/octet-stream")
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("fetch %s: larger than %d bytes", url, limit)
+	}
+	return body, nil
+}
+
+// Newer reports whether release tag latest is newer than current. Tags
+// are compared as vMAJOR.MINOR.PATCH; a pre-release sorts before its
+// release. A current version that does not parse, such as "dev", is
+// never considered older.
+func Newer(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range 3 {
+		if l.num[i] != c.num[i] {
+			return l.num[i] > c.num[i]
+		}
+	}
+	return c.pre != "" && (l.pre == "" || l.pre > c.pre)
+}
+
+type version struct {
+	num [3]int
+	pre string
+}
+
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.num[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,141 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeRelease serves a release of bin through the GitHub API paths.
+func fakeRelease(t *testing.T, bin []byte, sums string, sig []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	assets := fmt.Sprintf(`{"name": %q, "browser_download_url": %q}, {"name": %q, "browser_download_url": %q}`,
+		asset, srv.URL+"/dl/bin", ChecksumsAsset, srv.URL+"/dl/sums")
+	if sig != nil {
+		assets += fmt.Sprintf(`, {"name": %q, "browser_download_url": %q}`, SignatureAsset, srv.URL+"/dl/sig")
+	}
+	mux.HandleFunc("/repos/o/r/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name": "v1.3.0", "assets": [%s]}`, assets)
+	})
+	mux.HandleFunc("/dl/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(bin) })
+	mux.HandleFunc("/dl/sums", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(sums)) })
+	mux.HandleFunc("/dl/sig", func(w http.ResponseWriter, r *http.Request) { w.Write(sig) })
+	return srv
+}
+
+func sumsFor(bin []byte) string {
+	sum := sha256.Sum256(bin)
+	return fmt.Sprintf("%s  other_asset\n%s  %s\n", hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(sum[:]), AssetName(runtime.GOOS, runtime.GOARCH))
+}
+
+func writeExe(t *testing.T) string {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "syschecker")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func TestApplySigned(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := []byte("new binary")
+	sums := sumsFor(bin)
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums))))
+	srv := fakeRelease(t, bin, sums, sig)
+	c := Config{Repo: "o/r", APIURL: srv.URL, PublicKey: pub}
+
+	ctx := context.Background()
+	rel, err := Latest(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Tag != "v1.3.0" {
+		t.Errorf("tag %q", rel.Tag)
+	}
+	exe := writeExe(t)
+	if err := Apply(ctx, c, rel, exe); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(bin) {
+		t.Errorf("exe holds %q", got)
+	}
+	if info, _ := os.Stat(exe); runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+		t.Errorf("mode %v, want 0755", info.Mode().Perm())
+	}
+
+	// A different key rejects the same release.
+	other, _, _ := ed25519.GenerateKey(nil)
+	c.PublicKey = other
+	if err := Apply(ctx, c, rel, writeExe(t)); err == nil {
+		t.Error("signature of another key accepted")
+	}
+}
+
+func TestApplyRejectsBadChecksumAndUnsigned(t *testing.T) {
+	ctx := context.Background()
+	bin := []byte("new binary")
+	srv := fakeRelease(t, bin, sumsFor([]byte("something else")), nil)
+	c := Config{Repo: "o/r", APIURL: srv.URL}
+	rel, err := Latest(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe := writeExe(t)
+	if err := Apply(ctx, c, rel, exe); err == nil {
+		t.Error("mismatched checksum accepted")
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old" {
+		t.Errorf("exe replaced despite the error: %q", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("staged file left behind: %v", entries)
+	}
+
+	c.PublicKey, _, _ = ed25519.GenerateKey(nil)
+	if err := Apply(ctx, c, rel, exe); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("got %v, want ErrUnsigned", err)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	for _, tc := range []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"v1.2.3", "v1.10.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.3.0", "v1.2.9", false},
+		{"v1.3.0-rc1", "v1.3.0", true},
+		{"v1.3.0", "v1.3.0-rc1", false},
+		{"1.2.3+abc", "v1.2.4", true},
+		{"dev", "v9.9.9", false},
+		{"v1.2.3", "nightly", false},
+	} {
+		if got := Newer(tc.current, tc.latest); got != tc.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tc.current, tc.latest, got, tc.want)
+		}
+	}
+}
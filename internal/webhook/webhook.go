@@ -0,0 +1,219 @@
+// Package webhook posts persisted pipeline payloads to outbound HTTP
+// endpoints, signed with HMAC-SHA256 and retried with backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"syschecker/internal/output"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderSignature = "X-Syschecker-Signature" // "sha256=" + hex HMAC of the body
+	HeaderEvent     = "X-Syschecker-Event"
+	HeaderDelivery  = "X-Syschecker-Delivery"
+)
+
+// EnvSecret is consulted when no secret is configured.
+const EnvSecret = "SYSCHECKER_WEBHOOK_SECRET"
+
+// Config describes one webhook target.
+type Config struct {
+	URL          string
+	Secret       string        // signs the body; unsigned when empty
+	OnlyOnChange bool          // skip snapshots whose active flags did not change
+	MaxAttempts  int           // including the first; default 4
+	Backoff      time.Duration // before the first retry, doubled after each; default 1s
+	Timeout      time.Duration // per attempt; default 10s
+	QueueSize    int           // pending deliveries before new ones are dropped; default 32
+}
+
+// DefaultConfig returns a config for url with the default retry policy.
+func DefaultConfig(url string) Config {
+	return Config{
+		URL:         url,
+		Secret:      os.Getenv(EnvSecret),
+		MaxAttempts: 4,
+		Backoff:     time.Second,
+		Timeout:     10 * time.Second,
+		QueueSize:   32,
+	}
+}
+
+// Validate reports configuration errors.
+func (c Config) Validate() error {
+	if c.URL == "" {
+		return errors.New("webhook URL is required")
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("webhook max attempts must be at least 1, got %d", c.MaxAttempts)
+	}
+	if c.Backoff < 0 || c.Timeout <= 0 || c.QueueSize < 1 {
+		return errors.New("webhook backoff, timeout and queue size must be positive")
+	}
+	return nil
+}
+
+type delivery struct {
+	id    string
+	event string
+	body  []byte
+}
+
+// Sender delivers payloads to one endpoint from a background goroutine so
+// a slow receiver never holds up collection.
+type Sender struct {
+	cfg    Config
+	client *http.Client
+
+	mu    sync.Mutex
+	flags map[string]int64 // last bitmask per agent, for OnlyOnChange
+
+	queue  chan delivery
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSender validates cfg and starts the delivery loop. Close stops it.
+func NewSender(cfg Config) (*Sender, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Sender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		flags:  make(map[string]int64),
+		queue:  make(chan delivery, cfg.QueueSize),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s, nil
+}
+
+// Notify queues p for delivery. It never blocks; when the queue is full the
+// payload is dropped and an error returned.
+func (s *Sender) Notify(ctx context.Context, p *output.PipelinePayload) error {
+	event := "snapshot"
+	if s.changed(p) {
+		event = "flags_changed"
+	} else if s.cfg.OnlyOnChange {
+		return nil
+	}
+	body, err := output.EncodePayload(p)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+	d := delivery{
+		id:    fmt.Sprintf("%s-%d", p.Raw.AgentID, p.Raw.CollectedAt.UnixNano()),
+		event: event,
+		body:  body,
+	}
+	select {
+	case s.queue <- d:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue for %s is full, dropped delivery %s", s.cfg.URL, d.id)
+	}
+}
+
+// changed records the agent's active flags and reports whether they differ
+// from its previous snapshot.
+func (s *Sender) changed(p *output.PipelinePayload) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mask := p.Flags.Mask()
+	prev, seen := s.flags[p.Raw.AgentID]
+	s.flags[p.Raw.AgentID] = mask
+	return !seen && mask != 0 || seen && prev != mask
+}
+
+// Close stops the delivery loop, abandoning queued and in-flight deliveries.
+func (s *Sender) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Sender) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-s.queue:
+			if err := s.deliver(ctx, d); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Webhook delivery failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// deliver posts d, retrying network errors, 429s and 5xx responses with
+// exponential backoff.
+func (s *Sender) deliver(ctx context.Context, d delivery) error {
+	backoff := s.cfg.Backoff
+	var err error
+	for attempt := 1; attempt <= s.cfg.MaxAttempts; attempt++ {
+		var retry bool
+		if retry, err = s.post(ctx, d); err == nil || !retry {
+			return err
+		}
+		if attempt == s.cfg.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("%s after %d attempts: %w", s.cfg.URL, s.cfg.MaxAttempts, err)
+}
+
+func (s *Sender) post(ctx context.Context, d delivery) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return false, fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.event)
+	req.Header.Set(HeaderDelivery, d.id)
+	if s.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(s.cfg.Secret, d.body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("%s rejected delivery %s: %s", s.cfg.URL, d.id, resp.Status)
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 keyed with secret. Receivers should recompute it and
+// compare with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
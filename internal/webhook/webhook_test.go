@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"syschecker/internal/database/relational"
+	"syschecker/internal/output"
+)
+
+func payload(cpu, mem bool) *output.PipelinePayload {
+	return &output.PipelinePayload{
+		Raw:   relational.RawStatsFixed{AgentID: "a1", CollectedAt: time.Unix(100, 0)},
+		Flags: relational.SnapshotFlags{FlagCPUOverloaded: cpu, FlagMemoryPressure: mem},
+	}
+}
+
+func TestSenderSignsAndRetries(t *testing.T) {
+	var attempts atomic.Int32
+	got := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign("s3cret", body) {
+			t.Errorf("bad signature %q", r.Header.Get(HeaderSignature))
+		}
+		got <- r
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig(srv.URL)
+	cfg.Secret, cfg.Backoff = "s3cret", time.Millisecond
+	s, err := NewSender(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Notify(context.Background(), payload(true, false)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-got:
+		if r.Header.Get(HeaderEvent) != "flags_changed" {
+			t.Errorf("event = %q", r.Header.Get(HeaderEvent))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
+func TestSenderOnlyOnChange(t *testing.T) {
+	cfg := DefaultConfig("http://127.0.0.1:0")
+	cfg.OnlyOnChange = true
+	s := &Sender{cfg: cfg, flags: make(map[string]int64), queue: make(chan delivery, 8)}
+
+	for _, mem := range []bool{false, false, true, true, false} {
+		if err := s.Notify(context.Background(), payload(false, mem)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only the raise of memory_pressure and its clearing are delivered.
+	if len(s.queue) != 2 {
+		t.Errorf("queued %d deliveries, want 2", len(s.queue))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err == nil {
+		t.Error("expected an error for a missing URL")
+	}
+	if err := DefaultConfig("http://example.invalid").Validate(); err != nil {
+		t.Errorf("default config: %v", err)
+	}
+}
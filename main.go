@@ -2,42 +2,228 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"syschecker/internal/alert"
+	"syschecker/internal/buildinfo"
+	"syschecker/internal/cli"
 	"syschecker/internal/collector"
+	"syschecker/internal/config"
 	"syschecker/internal/database"
 	"syschecker/internal/database/relational"
+	"syschecker/internal/debugserver"
+	"syschecker/internal/doctor"
 	"syschecker/internal/flagger"
+	"syschecker/internal/fleet"
+	"syschecker/internal/health"
+	"syschecker/internal/i18n"
+	"syschecker/internal/schedule"
+	"syschecker/internal/selfstats"
+	"syschecker/internal/service"
+	"syschecker/internal/stream"
+	"syschecker/internal/timefmt"
+	"syschecker/internal/units"
+	"syschecker/internal/webhook"
 	"syschecker/ui/tui"
 	"time"
+	_ "time/tzdata" // -tz zone names on hosts without a zoneinfo database
 )
 
 func main() {
+	debugAddr := flag.String("debug-addr", "", "serve pprof and runtime stats on this address (or $"+debugserver.EnvAddr+")")
+	hostRoot := flag.String("host-root", os.Getenv(collector.EnvHostRoot), "collect from a host filesystem mounted at this path (or $"+collector.EnvHostRoot+")")
+	headless := flag.Bool("headless", false, "run the collector and data worker without the TUI until interrupted")
+	readOnly := flag.Bool("read-only", false, "view the snapshots in -db without collecting, e.g. a database copied from another machine or published at an s3:// or https:// URL")
+	viewHost := flag.String("host", "", "with -read-only, the hostname to view (default: the most recently seen host)")
+	probe := flag.Bool("probe", false, "probe the network every second for latency, jitter and loss")
+	processDetail := flag.Int("process-detail", 0, "capture the command line and cgroup of this many top processes")
+	checksFile := flag.String("checks", "", "JSON file of custom check commands run on the slow cycle; failures raise the check_failed flag")
+	var redact []string
+	flag.Func("redact", "extra regex scrubbed from captured command lines (repeatable)", func(s string) error {
+		redact = append(redact, s)
+		return nil
+	})
+	labels := map[string]string{}
+	flag.Func("label", "host label key=value for grouping and alert routing (repeatable)", func(s string) error {
+		k, v, err := relational.ParseLabel(s)
+		if err != nil {
+			return err
+		}
+		labels[k] = v
+		return nil
+	})
+	var webhooks []string
+	flag.Func("webhook", "POST each persisted snapshot to this URL, signed with $"+webhook.EnvSecret+" (repeatable)", func(s string) error {
+		webhooks = append(webhooks, s)
+		return nil
+	})
+	pagerDutyKey := flag.String("pagerduty-key", os.Getenv(alert.EnvPagerDutyKey), "open PagerDuty incidents with this Events API v2 routing key (or $"+alert.EnvPagerDutyKey+")")
+	opsgenieKey := flag.String("opsgenie-key", os.Getenv(alert.EnvOpsgenieKey), "open Opsgenie alerts with this API key (or $"+alert.EnvOpsgenieKey+")")
+	alertRoutes := flag.String("alert-routes", "", "JSON file routing alerts by flag, category, severity, host and time to PagerDuty, Opsgenie, Slack, webhook and email targets, with per-flag debounce and cooldown policies")
+	lang := flag.String("lang", i18n.FromEnv(), "language of flag explanations: "+strings.Join(i18n.Supported(), ", ")+" (or $"+i18n.EnvLang+")")
+	unitSystem := flag.String("units", units.Binary.String(), "byte units in explanations: binary (KiB, MiB) or si (kB, MB)")
+	webhookOnChange := flag.Bool("webhook-on-change", false, "only fire webhooks when a host's active flags change")
+	nodeID := flag.String("node-id", os.Getenv(relational.EnvNodeID), "snowflake node ID (0-1023) for primary keys; rarely needed, as processes sharing -db lease distinct ones and aggregated backups get IDs of the central database (default: leased from -db) (or $"+relational.EnvNodeID+")")
+	dbCompression := flag.String("db-compression", "", "force a DuckDB column compression method for new data: "+strings.Join(relational.CompressionMethods, ", ")+" (default: DuckDB chooses per column)")
+	dbMaxSize := flag.String("db-max-size", "", `storage budget for -db, e.g. "2GiB"; beyond it the oldest snapshots are deleted early, and near it the storage_budget flag is raised`)
+	dbJournal := flag.String("db-journal", "", `JSONL file buffering snapshots while -db rejects writes, replayed once it accepts them again (default: -db path + ".journal"; "off" disables)`)
+	changeOnly := flag.String("change-only", "", `skip storing snapshots that differ from the last stored one by less than epsilons, counting repeats instead: "default" or overrides such as "cpu=5,max_repeats=60"`)
+	tz := flag.String("tz", "", "time zone to display times in: UTC (default), Local or an IANA name such as Europe/Berlin; stored times are always UTC")
+	fleetAddr := flag.String("fleet-addr", os.Getenv(fleet.EnvAddr), "serve this agent's backups to a fleet aggregator on this address over mutual TLS, e.g. :"+fleet.DefaultPort+" (or $"+fleet.EnvAddr+")")
+	fleetTokenFile := flag.String("fleet-token-file", "", "file holding the token the aggregator must sign fleet API requests with, unique to this agent (or the token in $"+fleet.EnvToken+")")
+	var fleetTLS fleet.TLSFiles
+	fleetTLS.Register(flag.CommandLine)
+	var g cli.Globals
+	g.Register(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "tui" {
+		// "syschecker tui" takes the flags of plain "syschecker".
+		_ = flag.CommandLine.Parse(args[1:])
+		if args = flag.Args(); len(args) > 0 {
+			log.Fatalf("tui takes no arguments, got %q", args)
+		}
+	}
+	if err := cli.SetLogLevel(g.LogLevel); err != nil {
+		log.Fatal(err)
+	}
+	if len(args) > 0 {
+		ok, err := runCommand(g, args[0], args[1:])
+		if !ok {
+			log.Fatalf("unknown command %q; run syschecker -h for the list", args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	byteUnits, err := units.ParseSystem(*unitSystem)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	loc, err := timefmt.ParseZone(*tz)
+	if err != nil {
+		log.Fatalf("Invalid -tz: %v", err)
+	}
+	cfg := flagger.DefaultConfig().WithLocale(*lang).WithUnits(byteUnits).WithLocation(loc)
+
+	// Read-only guests only view stored data: no collectors, workers or API.
+	if *readOnly {
+		if *headless {
+			log.Fatal("-read-only needs the TUI and cannot be combined with -headless")
+		}
+		if err := runReadOnly(g.DB, *viewHost, cfg); err != nil {
+			fmt.Printf("Error running TUI: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if relational.IsRemote(g.DB) {
+		log.Fatalf("-db %s is a remote database, which can only be viewed with -read-only", g.DB)
+	}
+
+	// Collection profiles, switchable at runtime via POST /api/profile?name=...
+	scheduler := schedule.NewScheduler()
+
 	// 1. Initialize Collector
 	// Use the interface to allow for different collector implementations
-	var provider collector.StatsProvider = collector.NewSystemCollector()
+	collectorCfg := collector.DefaultCollectorConfig()
+	if *hostRoot != "" {
+		if err := collector.UseHostRoot(*hostRoot); err != nil {
+			log.Fatalf("Failed to use host root: %v", err)
+		}
+		collectorCfg = collectorCfg.WithHostRoot(*hostRoot)
+	}
+	collectorCfg = collectorCfg.WithProcessDetail(*processDetail, redact...).WithProber(*probe)
+	if *checksFile != "" {
+		checks, err := collector.LoadChecks(*checksFile)
+		if err != nil {
+			log.Fatalf("Failed to load checks: %v", err)
+		}
+		collectorCfg = collectorCfg.WithChecks(checks...)
+	}
+	if err := collectorCfg.Validate(); err != nil {
+		log.Fatalf("Invalid collector config: %v", err)
+	}
+	var provider collector.StatsProvider = collector.NewSystemCollectorWithConfig(collectorCfg)
 
 	// 2. Initialize Config
-	cfg := flagger.DefaultConfig()
+	if *hostRoot != "" {
+		cfg.DiskScan.Roots = []string{*hostRoot}
+	}
+
+	// Startup self-test: report prerequisites the agent would otherwise
+	// silently degrade around. "syschecker doctor" runs the full set.
+	for _, r := range doctor.Run(context.Background(), 2*time.Second,
+		doctor.DBWritable(g.DB), doctor.Clock(nil, nil), doctor.Smartctl(), doctor.DockerSocket()) {
+		if r.Status >= doctor.Warn {
+			log.Printf("Self-test %s: %s (fix: %s)", r.Name, r.Detail, r.Fix)
+		}
+	}
 
 	// 3. Initialize Database (DuckDB)
 	// Use a file-based DB for persistence, or ":memory:" for ephemeral
-	dbClient, err := relational.NewDuckDBClient("syschecker.db", relational.WithThreads(4))
+	dbClient, err := relational.NewDuckDBClient(g.DB, relational.WithThreads(4), relational.WithCompression(*dbCompression))
 	if err != nil {
 		log.Fatalf("Failed to initialize DuckDB: %v", err)
 	}
 	defer dbClient.Close()
 
-	// 4. Initialize Repository
-	repo := relational.NewRepo(dbClient.DB())
+	// 4. Initialize Repository, pruning early to stay within any budget
+	var repoOpts []relational.RepoOption
+	if *nodeID != "" {
+		node, err := relational.ParseNodeID(*nodeID)
+		if err != nil {
+			log.Fatalf("Invalid -node-id: %v", err)
+		}
+		repoOpts = append(repoOpts, relational.WithNodeID(node))
+	}
+	var selfOpts []selfstats.Option
+	if *dbMaxSize != "" {
+		maxBytes, err := units.ParseBytes(*dbMaxSize)
+		if err != nil || maxBytes <= 0 {
+			log.Fatalf("Invalid -db-max-size %q", *dbMaxSize)
+		}
+		repoOpts = append(repoOpts, relational.WithStorageBudget(relational.DefaultStorageBudget(maxBytes)))
+		selfOpts = append(selfOpts, selfstats.WithDBBudget(maxBytes))
+	}
+	repo := relational.NewRepo(dbClient.DB(), repoOpts...)
+
+	// The database connection is checked periodically and its status sent
+	// with every persisted snapshot streamed as Server-Sent Events.
+	deps := health.NewMonitor([]health.Dependency{{Name: "duckdb", Ping: repo.Ping}})
+	depsCtx, stopDeps := context.WithCancel(context.Background())
+	defer stopDeps()
+	go deps.Run(depsCtx)
+	hub := stream.NewHub(stream.WithDependencies(deps.Statuses))
 	// Ensure schema exists
 	if err := repo.Migrate(context.Background()); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// 5. Initialize Flagger
+	// 5. Initialize Flagger, layering the config file over the flags. Edits
+	// to the file are re-applied to the flagger and default profile live.
 	flaggerSvc := flagger.NewFlaggerService(cfg)
+	learner := flagger.NewBaselineLearner(cfg, repo, flaggerSvc)
+	if g.Config != "" {
+		file, err := config.Load(g.Config)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		target := config.Target{Flagger: flaggerSvc, Learner: learner, BaseConfig: cfg, Scheduler: scheduler, BaseProfile: schedule.Builtin()[0]}
+		target.Apply(file)
+		cfg = flaggerSvc.Config()
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go config.Watch(watchCtx, g.Config, 2*time.Second, target)
+	}
 
 	// 6. Get Host Info for Worker Identity
 	// We do a quick fetch to get stable IDs
@@ -68,22 +254,165 @@ func main() {
 	if slowStats != nil && slowStats.Hostname != "" {
 		agentID = slowStats.Hostname
 	}
+	if len(labels) > 0 {
+		if _, err := repo.SetHostLabels(ctx, agentID, labels, false); err != nil {
+			log.Fatalf("Failed to set host labels: %v", err)
+		}
+	}
 
-	// 8. Initialize Data Worker
-	worker, err := database.NewDataWorker(sysCol, flaggerSvc, repo, nil, agentID, machineID, bootID)
+	// 8. Initialize Data Worker, with any outbound webhooks and paging
+	escalator := flagger.NewEscalator(cfg.Escalation, repo)
+	opts := []database.DataWorkerOption{
+		database.WithEscalator(escalator),
+		database.WithBaselineLearner(learner),
+		database.WithSeasonalDetector(flagger.NewSeasonalDetector(cfg.Seasonal, repo)),
+		database.WithMemoryForecaster(flagger.NewMemoryForecaster(cfg.Forecast)),
+		database.WithDiskInvestigator(flagger.NewDiskScanner(cfg.DiskScan, nil)),
+		database.WithStorageGuard(flagger.NewStorageGuard(cfg.Storage, repo)),
+		database.WithScheduler(scheduler),
+		database.WithIngestLease(database.NewLeaseKeeper(repo, agentID, relational.LeaseHolder("agent"))),
+		database.WithBurstCapture(flagger.NewBurstTrigger(cfg.Burst, repo, scheduler)),
+		database.WithPublisher(hub),
+	}
+	if *dbJournal != "off" && g.DB != ":memory:" {
+		path := *dbJournal
+		if path == "" {
+			path = g.DB + ".journal"
+		}
+		journal, err := database.OpenJournal(path, database.DefaultJournalMaxBytes)
+		if err != nil {
+			log.Fatalf("Invalid -db-journal: %v", err)
+		}
+		if n := journal.Pending(); n > 0 {
+			slog.Info("Journaled snapshots will be replayed", "count", n, "path", path)
+		}
+		opts = append(opts, database.WithJournal(journal))
+	}
+	if *changeOnly != "" {
+		eps, err := database.ParseChangeEpsilons(*changeOnly)
+		if err != nil {
+			log.Fatalf("Invalid -change-only: %v", err)
+		}
+		opts = append(opts, database.WithChangeOnly(eps))
+	}
+	for _, url := range webhooks {
+		whCfg := webhook.DefaultConfig(url)
+		whCfg.OnlyOnChange = *webhookOnChange
+		sender, err := webhook.NewSender(whCfg)
+		if err != nil {
+			log.Fatalf("Invalid webhook: %v", err)
+		}
+		defer sender.Close()
+		opts = append(opts, database.WithNotifier(sender))
+	}
+	var notifier *alert.Notifier
+	if *alertRoutes != "" {
+		router, err := alert.LoadRouter(*alertRoutes)
+		if err != nil {
+			log.Fatalf("Invalid alert routes: %v", err)
+		}
+		notifier = alert.NewRoutedNotifier(router)
+	} else {
+		var pagers []alert.Sender
+		if *pagerDutyKey != "" {
+			pagers = append(pagers, alert.NewPagerDuty(*pagerDutyKey))
+		}
+		if *opsgenieKey != "" {
+			pagers = append(pagers, alert.NewOpsgenie(*opsgenieKey))
+		}
+		if len(pagers) > 0 {
+			notifier = alert.NewNotifier(pagers...)
+		}
+	}
+	if notifier != nil {
+		defer notifier.Close()
+		opts = append(opts, database.WithNotifier(notifier))
+	}
+	worker, err := database.NewDataWorker(sysCol, flaggerSvc, repo, nil, agentID, machineID, bootID, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create data worker: %v", err)
 	}
 
+	// The API needs the repo for host labels (GET/PUT/PATCH /api/v1/labels)
+	// and the worker for the self panel's persist latency (GET /api/v1/self).
+	routes := []debugserver.Route{
+		{Pattern: "/api/profile", Handler: schedule.Handler(scheduler)},
+		{Pattern: "/api/v1/stream", Handler: stream.Handler(hub)},
+		{Pattern: "/api/v1/labels", Handler: database.LabelsHandler(repo)},
+		{Pattern: "/api/v1/storage", Handler: database.StorageHandler(repo)},
+		{Pattern: "/api/v1/bookmarks", Handler: database.BookmarksHandler(repo)},
+		{Pattern: "/api/v1/series", Handler: database.SeriesHandler(repo)},
+		{Pattern: "/api/v1/dependencies", Handler: health.Handler(deps)},
+	}
+	if sampler, err := selfstats.NewSampler(g.DB, append(selfOpts, selfstats.WithPersistReporter(worker))...); err != nil {
+		log.Printf("Warning: self stats unavailable: %v", err)
+	} else {
+		routes = append(routes, debugserver.Route{Pattern: "/api/v1/self", Handler: selfstats.Handler(sampler)})
+	}
+	if _, err := debugserver.Start(debugserver.Addr(*debugAddr), routes...); err != nil {
+		log.Fatalf("Failed to start debug server: %v", err)
+	}
+	if *fleetAddr != "" {
+		tlsConfig, err := fleetTLS.ServerConfig()
+		if err != nil {
+			log.Fatalf("Failed to start fleet API: %v", err)
+		}
+		token, err := fleet.AgentToken(*fleetTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to start fleet API: %v", err)
+		}
+		var verifier *fleet.Verifier
+		if token != "" {
+			verifier = fleet.NewVerifier(token, nil)
+		}
+		if _, err := fleet.Serve(*fleetAddr, tlsConfig, verifier, database.BackupHandler(repo)); err != nil {
+			log.Fatalf("Failed to start fleet API: %v", err)
+		}
+	}
+
 	// 9. Start Data Worker
 	if err := worker.Start(context.Background()); err != nil {
 		log.Fatalf("Failed to start data worker: %v", err)
 	}
 	defer worker.Stop()
 
-	// 10. Start TUI
+	// 10. Headless agents (e.g. in a container or a system service) run
+	// until signalled or stopped by the service manager
+	if *headless {
+		sigCtx, stop := service.NotifyContext(context.Background())
+		defer stop()
+		slog.Info("Collecting; press Ctrl+C to stop", "agent", agentID, "version", buildinfo.Get())
+		<-sigCtx.Done()
+		return
+	}
+
+	// 11. Start TUI
 	if err := tui.Start(provider, cfg); err != nil {
 		fmt.Printf("Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// runReadOnly shows the latest snapshots stored in dbPath in the TUI. The
+// file is opened read-only and never migrated, so a copy from an agent of
+// any version can be inspected while that agent keeps writing the original.
+// A URL is attached over HTTP or S3 instead; see relational.OpenRemote.
+func runReadOnly(dbPath, hostname string, cfg flagger.Config) error {
+	var dbClient *relational.DuckDBClient
+	var err error
+	if relational.IsRemote(dbPath) {
+		dbClient, err = relational.OpenRemote(relational.RemoteSourceFromEnv(dbPath))
+	} else {
+		dbClient, err = relational.NewDuckDBClient(dbPath + "?access_mode=READ_ONLY")
+	}
+	if err != nil {
+		return fmt.Errorf("open %s read-only: %w", dbPath, err)
+	}
+	defer dbClient.Close()
+
+	provider := relational.NewSnapshotProvider(relational.NewRepo(dbClient.DB()), hostname)
+	if _, err := provider.GetFastMetrics(context.Background()); err != nil {
+		return fmt.Errorf("read %s: %w", dbPath, err)
+	}
+	return tui.Start(provider, cfg)
+}